@@ -153,17 +153,12 @@ func (b *Bot) setupBot(ctx context.Context, updatedAt time.Time) error {
 					values.TrapMemberStatusActive,
 				)
 
-				file, err := b.fileService.UploadBotFile(ctx, user, res.Body)
+				file, err := b.fileService.UploadBotFile(ctx, user, values.NewFileName(meta.Name), res.Body)
 				if err != nil {
 					return fmt.Errorf("failed to upload file: %w", err)
 				}
 
-				var resourceType values.ResourceType
-				if file.File.GetType() == values.FileTypeOther {
-					resourceType = values.ResourceTypeOther
-				} else {
-					resourceType = values.ResourceTypeImage
-				}
+				resourceType := file.File.GetType().DefaultResourceType()
 
 				createdAt, err := time.Parse("2006-01-02T15:04:05.999999Z", meta.CreatedAt)
 				if err != nil {
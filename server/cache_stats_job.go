@@ -0,0 +1,36 @@
+package main
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/mazrean/Quantainer/storage/readcache"
+)
+
+const defaultCacheStatsInterval = time.Hour
+
+// runCacheStatsJob intervalごとに、読み込みのキャッシュの利用状況をログに出力する
+func runCacheStatsJob(ctx context.Context, readCache *readcache.File, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		stats := readCache.Stats()
+
+		log.Printf(
+			"info: read cache stats: %d hits, %d misses, %d evictions, %d entries, %d bytes\n",
+			stats.Hits,
+			stats.Misses,
+			stats.Evictions,
+			stats.Entries,
+			stats.Bytes,
+		)
+	}
+}
@@ -7,20 +7,37 @@ import (
 )
 
 type File struct {
-	id        values.FileID
-	fileType  values.FileType
-	createdAt time.Time
+	id            values.FileID
+	fileType      values.FileType
+	hash          values.FileHash
+	size          int64
+	imageMetadata *ImageMetadata
+	// perceptualHash 画像以外や計算していない場合はnil
+	perceptualHash *values.PerceptualHash
+	// scan 検査していない場合はnil
+	scan *FileScan
+	// originalName アップロードされた時のファイル名。わからない場合は空文字列
+	originalName values.FileName
+	// originalSize アップロードされた時のバイト数。メタデータの除去などで保存した内容とは異なりうる
+	originalSize int64
+	createdAt    time.Time
 }
 
 func NewFile(
 	id values.FileID,
 	fileType values.FileType,
+	hash values.FileHash,
+	size int64,
+	imageMetadata *ImageMetadata,
 	createdAt time.Time,
 ) *File {
 	return &File{
-		id:        id,
-		fileType:  fileType,
-		createdAt: createdAt,
+		id:            id,
+		fileType:      fileType,
+		hash:          hash,
+		size:          size,
+		imageMetadata: imageMetadata,
+		createdAt:     createdAt,
 	}
 }
 
@@ -32,6 +49,75 @@ func (f *File) GetType() values.FileType {
 	return f.fileType
 }
 
+func (f *File) GetHash() values.FileHash {
+	return f.hash
+}
+
+func (f *File) SetHash(hash values.FileHash) {
+	f.hash = hash
+}
+
+// GetSize ファイルのバイト数
+func (f *File) GetSize() int64 {
+	return f.size
+}
+
+func (f *File) SetSize(size int64) {
+	f.size = size
+}
+
+// GetImageMetadata 画像以外やメタデータを取り出していない場合はnil
+func (f *File) GetImageMetadata() *ImageMetadata {
+	return f.imageMetadata
+}
+
+func (f *File) SetImageMetadata(imageMetadata *ImageMetadata) {
+	f.imageMetadata = imageMetadata
+}
+
+// GetPerceptualHash 画像以外や計算していない場合はnil
+func (f *File) GetPerceptualHash() *values.PerceptualHash {
+	return f.perceptualHash
+}
+
+func (f *File) SetPerceptualHash(perceptualHash *values.PerceptualHash) {
+	f.perceptualHash = perceptualHash
+}
+
+// GetScan 検査していない場合はnil
+func (f *File) GetScan() *FileScan {
+	return f.scan
+}
+
+func (f *File) SetScan(scan *FileScan) {
+	f.scan = scan
+}
+
+// IsInfected 感染が検出され、隔離されているか
+func (f *File) IsInfected() bool {
+	return f.scan != nil && f.scan.GetStatus() == values.ScanStatusInfected
+}
+
+// GetOriginalName アップロードされた時のファイル名。わからない場合は空文字列
+func (f *File) GetOriginalName() values.FileName {
+	return f.originalName
+}
+
+// GetExtension アップロードされた時のファイル名の拡張子
+func (f *File) GetExtension() values.FileExtension {
+	return f.originalName.Extension()
+}
+
+// GetOriginalSize アップロードされた時のバイト数。記録する前にアップロードされたファイルは0
+func (f *File) GetOriginalSize() int64 {
+	return f.originalSize
+}
+
+func (f *File) SetOriginal(name values.FileName, size int64) {
+	f.originalName = name
+	f.originalSize = size
+}
+
 func (f *File) GetCreatedAt() time.Time {
 	return f.createdAt
 }
@@ -0,0 +1,40 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/mazrean/Quantainer/domain/values"
+)
+
+// FileScan ファイルのマルウェアの検査の結果
+type FileScan struct {
+	status values.ScanStatus
+	// signature 感染していない場合は空文字列
+	signature values.ScanSignature
+	scannedAt time.Time
+}
+
+func NewFileScan(
+	status values.ScanStatus,
+	signature values.ScanSignature,
+	scannedAt time.Time,
+) *FileScan {
+	return &FileScan{
+		status:    status,
+		signature: signature,
+		scannedAt: scannedAt,
+	}
+}
+
+func (fs *FileScan) GetStatus() values.ScanStatus {
+	return fs.status
+}
+
+// GetSignature 感染していない場合は空文字列
+func (fs *FileScan) GetSignature() values.ScanSignature {
+	return fs.signature
+}
+
+func (fs *FileScan) GetScannedAt() time.Time {
+	return fs.scannedAt
+}
@@ -0,0 +1,76 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/mazrean/Quantainer/domain/values"
+)
+
+// ImageMetadata 画像のメタデータ
+type ImageMetadata struct {
+	width       int
+	height      int
+	frames      int
+	colorSpace  values.ColorSpace
+	orientation int
+	capturedAt  *time.Time
+	cameraModel string
+}
+
+func NewImageMetadata(
+	width int,
+	height int,
+	frames int,
+	colorSpace values.ColorSpace,
+	orientation int,
+	capturedAt *time.Time,
+	cameraModel string,
+) *ImageMetadata {
+	return &ImageMetadata{
+		width:       width,
+		height:      height,
+		frames:      frames,
+		colorSpace:  colorSpace,
+		orientation: orientation,
+		capturedAt:  capturedAt,
+		cameraModel: cameraModel,
+	}
+}
+
+// GetWidth EXIFのOrientationによる回転を適用した後の幅
+func (im *ImageMetadata) GetWidth() int {
+	return im.width
+}
+
+// GetHeight EXIFのOrientationによる回転を適用した後の高さ
+func (im *ImageMetadata) GetHeight() int {
+	return im.height
+}
+
+// GetFrames アニメーションのフレーム数。静止画の場合は1
+func (im *ImageMetadata) GetFrames() int {
+	return im.frames
+}
+
+func (im *ImageMetadata) GetColorSpace() values.ColorSpace {
+	return im.colorSpace
+}
+
+func (im *ImageMetadata) GetImageOrientation() values.ImageOrientation {
+	return values.NewImageOrientation(im.width, im.height)
+}
+
+// GetOrientation EXIFのOrientation(1~8)。不明な場合は0
+func (im *ImageMetadata) GetOrientation() int {
+	return im.orientation
+}
+
+// GetCapturedAt 撮影日時。不明な場合はnil
+func (im *ImageMetadata) GetCapturedAt() *time.Time {
+	return im.capturedAt
+}
+
+// GetCameraModel カメラの機種。不明な場合は空文字列
+func (im *ImageMetadata) GetCameraModel() string {
+	return im.cameraModel
+}
@@ -0,0 +1,28 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/mazrean/Quantainer/domain/values"
+)
+
+// ResourceVersion リソースのファイルの版。ファイルは版ごとに別のものを参照する
+type ResourceVersion struct {
+	version   values.ResourceVersion
+	createdAt time.Time
+}
+
+func NewResourceVersion(version values.ResourceVersion, createdAt time.Time) *ResourceVersion {
+	return &ResourceVersion{
+		version:   version,
+		createdAt: createdAt,
+	}
+}
+
+func (rv *ResourceVersion) GetVersion() values.ResourceVersion {
+	return rv.version
+}
+
+func (rv *ResourceVersion) GetCreatedAt() time.Time {
+	return rv.createdAt
+}
@@ -0,0 +1,84 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/mazrean/Quantainer/domain/values"
+)
+
+// ShareLink traPのメンバー以外にファイル、リソース、グループを共有するリンク
+type ShareLink struct {
+	id           values.ShareLinkID
+	targetType   values.ShareLinkTargetType
+	targetID     values.ShareLinkTargetID
+	maxDownloads values.ShareLinkMaxDownloads
+	// downloads ダウンロードされた回数
+	downloads int64
+	expiresAt time.Time
+	// revokedAt 取り消されていない場合はnil
+	revokedAt *time.Time
+	createdAt time.Time
+}
+
+func NewShareLink(
+	id values.ShareLinkID,
+	targetType values.ShareLinkTargetType,
+	targetID values.ShareLinkTargetID,
+	maxDownloads values.ShareLinkMaxDownloads,
+	downloads int64,
+	expiresAt time.Time,
+	revokedAt *time.Time,
+	createdAt time.Time,
+) *ShareLink {
+	return &ShareLink{
+		id:           id,
+		targetType:   targetType,
+		targetID:     targetID,
+		maxDownloads: maxDownloads,
+		downloads:    downloads,
+		expiresAt:    expiresAt,
+		revokedAt:    revokedAt,
+		createdAt:    createdAt,
+	}
+}
+
+func (sl *ShareLink) GetID() values.ShareLinkID {
+	return sl.id
+}
+
+func (sl *ShareLink) GetTargetType() values.ShareLinkTargetType {
+	return sl.targetType
+}
+
+func (sl *ShareLink) GetTargetID() values.ShareLinkTargetID {
+	return sl.targetID
+}
+
+func (sl *ShareLink) GetMaxDownloads() values.ShareLinkMaxDownloads {
+	return sl.maxDownloads
+}
+
+func (sl *ShareLink) GetDownloads() int64 {
+	return sl.downloads
+}
+
+func (sl *ShareLink) GetExpiresAt() time.Time {
+	return sl.expiresAt
+}
+
+func (sl *ShareLink) GetRevokedAt() *time.Time {
+	return sl.revokedAt
+}
+
+func (sl *ShareLink) GetCreatedAt() time.Time {
+	return sl.createdAt
+}
+
+// IsAvailable 有効期限内で取り消されておらず、ダウンロードの回数が上限に達していないか
+func (sl *ShareLink) IsAvailable(now time.Time) bool {
+	if sl.revokedAt != nil || !now.Before(sl.expiresAt) {
+		return false
+	}
+
+	return sl.maxDownloads == 0 || sl.downloads < int64(sl.maxDownloads)
+}
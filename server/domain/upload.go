@@ -0,0 +1,75 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/mazrean/Quantainer/domain/values"
+)
+
+// Upload 再開可能なアップロード
+type Upload struct {
+	id       values.UploadID
+	length   int64
+	offset   int64
+	metadata values.UploadMetadata
+	// expiresAt この日時までに書き込みがなければ破棄される
+	expiresAt time.Time
+	createdAt time.Time
+}
+
+func NewUpload(
+	id values.UploadID,
+	length int64,
+	offset int64,
+	metadata values.UploadMetadata,
+	expiresAt time.Time,
+	createdAt time.Time,
+) *Upload {
+	return &Upload{
+		id:        id,
+		length:    length,
+		offset:    offset,
+		metadata:  metadata,
+		expiresAt: expiresAt,
+		createdAt: createdAt,
+	}
+}
+
+func (u *Upload) GetID() values.UploadID {
+	return u.id
+}
+
+// GetLength アップロードするファイルのバイト数
+func (u *Upload) GetLength() int64 {
+	return u.length
+}
+
+// GetOffset 書き込み済みのバイト数
+func (u *Upload) GetOffset() int64 {
+	return u.offset
+}
+
+func (u *Upload) SetOffset(offset int64) {
+	u.offset = offset
+}
+
+// IsCompleted 全て書き込まれたか
+func (u *Upload) IsCompleted() bool {
+	return u.offset >= u.length
+}
+
+func (u *Upload) GetMetadata() values.UploadMetadata {
+	return u.metadata
+}
+
+func (u *Upload) GetExpiresAt() time.Time {
+	return u.expiresAt
+}
+
+func (u *Upload) SetExpiresAt(expiresAt time.Time) {
+	u.expiresAt = expiresAt
+}
+
+func (u *Upload) GetCreatedAt() time.Time {
+	return u.createdAt
+}
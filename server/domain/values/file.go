@@ -1,12 +1,18 @@
 package values
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+
 	"github.com/google/uuid"
 )
 
 type (
 	FileID   uuid.UUID
 	FileType int8
+	// FileHash ストレージに保存された内容のSHA-256
+	FileHash [sha256.Size]byte
 )
 
 func NewFileID() FileID {
@@ -24,13 +30,78 @@ const (
 	FileTypeSvg
 	FileTypeGif
 	FileTypeOther
+	FileTypeMp3
+	FileTypeOgg
+	FileTypeWav
+	FileTypeFlac
+	FileTypeMp4
+	FileTypeWebM
+	FileTypePdf
+	FileTypeZip
+	FileTypeAvif
 )
 
 func (ft FileType) IsValidResourceType(resourceType ResourceType) bool {
+	return resourceType == ResourceTypeOther || resourceType == ft.DefaultResourceType()
+}
+
+// DefaultResourceType ファイルの種類に対応するリソースの種類。対応するものがなければResourceTypeOther。
+func (ft FileType) DefaultResourceType() ResourceType {
 	switch ft {
-	case FileTypeJpeg, FileTypePng, FileTypeWebP, FileTypeSvg, FileTypeGif:
-		return resourceType == ResourceTypeImage || resourceType == ResourceTypeOther
+	case FileTypeJpeg, FileTypePng, FileTypeWebP, FileTypeSvg, FileTypeGif, FileTypeAvif:
+		return ResourceTypeImage
+	case FileTypeMp3, FileTypeOgg, FileTypeWav, FileTypeFlac:
+		return ResourceTypeMusic
+	case FileTypeMp4, FileTypeWebM:
+		return ResourceTypeVideo
+	case FileTypePdf:
+		return ResourceTypeDocument
+	case FileTypeZip:
+		return ResourceTypeArchive
 	default:
-		return resourceType == ResourceTypeOther
+		return ResourceTypeOther
+	}
+}
+
+var (
+	ErrFileHashInvalidLength = errors.New("file hash has invalid length")
+)
+
+func NewFileHash(hash []byte) (FileHash, error) {
+	var fileHash FileHash
+	if len(hash) != len(fileHash) {
+		return FileHash{}, ErrFileHashInvalidLength
+	}
+
+	copy(fileHash[:], hash)
+
+	return fileHash, nil
+}
+
+// NewFileHashFromString 16進数表記のハッシュからFileHashを生成する。
+// ハッシュ導入前に保存されたファイルのため、空文字列はゼロ値として扱う。
+func NewFileHashFromString(strHash string) (FileHash, error) {
+	if len(strHash) == 0 {
+		return FileHash{}, nil
+	}
+
+	hash, err := hex.DecodeString(strHash)
+	if err != nil {
+		return FileHash{}, err
 	}
+
+	return NewFileHash(hash)
+}
+
+// IsZero ハッシュが未設定か
+func (fh FileHash) IsZero() bool {
+	return fh == FileHash{}
+}
+
+func (fh FileHash) String() string {
+	if fh.IsZero() {
+		return ""
+	}
+
+	return hex.EncodeToString(fh[:])
 }
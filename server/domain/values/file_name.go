@@ -0,0 +1,101 @@
+package values
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+type (
+	// FileName アップロードされた時のファイル名。ディレクトリを含まない
+	FileName string
+	// FileExtension 小文字のファイルの拡張子。ドットを含まない
+	FileExtension string
+)
+
+// fileNameMaxLength ファイル名の最大のバイト数。多くのファイルシステムの上限に合わせる
+const fileNameMaxLength = 255
+
+// NewFileName クライアントから送られたファイル名を、ディレクトリと制御文字を取り除いて最大のバイト数に切り詰める。
+// ファイル名がわからない場合は空文字列にする。
+func NewFileName(name string) FileName {
+	// Windowsのクライアントはバックスラッシュ区切りのパスを送ることがある
+	if i := strings.LastIndexAny(name, `/\`); i >= 0 {
+		name = name[i+1:]
+	}
+
+	name = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) || r == utf8.RuneError {
+			return -1
+		}
+
+		return r
+	}, name)
+	name = strings.TrimSpace(name)
+
+	if len(name) > fileNameMaxLength {
+		// マルチバイト文字の途中で切らないよう、文字の境界まで戻す
+		end := fileNameMaxLength
+		for end > 0 && !utf8.RuneStart(name[end]) {
+			end--
+		}
+		name = name[:end]
+	}
+
+	if name == "." || name == ".." {
+		return ""
+	}
+
+	return FileName(name)
+}
+
+// Extension 拡張子がない場合は空文字列。ドットで始まるだけの名前は拡張子とみなさない
+func (fn FileName) Extension() FileExtension {
+	i := strings.LastIndex(string(fn), ".")
+	if i <= 0 || i == len(fn)-1 {
+		return ""
+	}
+
+	return NewFileExtension(string(fn[i+1:]))
+}
+
+// NewFileExtension 先頭のドットを取り除き小文字にする
+func NewFileExtension(extension string) FileExtension {
+	return FileExtension(strings.ToLower(strings.TrimPrefix(extension, ".")))
+}
+
+// DefaultExtension ファイルの種類に対応するドット付きの拡張子。その他のファイルは内容から拡張子がわからないので空文字列
+func (ft FileType) DefaultExtension() string {
+	switch ft {
+	case FileTypeJpeg:
+		return ".jpg"
+	case FileTypePng:
+		return ".png"
+	case FileTypeWebP:
+		return ".webp"
+	case FileTypeSvg:
+		return ".svg"
+	case FileTypeGif:
+		return ".gif"
+	case FileTypeMp3:
+		return ".mp3"
+	case FileTypeOgg:
+		return ".ogg"
+	case FileTypeWav:
+		return ".wav"
+	case FileTypeFlac:
+		return ".flac"
+	case FileTypeMp4:
+		return ".mp4"
+	case FileTypeWebM:
+		return ".webm"
+	case FileTypePdf:
+		return ".pdf"
+	case FileTypeZip:
+		return ".zip"
+	case FileTypeAvif:
+		return ".avif"
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,122 @@
+package values
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewFileName(t *testing.T) {
+	t.Parallel()
+
+	type test struct {
+		description string
+		name        string
+		fileName    FileName
+	}
+
+	testCases := []test{
+		{
+			description: "そのまま",
+			name:        "cursor.png",
+			fileName:    "cursor.png",
+		},
+		{
+			description: "ディレクトリを取り除く",
+			name:        "images/cursor.png",
+			fileName:    "cursor.png",
+		},
+		{
+			description: "バックスラッシュ区切りのディレクトリも取り除く",
+			name:        `C:\Users\mazrean\cursor.png`,
+			fileName:    "cursor.png",
+		},
+		{
+			description: "制御文字と前後の空白を取り除く",
+			name:        " cur\nsor.png\t",
+			fileName:    "cursor.png",
+		},
+		{
+			description: "ASCII以外の文字も残す",
+			name:        "カーソル.png",
+			fileName:    "カーソル.png",
+		},
+		{
+			description: "..は空文字列",
+			name:        "..",
+			fileName:    "",
+		},
+		{
+			description: "最大のバイト数に切り詰める",
+			name:        strings.Repeat("a", 300),
+			fileName:    FileName(strings.Repeat("a", 255)),
+		},
+		{
+			description: "マルチバイト文字の途中では切らない",
+			name:        strings.Repeat("あ", 100),
+			fileName:    FileName(strings.Repeat("あ", 85)),
+		},
+	}
+
+	for _, testCase := range testCases {
+		testCase := testCase
+		t.Run(testCase.description, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, testCase.fileName, NewFileName(testCase.name))
+		})
+	}
+}
+
+func TestFileNameExtension(t *testing.T) {
+	t.Parallel()
+
+	type test struct {
+		description string
+		fileName    FileName
+		extension   FileExtension
+	}
+
+	testCases := []test{
+		{
+			description: "小文字にする",
+			fileName:    "cursor.PNG",
+			extension:   "png",
+		},
+		{
+			description: "最後のドット以降",
+			fileName:    "cursor.tar.gz",
+			extension:   "gz",
+		},
+		{
+			description: "ドットがないので空文字列",
+			fileName:    "README",
+			extension:   "",
+		},
+		{
+			description: "ドットで始まるだけなので空文字列",
+			fileName:    ".gitignore",
+			extension:   "",
+		},
+		{
+			description: "ドットで終わるので空文字列",
+			fileName:    "cursor.",
+			extension:   "",
+		},
+		{
+			description: "ファイル名がないので空文字列",
+			fileName:    "",
+			extension:   "",
+		},
+	}
+
+	for _, testCase := range testCases {
+		testCase := testCase
+		t.Run(testCase.description, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, testCase.extension, testCase.fileName.Extension())
+		})
+	}
+}
@@ -0,0 +1,17 @@
+package values
+
+type (
+	// ScanStatus マルウェアの検査の結果
+	ScanStatus int8
+	// ScanSignature 検出されたマルウェアの名前
+	ScanSignature string
+)
+
+const (
+	ScanStatusClean ScanStatus = iota + 1
+	ScanStatusInfected
+)
+
+func NewScanSignature(signature string) ScanSignature {
+	return ScanSignature(signature)
+}
@@ -0,0 +1,90 @@
+package values
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsValidResourceType(t *testing.T) {
+	t.Parallel()
+
+	type test struct {
+		description  string
+		fileType     FileType
+		resourceType ResourceType
+		isValid      bool
+	}
+
+	testCases := []test{
+		{
+			description:  "画像は画像のリソースにできる",
+			fileType:     FileTypePng,
+			resourceType: ResourceTypeImage,
+			isValid:      true,
+		},
+		{
+			description:  "avifは画像のリソースにできる",
+			fileType:     FileTypeAvif,
+			resourceType: ResourceTypeImage,
+			isValid:      true,
+		},
+		{
+			description:  "音声は音楽のリソースにできる",
+			fileType:     FileTypeFlac,
+			resourceType: ResourceTypeMusic,
+			isValid:      true,
+		},
+		{
+			description:  "動画は動画のリソースにできる",
+			fileType:     FileTypeWebM,
+			resourceType: ResourceTypeVideo,
+			isValid:      true,
+		},
+		{
+			description:  "pdfはドキュメントのリソースにできる",
+			fileType:     FileTypePdf,
+			resourceType: ResourceTypeDocument,
+			isValid:      true,
+		},
+		{
+			description:  "zipはアーカイブのリソースにできる",
+			fileType:     FileTypeZip,
+			resourceType: ResourceTypeArchive,
+			isValid:      true,
+		},
+		{
+			description:  "どの種類のファイルもその他のリソースにできる",
+			fileType:     FileTypeMp3,
+			resourceType: ResourceTypeOther,
+			isValid:      true,
+		},
+		{
+			description:  "音声は画像のリソースにできない",
+			fileType:     FileTypeMp3,
+			resourceType: ResourceTypeImage,
+			isValid:      false,
+		},
+		{
+			description:  "画像は動画のリソースにできない",
+			fileType:     FileTypeGif,
+			resourceType: ResourceTypeVideo,
+			isValid:      false,
+		},
+		{
+			description:  "その他のファイルはアーカイブのリソースにできない",
+			fileType:     FileTypeOther,
+			resourceType: ResourceTypeArchive,
+			isValid:      false,
+		},
+	}
+
+	for _, testCase := range testCases {
+		testCase := testCase
+		t.Run(testCase.description, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, testCase.isValid, testCase.fileType.IsValidResourceType(testCase.resourceType))
+		})
+	}
+}
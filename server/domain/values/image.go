@@ -0,0 +1,57 @@
+package values
+
+import "github.com/mazrean/Quantainer/pkg/imagehash"
+
+type (
+	// ColorSpace 画像の色空間
+	ColorSpace int8
+	// ImageOrientation 画像の縦横の向き
+	ImageOrientation int8
+	// PerceptualHash 再エンコードや縮小をしても近い値になる画像のハッシュ
+	PerceptualHash uint64
+)
+
+const (
+	ColorSpaceUnknown ColorSpace = iota
+	ColorSpaceGray
+	ColorSpaceRGB
+	ColorSpaceCMYK
+)
+
+const (
+	ImageOrientationLandscape ImageOrientation = iota + 1
+	ImageOrientationPortrait
+	ImageOrientationSquare
+)
+
+// NewImageOrientation 幅と高さから画像の向きを判定する
+func NewImageOrientation(width int, height int) ImageOrientation {
+	switch {
+	case width > height:
+		return ImageOrientationLandscape
+	case width < height:
+		return ImageOrientationPortrait
+	default:
+		return ImageOrientationSquare
+	}
+}
+
+// IsAnalyzableImage 幅や高さなどのメタデータを取り出せるファイルの種類か
+func (ft FileType) IsAnalyzableImage() bool {
+	switch ft {
+	case FileTypeJpeg, FileTypePng, FileTypeWebP, FileTypeSvg, FileTypeGif, FileTypeAvif:
+		return true
+	default:
+		return false
+	}
+}
+
+// IsPerceptualHashSupported 知覚ハッシュを計算できるファイルの種類か
+func (ft FileType) IsPerceptualHashSupported() bool {
+	return ft.IsRenditionSupported()
+}
+
+// Distance 異なるビットの数。小さいほど似ている
+func (ph PerceptualHash) Distance(other PerceptualHash) int {
+	return imagehash.Distance(uint64(ph), uint64(other))
+}
@@ -0,0 +1,125 @@
+package values
+
+type (
+	// ImageFit 変換後の幅と高さへの画像の合わせ方
+	ImageFit int8
+	// ImageQuality JPEGに変換する場合の品質
+	ImageQuality int
+)
+
+const (
+	// ImageFitContain 縦横比を保ったまま幅と高さに収まるよう縮小する。元の画像より大きくはしない
+	ImageFitContain ImageFit = iota + 1
+	// ImageFitCover 縦横比を保ったまま幅と高さを覆うよう拡大縮小し、はみ出た部分を中央を残して切り取る
+	ImageFitCover
+)
+
+// DefaultImageQuality 品質が指定されなかった場合の品質
+const DefaultImageQuality ImageQuality = 85
+
+// ImageQualities 変換に使える品質。変換結果はキャッシュするので、種類を増やしすぎないよう限定する
+var ImageQualities = []ImageQuality{60, 75, DefaultImageQuality, 95}
+
+func (iq ImageQuality) IsValid() bool {
+	for _, quality := range ImageQualities {
+		if iq == quality {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ImageTransformPreset 変換後の幅と高さと合わせ方の組
+type ImageTransformPreset struct {
+	width  int
+	height int
+	fit    ImageFit
+}
+
+func NewImageTransformPreset(width int, height int, fit ImageFit) ImageTransformPreset {
+	return ImageTransformPreset{
+		width:  width,
+		height: height,
+		fit:    fit,
+	}
+}
+
+// ImageTransformPresets 変換に使えるプリセット。
+// 任意の大きさを許すと変換のCPU負荷とキャッシュの容量を際限なく使わせられるので、これらに限定する
+var ImageTransformPresets = []ImageTransformPreset{
+	// traQのアイコンなどの正方形のサムネイル
+	NewImageTransformPreset(64, 64, ImageFitCover),
+	NewImageTransformPreset(128, 128, ImageFitCover),
+	NewImageTransformPreset(256, 256, ImageFitCover),
+	NewImageTransformPreset(512, 512, ImageFitCover),
+	// Webや印刷のプレビュー
+	NewImageTransformPreset(512, 512, ImageFitContain),
+	NewImageTransformPreset(1024, 1024, ImageFitContain),
+	NewImageTransformPreset(2048, 2048, ImageFitContain),
+}
+
+func (itp ImageTransformPreset) GetWidth() int {
+	return itp.width
+}
+
+func (itp ImageTransformPreset) GetHeight() int {
+	return itp.height
+}
+
+func (itp ImageTransformPreset) GetFit() ImageFit {
+	return itp.fit
+}
+
+func (itp ImageTransformPreset) IsValid() bool {
+	for _, preset := range ImageTransformPresets {
+		if itp == preset {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ImageTransform 変換の内容。同じ内容の変換結果はレンディションとして使い回す
+type ImageTransform struct {
+	preset  ImageTransformPreset
+	format  FileType
+	quality ImageQuality
+}
+
+// NewImageTransform 品質はJPEGの場合のみ使うので、それ以外の形式では0にする
+func NewImageTransform(preset ImageTransformPreset, format FileType, quality ImageQuality) ImageTransform {
+	if format != FileTypeJpeg {
+		quality = 0
+	}
+
+	return ImageTransform{
+		preset:  preset,
+		format:  format,
+		quality: quality,
+	}
+}
+
+func (it ImageTransform) GetPreset() ImageTransformPreset {
+	return it.preset
+}
+
+func (it ImageTransform) GetFormat() FileType {
+	return it.format
+}
+
+// GetQuality JPEG以外の形式の場合は0
+func (it ImageTransform) GetQuality() ImageQuality {
+	return it.quality
+}
+
+// IsTransformFormat 変換後の形式にできるファイルの種類か
+func (ft FileType) IsTransformFormat() bool {
+	switch ft {
+	case FileTypeJpeg, FileTypePng, FileTypeWebP:
+		return true
+	default:
+		return false
+	}
+}
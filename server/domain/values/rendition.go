@@ -0,0 +1,35 @@
+package values
+
+// RenditionSize サムネイルなどのレンディションの長辺のピクセル数
+type RenditionSize int
+
+const (
+	RenditionSizeSmall RenditionSize = 256
+	RenditionSizeLarge RenditionSize = 1024
+)
+
+// RenditionSizes アップロード時に生成するレンディションのサイズ
+var RenditionSizes = []RenditionSize{
+	RenditionSizeSmall,
+	RenditionSizeLarge,
+}
+
+func (rs RenditionSize) IsValid() bool {
+	for _, size := range RenditionSizes {
+		if rs == size {
+			return true
+		}
+	}
+
+	return false
+}
+
+// IsRenditionSupported レンディションを生成できるファイルの種類か
+func (ft FileType) IsRenditionSupported() bool {
+	switch ft {
+	case FileTypeJpeg, FileTypePng, FileTypeWebP, FileTypeGif:
+		return true
+	default:
+		return false
+	}
+}
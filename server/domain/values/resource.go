@@ -7,6 +7,8 @@ type (
 	ResourceName    string
 	ResourceType    int8
 	ResourceComment string
+	// ResourceVersion リソースのファイルの版。最初のファイルが1で、ファイルを差し替えるごとに1増える
+	ResourceVersion int
 )
 
 func NewResourceID() ResourceID {
@@ -22,10 +24,21 @@ func NewResourceName(name string) ResourceName {
 }
 
 const (
-	ResourceTypeImage = iota + 1
+	ResourceTypeImage ResourceType = iota + 1
 	ResourceTypeOther
+	ResourceTypeMusic
+	ResourceTypeVideo
+	ResourceTypeDocument
+	ResourceTypeArchive
 )
 
 func NewResourceComment(comment string) ResourceComment {
 	return ResourceComment(comment)
 }
+
+// FirstResourceVersion リソース作成時のファイルの版
+const FirstResourceVersion ResourceVersion = 1
+
+func (rv ResourceVersion) Next() ResourceVersion {
+	return rv + 1
+}
@@ -0,0 +1,114 @@
+package values
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type (
+	ShareLinkID uuid.UUID
+	// ShareLinkTargetType 共有するものの種類
+	ShareLinkTargetType int8
+	// ShareLinkTargetID 共有するファイル、リソース、グループのid
+	ShareLinkTargetID uuid.UUID
+	// ShareLinkMaxDownloads ダウンロードできる回数の上限。0の場合は無制限
+	ShareLinkMaxDownloads int64
+	// ShareToken 共有リンクのidと有効期限に署名したトークン
+	ShareToken string
+)
+
+func NewShareLinkID() ShareLinkID {
+	return ShareLinkID(uuid.New())
+}
+
+func NewShareLinkIDFromUUID(u uuid.UUID) ShareLinkID {
+	return ShareLinkID(u)
+}
+
+const (
+	ShareLinkTargetTypeFile ShareLinkTargetType = iota + 1
+	ShareLinkTargetTypeResource
+	ShareLinkTargetTypeGroup
+)
+
+func NewShareLinkTargetID(u uuid.UUID) ShareLinkTargetID {
+	return ShareLinkTargetID(u)
+}
+
+func NewShareLinkMaxDownloads(maxDownloads int64) ShareLinkMaxDownloads {
+	return ShareLinkMaxDownloads(maxDownloads)
+}
+
+func (smd ShareLinkMaxDownloads) Validate() error {
+	if smd < 0 {
+		return ErrShareLinkMaxDownloadsNegative
+	}
+
+	return nil
+}
+
+var (
+	ErrShareLinkMaxDownloadsNegative = errors.New("share link max downloads is negative")
+	ErrShareTokenInvalid             = errors.New("share token is invalid")
+)
+
+// shareTokenPayloadLength idの16バイトと有効期限のUNIX時間の8バイト
+const shareTokenPayloadLength = 16 + 8
+
+// NewShareToken idと有効期限をbase64urlでエンコードしたものと、そのHMAC-SHA256を.で区切る
+func NewShareToken(secret []byte, id ShareLinkID, expiresAt time.Time) ShareToken {
+	payload := make([]byte, shareTokenPayloadLength)
+	copy(payload, id[:])
+	binary.BigEndian.PutUint64(payload[len(id):], uint64(expiresAt.Unix()))
+
+	return ShareToken(
+		base64.RawURLEncoding.EncodeToString(payload) +
+			"." +
+			base64.RawURLEncoding.EncodeToString(signShareTokenPayload(secret, payload)),
+	)
+}
+
+// Verify 署名を検証し、共有リンクのidと有効期限を返す。
+// 有効期限が切れているかは確認しない。
+func (st ShareToken) Verify(secret []byte) (ShareLinkID, time.Time, error) {
+	parts := strings.SplitN(string(st), ".", 2)
+	if len(parts) != 2 {
+		return ShareLinkID{}, time.Time{}, ErrShareTokenInvalid
+	}
+	strPayload, strSignature := parts[0], parts[1]
+
+	payload, err := base64.RawURLEncoding.DecodeString(strPayload)
+	if err != nil || len(payload) != shareTokenPayloadLength {
+		return ShareLinkID{}, time.Time{}, ErrShareTokenInvalid
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(strSignature)
+	if err != nil {
+		return ShareLinkID{}, time.Time{}, ErrShareTokenInvalid
+	}
+
+	if !hmac.Equal(signature, signShareTokenPayload(secret, payload)) {
+		return ShareLinkID{}, time.Time{}, ErrShareTokenInvalid
+	}
+
+	var id ShareLinkID
+	copy(id[:], payload[:16])
+	expiresAt := time.Unix(int64(binary.BigEndian.Uint64(payload[16:])), 0)
+
+	return id, expiresAt, nil
+}
+
+func signShareTokenPayload(secret []byte, payload []byte) []byte {
+	mac := hmac.New(sha256.New, secret)
+	// hash.Hashへの書き込みはエラーにならない
+	_, _ = mac.Write(payload)
+
+	return mac.Sum(nil)
+}
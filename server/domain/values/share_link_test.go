@@ -0,0 +1,82 @@
+package values
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShareTokenVerify(t *testing.T) {
+	t.Parallel()
+
+	secret := []byte("secret")
+	id := NewShareLinkID()
+	expiresAt := time.Unix(time.Now().Add(time.Hour).Unix(), 0)
+
+	token := NewShareToken(secret, id, expiresAt)
+	parts := strings.SplitN(string(token), ".", 2)
+	strPayload, strSignature := parts[0], parts[1]
+	extendedPayload := strings.SplitN(string(NewShareToken(secret, id, expiresAt.Add(time.Hour))), ".", 2)[0]
+
+	type test struct {
+		description string
+		token       ShareToken
+		secret      []byte
+		isErr       bool
+	}
+
+	testCases := []test{
+		{
+			description: "同じ秘密鍵で署名したトークンは有効",
+			token:       token,
+			secret:      secret,
+		},
+		{
+			description: "異なる秘密鍵で署名したトークンは無効",
+			token:       token,
+			secret:      []byte("other secret"),
+			isErr:       true,
+		},
+		{
+			description: "有効期限を書き換えたトークンは無効",
+			token:       ShareToken(extendedPayload + "." + strSignature),
+			secret:      secret,
+			isErr:       true,
+		},
+		{
+			description: "署名がないトークンは無効",
+			token:       ShareToken(strPayload),
+			secret:      secret,
+			isErr:       true,
+		},
+		{
+			description: "base64でないトークンは無効",
+			token:       ShareToken("!!!." + strSignature),
+			secret:      secret,
+			isErr:       true,
+		},
+	}
+
+	for _, testCase := range testCases {
+		testCase := testCase
+
+		t.Run(testCase.description, func(t *testing.T) {
+			t.Parallel()
+
+			actualID, actualExpiresAt, err := testCase.token.Verify(testCase.secret)
+
+			if testCase.isErr {
+				assert.ErrorIs(t, err, ErrShareTokenInvalid)
+				return
+			}
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			assert.Equal(t, id, actualID)
+			assert.True(t, expiresAt.Equal(actualExpiresAt))
+		})
+	}
+}
@@ -0,0 +1,98 @@
+package values
+
+import (
+	"encoding/base64"
+	"errors"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+type (
+	// UploadID 再開可能なアップロードのid。全て書き込まれると同じidのファイルとして保存される。
+	UploadID uuid.UUID
+	// UploadMetadata tusのUpload-Metadataヘッダーの値。
+	// キーとbase64でエンコードした値をスペースで区切った組をカンマで区切って並べる。
+	UploadMetadata string
+)
+
+func NewUploadID() UploadID {
+	return UploadID(uuid.New())
+}
+
+func NewUploadIDFromUUID(u uuid.UUID) UploadID {
+	return UploadID(u)
+}
+
+// FileID 全て書き込まれた後に保存されるファイルのid
+func (ui UploadID) FileID() FileID {
+	return FileID(ui)
+}
+
+// uploadMetadataMaxLength Upload-Metadataヘッダーの最大のバイト数
+const uploadMetadataMaxLength = 4096
+
+var (
+	ErrUploadMetadataTooLong      = errors.New("upload metadata is too long")
+	ErrUploadMetadataInvalidPair  = errors.New("upload metadata contains invalid pair")
+	ErrUploadMetadataDuplicateKey = errors.New("upload metadata contains duplicate key")
+)
+
+func (um UploadMetadata) Validate() error {
+	if len(um) > uploadMetadataMaxLength {
+		return ErrUploadMetadataTooLong
+	}
+
+	if len(um) == 0 {
+		return nil
+	}
+
+	keys := map[string]struct{}{}
+	for _, pair := range strings.Split(string(um), ",") {
+		key, _, ok := parseUploadMetadataPair(pair)
+		if !ok {
+			return ErrUploadMetadataInvalidPair
+		}
+
+		if _, ok := keys[key]; ok {
+			return ErrUploadMetadataDuplicateKey
+		}
+		keys[key] = struct{}{}
+	}
+
+	return nil
+}
+
+// Get keyの値をデコードして返す。値のないキーの場合は空文字列を返す。
+func (um UploadMetadata) Get(key string) (string, bool) {
+	if len(um) == 0 {
+		return "", false
+	}
+
+	for _, pair := range strings.Split(string(um), ",") {
+		pairKey, value, ok := parseUploadMetadataPair(pair)
+		if ok && pairKey == key {
+			return value, true
+		}
+	}
+
+	return "", false
+}
+
+func parseUploadMetadataPair(pair string) (string, string, bool) {
+	fields := strings.Fields(pair)
+	if len(fields) == 0 || len(fields) > 2 {
+		return "", "", false
+	}
+
+	if len(fields) == 1 {
+		return fields[0], "", true
+	}
+
+	value, err := base64.StdEncoding.DecodeString(fields[1])
+	if err != nil {
+		return "", "", false
+	}
+
+	return fields[0], string(value), true
+}
@@ -0,0 +1,75 @@
+package values
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUploadMetadataValidate(t *testing.T) {
+	t.Parallel()
+
+	type test struct {
+		description string
+		metadata    UploadMetadata
+		err         error
+	}
+
+	testCases := []test{
+		{
+			description: "空文字列は有効",
+			metadata:    "",
+		},
+		{
+			description: "キーと値の組は有効",
+			metadata:    "filename d29ybGRfZG9taW5hdGlvbl9wbGFuLnBkZg==,is_confidential",
+		},
+		{
+			description: "値がbase64でないので無効",
+			metadata:    "filename world_domination_plan.pdf",
+			err:         ErrUploadMetadataInvalidPair,
+		},
+		{
+			description: "キーがないので無効",
+			metadata:    "filename aGVsbG8=,,type aW1hZ2U=",
+			err:         ErrUploadMetadataInvalidPair,
+		},
+		{
+			description: "キーが重複しているので無効",
+			metadata:    "filename aGVsbG8=,filename d29ybGQ=",
+			err:         ErrUploadMetadataDuplicateKey,
+		},
+	}
+
+	for _, testCase := range testCases {
+		testCase := testCase
+
+		t.Run(testCase.description, func(t *testing.T) {
+			t.Parallel()
+
+			err := testCase.metadata.Validate()
+			if testCase.err == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, testCase.err)
+			}
+		})
+	}
+}
+
+func TestUploadMetadataGet(t *testing.T) {
+	t.Parallel()
+
+	metadata := UploadMetadata("filename aGVsbG8ucG5n, is_confidential")
+
+	value, ok := metadata.Get("filename")
+	assert.True(t, ok)
+	assert.Equal(t, "hello.png", value)
+
+	value, ok = metadata.Get("is_confidential")
+	assert.True(t, ok)
+	assert.Equal(t, "", value)
+
+	_, ok = metadata.Get("type")
+	assert.False(t, ok)
+}
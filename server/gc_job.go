@@ -0,0 +1,42 @@
+package main
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/mazrean/Quantainer/service"
+)
+
+// defaultGCInterval 復元期間は日単位なので、頻繁に実行する必要はない
+const defaultGCInterval = time.Hour
+
+// runGCJob intervalごとに、復元期間を過ぎたファイル・リソースを完全に削除する
+func runGCJob(ctx context.Context, garbageCollector service.GarbageCollector, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		result, err := garbageCollector.CollectGarbage(ctx)
+		if err != nil {
+			log.Printf("error: failed to collect garbage: %v\n", err)
+			continue
+		}
+
+		log.Printf(
+			"info: garbage collection finished: purged %d resources, %d groups, %d files, discarded %d uploads, deleted %d objects, failed %d\n",
+			result.PurgedResources,
+			result.PurgedGroups,
+			result.PurgedFiles,
+			result.DiscardedUploads,
+			result.DeletedObjects,
+			result.Failed,
+		)
+	}
+}
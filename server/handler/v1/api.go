@@ -13,6 +13,10 @@ type API struct {
 	*File
 	*Resource
 	*Group
+	*Quota
+	*Upload
+	*ShareLink
+	*Importer
 }
 
 func NewAPI(
@@ -22,14 +26,22 @@ func NewAPI(
 	file *File,
 	resource *Resource,
 	group *Group,
+	quota *Quota,
+	upload *Upload,
+	shareLink *ShareLink,
+	importer *Importer,
 ) *API {
 	return &API{
-		User:     user,
-		OAuth2:   oAuth2,
-		Session:  session,
-		File:     file,
-		Resource: resource,
-		Group:    group,
+		User:      user,
+		OAuth2:    oAuth2,
+		Session:   session,
+		File:      file,
+		Resource:  resource,
+		Group:     group,
+		Quota:     quota,
+		Upload:    upload,
+		ShareLink: shareLink,
+		Importer:  importer,
 	}
 }
 
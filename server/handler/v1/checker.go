@@ -7,22 +7,26 @@ import (
 	"net/http"
 
 	"github.com/labstack/echo/v4"
+	"github.com/mazrean/Quantainer/domain/values"
 	Openapi "github.com/mazrean/Quantainer/handler/v1/openapi"
 	"github.com/mazrean/Quantainer/service"
 )
 
 type Checker struct {
-	session     *Session
-	oidcService service.OIDC
+	session          *Session
+	oidcService      service.OIDC
+	shareLinkService service.ShareLink
 }
 
 func NewChecker(
 	session *Session,
 	oidcService service.OIDC,
+	shareLinkService service.ShareLink,
 ) *Checker {
 	return &Checker{
-		session:     session,
-		oidcService: oidcService,
+		session:          session,
+		oidcService:      oidcService,
+		shareLinkService: shareLinkService,
 	}
 }
 
@@ -35,17 +39,24 @@ func (m *Checker) check(c echo.Context) error {
 			key:     Openapi.TraPMemberAuthScopes,
 			handler: m.TrapMemberAuthChecker,
 		},
+		{
+			key:     Openapi.ShareLinkAuthScopes,
+			handler: m.ShareLinkAuthChecker,
+		},
 	}
 
+	// 複数の認証方法が指定されている場合は、いずれかを満たせばよい
+	var err error
 	for _, checker := range checkers {
 		if c.Get(checker.key) != nil {
-			if err := checker.handler(c); err != nil {
-				return err
+			err = checker.handler(c)
+			if err == nil {
+				return nil
 			}
 		}
 	}
 
-	return nil
+	return err
 }
 
 func (m *Checker) TrapMemberAuthChecker(c echo.Context) error {
@@ -86,3 +97,27 @@ func (m *Checker) checkTrapMemberAuth(c echo.Context) (bool, string, error) {
 
 	return true, "", nil
 }
+
+// ShareLinkAuthChecker 利用可能な共有リンクのトークンの場合、共有リンクをcontextに設定する
+func (m *Checker) ShareLinkAuthChecker(c echo.Context) error {
+	token := c.QueryParam(shareTokenQueryKey)
+	if len(token) == 0 {
+		return echo.NewHTTPError(http.StatusUnauthorized, "no share token")
+	}
+
+	shareLink, err := m.shareLinkService.AuthenticateShareToken(c.Request().Context(), values.ShareToken(token))
+	if errors.Is(err, service.ErrInvalidShareToken) {
+		return echo.NewHTTPError(http.StatusUnauthorized, "invalid share token")
+	}
+	if errors.Is(err, service.ErrShareLinkUnavailable) {
+		return echo.NewHTTPError(http.StatusGone, "share link is no longer available")
+	}
+	if err != nil {
+		log.Printf("error: failed to authenticate share token: %v\n", err)
+		return echo.NewHTTPError(http.StatusInternalServerError)
+	}
+
+	c.Set(shareLinkContextKey, shareLink)
+
+	return nil
+}
@@ -5,12 +5,17 @@ import (
 
 	"github.com/gorilla/sessions"
 	"github.com/labstack/echo/v4"
+	"github.com/mazrean/Quantainer/domain"
 )
 
 const (
-	sessionContextKey = "session"
+	sessionContextKey   = "session"
+	shareLinkContextKey = "shareLink"
 )
 
+// shareTokenQueryKey 共有リンクのトークンを指定するクエリパラメーター
+const shareTokenQueryKey = "token"
+
 func getSession(c echo.Context) (*sessions.Session, error) {
 	iSession := c.Get(sessionContextKey)
 	if iSession == nil {
@@ -24,3 +29,15 @@ func getSession(c echo.Context) (*sessions.Session, error) {
 
 	return session, nil
 }
+
+// getShareLink 共有リンクのトークンで認証された場合のみ、共有リンクを返す
+func getShareLink(c echo.Context) (*domain.ShareLink, bool) {
+	iShareLink := c.Get(shareLinkContextKey)
+	if iShareLink == nil {
+		return nil, false
+	}
+
+	shareLink, ok := iShareLink.(*domain.ShareLink)
+
+	return shareLink, ok
+}
@@ -1,33 +1,48 @@
 package v1
 
 import (
-	"bytes"
 	"errors"
 	"fmt"
+	"io"
 	"log"
+	"mime/multipart"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
+	"github.com/mazrean/Quantainer/domain"
 	"github.com/mazrean/Quantainer/domain/values"
 	Openapi "github.com/mazrean/Quantainer/handler/v1/openapi"
 	"github.com/mazrean/Quantainer/service"
 )
 
 type File struct {
-	session     *Session
-	checker     *Checker
-	fileService service.File
+	session          *Session
+	checker          *Checker
+	fileService      service.File
+	shareLinkService service.ShareLink
 }
 
-func NewFile(session *Session, checker *Checker, fileService service.File) *File {
+func NewFile(
+	session *Session,
+	checker *Checker,
+	fileService service.File,
+	shareLinkService service.ShareLink,
+) *File {
 	return &File{
-		session:     session,
-		checker:     checker,
-		fileService: fileService,
+		session:          session,
+		checker:          checker,
+		fileService:      fileService,
+		shareLinkService: shareLinkService,
 	}
 }
 
+// multipartOverhead ファイル以外のmultipartのヘッダーなどに許容するバイト数
+const multipartOverhead = 1 << 20
+
 func (f *File) PostFile(c echo.Context) error {
 	err := f.checker.check(c)
 	if err != nil {
@@ -46,18 +61,34 @@ func (f *File) PostFile(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusInternalServerError, "failed to get user")
 	}
 
-	fileHeader, err := c.FormFile("file")
-	if err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, "no file")
+	// 種類ごとの上限はファイルの種類を判定してから確認するので、ここでは最も大きい上限で打ち切る
+	maxSize := f.fileService.GetMaxUploadSize()
+	if maxSize > 0 {
+		c.Request().Body = http.MaxBytesReader(c.Response(), c.Request().Body, maxSize+multipartOverhead)
 	}
 
-	reqFile, err := fileHeader.Open()
+	reqFile, err := getFormFile(c, "file")
+	if errors.Is(err, errNoFormFile) {
+		return echo.NewHTTPError(http.StatusBadRequest, "no file")
+	}
 	if err != nil {
 		return echo.NewHTTPError(http.StatusBadRequest, fmt.Errorf("failed to open file:%w", err))
 	}
 	defer reqFile.Close()
 
-	fileInfo, err := f.fileService.Upload(c.Request().Context(), authSession, reqFile)
+	fileInfo, err := f.fileService.Upload(c.Request().Context(), authSession, values.NewFileName(reqFile.FileName()), reqFile)
+	if errors.Is(err, service.ErrQuotaExceeded) {
+		return echo.NewHTTPError(http.StatusRequestEntityTooLarge, "quota exceeded")
+	}
+	if errors.Is(err, service.ErrStorageFull) {
+		return echo.NewHTTPError(http.StatusInsufficientStorage, "storage full")
+	}
+	if errors.Is(err, service.ErrFileTooLarge) {
+		return echo.NewHTTPError(http.StatusRequestEntityTooLarge, "file too large")
+	}
+	if errors.Is(err, service.ErrInvalidFormat) {
+		return echo.NewHTTPError(http.StatusUnsupportedMediaType, "file type not allowed")
+	}
 	if err != nil {
 		return echo.NewHTTPError(http.StatusBadRequest, fmt.Errorf("failed to upload file:%w", err))
 	}
@@ -74,6 +105,24 @@ func (f *File) PostFile(c echo.Context) error {
 		fileType = Openapi.FileTypeSvg
 	case values.FileTypeGif:
 		fileType = Openapi.FileTypeGif
+	case values.FileTypeMp3:
+		fileType = Openapi.FileTypeMp3
+	case values.FileTypeOgg:
+		fileType = Openapi.FileTypeOgg
+	case values.FileTypeWav:
+		fileType = Openapi.FileTypeWav
+	case values.FileTypeFlac:
+		fileType = Openapi.FileTypeFlac
+	case values.FileTypeMp4:
+		fileType = Openapi.FileTypeMp4
+	case values.FileTypeWebM:
+		fileType = Openapi.FileTypeWebm
+	case values.FileTypePdf:
+		fileType = Openapi.FileTypePdf
+	case values.FileTypeZip:
+		fileType = Openapi.FileTypeZip
+	case values.FileTypeAvif:
+		fileType = Openapi.FileTypeAvif
 	case values.FileTypeOther:
 		fileType = Openapi.FileTypeOther
 	default:
@@ -81,15 +130,39 @@ func (f *File) PostFile(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusInternalServerError, "unexpected file type")
 	}
 
+	var similarResources *[]Openapi.SimilarResource
+	if len(fileInfo.SimilarResources) != 0 {
+		apiSimilarResources, err := newOpenapiSimilarResources(fileInfo.SimilarResources)
+		if err != nil {
+			log.Printf("error: failed to convert similar resources: %v\n", err)
+			return echo.NewHTTPError(http.StatusInternalServerError, "invalid resource type")
+		}
+
+		similarResources = &apiSimilarResources
+	}
+
+	scan, err := newOpenapiFileScan(fileInfo.File)
+	if err != nil {
+		log.Printf("error: failed to convert file scan: %v\n", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "invalid scan status")
+	}
+
 	return c.JSON(http.StatusCreated, Openapi.File{
-		Id:        uuid.UUID(fileInfo.File.GetID()).String(),
-		Type:      fileType,
-		Creator:   string(fileInfo.Creator.GetName()),
-		CreatedAt: fileInfo.File.GetCreatedAt(),
+		Id:               uuid.UUID(fileInfo.File.GetID()).String(),
+		Type:             fileType,
+		Creator:          string(fileInfo.Creator.GetName()),
+		Size:             fileInfo.File.GetSize(),
+		OriginalName:     string(fileInfo.File.GetOriginalName()),
+		Extension:        string(fileInfo.File.GetExtension()),
+		OriginalSize:     fileInfo.File.GetOriginalSize(),
+		ImageMetadata:    newOpenapiImageMetadata(fileInfo.File),
+		CreatedAt:        fileInfo.File.GetCreatedAt(),
+		Scan:             scan,
+		SimilarResources: similarResources,
 	})
 }
 
-func (f *File) GetFile(c echo.Context, strFileID Openapi.FileIDInPath) error {
+func (f *File) GetFile(c echo.Context, strFileID Openapi.FileIDInPath, params Openapi.GetFileParams) error {
 	err := f.checker.check(c)
 	if err != nil {
 		return err
@@ -99,16 +172,57 @@ func (f *File) GetFile(c echo.Context, strFileID Openapi.FileIDInPath) error {
 	if err != nil {
 		return echo.NewHTTPError(http.StatusBadRequest, "invalid file id")
 	}
+	fileID := values.NewFileIDFromUUID(uuidFileID)
 
-	buf := bytes.NewBuffer(nil)
-	file, err := f.fileService.Download(c.Request().Context(), values.NewFileIDFromUUID(uuidFileID), buf)
+	var (
+		file   *domain.File
+		reader io.ReadSeekCloser
+	)
+	if params.Size == nil {
+		file, reader, err = f.fileService.Download(c.Request().Context(), fileID)
+	} else {
+		size := values.RenditionSize(*params.Size)
+		if !size.IsValid() {
+			return echo.NewHTTPError(http.StatusBadRequest, "invalid size")
+		}
+
+		file, reader, err = f.fileService.DownloadRendition(c.Request().Context(), fileID, size)
+	}
 	if errors.Is(err, service.ErrNoFile) {
 		return echo.NewHTTPError(http.StatusNotFound, "file not found")
 	}
+	if errors.Is(err, service.ErrFileInfected) {
+		return echo.NewHTTPError(http.StatusForbidden, "file is quarantined")
+	}
 	if err != nil {
 		log.Printf("error: failed to get file: %v\n", err)
 		return echo.NewHTTPError(http.StatusInternalServerError, "failed to download file")
 	}
+	defer reader.Close()
+
+	etag := fileETag(file)
+
+	shareLink, ok := getShareLink(c)
+	if ok {
+		// レンディションがなく元のファイルを返す場合も、ダウンロードとして数える。
+		// 動画のシークやキャッシュの再検証で回数を使い切らないよう、先頭から返す場合のみ数える。
+		isDownload := file.GetID() == fileID &&
+			servesFromHead(c.Request(), etag, file.GetCreatedAt(), file.GetSize())
+		err = f.shareLinkService.AccessSharedFile(c.Request().Context(), shareLink, fileID, isDownload)
+		if errors.Is(err, service.ErrForbidden) {
+			return echo.NewHTTPError(http.StatusForbidden, "file is not shared")
+		}
+		if errors.Is(err, service.ErrShareLinkUnavailable) {
+			return echo.NewHTTPError(http.StatusGone, "share link is no longer available")
+		}
+		if errors.Is(err, service.ErrNoFile) || errors.Is(err, service.ErrNoResource) || errors.Is(err, service.ErrNoGroup) {
+			return echo.NewHTTPError(http.StatusNotFound, "shared content not found")
+		}
+		if err != nil {
+			log.Printf("error: failed to access shared file: %v\n", err)
+			return echo.NewHTTPError(http.StatusInternalServerError, "failed to access shared file")
+		}
+	}
 
 	var mime string
 	switch file.GetType() {
@@ -122,6 +236,24 @@ func (f *File) GetFile(c echo.Context, strFileID Openapi.FileIDInPath) error {
 		mime = "image/svg+xml"
 	case values.FileTypeGif:
 		mime = "image/gif"
+	case values.FileTypeMp3:
+		mime = "audio/mpeg"
+	case values.FileTypeOgg:
+		mime = "audio/ogg"
+	case values.FileTypeWav:
+		mime = "audio/wav"
+	case values.FileTypeFlac:
+		mime = "audio/flac"
+	case values.FileTypeMp4:
+		mime = "video/mp4"
+	case values.FileTypeWebM:
+		mime = "video/webm"
+	case values.FileTypePdf:
+		mime = "application/pdf"
+	case values.FileTypeZip:
+		mime = "application/zip"
+	case values.FileTypeAvif:
+		mime = "image/avif"
 	case values.FileTypeOther:
 		mime = "application/octet-stream"
 	default:
@@ -129,5 +261,498 @@ func (f *File) GetFile(c echo.Context, strFileID Openapi.FileIDInPath) error {
 		return echo.NewHTTPError(http.StatusInternalServerError, "unexpected file type")
 	}
 
-	return c.Stream(http.StatusOK, mime, buf)
+	// ファイルはIDごとに不変なので、IDをETagとして長期間キャッシュさせる
+	header := c.Response().Header()
+	header.Set(echo.HeaderContentType, mime)
+	header.Set("ETag", etag)
+	header.Set("Cache-Control", fileCacheControl)
+	setFileSecurityHeaders(header, file.GetType())
+	header.Set(echo.HeaderContentDisposition, contentDisposition(file))
+
+	// Range・If-None-Match・If-Modified-Sinceの処理はhttp.ServeContentに任せる
+	http.ServeContent(c.Response(), c.Request(), "", file.GetCreatedAt(), reader)
+
+	return nil
+}
+
+func (f *File) GetFileTransform(c echo.Context, strFileID Openapi.FileIDInPath, params Openapi.GetFileTransformParams) error {
+	err := f.checker.check(c)
+	if err != nil {
+		return err
+	}
+
+	uuidFileID, err := uuid.Parse(string(strFileID))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid file id")
+	}
+	fileID := values.NewFileIDFromUUID(uuidFileID)
+
+	fit := values.ImageFitContain
+	if params.Fit != nil {
+		switch Openapi.TransformFitInQuery(*params.Fit) {
+		case Openapi.Contain:
+			fit = values.ImageFitContain
+		case Openapi.Cover:
+			fit = values.ImageFitCover
+		default:
+			return echo.NewHTTPError(http.StatusBadRequest, "invalid fit")
+		}
+	}
+
+	preset := values.NewImageTransformPreset(int(params.W), int(params.H), fit)
+	if !preset.IsValid() {
+		return echo.NewHTTPError(http.StatusBadRequest, "unsupported transform preset")
+	}
+
+	quality := values.DefaultImageQuality
+	if params.Q != nil {
+		quality = values.ImageQuality(*params.Q)
+		if !quality.IsValid() {
+			return echo.NewHTTPError(http.StatusBadRequest, "invalid quality")
+		}
+	}
+
+	var format *values.FileType
+	if params.Format != nil {
+		var fileType values.FileType
+		switch Openapi.TransformFormatInQuery(*params.Format) {
+		case Openapi.Jpeg:
+			fileType = values.FileTypeJpeg
+		case Openapi.Png:
+			fileType = values.FileTypePng
+		case Openapi.Webp:
+			fileType = values.FileTypeWebP
+		default:
+			return echo.NewHTTPError(http.StatusBadRequest, "invalid format")
+		}
+		format = &fileType
+	} else {
+		// 形式の指定がない場合はAcceptで決めるので、Acceptごとにキャッシュさせる
+		c.Response().Header().Add("Vary", echo.HeaderAccept)
+
+		var ok bool
+		format, ok = negotiateTransformFormat(c.Request().Header.Get(echo.HeaderAccept))
+		if !ok {
+			return echo.NewHTTPError(http.StatusNotAcceptable, "no acceptable format")
+		}
+	}
+
+	// 共有リンクから際限なく変換させないよう、変換する前に共有されているか確認する
+	shareLink, ok := getShareLink(c)
+	if ok {
+		err = f.shareLinkService.AccessSharedFile(c.Request().Context(), shareLink, fileID, false)
+		if errors.Is(err, service.ErrForbidden) {
+			return echo.NewHTTPError(http.StatusForbidden, "file is not shared")
+		}
+		if errors.Is(err, service.ErrShareLinkUnavailable) {
+			return echo.NewHTTPError(http.StatusGone, "share link is no longer available")
+		}
+		if errors.Is(err, service.ErrNoFile) || errors.Is(err, service.ErrNoResource) || errors.Is(err, service.ErrNoGroup) {
+			return echo.NewHTTPError(http.StatusNotFound, "shared content not found")
+		}
+		if err != nil {
+			log.Printf("error: failed to access shared file: %v\n", err)
+			return echo.NewHTTPError(http.StatusInternalServerError, "failed to access shared file")
+		}
+	}
+
+	file, reader, err := f.fileService.DownloadTransformed(c.Request().Context(), fileID, preset, format, quality)
+	if errors.Is(err, service.ErrNoFile) {
+		return echo.NewHTTPError(http.StatusNotFound, "file not found")
+	}
+	if errors.Is(err, service.ErrInvalidFormat) {
+		return echo.NewHTTPError(http.StatusBadRequest, "not a transformable image")
+	}
+	if errors.Is(err, service.ErrFileInfected) {
+		return echo.NewHTTPError(http.StatusForbidden, "file is quarantined")
+	}
+	if err != nil {
+		log.Printf("error: failed to transform file: %v\n", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to transform file")
+	}
+	defer reader.Close()
+
+	var mime string
+	switch file.GetType() {
+	case values.FileTypeJpeg:
+		mime = "image/jpeg"
+	case values.FileTypePng:
+		mime = "image/png"
+	case values.FileTypeWebP:
+		mime = "image/webp"
+	default:
+		log.Printf("error: unknown transform file type: %d", file.GetType())
+		return echo.NewHTTPError(http.StatusInternalServerError, "unexpected file type")
+	}
+
+	// 変換結果もIDごとに不変なので、元のファイルと同様にキャッシュさせる
+	header := c.Response().Header()
+	header.Set(echo.HeaderContentType, mime)
+	header.Set("ETag", fileETag(file))
+	header.Set("Cache-Control", fileCacheControl)
+	setFileSecurityHeaders(header, file.GetType())
+	header.Set(echo.HeaderContentDisposition, contentDisposition(file))
+
+	http.ServeContent(c.Response(), c.Request(), "", file.GetCreatedAt(), reader)
+
+	return nil
+}
+
+func (f *File) DeleteFile(c echo.Context, strFileID Openapi.FileIDInPath) error {
+	err := f.checker.check(c)
+	if err != nil {
+		return err
+	}
+
+	session, err := getSession(c)
+	if err != nil {
+		log.Printf("error: failed to get session: %v\n", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to get session")
+	}
+
+	authSession, err := f.session.getAuthSession(session)
+	if err != nil {
+		log.Printf("error: failed to get auth session: %v\n", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to get auth session")
+	}
+
+	uuidFileID, err := uuid.Parse(string(strFileID))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid file id")
+	}
+
+	err = f.fileService.DeleteFile(
+		c.Request().Context(),
+		authSession,
+		values.NewFileIDFromUUID(uuidFileID),
+	)
+	if errors.Is(err, service.ErrNoFile) {
+		return echo.NewHTTPError(http.StatusNotFound, "file not found")
+	}
+	if errors.Is(err, service.ErrForbidden) {
+		return echo.NewHTTPError(http.StatusForbidden, "forbidden")
+	}
+	if err != nil {
+		log.Printf("error: failed to delete file: %v\n", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to delete file")
+	}
+
+	return c.NoContent(http.StatusOK)
+}
+
+func (f *File) RestoreFile(c echo.Context, strFileID Openapi.FileIDInPath) error {
+	err := f.checker.check(c)
+	if err != nil {
+		return err
+	}
+
+	session, err := getSession(c)
+	if err != nil {
+		log.Printf("error: failed to get session: %v\n", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to get session")
+	}
+
+	authSession, err := f.session.getAuthSession(session)
+	if err != nil {
+		log.Printf("error: failed to get auth session: %v\n", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to get auth session")
+	}
+
+	uuidFileID, err := uuid.Parse(string(strFileID))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid file id")
+	}
+
+	err = f.fileService.RestoreFile(
+		c.Request().Context(),
+		authSession,
+		values.NewFileIDFromUUID(uuidFileID),
+	)
+	if errors.Is(err, service.ErrNoFile) {
+		return echo.NewHTTPError(http.StatusNotFound, "deleted file not found")
+	}
+	if errors.Is(err, service.ErrForbidden) {
+		return echo.NewHTTPError(http.StatusForbidden, "forbidden")
+	}
+	if errors.Is(err, service.ErrRestorePeriodExpired) {
+		return echo.NewHTTPError(http.StatusGone, "restore period expired")
+	}
+	if err != nil {
+		log.Printf("error: failed to restore file: %v\n", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to restore file")
+	}
+
+	return c.NoContent(http.StatusOK)
+}
+
+// fileCacheControl ログインが必要なので共有キャッシュには載せない
+const fileCacheControl = "private, max-age=31536000, immutable"
+
+// fileContentSecurityPolicy ファイルを直接開いた場合も、SVGなどに含まれるスクリプトや外部への参照を実行させない
+const fileContentSecurityPolicy = "default-src 'none'; img-src data:; style-src 'unsafe-inline'; sandbox"
+
+// setFileSecurityHeaders スクリプトを実行し得る種類のみCSPで制限する。
+// PDFのビューアや音声・動画の再生はsandboxやdefault-srcで動かなくなるので、その他の種類はnosniffのみにする。
+func setFileSecurityHeaders(header http.Header, fileType values.FileType) {
+	header.Set("X-Content-Type-Options", "nosniff")
+
+	switch fileType {
+	case values.FileTypeSvg, values.FileTypeOther:
+		header.Set("Content-Security-Policy", fileContentSecurityPolicy)
+	}
+}
+
+var errNoFormFile = errors.New("no form file")
+
+// fileETag ファイルはIDごとに不変なので、IDをETagにする
+func fileETag(file *domain.File) string {
+	return fmt.Sprintf(`"%s"`, uuid.UUID(file.GetID()).String())
+}
+
+// servesFromHead http.ServeContentがファイルの先頭から内容を返すかを判定する。
+// If-None-MatchやIf-Modified-Sinceで304を返す場合と、先頭を含まない範囲のみのRangeリクエストはfalseを返す。
+// Rangeリクエストを分割しても数えられるよう、先頭を含む範囲のRangeリクエストはtrueを返す。
+func servesFromHead(req *http.Request, etag string, modtime time.Time, size int64) bool {
+	// http.ServeContentと同様に、If-None-MatchがあればIf-Modified-Sinceは見ない
+	if ifNoneMatch := req.Header.Get("If-None-Match"); ifNoneMatch != "" {
+		if etagWeakMatch(ifNoneMatch, etag) {
+			return false
+		}
+	} else if ifModifiedSince, err := http.ParseTime(req.Header.Get("If-Modified-Since")); err == nil &&
+		!modtime.Truncate(time.Second).After(ifModifiedSince) {
+		return false
+	}
+
+	strRange := req.Header.Get("Range")
+	if strRange == "" {
+		return true
+	}
+
+	// If-Rangeが一致しない場合、Rangeは無視されて全体が返る
+	if ifRange := req.Header.Get("If-Range"); ifRange != "" && ifRange != etag {
+		ifRangeTime, err := http.ParseTime(ifRange)
+		if err != nil || !modtime.Truncate(time.Second).Equal(ifRangeTime) {
+			return true
+		}
+	}
+
+	return rangeIncludesHead(strRange, size)
+}
+
+// etagWeakMatch If-None-MatchのいずれかのETagが弱い比較で一致するか
+func etagWeakMatch(header string, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// rangeIncludesHead Rangeヘッダーの範囲を返す場合に、ファイルの先頭が含まれるか。
+// 範囲の合計がファイルより大きい場合はhttp.ServeContentが全体を返すので、trueを返す。
+// 不正な範囲の場合は416になり内容を返さないので、falseを返す。
+func rangeIncludesHead(strRange string, size int64) bool {
+	const prefix = "bytes="
+	if !strings.HasPrefix(strRange, prefix) {
+		return false
+	}
+
+	var (
+		includesHead bool
+		totalLength  int64
+	)
+	for _, spec := range strings.Split(strRange[len(prefix):], ",") {
+		spec = strings.TrimSpace(spec)
+		if spec == "" {
+			continue
+		}
+
+		startEnd := strings.SplitN(spec, "-", 2)
+		if len(startEnd) != 2 {
+			return false
+		}
+		strStart, strEnd := strings.TrimSpace(startEnd[0]), strings.TrimSpace(startEnd[1])
+
+		var start, length int64
+		if strStart == "" {
+			// 末尾からのバイト数の指定
+			suffixLength, err := strconv.ParseInt(strEnd, 10, 64)
+			if err != nil || suffixLength < 0 {
+				return false
+			}
+			if suffixLength > size {
+				suffixLength = size
+			}
+			start, length = size-suffixLength, suffixLength
+		} else {
+			var err error
+			start, err = strconv.ParseInt(strStart, 10, 64)
+			if err != nil || start < 0 {
+				return false
+			}
+			if start >= size {
+				// 満たせない範囲は無視される
+				continue
+			}
+
+			end := size - 1
+			if strEnd != "" {
+				end, err = strconv.ParseInt(strEnd, 10, 64)
+				if err != nil || start > end {
+					return false
+				}
+				if end >= size {
+					end = size - 1
+				}
+			}
+			length = end - start + 1
+		}
+
+		if start == 0 {
+			includesHead = true
+		}
+		totalLength += length
+	}
+
+	return includesHead || totalLength > size
+}
+
+// contentDisposition アップロードされた時のファイル名をRFC 6266の形式で設定する。
+// ASCII以外の文字を含む名前は、filenameに置き換えた名前を、filename*にRFC 8187の形式で符号化した名前を設定する。
+// ファイル名がわからない場合は、idに種類に応じた拡張子を付けた名前にする。
+func contentDisposition(file *domain.File) string {
+	name := string(file.GetOriginalName())
+	if len(name) == 0 {
+		name = uuid.UUID(file.GetID()).String() + file.GetType().DefaultExtension()
+	}
+
+	asciiName := strings.Map(func(r rune) rune {
+		if r < 0x20 || r > 0x7e || r == '"' || r == '\\' {
+			return '_'
+		}
+
+		return r
+	}, name)
+
+	// ブラウザで開けるよう、attachmentではなくinlineにする
+	disposition := fmt.Sprintf(`inline; filename="%s"`, asciiName)
+	if asciiName != name {
+		disposition += "; filename*=UTF-8''" + encodeExtValue(name)
+	}
+
+	return disposition
+}
+
+// encodeExtValue RFC 8187のattr-char以外のバイトをパーセントエンコードする
+func encodeExtValue(value string) string {
+	var builder strings.Builder
+	for i := 0; i < len(value); i++ {
+		b := value[i]
+		if ('a' <= b && b <= 'z') || ('A' <= b && b <= 'Z') || ('0' <= b && b <= '9') || strings.IndexByte("!#$&+-.^_`|~", b) >= 0 {
+			builder.WriteByte(b)
+			continue
+		}
+
+		fmt.Fprintf(&builder, "%%%02X", b)
+	}
+
+	return builder.String()
+}
+
+// getFormFile ファイル全体をメモリやディスクに展開しないよう、multipartのpartをそのまま返す
+func getFormFile(c echo.Context, name string) (*multipart.Part, error) {
+	reader, err := c.Request().MultipartReader()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get multipart reader: %w", err)
+	}
+
+	for {
+		part, err := reader.NextPart()
+		if errors.Is(err, io.EOF) {
+			return nil, errNoFormFile
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to get next part: %w", err)
+		}
+
+		if part.FormName() == name {
+			return part, nil
+		}
+
+		err = part.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to close part: %w", err)
+		}
+	}
+}
+
+// transformAcceptTypes 変換後の形式にできるメディアタイプ
+var transformAcceptTypes = map[string]values.FileType{
+	"image/jpeg": values.FileTypeJpeg,
+	"image/png":  values.FileTypePng,
+	"image/webp": values.FileTypeWebP,
+}
+
+// negotiateTransformFormat Acceptヘッダーから変換後の形式を決める。
+// 変換できる形式が明示されていない場合はnilを返し、元の画像に合わせた形式にする。
+// 受け入れられる形式がない場合はfalseを返す。
+func negotiateTransformFormat(accept string) (*values.FileType, bool) {
+	if strings.TrimSpace(accept) == "" {
+		return nil, true
+	}
+
+	var (
+		format    *values.FileType
+		formatQ   float64
+		wildcardQ float64
+	)
+	for _, mediaRange := range strings.Split(accept, ",") {
+		parts := strings.Split(mediaRange, ";")
+		mediaType := strings.ToLower(strings.TrimSpace(parts[0]))
+
+		q := 1.0
+		isValid := true
+		for _, param := range parts[1:] {
+			keyValue := strings.SplitN(strings.TrimSpace(param), "=", 2)
+			if len(keyValue) != 2 || strings.ToLower(strings.TrimSpace(keyValue[0])) != "q" {
+				continue
+			}
+
+			parsedQ, err := strconv.ParseFloat(strings.TrimSpace(keyValue[1]), 64)
+			if err != nil || parsedQ < 0 || parsedQ > 1 {
+				isValid = false
+				break
+			}
+			q = parsedQ
+		}
+		if !isValid {
+			continue
+		}
+
+		switch mediaType {
+		case "*/*", "image/*":
+			if q > wildcardQ {
+				wildcardQ = q
+			}
+		default:
+			fileType, ok := transformAcceptTypes[mediaType]
+			if ok && q > formatQ {
+				fileType := fileType
+				format = &fileType
+				formatQ = q
+			}
+		}
+	}
+
+	if format != nil && formatQ >= wildcardQ {
+		return format, true
+	}
+	if wildcardQ > 0 {
+		return nil, true
+	}
+
+	return nil, false
 }
@@ -0,0 +1,292 @@
+package v1
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/mazrean/Quantainer/domain"
+	"github.com/mazrean/Quantainer/domain/values"
+	Openapi "github.com/mazrean/Quantainer/handler/v1/openapi"
+	mockService "github.com/mazrean/Quantainer/service/mock"
+	"github.com/stretchr/testify/assert"
+)
+
+type nopReadSeekCloser struct {
+	*bytes.Reader
+}
+
+func (nopReadSeekCloser) Close() error {
+	return nil
+}
+
+func TestGetFileSecurityHeaders(t *testing.T) {
+	t.Parallel()
+
+	type test struct {
+		description string
+		fileType    values.FileType
+		isStrictCSP bool
+	}
+
+	testCases := []test{
+		{
+			description: "jpegはCSPを付けない",
+			fileType:    values.FileTypeJpeg,
+		},
+		{
+			description: "pngはCSPを付けない",
+			fileType:    values.FileTypePng,
+		},
+		{
+			description: "webpはCSPを付けない",
+			fileType:    values.FileTypeWebP,
+		},
+		{
+			description: "gifはCSPを付けない",
+			fileType:    values.FileTypeGif,
+		},
+		{
+			description: "avifはCSPを付けない",
+			fileType:    values.FileTypeAvif,
+		},
+		{
+			description: "svgはスクリプトを実行し得るのでCSPで制限する",
+			fileType:    values.FileTypeSvg,
+			isStrictCSP: true,
+		},
+		{
+			description: "mp3は再生できるようCSPを付けない",
+			fileType:    values.FileTypeMp3,
+		},
+		{
+			description: "oggは再生できるようCSPを付けない",
+			fileType:    values.FileTypeOgg,
+		},
+		{
+			description: "wavは再生できるようCSPを付けない",
+			fileType:    values.FileTypeWav,
+		},
+		{
+			description: "flacは再生できるようCSPを付けない",
+			fileType:    values.FileTypeFlac,
+		},
+		{
+			description: "mp4は再生できるようCSPを付けない",
+			fileType:    values.FileTypeMp4,
+		},
+		{
+			description: "webmは再生できるようCSPを付けない",
+			fileType:    values.FileTypeWebM,
+		},
+		{
+			description: "pdfはビューアで開けるようCSPを付けない",
+			fileType:    values.FileTypePdf,
+		},
+		{
+			description: "zipはCSPを付けない",
+			fileType:    values.FileTypeZip,
+		},
+		{
+			description: "種類が不明なファイルはCSPで制限する",
+			fileType:    values.FileTypeOther,
+			isStrictCSP: true,
+		},
+	}
+
+	for _, testCase := range testCases {
+		testCase := testCase
+
+		t.Run(testCase.description, func(t *testing.T) {
+			t.Parallel()
+
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockFileService := mockService.NewMockFile(ctrl)
+
+			fileHandler := NewFile(nil, &Checker{}, mockFileService, nil)
+
+			fileID := values.NewFileID()
+			content := []byte("content")
+			file := domain.NewFile(
+				fileID,
+				testCase.fileType,
+				values.FileHash{},
+				int64(len(content)),
+				nil,
+				time.Now(),
+			)
+
+			mockFileService.
+				EXPECT().
+				Download(gomock.Any(), fileID).
+				Return(file, nopReadSeekCloser{bytes.NewReader(content)}, nil)
+
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/files/id", nil)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+
+			err := fileHandler.GetFile(c, Openapi.FileIDInPath(uuid.UUID(fileID).String()), Openapi.GetFileParams{})
+			if err != nil {
+				t.Fatalf("failed to get file: %v", err)
+			}
+
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.Equal(t, "nosniff", rec.Header().Get("X-Content-Type-Options"))
+			if testCase.isStrictCSP {
+				assert.Equal(t, fileContentSecurityPolicy, rec.Header().Get("Content-Security-Policy"))
+			} else {
+				assert.Empty(t, rec.Header().Get("Content-Security-Policy"))
+			}
+		})
+	}
+}
+
+func TestServesFromHead(t *testing.T) {
+	t.Parallel()
+
+	const (
+		etag = `"0b7e4d4f-9d3c-4b6a-8f3e-6f1f2c1a9b00"`
+		size = int64(1000)
+	)
+	modtime := time.Date(2022, 4, 1, 12, 0, 0, 500, time.UTC)
+
+	type test struct {
+		description string
+		header      map[string]string
+		expected    bool
+	}
+
+	testCases := []test{
+		{
+			description: "条件なしのリクエストは数える",
+			header:      map[string]string{},
+			expected:    true,
+		},
+		{
+			description: "先頭からのRangeリクエストは数える",
+			header:      map[string]string{"Range": "bytes=0-"},
+			expected:    true,
+		},
+		{
+			description: "先頭を含む範囲のRangeリクエストは数える",
+			header:      map[string]string{"Range": "bytes=0-99"},
+			expected:    true,
+		},
+		{
+			description: "シークのRangeリクエストは数えない",
+			header:      map[string]string{"Range": "bytes=500-"},
+			expected:    false,
+		},
+		{
+			description: "先頭を含まない複数の範囲のRangeリクエストは数えない",
+			header:      map[string]string{"Range": "bytes=100-199, 300-399"},
+			expected:    false,
+		},
+		{
+			description: "先頭を含まない末尾からのRangeリクエストは数えない",
+			header:      map[string]string{"Range": "bytes=-100"},
+			expected:    false,
+		},
+		{
+			description: "ファイルより大きい末尾からのRangeリクエストは数える",
+			header:      map[string]string{"Range": "bytes=-2000"},
+			expected:    true,
+		},
+		{
+			description: "範囲の合計がファイルより大きいRangeリクエストは全体が返るので数える",
+			header:      map[string]string{"Range": "bytes=1-,1-"},
+			expected:    true,
+		},
+		{
+			description: "不正なRangeリクエストは数えない",
+			header:      map[string]string{"Range": "bytes=200-100"},
+			expected:    false,
+		},
+		{
+			description: "満たせないRangeリクエストは数えない",
+			header:      map[string]string{"Range": "bytes=2000-"},
+			expected:    false,
+		},
+		{
+			description: "バイト以外の単位のRangeリクエストは数えない",
+			header:      map[string]string{"Range": "items=0-"},
+			expected:    false,
+		},
+		{
+			description: "If-Rangeが一致しないRangeリクエストは全体が返るので数える",
+			header:      map[string]string{"Range": "bytes=500-", "If-Range": `"other"`},
+			expected:    true,
+		},
+		{
+			description: "If-Rangeが一致するRangeリクエストは数えない",
+			header:      map[string]string{"Range": "bytes=500-", "If-Range": etag},
+			expected:    false,
+		},
+		{
+			description: "If-Rangeの日時が一致するRangeリクエストは数えない",
+			header:      map[string]string{"Range": "bytes=500-", "If-Range": modtime.Format(http.TimeFormat)},
+			expected:    false,
+		},
+		{
+			description: "If-None-Matchが一致するリクエストは数えない",
+			header:      map[string]string{"If-None-Match": etag},
+			expected:    false,
+		},
+		{
+			description: "If-None-Matchが弱い比較で一致するリクエストは数えない",
+			header:      map[string]string{"If-None-Match": `"other", W/` + etag},
+			expected:    false,
+		},
+		{
+			description: "If-None-Matchが*のリクエストは数えない",
+			header:      map[string]string{"If-None-Match": "*"},
+			expected:    false,
+		},
+		{
+			description: "If-None-Matchが一致しないリクエストは数える",
+			header:      map[string]string{"If-None-Match": `"other"`},
+			expected:    true,
+		},
+		{
+			description: "If-Modified-Since以降に更新されていないリクエストは数えない",
+			header:      map[string]string{"If-Modified-Since": modtime.Format(http.TimeFormat)},
+			expected:    false,
+		},
+		{
+			description: "If-Modified-Since以降に更新されたリクエストは数える",
+			header:      map[string]string{"If-Modified-Since": modtime.Add(-time.Hour).Format(http.TimeFormat)},
+			expected:    true,
+		},
+		{
+			description: "If-None-MatchがあればIf-Modified-Sinceは見ない",
+			header: map[string]string{
+				"If-None-Match":     `"other"`,
+				"If-Modified-Since": modtime.Format(http.TimeFormat),
+			},
+			expected: true,
+		},
+	}
+
+	for _, testCase := range testCases {
+		testCase := testCase
+
+		t.Run(testCase.description, func(t *testing.T) {
+			t.Parallel()
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/files/id", nil)
+			for key, value := range testCase.header {
+				req.Header.Set(key, value)
+			}
+
+			assert.Equal(t, testCase.expected, servesFromHead(req, etag, modtime, size))
+		})
+	}
+}
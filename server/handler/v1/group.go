@@ -3,6 +3,7 @@ package v1
 import (
 	"errors"
 	"log"
+	"mime"
 	"net/http"
 
 	"github.com/google/uuid"
@@ -127,6 +128,14 @@ func (g *Group) PostGroup(c echo.Context) error {
 	switch groupDetail.MainResource.Resource.GetType() {
 	case values.ResourceTypeImage:
 		resourceType = Openapi.ResourceTypeImage
+	case values.ResourceTypeMusic:
+		resourceType = Openapi.ResourceTypeMusic
+	case values.ResourceTypeVideo:
+		resourceType = Openapi.ResourceTypeVideo
+	case values.ResourceTypeDocument:
+		resourceType = Openapi.ResourceTypeDocument
+	case values.ResourceTypeArchive:
+		resourceType = Openapi.ResourceTypeArchive
 	case values.ResourceTypeOther:
 		resourceType = Openapi.ResourceTypeOther
 	default:
@@ -143,10 +152,13 @@ func (g *Group) PostGroup(c echo.Context) error {
 		GroupBase:      apiGroup.GroupBase,
 		Administrators: administrators,
 		MainResource: Openapi.Resource{
-			Id:        uuid.UUID(groupDetail.MainResource.Resource.GetID()).String(),
-			FileID:    uuid.UUID(groupDetail.MainResource.File.GetID()).String(),
-			Creator:   string(groupDetail.MainResource.Creator.GetName()),
-			CreatedAt: groupDetail.GetCreatedAt(),
+			Id:            uuid.UUID(groupDetail.MainResource.Resource.GetID()).String(),
+			FileID:        uuid.UUID(groupDetail.MainResource.File.GetID()).String(),
+			ImageMetadata: newOpenapiImageMetadata(groupDetail.MainResource.File),
+			State:         newOpenapiResourceState(groupDetail.MainResource.File),
+			Size:          groupDetail.MainResource.File.GetSize(),
+			Creator:       string(groupDetail.MainResource.Creator.GetName()),
+			CreatedAt:     groupDetail.GetCreatedAt(),
 			NewResource: Openapi.NewResource{
 				Name:         string(groupDetail.MainResource.GetName()),
 				Comment:      string(groupDetail.MainResource.GetComment()),
@@ -265,6 +277,14 @@ func (g *Group) GetGroups(c echo.Context, params Openapi.GetGroupsParams) error
 		switch groupInfo.MainResource.Resource.GetType() {
 		case values.ResourceTypeImage:
 			resourceType = Openapi.ResourceTypeImage
+		case values.ResourceTypeMusic:
+			resourceType = Openapi.ResourceTypeMusic
+		case values.ResourceTypeVideo:
+			resourceType = Openapi.ResourceTypeVideo
+		case values.ResourceTypeDocument:
+			resourceType = Openapi.ResourceTypeDocument
+		case values.ResourceTypeArchive:
+			resourceType = Openapi.ResourceTypeArchive
 		case values.ResourceTypeOther:
 			resourceType = Openapi.ResourceTypeOther
 		default:
@@ -281,10 +301,13 @@ func (g *Group) GetGroups(c echo.Context, params Openapi.GetGroupsParams) error
 				WritePermission: writePermission,
 			},
 			MainResource: Openapi.Resource{
-				Id:        uuid.UUID(groupInfo.MainResource.Resource.GetID()).String(),
-				FileID:    uuid.UUID(groupInfo.MainResource.File.GetID()).String(),
-				Creator:   string(groupInfo.MainResource.Creator.GetName()),
-				CreatedAt: groupInfo.GetCreatedAt(),
+				Id:            uuid.UUID(groupInfo.MainResource.Resource.GetID()).String(),
+				FileID:        uuid.UUID(groupInfo.MainResource.File.GetID()).String(),
+				ImageMetadata: newOpenapiImageMetadata(groupInfo.MainResource.File),
+				State:         newOpenapiResourceState(groupInfo.MainResource.File),
+				Size:          groupInfo.MainResource.File.GetSize(),
+				Creator:       string(groupInfo.MainResource.Creator.GetName()),
+				CreatedAt:     groupInfo.GetCreatedAt(),
 				NewResource: Openapi.NewResource{
 					Name:         string(groupInfo.MainResource.GetName()),
 					Comment:      string(groupInfo.MainResource.GetComment()),
@@ -350,6 +373,14 @@ func (g *Group) GetGroup(c echo.Context, strGroupID Openapi.GroupIDInPath) error
 	switch groupDetail.MainResource.Resource.GetType() {
 	case values.ResourceTypeImage:
 		resourceType = Openapi.ResourceTypeImage
+	case values.ResourceTypeMusic:
+		resourceType = Openapi.ResourceTypeMusic
+	case values.ResourceTypeVideo:
+		resourceType = Openapi.ResourceTypeVideo
+	case values.ResourceTypeDocument:
+		resourceType = Openapi.ResourceTypeDocument
+	case values.ResourceTypeArchive:
+		resourceType = Openapi.ResourceTypeArchive
 	case values.ResourceTypeOther:
 		resourceType = Openapi.ResourceTypeOther
 	default:
@@ -370,10 +401,13 @@ func (g *Group) GetGroup(c echo.Context, strGroupID Openapi.GroupIDInPath) error
 		},
 		Administrators: administrators,
 		MainResource: Openapi.Resource{
-			Id:        uuid.UUID(groupDetail.MainResource.Resource.GetID()).String(),
-			FileID:    uuid.UUID(groupDetail.MainResource.File.GetID()).String(),
-			Creator:   string(groupDetail.MainResource.Creator.GetName()),
-			CreatedAt: groupDetail.GetCreatedAt(),
+			Id:            uuid.UUID(groupDetail.MainResource.Resource.GetID()).String(),
+			FileID:        uuid.UUID(groupDetail.MainResource.File.GetID()).String(),
+			ImageMetadata: newOpenapiImageMetadata(groupDetail.MainResource.File),
+			State:         newOpenapiResourceState(groupDetail.MainResource.File),
+			Size:          groupDetail.MainResource.File.GetSize(),
+			Creator:       string(groupDetail.MainResource.Creator.GetName()),
+			CreatedAt:     groupDetail.GetCreatedAt(),
 			NewResource: Openapi.NewResource{
 				Name:         string(groupDetail.MainResource.GetName()),
 				Comment:      string(groupDetail.MainResource.GetComment()),
@@ -383,6 +417,56 @@ func (g *Group) GetGroup(c echo.Context, strGroupID Openapi.GroupIDInPath) error
 	})
 }
 
+func (g *Group) GetGroupArchive(c echo.Context, strGroupID Openapi.GroupIDInPath) error {
+	err := g.checker.check(c)
+	if err != nil {
+		return err
+	}
+
+	session, err := getSession(c)
+	if err != nil {
+		log.Printf("error: failed to get session: %v\n", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to get session")
+	}
+
+	authSession, err := g.session.getAuthSession(session)
+	if err != nil {
+		log.Printf("error: failed to get auth session: %v\n", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to get auth session")
+	}
+
+	uuidGroupID, err := uuid.Parse(string(strGroupID))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid group id")
+	}
+
+	group, reader, err := g.groupServer.GetGroupArchive(
+		c.Request().Context(),
+		authSession,
+		values.NewGroupIDFromUUID(uuidGroupID),
+	)
+	if errors.Is(err, service.ErrNoGroup) {
+		return echo.NewHTTPError(http.StatusNotFound, "group not found")
+	}
+	if errors.Is(err, service.ErrForbidden) {
+		return echo.NewHTTPError(http.StatusForbidden, "forbidden")
+	}
+	if err != nil {
+		log.Printf("error: failed to get group archive: %v\n", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to get group archive")
+	}
+	defer reader.Close()
+
+	// ASCII以外の文字を含むグループ名はfilename*で送る
+	c.Response().Header().Set(echo.HeaderContentDisposition, mime.FormatMediaType("attachment", map[string]string{
+		"filename": string(group.GetName()) + ".zip",
+	}))
+
+	// ヘッダーの送信後はエラーを返せないが、途中で失敗した場合はZIPの末尾の目次が書き込まれないので、
+	// 壊れたZIPとして扱われる
+	return c.Stream(http.StatusOK, "application/zip", reader)
+}
+
 func (g *Group) PatchGroup(c echo.Context, strGroupID Openapi.GroupIDInPath) error {
 	err := g.checker.check(c)
 	if err != nil {
@@ -492,6 +576,14 @@ func (g *Group) PatchGroup(c echo.Context, strGroupID Openapi.GroupIDInPath) err
 	switch groupDetail.MainResource.Resource.GetType() {
 	case values.ResourceTypeImage:
 		resourceType = Openapi.ResourceTypeImage
+	case values.ResourceTypeMusic:
+		resourceType = Openapi.ResourceTypeMusic
+	case values.ResourceTypeVideo:
+		resourceType = Openapi.ResourceTypeVideo
+	case values.ResourceTypeDocument:
+		resourceType = Openapi.ResourceTypeDocument
+	case values.ResourceTypeArchive:
+		resourceType = Openapi.ResourceTypeArchive
 	case values.ResourceTypeOther:
 		resourceType = Openapi.ResourceTypeOther
 	default:
@@ -508,10 +600,13 @@ func (g *Group) PatchGroup(c echo.Context, strGroupID Openapi.GroupIDInPath) err
 		GroupBase:      apiGroup.GroupBase,
 		Administrators: administrators,
 		MainResource: Openapi.Resource{
-			Id:        uuid.UUID(groupDetail.MainResource.Resource.GetID()).String(),
-			FileID:    uuid.UUID(groupDetail.MainResource.File.GetID()).String(),
-			Creator:   string(groupDetail.MainResource.Creator.GetName()),
-			CreatedAt: groupDetail.GetCreatedAt(),
+			Id:            uuid.UUID(groupDetail.MainResource.Resource.GetID()).String(),
+			FileID:        uuid.UUID(groupDetail.MainResource.File.GetID()).String(),
+			ImageMetadata: newOpenapiImageMetadata(groupDetail.MainResource.File),
+			State:         newOpenapiResourceState(groupDetail.MainResource.File),
+			Size:          groupDetail.MainResource.File.GetSize(),
+			Creator:       string(groupDetail.MainResource.Creator.GetName()),
+			CreatedAt:     groupDetail.GetCreatedAt(),
 			NewResource: Openapi.NewResource{
 				Name:         string(groupDetail.MainResource.GetName()),
 				Comment:      string(groupDetail.MainResource.GetComment()),
@@ -623,6 +718,14 @@ func (g *Group) PostResourceToGroup(c echo.Context, strGroupID Openapi.GroupIDIn
 		switch resourceInfo.Resource.GetType() {
 		case values.ResourceTypeImage:
 			resourceType = Openapi.ResourceTypeImage
+		case values.ResourceTypeMusic:
+			resourceType = Openapi.ResourceTypeMusic
+		case values.ResourceTypeVideo:
+			resourceType = Openapi.ResourceTypeVideo
+		case values.ResourceTypeDocument:
+			resourceType = Openapi.ResourceTypeDocument
+		case values.ResourceTypeArchive:
+			resourceType = Openapi.ResourceTypeArchive
 		case values.ResourceTypeOther:
 			resourceType = Openapi.ResourceTypeOther
 		default:
@@ -631,10 +734,13 @@ func (g *Group) PostResourceToGroup(c echo.Context, strGroupID Openapi.GroupIDIn
 		}
 
 		resources = append(resources, Openapi.Resource{
-			Id:        uuid.UUID(resourceInfo.Resource.GetID()).String(),
-			Creator:   string(resourceInfo.Creator.GetName()),
-			FileID:    uuid.UUID(resourceInfo.File.GetID()).String(),
-			CreatedAt: resourceInfo.Resource.GetCreatedAt(),
+			Id:            uuid.UUID(resourceInfo.Resource.GetID()).String(),
+			Creator:       string(resourceInfo.Creator.GetName()),
+			FileID:        uuid.UUID(resourceInfo.File.GetID()).String(),
+			ImageMetadata: newOpenapiImageMetadata(resourceInfo.File),
+			State:         newOpenapiResourceState(resourceInfo.File),
+			Size:          resourceInfo.File.GetSize(),
+			CreatedAt:     resourceInfo.Resource.GetCreatedAt(),
 			NewResource: Openapi.NewResource{
 				Name:         string(resourceInfo.Resource.GetName()),
 				Comment:      string(resourceInfo.Resource.GetComment()),
@@ -0,0 +1,216 @@
+package v1
+
+import (
+	"archive/zip"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/mazrean/Quantainer/domain/values"
+	Openapi "github.com/mazrean/Quantainer/handler/v1/openapi"
+	"github.com/mazrean/Quantainer/pkg/common"
+	"github.com/mazrean/Quantainer/service"
+)
+
+// importMaxMemory ZIPのうちメモリに置くバイト数。超えた分は一時ファイルに書き込まれる
+const importMaxMemory = 32 << 20
+
+type Importer struct {
+	session         *Session
+	checker         *Checker
+	importerService service.Importer
+	// maxBodySize インポート時のリクエストボディのバイト数の上限。0の場合は無制限
+	maxBodySize int64
+}
+
+func NewImporter(
+	session *Session,
+	checker *Checker,
+	importerService service.Importer,
+	maxBodySize common.ImportMaxBodySize,
+) *Importer {
+	return &Importer{
+		session:         session,
+		checker:         checker,
+		importerService: importerService,
+		maxBodySize:     int64(maxBodySize),
+	}
+}
+
+func (i *Importer) PostGroupImport(c echo.Context) error {
+	err := i.checker.check(c)
+	if err != nil {
+		return err
+	}
+
+	session, err := getSession(c)
+	if err != nil {
+		log.Printf("error: failed to get session: %v\n", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to get session")
+	}
+
+	authSession, err := i.session.getAuthSession(session)
+	if err != nil {
+		log.Printf("error: failed to get auth session: %v\n", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to get auth session")
+	}
+
+	if i.maxBodySize > 0 {
+		c.Request().Body = http.MaxBytesReader(c.Response(), c.Request().Body, i.maxBodySize)
+	}
+
+	// ZIPの目次は末尾にあるので、ファイル以外のフィールドと合わせて全て読み込んでから展開する
+	err = c.Request().ParseMultipartForm(importMaxMemory)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Errorf("failed to parse form:%w", err))
+	}
+	defer func() {
+		err := c.Request().MultipartForm.RemoveAll()
+		if err != nil {
+			log.Printf("error: failed to remove form files: %v\n", err)
+		}
+	}()
+
+	var groupType values.GroupType
+	switch Openapi.GroupType(c.FormValue("type")) {
+	case Openapi.GroupTypeArtBook:
+		groupType = values.GroupTypeArtBook
+	case Openapi.GroupTypeOther:
+		groupType = values.GroupTypeOther
+	default:
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid group type")
+	}
+
+	var readPermission values.GroupReadPermission
+	switch Openapi.ReadPermission(c.FormValue("readPermission")) {
+	case Openapi.ReadPermissionPublic:
+		readPermission = values.GroupReadPermissionPublic
+	case Openapi.ReadPermissionPrivate:
+		readPermission = values.GroupReadPermissionPrivate
+	default:
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid read permission")
+	}
+
+	var writePermission values.GroupWritePermission
+	switch Openapi.WritePermission(c.FormValue("writePermission")) {
+	case Openapi.WritePermissionPublic:
+		writePermission = values.GroupWritePermissionPublic
+	case Openapi.WritePermissionPrivate:
+		writePermission = values.GroupWritePermissionPrivate
+	default:
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid write permission")
+	}
+
+	fileHeader, err := c.FormFile("file")
+	if errors.Is(err, http.ErrMissingFile) {
+		return echo.NewHTTPError(http.StatusBadRequest, "no file")
+	}
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Errorf("failed to get file:%w", err))
+	}
+
+	reqFile, err := fileHeader.Open()
+	if err != nil {
+		log.Printf("error: failed to open file: %v\n", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to open file")
+	}
+	defer reqFile.Close()
+
+	zipReader, err := zip.NewReader(reqFile, fileHeader.Size)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid zip")
+	}
+
+	result, err := i.importerService.ImportGroup(
+		c.Request().Context(),
+		authSession,
+		&service.ImportGroupParams{
+			Name:            values.NewGroupName(c.FormValue("name")),
+			GroupType:       groupType,
+			Description:     values.NewGroupDescription(c.FormValue("description")),
+			ReadPermission:  readPermission,
+			WritePermission: writePermission,
+			MainResource:    c.FormValue("mainResource"),
+		},
+		zipReader,
+	)
+	if errors.Is(err, service.ErrInvalidImport) ||
+		errors.Is(err, zip.ErrFormat) ||
+		errors.Is(err, zip.ErrAlgorithm) ||
+		errors.Is(err, zip.ErrChecksum) {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Errorf("invalid zip:%w", err))
+	}
+	if errors.Is(err, service.ErrQuotaExceeded) {
+		return echo.NewHTTPError(http.StatusRequestEntityTooLarge, "quota exceeded")
+	}
+	if errors.Is(err, service.ErrStorageFull) {
+		return echo.NewHTTPError(http.StatusInsufficientStorage, "storage full")
+	}
+	if errors.Is(err, service.ErrFileTooLarge) {
+		return echo.NewHTTPError(http.StatusRequestEntityTooLarge, "file too large")
+	}
+	if errors.Is(err, service.ErrInvalidFormat) {
+		return echo.NewHTTPError(http.StatusUnsupportedMediaType, "file type not allowed")
+	}
+	if errors.Is(err, service.ErrInvalidPermission) {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid permission")
+	}
+	if err != nil {
+		log.Printf("error: failed to import group: %v\n", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to import group")
+	}
+
+	groupDetail := result.Group
+
+	var resourceType Openapi.ResourceType
+	switch groupDetail.MainResource.Resource.GetType() {
+	case values.ResourceTypeImage:
+		resourceType = Openapi.ResourceTypeImage
+	case values.ResourceTypeMusic:
+		resourceType = Openapi.ResourceTypeMusic
+	case values.ResourceTypeVideo:
+		resourceType = Openapi.ResourceTypeVideo
+	case values.ResourceTypeDocument:
+		resourceType = Openapi.ResourceTypeDocument
+	case values.ResourceTypeArchive:
+		resourceType = Openapi.ResourceTypeArchive
+	case values.ResourceTypeOther:
+		resourceType = Openapi.ResourceTypeOther
+	default:
+		return echo.NewHTTPError(http.StatusInternalServerError, "invalid resource type")
+	}
+
+	administrators := make([]string, 0, len(groupDetail.Administers))
+	for _, administrator := range groupDetail.Administers {
+		administrators = append(administrators, string(administrator.GetName()))
+	}
+
+	return c.JSON(http.StatusCreated, &Openapi.GroupDetail{
+		Id: uuid.UUID(groupDetail.Group.GetID()).String(),
+		GroupBase: Openapi.GroupBase{
+			Name:            string(groupDetail.Group.GetName()),
+			Type:            Openapi.GroupType(c.FormValue("type")),
+			Description:     string(groupDetail.Group.GetDescription()),
+			ReadPermission:  Openapi.ReadPermission(c.FormValue("readPermission")),
+			WritePermission: Openapi.WritePermission(c.FormValue("writePermission")),
+		},
+		Administrators: administrators,
+		MainResource: Openapi.Resource{
+			Id:            uuid.UUID(groupDetail.MainResource.Resource.GetID()).String(),
+			FileID:        uuid.UUID(groupDetail.MainResource.File.GetID()).String(),
+			ImageMetadata: newOpenapiImageMetadata(groupDetail.MainResource.File),
+			State:         newOpenapiResourceState(groupDetail.MainResource.File),
+			Size:          groupDetail.MainResource.File.GetSize(),
+			Creator:       string(groupDetail.MainResource.Creator.GetName()),
+			CreatedAt:     groupDetail.MainResource.Resource.GetCreatedAt(),
+			NewResource: Openapi.NewResource{
+				Name:         string(groupDetail.MainResource.GetName()),
+				Comment:      string(groupDetail.MainResource.GetComment()),
+				ResourceType: resourceType,
+			},
+		},
+	})
+}
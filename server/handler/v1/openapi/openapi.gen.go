@@ -20,22 +20,52 @@ import (
 )
 
 const (
+	ShareLinkAuthScopes  = "shareLinkAuth.Scopes"
 	TraPMemberAuthScopes = "traPMemberAuth.Scopes"
 )
 
+// Defines values for ColorSpace.
+const (
+	ColorSpaceCmyk ColorSpace = "cmyk"
+
+	ColorSpaceGray ColorSpace = "gray"
+
+	ColorSpaceRgb ColorSpace = "rgb"
+
+	ColorSpaceUnknown ColorSpace = "unknown"
+)
+
 // Defines values for FileType.
 const (
+	FileTypeAvif FileType = "avif"
+
+	FileTypeFlac FileType = "flac"
+
 	FileTypeGif FileType = "gif"
 
 	FileTypeJpeg FileType = "jpeg"
 
+	FileTypeMp3 FileType = "mp3"
+
+	FileTypeMp4 FileType = "mp4"
+
+	FileTypeOgg FileType = "ogg"
+
 	FileTypeOther FileType = "other"
 
+	FileTypePdf FileType = "pdf"
+
 	FileTypePng FileType = "png"
 
 	FileTypeSvg FileType = "svg"
 
+	FileTypeWav FileType = "wav"
+
+	FileTypeWebm FileType = "webm"
+
 	FileTypeWebp FileType = "webp"
+
+	FileTypeZip FileType = "zip"
 )
 
 // Defines values for GroupType.
@@ -45,6 +75,15 @@ const (
 	GroupTypeOther GroupType = "other"
 )
 
+// Defines values for ImageOrientation.
+const (
+	ImageOrientationLandscape ImageOrientation = "landscape"
+
+	ImageOrientationPortrait ImageOrientation = "portrait"
+
+	ImageOrientationSquare ImageOrientation = "square"
+)
+
 // Defines values for ReadPermission.
 const (
 	ReadPermissionPrivate ReadPermission = "private"
@@ -52,11 +91,42 @@ const (
 	ReadPermissionPublic ReadPermission = "public"
 )
 
+// Defines values for ResourceState.
+const (
+	ResourceStateAvailable ResourceState = "available"
+
+	ResourceStateInfected ResourceState = "infected"
+)
+
 // Defines values for ResourceType.
 const (
+	ResourceTypeArchive ResourceType = "archive"
+
+	ResourceTypeDocument ResourceType = "document"
+
 	ResourceTypeImage ResourceType = "image"
 
+	ResourceTypeMusic ResourceType = "music"
+
 	ResourceTypeOther ResourceType = "other"
+
+	ResourceTypeVideo ResourceType = "video"
+)
+
+// Defines values for ScanStatus.
+const (
+	ScanStatusClean ScanStatus = "clean"
+
+	ScanStatusInfected ScanStatus = "infected"
+)
+
+// Defines values for ShareLinkTargetType.
+const (
+	ShareLinkTargetTypeFile ShareLinkTargetType = "file"
+
+	ShareLinkTargetTypeGroup ShareLinkTargetType = "group"
+
+	ShareLinkTargetTypeResource ShareLinkTargetType = "resource"
 )
 
 // Defines values for WritePermission.
@@ -66,6 +136,48 @@ const (
 	WritePermissionPublic WritePermission = "public"
 )
 
+// Defines values for SizeInQuery.
+const (
+	N1024 SizeInQuery = 1024
+
+	N256 SizeInQuery = 256
+)
+
+// Defines values for TransformFitInQuery.
+const (
+	Contain TransformFitInQuery = "contain"
+
+	Cover TransformFitInQuery = "cover"
+)
+
+// Defines values for TransformFormatInQuery.
+const (
+	Jpeg TransformFormatInQuery = "jpeg"
+
+	Png TransformFormatInQuery = "png"
+
+	Webp TransformFormatInQuery = "webp"
+)
+
+// Defines values for TransformQualityInQuery.
+const (
+	N60 TransformQualityInQuery = 60
+
+	N75 TransformQualityInQuery = 75
+
+	N85 TransformQualityInQuery = 85
+
+	N95 TransformQualityInQuery = 95
+)
+
+// 画像の色空間
+type ColorSpace string
+
+// 重複の可能性が高いリソースのまとまり
+type DuplicateResources struct {
+	Resources []Resource `json:"resources"`
+}
+
 // ファイル
 type File struct {
 	// ファイル作成時刻
@@ -74,13 +186,51 @@ type File struct {
 	// ファイルの作成者
 	Creator string `json:"creator"`
 
+	// アップロードされた時のファイル名の小文字の拡張子。ドットは含まず、ない場合は空文字列
+	Extension string `json:"extension"`
+
 	// ファイルのid
 	Id string `json:"id"`
 
+	// 画像のメタデータ
+	ImageMetadata *ImageMetadata `json:"imageMetadata,omitempty"`
+
+	// アップロードされた時のファイル名。わからない場合は空文字列
+	OriginalName string `json:"originalName"`
+
+	// アップロードされた時のバイト数。
+	// メタデータの除去などで、保存されたファイルのバイト数とは異なることがある。
+	OriginalSize int64 `json:"originalSize"`
+
+	// ファイルのマルウェアの検査の結果。
+	// 検査が設定されていない場合や、検査の導入前にアップロードされたファイルにはない。
+	Scan *FileScan `json:"scan,omitempty"`
+
+	// アップロードした画像に似た画像の既存のリソース。
+	// 重複したアップロードの警告のため、ファイルのアップロード時のみ返す。
+	SimilarResources *[]SimilarResource `json:"similarResources,omitempty"`
+
+	// ファイルのバイト数
+	Size int64 `json:"size"`
+
 	// ファイルの種類
 	Type FileType `json:"type"`
 }
 
+// ファイルのマルウェアの検査の結果。
+// 検査が設定されていない場合や、検査の導入前にアップロードされたファイルにはない。
+type FileScan struct {
+	// 検査した時刻
+	ScannedAt time.Time `json:"scannedAt"`
+
+	// 検出されたマルウェアの名前。感染していない場合はない
+	Signature *string `json:"signature,omitempty"`
+
+	// マルウェアの検査の結果。
+	// infectedの場合ファイルは隔離され、ダウンロードできない。
+	Status ScanStatus `json:"status"`
+}
+
 // ファイルの種類
 type FileType string
 
@@ -117,6 +267,30 @@ type GroupDetail struct {
 	MainResource Resource `json:"mainResource"`
 }
 
+// ZIPからのグループの作成
+type GroupImport struct {
+	// グループの説明。省略した場合はmanifest.jsonのグループの説明
+	Description *string `json:"description,omitempty"`
+
+	// グループに含めるファイルをまとめたZIP
+	File string `json:"file"`
+
+	// メインのリソースにするファイルのZIP内でのパス。省略した場合はmanifest.jsonで指定されたもの、指定がなければ最初のファイル
+	MainResource *string `json:"mainResource,omitempty"`
+
+	// グループ名。省略した場合はmanifest.jsonのグループ名
+	Name *string `json:"name,omitempty"`
+
+	// グループ閲覧権限
+	ReadPermission ReadPermission `json:"readPermission"`
+
+	// グループの種類
+	Type GroupType `json:"type"`
+
+	// ファイル追加権限
+	WritePermission WritePermission `json:"writePermission"`
+}
+
 // GroupInfo defines model for GroupInfo.
 type GroupInfo struct {
 	// Embedded struct due to allOf(#/components/schemas/GroupBase)
@@ -132,6 +306,33 @@ type GroupInfo struct {
 // グループの種類
 type GroupType string
 
+// 画像のメタデータ
+type ImageMetadata struct {
+	// カメラの機種。不明な場合は省略される
+	CameraModel *string `json:"cameraModel,omitempty"`
+
+	// 撮影日時。不明な場合は省略される
+	CapturedAt *time.Time `json:"capturedAt,omitempty"`
+
+	// 画像の色空間
+	ColorSpace ColorSpace `json:"colorSpace"`
+
+	// アニメーションのフレーム数。静止画の場合は1
+	Frames int `json:"frames"`
+
+	// 画像の高さ(px)。EXIFのOrientationによる回転を適用した後の値
+	Height int `json:"height"`
+
+	// EXIFのOrientation。不明な場合は省略される
+	Orientation *int `json:"orientation,omitempty"`
+
+	// 画像の幅(px)。EXIFのOrientationによる回転を適用した後の値
+	Width int `json:"width"`
+}
+
+// 画像の縦横の向き
+type ImageOrientation string
+
 // 新規ファイル
 type NewFile struct {
 	File string `json:"file"`
@@ -161,6 +362,30 @@ type NewResource struct {
 	ResourceType ResourceType `json:"resourceType"`
 }
 
+// 新規共有リンク
+type NewShareLink struct {
+	// 有効期限
+	ExpiresAt time.Time `json:"expiresAt"`
+
+	// ダウンロードできる回数の上限。0または未指定の場合は無制限
+	MaxDownloads *int64 `json:"maxDownloads,omitempty"`
+
+	// 共有するファイル、リソース、グループのid
+	TargetID string `json:"targetID"`
+
+	// 共有するものの種類
+	TargetType ShareLinkTargetType `json:"targetType"`
+}
+
+// 使用量の上限。0の場合は無制限
+type Quota struct {
+	// ファイルの合計バイト数の上限
+	MaxBytes int64 `json:"maxBytes"`
+
+	// ファイル数の上限
+	MaxFiles int64 `json:"maxFiles"`
+}
+
 // グループ閲覧権限
 type ReadPermission string
 
@@ -180,11 +405,166 @@ type Resource struct {
 
 	// リソースid
 	Id string `json:"id"`
+
+	// 画像のメタデータ
+	ImageMetadata *ImageMetadata `json:"imageMetadata,omitempty"`
+
+	// ファイルのバイト数
+	Size int64 `json:"size"`
+
+	// リソースの状態。
+	// infectedの場合、ファイルからマルウェアが検出され隔離されているので、ダウンロードできない。
+	State ResourceState `json:"state"`
+}
+
+// 差し替え後のリソースのファイル
+type ResourceFile struct {
+	// ファイルid
+	FileID string `json:"fileID"`
 }
 
+// リソースの状態。
+// infectedの場合、ファイルからマルウェアが検出され隔離されているので、ダウンロードできない。
+type ResourceState string
+
 // リソースの種類
 type ResourceType string
 
+// リソースのファイルの版
+type ResourceVersion struct {
+	// この版に差し替えた時刻
+	CreatedAt time.Time `json:"createdAt"`
+
+	// ファイルの作成者
+	Creator string `json:"creator"`
+
+	// ファイルid
+	FileID string `json:"fileID"`
+
+	// 画像のメタデータ
+	ImageMetadata *ImageMetadata `json:"imageMetadata,omitempty"`
+
+	// ファイルのバイト数
+	Size int64 `json:"size"`
+
+	// リソースの状態。
+	// infectedの場合、ファイルからマルウェアが検出され隔離されているので、ダウンロードできない。
+	State ResourceState `json:"state"`
+
+	// 版。最初のファイルが1で、差し替えるごとに1増える
+	Version int `json:"version"`
+}
+
+// マルウェアの検査の結果。
+// infectedの場合ファイルは隔離され、ダウンロードできない。
+type ScanStatus string
+
+// 共有リンク
+type ShareLink struct {
+	// 共有リンク作成時刻
+	CreatedAt time.Time `json:"createdAt"`
+
+	// ダウンロードされた回数
+	Downloads int64 `json:"downloads"`
+
+	// 有効期限
+	ExpiresAt time.Time `json:"expiresAt"`
+
+	// 共有リンクのid
+	Id string `json:"id"`
+
+	// ダウンロードできる回数の上限。0の場合は無制限
+	MaxDownloads int64 `json:"maxDownloads"`
+
+	// 取り消されたか
+	Revoked bool `json:"revoked"`
+
+	// 共有するファイル、リソース、グループのid
+	TargetID string `json:"targetID"`
+
+	// 共有するものの種類
+	TargetType ShareLinkTargetType `json:"targetType"`
+
+	// 共有リンクのトークン。tokenクエリパラメーターで指定する
+	Token string `json:"token"`
+}
+
+// 共有するものの種類
+type ShareLinkTargetType string
+
+// 共有リンクで共有されているもの
+type SharedContent struct {
+	// ダウンロードされた回数
+	Downloads int64 `json:"downloads"`
+
+	// 有効期限
+	ExpiresAt time.Time    `json:"expiresAt"`
+	Files     []SharedFile `json:"files"`
+
+	// 共有されているグループ
+	Group *SharedGroup `json:"group,omitempty"`
+
+	// ダウンロードできる回数の上限。0の場合は無制限
+	MaxDownloads int64 `json:"maxDownloads"`
+
+	// 共有するものの種類
+	TargetType ShareLinkTargetType `json:"targetType"`
+}
+
+// 共有されているファイル。ファイルの作成者は含まない
+type SharedFile struct {
+	// リソースのコメント。ファイルを共有している場合は含まない
+	Comment *string `json:"comment,omitempty"`
+
+	// ファイル作成時刻
+	CreatedAt time.Time `json:"createdAt"`
+
+	// ファイルのid
+	Id string `json:"id"`
+
+	// 画像のメタデータ
+	ImageMetadata *ImageMetadata `json:"imageMetadata,omitempty"`
+
+	// リソース名。ファイルを共有している場合は含まない
+	Name *string `json:"name,omitempty"`
+
+	// ファイルのバイト数
+	Size int64 `json:"size"`
+
+	// ファイルの種類
+	Type FileType `json:"type"`
+}
+
+// 共有されているグループ
+type SharedGroup struct {
+	// グループの説明
+	Description string `json:"description"`
+
+	// グループ名
+	Name string `json:"name"`
+}
+
+// SimilarResource defines model for SimilarResource.
+type SimilarResource struct {
+	// Embedded struct due to allOf(#/components/schemas/Resource)
+	Resource `yaml:",inline"`
+	// Embedded fields due to inline allOf schema
+	// 知覚ハッシュの異なるビットの数。小さいほど似ている
+	Distance int `json:"distance"`
+}
+
+// ファイルの使用量。削除したファイルは完全に削除されるまで含み、サムネイルと変換した画像は含まない
+type Usage struct {
+	// ファイルの合計バイト数
+	Bytes int64 `json:"bytes"`
+
+	// ファイル数
+	Files int64 `json:"files"`
+
+	// 使用量の上限。0の場合は無制限
+	Quota Quota `json:"quota"`
+}
+
 // ユーザー
 type User struct {
 	// traQのID（UUID）
@@ -200,9 +580,15 @@ type WritePermission string
 // CodeInQuery defines model for codeInQuery.
 type CodeInQuery string
 
+// ExtensionInQuery defines model for extensionInQuery.
+type ExtensionInQuery []string
+
 // FileIDInPath defines model for fileIDInPath.
 type FileIDInPath string
 
+// FileNameInQuery defines model for fileNameInQuery.
+type FileNameInQuery string
+
 // GroupIDInPath defines model for groupIDInPath.
 type GroupIDInPath string
 
@@ -215,21 +601,110 @@ type GroupTypeInQuery []GroupType
 // LimitInQuery defines model for limitInQuery.
 type LimitInQuery int
 
+// MinHeightInQuery defines model for minHeightInQuery.
+type MinHeightInQuery int
+
+// MinWidthInQuery defines model for minWidthInQuery.
+type MinWidthInQuery int
+
 // OffsetInQuery defines model for offsetInQuery.
 type OffsetInQuery int
 
+// OrientationInQuery defines model for orientationInQuery.
+type OrientationInQuery []ImageOrientation
+
 // ResourceIDInPath defines model for resourceIDInPath.
 type ResourceIDInPath string
 
 // ResourceTypeInQuery defines model for resourceTypeInQuery.
 type ResourceTypeInQuery []ResourceType
 
+// ShareLinkIDInPath defines model for shareLinkIDInPath.
+type ShareLinkIDInPath string
+
+// SizeInQuery defines model for sizeInQuery.
+type SizeInQuery int
+
+// TransformFitInQuery defines model for transformFitInQuery.
+type TransformFitInQuery string
+
+// TransformFormatInQuery defines model for transformFormatInQuery.
+type TransformFormatInQuery string
+
+// TransformHeightInQuery defines model for transformHeightInQuery.
+type TransformHeightInQuery int
+
+// TransformQualityInQuery defines model for transformQualityInQuery.
+type TransformQualityInQuery int
+
+// TransformWidthInQuery defines model for transformWidthInQuery.
+type TransformWidthInQuery int
+
+// TusResumableInHeader defines model for tusResumableInHeader.
+type TusResumableInHeader string
+
+// UploadIDInPath defines model for uploadIDInPath.
+type UploadIDInPath string
+
+// UploadLengthInHeader defines model for uploadLengthInHeader.
+type UploadLengthInHeader int64
+
+// UploadMetadataInHeader defines model for uploadMetadataInHeader.
+type UploadMetadataInHeader string
+
+// UploadOffsetInHeader defines model for uploadOffsetInHeader.
+type UploadOffsetInHeader int64
+
 // UserInQuery defines model for userInQuery.
 type UserInQuery []string
 
+// UserNameInPath defines model for userNameInPath.
+type UserNameInPath string
+
+// GetFileParams defines parameters for GetFile.
+type GetFileParams struct {
+	// サムネイルの長辺のピクセル数。
+	// 指定しない場合や元の画像の方が小さい場合は元のファイルを返す。
+	// サムネイルは透過のない画像はJPEG、透過のある画像はPNGで返す。
+	// サーバーで作れるWebPは可逆圧縮のみで、JPEGやPNGより大きくなるためサムネイルには使わない。
+	// WebPが必要な場合は/files/{fileID}/transformで取得する。
+	Size *GetFileParamsSize `json:"size,omitempty"`
+}
+
+// GetFileParamsSize defines parameters for GetFile.
+type GetFileParamsSize int
+
 // PostResourceJSONBody defines parameters for PostResource.
 type PostResourceJSONBody NewResource
 
+// GetFileTransformParams defines parameters for GetFileTransform.
+type GetFileTransformParams struct {
+	// 変換後の幅(px)
+	W TransformWidthInQuery `json:"w"`
+
+	// 変換後の高さ(px)
+	H TransformHeightInQuery `json:"h"`
+
+	// 幅と高さへの合わせ方。デフォルトはcontain。
+	// containは縦横比を保って収まるよう縮小し、coverは縦横比を保って覆うよう拡大縮小して中央を切り取る。
+	Fit *GetFileTransformParamsFit `json:"fit,omitempty"`
+
+	// 変換後の形式。WebPは可逆圧縮になる
+	Format *GetFileTransformParamsFormat `json:"format,omitempty"`
+
+	// JPEGの品質。デフォルトは85で、JPEG以外では使わない
+	Q *GetFileTransformParamsQ `json:"q,omitempty"`
+}
+
+// GetFileTransformParamsFit defines parameters for GetFileTransform.
+type GetFileTransformParamsFit string
+
+// GetFileTransformParamsFormat defines parameters for GetFileTransform.
+type GetFileTransformParamsFormat string
+
+// GetFileTransformParamsQ defines parameters for GetFileTransform.
+type GetFileTransformParamsQ int
+
 // GetGroupsParams defines parameters for GetGroups.
 type GetGroupsParams struct {
 	// グループの種類
@@ -268,6 +743,21 @@ type GetResourcesParams struct {
 	// グループ
 	Group *GroupInQuery `json:"group,omitempty"`
 
+	// 画像の縦横の向き。指定した場合は幅と高さがわかる画像のリソースのみを返す
+	Orientation *OrientationInQuery `json:"orientation,omitempty"`
+
+	// 画像の最小の幅(px)
+	MinWidth *MinWidthInQuery `json:"minWidth,omitempty"`
+
+	// 画像の最小の高さ(px)
+	MinHeight *MinHeightInQuery `json:"minHeight,omitempty"`
+
+	// アップロードされた時のファイル名に含まれる文字列
+	FileName *FileNameInQuery `json:"fileName,omitempty"`
+
+	// アップロードされた時のファイル名の拡張子。ドットは含まず、大文字小文字を区別しない
+	Extension *ExtensionInQuery `json:"extension,omitempty"`
+
 	// 取得するデータの数
 	Limit *LimitInQuery `json:"limit,omitempty"`
 
@@ -275,6 +765,48 @@ type GetResourcesParams struct {
 	Offset *OffsetInQuery `json:"offset,omitempty"`
 }
 
+// PutResourceFileJSONBody defines parameters for PutResourceFile.
+type PutResourceFileJSONBody ResourceFile
+
+// GetSimilarResourcesParams defines parameters for GetSimilarResources.
+type GetSimilarResourcesParams struct {
+	// 取得するデータの数
+	Limit *LimitInQuery `json:"limit,omitempty"`
+}
+
+// PostShareLinkJSONBody defines parameters for PostShareLink.
+type PostShareLinkJSONBody NewShareLink
+
+// PostUploadParams defines parameters for PostUpload.
+type PostUploadParams struct {
+	// クライアントが使うtusのバージョン。1.0.0のみ対応
+	TusResumable *TusResumableInHeader `json:"Tus-Resumable,omitempty"`
+
+	// アップロードするファイルのバイト数
+	UploadLength *UploadLengthInHeader `json:"Upload-Length,omitempty"`
+
+	// キーとbase64でエンコードした値の組のカンマ区切り
+	UploadMetadata *UploadMetadataInHeader `json:"Upload-Metadata,omitempty"`
+}
+
+// HeadUploadParams defines parameters for HeadUpload.
+type HeadUploadParams struct {
+	// クライアントが使うtusのバージョン。1.0.0のみ対応
+	TusResumable *TusResumableInHeader `json:"Tus-Resumable,omitempty"`
+}
+
+// PatchUploadParams defines parameters for PatchUpload.
+type PatchUploadParams struct {
+	// クライアントが使うtusのバージョン。1.0.0のみ対応
+	TusResumable *TusResumableInHeader `json:"Tus-Resumable,omitempty"`
+
+	// 書き込みを始める位置のバイト数
+	UploadOffset *UploadOffsetInHeader `json:"Upload-Offset,omitempty"`
+}
+
+// PutUserQuotaJSONBody defines parameters for PutUserQuota.
+type PutUserQuotaJSONBody Quota
+
 // PostResourceJSONRequestBody defines body for PostResource for application/json ContentType.
 type PostResourceJSONRequestBody PostResourceJSONBody
 
@@ -284,23 +816,44 @@ type PostGroupJSONRequestBody PostGroupJSONBody
 // PatchGroupJSONRequestBody defines body for PatchGroup for application/json ContentType.
 type PatchGroupJSONRequestBody PatchGroupJSONBody
 
+// PutResourceFileJSONRequestBody defines body for PutResourceFile for application/json ContentType.
+type PutResourceFileJSONRequestBody PutResourceFileJSONBody
+
+// PostShareLinkJSONRequestBody defines body for PostShareLink for application/json ContentType.
+type PostShareLinkJSONRequestBody PostShareLinkJSONBody
+
+// PutUserQuotaJSONRequestBody defines body for PutUserQuota for application/json ContentType.
+type PutUserQuotaJSONRequestBody PutUserQuotaJSONBody
+
 // ServerInterface represents all server handlers.
 type ServerInterface interface {
 	// ファイルのアップロード
 	// (POST /files)
 	PostFile(ctx echo.Context) error
+	// ファイルの削除
+	// (DELETE /files/{fileID})
+	DeleteFile(ctx echo.Context, fileID FileIDInPath) error
 	// ファイルの取得
 	// (GET /files/{fileID})
-	GetFile(ctx echo.Context, fileID FileIDInPath) error
+	GetFile(ctx echo.Context, fileID FileIDInPath, params GetFileParams) error
 	// リソースの作成
 	// (POST /files/{fileID}/resources)
 	PostResource(ctx echo.Context, fileID FileIDInPath) error
+	// 削除したファイルの復元
+	// (POST /files/{fileID}/restore)
+	RestoreFile(ctx echo.Context, fileID FileIDInPath) error
+	// 変換した画像の取得
+	// (GET /files/{fileID}/transform)
+	GetFileTransform(ctx echo.Context, fileID FileIDInPath, params GetFileTransformParams) error
 	// グループの一覧の取得
 	// (GET /groups)
 	GetGroups(ctx echo.Context, params GetGroupsParams) error
 	// グループの作成
 	// (POST /groups)
 	PostGroup(ctx echo.Context) error
+	// ZIPからのグループの作成
+	// (POST /groups/import)
+	PostGroupImport(ctx echo.Context) error
 	// グループの削除
 	// (DELETE /groups/{groupID})
 	DeleteGroup(ctx echo.Context, groupID GroupIDInPath) error
@@ -310,6 +863,9 @@ type ServerInterface interface {
 	// グループの情報の編集
 	// (PATCH /groups/{groupID})
 	PatchGroup(ctx echo.Context, groupID GroupIDInPath) error
+	// グループのZIPでの取得
+	// (GET /groups/{groupID}/archive)
+	GetGroupArchive(ctx echo.Context, groupID GroupIDInPath) error
 	// グループの作成
 	// (POST /groups/{groupID}/resources/{resourceID})
 	PostResourceToGroup(ctx echo.Context, groupID GroupIDInPath, resourceID ResourceIDInPath) error
@@ -325,15 +881,66 @@ type ServerInterface interface {
 	// リソースの情報の取得
 	// (GET /resources)
 	GetResources(ctx echo.Context, params GetResourcesParams) error
+	// 重複の可能性が高いリソースの一覧
+	// (GET /resources/duplicates)
+	GetDuplicateResources(ctx echo.Context) error
+	// リソースの削除
+	// (DELETE /resources/{resourceID})
+	DeleteResource(ctx echo.Context, resourceID ResourceIDInPath) error
 	// リソースの情報の取得
 	// (GET /resources/{resourceID})
 	GetResource(ctx echo.Context, resourceID ResourceIDInPath) error
+	// リソースのファイルの差し替え
+	// (PUT /resources/{resourceID}/file)
+	PutResourceFile(ctx echo.Context, resourceID ResourceIDInPath) error
+	// 削除したリソースの復元
+	// (POST /resources/{resourceID}/restore)
+	RestoreResource(ctx echo.Context, resourceID ResourceIDInPath) error
+	// 似た画像のリソースの取得
+	// (GET /resources/{resourceID}/similar)
+	GetSimilarResources(ctx echo.Context, resourceID ResourceIDInPath, params GetSimilarResourcesParams) error
+	// リソースの版の一覧
+	// (GET /resources/{resourceID}/versions)
+	GetResourceVersions(ctx echo.Context, resourceID ResourceIDInPath) error
+	// 共有リンクの作成
+	// (POST /share-links)
+	PostShareLink(ctx echo.Context) error
+	// 共有リンクの取り消し
+	// (DELETE /share-links/{shareLinkID})
+	DeleteShareLink(ctx echo.Context, shareLinkID ShareLinkIDInPath) error
+	// 共有されているファイルの一覧
+	// (GET /shared)
+	GetSharedContent(ctx echo.Context) error
+	// 再開可能なアップロードの対応状況の取得
+	// (OPTIONS /uploads)
+	OptionsUploads(ctx echo.Context) error
+	// 再開可能なアップロードの作成
+	// (POST /uploads)
+	PostUpload(ctx echo.Context, params PostUploadParams) error
+	// アップロードの状態の取得
+	// (HEAD /uploads/{uploadID})
+	HeadUpload(ctx echo.Context, uploadID UploadIDInPath, params HeadUploadParams) error
+	// アップロードへの書き込み
+	// (PATCH /uploads/{uploadID})
+	PatchUpload(ctx echo.Context, uploadID UploadIDInPath, params PatchUploadParams) error
 	// traQの全ユーザー取得
 	// (GET /users)
 	GetUsers(ctx echo.Context) error
 	// 自分の情報の取得
 	// (GET /users/me)
 	GetMe(ctx echo.Context) error
+	// 自分が作成した共有リンクの一覧
+	// (GET /users/me/share-links)
+	GetMyShareLinks(ctx echo.Context) error
+	// 自分のファイルの使用量の取得
+	// (GET /users/me/usage)
+	GetMyUsage(ctx echo.Context) error
+	// ユーザーの上限のリセット
+	// (DELETE /users/{userName}/quota)
+	DeleteUserQuota(ctx echo.Context, userName UserNameInPath) error
+	// ユーザーの上限の変更
+	// (PUT /users/{userName}/quota)
+	PutUserQuota(ctx echo.Context, userName UserNameInPath) error
 }
 
 // ServerInterfaceWrapper converts echo contexts to parameters.
@@ -352,6 +959,24 @@ func (w *ServerInterfaceWrapper) PostFile(ctx echo.Context) error {
 	return err
 }
 
+// DeleteFile converts echo context to params.
+func (w *ServerInterfaceWrapper) DeleteFile(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "fileID" -------------
+	var fileID FileIDInPath
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "fileID", runtime.ParamLocationPath, ctx.Param("fileID"), &fileID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter fileID: %s", err))
+	}
+
+	ctx.Set(TraPMemberAuthScopes, []string{""})
+
+	// Invoke the callback with all the unmarshalled arguments
+	err = w.Handler.DeleteFile(ctx, fileID)
+	return err
+}
+
 // GetFile converts echo context to params.
 func (w *ServerInterfaceWrapper) GetFile(ctx echo.Context) error {
 	var err error
@@ -365,8 +990,19 @@ func (w *ServerInterfaceWrapper) GetFile(ctx echo.Context) error {
 
 	ctx.Set(TraPMemberAuthScopes, []string{""})
 
+	ctx.Set(ShareLinkAuthScopes, []string{""})
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params GetFileParams
+	// ------------- Optional query parameter "size" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "size", ctx.QueryParams(), &params.Size)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter size: %s", err))
+	}
+
 	// Invoke the callback with all the unmarshalled arguments
-	err = w.Handler.GetFile(ctx, fileID)
+	err = w.Handler.GetFile(ctx, fileID, params)
 	return err
 }
 
@@ -388,14 +1024,89 @@ func (w *ServerInterfaceWrapper) PostResource(ctx echo.Context) error {
 	return err
 }
 
-// GetGroups converts echo context to params.
-func (w *ServerInterfaceWrapper) GetGroups(ctx echo.Context) error {
+// RestoreFile converts echo context to params.
+func (w *ServerInterfaceWrapper) RestoreFile(ctx echo.Context) error {
 	var err error
+	// ------------- Path parameter "fileID" -------------
+	var fileID FileIDInPath
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "fileID", runtime.ParamLocationPath, ctx.Param("fileID"), &fileID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter fileID: %s", err))
+	}
 
 	ctx.Set(TraPMemberAuthScopes, []string{""})
 
-	// Parameter object where we will unmarshal all parameters from the context
-	var params GetGroupsParams
+	// Invoke the callback with all the unmarshalled arguments
+	err = w.Handler.RestoreFile(ctx, fileID)
+	return err
+}
+
+// GetFileTransform converts echo context to params.
+func (w *ServerInterfaceWrapper) GetFileTransform(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "fileID" -------------
+	var fileID FileIDInPath
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "fileID", runtime.ParamLocationPath, ctx.Param("fileID"), &fileID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter fileID: %s", err))
+	}
+
+	ctx.Set(TraPMemberAuthScopes, []string{""})
+
+	ctx.Set(ShareLinkAuthScopes, []string{""})
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params GetFileTransformParams
+	// ------------- Required query parameter "w" -------------
+
+	err = runtime.BindQueryParameter("form", true, true, "w", ctx.QueryParams(), &params.W)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter w: %s", err))
+	}
+
+	// ------------- Required query parameter "h" -------------
+
+	err = runtime.BindQueryParameter("form", true, true, "h", ctx.QueryParams(), &params.H)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter h: %s", err))
+	}
+
+	// ------------- Optional query parameter "fit" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "fit", ctx.QueryParams(), &params.Fit)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter fit: %s", err))
+	}
+
+	// ------------- Optional query parameter "format" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "format", ctx.QueryParams(), &params.Format)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter format: %s", err))
+	}
+
+	// ------------- Optional query parameter "q" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "q", ctx.QueryParams(), &params.Q)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter q: %s", err))
+	}
+
+	// Invoke the callback with all the unmarshalled arguments
+	err = w.Handler.GetFileTransform(ctx, fileID, params)
+	return err
+}
+
+// GetGroups converts echo context to params.
+func (w *ServerInterfaceWrapper) GetGroups(ctx echo.Context) error {
+	var err error
+
+	ctx.Set(TraPMemberAuthScopes, []string{""})
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params GetGroupsParams
 	// ------------- Optional query parameter "type" -------------
 
 	err = runtime.BindQueryParameter("form", true, false, "type", ctx.QueryParams(), &params.Type)
@@ -440,6 +1151,17 @@ func (w *ServerInterfaceWrapper) PostGroup(ctx echo.Context) error {
 	return err
 }
 
+// PostGroupImport converts echo context to params.
+func (w *ServerInterfaceWrapper) PostGroupImport(ctx echo.Context) error {
+	var err error
+
+	ctx.Set(TraPMemberAuthScopes, []string{""})
+
+	// Invoke the callback with all the unmarshalled arguments
+	err = w.Handler.PostGroupImport(ctx)
+	return err
+}
+
 // DeleteGroup converts echo context to params.
 func (w *ServerInterfaceWrapper) DeleteGroup(ctx echo.Context) error {
 	var err error
@@ -494,6 +1216,24 @@ func (w *ServerInterfaceWrapper) PatchGroup(ctx echo.Context) error {
 	return err
 }
 
+// GetGroupArchive converts echo context to params.
+func (w *ServerInterfaceWrapper) GetGroupArchive(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "groupID" -------------
+	var groupID GroupIDInPath
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "groupID", runtime.ParamLocationPath, ctx.Param("groupID"), &groupID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter groupID: %s", err))
+	}
+
+	ctx.Set(TraPMemberAuthScopes, []string{""})
+
+	// Invoke the callback with all the unmarshalled arguments
+	err = w.Handler.GetGroupArchive(ctx, groupID)
+	return err
+}
+
 // PostResourceToGroup converts echo context to params.
 func (w *ServerInterfaceWrapper) PostResourceToGroup(ctx echo.Context) error {
 	var err error
@@ -587,6 +1327,41 @@ func (w *ServerInterfaceWrapper) GetResources(ctx echo.Context) error {
 		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter group: %s", err))
 	}
 
+	// ------------- Optional query parameter "orientation" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "orientation", ctx.QueryParams(), &params.Orientation)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter orientation: %s", err))
+	}
+
+	// ------------- Optional query parameter "minWidth" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "minWidth", ctx.QueryParams(), &params.MinWidth)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter minWidth: %s", err))
+	}
+
+	// ------------- Optional query parameter "minHeight" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "minHeight", ctx.QueryParams(), &params.MinHeight)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter minHeight: %s", err))
+	}
+
+	// ------------- Optional query parameter "fileName" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "fileName", ctx.QueryParams(), &params.FileName)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter fileName: %s", err))
+	}
+
+	// ------------- Optional query parameter "extension" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "extension", ctx.QueryParams(), &params.Extension)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter extension: %s", err))
+	}
+
 	// ------------- Optional query parameter "limit" -------------
 
 	err = runtime.BindQueryParameter("form", true, false, "limit", ctx.QueryParams(), &params.Limit)
@@ -606,6 +1381,35 @@ func (w *ServerInterfaceWrapper) GetResources(ctx echo.Context) error {
 	return err
 }
 
+// GetDuplicateResources converts echo context to params.
+func (w *ServerInterfaceWrapper) GetDuplicateResources(ctx echo.Context) error {
+	var err error
+
+	ctx.Set(TraPMemberAuthScopes, []string{""})
+
+	// Invoke the callback with all the unmarshalled arguments
+	err = w.Handler.GetDuplicateResources(ctx)
+	return err
+}
+
+// DeleteResource converts echo context to params.
+func (w *ServerInterfaceWrapper) DeleteResource(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "resourceID" -------------
+	var resourceID ResourceIDInPath
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "resourceID", runtime.ParamLocationPath, ctx.Param("resourceID"), &resourceID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter resourceID: %s", err))
+	}
+
+	ctx.Set(TraPMemberAuthScopes, []string{""})
+
+	// Invoke the callback with all the unmarshalled arguments
+	err = w.Handler.DeleteResource(ctx, resourceID)
+	return err
+}
+
 // GetResource converts echo context to params.
 func (w *ServerInterfaceWrapper) GetResource(ctx echo.Context) error {
 	var err error
@@ -624,6 +1428,288 @@ func (w *ServerInterfaceWrapper) GetResource(ctx echo.Context) error {
 	return err
 }
 
+// PutResourceFile converts echo context to params.
+func (w *ServerInterfaceWrapper) PutResourceFile(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "resourceID" -------------
+	var resourceID ResourceIDInPath
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "resourceID", runtime.ParamLocationPath, ctx.Param("resourceID"), &resourceID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter resourceID: %s", err))
+	}
+
+	ctx.Set(TraPMemberAuthScopes, []string{""})
+
+	// Invoke the callback with all the unmarshalled arguments
+	err = w.Handler.PutResourceFile(ctx, resourceID)
+	return err
+}
+
+// RestoreResource converts echo context to params.
+func (w *ServerInterfaceWrapper) RestoreResource(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "resourceID" -------------
+	var resourceID ResourceIDInPath
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "resourceID", runtime.ParamLocationPath, ctx.Param("resourceID"), &resourceID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter resourceID: %s", err))
+	}
+
+	ctx.Set(TraPMemberAuthScopes, []string{""})
+
+	// Invoke the callback with all the unmarshalled arguments
+	err = w.Handler.RestoreResource(ctx, resourceID)
+	return err
+}
+
+// GetSimilarResources converts echo context to params.
+func (w *ServerInterfaceWrapper) GetSimilarResources(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "resourceID" -------------
+	var resourceID ResourceIDInPath
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "resourceID", runtime.ParamLocationPath, ctx.Param("resourceID"), &resourceID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter resourceID: %s", err))
+	}
+
+	ctx.Set(TraPMemberAuthScopes, []string{""})
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params GetSimilarResourcesParams
+	// ------------- Optional query parameter "limit" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "limit", ctx.QueryParams(), &params.Limit)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter limit: %s", err))
+	}
+
+	// Invoke the callback with all the unmarshalled arguments
+	err = w.Handler.GetSimilarResources(ctx, resourceID, params)
+	return err
+}
+
+// GetResourceVersions converts echo context to params.
+func (w *ServerInterfaceWrapper) GetResourceVersions(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "resourceID" -------------
+	var resourceID ResourceIDInPath
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "resourceID", runtime.ParamLocationPath, ctx.Param("resourceID"), &resourceID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter resourceID: %s", err))
+	}
+
+	ctx.Set(TraPMemberAuthScopes, []string{""})
+
+	// Invoke the callback with all the unmarshalled arguments
+	err = w.Handler.GetResourceVersions(ctx, resourceID)
+	return err
+}
+
+// PostShareLink converts echo context to params.
+func (w *ServerInterfaceWrapper) PostShareLink(ctx echo.Context) error {
+	var err error
+
+	ctx.Set(TraPMemberAuthScopes, []string{""})
+
+	// Invoke the callback with all the unmarshalled arguments
+	err = w.Handler.PostShareLink(ctx)
+	return err
+}
+
+// DeleteShareLink converts echo context to params.
+func (w *ServerInterfaceWrapper) DeleteShareLink(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "shareLinkID" -------------
+	var shareLinkID ShareLinkIDInPath
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "shareLinkID", runtime.ParamLocationPath, ctx.Param("shareLinkID"), &shareLinkID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter shareLinkID: %s", err))
+	}
+
+	ctx.Set(TraPMemberAuthScopes, []string{""})
+
+	// Invoke the callback with all the unmarshalled arguments
+	err = w.Handler.DeleteShareLink(ctx, shareLinkID)
+	return err
+}
+
+// GetSharedContent converts echo context to params.
+func (w *ServerInterfaceWrapper) GetSharedContent(ctx echo.Context) error {
+	var err error
+
+	ctx.Set(ShareLinkAuthScopes, []string{""})
+
+	// Invoke the callback with all the unmarshalled arguments
+	err = w.Handler.GetSharedContent(ctx)
+	return err
+}
+
+// OptionsUploads converts echo context to params.
+func (w *ServerInterfaceWrapper) OptionsUploads(ctx echo.Context) error {
+	var err error
+
+	// Invoke the callback with all the unmarshalled arguments
+	err = w.Handler.OptionsUploads(ctx)
+	return err
+}
+
+// PostUpload converts echo context to params.
+func (w *ServerInterfaceWrapper) PostUpload(ctx echo.Context) error {
+	var err error
+
+	ctx.Set(TraPMemberAuthScopes, []string{""})
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params PostUploadParams
+
+	headers := ctx.Request().Header
+	// ------------- Optional header parameter "Tus-Resumable" -------------
+	if valueList, found := headers[http.CanonicalHeaderKey("Tus-Resumable")]; found {
+		var TusResumable TusResumableInHeader
+		n := len(valueList)
+		if n != 1 {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Expected one value for Tus-Resumable, got %d", n))
+		}
+
+		err = runtime.BindStyledParameterWithLocation("simple", false, "Tus-Resumable", runtime.ParamLocationHeader, valueList[0], &TusResumable)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter Tus-Resumable: %s", err))
+		}
+
+		params.TusResumable = &TusResumable
+	}
+	// ------------- Optional header parameter "Upload-Length" -------------
+	if valueList, found := headers[http.CanonicalHeaderKey("Upload-Length")]; found {
+		var UploadLength UploadLengthInHeader
+		n := len(valueList)
+		if n != 1 {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Expected one value for Upload-Length, got %d", n))
+		}
+
+		err = runtime.BindStyledParameterWithLocation("simple", false, "Upload-Length", runtime.ParamLocationHeader, valueList[0], &UploadLength)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter Upload-Length: %s", err))
+		}
+
+		params.UploadLength = &UploadLength
+	}
+	// ------------- Optional header parameter "Upload-Metadata" -------------
+	if valueList, found := headers[http.CanonicalHeaderKey("Upload-Metadata")]; found {
+		var UploadMetadata UploadMetadataInHeader
+		n := len(valueList)
+		if n != 1 {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Expected one value for Upload-Metadata, got %d", n))
+		}
+
+		err = runtime.BindStyledParameterWithLocation("simple", false, "Upload-Metadata", runtime.ParamLocationHeader, valueList[0], &UploadMetadata)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter Upload-Metadata: %s", err))
+		}
+
+		params.UploadMetadata = &UploadMetadata
+	}
+
+	// Invoke the callback with all the unmarshalled arguments
+	err = w.Handler.PostUpload(ctx, params)
+	return err
+}
+
+// HeadUpload converts echo context to params.
+func (w *ServerInterfaceWrapper) HeadUpload(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "uploadID" -------------
+	var uploadID UploadIDInPath
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "uploadID", runtime.ParamLocationPath, ctx.Param("uploadID"), &uploadID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter uploadID: %s", err))
+	}
+
+	ctx.Set(TraPMemberAuthScopes, []string{""})
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params HeadUploadParams
+
+	headers := ctx.Request().Header
+	// ------------- Optional header parameter "Tus-Resumable" -------------
+	if valueList, found := headers[http.CanonicalHeaderKey("Tus-Resumable")]; found {
+		var TusResumable TusResumableInHeader
+		n := len(valueList)
+		if n != 1 {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Expected one value for Tus-Resumable, got %d", n))
+		}
+
+		err = runtime.BindStyledParameterWithLocation("simple", false, "Tus-Resumable", runtime.ParamLocationHeader, valueList[0], &TusResumable)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter Tus-Resumable: %s", err))
+		}
+
+		params.TusResumable = &TusResumable
+	}
+
+	// Invoke the callback with all the unmarshalled arguments
+	err = w.Handler.HeadUpload(ctx, uploadID, params)
+	return err
+}
+
+// PatchUpload converts echo context to params.
+func (w *ServerInterfaceWrapper) PatchUpload(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "uploadID" -------------
+	var uploadID UploadIDInPath
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "uploadID", runtime.ParamLocationPath, ctx.Param("uploadID"), &uploadID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter uploadID: %s", err))
+	}
+
+	ctx.Set(TraPMemberAuthScopes, []string{""})
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params PatchUploadParams
+
+	headers := ctx.Request().Header
+	// ------------- Optional header parameter "Tus-Resumable" -------------
+	if valueList, found := headers[http.CanonicalHeaderKey("Tus-Resumable")]; found {
+		var TusResumable TusResumableInHeader
+		n := len(valueList)
+		if n != 1 {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Expected one value for Tus-Resumable, got %d", n))
+		}
+
+		err = runtime.BindStyledParameterWithLocation("simple", false, "Tus-Resumable", runtime.ParamLocationHeader, valueList[0], &TusResumable)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter Tus-Resumable: %s", err))
+		}
+
+		params.TusResumable = &TusResumable
+	}
+	// ------------- Optional header parameter "Upload-Offset" -------------
+	if valueList, found := headers[http.CanonicalHeaderKey("Upload-Offset")]; found {
+		var UploadOffset UploadOffsetInHeader
+		n := len(valueList)
+		if n != 1 {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Expected one value for Upload-Offset, got %d", n))
+		}
+
+		err = runtime.BindStyledParameterWithLocation("simple", false, "Upload-Offset", runtime.ParamLocationHeader, valueList[0], &UploadOffset)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter Upload-Offset: %s", err))
+		}
+
+		params.UploadOffset = &UploadOffset
+	}
+
+	// Invoke the callback with all the unmarshalled arguments
+	err = w.Handler.PatchUpload(ctx, uploadID, params)
+	return err
+}
+
 // GetUsers converts echo context to params.
 func (w *ServerInterfaceWrapper) GetUsers(ctx echo.Context) error {
 	var err error
@@ -646,6 +1732,64 @@ func (w *ServerInterfaceWrapper) GetMe(ctx echo.Context) error {
 	return err
 }
 
+// GetMyShareLinks converts echo context to params.
+func (w *ServerInterfaceWrapper) GetMyShareLinks(ctx echo.Context) error {
+	var err error
+
+	ctx.Set(TraPMemberAuthScopes, []string{""})
+
+	// Invoke the callback with all the unmarshalled arguments
+	err = w.Handler.GetMyShareLinks(ctx)
+	return err
+}
+
+// GetMyUsage converts echo context to params.
+func (w *ServerInterfaceWrapper) GetMyUsage(ctx echo.Context) error {
+	var err error
+
+	ctx.Set(TraPMemberAuthScopes, []string{""})
+
+	// Invoke the callback with all the unmarshalled arguments
+	err = w.Handler.GetMyUsage(ctx)
+	return err
+}
+
+// DeleteUserQuota converts echo context to params.
+func (w *ServerInterfaceWrapper) DeleteUserQuota(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "userName" -------------
+	var userName UserNameInPath
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "userName", runtime.ParamLocationPath, ctx.Param("userName"), &userName)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter userName: %s", err))
+	}
+
+	ctx.Set(TraPMemberAuthScopes, []string{""})
+
+	// Invoke the callback with all the unmarshalled arguments
+	err = w.Handler.DeleteUserQuota(ctx, userName)
+	return err
+}
+
+// PutUserQuota converts echo context to params.
+func (w *ServerInterfaceWrapper) PutUserQuota(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "userName" -------------
+	var userName UserNameInPath
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "userName", runtime.ParamLocationPath, ctx.Param("userName"), &userName)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter userName: %s", err))
+	}
+
+	ctx.Set(TraPMemberAuthScopes, []string{""})
+
+	// Invoke the callback with all the unmarshalled arguments
+	err = w.Handler.PutUserQuota(ctx, userName)
+	return err
+}
+
 // This is a simple interface which specifies echo.Route addition functions which
 // are present on both echo.Echo and echo.Group, since we want to allow using
 // either of them for path registration
@@ -675,69 +1819,192 @@ func RegisterHandlersWithBaseURL(router EchoRouter, si ServerInterface, baseURL
 	}
 
 	router.POST(baseURL+"/files", wrapper.PostFile)
+	router.DELETE(baseURL+"/files/:fileID", wrapper.DeleteFile)
 	router.GET(baseURL+"/files/:fileID", wrapper.GetFile)
 	router.POST(baseURL+"/files/:fileID/resources", wrapper.PostResource)
+	router.POST(baseURL+"/files/:fileID/restore", wrapper.RestoreFile)
+	router.GET(baseURL+"/files/:fileID/transform", wrapper.GetFileTransform)
 	router.GET(baseURL+"/groups", wrapper.GetGroups)
 	router.POST(baseURL+"/groups", wrapper.PostGroup)
+	router.POST(baseURL+"/groups/import", wrapper.PostGroupImport)
 	router.DELETE(baseURL+"/groups/:groupID", wrapper.DeleteGroup)
 	router.GET(baseURL+"/groups/:groupID", wrapper.GetGroup)
 	router.PATCH(baseURL+"/groups/:groupID", wrapper.PatchGroup)
+	router.GET(baseURL+"/groups/:groupID/archive", wrapper.GetGroupArchive)
 	router.POST(baseURL+"/groups/:groupID/resources/:resourceID", wrapper.PostResourceToGroup)
 	router.GET(baseURL+"/oauth2/callback", wrapper.Callback)
 	router.GET(baseURL+"/oauth2/generate/code", wrapper.GetGeneratedCode)
 	router.POST(baseURL+"/oauth2/logout", wrapper.PostLogout)
 	router.GET(baseURL+"/resources", wrapper.GetResources)
+	router.GET(baseURL+"/resources/duplicates", wrapper.GetDuplicateResources)
+	router.DELETE(baseURL+"/resources/:resourceID", wrapper.DeleteResource)
 	router.GET(baseURL+"/resources/:resourceID", wrapper.GetResource)
+	router.PUT(baseURL+"/resources/:resourceID/file", wrapper.PutResourceFile)
+	router.POST(baseURL+"/resources/:resourceID/restore", wrapper.RestoreResource)
+	router.GET(baseURL+"/resources/:resourceID/similar", wrapper.GetSimilarResources)
+	router.GET(baseURL+"/resources/:resourceID/versions", wrapper.GetResourceVersions)
+	router.POST(baseURL+"/share-links", wrapper.PostShareLink)
+	router.DELETE(baseURL+"/share-links/:shareLinkID", wrapper.DeleteShareLink)
+	router.GET(baseURL+"/shared", wrapper.GetSharedContent)
+	router.OPTIONS(baseURL+"/uploads", wrapper.OptionsUploads)
+	router.POST(baseURL+"/uploads", wrapper.PostUpload)
+	router.HEAD(baseURL+"/uploads/:uploadID", wrapper.HeadUpload)
+	router.PATCH(baseURL+"/uploads/:uploadID", wrapper.PatchUpload)
 	router.GET(baseURL+"/users", wrapper.GetUsers)
 	router.GET(baseURL+"/users/me", wrapper.GetMe)
+	router.GET(baseURL+"/users/me/share-links", wrapper.GetMyShareLinks)
+	router.GET(baseURL+"/users/me/usage", wrapper.GetMyUsage)
+	router.DELETE(baseURL+"/users/:userName/quota", wrapper.DeleteUserQuota)
+	router.PUT(baseURL+"/users/:userName/quota", wrapper.PutUserQuota)
 
 }
 
 // Base64 encoded, gzipped, json marshaled Swagger object
 var swaggerSpec = []string{
 
-	"H4sIAAAAAAAC/+xb3XMT1xX/Vzy3favklWSYgjJ5ADylnjbhIzCZKcN0rlZX0sJ+5e5dE9ejGVYKxIFQ",
-	"/NBAmaYlEMYYuxHEZJpSm+aPuUiWn/IvdO7dXe33h4Tc4tAXZsTuPefcc37nd849d70MRE3RNRWpxADV",
-	"ZaBDDBVEEOa/RK2OFtQzJsJL7GcdGSKWdCJpKqiCU8dM0qrMlqjVY++BApDYf3/E3y4AFSoIVIHzCKOP",
-	"TAmjOqgSbKICMMQWUiATSpZ09p5BsKQ2QbtdAA1JRgvzC+ppSFpRtbT7Be08oJ1HtLtJrZ5UdxXr7PWR",
-	"XltIquaGhhVIQBWYJpcStaSJNVNPMaXzjBnR3aHdu0l2OCKmYkhSJPx2JISBCwB+pckiuLU5bTq3pKNc",
-	"dlGrt7ve23vwtwQDuXy/fRJBCgfhzzFqgCr4meAhVbBfM4STrg2gPTIRYgyXuIWypEgk0br+7Tv9f9+l",
-	"1j3auUm7nzIzOz9Qqzf44lmCjVweiAGvpBLURJgr1RoNA42v1V6WoHj0MFUzRoZmYjE1dzZo5yXX+SIJ",
-	"sJ6U18SsKygdIj6L9gciZ31mxKLENBBOsc9jm91723ufbw2vXkuwjwmKty8ok2B4ZmH+x52V8+cX5qn1",
-	"mFob1PpkcOfFjzufgQJAH0NFl5lABf4BI6hGvRvZRdtVy9X9SmLL07YCCkDHmo4wkZDN9BhBgurHSPq6",
-	"Vy+/HKysDu51+ivbAVMrpfLRYulosXL4XOlo9XC5Olf+nZ9F6pCgIpEUFLcZrlzDmVxva7cDkMtLUj1f",
-	"/fCEodohWDnyy3qxfLh+tHioUa4UjzQajWKtXjpypFau1OCRUjY7uv+RjkwWJhuVbX+mXQCeSM83BV+I",
-	"Lo4UarVLSCRM4UhY1oZHKYZUU2HaLumoyfCgsn+voBovE4vsR1NqgALQSAthn05vk5x9j0MDpVP/7vPt",
-	"0e6p1auxBWH8BVZn1JHhxt8Hf/5jCH6Vcv/Fd9Tq0c5z2r1PO99S6/Fw60n/6y1q3aXWfYLh6ZmTGOot",
-	"SZw5ockyErn0mG3Z6ZxmRH/1VkB9gvAZZlacBoxg/TTCimQYzobTGSzwdk50BUrjFSwRlF/jh6HXwwDl",
-	"HhpB1O+nyN6iuuPQy42dRwRKMrMNyvKpBqheyLFDDr92YTmEJ1hXJFUyCGbJY2S3Jr0Hu6vXHWp3WTtn",
-	"krsknEA3QUVcUKZgBUqqW7fy1rd4FglIKoTdEg3FxUJG7j15vvvds0H3Wv+rb0eBW1Ab2nTC9qZ7cEoO",
-	"SyDqhHbZJWqIyXFNu5xKye+jK/HVf3Dn2XDtdmoP0HAWjpxbk1TIG5y45s5zE18Xl9Xvoyt8u9PBhj8Q",
-	"C/Nxdc7e2HNWBXxtZR64eE1vJlds9lc3aedqqHHl3fKkzBHyZ2ijQevGRKATBn8qxOMi4K9Qb6gpClJJ",
-	"ZvPOS+8DFoDuyhh11SckXFfZ0Yg92KLdLu2u0A7fFXt7s5wWxnM56mPwXBBf4ALyCiNHxGH9bKSkJwdl",
-	"787WcO3xYP3J3r1VX37rZk2WRO5+aRESFJvg/kjmyyl/+KNZldr4e5E5YI2/M/1JlbtPnX/8mSN07p62",
-	"2rjC5Z0cRrOwtCNElEb8nOBDXtIpI/4g74JbUmATpZau8+z4HCN3jQv9B+3uRJgpztfsdM0J2Tlghw/V",
-	"04pzPJu9/tk+LpRcVxzrfBjt65MBP/zhZf/GV5PQTrsADCSaWCJLHzBWsb3PTj3vIaWG8DHTnjbxqYio",
-	"aZcl5I1FDMRtM7ytQl36DXLGFpLTO4qaSqDIWchZ53nIxDKoghYhulEVhKZEWmZtVtQUwXlFOGNClUBJ",
-	"RZg5JegB7xm1esdOLzAzJMJj4D2asR8sImy7EZRnS7MlJkzTkQp1CVTB3GxptsLcBEmL719geWVPzTWD",
-	"ZJIY7TzkZewu7X7D6wADBEMzZO8v1EEVnNYMwts3GwPIIMe1+pLrHqcGK6ZMJB1iIjCMFuuQ8GGTN3bK",
-	"KAZcQbvtjOh0TTXsTVRK5ZAmqOuyJHLzhEuGDa98alwd4WAMVlb7N+4zvx4qlRJI5CntrDMS6a5Qq9d/",
-	"+bC/c5tanw83HlHra2qtUesT2rlpiyjHifiGlVqnEbzrLOBZyNYcjlP76l8rgy/v87c3uPh12n3C+MYP",
-	"fF5nw5C/cJHxpmEqCmuU84WcwKbhtc1MhQ0kYdnm6TYzsImyAWWPkiMgOok8DAXCW0oJryYSRIoGwQgq",
-	"wTDnOQ1wchf4IGnCtbo68VI+sppsrSE0pcbEa43F5i8+VuSE9fYILbw4MSNeE2kjLITQVQjc6CV0it4r",
-	"QuDurX0xCk7B7Ykj14VjCy8k8maglbCbv1iu9E02kvlychYLdM77S5h+PW8facbHOwBl7zDmcCa/fjSS",
-	"uTI4THn1z6vDtceppHnSFjhuxkSuQdkZK2ON/8opx+uBe8wc7wevIJmzx6gEUdzmv4nlg8DY26lkRL95",
-	"cMxCjotL+06dc2w8j4UEJfPYSed2fp9IzBa/zwzmH+G/jSSWEOwwWDzqEpadb0Patl0yItlD4f5nN/bu",
-	"PYqAaJ6v9mAUzfaDm4CjLUfzLg/z26P3bOYHr8mSU8qNNz0aUXfG0OH4BTTYFkIitvIHdvf79b2/XI8S",
-	"K5Pyv2PW/6NnKU/M8vCjd+YQlr27kPb4B5AQ1LI7qcgnVSmnltzVfjRI1RKIpzz99sx3AzBWd/a2l2oN",
-	"mqRVEUQoyzUoXk48bvCPYd07sB0ufpV2u7TzNAKBE66scQt1zGNRq6Oo36m1Tq1bE3py5KbULbm+sv0z",
-	"Aev7Py225wyOq5tIZd5C/I1Mf7OXfi+2oCwjtYn4xm+m13pHfP2E+2GyLwRzpbmkENCrHedmYbhxq3/7",
-	"6e6ftvf++pBam3vW97uPt50POq92QAG0EKw7H1B/gEjxhD0PD+Ssdw/gTsffhTWxjsqVuUOH35lhVPOu",
-	"8M7MrwnRT6ly3BSqPaXoZjkwEmhfqGStqZkkbQBup/lD2lmz74SjZPhbW8bBb1oje431XGB6ljDkDUxD",
-	"crSvZ0cyx03CuE90pz++CHy8/hMZd0xaT9/04Vtyex8avyW3ZFNG9X6ey/JOXX8SUZuUHELXASz5k9nL",
-	"qZH9a+v+i/vkKJ/n0v4bGcs/Lzjo2ZrhXjfq/C8R7Dzl4RKU5E5q+OlGf+V6vpx8b1+z0Y7QAYtIivvC",
-	"0eCi8aKbft5XDVVBkDURyi3NINW5UqkkQF0SFsu8ljlSRp9FOO0Eq4v+vzvx/eb3Jb7f2P/1V+DvwdoX",
-	"2/8JAAD//yFodCIBOAAA",
+	"H4sIAAAAAAAC/+x963fTxvbov8LSvR9+v3UcbIcEaM46Hyj0kXtLoTxu72rLOkuxlUQHW3IlGUJZWcuS",
+	"EwjEIWmABEpogIY8SGNT6KGBBPLHTOTHp/Mv3DUzeo00I8t5AOHyBWJbM7O1Z+89+z2XuZSczcmSIGkq",
+	"13WZy/EKnxU0QUGfUnJa6Ja+yQvKJfgxLagpRcxpoixxXdyJI3mtv31/Auhl+BwX40T49Y/o6Rgn8VmB",
+	"6+KsnxThx7yoCGmuS1PyQoxTU/1CloeTapdy8DlVU0SpjxscjHHCgCZIqihLzKWB8QgUi6A4DYoroLgO",
+	"iteAfhsYJaDPVu8aQC+D4m1gPATGHCgumxNjQC9XRx+a6/82VyZAwYDPw+EjQK+YE8tAfwP0X0BBN+cW",
+	"qlNXzZVp8+k4/gMYk2bplTnyGOjTQH8C9CHGazowc953EzUhixApDPDZXAY+mFPTXMz/0s4XvKLwlxAS",
+	"esWM0H2sWzrJa/0UBHjeD+hlMW2DlYOPO1DhSULR3ysrWV7jurh8XqRAZkHyNZ8VdnA3li2sGyVgjFoo",
+	"H5lmoNZen8Bslh/4SpD6IG7aOztpYPcpcj4XgkHjKcQdBHeahT5rim3iD8/CRp4LBwMBaALi7dlTIGgj",
+	"wnTmUk6IBBfQy7XFcuPhrwwA0fxUuv+fitDLdXH/I+5KmTh+TI1/YcNApf+MmBU1JnTm+JT5Zhrod4Ex",
+	"CopXIZjGBuTy208ZMKL5OIrgESVN6BMUtGhWlL4UxL5+9sK1W2tmcRyuNFMwn8I/Gst3gH77v3ID/81Y",
+	"2ZmUJGBRErP5LNeVjDEg+VZMa/0tAGK+HA6HAk3YChByb68qtL4JeBgDDufH0I2QFVGQNF4LOwQcDNRW",
+	"56uLTyAGJn4G+hgoGNXSVbP8C5LZs+aDP80JJOlfDgN9EW8X0EvAGAf6KDBGnXlA8QkwXqPXeAk/6hvA",
+	"mKxv3AL6XdbLuGC2Tv/dWb5POOGZgcYGiqDKeSUVehS4ULMEmTvLNmWZPVG46CDxuAui45QHDCra1H5e",
+	"Eb4SpfNsvJnDf1RnrkFYi8+BUQk5RT2TbRN9qvhTmMT9Nyg+AMUx51hv3P6r/uYVosxbEERjDRSXq7ef",
+	"goLxg+ShcaiXWGRuDJnDRYh0RzxMvQR6CUmI2+5jegU/RugRNrGj6QPQVBqF8YZ+AzEGXNBeofK/Tn72",
+	"BSjonp8ND1dVTn79BdAX/DOvg+IE/Fdf2Hw9g/WAb4WekxCw8UqjcMWcWaitli0u1BdAQUfLGENwOmME",
+	"GNfNuQXI7Po4BMcYBfosMPQA1BDwzdcbiNkh1AgCvFLJ3Biuz+tAf+IgJQ51DTV+GetNg3FN4SUVbivQ",
+	"FwhpB2dhUDTcY4KiBQlK2O/bOw/Gkon2jnNUUeus9HnYqUdIsFUk8UbQq92D2wwV26toR5fQ0Q3fKCVL",
+	"Gi9KCGD7b72CJWa1cgsYk5sb94H+G9DnzfEbSCcbhfjVr9RWy4hqpkFBT8kXBIU1rj5/BehX8CCoZc8t",
+	"uEP1+c3VFXOuDPXokatw18anmuCvV9Ro6OMs6LkYh6DhztEYzEUj4kU2JueuVcfvmW9KEIevH5nr46Bg",
+	"UAlwGZMXC1i0DBXef+WEPi7G5ST470WhJ9cE4iaKhxfipipHfxSLi0p+3+T5jKhdYoKBuFAvmzf1+vMn",
+	"VIo73Ony6+baY3NuCn4muZAB9o80RB5MxA51xg53xj7pbMI64QoTseWhqtLFlrGXV08Jaj7L92SEbulL",
+	"gU8LCk2+V0BxCQomaC89R9gqQazoV7S8ioQxEonGKiguwAcKRnJ/AtnXQN8wK2/MjRkb4n68hgPymbza",
+	"5oBAYtGxPNFk1GMpn8vIfDrMVgoaeMzT0p5sm0clngbbeGE4DUKG1VHCPoaYhX+PeOwDPwrPogXb8Ioc",
+	"FVhR0g52cFQixOAeFzQ+zWt8GMAITn2xh1eFgx2QNYxFpIE8t+FHSmthDh7i/x6CwBvLiFx+RZ4IKESb",
+	"vIINBRfuZsEgn7B0fBbA1XurQB+rv1nH6rC5MIqO2dHN12O11+XWcHsiqPtHwq0qKCHKprvTtbtrjdKz",
+	"emGYwdhwIrqySc6pKfw33cf+sz5y9mz3MSS9oNCqTr38z/o1LuZhqSz/kyLwUiSHDlwcu1HoTNZkURqn",
+	"WTO25F4btH9E731UzsjK6RyfEkLsq/q1Z7WlV42pm1zMFspcH3ytGKf09cADOXvpPBfj8tJ5Sb4oUc65",
+	"GHcsn8uIKV4TbNWdgvbG1bH63FUonscr9eLramEB6CV01g0FTLM3QF+E/yJmyClyTlA0EU+qeFdoyZxg",
+	"WGA2ar/3TO2+o9zzLyGlwaGfixkhnEADsKYUgdeE9BEtfNzm65nqyET1rmGOrBEE2J5IftKW+KStvfNM",
+	"4pOuzmTXgeR3XgdQmteENk1ENBLYE7S4rDT1LuLVMVtFon3XGboTnlvXGRvNi0vYQ3qltvTK62F0XwBr",
+	"ZgHgxXQ0d6s7kdDTwbcfPpRuS3amP2nr6E22tx3u7e1t60knDh/uSbb38IcTzb1yMU6EDgFHeEfxHjgP",
+	"Y5dJnyjxGSQRto/3gmE7SK5FRWgqr6iysp+BVxu+0+JPW4PPPWawGVl8CN1NHr9T4+6ceWMNQbuEddDN",
+	"jfvmyh1nphCtAImTSu32U6zuA/0m+qaEjVnbXnHeNZnoONx56GCs+fkFxS0vNdtNKDpOw+fg82JWzPBK",
+	"iKCkIQtqDbbEXt5cX/d8LFenHyE0kB4u+E62yJ1G5jNFy6uvzJs/X4dDsX1d0P1IDIyy9kvf8Nj8XCya",
+	"JD5NvntQIGMfSlMO9SklW9o3/E3zfcNOKN9J4XK4K2djtm+AYNUYETsiuCTmOR9YB85pi7qaoONX+Icx",
+	"D4wFuGF6uTo3U51dQzrmRPXXGexRsr4r1RdXkGsJs808PH9JHxMo6M4E5tMb5vBj8xoM64RwMQkRdMt4",
+	"HDKBcxHyjEQ/F+2Fp7Fo2NETURX7JF7LKwJ1WfPqK8/r+DFqToxBJBSM6tBsdfYm9n74UWe/NQHyt6K0",
+	"/4ygavs/6z565NQ/vzz2aVuSCp3Ga/nmLJTipdP4ST9RWhPEPOhlkdWZSzkKEnxk5Xh22T6PGKdegB/6",
+	"xF5I3Vo/0sCzuQPwUx96jL8A9yfDp9APHXhkFk6ThmN+EuEs/AWxl6pYoijSp7wqhIewas/XHFwBvQyt",
+	"rwDREaObxMPqT36v3rnho7z2pPnyTyQTn4PiLDD+gI7PZ0vmb88wuWoKf3LfFwqf6xdT+47KmYyQskIH",
+	"gdeSGKe4C4Q5MUYsz5h8HwSLtoIi8OmTgpIVVVtTC1eRiacjikcixHdRETUh+orf+h73E7PEuzwcI/AU",
+	"eLfg2jSyR8AeEzRezEDY+EzmRC/X9X2EN0TkNxi77KMnPg3ja6qmQOmvNg+xlh/WJq5Y1qt9WDZVGv3H",
+	"o5huuhCaqOnEWV6UnHM4sgFFOwaJmWJ+tAS34lysCe8tPa/9+bRaHDYf/OFsXHc2JyuU4+K77pO2Flv2",
+	"zYPtmh0QA6Bg1Gb02u3HvohjlpfEXijY/6XKUnB9R4YEcN9LtySJ4SiHArlifEEcyzg2dKDPftd90rvX",
+	"PaLEK5doK/p32y/38fTPA1HSZaq/7bvuk+aVYeTEKIPiz1jbjIKiBTuqZR+zBtIlC7r9fQmdnz+jX5/C",
+	"0PfIfZ/xsmVpupVdxEL4g5OtllBtKkYtSmVK026pV94ZWfq+i7UdkmIMtYuRi2OrXbyifSrL5x0Fi6Yn",
+	"dfudCwxnn8+sDnqs+Kyg8MfltJChAboMxxeXoIWxNFtbLIOCsbk6BmWkJ8xqs9ltHPgl1Jjur45+1nbo",
+	"+AGqw4rPQeWcbhZMls3Xf1SnH0Pzs9VFt+dGI3yoYfTk8bZCIQ8zLVnW/SjC4zow/rKCQVjIFX+HXxYf",
+	"YCdI4/7d6sqj2i1khtnvmSTsXZph24+zkdg04MYXQcH47P92fw70sidRBRl6I8AYNe/9Wl97BIzJhr5U",
+	"u7VoyU0cYSvMkXb34QQNFG8CTQAeysotbmwScukATnE6HAtNd4pxF1F6FBsrVtBwx1DySXuCGu7wCpqL",
+	"VspWv51AZhENQXU08RtILoqcPuWRKxleSqspHp0FULtSeByb/zHPKwJVynwtXKR7watTT+vz46G+cFvp",
+	"aaqt+HDEPIK+Fi4iobozJ5BX3Hcfi64jRTmU3PwsNZraV/AFRLqPbcNo8OHT96IkdC2ec9Y2sDVLmy4I",
+	"fPlOHDmbFSStaZ4Zsrof4sh6C0qgZxK/SY0OwnVgPLPiDAZ6K/j0cjJsG89EUN/IFDa6bUvMF3MQwaD1",
+	"03aaGgvLvoy3AKKFgZyoCCr1dJ25Zl5/WZ2Zbdyd2LmTM8sPHJMvSjA2TKP7YgH614rPPc5ElPKFpCzy",
+	"2Zc3V6837sJgUAJZPbNAr1RnntjGgnso1oYemiMvfMAnExQ/sHNGUM8rjVf6BI3G/hZyg9ZQQfeSGPy4",
+	"FbUVLxyFsBwyOOMOCej37k+ed4p5KIBGY9/kZZrquPl6o3ZrsXF13LcfdOz7herAp5c0qhZEWpTmxEh9",
+	"cYSM2FirkVt66MChjuTh9o6WtzbLD8Cjqwko7IUTrVJTQOxaqPCAQtuFUwHbki1+G1PP6vML1cUlC1rr",
+	"XM/lezJiCm2GeIHX6Ee5V2ZHOz29gj54foaGul0G2WOhbqvCJnTe3QoXpyMkg79vUeq3Fr2DsY7Ih/Bp",
+	"9DDVyneDd04xlUvJTkRP1Ug2YitHng3ychldazb/KgN9unpvA+gj2Ijw6zzNFOp3QpsUBb37GEOYeTeg",
+	"aSXB9RfVYRyMF6VeIaUJafeY8Uemkc/XH6UrecN4jV9uNe49IsKcMPJfxrkDTPWDCFs6LpgLvJixki9t",
+	"2ELFKivARi+dsJdBzOcNpOVVJMgviGlB5mJcWk7lkY4Y43gl1S9eCJft/0dQGIcIm8wgWNdGWspk0m/i",
+	"QdB68VD0LgRwP0g5/+EK3Bh3gUWBkFwKBtXDD/RSEvMoQU2Qd2+hrJ3lpPloGn/lfZv2pjqYDY1f2MuK",
+	"/Vc0sR/jPFF4CvKjZGMEJRyBggohvVoVV6kMpvNQURViUDYzJUOkgW/o7uh76dbMSivahM1KLhK5v3Vj",
+	"WUw3xWULQZCdNLzphl4EHCrCBfm8kKZWlgLjevXFiLM1QB91p+iRZUS/e9ggj3GafF6Qomwp9DxBUCu4",
+	"JgSNg59g3cATGF0tLtnBgg1cUmf7P+5iARiuneHXQ8DEIjgGfMTjZTV3Q5slrtFQEr6BOA5M0YiQ99d1",
+	"lXFWbTtboKWPypJG9ScGUL9gg0BqiAiWYNrAhydyem1/SLTcTYRdZMhQ8lL6bE988ymw0/79EVO76HuL",
+	"yFe9TG+QB+lMBiKplxCFBksv9uTUW/mK23fK+5czJm0Ipx3w3PpkcnW6pv+uKiei1gjssm4fKaKxo1jf",
+	"ijXxNlOt1Shp014pE5FpyCYtO5A2uuWEz/DD3AoceSehYsCXZh/ZxRvi302LqsZL1EKy2cf1+V9AcRwU",
+	"iyih4TE8yJ1Si+JNu4ynjNManGYFQF8D+hIqZrA2ojVzzgEpgmPOVzLh99OdVfk+yqsFxKcdCikY5rXr",
+	"jbtzVmkFabqZ5ZI5DO1U+xkrdQGx3gLiwQ1QCDYzWMQ1zGSxR7ic7tlidGWrXoDeSCGUplG44MQ/2sGn",
+	"MPLEESo/GfRYYRUMmz0VjS/OqtSa3eI8IoQXoLgeQLCYppdyoqC8Vc3pLxvdKc8QXWZsv3qVJmDRWjSc",
+	"fRtMPWTvfX3jtXn9wVYCUvDwEVJ5RdQunYa7jbHvtGaBvejQdtB6zNjGjaWS5sT/LSCdFKbMHxeyPYJC",
+	"DE/J8nlRcMerAnozNTgFhEq0chxRf4oUUkescS5+80qG6+L6NS2ndsXjfaLWn+/Zn5KzceuR+Dd5HnW3",
+	"wLRO4s/9DejlIye7IRiilhGIn/bhHxx3mlXtPxjj5Jwg8TmR6+IO7E/sb4dI5rV+hL24w605WdWan+uB",
+	"6h4OTa/gPk1pros7Kava57ZF9mNeULVP5fQlGz2WwpjNZzQxxytaHFJ4m636uDXLTYKM2MQYxGSq5mRJ",
+	"xS/Rnkj6VuJzuOpYlKU4zN6Nvoy9hn8zqiMT5vVZiNeORIKheyHT3HiJjzSru4heqj+Zw/1SrJMMTZGk",
+	"TbECT30rlYgoIkJjkgfCJRRxDDkWkD4aLN3DtrTtNC3bDXVuu6OMyfqLYeSp90Kd7IxWkliqL/5hjleC",
+	"dWT2wmT4ajDGddJwuvlqpDoz6zQ6Qn6PJSiK0YBDjEZK61CvMF6aw4ubr2/ScUJ5O4+UQbqQX0J8fw5q",
+	"Dmo+m4WZadE4ROP7VDdPDS7hazOE3yAjaE0VjEX/JhqT5rhRG16w3VyE7WVpF7hYlmXmLTplJ3azpZI1",
+	"zjam0QT2dzCqdqg6/bgxdROqHm+WUB8p9CSsvIauevNNiangWG5oUmgcQ+/uiA2Coyn08O5ZMHEgeoAJ",
+	"IscwPEheQP4jd66OZnOVzJU75syiwwBbYJRtkjXexSApx7g+ofmxYTXOQlQU7txEuCENsNkAuaPJCNpE",
+	"WdHPYcq68ZsnYRorPuMhjc7cosyCTgMt6F8q254lnAg04oAAPdQPcLOsA4kOZ4Xq/Yebay821+5AcP2E",
+	"uRgUyebwSOPBCnxPj1J/ipf6hMDoigWBG9+xHJttx0Q1J6uinZesV1ou/jcmT31+dN/B9oMHPeyzYJcF",
+	"33URTw7TS6yeAcE3FdNAX7YPgmVzYwbodyA8ToMHYxKh7WfoJMWltfqyZ+2AFPlCsDUPbw9jhj3rPhL3",
+	"9uQbPEcXQAyVQk5pgtamaorAZ0nVIkoOM/L9xFHJ7BbH5qQtD0XFuVsbq8ZhMe9Wx6oX+v42kM0wxuNi",
+	"YUrPGtYR0J44GBRAmGECxWS1ypB575krkfRld54DNDnc3dv2tSwJbcd5LdXvJJh297Ydl9Niryik206L",
+	"UkqAjgvE53YVwHVop9/7E/HA9Ns+qK4j7vuFIdI80tZAtRQrvzGPtEjxECx+fPE1G1Wt5N/M+pXBLR6N",
+	"HclElKiaN2KCumrBILon7jhdXR2x3DDu24QcCCVb0V5u6LfsvXBV5taptOTuzs6f9rGA6dxMBUAsQ1MB",
+	"WpO2RFvzwXNBZThOtG/a1uQxpllLqMlOGXDQlPVULbPN2a0bmUTC7O7as9513jubdte1Wfp+E6TsCSGf",
+	"G6STpSbjxiC7QpRsd3FQTVw0J0pIcSPtK32WeNPimi/4YFlrrduDXiuPpX6dwvj5aMUFdpTaACf6yUUi",
+	"Hx5YUzdRfeENoN+wXsoYfQtsxKZQi7Ki+DrclsoQVKr5iFrMFtdgo+fiGurJW1z7AhVcWiEPYxJxxDSi",
+	"kTUneOTxXy0SfZH1BU/cZD7Qxxm1YEWWHDnlf5kvhwdwKew+73z/DW2v3x/COtnCL8C4jiZp23ewY+Bg",
+	"ByiuJdsPDyTbD4PiWnvnwYH2zoOguNaZbB/oTLbvQ52L4cPWF/DxRHvHAPwHDkh0HB6A/+yzmh3/IP0g",
+	"Yd0YGJP0ht965UgqJeQ0ULyDwC5YKUB3btTm3HZFFvNYZim0Un+QyEcc5rB6K5BtxEt23197UavpN/T4",
+	"GCW7zS/q9U10EceIt7IaoSG6Aoq/ecKAXr/QFpwDDkEQboGCHqqpLdNsZ5Y5ecYh11Y1HXpb4sFY9IFk",
+	"R+hWRn4ubm0Y0TS7lZG+ttER7Og9Z/2GqE3vtfFIk5XLlghxJKbHyrFlpZs8zHTXf7RJo9qkNC8FTW4v",
+	"4zbf+HRydvT9Nm7fhXVKS4TYTRsVZS+qTJXF31hqtVCfX/BCFDhbvsATtgpa4N6mCELa2z47wuPExUsR",
+	"nicvCWrRgxo0YqNfHYWaCwWbGYSat++fbdqMcmxatrKaB5lODUZvs6BTAyFv9zwaePpddmd4e/X9/+jR",
+	"YGy2n1hc0RUXnf54dPqxW7aVzYkhWiEl6ZHw+hmMSbw+tiuwRQCHkJ3oCJDdIY4V9l33ydq9PzdXR5Ee",
+	"RLweauEHfR5Av2NvVNnXlc1q1EwGnMhoOA4iFdeIlGR9cXN1HujPGw+uoCHMPnf4og4Eqm/pZYq+oVes",
+	"tnd6Gc6sL6NVYLGJN8CFzOkhn5qB42/+JF4i9tUo3NpcXUF27R/V29O+fnWw77WzIeTc8O2JrTOsJoL6",
+	"PIrXz3t0hLt0u8iRIFbDxV1L9PEu8oFIE5yFg8i5bF4ZNssvCTf/x3QgX6wfdTrak3lBUbqOhgjry9bN",
+	"o+GpQeS8TpIGLbnGPfNb8cu+7+deIC/FVZKiqOm492JzNZ3bpkq7Q6Lnfd+NIDopumvr1g4ZO4HOlOgb",
+	"W/trsXHvSvAMg7O8OzX4I/VcirJnUeRj3O5yEdEyx1pOeB+VYAtlMoktoOgxdEZj0srm0qH/qVoehbGC",
+	"wDNWgRruWwrXgSoh1hlrt5+Y4395EqHmLS3SSb+iKbjGEFlzp3tr+hwt36/Dus2k8cxb9JEFMFuxJnY9",
+	"7Y37v5rDvzemRgNHY4XVEN2NRFKy/5hi+4hFGi3xH7xtYOccw+9VaJNErrc1GOliTHQ0G/wuMlIpJuHC",
+	"bp41VGnj5KbEL7tNMQdbzwnwLdbcyRa4BjokuyWyI8hpRSQz1JzkznvuQq5a++jFCTEMZD6v9bfHU3wm",
+	"08OnzjPPuxNwKbvueh1NP4HirZUACRy159qBdI2UnBZo9u4ibCi8NUw6aAp9JRtXGD9b4HsIueu89qC6",
+	"T5AgtgT0RFN8w4f+mernMxkBZfUtQvMv1LKwpk8fhdP7tuBA4gBrC2AHEFxeWH8yZo5XarfWGvcfocjM",
+	"X7WFNcdLxMWsWzjRhKcFre0oLmsjeNYtBrSL3P7B96TSQrL9QEfn3/dBUfOP+N/3falpuRNShnr67dDu",
+	"NkNgYKM9W5WR++S8FlbHhtn8EYpwjVCF4Vd4jr1vIgfelYo5IsuSUcVBaJcRjGX3xrpWmdDb6Hj3Ilv4",
+	"1I3+vKc5fQujsqLUasZHVpRazvWAQUp8nWzkIc49cx9YNPCjTsHMdWX7ZHzZrq5mm7Zv6mWLBvfqAEpn",
+	"h1J942egj4OCvrX7fN0oKC6s8v2yEjQxQ0xoZDC/QDNt3Zqk3F38NsiasuyuR7upliOR9vq2CDk68WBq",
+	"iULafqONXXTLrqOlpJK3UkfLjjAuevNq/I4RskkdduFsrk0FJimF3tT1BGWr6mjgsn+gJ/eTWN0waEW8",
+	"rdUDk68ziqLDBo412rNsqWaYqM/YqxnnLIraSt0wsaXvom6Ywjh0zozttNa5m1GaqNUzH4Q6sFXl3ec8",
+	"o4vduH3Lz7bXiHG5vNZa52pjEufto1bUlrz1txAOl/KWZAcFvX71Sf0VTPdkXcbtu/CYXOeaR7b57vdB",
+	"V5gUqlNPbUD9vY/rDxfdvP2C3tBvoFvPaQ8bhrl+G+hjtRe/oAJ0ZsNErCGNv0HyIoA0t/CAyDJBME7D",
+	"AP61ERzJcAq/GbkjeY3od787wTdiiV0OwL2Vsjp2qkaJ2i8ehXNwvQreC2+WL0FK1A74b+14C4fOmGRz",
+	"lje9ZaGlQ5GOh3d+UvrrqTzColXFduuVii0EGchaMPLQ91YZ0vRoXOeA6NtfIkfqnWEJecYkGwTr1j20",
+	"2W9sldSrTbamxVOqH8ntgglTvjdhNr4wJu35UIb/xnB9XneyF0MrKz/quuHVlc1U347EJ81KN4IbSeS5",
+	"7aHqTApHtixHVNwkdDteoCEfz0K9aeNnxzsTKMXEU9rlhP4kDbd/89IrGMEeHjNHpsl6Q5qB4Gt22rp3",
+	"mvSBvhWXpg/mPeHZfD9N0pC+ru/UDrI6NUaOv2Ad21G5GQxkTgzRbIaKrwrbTuoPtwdCC9ixuUE0n2Vy",
+	"oO+6obfjPvUt+pGFdkpXxQQW5nXdIc5BNYBtGVE6H9KjFAJvXVsOCXkCFNft82O5hWs/3K7odiNFu5bS",
+	"qqUIqn2L1DAE1F8KequJbdYiBPt5iziBXjmQqE4/RpfAvfE0DQqNXcC4tnuJz66lurpLDJKtgzUlL+xm",
+	"3YZv4b2gAlMvNdmeIkyZ8R0IiWARciCfCfGzpXN6eDt+2Sn2bRKXCa7hrWmGPdcZQDBtO3f4PSdthhZo",
+	"ILlob1pfocjZMt25070XROclCArptdwc0qXM4NGUZupuTVuZNL+axRMFb6bLLQQD2VQ7iLj9aBedouRC",
+	"rYdMmmGPKJnriHyFhy0aWzPw93RvhWZdFCJTIUOK53PO3Uwygo5y9YOWV/cl98P7l06cPNN94uvTPvEX",
+	"INUTeKazOfdWMYJQO0JSI8/k1TZL4QfFNfjpMzvpyfp8nB9oOy3+JPj6JeG+G/CC2ee641LwpSqaV8Ya",
+	"U6M4HwEF04Pu6TIxT2hLCro26yALXWEjyhKuF7abw4xSF/WoqETlL+3Zs6e+AsbkVzLmbB8WvJ2bUKVM",
+	"9d4q0Mfqb9ZRBk7JyumlzSumgTEppuHPwSv37GDb5sZ9c+UO6YyFV3yiq409a21gA7W9o3rXaEzdJH8p",
+	"2WQfhKJSe/Bn9behJrkDUC3G1NV6U6e8ekpQ81l402+39CVKsI2UqYiW+0qQ+rT+1sfZdz65I8/Rter3",
+	"7q6C9uAYu8MN8bSWV62Lo6CYX8UtvHe4vjlqQfOeKzaOIpfoTS8JKR6/jP+wlHCYPR4moL787MgxPwkV",
+	"DC+rWgcheSEYMCYx77WdQKmiVAkUYFlI9zvKsuf2cBSFvb/+HD1GLVdwvF93xwxESFN9dodYeteraijo",
+	"sW6O37keUTarNK9Ndjnm5JEzR78MsgzJDnArx2qvy/gEtBI3jEmXr4xCyPnsjTyGHcDT1Ib8xqR7oVAb",
+	"TJ9o606zNQR6WfU7OVhPWFnnBHdH8XXhdPW/baeRPyW/pOOjKIkiSmgBWR8zlJqfJ7CDT6F+9U9fK933",
+	"SfPYyc4q9iUfsFSG1WFmZy5j6minOZFQFybaAqsBFb7UKDyrPyxtrq7szbudIrwkQ6FSrbI7qnPIKt+D",
+	"AtxDTewE17NotrcRMoMr7fmOek3Qa28X3CPvdsWz7CLP+tUn5siVaOnIx3c1ERnv0B7bkRD0heyGP/wX",
+	"ujMlr9+D4ui2XakeP52Tc4b8gsYQxa+nl+1fcZ+0AsOzevySEx94O4zqCXztcW5taQNZXkibYvL2Jb3h",
+	"tBItY9t7qizah3sY41/CdwTvKvfDBfYs+7PxG0EgXIb/wYLXwbhzFTC7fovU0myVAF4RB2FYggCgPtjV",
+	"kTVszvhig2Z5tv6wFBpRwYFBKJDxrcO7XzneQnEeI5HEi5Z3kkhC2xe97G1OHqSBlk1zi1CaV4pEpBJo",
+	"iNtN2a1Mq9bJ5WReI2ll51Mx7NuvWRUPe8Ei/RDIGdMKRZihBZQLNh27t0J3xeMZOcVn+mVV6zqQSCTi",
+	"fE6MX0gi4rVmca6Vtvo4DMacb/KqoHg/I1PE81nx3tpvfYeby3i+wCfq4LnB/zcADodVARPKAAA=",
 }
 
 // GetSwagger returns the content of the embedded swagger specification file
@@ -811,3 +2078,4 @@ func GetSwagger() (swagger *openapi3.T, err error) {
 	}
 	return
 }
+
@@ -0,0 +1,147 @@
+package v1
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/mazrean/Quantainer/domain/values"
+	Openapi "github.com/mazrean/Quantainer/handler/v1/openapi"
+	"github.com/mazrean/Quantainer/service"
+)
+
+type Quota struct {
+	session      *Session
+	checker      *Checker
+	quotaService service.Quota
+}
+
+func NewQuota(session *Session, checker *Checker, quotaService service.Quota) *Quota {
+	return &Quota{
+		session:      session,
+		checker:      checker,
+		quotaService: quotaService,
+	}
+}
+
+func (q *Quota) GetMyUsage(c echo.Context) error {
+	err := q.checker.check(c)
+	if err != nil {
+		return err
+	}
+
+	session, err := getSession(c)
+	if err != nil {
+		log.Printf("error: failed to get session: %v\n", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to get session")
+	}
+
+	authSession, err := q.session.getAuthSession(session)
+	if err != nil {
+		log.Printf("error: failed to get auth session: %v\n", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to get auth session")
+	}
+
+	usageInfo, err := q.quotaService.GetMyUsage(c.Request().Context(), authSession)
+	if err != nil {
+		log.Printf("error: failed to get usage: %v\n", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to get usage")
+	}
+
+	return c.JSON(http.StatusOK, Openapi.Usage{
+		Bytes: usageInfo.Usage.Bytes,
+		Files: usageInfo.Usage.Files,
+		Quota: Openapi.Quota{
+			MaxBytes: usageInfo.Limit.MaxBytes,
+			MaxFiles: usageInfo.Limit.MaxFiles,
+		},
+	})
+}
+
+func (q *Quota) PutUserQuota(c echo.Context, userName Openapi.UserNameInPath) error {
+	err := q.checker.check(c)
+	if err != nil {
+		return err
+	}
+
+	session, err := getSession(c)
+	if err != nil {
+		log.Printf("error: failed to get session: %v\n", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to get session")
+	}
+
+	authSession, err := q.session.getAuthSession(session)
+	if err != nil {
+		log.Printf("error: failed to get auth session: %v\n", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to get auth session")
+	}
+
+	var apiQuota Openapi.Quota
+	err = c.Bind(&apiQuota)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
+	}
+
+	err = q.quotaService.SetUserQuota(
+		c.Request().Context(),
+		authSession,
+		values.NewTrapMemberName(string(userName)),
+		&service.QuotaLimit{
+			MaxBytes: apiQuota.MaxBytes,
+			MaxFiles: apiQuota.MaxFiles,
+		},
+	)
+	if errors.Is(err, service.ErrInvalidFormat) {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid quota")
+	}
+	if errors.Is(err, service.ErrForbidden) {
+		return echo.NewHTTPError(http.StatusForbidden, "forbidden")
+	}
+	if errors.Is(err, service.ErrNoUser) {
+		return echo.NewHTTPError(http.StatusNotFound, "user not found")
+	}
+	if err != nil {
+		log.Printf("error: failed to set user quota: %v\n", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to set user quota")
+	}
+
+	return c.NoContent(http.StatusOK)
+}
+
+func (q *Quota) DeleteUserQuota(c echo.Context, userName Openapi.UserNameInPath) error {
+	err := q.checker.check(c)
+	if err != nil {
+		return err
+	}
+
+	session, err := getSession(c)
+	if err != nil {
+		log.Printf("error: failed to get session: %v\n", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to get session")
+	}
+
+	authSession, err := q.session.getAuthSession(session)
+	if err != nil {
+		log.Printf("error: failed to get auth session: %v\n", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to get auth session")
+	}
+
+	err = q.quotaService.ResetUserQuota(
+		c.Request().Context(),
+		authSession,
+		values.NewTrapMemberName(string(userName)),
+	)
+	if errors.Is(err, service.ErrForbidden) {
+		return echo.NewHTTPError(http.StatusForbidden, "forbidden")
+	}
+	if errors.Is(err, service.ErrNoUser) {
+		return echo.NewHTTPError(http.StatusNotFound, "user not found")
+	}
+	if err != nil {
+		log.Printf("error: failed to reset user quota: %v\n", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to reset user quota")
+	}
+
+	return c.NoContent(http.StatusOK)
+}
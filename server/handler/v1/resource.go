@@ -2,11 +2,14 @@ package v1
 
 import (
 	"errors"
+	"fmt"
 	"log"
 	"net/http"
+	"unicode/utf8"
 
 	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
+	"github.com/mazrean/Quantainer/domain"
 	"github.com/mazrean/Quantainer/domain/values"
 	Openapi "github.com/mazrean/Quantainer/handler/v1/openapi"
 	"github.com/mazrean/Quantainer/service"
@@ -63,6 +66,14 @@ func (r *Resource) PostResource(c echo.Context, strFileID Openapi.FileIDInPath)
 	switch newResource.ResourceType {
 	case Openapi.ResourceTypeImage:
 		resourceType = values.ResourceTypeImage
+	case Openapi.ResourceTypeMusic:
+		resourceType = values.ResourceTypeMusic
+	case Openapi.ResourceTypeVideo:
+		resourceType = values.ResourceTypeVideo
+	case Openapi.ResourceTypeDocument:
+		resourceType = values.ResourceTypeDocument
+	case Openapi.ResourceTypeArchive:
+		resourceType = values.ResourceTypeArchive
 	case Openapi.ResourceTypeOther:
 		resourceType = values.ResourceTypeOther
 	default:
@@ -92,11 +103,14 @@ func (r *Resource) PostResource(c echo.Context, strFileID Openapi.FileIDInPath)
 	}
 
 	return c.JSON(http.StatusCreated, &Openapi.Resource{
-		Id:          uuid.UUID(resource.Resource.GetID()).String(),
-		Creator:     string(resource.Creator.GetName()),
-		FileID:      string(strFileID),
-		CreatedAt:   resource.Resource.GetCreatedAt(),
-		NewResource: newResource,
+		Id:            uuid.UUID(resource.Resource.GetID()).String(),
+		Creator:       string(resource.Creator.GetName()),
+		FileID:        string(strFileID),
+		CreatedAt:     resource.Resource.GetCreatedAt(),
+		ImageMetadata: newOpenapiImageMetadata(resource.File),
+		State:         newOpenapiResourceState(resource.File),
+		Size:          resource.File.GetSize(),
+		NewResource:   newResource,
 	})
 }
 
@@ -140,6 +154,14 @@ func (r *Resource) GetResource(c echo.Context, resourceID Openapi.ResourceIDInPa
 	switch resource.Resource.GetType() {
 	case values.ResourceTypeImage:
 		resourceType = Openapi.ResourceTypeImage
+	case values.ResourceTypeMusic:
+		resourceType = Openapi.ResourceTypeMusic
+	case values.ResourceTypeVideo:
+		resourceType = Openapi.ResourceTypeVideo
+	case values.ResourceTypeDocument:
+		resourceType = Openapi.ResourceTypeDocument
+	case values.ResourceTypeArchive:
+		resourceType = Openapi.ResourceTypeArchive
 	case values.ResourceTypeOther:
 		resourceType = Openapi.ResourceTypeOther
 	default:
@@ -148,10 +170,13 @@ func (r *Resource) GetResource(c echo.Context, resourceID Openapi.ResourceIDInPa
 	}
 
 	return c.JSON(http.StatusOK, &Openapi.Resource{
-		Id:        uuid.UUID(resource.Resource.GetID()).String(),
-		Creator:   string(resource.Creator.GetName()),
-		FileID:    uuid.UUID(resource.File.GetID()).String(),
-		CreatedAt: resource.Resource.GetCreatedAt(),
+		Id:            uuid.UUID(resource.Resource.GetID()).String(),
+		Creator:       string(resource.Creator.GetName()),
+		FileID:        uuid.UUID(resource.File.GetID()).String(),
+		ImageMetadata: newOpenapiImageMetadata(resource.File),
+		State:         newOpenapiResourceState(resource.File),
+		Size:          resource.File.GetSize(),
+		CreatedAt:     resource.Resource.GetCreatedAt(),
 		NewResource: Openapi.NewResource{
 			Name:         string(resource.Resource.GetName()),
 			Comment:      string(resource.Resource.GetComment()),
@@ -199,6 +224,14 @@ func (r *Resource) GetResources(c echo.Context, params Openapi.GetResourcesParam
 			switch resourceType {
 			case Openapi.ResourceTypeImage:
 				resourceTypes = append(resourceTypes, values.ResourceTypeImage)
+			case Openapi.ResourceTypeMusic:
+				resourceTypes = append(resourceTypes, values.ResourceTypeMusic)
+			case Openapi.ResourceTypeVideo:
+				resourceTypes = append(resourceTypes, values.ResourceTypeVideo)
+			case Openapi.ResourceTypeDocument:
+				resourceTypes = append(resourceTypes, values.ResourceTypeDocument)
+			case Openapi.ResourceTypeArchive:
+				resourceTypes = append(resourceTypes, values.ResourceTypeArchive)
 			case Openapi.ResourceTypeOther:
 				resourceTypes = append(resourceTypes, values.ResourceTypeOther)
 			default:
@@ -230,15 +263,69 @@ func (r *Resource) GetResources(c echo.Context, params Openapi.GetResourcesParam
 		group = &groupID
 	}
 
+	var imageOrientations []values.ImageOrientation
+	if params.Orientation != nil {
+		imageOrientations = make([]values.ImageOrientation, 0, len(*params.Orientation))
+		for _, imageOrientation := range *params.Orientation {
+			switch imageOrientation {
+			case Openapi.ImageOrientationLandscape:
+				imageOrientations = append(imageOrientations, values.ImageOrientationLandscape)
+			case Openapi.ImageOrientationPortrait:
+				imageOrientations = append(imageOrientations, values.ImageOrientationPortrait)
+			case Openapi.ImageOrientationSquare:
+				imageOrientations = append(imageOrientations, values.ImageOrientationSquare)
+			default:
+				return echo.NewHTTPError(http.StatusBadRequest, "invalid orientation")
+			}
+		}
+	}
+
+	var minWidth int
+	if params.MinWidth != nil {
+		if *params.MinWidth < 1 {
+			return echo.NewHTTPError(http.StatusBadRequest, "invalid min width")
+		}
+		minWidth = int(*params.MinWidth)
+	}
+
+	var minHeight int
+	if params.MinHeight != nil {
+		if *params.MinHeight < 1 {
+			return echo.NewHTTPError(http.StatusBadRequest, "invalid min height")
+		}
+		minHeight = int(*params.MinHeight)
+	}
+
+	var fileName string
+	if params.FileName != nil {
+		fileName = string(*params.FileName)
+		if utf8.RuneCountInString(fileName) > maxFileNameQueryLength {
+			return echo.NewHTTPError(http.StatusBadRequest, "too long file name")
+		}
+	}
+
+	var extensions []values.FileExtension
+	if params.Extension != nil {
+		extensions = make([]values.FileExtension, 0, len(*params.Extension))
+		for _, extension := range *params.Extension {
+			extensions = append(extensions, values.NewFileExtension(extension))
+		}
+	}
+
 	resourceInfos, err := r.resourceService.GetResources(
 		c.Request().Context(),
 		authSession,
 		&service.ResourceSearchParams{
-			ResourceTypes: resourceTypes,
-			Users:         users,
-			Group:         group,
-			Limit:         limit,
-			Offset:        offset,
+			ResourceTypes:     resourceTypes,
+			Users:             users,
+			Group:             group,
+			ImageOrientations: imageOrientations,
+			MinWidth:          minWidth,
+			MinHeight:         minHeight,
+			FileName:          fileName,
+			Extensions:        extensions,
+			Limit:             limit,
+			Offset:            offset,
 		},
 	)
 	if errors.Is(err, service.ErrNoUser) {
@@ -258,6 +345,14 @@ func (r *Resource) GetResources(c echo.Context, params Openapi.GetResourcesParam
 		switch resourceInfo.Resource.GetType() {
 		case values.ResourceTypeImage:
 			resourceType = Openapi.ResourceTypeImage
+		case values.ResourceTypeMusic:
+			resourceType = Openapi.ResourceTypeMusic
+		case values.ResourceTypeVideo:
+			resourceType = Openapi.ResourceTypeVideo
+		case values.ResourceTypeDocument:
+			resourceType = Openapi.ResourceTypeDocument
+		case values.ResourceTypeArchive:
+			resourceType = Openapi.ResourceTypeArchive
 		case values.ResourceTypeOther:
 			resourceType = Openapi.ResourceTypeOther
 		default:
@@ -266,10 +361,13 @@ func (r *Resource) GetResources(c echo.Context, params Openapi.GetResourcesParam
 		}
 
 		resources = append(resources, Openapi.Resource{
-			Id:        uuid.UUID(resourceInfo.Resource.GetID()).String(),
-			Creator:   string(resourceInfo.Creator.GetName()),
-			FileID:    uuid.UUID(resourceInfo.File.GetID()).String(),
-			CreatedAt: resourceInfo.Resource.GetCreatedAt(),
+			Id:            uuid.UUID(resourceInfo.Resource.GetID()).String(),
+			Creator:       string(resourceInfo.Creator.GetName()),
+			FileID:        uuid.UUID(resourceInfo.File.GetID()).String(),
+			ImageMetadata: newOpenapiImageMetadata(resourceInfo.File),
+			State:         newOpenapiResourceState(resourceInfo.File),
+			Size:          resourceInfo.File.GetSize(),
+			CreatedAt:     resourceInfo.Resource.GetCreatedAt(),
 			NewResource: Openapi.NewResource{
 				Name:         string(resourceInfo.Resource.GetName()),
 				Comment:      string(resourceInfo.Resource.GetComment()),
@@ -280,3 +378,450 @@ func (r *Resource) GetResources(c echo.Context, params Openapi.GetResourcesParam
 
 	return c.JSON(http.StatusOK, resources)
 }
+
+func (r *Resource) DeleteResource(c echo.Context, strResourceID Openapi.ResourceIDInPath) error {
+	err := r.checker.check(c)
+	if err != nil {
+		return err
+	}
+
+	session, err := getSession(c)
+	if err != nil {
+		log.Printf("error: failed to get session: %v\n", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to get session")
+	}
+
+	authSession, err := r.session.getAuthSession(session)
+	if err != nil {
+		log.Printf("error: failed to get auth session: %v\n", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to get auth session")
+	}
+
+	uuidResourceID, err := uuid.Parse(string(strResourceID))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid resource id")
+	}
+
+	err = r.resourceService.DeleteResource(
+		c.Request().Context(),
+		authSession,
+		values.NewResourceIDFromUUID(uuidResourceID),
+	)
+	if errors.Is(err, service.ErrNoResource) {
+		return echo.NewHTTPError(http.StatusNotFound, "resource not found")
+	}
+	if errors.Is(err, service.ErrForbidden) {
+		return echo.NewHTTPError(http.StatusForbidden, "forbidden")
+	}
+	if err != nil {
+		log.Printf("error: failed to delete resource: %v\n", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to delete resource")
+	}
+
+	return c.NoContent(http.StatusOK)
+}
+
+func (r *Resource) RestoreResource(c echo.Context, strResourceID Openapi.ResourceIDInPath) error {
+	err := r.checker.check(c)
+	if err != nil {
+		return err
+	}
+
+	session, err := getSession(c)
+	if err != nil {
+		log.Printf("error: failed to get session: %v\n", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to get session")
+	}
+
+	authSession, err := r.session.getAuthSession(session)
+	if err != nil {
+		log.Printf("error: failed to get auth session: %v\n", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to get auth session")
+	}
+
+	uuidResourceID, err := uuid.Parse(string(strResourceID))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid resource id")
+	}
+
+	err = r.resourceService.RestoreResource(
+		c.Request().Context(),
+		authSession,
+		values.NewResourceIDFromUUID(uuidResourceID),
+	)
+	if errors.Is(err, service.ErrNoResource) {
+		return echo.NewHTTPError(http.StatusNotFound, "deleted resource not found")
+	}
+	if errors.Is(err, service.ErrForbidden) {
+		return echo.NewHTTPError(http.StatusForbidden, "forbidden")
+	}
+	if errors.Is(err, service.ErrFileDeleted) {
+		return echo.NewHTTPError(http.StatusConflict, "file is deleted")
+	}
+	if errors.Is(err, service.ErrRestorePeriodExpired) {
+		return echo.NewHTTPError(http.StatusGone, "restore period expired")
+	}
+	if err != nil {
+		log.Printf("error: failed to restore resource: %v\n", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to restore resource")
+	}
+
+	return c.NoContent(http.StatusOK)
+}
+
+func (r *Resource) GetSimilarResources(c echo.Context, strResourceID Openapi.ResourceIDInPath, params Openapi.GetSimilarResourcesParams) error {
+	err := r.checker.check(c)
+	if err != nil {
+		return err
+	}
+
+	session, err := getSession(c)
+	if err != nil {
+		log.Printf("error: failed to get session: %v\n", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to get session")
+	}
+
+	authSession, err := r.session.getAuthSession(session)
+	if err != nil {
+		log.Printf("error: failed to get auth session: %v\n", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to get auth session")
+	}
+
+	uuidResourceID, err := uuid.Parse(string(strResourceID))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid resource id")
+	}
+
+	limit := defaultSimilarResourceLimit
+	if params.Limit != nil {
+		if *params.Limit < 1 {
+			return echo.NewHTTPError(http.StatusBadRequest, "invalid limit")
+		}
+		limit = int(*params.Limit)
+	}
+
+	similarResourceInfos, err := r.resourceService.GetSimilarResources(
+		c.Request().Context(),
+		authSession,
+		values.NewResourceIDFromUUID(uuidResourceID),
+		limit,
+	)
+	if errors.Is(err, service.ErrNoResource) {
+		return echo.NewHTTPError(http.StatusNotFound, "resource not found")
+	}
+	if err != nil {
+		log.Printf("error: failed to get similar resources: %v\n", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to get similar resources")
+	}
+
+	similarResources, err := newOpenapiSimilarResources(similarResourceInfos)
+	if err != nil {
+		log.Printf("error: failed to convert similar resources: %v\n", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "invalid resource type")
+	}
+
+	return c.JSON(http.StatusOK, similarResources)
+}
+
+func (r *Resource) GetDuplicateResources(c echo.Context) error {
+	err := r.checker.check(c)
+	if err != nil {
+		return err
+	}
+
+	session, err := getSession(c)
+	if err != nil {
+		log.Printf("error: failed to get session: %v\n", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to get session")
+	}
+
+	authSession, err := r.session.getAuthSession(session)
+	if err != nil {
+		log.Printf("error: failed to get auth session: %v\n", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to get auth session")
+	}
+
+	duplicateResourceInfos, err := r.resourceService.GetDuplicateResources(c.Request().Context(), authSession)
+	if errors.Is(err, service.ErrForbidden) {
+		return echo.NewHTTPError(http.StatusForbidden, "you are not an administrator")
+	}
+	if err != nil {
+		log.Printf("error: failed to get duplicate resources: %v\n", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to get duplicate resources")
+	}
+
+	duplicates := make([]Openapi.DuplicateResources, 0, len(duplicateResourceInfos))
+	for _, resourceInfos := range duplicateResourceInfos {
+		resources := make([]Openapi.Resource, 0, len(resourceInfos))
+		for _, resourceInfo := range resourceInfos {
+			resource, err := newOpenapiResource(resourceInfo)
+			if err != nil {
+				log.Printf("error: failed to convert resource: %v\n", err)
+				return echo.NewHTTPError(http.StatusInternalServerError, "invalid resource type")
+			}
+
+			resources = append(resources, resource)
+		}
+
+		duplicates = append(duplicates, Openapi.DuplicateResources{
+			Resources: resources,
+		})
+	}
+
+	return c.JSON(http.StatusOK, duplicates)
+}
+
+func (r *Resource) PutResourceFile(c echo.Context, strResourceID Openapi.ResourceIDInPath) error {
+	err := r.checker.check(c)
+	if err != nil {
+		return err
+	}
+
+	session, err := getSession(c)
+	if err != nil {
+		log.Printf("error: failed to get session: %v\n", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to get session")
+	}
+
+	authSession, err := r.session.getAuthSession(session)
+	if err != nil {
+		log.Printf("error: failed to get auth session: %v\n", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to get auth session")
+	}
+
+	uuidResourceID, err := uuid.Parse(string(strResourceID))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid resource id")
+	}
+
+	var resourceFile Openapi.ResourceFile
+	err = c.Bind(&resourceFile)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
+	}
+
+	uuidFileID, err := uuid.Parse(resourceFile.FileID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid file id")
+	}
+
+	resourceInfo, err := r.resourceService.ReplaceResourceFile(
+		c.Request().Context(),
+		authSession,
+		values.NewResourceIDFromUUID(uuidResourceID),
+		values.NewFileIDFromUUID(uuidFileID),
+	)
+	if errors.Is(err, service.ErrNoResource) {
+		return echo.NewHTTPError(http.StatusNotFound, "resource not found")
+	}
+	if errors.Is(err, service.ErrNoFile) {
+		return echo.NewHTTPError(http.StatusNotFound, "file not found")
+	}
+	if errors.Is(err, service.ErrForbidden) {
+		return echo.NewHTTPError(http.StatusForbidden, "you are not the resource and file owner")
+	}
+	if errors.Is(err, service.ErrInvalidResourceType) {
+		return echo.NewHTTPError(http.StatusBadRequest, "file type does not match resource type")
+	}
+	if errors.Is(err, service.ErrInvalidFormat) {
+		return echo.NewHTTPError(http.StatusBadRequest, "file is quarantined")
+	}
+	if err != nil {
+		log.Printf("error: failed to replace resource file: %v\n", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to replace resource file")
+	}
+
+	resource, err := newOpenapiResource(resourceInfo)
+	if err != nil {
+		log.Printf("error: failed to convert resource: %v\n", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "invalid resource type")
+	}
+
+	return c.JSON(http.StatusOK, resource)
+}
+
+func (r *Resource) GetResourceVersions(c echo.Context, strResourceID Openapi.ResourceIDInPath) error {
+	err := r.checker.check(c)
+	if err != nil {
+		return err
+	}
+
+	session, err := getSession(c)
+	if err != nil {
+		log.Printf("error: failed to get session: %v\n", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to get session")
+	}
+
+	authSession, err := r.session.getAuthSession(session)
+	if err != nil {
+		log.Printf("error: failed to get auth session: %v\n", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to get auth session")
+	}
+
+	uuidResourceID, err := uuid.Parse(string(strResourceID))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid resource id")
+	}
+
+	versionInfos, err := r.resourceService.GetResourceVersions(
+		c.Request().Context(),
+		authSession,
+		values.NewResourceIDFromUUID(uuidResourceID),
+	)
+	if errors.Is(err, service.ErrNoResource) {
+		return echo.NewHTTPError(http.StatusNotFound, "resource not found")
+	}
+	if err != nil {
+		log.Printf("error: failed to get resource versions: %v\n", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to get resource versions")
+	}
+
+	versions := make([]Openapi.ResourceVersion, 0, len(versionInfos))
+	for _, versionInfo := range versionInfos {
+		versions = append(versions, Openapi.ResourceVersion{
+			Version:       int(versionInfo.GetVersion()),
+			FileID:        uuid.UUID(versionInfo.File.GetID()).String(),
+			Creator:       string(versionInfo.Creator.GetName()),
+			CreatedAt:     versionInfo.ResourceVersion.GetCreatedAt(),
+			Size:          versionInfo.File.GetSize(),
+			ImageMetadata: newOpenapiImageMetadata(versionInfo.File),
+			State:         newOpenapiResourceState(versionInfo.File),
+		})
+	}
+
+	return c.JSON(http.StatusOK, versions)
+}
+
+// defaultSimilarResourceLimit limitが指定されなかった場合に返す似たリソースの数
+const defaultSimilarResourceLimit = 20
+
+// maxFileNameQueryLength 検索するファイル名の最大の文字数。保存するファイル名より長いものは一致しない
+const maxFileNameQueryLength = 255
+
+func newOpenapiResource(resourceInfo *service.ResourceInfo) (Openapi.Resource, error) {
+	var resourceType Openapi.ResourceType
+	switch resourceInfo.Resource.GetType() {
+	case values.ResourceTypeImage:
+		resourceType = Openapi.ResourceTypeImage
+	case values.ResourceTypeMusic:
+		resourceType = Openapi.ResourceTypeMusic
+	case values.ResourceTypeVideo:
+		resourceType = Openapi.ResourceTypeVideo
+	case values.ResourceTypeDocument:
+		resourceType = Openapi.ResourceTypeDocument
+	case values.ResourceTypeArchive:
+		resourceType = Openapi.ResourceTypeArchive
+	case values.ResourceTypeOther:
+		resourceType = Openapi.ResourceTypeOther
+	default:
+		return Openapi.Resource{}, fmt.Errorf("unknown resource type: %v", resourceInfo.Resource.GetType())
+	}
+
+	return Openapi.Resource{
+		Id:            uuid.UUID(resourceInfo.Resource.GetID()).String(),
+		Creator:       string(resourceInfo.Creator.GetName()),
+		FileID:        uuid.UUID(resourceInfo.File.GetID()).String(),
+		ImageMetadata: newOpenapiImageMetadata(resourceInfo.File),
+		State:         newOpenapiResourceState(resourceInfo.File),
+		Size:          resourceInfo.File.GetSize(),
+		CreatedAt:     resourceInfo.Resource.GetCreatedAt(),
+		NewResource: Openapi.NewResource{
+			Name:         string(resourceInfo.Resource.GetName()),
+			Comment:      string(resourceInfo.Resource.GetComment()),
+			ResourceType: resourceType,
+		},
+	}, nil
+}
+
+func newOpenapiSimilarResources(similarResourceInfos []*service.SimilarResourceInfo) ([]Openapi.SimilarResource, error) {
+	similarResources := make([]Openapi.SimilarResource, 0, len(similarResourceInfos))
+	for _, similarResourceInfo := range similarResourceInfos {
+		resource, err := newOpenapiResource(similarResourceInfo.ResourceInfo)
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert resource: %w", err)
+		}
+
+		similarResources = append(similarResources, Openapi.SimilarResource{
+			Resource: resource,
+			Distance: similarResourceInfo.Distance,
+		})
+	}
+
+	return similarResources, nil
+}
+
+// newOpenapiResourceState ファイルが隔離されている場合はinfected
+func newOpenapiResourceState(file *domain.File) Openapi.ResourceState {
+	if file.IsInfected() {
+		return Openapi.ResourceStateInfected
+	}
+
+	return Openapi.ResourceStateAvailable
+}
+
+// newOpenapiFileScan 検査していない場合はnil
+func newOpenapiFileScan(file *domain.File) (*Openapi.FileScan, error) {
+	scan := file.GetScan()
+	if scan == nil {
+		return nil, nil
+	}
+
+	var status Openapi.ScanStatus
+	switch scan.GetStatus() {
+	case values.ScanStatusClean:
+		status = Openapi.ScanStatusClean
+	case values.ScanStatusInfected:
+		status = Openapi.ScanStatusInfected
+	default:
+		return nil, fmt.Errorf("unknown scan status: %d", scan.GetStatus())
+	}
+
+	apiScan := &Openapi.FileScan{
+		Status:    status,
+		ScannedAt: scan.GetScannedAt(),
+	}
+
+	if signature := string(scan.GetSignature()); len(signature) != 0 {
+		apiScan.Signature = &signature
+	}
+
+	return apiScan, nil
+}
+
+// newOpenapiImageMetadata 画像のメタデータがない場合はnil
+func newOpenapiImageMetadata(file *domain.File) *Openapi.ImageMetadata {
+	imageMetadata := file.GetImageMetadata()
+	if imageMetadata == nil {
+		return nil
+	}
+
+	var colorSpace Openapi.ColorSpace
+	switch imageMetadata.GetColorSpace() {
+	case values.ColorSpaceGray:
+		colorSpace = Openapi.ColorSpaceGray
+	case values.ColorSpaceRGB:
+		colorSpace = Openapi.ColorSpaceRgb
+	case values.ColorSpaceCMYK:
+		colorSpace = Openapi.ColorSpaceCmyk
+	default:
+		colorSpace = Openapi.ColorSpaceUnknown
+	}
+
+	apiImageMetadata := &Openapi.ImageMetadata{
+		Width:      imageMetadata.GetWidth(),
+		Height:     imageMetadata.GetHeight(),
+		Frames:     imageMetadata.GetFrames(),
+		ColorSpace: colorSpace,
+		CapturedAt: imageMetadata.GetCapturedAt(),
+	}
+
+	if orientation := imageMetadata.GetOrientation(); orientation != 0 {
+		apiImageMetadata.Orientation = &orientation
+	}
+
+	if cameraModel := imageMetadata.GetCameraModel(); len(cameraModel) != 0 {
+		apiImageMetadata.CameraModel = &cameraModel
+	}
+
+	return apiImageMetadata
+}
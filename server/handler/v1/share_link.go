@@ -0,0 +1,330 @@
+package v1
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/mazrean/Quantainer/domain/values"
+	Openapi "github.com/mazrean/Quantainer/handler/v1/openapi"
+	"github.com/mazrean/Quantainer/service"
+)
+
+type ShareLink struct {
+	session          *Session
+	checker          *Checker
+	shareLinkService service.ShareLink
+}
+
+func NewShareLink(session *Session, checker *Checker, shareLinkService service.ShareLink) *ShareLink {
+	return &ShareLink{
+		session:          session,
+		checker:          checker,
+		shareLinkService: shareLinkService,
+	}
+}
+
+func (sl *ShareLink) PostShareLink(c echo.Context) error {
+	err := sl.checker.check(c)
+	if err != nil {
+		return err
+	}
+
+	session, err := getSession(c)
+	if err != nil {
+		log.Printf("error: failed to get session: %v\n", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to get session")
+	}
+
+	authSession, err := sl.session.getAuthSession(session)
+	if err != nil {
+		log.Printf("error: failed to get auth session: %v\n", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to get auth session")
+	}
+
+	var newShareLink Openapi.NewShareLink
+	err = c.Bind(&newShareLink)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
+	}
+
+	var targetType values.ShareLinkTargetType
+	switch newShareLink.TargetType {
+	case Openapi.ShareLinkTargetTypeFile:
+		targetType = values.ShareLinkTargetTypeFile
+	case Openapi.ShareLinkTargetTypeResource:
+		targetType = values.ShareLinkTargetTypeResource
+	case Openapi.ShareLinkTargetTypeGroup:
+		targetType = values.ShareLinkTargetTypeGroup
+	default:
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid target type")
+	}
+
+	uuidTargetID, err := uuid.Parse(newShareLink.TargetID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid target id")
+	}
+
+	var maxDownloads values.ShareLinkMaxDownloads
+	if newShareLink.MaxDownloads != nil {
+		maxDownloads = values.NewShareLinkMaxDownloads(*newShareLink.MaxDownloads)
+	}
+
+	err = maxDownloads.Validate()
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("invalid max downloads: %v", err))
+	}
+
+	shareLinkInfo, err := sl.shareLinkService.CreateShareLink(
+		c.Request().Context(),
+		authSession,
+		targetType,
+		values.NewShareLinkTargetID(uuidTargetID),
+		newShareLink.ExpiresAt,
+		maxDownloads,
+	)
+	if errors.Is(err, service.ErrInvalidFormat) {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid expires at")
+	}
+	if errors.Is(err, service.ErrNoFile) {
+		return echo.NewHTTPError(http.StatusNotFound, "file not found")
+	}
+	if errors.Is(err, service.ErrNoResource) {
+		return echo.NewHTTPError(http.StatusNotFound, "resource not found")
+	}
+	if errors.Is(err, service.ErrNoGroup) {
+		return echo.NewHTTPError(http.StatusNotFound, "group not found")
+	}
+	if errors.Is(err, service.ErrForbidden) {
+		return echo.NewHTTPError(http.StatusForbidden, "forbidden")
+	}
+	if err != nil {
+		log.Printf("error: failed to create share link: %v\n", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to create share link")
+	}
+
+	shareLink, err := newOpenapiShareLink(shareLinkInfo)
+	if err != nil {
+		log.Printf("error: failed to convert share link: %v\n", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to convert share link")
+	}
+
+	return c.JSON(http.StatusCreated, shareLink)
+}
+
+func (sl *ShareLink) GetMyShareLinks(c echo.Context) error {
+	err := sl.checker.check(c)
+	if err != nil {
+		return err
+	}
+
+	session, err := getSession(c)
+	if err != nil {
+		log.Printf("error: failed to get session: %v\n", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to get session")
+	}
+
+	authSession, err := sl.session.getAuthSession(session)
+	if err != nil {
+		log.Printf("error: failed to get auth session: %v\n", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to get auth session")
+	}
+
+	shareLinkInfos, err := sl.shareLinkService.GetMyShareLinks(c.Request().Context(), authSession)
+	if err != nil {
+		log.Printf("error: failed to get share links: %v\n", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to get share links")
+	}
+
+	shareLinks := make([]Openapi.ShareLink, 0, len(shareLinkInfos))
+	for _, shareLinkInfo := range shareLinkInfos {
+		shareLink, err := newOpenapiShareLink(shareLinkInfo)
+		if err != nil {
+			log.Printf("error: failed to convert share link: %v\n", err)
+			return echo.NewHTTPError(http.StatusInternalServerError, "failed to convert share link")
+		}
+
+		shareLinks = append(shareLinks, shareLink)
+	}
+
+	return c.JSON(http.StatusOK, shareLinks)
+}
+
+func (sl *ShareLink) DeleteShareLink(c echo.Context, strShareLinkID Openapi.ShareLinkIDInPath) error {
+	err := sl.checker.check(c)
+	if err != nil {
+		return err
+	}
+
+	session, err := getSession(c)
+	if err != nil {
+		log.Printf("error: failed to get session: %v\n", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to get session")
+	}
+
+	authSession, err := sl.session.getAuthSession(session)
+	if err != nil {
+		log.Printf("error: failed to get auth session: %v\n", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to get auth session")
+	}
+
+	uuidShareLinkID, err := uuid.Parse(string(strShareLinkID))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid share link id")
+	}
+
+	err = sl.shareLinkService.RevokeShareLink(
+		c.Request().Context(),
+		authSession,
+		values.NewShareLinkIDFromUUID(uuidShareLinkID),
+	)
+	if errors.Is(err, service.ErrNoShareLink) {
+		return echo.NewHTTPError(http.StatusNotFound, "share link not found")
+	}
+	if errors.Is(err, service.ErrForbidden) {
+		return echo.NewHTTPError(http.StatusForbidden, "forbidden")
+	}
+	if err != nil {
+		log.Printf("error: failed to revoke share link: %v\n", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to revoke share link")
+	}
+
+	return c.NoContent(http.StatusOK)
+}
+
+func (sl *ShareLink) GetSharedContent(c echo.Context) error {
+	err := sl.checker.check(c)
+	if err != nil {
+		return err
+	}
+
+	shareLink, ok := getShareLink(c)
+	if !ok {
+		log.Printf("error: share link is not set\n")
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to get share link")
+	}
+
+	content, err := sl.shareLinkService.GetSharedContent(c.Request().Context(), shareLink)
+	if errors.Is(err, service.ErrNoFile) || errors.Is(err, service.ErrNoResource) || errors.Is(err, service.ErrNoGroup) {
+		return echo.NewHTTPError(http.StatusNotFound, "shared content not found")
+	}
+	if err != nil {
+		log.Printf("error: failed to get shared content: %v\n", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to get shared content")
+	}
+
+	var targetType Openapi.ShareLinkTargetType
+	switch content.GetTargetType() {
+	case values.ShareLinkTargetTypeFile:
+		targetType = Openapi.ShareLinkTargetTypeFile
+	case values.ShareLinkTargetTypeResource:
+		targetType = Openapi.ShareLinkTargetTypeResource
+	case values.ShareLinkTargetTypeGroup:
+		targetType = Openapi.ShareLinkTargetTypeGroup
+	default:
+		log.Printf("error: unknown share link target type: %d", content.GetTargetType())
+		return echo.NewHTTPError(http.StatusInternalServerError, "unexpected share link target type")
+	}
+
+	var group *Openapi.SharedGroup
+	if content.Group != nil {
+		group = &Openapi.SharedGroup{
+			Name:        string(content.Group.GetName()),
+			Description: string(content.Group.GetDescription()),
+		}
+	}
+
+	files := make([]Openapi.SharedFile, 0, len(content.Files))
+	for _, sharedFile := range content.Files {
+		var fileType Openapi.FileType
+		switch sharedFile.GetType() {
+		case values.FileTypeJpeg:
+			fileType = Openapi.FileTypeJpeg
+		case values.FileTypePng:
+			fileType = Openapi.FileTypePng
+		case values.FileTypeWebP:
+			fileType = Openapi.FileTypeWebp
+		case values.FileTypeSvg:
+			fileType = Openapi.FileTypeSvg
+		case values.FileTypeGif:
+			fileType = Openapi.FileTypeGif
+		case values.FileTypeMp3:
+			fileType = Openapi.FileTypeMp3
+		case values.FileTypeOgg:
+			fileType = Openapi.FileTypeOgg
+		case values.FileTypeWav:
+			fileType = Openapi.FileTypeWav
+		case values.FileTypeFlac:
+			fileType = Openapi.FileTypeFlac
+		case values.FileTypeMp4:
+			fileType = Openapi.FileTypeMp4
+		case values.FileTypeWebM:
+			fileType = Openapi.FileTypeWebm
+		case values.FileTypePdf:
+			fileType = Openapi.FileTypePdf
+		case values.FileTypeZip:
+			fileType = Openapi.FileTypeZip
+		case values.FileTypeAvif:
+			fileType = Openapi.FileTypeAvif
+		case values.FileTypeOther:
+			fileType = Openapi.FileTypeOther
+		default:
+			log.Printf("error: unknown file type: %d", sharedFile.GetType())
+			return echo.NewHTTPError(http.StatusInternalServerError, "unexpected file type")
+		}
+
+		file := Openapi.SharedFile{
+			Id:            uuid.UUID(sharedFile.GetID()).String(),
+			Type:          fileType,
+			Size:          sharedFile.GetSize(),
+			ImageMetadata: newOpenapiImageMetadata(sharedFile.File),
+			CreatedAt:     sharedFile.File.GetCreatedAt(),
+		}
+		if sharedFile.Resource != nil {
+			name := string(sharedFile.Resource.GetName())
+			comment := string(sharedFile.Resource.GetComment())
+			file.Name = &name
+			file.Comment = &comment
+		}
+
+		files = append(files, file)
+	}
+
+	return c.JSON(http.StatusOK, Openapi.SharedContent{
+		TargetType:   targetType,
+		ExpiresAt:    content.GetExpiresAt(),
+		MaxDownloads: int64(content.GetMaxDownloads()),
+		Downloads:    content.GetDownloads(),
+		Group:        group,
+		Files:        files,
+	})
+}
+
+func newOpenapiShareLink(shareLinkInfo *service.ShareLinkInfo) (Openapi.ShareLink, error) {
+	var targetType Openapi.ShareLinkTargetType
+	switch shareLinkInfo.GetTargetType() {
+	case values.ShareLinkTargetTypeFile:
+		targetType = Openapi.ShareLinkTargetTypeFile
+	case values.ShareLinkTargetTypeResource:
+		targetType = Openapi.ShareLinkTargetTypeResource
+	case values.ShareLinkTargetTypeGroup:
+		targetType = Openapi.ShareLinkTargetTypeGroup
+	default:
+		return Openapi.ShareLink{}, fmt.Errorf("unknown share link target type: %d", shareLinkInfo.GetTargetType())
+	}
+
+	return Openapi.ShareLink{
+		Id:           uuid.UUID(shareLinkInfo.GetID()).String(),
+		Token:        string(shareLinkInfo.Token),
+		TargetType:   targetType,
+		TargetID:     uuid.UUID(shareLinkInfo.GetTargetID()).String(),
+		ExpiresAt:    shareLinkInfo.GetExpiresAt(),
+		MaxDownloads: int64(shareLinkInfo.GetMaxDownloads()),
+		Downloads:    shareLinkInfo.GetDownloads(),
+		Revoked:      shareLinkInfo.GetRevokedAt() != nil,
+		CreatedAt:    shareLinkInfo.GetCreatedAt(),
+	}, nil
+}
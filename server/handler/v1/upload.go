@@ -0,0 +1,289 @@
+package v1
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"mime"
+	"net/http"
+	"path"
+	"strconv"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/mazrean/Quantainer/domain"
+	"github.com/mazrean/Quantainer/domain/values"
+	Openapi "github.com/mazrean/Quantainer/handler/v1/openapi"
+	"github.com/mazrean/Quantainer/service"
+)
+
+const (
+	// tusVersion 対応しているtusプロトコルのバージョン
+	tusVersion = "1.0.0"
+	// tusExtensions 対応しているtusプロトコルの拡張
+	tusExtensions = "creation,expiration"
+
+	tusOffsetContentType = "application/offset+octet-stream"
+)
+
+const (
+	headerTusResumable   = "Tus-Resumable"
+	headerTusVersion     = "Tus-Version"
+	headerTusExtension   = "Tus-Extension"
+	headerTusMaxSize     = "Tus-Max-Size"
+	headerUploadOffset   = "Upload-Offset"
+	headerUploadLength   = "Upload-Length"
+	headerUploadMetadata = "Upload-Metadata"
+	headerUploadExpires  = "Upload-Expires"
+	// headerFileID 全て書き込まれて保存されたファイルのid
+	headerFileID = "Quantainer-File-Id"
+)
+
+type Upload struct {
+	session       *Session
+	checker       *Checker
+	uploadService service.Upload
+}
+
+func NewUpload(session *Session, checker *Checker, uploadService service.Upload) *Upload {
+	return &Upload{
+		session:       session,
+		checker:       checker,
+		uploadService: uploadService,
+	}
+}
+
+func (u *Upload) OptionsUploads(c echo.Context) error {
+	header := c.Response().Header()
+	header.Set(headerTusResumable, tusVersion)
+	header.Set(headerTusVersion, tusVersion)
+	header.Set(headerTusExtension, tusExtensions)
+
+	maxSize := u.uploadService.GetMaxUploadSize()
+	if maxSize > 0 {
+		header.Set(headerTusMaxSize, strconv.FormatInt(maxSize, 10))
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (u *Upload) PostUpload(c echo.Context, params Openapi.PostUploadParams) error {
+	err := checkTusResumable(c, params.TusResumable)
+	if err != nil {
+		return err
+	}
+
+	err = u.checker.check(c)
+	if err != nil {
+		return err
+	}
+
+	session, err := getSession(c)
+	if err != nil {
+		log.Printf("error: failed to get session: %v\n", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to get session")
+	}
+
+	authSession, err := u.session.getAuthSession(session)
+	if err != nil {
+		log.Printf("error: failed to get auth session: %v\n", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to get auth session")
+	}
+
+	// Upload-Lengthを後から指定するcreation-defer-length拡張には対応しない
+	if params.UploadLength == nil || *params.UploadLength <= 0 {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid Upload-Length")
+	}
+
+	var metadata values.UploadMetadata
+	if params.UploadMetadata != nil {
+		metadata = values.UploadMetadata(*params.UploadMetadata)
+	}
+
+	err = metadata.Validate()
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("invalid Upload-Metadata: %v", err))
+	}
+
+	upload, err := u.uploadService.CreateUpload(c.Request().Context(), authSession, int64(*params.UploadLength), metadata)
+	if errors.Is(err, service.ErrQuotaExceeded) {
+		return echo.NewHTTPError(http.StatusRequestEntityTooLarge, "quota exceeded")
+	}
+	if errors.Is(err, service.ErrStorageFull) {
+		return echo.NewHTTPError(http.StatusInsufficientStorage, "storage full")
+	}
+	if errors.Is(err, service.ErrFileTooLarge) {
+		return echo.NewHTTPError(http.StatusRequestEntityTooLarge, "file too large")
+	}
+	if err != nil {
+		log.Printf("error: failed to create upload: %v\n", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to create upload")
+	}
+
+	header := c.Response().Header()
+	header.Set(echo.HeaderLocation, path.Join(c.Request().URL.Path, uuid.UUID(upload.GetID()).String()))
+	setUploadExpires(c, upload)
+
+	return c.NoContent(http.StatusCreated)
+}
+
+func (u *Upload) HeadUpload(c echo.Context, strUploadID Openapi.UploadIDInPath, params Openapi.HeadUploadParams) error {
+	err := checkTusResumable(c, params.TusResumable)
+	if err != nil {
+		return err
+	}
+
+	err = u.checker.check(c)
+	if err != nil {
+		return err
+	}
+
+	session, err := getSession(c)
+	if err != nil {
+		log.Printf("error: failed to get session: %v\n", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to get session")
+	}
+
+	authSession, err := u.session.getAuthSession(session)
+	if err != nil {
+		log.Printf("error: failed to get auth session: %v\n", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to get auth session")
+	}
+
+	uploadID, err := parseUploadID(strUploadID)
+	if err != nil {
+		return err
+	}
+
+	upload, err := u.uploadService.GetUpload(c.Request().Context(), authSession, uploadID)
+	if errors.Is(err, service.ErrNoUpload) {
+		return echo.NewHTTPError(http.StatusNotFound, "upload not found")
+	}
+	if errors.Is(err, service.ErrForbidden) {
+		return echo.NewHTTPError(http.StatusForbidden, "forbidden")
+	}
+	if err != nil {
+		log.Printf("error: failed to get upload: %v\n", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to get upload")
+	}
+
+	header := c.Response().Header()
+	header.Set("Cache-Control", "no-store")
+	header.Set(headerUploadOffset, strconv.FormatInt(upload.GetOffset(), 10))
+	header.Set(headerUploadLength, strconv.FormatInt(upload.GetLength(), 10))
+	if len(upload.GetMetadata()) != 0 {
+		header.Set(headerUploadMetadata, string(upload.GetMetadata()))
+	}
+	setUploadExpires(c, upload)
+
+	return c.NoContent(http.StatusOK)
+}
+
+func (u *Upload) PatchUpload(c echo.Context, strUploadID Openapi.UploadIDInPath, params Openapi.PatchUploadParams) error {
+	err := checkTusResumable(c, params.TusResumable)
+	if err != nil {
+		return err
+	}
+
+	err = u.checker.check(c)
+	if err != nil {
+		return err
+	}
+
+	session, err := getSession(c)
+	if err != nil {
+		log.Printf("error: failed to get session: %v\n", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to get session")
+	}
+
+	authSession, err := u.session.getAuthSession(session)
+	if err != nil {
+		log.Printf("error: failed to get auth session: %v\n", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to get auth session")
+	}
+
+	uploadID, err := parseUploadID(strUploadID)
+	if err != nil {
+		return err
+	}
+
+	contentType, _, err := mime.ParseMediaType(c.Request().Header.Get(echo.HeaderContentType))
+	if err != nil || contentType != tusOffsetContentType {
+		return echo.NewHTTPError(http.StatusUnsupportedMediaType, "invalid Content-Type")
+	}
+
+	if params.UploadOffset == nil || *params.UploadOffset < 0 {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid Upload-Offset")
+	}
+
+	upload, fileInfo, err := u.uploadService.WriteUpload(
+		c.Request().Context(),
+		authSession,
+		uploadID,
+		int64(*params.UploadOffset),
+		c.Request().Body,
+	)
+	if errors.Is(err, service.ErrNoUpload) {
+		return echo.NewHTTPError(http.StatusNotFound, "upload not found")
+	}
+	if errors.Is(err, service.ErrForbidden) {
+		return echo.NewHTTPError(http.StatusForbidden, "forbidden")
+	}
+	if errors.Is(err, service.ErrUploadOffsetMismatch) {
+		return echo.NewHTTPError(http.StatusConflict, "Upload-Offset mismatch")
+	}
+	if errors.Is(err, service.ErrUploadLocked) {
+		return echo.NewHTTPError(http.StatusLocked, "upload is being written")
+	}
+	if errors.Is(err, service.ErrQuotaExceeded) {
+		return echo.NewHTTPError(http.StatusRequestEntityTooLarge, "quota exceeded")
+	}
+	if errors.Is(err, service.ErrStorageFull) {
+		return echo.NewHTTPError(http.StatusInsufficientStorage, "storage full")
+	}
+	if errors.Is(err, service.ErrFileTooLarge) {
+		return echo.NewHTTPError(http.StatusRequestEntityTooLarge, "file too large")
+	}
+	if errors.Is(err, service.ErrInvalidFormat) {
+		return echo.NewHTTPError(http.StatusUnsupportedMediaType, "file type not allowed")
+	}
+	if err != nil {
+		log.Printf("error: failed to write upload: %v\n", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to write upload")
+	}
+
+	header := c.Response().Header()
+	header.Set(headerUploadOffset, strconv.FormatInt(upload.GetOffset(), 10))
+	if fileInfo != nil {
+		header.Set(headerFileID, uuid.UUID(fileInfo.File.GetID()).String())
+	} else {
+		setUploadExpires(c, upload)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// checkTusResumable エラーのレスポンスにも含める必要があるので、Tus-Resumableヘッダーもここで設定する
+func checkTusResumable(c echo.Context, tusResumable *Openapi.TusResumableInHeader) error {
+	c.Response().Header().Set(headerTusResumable, tusVersion)
+
+	if tusResumable == nil || string(*tusResumable) != tusVersion {
+		c.Response().Header().Set(headerTusVersion, tusVersion)
+		return echo.NewHTTPError(http.StatusPreconditionFailed, "unsupported tus version")
+	}
+
+	return nil
+}
+
+func parseUploadID(strUploadID Openapi.UploadIDInPath) (values.UploadID, error) {
+	uuidUploadID, err := uuid.Parse(string(strUploadID))
+	if err != nil {
+		return values.UploadID{}, echo.NewHTTPError(http.StatusBadRequest, "invalid upload id")
+	}
+
+	return values.NewUploadIDFromUUID(uuidUploadID), nil
+}
+
+func setUploadExpires(c echo.Context, upload *domain.Upload) {
+	c.Response().Header().Set(headerUploadExpires, upload.GetExpiresAt().UTC().Format(http.TimeFormat))
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"archive/zip"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mazrean/Quantainer/domain"
+	"github.com/mazrean/Quantainer/domain/values"
+	"github.com/mazrean/Quantainer/service"
+)
+
+const importCommandUsage = `usage:
+  quantainer import --type <artBook|other> [--name <name>] [--description <description>] [--read <public|private>] [--write <public|private>] [--main <path>] <zip file or directory>
+
+ENV IMPORT_ACCESS_TOKEN: 作成者になるユーザーのtraQのアクセストークン`
+
+// importSessionLifetime インポートの間だけ使うセッションの有効期間
+const importSessionLifetime = 24 * time.Hour
+
+// runImportCommand quantainer import
+func runImportCommand(isProduction bool, args []string) error {
+	flagSet := flag.NewFlagSet("import", flag.ContinueOnError)
+	name := flagSet.String("name", "", "グループ名(デフォルト: manifest.jsonのグループ名)")
+	strGroupType := flagSet.String("type", "", "グループの種類(artBook, other)")
+	description := flagSet.String("description", "", "グループの説明(デフォルト: manifest.jsonのグループの説明)")
+	strReadPermission := flagSet.String("read", "public", "閲覧権限(public, private)")
+	strWritePermission := flagSet.String("write", "private", "編集権限(public, private)")
+	mainResource := flagSet.String("main", "", "メインのリソースにするファイルのパス(デフォルト: manifest.jsonで指定されたもの、指定がなければ最初のファイル)")
+	err := flagSet.Parse(args)
+	if err != nil {
+		return fmt.Errorf("failed to parse flags: %w", err)
+	}
+
+	if flagSet.NArg() != 1 {
+		return errors.New(importCommandUsage)
+	}
+
+	var groupType values.GroupType
+	switch *strGroupType {
+	case "artBook":
+		groupType = values.GroupTypeArtBook
+	case "other":
+		groupType = values.GroupTypeOther
+	default:
+		return fmt.Errorf("invalid --type: %s\n%s", *strGroupType, importCommandUsage)
+	}
+
+	var readPermission values.GroupReadPermission
+	switch *strReadPermission {
+	case "public":
+		readPermission = values.GroupReadPermissionPublic
+	case "private":
+		readPermission = values.GroupReadPermissionPrivate
+	default:
+		return fmt.Errorf("invalid --read: %s", *strReadPermission)
+	}
+
+	var writePermission values.GroupWritePermission
+	switch *strWritePermission {
+	case "public":
+		writePermission = values.GroupWritePermissionPublic
+	case "private":
+		writePermission = values.GroupWritePermissionPrivate
+	default:
+		return fmt.Errorf("invalid --write: %s", *strWritePermission)
+	}
+
+	// コマンドライン引数は他のユーザーから見えるので、環境変数で受け取る
+	accessToken, ok := os.LookupEnv("IMPORT_ACCESS_TOKEN")
+	if !ok {
+		return errors.New("ENV IMPORT_ACCESS_TOKEN is not set")
+	}
+
+	fsys, closeFS, err := openImportFS(flagSet.Arg(0))
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", flagSet.Arg(0), err)
+	}
+	defer closeFS()
+
+	importer, err := InjectImporter(loadServiceConfig(isProduction))
+	if err != nil {
+		return fmt.Errorf("failed to inject importer: %w", err)
+	}
+
+	// 中断した場合もトランザクションが取り消され、保存済みのファイルは削除される
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	session := domain.NewOIDCSession(values.OIDCAccessToken(accessToken), time.Now().Add(importSessionLifetime))
+
+	result, err := importer.ImportGroup(ctx, session, &service.ImportGroupParams{
+		Name:            values.NewGroupName(*name),
+		GroupType:       groupType,
+		Description:     values.NewGroupDescription(*description),
+		ReadPermission:  readPermission,
+		WritePermission: writePermission,
+		MainResource:    *mainResource,
+	}, fsys)
+	if err != nil {
+		return fmt.Errorf("failed to import: %w", err)
+	}
+
+	fmt.Printf("group: %s (%s)\n", uuid.UUID(result.Group.Group.GetID()).String(), result.Group.Group.GetName())
+	for _, resource := range result.Resources {
+		fmt.Printf("resource: %s (%s)\n", uuid.UUID(resource.Resource.GetID()).String(), resource.GetName())
+	}
+
+	return nil
+}
+
+// openImportFS ZIPファイルの場合はその中身を、ディレクトリの場合はその配下をfs.FSとして開く
+func openImportFS(importPath string) (fs.FS, func(), error) {
+	stat, err := os.Stat(importPath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to stat: %w", err)
+	}
+
+	if stat.IsDir() {
+		return os.DirFS(importPath), func() {}, nil
+	}
+
+	zipReader, err := zip.OpenReader(importPath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open zip: %w", err)
+	}
+
+	return zipReader, func() {
+		_ = zipReader.Close()
+	}, nil
+}
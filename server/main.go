@@ -1,12 +1,18 @@
 package main
 
 import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"log"
 	"net/http"
 	"net/url"
 	"os"
+	"strconv"
 	"strings"
 	"time"
 
@@ -34,6 +40,26 @@ func main() {
 
 	colog.Register()
 
+	if len(os.Args) >= 2 && os.Args[1] == "storage" {
+		err := runStorageCommand(isProduction, os.Args[2:])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			os.Exit(1)
+		}
+
+		return
+	}
+
+	if len(os.Args) >= 2 && os.Args[1] == "import" {
+		err := runImportCommand(isProduction, os.Args[2:])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			os.Exit(1)
+		}
+
+		return
+	}
+
 	secret, ok := os.LookupEnv("SESSION_SECRET")
 	if !ok {
 		panic("SESSION_SECRET is not set")
@@ -48,6 +74,99 @@ func main() {
 		panic(errors.New("ENV CLIENT_SECRET IS NULL"))
 	}
 
+	accessToken, ok := os.LookupEnv("ACCESS_TOKEN")
+	if !ok {
+		panic("ENV ACCESS_TOKEN is not set")
+	}
+
+	verificationToken, ok := os.LookupEnv("VERIFICATION_TOKEN")
+	if !ok {
+		panic("ENV VERIFICATION_TOKEN is not set")
+	}
+
+	defaultChannels, ok := os.LookupEnv("DEFAULT_CHANNELS")
+	if !ok {
+		panic("ENV DEFAULT_CHANNELS is not set")
+	}
+
+	// 共有リンクのトークンの署名に使う。
+	// 本番環境ではセッションとは別のものを必須とし、開発環境で未指定の場合はセッションの秘密鍵から導出する
+	shareLinkSecret, ok := os.LookupEnv("SHARE_LINK_SECRET")
+	if !ok {
+		if isProduction {
+			panic("SHARE_LINK_SECRET is not set")
+		}
+
+		shareLinkSecret = deriveShareLinkSecret(secret)
+	}
+
+	config := loadServiceConfig(isProduction)
+	config.SessionKey = "sessions"
+	config.SessionSecret = common.SessionSecret(secret)
+	config.OAuthClientID = common.ClientID(clientID)
+	config.AccessToken = common.AccessToken(accessToken)
+	config.VerificationToken = common.VerificationToken(verificationToken)
+	config.DefaultChannels = common.DefaultChannels(strings.Split(defaultChannels, ","))
+	config.ShareLinkSecret = common.ShareLinkSecret(shareLinkSecret)
+
+	service, err := InjectService(config)
+	if err != nil {
+		panic(fmt.Sprintf("failed to inject API: %v", err))
+	}
+
+	// 指定された場合のみ、定期的にストレージとDBの整合性を確認する
+	strScrubInterval, ok := os.LookupEnv("SCRUB_INTERVAL")
+	if ok {
+		scrubInterval, err := time.ParseDuration(strScrubInterval)
+		if err != nil {
+			panic(fmt.Sprintf("failed to parse SCRUB_INTERVAL: %v", err))
+		}
+
+		go runScrubJob(context.Background(), service.Scrubber, scrubInterval)
+	}
+
+	gcInterval := defaultGCInterval
+	strGCInterval, ok := os.LookupEnv("GC_INTERVAL")
+	if ok {
+		gcInterval, err = time.ParseDuration(strGCInterval)
+		if err != nil {
+			panic(fmt.Sprintf("failed to parse GC_INTERVAL: %v", err))
+		}
+	}
+
+	go runGCJob(context.Background(), service.GarbageCollector, gcInterval)
+
+	if service.ReadCache != nil {
+		go runCacheStatsJob(context.Background(), service.ReadCache, defaultCacheStatsInterval)
+	}
+
+	api := service.API
+
+	addr, ok := os.LookupEnv("ADDR")
+	if !ok {
+		panic("ADDR is not set")
+	}
+
+	err = api.Start(addr)
+	if err != nil {
+		panic(fmt.Sprintf("failed to start API: %v", err))
+	}
+}
+
+// loadServiceConfig ファイル・リソース・グループの操作に必要な設定を環境変数から読み込む。
+// セッションやbotの設定はAPIを起動する場合のみ必要なので含まない。
+// shareLinkSecretLabel セッションの秘密鍵から共有リンクの秘密鍵を導出する際のラベル
+const shareLinkSecretLabel = "quantainer share link secret"
+
+// deriveShareLinkSecret セッションの秘密鍵をそのまま使わないよう、HMACで共有リンクの秘密鍵を導出する
+func deriveShareLinkSecret(sessionSecret string) string {
+	mac := hmac.New(sha256.New, []byte(sessionSecret))
+	mac.Write([]byte(shareLinkSecretLabel))
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func loadServiceConfig(isProduction bool) *Config {
 	traQBaseURL, err := url.Parse("https://q.trap.jp/api/v3")
 	if err != nil {
 		panic(fmt.Sprintf("failed to parse traQBaseURL: %v", err))
@@ -58,102 +177,268 @@ func main() {
 		panic("ENV FILE_PATH is not set")
 	}
 
-	var (
-		swiftAuthURL    common.SwiftAuthURL
-		swiftUserName   common.SwiftUserName
-		swiftPassword   common.SwiftPassword
-		swiftTenantID   common.SwiftTenantID
-		swiftTenantName common.SwiftTenantName
-		swiftContainer  common.SwiftContainer
-	)
-	if isProduction {
+	// 未指定の場合は従来通り、本番環境ではswift、それ以外ではローカルのストレージを使う
+	storageType := common.StorageType(os.Getenv("STORAGE_TYPE"))
+	if len(storageType) == 0 {
+		if isProduction {
+			storageType = common.StorageTypeSwift
+		} else {
+			storageType = common.StorageTypeLocal
+		}
+	}
+
+	// 他のユーザーのファイルやリソースも削除できる管理者のtraQ ID
+	var administrators []string
+	strAdministrators := os.Getenv("ADMINISTRATORS")
+	if len(strAdministrators) != 0 {
+		administrators = strings.Split(strAdministrators, ",")
+	}
+
+	// 使用量の上限。未指定の場合は無制限
+	userMaxBytes := lookupLimitEnv("USER_MAX_BYTES")
+	userMaxFiles := lookupLimitEnv("USER_MAX_FILES")
+	globalMaxBytes := lookupLimitEnv("GLOBAL_MAX_BYTES")
+	globalMaxFiles := lookupLimitEnv("GLOBAL_MAX_FILES")
+
+	uploadMaxSize := lookupLimitEnv("UPLOAD_MAX_SIZE")
+	uploadMaxSizes, err := parseUploadMaxSizes(os.Getenv("UPLOAD_MAX_SIZES"))
+	if err != nil {
+		panic(fmt.Sprintf("failed to parse UPLOAD_MAX_SIZES: %v", err))
+	}
+
+	// ZIPでのインポート時のリクエストボディの上限。未指定の場合は無制限
+	importMaxBodySize := lookupLimitEnv("IMPORT_MAX_BODY_SIZE")
+
+	uploadRejectExecutables := true
+	strUploadRejectExecutables, ok := os.LookupEnv("UPLOAD_REJECT_EXECUTABLES")
+	if ok {
+		uploadRejectExecutables, err = strconv.ParseBool(strUploadRejectExecutables)
+		if err != nil {
+			panic(fmt.Sprintf("failed to parse UPLOAD_REJECT_EXECUTABLES: %v", err))
+		}
+	}
+
+	// 位置情報などを含むので、明示的に無効にしない限り画像のメタデータを取り除いて保存する
+	stripImageMetadata := true
+	strStripImageMetadata, ok := os.LookupEnv("STRIP_IMAGE_METADATA")
+	if ok {
+		stripImageMetadata, err = strconv.ParseBool(strStripImageMetadata)
+		if err != nil {
+			panic(fmt.Sprintf("failed to parse STRIP_IMAGE_METADATA: %v", err))
+		}
+	}
+
+	// 未指定の場合はアップロードされたファイルを検査しない
+	scannerType := common.ScannerType(os.Getenv("SCANNER_TYPE"))
+	if len(scannerType) == 0 {
+		scannerType = common.ScannerTypeNone
+	}
+
+	var clamdAddress string
+	if scannerType == common.ScannerTypeClamd {
+		clamdAddress, ok = os.LookupEnv("CLAMD_ADDRESS")
+		if !ok {
+			panic("ENV CLAMD_ADDRESS is not set")
+		}
+	}
+
+	config := &Config{
+		IsProduction:            common.IsProduction(isProduction),
+		TraQBaseURL:             common.TraQBaseURL(traQBaseURL),
+		StorageType:             storageType,
+		FilePath:                common.FilePath(filePath),
+		HttpClient:              http.DefaultClient,
+		Administrators:          common.Administrators(administrators),
+		UserMaxBytes:            common.UserMaxBytes(userMaxBytes),
+		UserMaxFiles:            common.UserMaxFiles(userMaxFiles),
+		GlobalMaxBytes:          common.GlobalMaxBytes(globalMaxBytes),
+		GlobalMaxFiles:          common.GlobalMaxFiles(globalMaxFiles),
+		UploadMaxSize:           common.UploadMaxSize(uploadMaxSize),
+		UploadMaxSizes:          common.UploadMaxSizes(uploadMaxSizes),
+		ImportMaxBodySize:       common.ImportMaxBodySize(importMaxBodySize),
+		UploadAllowedMimeTypes:  common.UploadAllowedMimeTypes(splitEnvList(os.Getenv("UPLOAD_ALLOWED_MIME_TYPES"))),
+		UploadDeniedMimeTypes:   common.UploadDeniedMimeTypes(splitEnvList(os.Getenv("UPLOAD_DENIED_MIME_TYPES"))),
+		UploadRejectExecutables: common.UploadRejectExecutables(uploadRejectExecutables),
+		StripImageMetadata:      common.StripImageMetadata(stripImageMetadata),
+		ScannerType:             scannerType,
+		ClamdAddress:            common.ClamdAddress(clamdAddress),
+		UpdatedAt:               common.UpdatedAt(time.Now()),
+	}
+	loadStorageConfig(config)
+
+	return config
+}
+
+// loadStorageConfig config.StorageTypeのストレージに必要な設定を環境変数から読み込む
+func loadStorageConfig(config *Config) {
+	switch config.StorageType {
+	case common.StorageTypeSwift:
 		strSwiftAuthURL, ok := os.LookupEnv("OS_AUTH_URL")
 		if !ok {
 			panic("ENV OS_AUTH_URL is not set")
 		}
-		swiftAuthURL, err = url.Parse(strSwiftAuthURL)
+		swiftAuthURL, err := url.Parse(strSwiftAuthURL)
 		if err != nil {
 			panic(fmt.Errorf("failed to parse swiftAuthURL: %w", err))
 		}
+		config.SwiftAuthURL = swiftAuthURL
 
 		strSwiftUserName, ok := os.LookupEnv("OS_USERNAME")
 		if !ok {
 			panic("ENV OS_USERNAME is not set")
 		}
-		swiftUserName = common.SwiftUserName(strSwiftUserName)
+		config.SwiftUserName = common.SwiftUserName(strSwiftUserName)
 
 		strSwiftPassword, ok := os.LookupEnv("OS_PASSWORD")
 		if !ok {
 			panic("ENV OS_PASSWORD is not set")
 		}
-		swiftPassword = common.SwiftPassword(strSwiftPassword)
+		config.SwiftPassword = common.SwiftPassword(strSwiftPassword)
 
 		strSwiftTenantID, ok := os.LookupEnv("OS_TENANT_ID")
 		if !ok {
 			panic("ENV OS_TENANT_ID is not set")
 		}
-		swiftTenantID = common.SwiftTenantID(strSwiftTenantID)
+		config.SwiftTenantID = common.SwiftTenantID(strSwiftTenantID)
 
 		strSwiftTenantName, ok := os.LookupEnv("OS_TENANT_NAME")
 		if !ok {
 			panic("ENV OS_TENANT_NAME is not set")
 		}
-		swiftTenantName = common.SwiftTenantName(strSwiftTenantName)
+		config.SwiftTenantName = common.SwiftTenantName(strSwiftTenantName)
 
 		strSwiftContainer, ok := os.LookupEnv("OS_CONTAINER")
 		if !ok {
 			panic("ENV OS_CONTAINER is not set")
 		}
-		swiftContainer = common.SwiftContainer(strSwiftContainer)
+		config.SwiftContainer = common.SwiftContainer(strSwiftContainer)
+	case common.StorageTypeS3:
+		// AWS以外のS3互換ストレージを使う場合のみ指定する
+		config.S3Endpoint = common.S3Endpoint(os.Getenv("S3_ENDPOINT"))
+
+		strS3Region, ok := os.LookupEnv("S3_REGION")
+		if !ok {
+			panic("ENV S3_REGION is not set")
+		}
+		config.S3Region = common.S3Region(strS3Region)
+
+		strS3Bucket, ok := os.LookupEnv("S3_BUCKET")
+		if !ok {
+			panic("ENV S3_BUCKET is not set")
+		}
+		config.S3Bucket = common.S3Bucket(strS3Bucket)
+
+		strS3AccessKeyID, ok := os.LookupEnv("S3_ACCESS_KEY_ID")
+		if !ok {
+			panic("ENV S3_ACCESS_KEY_ID is not set")
+		}
+		config.S3AccessKeyID = common.S3AccessKeyID(strS3AccessKeyID)
+
+		strS3SecretAccessKey, ok := os.LookupEnv("S3_SECRET_ACCESS_KEY")
+		if !ok {
+			panic("ENV S3_SECRET_ACCESS_KEY is not set")
+		}
+		config.S3SecretAccessKey = common.S3SecretAccessKey(strS3SecretAccessKey)
+
+		config.S3Prefix = common.S3Prefix(os.Getenv("S3_PREFIX"))
 	}
 
-	accessToken, ok := os.LookupEnv("ACCESS_TOKEN")
-	if !ok {
-		panic("ENV ACCESS_TOKEN is not set")
+	config.StorageEncryptionKeys = lookupStorageEncryptionKeys()
+	config.StorageCacheMaxBytes = lookupStorageCacheMaxBytes(config.StorageType)
+}
+
+// defaultSwiftCacheMaxBytes swiftはネットワーク越しの読み込みになるので、未指定でもキャッシュする
+const defaultSwiftCacheMaxBytes = 10 << 30
+
+// lookupStorageCacheMaxBytes 0の場合は読み込みのキャッシュを無効にする
+func lookupStorageCacheMaxBytes(storageType common.StorageType) common.StorageCacheMaxBytes {
+	_, ok := os.LookupEnv("STORAGE_CACHE_MAX_BYTES")
+	if !ok && storageType == common.StorageTypeSwift {
+		return defaultSwiftCacheMaxBytes
 	}
 
-	verificationToken, ok := os.LookupEnv("VERIFICATION_TOKEN")
-	if !ok {
-		panic("ENV VERIFICATION_TOKEN is not set")
+	return common.StorageCacheMaxBytes(lookupLimitEnv("STORAGE_CACHE_MAX_BYTES"))
+}
+
+// lookupStorageEncryptionKeys 指定された場合のみ、保存する内容を暗号化する。
+// 暗号化する場合はファイルごとに異なるデータ鍵を使うため、同じ内容のファイルでも実体を共有せず、
+// 重複したアップロードの分だけストレージの使用量が増える。
+func lookupStorageEncryptionKeys() common.StorageEncryptionKeys {
+	storageEncryptionKeys, err := parseStorageEncryptionKeys(os.Getenv("STORAGE_ENCRYPTION_KEYS"))
+	if err != nil {
+		panic(fmt.Sprintf("failed to parse STORAGE_ENCRYPTION_KEYS: %v", err))
 	}
 
-	defaultChannels, ok := os.LookupEnv("DEFAULT_CHANNELS")
+	if len(storageEncryptionKeys) != 0 {
+		log.Printf("warn: storage encryption is enabled: files with the same content are no longer deduplicated\n")
+	}
+
+	return storageEncryptionKeys
+}
+
+// lookupLimitEnv 未指定の場合は無制限を表す0を返す
+func lookupLimitEnv(key string) int64 {
+	strValue, ok := os.LookupEnv(key)
 	if !ok {
-		panic("ENV DEFAULT_CHANNELS is not set")
+		return 0
 	}
 
-	service, err := InjectService(&Config{
-		IsProduction:      common.IsProduction(isProduction),
-		SessionKey:        "sessions",
-		SessionSecret:     common.SessionSecret(secret),
-		TraQBaseURL:       common.TraQBaseURL(traQBaseURL),
-		OAuthClientID:     common.ClientID(clientID),
-		SwiftAuthURL:      swiftAuthURL,
-		SwiftUserName:     swiftUserName,
-		SwiftPassword:     swiftPassword,
-		SwiftTenantID:     swiftTenantID,
-		SwiftTenantName:   swiftTenantName,
-		SwiftContainer:    swiftContainer,
-		FilePath:          common.FilePath(filePath),
-		HttpClient:        http.DefaultClient,
-		AccessToken:       common.AccessToken(accessToken),
-		VerificationToken: common.VerificationToken(verificationToken),
-		DefaultChannels:   common.DefaultChannels(strings.Split(defaultChannels, ",")),
-		UpdatedAt:         common.UpdatedAt(time.Now()),
-	})
-	if err != nil {
-		panic(fmt.Sprintf("failed to inject API: %v", err))
+	value, err := strconv.ParseInt(strValue, 10, 64)
+	if err != nil || value < 0 {
+		panic(fmt.Sprintf("invalid %s: %s", key, strValue))
 	}
 
-	api := service.API
+	return value
+}
 
-	addr, ok := os.LookupEnv("ADDR")
-	if !ok {
-		panic("ADDR is not set")
+// parseUploadMaxSizes jpeg=1048576,other=10485760 の形式
+func parseUploadMaxSizes(strMaxSizes string) (map[string]int64, error) {
+	maxSizes := map[string]int64{}
+	for _, strMaxSize := range splitEnvList(strMaxSizes) {
+		kv := strings.SplitN(strMaxSize, "=", 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("invalid format: %s", strMaxSize)
+		}
+
+		size, err := strconv.ParseInt(strings.TrimSpace(kv[1]), 10, 64)
+		if err != nil || size < 0 {
+			return nil, fmt.Errorf("invalid size: %s", strMaxSize)
+		}
+
+		maxSizes[strings.TrimSpace(kv[0])] = size
 	}
 
-	err = api.Start(addr)
-	if err != nil {
-		panic(fmt.Sprintf("failed to start API: %v", err))
+	return maxSizes, nil
+}
+
+// parseStorageEncryptionKeys <鍵のID>=<base64でエンコードした32バイトの鍵>をカンマ区切りで並べたものを読み込む。
+// 鍵を切り替える場合は、新しい鍵を先頭に追加し、以前の鍵も残す。
+func parseStorageEncryptionKeys(strKeys string) (common.StorageEncryptionKeys, error) {
+	var keys common.StorageEncryptionKeys
+	for _, strKey := range splitEnvList(strKeys) {
+		kv := strings.SplitN(strKey, "=", 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("invalid format: %s", strKey)
+		}
+
+		key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(kv[1]))
+		if err != nil {
+			return nil, fmt.Errorf("invalid key(%s): %w", strings.TrimSpace(kv[0]), err)
+		}
+
+		keys = append(keys, common.StorageEncryptionKey{
+			ID:  strings.TrimSpace(kv[0]),
+			Key: key,
+		})
 	}
+
+	return keys, nil
+}
+
+func splitEnvList(strList string) []string {
+	if len(strList) == 0 {
+		return nil
+	}
+
+	return strings.Split(strList, ",")
 }
@@ -6,20 +6,63 @@ import (
 )
 
 type (
-	IsProduction      bool
-	ClientID          string
-	TraQBaseURL       *url.URL
-	SessionSecret     string
-	SessionKey        string
-	SwiftAuthURL      *url.URL
-	SwiftUserName     string
-	SwiftPassword     string
-	SwiftTenantID     string
-	SwiftTenantName   string
-	SwiftContainer    string
-	FilePath          string
-	AccessToken       string
-	VerificationToken string
-	DefaultChannels   []string
-	UpdatedAt         time.Time
+	IsProduction            bool
+	ClientID                string
+	TraQBaseURL             *url.URL
+	SessionSecret           string
+	SessionKey              string
+	SwiftAuthURL            *url.URL
+	SwiftUserName           string
+	SwiftPassword           string
+	SwiftTenantID           string
+	SwiftTenantName         string
+	SwiftContainer          string
+	StorageType             string
+	S3Endpoint              string
+	S3Region                string
+	S3Bucket                string
+	S3AccessKeyID           string
+	S3SecretAccessKey       string
+	S3Prefix                string
+	FilePath                string
+	AccessToken             string
+	VerificationToken       string
+	DefaultChannels         []string
+	Administrators          []string
+	UserMaxBytes            int64
+	UserMaxFiles            int64
+	GlobalMaxBytes          int64
+	GlobalMaxFiles          int64
+	UploadMaxSize           int64
+	UploadMaxSizes          map[string]int64
+	ImportMaxBodySize       int64
+	UploadAllowedMimeTypes  []string
+	UploadDeniedMimeTypes   []string
+	UploadRejectExecutables bool
+	StripImageMetadata      bool
+	ShareLinkSecret         string
+	StorageEncryptionKeys   []StorageEncryptionKey
+	StorageCacheMaxBytes    int64
+	ScannerType             string
+	ClamdAddress            string
+	UpdatedAt               time.Time
+)
+
+// StorageEncryptionKey 保存する内容のデータ鍵を包むマスター鍵。
+// StorageEncryptionKeysの先頭の鍵で新しく保存する内容を暗号化し、残りは復号にのみ使う
+type StorageEncryptionKey struct {
+	ID  string
+	Key []byte
+}
+
+const (
+	StorageTypeLocal StorageType = "local"
+	StorageTypeSwift StorageType = "swift"
+	StorageTypeS3    StorageType = "s3"
+)
+
+const (
+	// ScannerTypeNone アップロードされたファイルを検査しない
+	ScannerTypeNone  ScannerType = "none"
+	ScannerTypeClamd ScannerType = "clamd"
 )
@@ -0,0 +1,104 @@
+package imagehash
+
+// bkNode ハミング距離でのBK木の節。
+// 子は親との距離ごとに持つので、三角不等式によって探索する子を絞り込める
+type bkNode struct {
+	hash     uint64
+	index    int
+	children map[int]*bkNode
+}
+
+func (n *bkNode) insert(hash uint64, index int) {
+	for {
+		distance := Distance(n.hash, hash)
+		child, ok := n.children[distance]
+		if !ok {
+			n.children[distance] = &bkNode{
+				hash:     hash,
+				index:    index,
+				children: map[int]*bkNode{},
+			}
+
+			return
+		}
+
+		n = child
+	}
+}
+
+// search hashとの距離がmaxDistance以下の節の番号でfnを呼ぶ
+func (n *bkNode) search(hash uint64, maxDistance int, fn func(index int)) {
+	distance := Distance(n.hash, hash)
+	if distance <= maxDistance {
+		fn(n.index)
+	}
+
+	for childDistance, child := range n.children {
+		if childDistance >= distance-maxDistance && childDistance <= distance+maxDistance {
+			child.search(hash, maxDistance, fn)
+		}
+	}
+}
+
+// Cluster 距離がmaxDistance以下のハッシュを同じまとまりとし、2つ以上のハッシュを含むまとまりの番号を返す。
+// AとB、BとCが近ければ、AとCが離れていても同じまとまりになる。
+// まとまりとその中の番号は、hashesでの最初の番号の順に並べる。
+func Cluster(hashes []uint64, maxDistance int) [][]int {
+	if len(hashes) == 0 {
+		return nil
+	}
+
+	parents := make([]int, len(hashes))
+	for i := range parents {
+		parents[i] = i
+	}
+
+	var find func(i int) int
+	find = func(i int) int {
+		if parents[i] != i {
+			parents[i] = find(parents[i])
+		}
+
+		return parents[i]
+	}
+
+	root := &bkNode{
+		hash:     hashes[0],
+		index:    0,
+		children: map[int]*bkNode{},
+	}
+	for i, hash := range hashes[1:] {
+		index := i + 1
+
+		root.search(hash, maxDistance, func(similarIndex int) {
+			a, b := find(index), find(similarIndex)
+			// 番号の小さい方を代表にし、まとまりの並び順を決める
+			if a < b {
+				parents[b] = a
+			} else {
+				parents[a] = b
+			}
+		})
+
+		root.insert(hash, index)
+	}
+
+	clusterMap := map[int][]int{}
+	var roots []int
+	for i := range hashes {
+		r := find(i)
+		if _, ok := clusterMap[r]; !ok {
+			roots = append(roots, r)
+		}
+		clusterMap[r] = append(clusterMap[r], i)
+	}
+
+	var clusters [][]int
+	for _, r := range roots {
+		if len(clusterMap[r]) >= 2 {
+			clusters = append(clusters, clusterMap[r])
+		}
+	}
+
+	return clusters
+}
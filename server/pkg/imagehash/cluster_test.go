@@ -0,0 +1,54 @@
+package imagehash
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCluster(t *testing.T) {
+	t.Parallel()
+
+	type test struct {
+		description string
+		hashes      []uint64
+		maxDistance int
+		clusters    [][]int
+	}
+
+	testCases := []test{
+		{
+			description: "ハッシュがないのでnil",
+			hashes:      nil,
+			maxDistance: 2,
+			clusters:    nil,
+		},
+		{
+			description: "近いハッシュがないのでnil",
+			hashes:      []uint64{0x0, 0xFF, 0xFF00},
+			maxDistance: 2,
+			clusters:    nil,
+		},
+		{
+			description: "同じハッシュと近いハッシュがまとまる",
+			hashes:      []uint64{0xFF, 0x0, 0xFF, 0x1, 0xFE},
+			maxDistance: 2,
+			clusters:    [][]int{{0, 2, 4}, {1, 3}},
+		},
+		{
+			description: "近いハッシュを介してまとまる",
+			hashes:      []uint64{0x0, 0xF, 0x3},
+			maxDistance: 2,
+			clusters:    [][]int{{0, 1, 2}},
+		},
+	}
+
+	for _, testCase := range testCases {
+		testCase := testCase
+		t.Run(testCase.description, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, testCase.clusters, Cluster(testCase.hashes, testCase.maxDistance))
+		})
+	}
+}
@@ -0,0 +1,50 @@
+// Package imagehash 再エンコードや縮小をしても近い値になる、画像の知覚ハッシュを計算する
+package imagehash
+
+import (
+	"image"
+	"math/bits"
+
+	"golang.org/x/image/draw"
+)
+
+const (
+	// dHashWidth 隣り合う画素を比べるので、ハッシュの1行のビット数より1つ多い
+	dHashWidth  = 9
+	dHashHeight = 8
+)
+
+// DHash 画像を9x8のグレースケールに縮小し、横に隣り合う画素の明るさの大小を並べた64ビットのハッシュ(dHash)。
+// 縮小によって細部の違いが消えるので、JPEGの品質や大きさの違いではほとんど変化しない。
+func DHash(img image.Image) uint64 {
+	// ApproxBiLinearは一部の画素しか見ないので、全ての画素を平均するBiLinearで縮小する。
+	// 縮小前にグレースケールにすると巨大な画像で遅くなるので、縮小してから明るさを求める
+	small := image.NewRGBA(image.Rect(0, 0, dHashWidth, dHashHeight))
+	draw.BiLinear.Scale(small, small.Bounds(), img, img.Bounds(), draw.Src, nil)
+
+	var hash uint64
+	for y := 0; y < dHashHeight; y++ {
+		for x := 0; x < dHashWidth-1; x++ {
+			hash <<= 1
+			if luminance(small, x, y) > luminance(small, x+1, y) {
+				hash |= 1
+			}
+		}
+	}
+
+	return hash
+}
+
+// Distance 異なるビットの数(ハミング距離)。小さいほど似ている
+func Distance(a uint64, b uint64) int {
+	return bits.OnesCount64(a ^ b)
+}
+
+// luminance ITU-R BT.601の係数で求めた明るさ。
+// 透過した画素は背景によって見え方が変わるので、アルファ乗算済みの値のまま扱う
+func luminance(img *image.RGBA, x int, y int) uint32 {
+	offset := img.PixOffset(x, y)
+	r, g, b := uint32(img.Pix[offset]), uint32(img.Pix[offset+1]), uint32(img.Pix[offset+2])
+
+	return 299*r + 587*g + 114*b
+}
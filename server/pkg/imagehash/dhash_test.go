@@ -0,0 +1,116 @@
+package imagehash
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/image/draw"
+)
+
+// newGradient 左上から右下に向かって明るさが変わる画像
+func newGradient(width int, height int, reverse bool) image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			v := uint8((x*255/width + y*255/height) / 2)
+			if reverse {
+				v = 255 - v
+			}
+
+			// 左右で明るさの変化の向きが変わるよう、中央に縦線を入れる
+			if x > width/3 && x < width/2 {
+				v = 255 - v
+			}
+
+			img.Set(x, y, color.RGBA{R: v, G: v / 2, B: 255 - v, A: 255})
+		}
+	}
+
+	return img
+}
+
+func reencodeJPEG(t *testing.T, img image.Image, quality int) image.Image {
+	t.Helper()
+
+	buf := bytes.NewBuffer(nil)
+	err := jpeg.Encode(buf, img, &jpeg.Options{Quality: quality})
+	if err != nil {
+		t.Fatalf("failed to encode jpeg: %v", err)
+	}
+
+	decoded, err := jpeg.Decode(buf)
+	if err != nil {
+		t.Fatalf("failed to decode jpeg: %v", err)
+	}
+
+	return decoded
+}
+
+func resize(img image.Image, width int, height int) image.Image {
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, img.Bounds(), draw.Src, nil)
+
+	return dst
+}
+
+func TestDHash(t *testing.T) {
+	t.Parallel()
+
+	original := newGradient(400, 300, false)
+	originalHash := DHash(original)
+
+	type test struct {
+		description string
+		img         image.Image
+		similar     bool
+	}
+
+	testCases := []test{
+		{
+			description: "同じ画像なので似ている",
+			img:         original,
+			similar:     true,
+		},
+		{
+			description: "低い品質で再エンコードしても似ている",
+			img:         reencodeJPEG(t, original, 30),
+			similar:     true,
+		},
+		{
+			description: "縮小しても似ている",
+			img:         resize(original, 160, 120),
+			similar:     true,
+		},
+		{
+			description: "明るさの変化が逆なので似ていない",
+			img:         newGradient(400, 300, true),
+			similar:     false,
+		},
+	}
+
+	for _, testCase := range testCases {
+		testCase := testCase
+		t.Run(testCase.description, func(t *testing.T) {
+			t.Parallel()
+
+			distance := Distance(originalHash, DHash(testCase.img))
+			if testCase.similar {
+				assert.LessOrEqual(t, distance, 4)
+			} else {
+				assert.Greater(t, distance, 16)
+			}
+		})
+	}
+}
+
+func TestDistance(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, 0, Distance(0xF0F0, 0xF0F0))
+	assert.Equal(t, 4, Distance(0xF0F0, 0xF000))
+	assert.Equal(t, 64, Distance(0, ^uint64(0)))
+}
@@ -0,0 +1,176 @@
+package imageinfo
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+)
+
+// avifContainerBoxes 子のボックスを持つボックス
+var avifContainerBoxes = map[string]struct{}{
+	"iprp": {},
+	"ipco": {},
+	"moov": {},
+	"trak": {},
+	"mdia": {},
+	"minf": {},
+	"stbl": {},
+}
+
+type avifParser struct {
+	info    *Info
+	rotated bool
+	// samples 画像シーケンスのサンプル数
+	samples int
+}
+
+// AVIF AVIFの情報を取り出す。
+// 画像シーケンスのフレーム数を数えるため、ファイル全体を読む。
+func AVIF(reader io.Reader) (*Info, error) {
+	parser := &avifParser{}
+
+	err := parser.parseBoxes(bufio.NewReader(reader), math.MaxInt64, true)
+	if err != nil {
+		return nil, err
+	}
+
+	if parser.info == nil {
+		return nil, ErrInvalidFormat
+	}
+
+	// irotで90度または270度回転する場合は表示上の幅と高さが入れ替わる
+	if parser.rotated {
+		parser.info.Width, parser.info.Height = parser.info.Height, parser.info.Width
+	}
+
+	parser.info.Frames = 1
+	if parser.samples > 0 {
+		parser.info.Frames = parser.samples
+	}
+
+	return parser.info, nil
+}
+
+func (ap *avifParser) parseBoxes(reader io.Reader, remaining int64, isTopLevel bool) error {
+	var boxHead [8]byte
+	for remaining > 0 {
+		_, err := io.ReadFull(reader, boxHead[:])
+		if isTopLevel && errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read box: %w", ErrInvalidFormat)
+		}
+
+		size := int64(binary.BigEndian.Uint32(boxHead[:4]))
+		boxType := string(boxHead[4:])
+		headerSize := int64(8)
+		switch size {
+		case 0:
+			// ファイルの終わりまで
+			if !isTopLevel {
+				return ErrInvalidFormat
+			}
+			size = math.MaxInt64
+		case 1:
+			var largeSize [8]byte
+			err = readFull(reader, largeSize[:])
+			if err != nil {
+				return fmt.Errorf("failed to read large size: %w", err)
+			}
+			size = int64(binary.BigEndian.Uint64(largeSize[:]))
+			headerSize += 8
+		}
+		if size < headerSize || size > remaining {
+			return ErrInvalidFormat
+		}
+		if remaining != math.MaxInt64 {
+			remaining -= size
+		}
+
+		contentReader := io.LimitReader(reader, size-headerSize)
+		err = ap.parseBox(contentReader, boxType, size-headerSize)
+		if err != nil {
+			return fmt.Errorf("failed to parse box(%s): %w", boxType, err)
+		}
+
+		// 読み残した部分を捨てる
+		_, err = io.Copy(io.Discard, contentReader)
+		if err != nil {
+			return fmt.Errorf("failed to skip box: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (ap *avifParser) parseBox(reader io.Reader, boxType string, size int64) error {
+	if _, ok := avifContainerBoxes[boxType]; ok {
+		return ap.parseBoxes(reader, size, false)
+	}
+
+	switch boxType {
+	case "ftyp":
+		if size < 4 {
+			return ErrInvalidFormat
+		}
+
+		brand := make([]byte, 4)
+		err := readFull(reader, brand)
+		if err != nil {
+			return err
+		}
+		if string(brand) != "avif" && string(brand) != "avis" {
+			return ErrInvalidFormat
+		}
+	case "meta":
+		// バージョンとフラグを飛ばす
+		err := discard(reader, 4)
+		if err != nil {
+			return err
+		}
+
+		return ap.parseBoxes(reader, size-4, false)
+	case "ispe":
+		content := make([]byte, 12)
+		err := readFull(reader, content)
+		if err != nil {
+			return err
+		}
+
+		width := int(binary.BigEndian.Uint32(content[4:8]))
+		height := int(binary.BigEndian.Uint32(content[8:12]))
+		// サムネイルやアルファチャンネルの分もあるので、最も大きいものを画像の大きさとする
+		if ap.info == nil || width*height > ap.info.Width*ap.info.Height {
+			ap.info = &Info{
+				Width:      width,
+				Height:     height,
+				ColorSpace: ColorSpaceRGB,
+			}
+		}
+	case "irot":
+		angle := make([]byte, 1)
+		err := readFull(reader, angle)
+		if err != nil {
+			return err
+		}
+
+		ap.rotated = angle[0]&0x03 == 1 || angle[0]&0x03 == 3
+	case "stsz":
+		content := make([]byte, 12)
+		err := readFull(reader, content)
+		if err != nil {
+			return err
+		}
+
+		samples := int(binary.BigEndian.Uint32(content[8:12]))
+		if samples > ap.samples {
+			ap.samples = samples
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,124 @@
+package imageinfo
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+const (
+	gifImageDescriptor = 0x2c
+	gifExtension       = 0x21
+	gifTrailer         = 0x3b
+	gifColorTableFlag  = 0x80
+)
+
+// GIF GIFの情報を取り出す。
+// フレーム数を数えるため、ファイル全体を読む。
+func GIF(reader io.Reader) (*Info, error) {
+	bufReader := bufio.NewReader(reader)
+
+	header := make([]byte, 13)
+	err := readFull(bufReader, header)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read header: %w", err)
+	}
+	if string(header[:6]) != "GIF87a" && string(header[:6]) != "GIF89a" {
+		return nil, ErrInvalidFormat
+	}
+
+	info := &Info{
+		Width:      int(binary.LittleEndian.Uint16(header[6:8])),
+		Height:     int(binary.LittleEndian.Uint16(header[8:10])),
+		ColorSpace: ColorSpaceRGB,
+	}
+
+	err = skipGIFColorTable(bufReader, header[10])
+	if err != nil {
+		return nil, fmt.Errorf("failed to skip global color table: %w", err)
+	}
+
+	for {
+		blockType, err := bufReader.ReadByte()
+		if errors.Is(err, io.EOF) && info.Frames > 0 {
+			// 末尾のトレイラーが欠けたファイルも多いので、読めたところまでで扱う
+			return info, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read block type: %w", ErrInvalidFormat)
+		}
+
+		switch blockType {
+		case gifImageDescriptor:
+			descriptor := make([]byte, 9)
+			err = readFull(bufReader, descriptor)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read image descriptor: %w", err)
+			}
+
+			err = skipGIFColorTable(bufReader, descriptor[8])
+			if err != nil {
+				return nil, fmt.Errorf("failed to skip local color table: %w", err)
+			}
+
+			// LZWの最小コードサイズ
+			err = discard(bufReader, 1)
+			if err != nil {
+				return nil, fmt.Errorf("failed to skip LZW minimum code size: %w", err)
+			}
+
+			err = skipGIFSubBlocks(bufReader)
+			if err != nil {
+				return nil, fmt.Errorf("failed to skip image data: %w", err)
+			}
+
+			info.Frames++
+		case gifExtension:
+			// ラベル
+			err = discard(bufReader, 1)
+			if err != nil {
+				return nil, fmt.Errorf("failed to skip extension label: %w", err)
+			}
+
+			err = skipGIFSubBlocks(bufReader)
+			if err != nil {
+				return nil, fmt.Errorf("failed to skip extension: %w", err)
+			}
+		case gifTrailer:
+			if info.Frames == 0 {
+				return nil, ErrInvalidFormat
+			}
+
+			return info, nil
+		default:
+			return nil, fmt.Errorf("unknown block type(%x): %w", blockType, ErrInvalidFormat)
+		}
+	}
+}
+
+func skipGIFColorTable(reader io.Reader, flags byte) error {
+	if flags&gifColorTableFlag == 0 {
+		return nil
+	}
+
+	return discard(reader, 3*(1<<((flags&0x07)+1)))
+}
+
+func skipGIFSubBlocks(reader *bufio.Reader) error {
+	for {
+		size, err := reader.ReadByte()
+		if err != nil {
+			return fmt.Errorf("failed to read sub block size: %w", ErrInvalidFormat)
+		}
+		if size == 0 {
+			return nil
+		}
+
+		err = discard(reader, int64(size))
+		if err != nil {
+			return err
+		}
+	}
+}
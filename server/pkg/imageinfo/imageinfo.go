@@ -0,0 +1,52 @@
+package imageinfo
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Info 画像の技術的な情報
+type Info struct {
+	Width  int
+	Height int
+	// Frames アニメーションのフレーム数。静止画の場合は1
+	Frames     int
+	ColorSpace ColorSpace
+}
+
+// ColorSpace 画像の色空間
+type ColorSpace int
+
+const (
+	ColorSpaceUnknown ColorSpace = iota
+	ColorSpaceGray
+	ColorSpaceRGB
+	ColorSpaceCMYK
+)
+
+var ErrInvalidFormat = errors.New("invalid format")
+
+func readFull(reader io.Reader, buf []byte) error {
+	_, err := io.ReadFull(reader, buf)
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return fmt.Errorf("unexpected end of file: %w", ErrInvalidFormat)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read: %w", err)
+	}
+
+	return nil
+}
+
+func discard(reader io.Reader, n int64) error {
+	written, err := io.CopyN(io.Discard, reader, n)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("failed to discard: %w", err)
+	}
+	if written != n {
+		return fmt.Errorf("unexpected end of file: %w", ErrInvalidFormat)
+	}
+
+	return nil
+}
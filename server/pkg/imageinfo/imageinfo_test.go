@@ -0,0 +1,305 @@
+package imageinfo
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/color/palette"
+	"image/gif"
+	"image/png"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func encodePNG(t *testing.T, img image.Image) []byte {
+	t.Helper()
+
+	buf := bytes.NewBuffer(nil)
+	err := png.Encode(buf, img)
+	if err != nil {
+		t.Fatalf("failed to encode png: %v", err)
+	}
+
+	return buf.Bytes()
+}
+
+// encodeAPNG IHDRの直後にacTLチャンクを挟む
+func encodeAPNG(t *testing.T, img image.Image, frames uint32) []byte {
+	t.Helper()
+
+	encoded := encodePNG(t, img)
+	ihdrEnd := len(pngSignature) + 8 + 13 + 4
+
+	actl := make([]byte, 4+4+8+4)
+	binary.BigEndian.PutUint32(actl[0:4], 8)
+	copy(actl[4:8], "acTL")
+	binary.BigEndian.PutUint32(actl[8:12], frames)
+
+	apng := append([]byte{}, encoded[:ihdrEnd]...)
+	apng = append(apng, actl...)
+
+	return append(apng, encoded[ihdrEnd:]...)
+}
+
+func encodeGIF(t *testing.T, frames int) []byte {
+	t.Helper()
+
+	anim := &gif.GIF{}
+	for i := 0; i < frames; i++ {
+		anim.Image = append(anim.Image, image.NewPaletted(image.Rect(0, 0, 5, 4), palette.Plan9))
+		anim.Delay = append(anim.Delay, 10)
+	}
+
+	buf := bytes.NewBuffer(nil)
+	err := gif.EncodeAll(buf, anim)
+	if err != nil {
+		t.Fatalf("failed to encode gif: %v", err)
+	}
+
+	return buf.Bytes()
+}
+
+func riffChunk(fourCC string, payload []byte) []byte {
+	chunk := []byte(fourCC)
+	chunk = append(chunk, 0, 0, 0, 0)
+	binary.LittleEndian.PutUint32(chunk[4:], uint32(len(payload)))
+	chunk = append(chunk, payload...)
+	if len(payload)%2 != 0 {
+		chunk = append(chunk, 0)
+	}
+
+	return chunk
+}
+
+func buildWebP(chunks ...[]byte) []byte {
+	body := []byte("WEBP")
+	for _, chunk := range chunks {
+		body = append(body, chunk...)
+	}
+
+	size := make([]byte, 4)
+	binary.LittleEndian.PutUint32(size, uint32(len(body)))
+
+	return append(append([]byte("RIFF"), size...), body...)
+}
+
+func vp8lChunk(width, height int) []byte {
+	payload := make([]byte, 5)
+	payload[0] = webpVP8LSignature
+	binary.LittleEndian.PutUint32(payload[1:], uint32(width-1)|uint32(height-1)<<14)
+
+	return riffChunk("VP8L", payload)
+}
+
+func vp8Chunk(width, height int) []byte {
+	payload := []byte{0, 0, 0, 0x9d, 0x01, 0x2a, 0, 0, 0, 0}
+	binary.LittleEndian.PutUint16(payload[6:], uint16(width))
+	binary.LittleEndian.PutUint16(payload[8:], uint16(height))
+
+	return riffChunk("VP8 ", payload)
+}
+
+func vp8xChunk(width, height int) []byte {
+	payload := []byte{0x02, 0, 0, 0}
+	payload = append(payload, byte(width-1), byte((width-1)>>8), byte((width-1)>>16))
+	payload = append(payload, byte(height-1), byte((height-1)>>8), byte((height-1)>>16))
+
+	return riffChunk("VP8X", payload)
+}
+
+func box(boxType string, payload []byte) []byte {
+	header := make([]byte, 4, 8+len(payload))
+	binary.BigEndian.PutUint32(header, uint32(8+len(payload)))
+
+	return append(append(header, boxType...), payload...)
+}
+
+func fullBox(boxType string, payload []byte) []byte {
+	return box(boxType, append([]byte{0, 0, 0, 0}, payload...))
+}
+
+func ispeBox(width, height int) []byte {
+	payload := make([]byte, 8)
+	binary.BigEndian.PutUint32(payload, uint32(width))
+	binary.BigEndian.PutUint32(payload[4:], uint32(height))
+
+	return fullBox("ispe", payload)
+}
+
+func buildAVIF(brand string, properties ...[]byte) []byte {
+	var ipco []byte
+	for _, property := range properties {
+		ipco = append(ipco, property...)
+	}
+
+	avif := box("ftyp", []byte(brand+"\x00\x00\x00\x00mif1"))
+	avif = append(avif, fullBox("meta", box("iprp", box("ipco", ipco)))...)
+
+	return append(avif, box("mdat", []byte("image data"))...)
+}
+
+func TestDecode(t *testing.T) {
+	t.Parallel()
+
+	type decoder func(io.Reader) (*Info, error)
+
+	type test struct {
+		description string
+		decode      decoder
+		input       []byte
+		info        *Info
+		isErr       bool
+	}
+
+	gray := image.NewGray(image.Rect(0, 0, 4, 3))
+	rgba := image.NewNRGBA(image.Rect(0, 0, 3, 4))
+	rgba.Set(0, 0, color.NRGBA{R: 255, A: 128})
+
+	testCases := []test{
+		{
+			description: "グレースケールのPNG",
+			decode:      PNG,
+			input:       encodePNG(t, gray),
+			info:        &Info{Width: 4, Height: 3, Frames: 1, ColorSpace: ColorSpaceGray},
+		},
+		{
+			description: "カラーのPNG",
+			decode:      PNG,
+			input:       encodePNG(t, rgba),
+			info:        &Info{Width: 3, Height: 4, Frames: 1, ColorSpace: ColorSpaceRGB},
+		},
+		{
+			description: "APNGはacTLのフレーム数",
+			decode:      PNG,
+			input:       encodeAPNG(t, rgba, 3),
+			info:        &Info{Width: 3, Height: 4, Frames: 3, ColorSpace: ColorSpaceRGB},
+		},
+		{
+			description: "途中で終わっているPNGはエラー",
+			decode:      PNG,
+			input:       encodePNG(t, rgba)[:20],
+			isErr:       true,
+		},
+		{
+			description: "静止画のGIF",
+			decode:      GIF,
+			input:       encodeGIF(t, 1),
+			info:        &Info{Width: 5, Height: 4, Frames: 1, ColorSpace: ColorSpaceRGB},
+		},
+		{
+			description: "アニメーションGIFはフレーム数を数える",
+			decode:      GIF,
+			input:       encodeGIF(t, 3),
+			info:        &Info{Width: 5, Height: 4, Frames: 3, ColorSpace: ColorSpaceRGB},
+		},
+		{
+			description: "GIFでないのでエラー",
+			decode:      GIF,
+			input:       []byte("GIF00a"),
+			isErr:       true,
+		},
+		{
+			description: "可逆圧縮のWebP",
+			decode:      WebP,
+			input:       buildWebP(vp8lChunk(300, 200)),
+			info:        &Info{Width: 300, Height: 200, Frames: 1, ColorSpace: ColorSpaceRGB},
+		},
+		{
+			description: "非可逆圧縮のWebP",
+			decode:      WebP,
+			input:       buildWebP(vp8Chunk(640, 480)),
+			info:        &Info{Width: 640, Height: 480, Frames: 1, ColorSpace: ColorSpaceRGB},
+		},
+		{
+			description: "アニメーションWebPはキャンバスの大きさとANMFの数",
+			decode:      WebP,
+			input: buildWebP(
+				vp8xChunk(1000, 2000),
+				riffChunk("ANIM", make([]byte, 6)),
+				riffChunk("ANMF", make([]byte, 17)),
+				riffChunk("ANMF", make([]byte, 17)),
+			),
+			info: &Info{Width: 1000, Height: 2000, Frames: 2, ColorSpace: ColorSpaceRGB},
+		},
+		{
+			description: "画像のチャンクがないWebPはエラー",
+			decode:      WebP,
+			input:       buildWebP(riffChunk("EXIF", make([]byte, 4))),
+			isErr:       true,
+		},
+		{
+			description: "AVIFは最も大きいispe",
+			decode:      AVIF,
+			input:       buildAVIF("avif", ispeBox(160, 90), ispeBox(1920, 1080)),
+			info:        &Info{Width: 1920, Height: 1080, Frames: 1, ColorSpace: ColorSpaceRGB},
+		},
+		{
+			description: "AVIFはirotで90度回転する場合に幅と高さを入れ替える",
+			decode:      AVIF,
+			input:       buildAVIF("avif", ispeBox(1920, 1080), box("irot", []byte{1})),
+			info:        &Info{Width: 1080, Height: 1920, Frames: 1, ColorSpace: ColorSpaceRGB},
+		},
+		{
+			description: "AVIF画像シーケンスはサンプル数",
+			decode:      AVIF,
+			input: append(
+				buildAVIF("avis", ispeBox(64, 64)),
+				box("moov", box("trak", box("mdia", box("minf", box("stbl", fullBox("stsz", []byte{0, 0, 0, 0, 0, 0, 0, 24}))))))...,
+			),
+			info: &Info{Width: 64, Height: 64, Frames: 24, ColorSpace: ColorSpaceRGB},
+		},
+		{
+			description: "AVIFでないISOBMFFはエラー",
+			decode:      AVIF,
+			input:       box("ftyp", []byte("isom\x00\x00\x00\x00")),
+			isErr:       true,
+		},
+		{
+			description: "SVGのwidthとheight",
+			decode:      SVG,
+			input:       []byte(`<?xml version="1.0"?><svg xmlns="http://www.w3.org/2000/svg" width="120px" height="80"><rect stroke-width="3"/></svg>`),
+			info:        &Info{Width: 120, Height: 80, Frames: 1, ColorSpace: ColorSpaceRGB},
+		},
+		{
+			description: "SVGのviewBox",
+			decode:      SVG,
+			input:       []byte(`<svg viewBox="0 0 24 48"></svg>`),
+			info:        &Info{Width: 24, Height: 48, Frames: 1, ColorSpace: ColorSpaceRGB},
+		},
+		{
+			description: "SVGのwidthのみの場合はviewBoxの縦横比",
+			decode:      SVG,
+			input:       []byte(`<svg width="100" viewBox="0,0,50,25"></svg>`),
+			info:        &Info{Width: 100, Height: 50, Frames: 1, ColorSpace: ColorSpaceRGB},
+		},
+		{
+			description: "SVGの大きさが%の場合はエラー",
+			decode:      SVG,
+			input:       []byte(`<svg width="100%" height="100%"></svg>`),
+			isErr:       true,
+		},
+	}
+
+	for _, testCase := range testCases {
+		testCase := testCase
+
+		t.Run(testCase.description, func(t *testing.T) {
+			t.Parallel()
+
+			info, err := testCase.decode(bytes.NewReader(testCase.input))
+
+			if testCase.isErr {
+				assert.ErrorIs(t, err, ErrInvalidFormat)
+				return
+			}
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			assert.Equal(t, testCase.info, info)
+		})
+	}
+}
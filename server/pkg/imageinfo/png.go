@@ -0,0 +1,108 @@
+package imageinfo
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+var pngSignature = []byte("\x89PNG\r\n\x1a\n")
+
+const (
+	pngColorTypeGray      = 0
+	pngColorTypeGrayAlpha = 4
+)
+
+// PNG PNG(APNGを含む)の情報を取り出す。
+// フレーム数はIDATより前のacTLチャンクにあるので、IDATより後は読まない。
+func PNG(reader io.Reader) (*Info, error) {
+	bufReader := bufio.NewReader(reader)
+
+	signature := make([]byte, len(pngSignature))
+	err := readFull(bufReader, signature)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read signature: %w", err)
+	}
+	if !bytes.Equal(signature, pngSignature) {
+		return nil, ErrInvalidFormat
+	}
+
+	var (
+		info      *Info
+		chunkHead [8]byte
+	)
+	for {
+		err := readFull(bufReader, chunkHead[:])
+		if err != nil {
+			return nil, fmt.Errorf("failed to read chunk: %w", err)
+		}
+
+		length := int64(binary.BigEndian.Uint32(chunkHead[:4]))
+		chunkType := string(chunkHead[4:])
+
+		switch chunkType {
+		case "IHDR":
+			if length < 13 {
+				return nil, ErrInvalidFormat
+			}
+
+			ihdr := make([]byte, 13)
+			err = readFull(bufReader, ihdr)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read IHDR: %w", err)
+			}
+
+			colorSpace := ColorSpaceRGB
+			switch ihdr[9] {
+			case pngColorTypeGray, pngColorTypeGrayAlpha:
+				colorSpace = ColorSpaceGray
+			}
+
+			info = &Info{
+				Width:      int(binary.BigEndian.Uint32(ihdr[0:4])),
+				Height:     int(binary.BigEndian.Uint32(ihdr[4:8])),
+				Frames:     1,
+				ColorSpace: colorSpace,
+			}
+
+			// 残りとCRC
+			err = discard(bufReader, length-13+4)
+			if err != nil {
+				return nil, fmt.Errorf("failed to skip IHDR: %w", err)
+			}
+		case "acTL":
+			if info == nil || length < 8 {
+				return nil, ErrInvalidFormat
+			}
+
+			actl := make([]byte, 8)
+			err = readFull(bufReader, actl)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read acTL: %w", err)
+			}
+
+			frames := int(binary.BigEndian.Uint32(actl[0:4]))
+			if frames > 0 {
+				info.Frames = frames
+			}
+
+			err = discard(bufReader, length-8+4)
+			if err != nil {
+				return nil, fmt.Errorf("failed to skip acTL: %w", err)
+			}
+		case "IDAT", "IEND":
+			if info == nil {
+				return nil, ErrInvalidFormat
+			}
+
+			return info, nil
+		default:
+			err = discard(bufReader, length+4)
+			if err != nil {
+				return nil, fmt.Errorf("failed to skip chunk: %w", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package imageinfo
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// svgHeadLength ルート要素を探す先頭のバイト数
+const svgHeadLength = 64 << 10
+
+var (
+	svgRootRegex    = regexp.MustCompile(`(?is)<svg\b[^>]*>`)
+	svgWidthRegex   = regexp.MustCompile(`(?i)\swidth\s*=\s*["']\s*([0-9.]+)\s*(?:px)?\s*["']`)
+	svgHeightRegex  = regexp.MustCompile(`(?i)\sheight\s*=\s*["']\s*([0-9.]+)\s*(?:px)?\s*["']`)
+	svgViewBoxRegex = regexp.MustCompile(`(?i)\sviewBox\s*=\s*["']([^"']*)["']`)
+)
+
+// SVG SVGのルート要素のwidth、height属性またはviewBox属性から大きさを取り出す。
+// 単位が%などで大きさを決められない場合はErrInvalidFormatを返す。
+func SVG(reader io.Reader) (*Info, error) {
+	head, err := io.ReadAll(io.LimitReader(reader, svgHeadLength))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read head: %w", err)
+	}
+
+	root := svgRootRegex.Find(head)
+	if root == nil {
+		return nil, ErrInvalidFormat
+	}
+
+	width, height := parseSVGLength(svgWidthRegex, root), parseSVGLength(svgHeightRegex, root)
+	if width == 0 || height == 0 {
+		viewBoxWidth, viewBoxHeight := parseSVGViewBox(root)
+		switch {
+		case width == 0 && height == 0:
+			width, height = viewBoxWidth, viewBoxHeight
+		case width == 0 && viewBoxHeight != 0:
+			// 片方のみ指定された場合はviewBoxの縦横比を保つ
+			width = height * viewBoxWidth / viewBoxHeight
+		case height == 0 && viewBoxWidth != 0:
+			height = width * viewBoxHeight / viewBoxWidth
+		}
+	}
+
+	if width < 1 || height < 1 || width > math.MaxInt32 || height > math.MaxInt32 {
+		return nil, ErrInvalidFormat
+	}
+
+	return &Info{
+		Width:      int(math.Round(width)),
+		Height:     int(math.Round(height)),
+		Frames:     1,
+		ColorSpace: ColorSpaceRGB,
+	}, nil
+}
+
+func parseSVGLength(lengthRegex *regexp.Regexp, root []byte) float64 {
+	match := lengthRegex.FindSubmatch(root)
+	if match == nil {
+		return 0
+	}
+
+	length, err := strconv.ParseFloat(string(match[1]), 64)
+	if err != nil {
+		return 0
+	}
+
+	return length
+}
+
+func parseSVGViewBox(root []byte) (float64, float64) {
+	match := svgViewBoxRegex.FindSubmatch(root)
+	if match == nil {
+		return 0, 0
+	}
+
+	fields := strings.FieldsFunc(string(match[1]), func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\t' || r == '\n' || r == '\r'
+	})
+	if len(fields) != 4 {
+		return 0, 0
+	}
+
+	width, err := strconv.ParseFloat(fields[2], 64)
+	if err != nil || width <= 0 {
+		return 0, 0
+	}
+
+	height, err := strconv.ParseFloat(fields[3], 64)
+	if err != nil || height <= 0 {
+		return 0, 0
+	}
+
+	return width, height
+}
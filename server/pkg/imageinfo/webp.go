@@ -0,0 +1,134 @@
+package imageinfo
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+const (
+	webpVP8XHeaderSize = 10
+	webpVP8HeaderSize  = 10
+	webpVP8LHeaderSize = 5
+	webpVP8LSignature  = 0x2f
+)
+
+// WebP WebPの情報を取り出す。
+// アニメーションのフレーム数を数えるため、ファイル全体を読む。
+func WebP(reader io.Reader) (*Info, error) {
+	bufReader := bufio.NewReader(reader)
+
+	header := make([]byte, 12)
+	err := readFull(bufReader, header)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read header: %w", err)
+	}
+	if string(header[:4]) != "RIFF" || string(header[8:]) != "WEBP" {
+		return nil, ErrInvalidFormat
+	}
+
+	var (
+		info       *Info
+		canvasInfo *Info
+		frames     int
+		chunkHead  [8]byte
+	)
+	for {
+		_, err := io.ReadFull(bufReader, chunkHead[:])
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read chunk: %w", ErrInvalidFormat)
+		}
+
+		chunkType := string(chunkHead[:4])
+		size := int64(binary.LittleEndian.Uint32(chunkHead[4:]))
+		// チャンクは偶数長になるように詰められている
+		paddedSize := size + size%2
+
+		var headerSize int64
+		switch chunkType {
+		case "VP8X":
+			headerSize = webpVP8XHeaderSize
+		case "VP8 ":
+			headerSize = webpVP8HeaderSize
+		case "VP8L":
+			headerSize = webpVP8LHeaderSize
+		case "ANMF":
+			frames++
+		}
+		if headerSize == 0 || info != nil {
+			err = discard(bufReader, paddedSize)
+			if err != nil {
+				return nil, fmt.Errorf("failed to skip chunk: %w", err)
+			}
+			continue
+		}
+		if size < headerSize {
+			return nil, ErrInvalidFormat
+		}
+
+		chunkHeader := make([]byte, headerSize)
+		err = readFull(bufReader, chunkHeader)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read chunk header: %w", err)
+		}
+
+		switch chunkType {
+		case "VP8X":
+			canvasInfo = &Info{
+				Width:      int(uint24(chunkHeader[4:7])) + 1,
+				Height:     int(uint24(chunkHeader[7:10])) + 1,
+				ColorSpace: ColorSpaceRGB,
+			}
+		case "VP8 ":
+			if chunkHeader[3] != 0x9d || chunkHeader[4] != 0x01 || chunkHeader[5] != 0x2a {
+				return nil, ErrInvalidFormat
+			}
+
+			info = &Info{
+				Width:      int(binary.LittleEndian.Uint16(chunkHeader[6:8]) & 0x3fff),
+				Height:     int(binary.LittleEndian.Uint16(chunkHeader[8:10]) & 0x3fff),
+				ColorSpace: ColorSpaceRGB,
+			}
+		case "VP8L":
+			if chunkHeader[0] != webpVP8LSignature {
+				return nil, ErrInvalidFormat
+			}
+
+			bits := binary.LittleEndian.Uint32(chunkHeader[1:5])
+			info = &Info{
+				Width:      int(bits&0x3fff) + 1,
+				Height:     int((bits>>14)&0x3fff) + 1,
+				ColorSpace: ColorSpaceRGB,
+			}
+		}
+
+		err = discard(bufReader, paddedSize-headerSize)
+		if err != nil {
+			return nil, fmt.Errorf("failed to skip chunk: %w", err)
+		}
+	}
+
+	// 拡張形式の場合はキャンバスの大きさが画像の大きさ
+	if canvasInfo != nil {
+		info = canvasInfo
+	}
+	if info == nil {
+		return nil, ErrInvalidFormat
+	}
+
+	info.Frames = 1
+	if frames > 0 {
+		info.Frames = frames
+	}
+
+	return info, nil
+}
+
+func uint24(b []byte) uint32 {
+	return uint32(b[0]) | uint32(b[1])<<8 | uint32(b[2])<<16
+}
@@ -0,0 +1,377 @@
+package jpegmeta
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	tagModel              = 0x0110
+	tagOrientation        = 0x0112
+	tagDateTime           = 0x0132
+	tagExifIFD            = 0x8769
+	tagGPSIFD             = 0x8825
+	tagDateTimeOriginal   = 0x9003
+	tagOffsetTimeOriginal = 0x9011
+	tagMakerNote          = 0x927c
+	tagCameraOwnerName    = 0xa430
+	tagBodySerialNumber   = 0xa431
+	tagLensSerialNumber   = 0xa435
+)
+
+// sensitiveExifTags Exif IFDから取り除くタグ
+// メーカーノートはカメラのシリアル番号や位置情報を含むことがある
+var sensitiveExifTags = []uint16{
+	tagMakerNote,
+	tagCameraOwnerName,
+	tagBodySerialNumber,
+	tagLensSerialNumber,
+}
+
+const (
+	ifdEntrySize   = 12
+	exifTimeLayout = "2006:01:02 15:04:05"
+)
+
+var errInvalidExif = errors.New("invalid exif")
+
+type ifdEntry struct {
+	// offset TIFFヘッダからのエントリの位置
+	offset int
+	tag    uint16
+	typ    uint16
+	count  uint32
+}
+
+type tiffReader struct {
+	data  []byte
+	order binary.ByteOrder
+}
+
+func newTiffReader(data []byte) (*tiffReader, error) {
+	if len(data) < 8 {
+		return nil, errInvalidExif
+	}
+
+	var order binary.ByteOrder
+	switch string(data[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return nil, errInvalidExif
+	}
+
+	if order.Uint16(data[2:4]) != 42 {
+		return nil, errInvalidExif
+	}
+
+	return &tiffReader{
+		data:  data,
+		order: order,
+	}, nil
+}
+
+func (tr *tiffReader) firstIFDOffset() int {
+	return int(tr.order.Uint32(tr.data[4:8]))
+}
+
+func (tr *tiffReader) readIFD(offset int) ([]ifdEntry, error) {
+	if offset < 8 || offset+2 > len(tr.data) {
+		return nil, errInvalidExif
+	}
+
+	count := int(tr.order.Uint16(tr.data[offset : offset+2]))
+	if offset+2+count*ifdEntrySize+4 > len(tr.data) {
+		return nil, errInvalidExif
+	}
+
+	entries := make([]ifdEntry, 0, count)
+	for i := 0; i < count; i++ {
+		entryOffset := offset + 2 + i*ifdEntrySize
+		entries = append(entries, ifdEntry{
+			offset: entryOffset,
+			tag:    tr.order.Uint16(tr.data[entryOffset : entryOffset+2]),
+			typ:    tr.order.Uint16(tr.data[entryOffset+2 : entryOffset+4]),
+			count:  tr.order.Uint32(tr.data[entryOffset+4 : entryOffset+8]),
+		})
+	}
+
+	return entries, nil
+}
+
+func typeSize(typ uint16) int {
+	switch typ {
+	case 1, 2, 6, 7:
+		return 1
+	case 3, 8:
+		return 2
+	case 4, 9, 11:
+		return 4
+	case 5, 10, 12:
+		return 8
+	default:
+		return 0
+	}
+}
+
+// value エントリの値のバイト列
+// 4バイト以下の値はエントリ内に、それより大きい値はオフセットの指す位置に置かれる
+func (tr *tiffReader) value(entry ifdEntry) ([]byte, error) {
+	if entry.count > uint32(len(tr.data)) {
+		return nil, errInvalidExif
+	}
+	size := typeSize(entry.typ) * int(entry.count)
+
+	if size <= 4 {
+		return tr.data[entry.offset+8 : entry.offset+8+size], nil
+	}
+
+	valueOffset := int(tr.order.Uint32(tr.data[entry.offset+8 : entry.offset+12]))
+	if valueOffset < 0 || valueOffset+size > len(tr.data) {
+		return nil, errInvalidExif
+	}
+
+	return tr.data[valueOffset : valueOffset+size], nil
+}
+
+func (tr *tiffReader) uint(entry ifdEntry) (int, error) {
+	if entry.count == 0 {
+		return 0, errInvalidExif
+	}
+
+	value := tr.data[entry.offset+8 : entry.offset+12]
+	switch entry.typ {
+	case 3:
+		return int(tr.order.Uint16(value[:2])), nil
+	case 4:
+		return int(tr.order.Uint32(value)), nil
+	default:
+		return 0, errInvalidExif
+	}
+}
+
+func (tr *tiffReader) string(entry ifdEntry) (string, error) {
+	if entry.typ != 2 {
+		return "", errInvalidExif
+	}
+
+	value, err := tr.value(entry)
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(strings.TrimRight(string(value), "\x00")), nil
+}
+
+type exifMetadata struct {
+	orientation        int
+	model              string
+	dateTime           string
+	dateTimeOriginal   string
+	offsetTimeOriginal string
+}
+
+func parseExif(data []byte) (*exifMetadata, error) {
+	tr, err := newTiffReader(data)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := tr.readIFD(tr.firstIFDOffset())
+	if err != nil {
+		return nil, fmt.Errorf("failed to read IFD0: %w", err)
+	}
+
+	exif := &exifMetadata{}
+	exifIFDOffset := 0
+	for _, entry := range entries {
+		switch entry.tag {
+		case tagOrientation:
+			exif.orientation, _ = tr.uint(entry)
+		case tagModel:
+			exif.model, _ = tr.string(entry)
+		case tagDateTime:
+			exif.dateTime, _ = tr.string(entry)
+		case tagExifIFD:
+			exifIFDOffset, _ = tr.uint(entry)
+		}
+	}
+
+	if exifIFDOffset != 0 {
+		entries, err := tr.readIFD(exifIFDOffset)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read exif IFD: %w", err)
+		}
+
+		for _, entry := range entries {
+			switch entry.tag {
+			case tagDateTimeOriginal:
+				exif.dateTimeOriginal, _ = tr.string(entry)
+			case tagOffsetTimeOriginal:
+				exif.offsetTimeOriginal, _ = tr.string(entry)
+			}
+		}
+	}
+
+	return exif, nil
+}
+
+func (em *exifMetadata) apply(metadata *Metadata) {
+	if em.orientation >= 1 && em.orientation <= 8 {
+		metadata.Orientation = em.orientation
+	}
+
+	if metadata.CameraModel == "" {
+		metadata.CameraModel = em.model
+	}
+
+	if metadata.CapturedAt == nil {
+		metadata.CapturedAt = parseExifTime(em.dateTimeOriginal, em.offsetTimeOriginal)
+	}
+	if metadata.CapturedAt == nil {
+		metadata.CapturedAt = parseExifTime(em.dateTime, "")
+	}
+}
+
+// parseExifTime EXIFの日時を解釈する
+// タイムゾーンの情報がない場合はサーバーのタイムゾーンとみなす
+func parseExifTime(value string, offset string) *time.Time {
+	if value == "" {
+		return nil
+	}
+
+	var (
+		t   time.Time
+		err error
+	)
+	if offset != "" {
+		t, err = time.Parse(exifTimeLayout+"-07:00", value+offset)
+	} else {
+		t, err = time.ParseInLocation(exifTimeLayout, value, time.Local)
+	}
+	if err != nil {
+		return nil
+	}
+
+	return &t
+}
+
+// stripExif 位置情報などの機密性の高いタグをEXIFから取り除く。
+// オフセットがずれないように、その場で書き換えて取り除いた部分は0で埋める。
+func stripExif(data []byte) error {
+	tr, err := newTiffReader(data)
+	if err != nil {
+		return err
+	}
+
+	ifd0Offset := tr.firstIFDOffset()
+	entries, err := tr.readIFD(ifd0Offset)
+	if err != nil {
+		return fmt.Errorf("failed to read IFD0: %w", err)
+	}
+
+	for _, entry := range entries {
+		switch entry.tag {
+		case tagGPSIFD:
+			gpsIFDOffset, err := tr.uint(entry)
+			if err != nil {
+				return fmt.Errorf("failed to read GPS IFD offset: %w", err)
+			}
+
+			err = tr.clearIFD(gpsIFDOffset)
+			if err != nil {
+				return fmt.Errorf("failed to clear GPS IFD: %w", err)
+			}
+		case tagExifIFD:
+			exifIFDOffset, err := tr.uint(entry)
+			if err != nil {
+				return fmt.Errorf("failed to read exif IFD offset: %w", err)
+			}
+
+			for _, tag := range sensitiveExifTags {
+				err = tr.removeEntry(exifIFDOffset, tag)
+				if err != nil {
+					return fmt.Errorf("failed to remove tag(%x): %w", tag, err)
+				}
+			}
+		}
+	}
+
+	err = tr.removeEntry(ifd0Offset, tagGPSIFD)
+	if err != nil {
+		return fmt.Errorf("failed to remove GPS IFD: %w", err)
+	}
+
+	return nil
+}
+
+// clearIFD IFDとその値を0で埋める
+func (tr *tiffReader) clearIFD(offset int) error {
+	entries, err := tr.readIFD(offset)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		err = tr.clearValue(entry)
+		if err != nil {
+			return err
+		}
+	}
+
+	clearBytes(tr.data[offset : offset+2+len(entries)*ifdEntrySize+4])
+
+	return nil
+}
+
+func (tr *tiffReader) clearValue(entry ifdEntry) error {
+	value, err := tr.value(entry)
+	if err != nil {
+		return err
+	}
+
+	clearBytes(value)
+
+	return nil
+}
+
+// removeEntry IFDからタグのエントリを取り除き、後ろのエントリを詰める
+func (tr *tiffReader) removeEntry(ifdOffset int, tag uint16) error {
+	entries, err := tr.readIFD(ifdOffset)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		if entry.tag != tag {
+			continue
+		}
+
+		err = tr.clearValue(entry)
+		if err != nil {
+			return err
+		}
+
+		// 後ろのエントリと次のIFDへのオフセットを前に詰める
+		end := ifdOffset + 2 + len(entries)*ifdEntrySize + 4
+		copy(tr.data[entry.offset:], tr.data[entry.offset+ifdEntrySize:end])
+		clearBytes(tr.data[end-ifdEntrySize : end])
+		tr.order.PutUint16(tr.data[ifdOffset:ifdOffset+2], uint16(len(entries)-1))
+
+		// 同じタグが複数ある場合に備えて読み直す
+		return tr.removeEntry(ifdOffset, tag)
+	}
+
+	return nil
+}
+
+func clearBytes(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+}
@@ -0,0 +1,114 @@
+package jpegmeta
+
+import (
+	"bytes"
+	"encoding/binary"
+	"time"
+)
+
+const (
+	// photoshopIPTCResourceID IPTC-IIMを格納するPhotoshopの画像リソースのID
+	photoshopIPTCResourceID = 0x0404
+	iptcTagMarker           = 0x1c
+	iptcRecordApplication   = 2
+	iptcDateCreated         = 55
+	iptcTimeCreated         = 60
+)
+
+var photoshopResourceSignature = []byte("8BIM")
+
+type iptcMetadata struct {
+	createdAt *time.Time
+}
+
+// parseIPTC Photoshopの画像リソースからIPTC-IIMの作成日時を取り出す
+func parseIPTC(data []byte) *iptcMetadata {
+	iptc := &iptcMetadata{}
+
+	for len(data) >= 12 && bytes.HasPrefix(data, photoshopResourceSignature) {
+		resourceID := binary.BigEndian.Uint16(data[4:6])
+
+		// 名前は偶数長になるように詰められたPascal文字列
+		nameLength := int(data[6]) + 1
+		if nameLength%2 != 0 {
+			nameLength++
+		}
+		if 6+nameLength+4 > len(data) {
+			break
+		}
+
+		sizeOffset := 6 + nameLength
+		size := int(binary.BigEndian.Uint32(data[sizeOffset : sizeOffset+4]))
+		resourceOffset := sizeOffset + 4
+		if size < 0 || resourceOffset+size > len(data) {
+			break
+		}
+
+		if resourceID == photoshopIPTCResourceID {
+			iptc.createdAt = parseIPTCDataSets(data[resourceOffset : resourceOffset+size])
+			break
+		}
+
+		if size%2 != 0 {
+			size++
+		}
+		if resourceOffset+size > len(data) {
+			break
+		}
+		data = data[resourceOffset+size:]
+	}
+
+	return iptc
+}
+
+func parseIPTCDataSets(data []byte) *time.Time {
+	var date, clock string
+	for len(data) >= 5 && data[0] == iptcTagMarker {
+		record := data[1]
+		dataSet := data[2]
+		size := int(binary.BigEndian.Uint16(data[3:5]))
+		if 5+size > len(data) {
+			break
+		}
+
+		if record == iptcRecordApplication {
+			switch dataSet {
+			case iptcDateCreated:
+				date = string(data[5 : 5+size])
+			case iptcTimeCreated:
+				clock = string(data[5 : 5+size])
+			}
+		}
+
+		data = data[5+size:]
+	}
+
+	if date == "" {
+		return nil
+	}
+
+	if clock != "" {
+		t, err := time.Parse("20060102150405-0700", date+clock)
+		if err == nil {
+			return &t
+		}
+
+		t, err = time.ParseInLocation("20060102150405", date+clock, time.Local)
+		if err == nil {
+			return &t
+		}
+	}
+
+	t, err := time.ParseInLocation("20060102", date, time.Local)
+	if err != nil {
+		return nil
+	}
+
+	return &t
+}
+
+func (im *iptcMetadata) apply(metadata *Metadata) {
+	if metadata.CapturedAt == nil {
+		metadata.CapturedAt = im.createdAt
+	}
+}
@@ -0,0 +1,191 @@
+package jpegmeta
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// Metadata JPEGから取り出したメタデータ
+type Metadata struct {
+	Width  int
+	Height int
+	// Components 色成分の数。1はグレースケール、3はYCbCr、4はCMYK
+	Components int
+	// Orientation EXIFのOrientation(1~8)。不明な場合は0
+	Orientation int
+	// CapturedAt 撮影日時。不明な場合はnil
+	CapturedAt  *time.Time
+	CameraModel string
+}
+
+var ErrInvalidJPEG = errors.New("invalid jpeg")
+
+const (
+	markerSOI   = 0xd8
+	markerEOI   = 0xd9
+	markerSOS   = 0xda
+	markerTEM   = 0x01
+	markerRST0  = 0xd0
+	markerRST7  = 0xd7
+	markerSOF0  = 0xc0
+	markerSOF15 = 0xcf
+	markerDHT   = 0xc4
+	markerJPG   = 0xc8
+	markerDAC   = 0xcc
+	markerAPP1  = 0xe1
+	markerAPP13 = 0xed
+)
+
+// maxHeaderSize 画像データより前のセグメントの合計サイズの上限
+const maxHeaderSize = 16 << 20
+
+var (
+	exifHeader        = []byte("Exif\x00\x00")
+	xmpHeader         = []byte("http://ns.adobe.com/xap/1.0/\x00")
+	extendedXMPHeader = []byte("http://ns.adobe.com/xmp/extension/\x00")
+	photoshopHeader   = []byte("Photoshop 3.0\x00")
+)
+
+// Process readerからJPEGの画像データの直前までを読み込んでメタデータを取り出す。
+// stripがtrueの場合は位置情報などの機密性の高い情報を取り除く。
+// 返り値のio.Readerからはメタデータ処理後のJPEG全体を読み出せる。
+func Process(reader io.Reader, strip bool) (*Metadata, io.Reader, error) {
+	bufReader := bufio.NewReader(reader)
+
+	var soi [2]byte
+	_, err := io.ReadFull(bufReader, soi[:])
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read SOI: %w", err)
+	}
+	if soi[0] != 0xff || soi[1] != markerSOI {
+		return nil, nil, ErrInvalidJPEG
+	}
+
+	header := bytes.NewBuffer(soi[:])
+	metadata := &Metadata{}
+	var (
+		xmp  *xmpMetadata
+		iptc *iptcMetadata
+	)
+	for {
+		marker, err := readMarker(bufReader)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read marker: %w", err)
+		}
+
+		if marker == markerTEM || (marker >= markerRST0 && marker <= markerRST7) {
+			header.Write([]byte{0xff, marker})
+			continue
+		}
+		if marker == markerSOS || marker == markerEOI {
+			// 以降は画像データなのでそのまま流す
+			header.Write([]byte{0xff, marker})
+			break
+		}
+
+		var length [2]byte
+		_, err = io.ReadFull(bufReader, length[:])
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read segment length: %w", err)
+		}
+		segmentLength := int(binary.BigEndian.Uint16(length[:]))
+		if segmentLength < 2 {
+			return nil, nil, ErrInvalidJPEG
+		}
+		if header.Len()+segmentLength > maxHeaderSize {
+			return nil, nil, fmt.Errorf("too large header: %w", ErrInvalidJPEG)
+		}
+
+		payload := make([]byte, segmentLength-2)
+		_, err = io.ReadFull(bufReader, payload)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read segment: %w", err)
+		}
+
+		keep := true
+		switch {
+		case isSOF(marker):
+			if len(payload) < 6 {
+				return nil, nil, ErrInvalidJPEG
+			}
+			metadata.Height = int(binary.BigEndian.Uint16(payload[1:3]))
+			metadata.Width = int(binary.BigEndian.Uint16(payload[3:5]))
+			metadata.Components = int(payload[5])
+		case marker == markerAPP1 && bytes.HasPrefix(payload, exifHeader):
+			tiff := payload[len(exifHeader):]
+			exifData, err := parseExif(tiff)
+			if err == nil {
+				exifData.apply(metadata)
+			}
+
+			if strip {
+				// 解析できないEXIFは機密情報を含んでいる可能性があるので丸ごと取り除く
+				if err != nil {
+					keep = false
+					break
+				}
+
+				err = stripExif(tiff)
+				if err != nil {
+					keep = false
+				}
+			}
+		case marker == markerAPP1 && bytes.HasPrefix(payload, xmpHeader):
+			xmp = parseXMP(payload[len(xmpHeader):])
+			// XMPは位置情報や作者の情報を自由に持てるので、必要な情報を取り出した上で取り除く
+			keep = !strip
+		case marker == markerAPP1 && bytes.HasPrefix(payload, extendedXMPHeader):
+			keep = !strip
+		case marker == markerAPP13 && bytes.HasPrefix(payload, photoshopHeader):
+			iptc = parseIPTC(payload[len(photoshopHeader):])
+			// IPTCは撮影場所や作者の連絡先を持てるので、必要な情報を取り出した上で取り除く
+			keep = !strip
+		}
+
+		if keep {
+			header.Write([]byte{0xff, marker})
+			header.Write(length[:])
+			header.Write(payload)
+		}
+	}
+
+	// EXIFを優先し、足りない情報をXMP、IPTCの順で補う
+	if xmp != nil {
+		xmp.apply(metadata)
+	}
+	if iptc != nil {
+		iptc.apply(metadata)
+	}
+
+	return metadata, io.MultiReader(header, bufReader), nil
+}
+
+func readMarker(reader *bufio.Reader) (byte, error) {
+	b, err := reader.ReadByte()
+	if err != nil {
+		return 0, fmt.Errorf("failed to read byte: %w", err)
+	}
+	if b != 0xff {
+		return 0, ErrInvalidJPEG
+	}
+
+	// マーカーの前には任意個の0xffを置ける
+	for b == 0xff {
+		b, err = reader.ReadByte()
+		if err != nil {
+			return 0, fmt.Errorf("failed to read byte: %w", err)
+		}
+	}
+
+	return b, nil
+}
+
+func isSOF(marker byte) bool {
+	return marker >= markerSOF0 && marker <= markerSOF15 &&
+		marker != markerDHT && marker != markerJPG && marker != markerDAC
+}
@@ -0,0 +1,326 @@
+package jpegmeta
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/jpeg"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testIFDEntry struct {
+	tag   uint16
+	typ   uint16
+	count uint32
+	value []byte
+}
+
+type testIFD struct {
+	entries []testIFDEntry
+	// pointers サブIFDを指すエントリのタグとそのIFD
+	pointers map[uint16]*testIFD
+}
+
+// buildTestExif ビッグエンディアンのTIFF構造を組み立てる
+func buildTestExif(ifd0 *testIFD) []byte {
+	buf := []byte("MM\x00\x2a\x00\x00\x00\x08")
+
+	var writeIFD func(ifd *testIFD) uint32
+	writeIFD = func(ifd *testIFD) uint32 {
+		entryCount := len(ifd.entries) + len(ifd.pointers)
+		offset := uint32(len(buf))
+		ifdSize := 2 + entryCount*ifdEntrySize + 4
+		buf = append(buf, make([]byte, ifdSize)...)
+		binary.BigEndian.PutUint16(buf[offset:], uint16(entryCount))
+
+		entryOffset := int(offset) + 2
+		for _, entry := range ifd.entries {
+			binary.BigEndian.PutUint16(buf[entryOffset:], entry.tag)
+			binary.BigEndian.PutUint16(buf[entryOffset+2:], entry.typ)
+			binary.BigEndian.PutUint32(buf[entryOffset+4:], entry.count)
+			if len(entry.value) <= 4 {
+				copy(buf[entryOffset+8:], entry.value)
+			} else {
+				binary.BigEndian.PutUint32(buf[entryOffset+8:], uint32(len(buf)))
+				buf = append(buf, entry.value...)
+			}
+			entryOffset += ifdEntrySize
+		}
+
+		for tag, pointer := range ifd.pointers {
+			pointerEntryOffset := entryOffset
+			binary.BigEndian.PutUint16(buf[pointerEntryOffset:], tag)
+			binary.BigEndian.PutUint16(buf[pointerEntryOffset+2:], 4)
+			binary.BigEndian.PutUint32(buf[pointerEntryOffset+4:], 1)
+			subOffset := writeIFD(pointer)
+			binary.BigEndian.PutUint32(buf[pointerEntryOffset+8:], subOffset)
+			entryOffset += ifdEntrySize
+		}
+
+		return offset
+	}
+	writeIFD(ifd0)
+
+	return buf
+}
+
+func asciiEntry(tag uint16, value string) testIFDEntry {
+	return testIFDEntry{
+		tag:   tag,
+		typ:   2,
+		count: uint32(len(value) + 1),
+		value: append([]byte(value), 0),
+	}
+}
+
+func segment(marker byte, payload []byte) []byte {
+	buf := []byte{0xff, marker, 0, 0}
+	binary.BigEndian.PutUint16(buf[2:], uint16(len(payload)+2))
+
+	return append(buf, payload...)
+}
+
+func buildTestJPEG(t *testing.T, segments ...[]byte) []byte {
+	t.Helper()
+
+	buf := bytes.NewBuffer(nil)
+	err := jpeg.Encode(buf, image.NewRGBA(image.Rect(0, 0, 3, 2)), nil)
+	if err != nil {
+		t.Fatalf("failed to encode jpeg: %v", err)
+	}
+	encoded := buf.Bytes()
+
+	jpegBytes := append([]byte{}, encoded[:2]...)
+	for _, segment := range segments {
+		jpegBytes = append(jpegBytes, segment...)
+	}
+
+	return append(jpegBytes, encoded[2:]...)
+}
+
+const (
+	testModel        = "Test Camera"
+	testSerialNumber = "SERIAL-0123456789"
+	testGPSTag       = 0x0002
+)
+
+var testLatitude = []byte("LATITUDE-RATIONALS-24BYT")
+
+func testExifSegment() []byte {
+	orientation := []byte{0, 6, 0, 0}
+
+	return segment(markerAPP1, append(append([]byte{}, exifHeader...), buildTestExif(&testIFD{
+		entries: []testIFDEntry{
+			asciiEntry(tagModel, testModel),
+			{tag: tagOrientation, typ: 3, count: 1, value: orientation},
+		},
+		pointers: map[uint16]*testIFD{
+			tagExifIFD: {
+				entries: []testIFDEntry{
+					asciiEntry(tagDateTimeOriginal, "2021:08:01 12:34:56"),
+					asciiEntry(tagOffsetTimeOriginal, "+09:00"),
+					asciiEntry(tagBodySerialNumber, testSerialNumber),
+				},
+			},
+			tagGPSIFD: {
+				entries: []testIFDEntry{
+					{tag: testGPSTag, typ: 5, count: 3, value: testLatitude},
+				},
+			},
+		},
+	})...))
+}
+
+func TestProcess(t *testing.T) {
+	t.Parallel()
+
+	capturedAt := time.Date(2021, 8, 1, 12, 34, 56, 0, time.FixedZone("", 9*60*60))
+	xmpCreatedAt := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	iptcCreatedAt := time.Date(2019, 5, 6, 7, 8, 9, 0, time.FixedZone("", 9*60*60))
+
+	xmpSegment := segment(markerAPP1, append(append([]byte{}, xmpHeader...),
+		[]byte(`<x:xmpmeta><rdf:Description tiff:Model="XMP Camera" xmp:CreateDate="2020-01-02T03:04:05Z" exif:GPSLatitude="35,40N"/></x:xmpmeta>`)...))
+
+	iptcDataSets := []byte{
+		0x1c, 2, 55, 0, 8, '2', '0', '1', '9', '0', '5', '0', '6',
+		0x1c, 2, 60, 0, 11, '0', '7', '0', '8', '0', '9', '+', '0', '9', '0', '0',
+	}
+	iptcResource := append([]byte("8BIM\x04\x04\x00\x00"), 0, 0, 0, byte(len(iptcDataSets)))
+	iptcResource = append(iptcResource, iptcDataSets...)
+	iptcSegment := segment(markerAPP13, append(append([]byte{}, photoshopHeader...), iptcResource...))
+
+	type test struct {
+		description string
+		input       []byte
+		strip       bool
+		metadata    *Metadata
+		removed     [][]byte
+		kept        [][]byte
+		isErr       bool
+		err         error
+	}
+
+	testCases := []test{
+		{
+			description: "EXIFから情報を取り出し、位置情報を取り除く",
+			input:       buildTestJPEG(t, testExifSegment()),
+			strip:       true,
+			metadata: &Metadata{
+				Width:       3,
+				Height:      2,
+				Components:  3,
+				Orientation: 6,
+				CapturedAt:  &capturedAt,
+				CameraModel: testModel,
+			},
+			removed: [][]byte{testLatitude, []byte(testSerialNumber)},
+			kept:    [][]byte{[]byte(testModel)},
+		},
+		{
+			description: "stripがfalseなのでEXIFはそのまま",
+			input:       buildTestJPEG(t, testExifSegment()),
+			strip:       false,
+			metadata: &Metadata{
+				Width:       3,
+				Height:      2,
+				Components:  3,
+				Orientation: 6,
+				CapturedAt:  &capturedAt,
+				CameraModel: testModel,
+			},
+			kept: [][]byte{testLatitude, []byte(testSerialNumber), []byte(testModel)},
+		},
+		{
+			description: "EXIFがなければXMPから情報を取り出し、XMPを取り除く",
+			input:       buildTestJPEG(t, xmpSegment),
+			strip:       true,
+			metadata: &Metadata{
+				Width:       3,
+				Height:      2,
+				CapturedAt:  &xmpCreatedAt,
+				CameraModel: "XMP Camera",
+			},
+			removed: [][]byte{[]byte("GPSLatitude")},
+		},
+		{
+			description: "EXIFの情報がXMPより優先される",
+			input:       buildTestJPEG(t, testExifSegment(), xmpSegment),
+			strip:       true,
+			metadata: &Metadata{
+				Width:       3,
+				Height:      2,
+				Components:  3,
+				Orientation: 6,
+				CapturedAt:  &capturedAt,
+				CameraModel: testModel,
+			},
+		},
+		{
+			description: "IPTCから撮影日時を取り出し、IPTCを取り除く",
+			input:       buildTestJPEG(t, iptcSegment),
+			strip:       true,
+			metadata: &Metadata{
+				Width:      3,
+				Height:     2,
+				CapturedAt: &iptcCreatedAt,
+			},
+			removed: [][]byte{photoshopHeader},
+		},
+		{
+			description: "解析できないEXIFはstripがtrueなら丸ごと取り除く",
+			input:       buildTestJPEG(t, segment(markerAPP1, append(append([]byte{}, exifHeader...), []byte("XX-broken-GPS")...))),
+			strip:       true,
+			metadata: &Metadata{
+				Width:  3,
+				Height: 2,
+			},
+			removed: [][]byte{[]byte("broken-GPS")},
+		},
+		{
+			description: "メタデータがなくても画像の大きさは取り出せる",
+			input:       buildTestJPEG(t),
+			strip:       true,
+			metadata: &Metadata{
+				Width:  3,
+				Height: 2,
+			},
+		},
+		{
+			description: "JPEGでないのでエラー",
+			input:       []byte("\x89PNG\r\n\x1a\n"),
+			strip:       true,
+			isErr:       true,
+			err:         ErrInvalidJPEG,
+		},
+		{
+			description: "セグメントが途中で終わっているのでエラー",
+			input:       []byte{0xff, markerSOI, 0xff, markerAPP1, 0x10, 0x00, 'E'},
+			strip:       true,
+			isErr:       true,
+		},
+	}
+
+	for _, testCase := range testCases {
+		testCase := testCase
+
+		t.Run(testCase.description, func(t *testing.T) {
+			t.Parallel()
+
+			metadata, reader, err := Process(bytes.NewReader(testCase.input), testCase.strip)
+
+			if testCase.isErr {
+				if testCase.err == nil {
+					assert.Error(t, err)
+				} else if !assert.ErrorIs(t, err, testCase.err) {
+					t.Logf("error: %v", err)
+				}
+				return
+			}
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			assert.Equal(t, testCase.metadata.Width, metadata.Width)
+			assert.Equal(t, testCase.metadata.Height, metadata.Height)
+			if testCase.metadata.Components != 0 {
+				assert.Equal(t, testCase.metadata.Components, metadata.Components)
+			}
+			assert.Equal(t, testCase.metadata.Orientation, metadata.Orientation)
+			assert.Equal(t, testCase.metadata.CameraModel, metadata.CameraModel)
+			if testCase.metadata.CapturedAt == nil {
+				assert.Nil(t, metadata.CapturedAt)
+			} else if assert.NotNil(t, metadata.CapturedAt) {
+				assert.True(t, testCase.metadata.CapturedAt.Equal(*metadata.CapturedAt))
+			}
+
+			output, err := io.ReadAll(reader)
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			for _, removed := range testCase.removed {
+				assert.NotContains(t, string(output), string(removed))
+			}
+			for _, kept := range testCase.kept {
+				assert.Contains(t, string(output), string(kept))
+			}
+
+			// 処理後も画像として読める
+			_, err = jpeg.Decode(bytes.NewReader(output))
+			assert.NoError(t, err)
+
+			// 処理後も取り除いていない情報は読み出せる
+			reprocessed, _, err := Process(bytes.NewReader(output), false)
+			if !assert.NoError(t, err) {
+				return
+			}
+			if testCase.strip {
+				assert.Equal(t, testCase.metadata.Orientation, reprocessed.Orientation)
+			}
+		})
+	}
+}
@@ -0,0 +1,89 @@
+package jpegmeta
+
+import (
+	"regexp"
+	"strings"
+	"time"
+)
+
+var xmpTimeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04-07:00",
+	"2006-01-02T15:04",
+	"2006-01-02",
+}
+
+type xmpMetadata struct {
+	model     string
+	createdAt *time.Time
+}
+
+func xmpPropertyRegex(name string) *regexp.Regexp {
+	// 属性と要素のどちらの形式でも書ける
+	return regexp.MustCompile(`(?s)` + name + `(?:\s*=\s*"([^"]*)"|>([^<]*)<)`)
+}
+
+var (
+	xmpModelRegex = xmpPropertyRegex(`tiff:Model`)
+	// xmpDateRegexes 優先度順
+	xmpDateRegexes = []*regexp.Regexp{
+		xmpPropertyRegex(`exif:DateTimeOriginal`),
+		xmpPropertyRegex(`photoshop:DateCreated`),
+		xmpPropertyRegex(`xmp:CreateDate`),
+	}
+)
+
+func parseXMP(data []byte) *xmpMetadata {
+	xmp := &xmpMetadata{
+		model: findXMPProperty(xmpModelRegex, data),
+	}
+
+	for _, dateRegex := range xmpDateRegexes {
+		value := findXMPProperty(dateRegex, data)
+		if value == "" {
+			continue
+		}
+
+		xmp.createdAt = parseXMPTime(value)
+		if xmp.createdAt != nil {
+			break
+		}
+	}
+
+	return xmp
+}
+
+func findXMPProperty(propertyRegex *regexp.Regexp, data []byte) string {
+	match := propertyRegex.FindSubmatch(data)
+	if match == nil {
+		return ""
+	}
+
+	if len(match[1]) != 0 {
+		return strings.TrimSpace(string(match[1]))
+	}
+
+	return strings.TrimSpace(string(match[2]))
+}
+
+func parseXMPTime(value string) *time.Time {
+	for _, layout := range xmpTimeLayouts {
+		t, err := time.ParseInLocation(layout, value, time.Local)
+		if err == nil {
+			return &t
+		}
+	}
+
+	return nil
+}
+
+func (xm *xmpMetadata) apply(metadata *Metadata) {
+	if metadata.CameraModel == "" {
+		metadata.CameraModel = xm.model
+	}
+
+	if metadata.CapturedAt == nil {
+		metadata.CapturedAt = xm.createdAt
+	}
+}
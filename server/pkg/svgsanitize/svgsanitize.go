@@ -0,0 +1,312 @@
+package svgsanitize
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+var ErrInvalidSVG = errors.New("invalid svg")
+
+// removedElements 子要素ごと取り除く要素。名前空間の接頭辞に関わらず、小文字にした要素名で判定する
+var removedElements = map[string]struct{}{
+	"script":        {},
+	"foreignobject": {},
+	"iframe":        {},
+	"frame":         {},
+	"object":        {},
+	"embed":         {},
+	"handler":       {},
+	"listener":      {},
+	"link":          {},
+	"meta":          {},
+	"base":          {},
+}
+
+// animationElements 他の属性の値を書き換えられる要素
+var animationElements = map[string]struct{}{
+	"animate":          {},
+	"animatecolor":     {},
+	"animatemotion":    {},
+	"animatetransform": {},
+	"set":              {},
+}
+
+var (
+	cssImportRegex = regexp.MustCompile(`(?i)@import[^;]*;?`)
+	cssURLRegex    = regexp.MustCompile(`(?i)url\(\s*("[^"]*"|'[^']*'|[^)]*?)\s*\)`)
+	// safeDataURLRegex 画像として読み込んでもスクリプトが実行されない種類のdata URL
+	safeDataURLRegex = regexp.MustCompile(`^data:image/(?:png|jpeg|gif|webp|avif)[;,]`)
+)
+
+var (
+	// textEscaper xml.EscapeTextと異なり、改行などはそのまま残す
+	textEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+	// attrEscaper 属性値の改行などは空白に正規化されるので、文字参照にする
+	attrEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", `"`, "&quot;", "\t", "&#x9;", "\n", "&#xA;", "\r", "&#xD;")
+)
+
+// sourceReader 読み込み元のエラーとXMLとして不正な場合のエラーを区別するため、読み込み元のエラーを記録する
+type sourceReader struct {
+	reader io.Reader
+	err    error
+}
+
+func (sr *sourceReader) Read(p []byte) (int, error) {
+	n, err := sr.reader.Read(p)
+	if err != nil && !errors.Is(err, io.EOF) {
+		sr.err = err
+	}
+
+	return n, err
+}
+
+// reader 読み込むごとにトークンを1つずつ無害化して書き出す
+type reader struct {
+	source  *sourceReader
+	decoder *xml.Decoder
+	buf     bytes.Buffer
+	// stack 開いている要素の名前
+	stack []xml.Name
+	// skipDepth 取り除いている要素の深さ。0の場合は取り除いていない
+	skipDepth int
+	hasRoot   bool
+	err       error
+}
+
+// NewReader SVGを読み込み、スクリプト、foreignObject、外部への参照、javascript: URLを取り除いたSVGを読み出すio.Readerを返す。
+// コメントやDOCTYPE宣言も取り除く。XMLとして不正な場合や、DOCTYPEで宣言された実体参照を含む場合はErrInvalidSVGを返す。
+func NewReader(r io.Reader) io.Reader {
+	source := &sourceReader{
+		reader: r,
+	}
+
+	decoder := xml.NewDecoder(source)
+	decoder.Strict = true
+
+	return &reader{
+		source:  source,
+		decoder: decoder,
+	}
+}
+
+func (r *reader) Read(p []byte) (int, error) {
+	for r.buf.Len() == 0 && r.err == nil {
+		r.err = r.next()
+	}
+
+	if r.buf.Len() != 0 {
+		return r.buf.Read(p)
+	}
+
+	return 0, r.err
+}
+
+func (r *reader) next() error {
+	token, err := r.decoder.RawToken()
+	if errors.Is(err, io.EOF) {
+		if len(r.stack) != 0 || !r.hasRoot {
+			return fmt.Errorf("unexpected end of svg: %w", ErrInvalidSVG)
+		}
+
+		return io.EOF
+	}
+	if err != nil && r.source.err != nil {
+		return fmt.Errorf("failed to read svg: %w", r.source.err)
+	}
+	if err != nil {
+		// 構文の誤りや、対応していない文字コードの場合
+		return fmt.Errorf("%v: %w", err, ErrInvalidSVG)
+	}
+
+	switch token := token.(type) {
+	case xml.StartElement:
+		if len(r.stack) == 0 {
+			if r.hasRoot || strings.ToLower(token.Name.Local) != "svg" {
+				return fmt.Errorf("root element must be svg: %w", ErrInvalidSVG)
+			}
+			r.hasRoot = true
+		}
+		r.stack = append(r.stack, token.Name)
+
+		if r.skipDepth != 0 {
+			r.skipDepth++
+			return nil
+		}
+
+		if isRemovedElement(token) {
+			r.skipDepth = 1
+			return nil
+		}
+
+		r.writeStartElement(token)
+	case xml.EndElement:
+		if len(r.stack) == 0 || r.stack[len(r.stack)-1] != token.Name {
+			return fmt.Errorf("unexpected end element(%s): %w", qualifiedName(token.Name), ErrInvalidSVG)
+		}
+		r.stack = r.stack[:len(r.stack)-1]
+
+		if r.skipDepth != 0 {
+			r.skipDepth--
+			return nil
+		}
+
+		r.buf.WriteString("</")
+		r.buf.WriteString(qualifiedName(token.Name))
+		r.buf.WriteString(">")
+	case xml.CharData:
+		if r.skipDepth != 0 {
+			return nil
+		}
+
+		text := string(token)
+		if len(r.stack) != 0 && strings.ToLower(r.stack[len(r.stack)-1].Local) == "style" {
+			var ok bool
+			text, ok = sanitizeCSS(text)
+			if !ok {
+				return nil
+			}
+		}
+
+		r.buf.WriteString(textEscaper.Replace(text))
+	case xml.ProcInst:
+		// xml-stylesheetなどで外部のファイルを読み込めるので、XML宣言以外は取り除く
+		if token.Target == "xml" && len(r.stack) == 0 && !r.hasRoot {
+			r.buf.WriteString("<?xml ")
+			r.buf.Write(token.Inst)
+			r.buf.WriteString("?>")
+		}
+	case xml.Comment, xml.Directive:
+		// コメントとDOCTYPE宣言は表示に影響しないので取り除く
+	}
+
+	return nil
+}
+
+func (r *reader) writeStartElement(element xml.StartElement) {
+	r.buf.WriteString("<")
+	r.buf.WriteString(qualifiedName(element.Name))
+
+	for _, attr := range element.Attr {
+		value, ok := sanitizeAttr(attr)
+		if !ok {
+			continue
+		}
+
+		r.buf.WriteString(" ")
+		r.buf.WriteString(qualifiedName(attr.Name))
+		r.buf.WriteString(`="`)
+		r.buf.WriteString(attrEscaper.Replace(value))
+		r.buf.WriteString(`"`)
+	}
+
+	r.buf.WriteString(">")
+}
+
+func isRemovedElement(element xml.StartElement) bool {
+	name := strings.ToLower(element.Name.Local)
+	if _, ok := removedElements[name]; ok {
+		return true
+	}
+
+	// アニメーションでhrefを書き換えると、外部への参照やjavascript: URLを作れる
+	if _, ok := animationElements[name]; ok {
+		for _, attr := range element.Attr {
+			if strings.ToLower(attr.Name.Local) != "attributename" {
+				continue
+			}
+
+			target := strings.ToLower(strings.TrimSpace(attr.Value))
+			if i := strings.LastIndex(target, ":"); i >= 0 {
+				target = target[i+1:]
+			}
+
+			if target == "href" || target == "src" {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// sanitizeAttr 取り除く属性の場合はfalseを返す
+func sanitizeAttr(attr xml.Attr) (string, bool) {
+	name := strings.ToLower(attr.Name.Local)
+	space := strings.ToLower(attr.Name.Space)
+
+	// onloadなどのイベントハンドラー
+	if strings.HasPrefix(name, "on") {
+		return "", false
+	}
+
+	// 相対的な参照の基準を変えられる
+	if space == "xml" && name == "base" {
+		return "", false
+	}
+
+	normalized := normalizeURL(attr.Value)
+	if strings.Contains(normalized, "javascript:") ||
+		strings.Contains(normalized, "vbscript:") ||
+		strings.Contains(normalized, "data:text/html") {
+		return "", false
+	}
+
+	if name == "href" || name == "src" {
+		if strings.HasPrefix(normalized, "#") || safeDataURLRegex.MatchString(normalized) {
+			return attr.Value, true
+		}
+
+		return "", false
+	}
+
+	if name == "style" || strings.Contains(normalized, "url(") {
+		return sanitizeCSS(attr.Value)
+	}
+
+	return attr.Value, true
+}
+
+// sanitizeCSS @importと、文書内以外を参照するurl()を取り除く。
+// エスケープで判定をすり抜けられるので、バックスラッシュを含む場合は全体を取り除く。
+func sanitizeCSS(css string) (string, bool) {
+	if strings.Contains(css, `\`) {
+		return "", false
+	}
+
+	css = cssImportRegex.ReplaceAllString(css, "")
+	css = cssURLRegex.ReplaceAllStringFunc(css, func(match string) string {
+		target := cssURLRegex.FindStringSubmatch(match)[1]
+		target = strings.Trim(target, `"'`)
+		if strings.HasPrefix(normalizeURL(target), "#") {
+			return match
+		}
+
+		return "none"
+	})
+
+	return css, true
+}
+
+// normalizeURL ブラウザはURL中の空白や制御文字を無視するので、取り除いてから小文字にする
+func normalizeURL(value string) string {
+	return strings.ToLower(strings.Map(func(r rune) rune {
+		if r <= ' ' || r == 0x7f {
+			return -1
+		}
+
+		return r
+	}, value))
+}
+
+func qualifiedName(name xml.Name) string {
+	if len(name.Space) == 0 {
+		return name.Local
+	}
+
+	return name.Space + ":" + name.Local
+}
@@ -0,0 +1,140 @@
+package svgsanitize
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type errReader struct{}
+
+func (errReader) Read([]byte) (int, error) {
+	return 0, errors.New("read error")
+}
+
+func TestNewReader(t *testing.T) {
+	t.Parallel()
+
+	type test struct {
+		description string
+		reader      io.Reader
+		expected    string
+		isErr       bool
+		err         error
+	}
+
+	testCases := []test{
+		{
+			description: "無害なSVGはそのまま",
+			reader:      strings.NewReader(`<?xml version="1.0" encoding="UTF-8"?><svg xmlns="http://www.w3.org/2000/svg" width="10" height="10"><rect fill="url(#g)" width="10" height="10"/></svg>`),
+			expected:    `<?xml version="1.0" encoding="UTF-8"?><svg xmlns="http://www.w3.org/2000/svg" width="10" height="10"><rect fill="url(#g)" width="10" height="10"></rect></svg>`,
+		},
+		{
+			description: "scriptとforeignObjectは子要素ごと取り除く",
+			reader:      strings.NewReader(`<svg><script>alert(1)</script><foreignObject><div><script>alert(2)</script></div></foreignObject><g/></svg>`),
+			expected:    `<svg><g></g></svg>`,
+		},
+		{
+			description: "名前空間の接頭辞付きのscriptも取り除く",
+			reader:      strings.NewReader(`<svg xmlns:h="http://www.w3.org/1999/xhtml"><h:script>alert(1)</h:script></svg>`),
+			expected:    `<svg xmlns:h="http://www.w3.org/1999/xhtml"></svg>`,
+		},
+		{
+			description: "イベントハンドラーを取り除く",
+			reader:      strings.NewReader(`<svg onload="alert(1)"><circle r="1" OnClick="alert(2)"/></svg>`),
+			expected:    `<svg><circle r="1"></circle></svg>`,
+		},
+		{
+			description: "空白を挟んだjavascript: URLを取り除く",
+			reader:      strings.NewReader("<svg><a href=\"java\tscript:alert(1)\"><text>a</text></a></svg>"),
+			expected:    `<svg><a><text>a</text></a></svg>`,
+		},
+		{
+			description: "文字参照で書かれたjavascript: URLを取り除く",
+			reader:      strings.NewReader(`<svg xmlns:xlink="http://www.w3.org/1999/xlink"><a xlink:href="&#106;avascript:alert(1)"></a></svg>`),
+			expected:    `<svg xmlns:xlink="http://www.w3.org/1999/xlink"><a></a></svg>`,
+		},
+		{
+			description: "外部への参照を取り除き、文書内と画像のdata URLは残す",
+			reader:      strings.NewReader(`<svg><use href="https://example.com/a.svg#x"/><use href="#x"/><image href="data:image/png;base64,AAAA"/><image href="data:image/svg+xml;base64,AAAA"/></svg>`),
+			expected:    `<svg><use></use><use href="#x"></use><image href="data:image/png;base64,AAAA"></image><image></image></svg>`,
+		},
+		{
+			description: "hrefを書き換えるアニメーションを取り除く",
+			reader:      strings.NewReader(`<svg><a><set attributeName="xlink:href" to="https://example.com"/><animate attributeName="opacity" to="0"/></a></svg>`),
+			expected:    `<svg><a><animate attributeName="opacity" to="0"></animate></a></svg>`,
+		},
+		{
+			description: "CSSの@importと外部へのurl()を取り除く",
+			reader:      strings.NewReader(`<svg><style>@import "https://example.com/a.css"; rect { fill: url(https://example.com/a.png); stroke: url('#g') }</style><rect style="fill: url(//example.com/a.png)"/></svg>`),
+			expected:    `<svg><style> rect { fill: none; stroke: url('#g') }</style><rect style="fill: none"></rect></svg>`,
+		},
+		{
+			description: "バックスラッシュを含むCSSは取り除く",
+			reader:      strings.NewReader(`<svg><rect style="fill: \75rl(https://example.com)"/></svg>`),
+			expected:    `<svg><rect></rect></svg>`,
+		},
+		{
+			description: "コメント、DOCTYPE、xml-stylesheetを取り除く",
+			reader:      strings.NewReader("<?xml version=\"1.0\"?>\n<?xml-stylesheet href=\"https://example.com/a.css\"?>\n<!DOCTYPE svg>\n<!-- comment --><svg>\n<g/>\n</svg>"),
+			expected:    "<?xml version=\"1.0\"?>\n\n\n<svg>\n<g></g>\n</svg>",
+		},
+		{
+			description: "DOCTYPEで宣言された実体参照はエラー",
+			reader:      strings.NewReader(`<!DOCTYPE svg [<!ENTITY a "aaaa">]><svg>&a;</svg>`),
+			isErr:       true,
+			err:         ErrInvalidSVG,
+		},
+		{
+			description: "タグが閉じていないのでエラー",
+			reader:      strings.NewReader(`<svg><g></svg>`),
+			isErr:       true,
+			err:         ErrInvalidSVG,
+		},
+		{
+			description: "途中で終わっているのでエラー",
+			reader:      strings.NewReader(`<svg><g>`),
+			isErr:       true,
+			err:         ErrInvalidSVG,
+		},
+		{
+			description: "ルート要素がsvgでないのでエラー",
+			reader:      strings.NewReader(`<html><svg></svg></html>`),
+			isErr:       true,
+			err:         ErrInvalidSVG,
+		},
+		{
+			description: "読み込み元のエラーはErrInvalidSVGにしない",
+			reader:      errReader{},
+			isErr:       true,
+		},
+	}
+
+	for _, testCase := range testCases {
+		testCase := testCase
+
+		t.Run(testCase.description, func(t *testing.T) {
+			t.Parallel()
+
+			actual, err := io.ReadAll(NewReader(testCase.reader))
+
+			if testCase.isErr {
+				if testCase.err == nil {
+					assert.Error(t, err)
+					assert.NotErrorIs(t, err, ErrInvalidSVG)
+				} else {
+					assert.ErrorIs(t, err, testCase.err)
+				}
+				return
+			}
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			assert.Equal(t, testCase.expected, string(actual))
+		})
+	}
+}
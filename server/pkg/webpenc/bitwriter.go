@@ -0,0 +1,30 @@
+package webpenc
+
+// bitWriter VP8Lのビット列は各バイトの下位ビットから詰めて書き込む
+type bitWriter struct {
+	buf   []byte
+	bits  uint64
+	nBits uint
+}
+
+// write valueの下位nビットを書き込む。nは32以下
+func (bw *bitWriter) write(value uint32, n uint) {
+	bw.bits |= uint64(value&(1<<n-1)) << bw.nBits
+	bw.nBits += n
+	for bw.nBits >= 8 {
+		bw.buf = append(bw.buf, byte(bw.bits))
+		bw.bits >>= 8
+		bw.nBits -= 8
+	}
+}
+
+// bytes 書き込み途中のビットを0で埋めて、書き込んだバイト列を返す
+func (bw *bitWriter) bytes() []byte {
+	if bw.nBits > 0 {
+		bw.buf = append(bw.buf, byte(bw.bits))
+		bw.bits = 0
+		bw.nBits = 0
+	}
+
+	return bw.buf
+}
@@ -0,0 +1,249 @@
+// Package webpenc 画像を可逆圧縮のWebP(VP8L)に符号化する。
+// 変換は緑の減算のみ、後方参照は左と上の画素の繰り返しのみの簡易的な符号化なので、
+// 写真ではlibwebpより大きくなるが、イラストやスクリーンショットでは十分小さくなる。
+package webpenc
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	"io"
+	"math/bits"
+)
+
+const (
+	// maxDimension VP8Lで表せる幅と高さの上限
+	maxDimension = 1 << 14
+
+	vp8lSignature = 0x2f
+	// transformSubtractGreen 赤と青から緑を引く変換
+	transformSubtractGreen = 2
+
+	literalAlphabetSize  = 256
+	lengthPrefixCount    = 24
+	distanceAlphabetSize = 40
+	// maxCopyLength 後方参照で繰り返せる画素数の上限
+	maxCopyLength = 4096
+	// minCopyLength これより短い繰り返しは後方参照にしてもほとんど小さくならない
+	minCopyLength = 3
+
+	// distanceCodeAbove 1つ上の画素への距離を表す距離コード
+	distanceCodeAbove = 1
+	// distanceCodeLeft 1つ左の画素への距離を表す距離コード
+	distanceCodeLeft = 2
+)
+
+var ErrTooLargeImage = errors.New("too large image")
+
+// token 画素をそのまま表すか、前の画素の繰り返しを表す
+type token struct {
+	argb         uint32
+	length       int
+	distanceCode int
+}
+
+func (t *token) isCopy() bool {
+	return t.length > 0
+}
+
+// Encode imgを可逆圧縮のWebPとしてwに書き込む
+func Encode(w io.Writer, img image.Image) error {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width < 1 || height < 1 || width > maxDimension || height > maxDimension {
+		return fmt.Errorf("%dx%d: %w", width, height, ErrTooLargeImage)
+	}
+
+	nrgba, ok := img.(*image.NRGBA)
+	if !ok || nrgba.Rect.Min != (image.Point{}) {
+		nrgba = image.NewNRGBA(image.Rect(0, 0, width, height))
+		draw.Draw(nrgba, nrgba.Bounds(), img, bounds.Min, draw.Src)
+	}
+
+	hasAlpha := false
+	pixels := make([]uint32, 0, width*height)
+	for y := 0; y < height; y++ {
+		row := nrgba.Pix[y*nrgba.Stride : y*nrgba.Stride+width*4]
+		for x := 0; x < width; x++ {
+			r, g, b, a := uint32(row[x*4]), uint32(row[x*4+1]), uint32(row[x*4+2]), uint32(row[x*4+3])
+			if a != 0xff {
+				hasAlpha = true
+			}
+
+			// 赤と青は緑と相関が強いので、緑を引くと値が0付近に集まり圧縮しやすくなる
+			r = (r - g) & 0xff
+			b = (b - g) & 0xff
+
+			pixels = append(pixels, a<<24|r<<16|g<<8|b)
+		}
+	}
+
+	bw := &bitWriter{}
+	bw.write(vp8lSignature, 8)
+	bw.write(uint32(width-1), 14)
+	bw.write(uint32(height-1), 14)
+	if hasAlpha {
+		bw.write(1, 1)
+	} else {
+		bw.write(0, 1)
+	}
+	// バージョン
+	bw.write(0, 3)
+
+	bw.write(1, 1)
+	bw.write(transformSubtractGreen, 2)
+	bw.write(0, 1)
+
+	// カラーキャッシュとメタプレフィックス符号は使わない
+	bw.write(0, 1)
+	bw.write(0, 1)
+
+	tokens := newTokens(pixels, width)
+
+	greenHistogram := make([]uint32, literalAlphabetSize+lengthPrefixCount)
+	redHistogram := make([]uint32, literalAlphabetSize)
+	blueHistogram := make([]uint32, literalAlphabetSize)
+	alphaHistogram := make([]uint32, literalAlphabetSize)
+	distanceHistogram := make([]uint32, distanceAlphabetSize)
+	for i := range tokens {
+		t := &tokens[i]
+		if t.isCopy() {
+			lengthPrefix, _, _ := prefixEncode(t.length)
+			greenHistogram[literalAlphabetSize+lengthPrefix]++
+			distancePrefix, _, _ := prefixEncode(t.distanceCode)
+			distanceHistogram[distancePrefix]++
+			continue
+		}
+
+		greenHistogram[t.argb>>8&0xff]++
+		redHistogram[t.argb>>16&0xff]++
+		blueHistogram[t.argb&0xff]++
+		alphaHistogram[t.argb>>24]++
+	}
+
+	// 最初の画素は必ずそのまま表すので、緑の符号で1つだけ使われる記号は256未満になる
+	greenCode := newHuffmanCode(greenHistogram, maxCodeLength)
+	redCode := newHuffmanCode(redHistogram, maxCodeLength)
+	blueCode := newHuffmanCode(blueHistogram, maxCodeLength)
+	alphaCode := newHuffmanCode(alphaHistogram, maxCodeLength)
+	distanceCode := newHuffmanCode(distanceHistogram, maxCodeLength)
+
+	for _, code := range []*huffmanCode{greenCode, redCode, blueCode, alphaCode, distanceCode} {
+		code.writeCode(bw)
+	}
+
+	for i := range tokens {
+		t := &tokens[i]
+		if t.isCopy() {
+			lengthPrefix, lengthExtraBits, lengthExtra := prefixEncode(t.length)
+			greenCode.writeSymbol(bw, literalAlphabetSize+lengthPrefix)
+			bw.write(lengthExtra, lengthExtraBits)
+
+			distancePrefix, distanceExtraBits, distanceExtra := prefixEncode(t.distanceCode)
+			distanceCode.writeSymbol(bw, distancePrefix)
+			bw.write(distanceExtra, distanceExtraBits)
+			continue
+		}
+
+		greenCode.writeSymbol(bw, int(t.argb>>8&0xff))
+		redCode.writeSymbol(bw, int(t.argb>>16&0xff))
+		blueCode.writeSymbol(bw, int(t.argb&0xff))
+		alphaCode.writeSymbol(bw, int(t.argb>>24))
+	}
+
+	err := writeRIFF(w, bw.bytes())
+	if err != nil {
+		return fmt.Errorf("failed to write riff: %w", err)
+	}
+
+	return nil
+}
+
+// newTokens 左か上の画素の繰り返しを後方参照にし、それ以外の画素はそのまま表す
+func newTokens(pixels []uint32, width int) []token {
+	tokens := make([]token, 0, len(pixels))
+	for i := 0; i < len(pixels); {
+		var (
+			length       int
+			distanceCode int
+		)
+		if i >= 1 {
+			if leftLength := matchLength(pixels, i, 1); leftLength > length {
+				length, distanceCode = leftLength, distanceCodeLeft
+			}
+		}
+		if i >= width {
+			if aboveLength := matchLength(pixels, i, width); aboveLength > length {
+				length, distanceCode = aboveLength, distanceCodeAbove
+			}
+		}
+
+		if length >= minCopyLength {
+			tokens = append(tokens, token{length: length, distanceCode: distanceCode})
+			i += length
+			continue
+		}
+
+		tokens = append(tokens, token{argb: pixels[i]})
+		i++
+	}
+
+	return tokens
+}
+
+// matchLength i番目からの画素がdistance前の画素と何個一致するか
+func matchLength(pixels []uint32, i int, distance int) int {
+	length := 0
+	for i+length < len(pixels) && length < maxCopyLength && pixels[i+length] == pixels[i+length-distance] {
+		length++
+	}
+
+	return length
+}
+
+// prefixEncode 長さと距離コードを、プレフィックス符号で表す値と追加のビットに分ける
+func prefixEncode(value int) (int, uint, uint32) {
+	v := value - 1
+	if v < 4 {
+		return v, 0, 0
+	}
+
+	highestBit := bits.Len(uint(v)) - 1
+	secondBit := (v >> (highestBit - 1)) & 1
+	extraBits := uint(highestBit - 1)
+
+	return 2*highestBit + secondBit, extraBits, uint32(v) & (1<<extraBits - 1)
+}
+
+func writeRIFF(w io.Writer, vp8l []byte) error {
+	chunkSize := len(vp8l)
+	padding := chunkSize & 1
+
+	header := make([]byte, 20)
+	copy(header[0:4], "RIFF")
+	binary.LittleEndian.PutUint32(header[4:8], uint32(4+8+chunkSize+padding))
+	copy(header[8:12], "WEBP")
+	copy(header[12:16], "VP8L")
+	binary.LittleEndian.PutUint32(header[16:20], uint32(chunkSize))
+
+	_, err := w.Write(header)
+	if err != nil {
+		return fmt.Errorf("failed to write header: %w", err)
+	}
+
+	_, err = w.Write(vp8l)
+	if err != nil {
+		return fmt.Errorf("failed to write vp8l: %w", err)
+	}
+
+	if padding != 0 {
+		_, err = w.Write([]byte{0})
+		if err != nil {
+			return fmt.Errorf("failed to write padding: %w", err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,143 @@
+package webpenc
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/image/webp"
+)
+
+func newTestImage(width int, height int, fill func(x int, y int) color.NRGBA) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.SetNRGBA(x, y, fill(x, y))
+		}
+	}
+
+	return img
+}
+
+func TestEncode(t *testing.T) {
+	t.Parallel()
+
+	random := rand.New(rand.NewSource(0))
+	noise := newTestImage(97, 61, func(x int, y int) color.NRGBA {
+		return color.NRGBA{R: uint8(random.Intn(256)), G: uint8(random.Intn(256)), B: uint8(random.Intn(256)), A: 0xff}
+	})
+
+	type test struct {
+		description string
+		img         image.Image
+		isErr       bool
+		err         error
+	}
+
+	testCases := []test{
+		{
+			description: "一色の画像",
+			img: newTestImage(64, 64, func(x int, y int) color.NRGBA {
+				return color.NRGBA{R: 0x12, G: 0x34, B: 0x56, A: 0xff}
+			}),
+		},
+		{
+			description: "1x1の画像",
+			img: newTestImage(1, 1, func(x int, y int) color.NRGBA {
+				return color.NRGBA{R: 0xff, A: 0xff}
+			}),
+		},
+		{
+			description: "縦縞の画像は上の画素の繰り返しで表す",
+			img: newTestImage(100, 50, func(x int, y int) color.NRGBA {
+				return color.NRGBA{R: uint8(x * 2), G: uint8(255 - x), B: uint8(x % 7 * 30), A: 0xff}
+			}),
+		},
+		{
+			description: "透過のある画像",
+			img: newTestImage(33, 17, func(x int, y int) color.NRGBA {
+				return color.NRGBA{R: uint8(x * 7), G: uint8(y * 15), B: 0x80, A: uint8((x + y) * 5)}
+			}),
+		},
+		{
+			description: "ノイズの画像",
+			img:         noise,
+		},
+		{
+			description: "NRGBA以外の画像",
+			img:         image.NewGray16(image.Rect(10, 10, 30, 20)),
+		},
+		{
+			description: "大きすぎるのでErrTooLargeImage",
+			img:         image.NewNRGBA(image.Rect(0, 0, maxDimension+1, 1)),
+			isErr:       true,
+			err:         ErrTooLargeImage,
+		},
+	}
+
+	for _, testCase := range testCases {
+		testCase := testCase
+		t.Run(testCase.description, func(t *testing.T) {
+			t.Parallel()
+
+			buf := bytes.NewBuffer(nil)
+			err := Encode(buf, testCase.img)
+
+			if testCase.isErr {
+				if testCase.err == nil {
+					assert.Error(t, err)
+				} else if !errors.Is(err, testCase.err) {
+					t.Errorf("error must be %v, but actual is %v", testCase.err, err)
+				}
+				return
+			}
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			decoded, err := webp.Decode(buf)
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			bounds := testCase.img.Bounds()
+			if !assert.Equal(t, bounds.Dx(), decoded.Bounds().Dx()) || !assert.Equal(t, bounds.Dy(), decoded.Bounds().Dy()) {
+				return
+			}
+
+			for y := 0; y < bounds.Dy(); y++ {
+				for x := 0; x < bounds.Dx(); x++ {
+					expected := color.NRGBAModel.Convert(testCase.img.At(bounds.Min.X+x, bounds.Min.Y+y))
+					actual := color.NRGBAModel.Convert(decoded.At(decoded.Bounds().Min.X+x, decoded.Bounds().Min.Y+y))
+					if expected != actual {
+						t.Fatalf("pixel (%d, %d) must be %v, but actual is %v", x, y, expected, actual)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestPrefixEncode(t *testing.T) {
+	t.Parallel()
+
+	// 復号の手順で元の値に戻ることを確かめる
+	for value := 1; value <= maxCopyLength; value++ {
+		prefix, extraBits, extra := prefixEncode(value)
+		if prefix < 4 {
+			assert.Equal(t, value, prefix+1)
+			assert.Equal(t, uint(0), extraBits)
+			continue
+		}
+
+		decodedExtraBits := uint((prefix - 2) >> 1)
+		offset := (2 + prefix&1) << decodedExtraBits
+		assert.Equal(t, decodedExtraBits, extraBits)
+		assert.Equal(t, value, offset+int(extra)+1)
+		assert.Less(t, prefix, lengthPrefixCount)
+	}
+}
@@ -0,0 +1,276 @@
+package webpenc
+
+import (
+	"math/bits"
+	"sort"
+)
+
+const (
+	// maxCodeLength VP8Lのプレフィックス符号の符号長の上限
+	maxCodeLength = 15
+	// maxCodeLengthCodeLength 符号長を符号化するプレフィックス符号の符号長の上限
+	maxCodeLengthCodeLength = 7
+
+	codeLengthRepeatPrevious  = 16
+	codeLengthRepeatZeros     = 17
+	codeLengthRepeatManyZeros = 18
+)
+
+// codeLengthCodeOrder 符号長を符号化するプレフィックス符号の符号長を書き込む順
+var codeLengthCodeOrder = [19]int{17, 18, 0, 1, 2, 3, 4, 5, 16, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15}
+
+// huffmanCode 符号長と、下位ビットから書き込めるようビットを反転した正準ハフマン符号
+type huffmanCode struct {
+	lengths []uint8
+	codes   []uint32
+	// isSingle 使う記号が1つ以下の場合、記号は0ビットで表される
+	isSingle bool
+}
+
+func newHuffmanCode(histogram []uint32, maxLength int) *huffmanCode {
+	lengths := buildCodeLengths(histogram, maxLength)
+
+	usedSymbols := 0
+	for _, length := range lengths {
+		if length != 0 {
+			usedSymbols++
+		}
+	}
+
+	return &huffmanCode{
+		lengths:  lengths,
+		codes:    buildCodes(lengths),
+		isSingle: usedSymbols <= 1,
+	}
+}
+
+func (hc *huffmanCode) writeSymbol(bw *bitWriter, symbol int) {
+	if hc.isSingle {
+		return
+	}
+
+	bw.write(hc.codes[symbol], uint(hc.lengths[symbol]))
+}
+
+// writeCode 復号に必要なプレフィックス符号の符号長を書き込む
+func (hc *huffmanCode) writeCode(bw *bitWriter) {
+	if hc.isSingle {
+		writeSimpleCode(bw, hc.lengths)
+		return
+	}
+
+	// 符号長の並びを0の連続と同じ符号長の連続を縮めた記号列にする
+	type codeLengthToken struct {
+		symbol    int
+		extra     uint32
+		extraBits uint
+	}
+	tokens := make([]codeLengthToken, 0, len(hc.lengths))
+	for i := 0; i < len(hc.lengths); {
+		length := hc.lengths[i]
+		run := 1
+		for i+run < len(hc.lengths) && hc.lengths[i+run] == length {
+			run++
+		}
+
+		if length == 0 {
+			for remaining := run; remaining > 0; {
+				switch {
+				case remaining >= 11:
+					repeat := min(remaining, 138)
+					tokens = append(tokens, codeLengthToken{symbol: codeLengthRepeatManyZeros, extra: uint32(repeat - 11), extraBits: 7})
+					remaining -= repeat
+				case remaining >= 3:
+					tokens = append(tokens, codeLengthToken{symbol: codeLengthRepeatZeros, extra: uint32(remaining - 3), extraBits: 3})
+					remaining = 0
+				default:
+					tokens = append(tokens, codeLengthToken{symbol: 0})
+					remaining--
+				}
+			}
+		} else {
+			// 直前の0以外の符号長を繰り返すので、最初の1つはそのまま書き込む
+			tokens = append(tokens, codeLengthToken{symbol: int(length)})
+			for remaining := run - 1; remaining > 0; {
+				if remaining >= 3 {
+					repeat := min(remaining, 6)
+					tokens = append(tokens, codeLengthToken{symbol: codeLengthRepeatPrevious, extra: uint32(repeat - 3), extraBits: 2})
+					remaining -= repeat
+				} else {
+					tokens = append(tokens, codeLengthToken{symbol: int(length)})
+					remaining--
+				}
+			}
+		}
+
+		i += run
+	}
+
+	histogram := make([]uint32, len(codeLengthCodeOrder))
+	for _, token := range tokens {
+		histogram[token.symbol]++
+	}
+	codeLengthCode := newHuffmanCode(histogram, maxCodeLengthCodeLength)
+
+	codeLengthCount := len(codeLengthCodeOrder)
+	for codeLengthCount > 4 && codeLengthCode.lengths[codeLengthCodeOrder[codeLengthCount-1]] == 0 {
+		codeLengthCount--
+	}
+
+	// 単純な符号でない
+	bw.write(0, 1)
+	bw.write(uint32(codeLengthCount-4), 4)
+	for _, symbol := range codeLengthCodeOrder[:codeLengthCount] {
+		bw.write(uint32(codeLengthCode.lengths[symbol]), 3)
+	}
+	// 全ての記号の符号長を書き込むので、記号の数は指定しない
+	bw.write(0, 1)
+
+	for _, token := range tokens {
+		codeLengthCode.writeSymbol(bw, token.symbol)
+		if token.extraBits > 0 {
+			bw.write(token.extra, token.extraBits)
+		}
+	}
+}
+
+// writeSimpleCode 使う記号が1つ以下のプレフィックス符号を書き込む。記号は256未満である必要がある
+func writeSimpleCode(bw *bitWriter, lengths []uint8) {
+	symbol := 0
+	for i, length := range lengths {
+		if length != 0 {
+			symbol = i
+			break
+		}
+	}
+
+	// 単純な符号で、記号の数は1
+	bw.write(1, 1)
+	bw.write(0, 1)
+	if symbol < 2 {
+		bw.write(0, 1)
+		bw.write(uint32(symbol), 1)
+	} else {
+		bw.write(1, 1)
+		bw.write(uint32(symbol), 8)
+	}
+}
+
+// buildCodeLengths 出現回数からmaxLength以下の符号長を求める。
+// 符号長がmaxLengthを超えた場合は、出現回数の差を縮めて求め直す。
+func buildCodeLengths(histogram []uint32, maxLength int) []uint8 {
+	type node struct {
+		count       uint64
+		left, right int
+	}
+
+	lengths := make([]uint8, len(histogram))
+	counts := make([]uint64, len(histogram))
+	for i, count := range histogram {
+		counts[i] = uint64(count)
+	}
+
+	for {
+		nodes := make([]node, 0, 2*len(histogram))
+		for symbol, count := range counts {
+			if count != 0 {
+				nodes = append(nodes, node{count: count, left: -1, right: symbol})
+			}
+		}
+
+		switch len(nodes) {
+		case 0:
+			return lengths
+		case 1:
+			lengths[nodes[0].right] = 1
+			return lengths
+		}
+
+		sort.SliceStable(nodes, func(i, j int) bool {
+			return nodes[i].count < nodes[j].count
+		})
+
+		// 出現回数順の葉と、作った順に出現回数が増える内部節の2つの列から小さい方を取り出す
+		leafCount := len(nodes)
+		nextLeaf, nextInner := 0, leafCount
+		pop := func() int {
+			if nextLeaf < leafCount && (nextInner >= len(nodes) || nodes[nextLeaf].count <= nodes[nextInner].count) {
+				nextLeaf++
+				return nextLeaf - 1
+			}
+
+			nextInner++
+			return nextInner - 1
+		}
+		for i := 0; i < leafCount-1; i++ {
+			left, right := pop(), pop()
+			nodes = append(nodes, node{
+				count: nodes[left].count + nodes[right].count,
+				left:  left,
+				right: right,
+			})
+		}
+
+		depths := make([]int, len(nodes))
+		isTooLong := false
+		for i := len(nodes) - 1; i >= leafCount; i-- {
+			depths[nodes[i].left] = depths[i] + 1
+			depths[nodes[i].right] = depths[i] + 1
+		}
+		for i := 0; i < leafCount; i++ {
+			if depths[i] > maxLength {
+				isTooLong = true
+				break
+			}
+			lengths[nodes[i].right] = uint8(depths[i])
+		}
+		if !isTooLong {
+			return lengths
+		}
+
+		for i := range lengths {
+			lengths[i] = 0
+		}
+		for i, count := range counts {
+			if count != 0 {
+				counts[i] = count>>1 | 1
+			}
+		}
+	}
+}
+
+// buildCodes 符号長から正準ハフマン符号を求め、下位ビットから書き込めるよう反転する
+func buildCodes(lengths []uint8) []uint32 {
+	var lengthCounts [maxCodeLength + 1]uint32
+	for _, length := range lengths {
+		lengthCounts[length]++
+	}
+	lengthCounts[0] = 0
+
+	var nextCodes [maxCodeLength + 1]uint32
+	code := uint32(0)
+	for length := 1; length <= maxCodeLength; length++ {
+		code = (code + lengthCounts[length-1]) << 1
+		nextCodes[length] = code
+	}
+
+	codes := make([]uint32, len(lengths))
+	for symbol, length := range lengths {
+		if length == 0 {
+			continue
+		}
+
+		codes[symbol] = bits.Reverse32(nextCodes[length]) >> (32 - uint(length))
+		nextCodes[length]++
+	}
+
+	return codes
+}
+
+func min(a int, b int) int {
+	if a < b {
+		return a
+	}
+
+	return b
+}
@@ -0,0 +1,80 @@
+package webpenc
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuildCodeLengths(t *testing.T) {
+	t.Parallel()
+
+	// フィボナッチ数列の出現回数はハフマン符号の符号長が最も長くなる
+	fibonacci := make([]uint32, 30)
+	fibonacci[0], fibonacci[1] = 1, 1
+	for i := 2; i < len(fibonacci); i++ {
+		fibonacci[i] = fibonacci[i-1] + fibonacci[i-2]
+	}
+
+	type test struct {
+		description string
+		histogram   []uint32
+		maxLength   int
+		lengths     []uint8
+	}
+
+	testCases := []test{
+		{
+			description: "記号がないので全て0",
+			histogram:   []uint32{0, 0, 0},
+			maxLength:   maxCodeLength,
+			lengths:     []uint8{0, 0, 0},
+		},
+		{
+			description: "記号が1つなのでその記号のみ1",
+			histogram:   []uint32{0, 5, 0},
+			maxLength:   maxCodeLength,
+			lengths:     []uint8{0, 1, 0},
+		},
+		{
+			description: "出現回数が多い記号ほど短い",
+			histogram:   []uint32{8, 4, 2, 1, 1},
+			maxLength:   maxCodeLength,
+			lengths:     []uint8{1, 2, 3, 4, 4},
+		},
+		{
+			description: "符号長の上限を超える場合は上限以下に縮める",
+			histogram:   fibonacci,
+			maxLength:   maxCodeLength,
+		},
+		{
+			description: "符号長を符号化する符号の上限も守る",
+			histogram:   fibonacci[:19],
+			maxLength:   maxCodeLengthCodeLength,
+		},
+	}
+
+	for _, testCase := range testCases {
+		testCase := testCase
+		t.Run(testCase.description, func(t *testing.T) {
+			t.Parallel()
+
+			lengths := buildCodeLengths(testCase.histogram, testCase.maxLength)
+
+			if testCase.lengths != nil {
+				assert.Equal(t, testCase.lengths, lengths)
+				return
+			}
+
+			// 全ての記号に符号が割り当てられ、過不足のない符号になっている
+			kraftSum := 0
+			for _, length := range lengths {
+				if !assert.NotZero(t, length) || !assert.LessOrEqual(t, int(length), testCase.maxLength) {
+					return
+				}
+				kraftSum += 1 << (testCase.maxLength - int(length))
+			}
+			assert.Equal(t, 1<<testCase.maxLength, kraftSum)
+		})
+	}
+}
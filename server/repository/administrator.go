@@ -1,5 +1,7 @@
 package repository
 
+//go:generate mockgen -source=$GOFILE -destination=mock/${GOFILE} -package=mock
+
 import (
 	"context"
 
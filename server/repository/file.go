@@ -1,7 +1,10 @@
 package repository
 
+//go:generate mockgen -source=$GOFILE -destination=mock/${GOFILE} -package=mock
+
 import (
 	"context"
+	"time"
 
 	"github.com/mazrean/Quantainer/domain"
 	"github.com/mazrean/Quantainer/domain/values"
@@ -11,9 +14,41 @@ import (
 type File interface {
 	SaveFile(ctx context.Context, user *service.UserInfo, file *domain.File) error
 	GetFile(ctx context.Context, fileID values.FileID, lockType LockType) (*FileWithCreator, error)
+	// GetFiles 作成日時順に全てのファイルを論理削除されたものも含めて取得する
+	GetFiles(ctx context.Context, limit int, offset int) ([]*domain.File, error)
+	UpdateFileHash(ctx context.Context, fileID values.FileID, hash values.FileHash) error
+	UpdateFilePerceptualHash(ctx context.Context, fileID values.FileID, perceptualHash values.PerceptualHash) error
+	// UpdateFileMetadata バイト数と画像のメタデータを更新する
+	UpdateFileMetadata(ctx context.Context, file *domain.File) error
+	// DeleteFile 論理削除する。GetFileなどでは取得できなくなる。
+	DeleteFile(ctx context.Context, fileID values.FileID, deletedAt time.Time) error
+	RestoreFile(ctx context.Context, fileID values.FileID) error
+	// GetDeletedFile 論理削除されたファイルを取得する
+	GetDeletedFile(ctx context.Context, fileID values.FileID, lockType LockType) (*DeletedFile, error)
+	// GetPurgeableFiles 完全に削除してよいファイルを取得する。
+	// before以前に論理削除されたファイルと、before以前に作成されたがどのリソースからも参照されていないファイルが対象。
+	// リソースの過去の版のファイルは、論理削除されるまで対象にしない。
+	// レンディションのファイルは元のファイルと一緒に削除するので含まない。
+	GetPurgeableFiles(ctx context.Context, before time.Time) ([]*domain.File, error)
+	// PurgeFile 論理削除されたものも含めて完全に削除する。
+	// ファイルを参照するリソースの版の履歴も削除する。
+	PurgeFile(ctx context.Context, fileID values.FileID) error
+	// GetUserUsage ユーザーがアップロードしたファイルの使用量を取得する。
+	// 復元で上限を超えないよう、完全に削除されるまでは論理削除されたファイルも含む。
+	// レンディションは含まない。
+	GetUserUsage(ctx context.Context, userID values.TraPMemberID) (*service.StorageUsage, error)
+	// GetTotalUsage 全てのファイルの使用量を取得する。
+	// 完全に削除されるまでは容量を使うので、論理削除されたファイルは含む。
+	// 閲覧時に作られるレンディション・変換結果で全体の上限に達してアップロードできなくならないよう、GetUserUsageと同様にそれらは含まない。
+	GetTotalUsage(ctx context.Context) (*service.StorageUsage, error)
 }
 
 type FileWithCreator struct {
 	*domain.File
 	Creator values.TraPMemberID
 }
+
+type DeletedFile struct {
+	*FileWithCreator
+	DeletedAt time.Time
+}
@@ -82,7 +82,13 @@ func (db *DB) Get() (*sql.DB, error) {
 	return db.db.DB()
 }
 
+// Transaction 既にトランザクション内の場合は、セーブポイントを使って外側のトランザクションの一部として実行する
 func (db *DB) Transaction(ctx context.Context, txOption *sql.TxOptions, fn func(ctx context.Context) error) error {
+	gormDB, err := db.getDB(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get db: %w", err)
+	}
+
 	fc := func(tx *gorm.DB) error {
 		ctx = context.WithValue(ctx, pkgContext.DBKey, tx)
 
@@ -95,12 +101,12 @@ func (db *DB) Transaction(ctx context.Context, txOption *sql.TxOptions, fn func(
 	}
 
 	if txOption == nil {
-		err := db.db.Transaction(fc)
+		err := gormDB.Transaction(fc)
 		if err != nil {
 			return fmt.Errorf("failed in transaction: %w", err)
 		}
 	} else {
-		err := db.db.Transaction(fc, txOption)
+		err := gormDB.Transaction(fc, txOption)
 		if err != nil {
 			return fmt.Errorf("failed in transaction: %w", err)
 		}
@@ -2,8 +2,10 @@ package gorm2
 
 import (
 	"context"
+	"database/sql"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/mazrean/Quantainer/domain"
@@ -20,8 +22,44 @@ const (
 	fileTypeSvg   = "svg"
 	fileTypeGif   = "gif"
 	fileTypeOther = "other"
+	fileTypeMp3   = "mp3"
+	fileTypeOgg   = "ogg"
+	fileTypeWav   = "wav"
+	fileTypeFlac  = "flac"
+	fileTypeMp4   = "mp4"
+	fileTypeWebM  = "webm"
+	fileTypePdf   = "pdf"
+	fileTypeZip   = "zip"
+	fileTypeAvif  = "avif"
 )
 
+const (
+	scanStatusNotScanned = ""
+	scanStatusClean      = "clean"
+	scanStatusInfected   = "infected"
+)
+
+// extensionColumnLength files.extensionの最大のバイト数
+const extensionColumnLength = 32
+
+const (
+	colorSpaceUnknown = ""
+	colorSpaceGray    = "gray"
+	colorSpaceRGB     = "rgb"
+	colorSpaceCMYK    = "cmyk"
+)
+
+// imageMetadataColumns 画像のメタデータを保持するカラム
+var imageMetadataColumns = []string{
+	"image_width",
+	"image_height",
+	"image_frames",
+	"color_space",
+	"image_orientation",
+	"captured_at",
+	"camera_model",
+}
+
 type File struct {
 	db *DB
 }
@@ -70,6 +108,42 @@ func setupFileTypeTable(db *gorm.DB) error {
 			Name:   fileTypeOther,
 			Active: true,
 		},
+		{
+			Name:   fileTypeMp3,
+			Active: true,
+		},
+		{
+			Name:   fileTypeOgg,
+			Active: true,
+		},
+		{
+			Name:   fileTypeWav,
+			Active: true,
+		},
+		{
+			Name:   fileTypeFlac,
+			Active: true,
+		},
+		{
+			Name:   fileTypeMp4,
+			Active: true,
+		},
+		{
+			Name:   fileTypeWebM,
+			Active: true,
+		},
+		{
+			Name:   fileTypePdf,
+			Active: true,
+		},
+		{
+			Name:   fileTypeZip,
+			Active: true,
+		},
+		{
+			Name:   fileTypeAvif,
+			Active: true,
+		},
 	}
 
 	for _, fileType := range fileTypes {
@@ -103,6 +177,24 @@ func (f *File) SaveFile(ctx context.Context, user *service.UserInfo, file *domai
 		fileTypeName = fileTypeSvg
 	case values.FileTypeGif:
 		fileTypeName = fileTypeGif
+	case values.FileTypeMp3:
+		fileTypeName = fileTypeMp3
+	case values.FileTypeOgg:
+		fileTypeName = fileTypeOgg
+	case values.FileTypeWav:
+		fileTypeName = fileTypeWav
+	case values.FileTypeFlac:
+		fileTypeName = fileTypeFlac
+	case values.FileTypeMp4:
+		fileTypeName = fileTypeMp4
+	case values.FileTypeWebM:
+		fileTypeName = fileTypeWebM
+	case values.FileTypePdf:
+		fileTypeName = fileTypePdf
+	case values.FileTypeZip:
+		fileTypeName = fileTypeZip
+	case values.FileTypeAvif:
+		fileTypeName = fileTypeAvif
 	case values.FileTypeOther:
 		fileTypeName = fileTypeOther
 	default:
@@ -122,10 +214,32 @@ func (f *File) SaveFile(ctx context.Context, user *service.UserInfo, file *domai
 	fileTypeID := fileType.ID
 
 	fileTable := FileTable{
-		ID:         uuid.UUID(file.GetID()),
-		FileTypeID: fileTypeID,
-		CreatorID:  uuid.UUID(user.GetID()),
-		CreatedAt:  file.GetCreatedAt(),
+		ID:           uuid.UUID(file.GetID()),
+		FileTypeID:   fileTypeID,
+		Hash:         file.GetHash().String(),
+		Size:         file.GetSize(),
+		OriginalName: string(file.GetOriginalName()),
+		Extension:    newExtensionColumn(file.GetExtension()),
+		OriginalSize: file.GetOriginalSize(),
+		CreatorID:    uuid.UUID(user.GetID()),
+		CreatedAt:    file.GetCreatedAt(),
+	}
+
+	err = setImageMetadata(&fileTable, file.GetImageMetadata())
+	if err != nil {
+		return fmt.Errorf("failed to set image metadata: %w", err)
+	}
+
+	if perceptualHash := file.GetPerceptualHash(); perceptualHash != nil {
+		fileTable.PerceptualHash = sql.NullInt64{
+			Int64: int64(*perceptualHash),
+			Valid: true,
+		}
+	}
+
+	err = setFileScan(&fileTable, file.GetScan())
+	if err != nil {
+		return fmt.Errorf("failed to set file scan: %w", err)
 	}
 
 	err = db.Create(&fileTable).Error
@@ -152,7 +266,25 @@ func (f *File) GetFile(ctx context.Context, fileID values.FileID, lockType repos
 		Session(&gorm.Session{}).
 		Joins("FileType").
 		Where("files.id = ?", uuid.UUID(fileID)).
-		Select("files.creator_id", "files.created_at").
+		Select(
+			"files.hash",
+			"files.size",
+			"files.image_width",
+			"files.image_height",
+			"files.image_frames",
+			"files.color_space",
+			"files.image_orientation",
+			"files.captured_at",
+			"files.camera_model",
+			"files.perceptual_hash",
+			"files.scan_status",
+			"files.scan_signature",
+			"files.scanned_at",
+			"files.original_name",
+			"files.original_size",
+			"files.creator_id",
+			"files.created_at",
+		).
 		Take(&fileTable).Error
 	if errors.Is(err, gorm.ErrRecordNotFound) {
 		return nil, repository.ErrRecordNotFound
@@ -173,18 +305,702 @@ func (f *File) GetFile(ctx context.Context, fileID values.FileID, lockType repos
 		fileType = values.FileTypeSvg
 	case fileTypeGif:
 		fileType = values.FileTypeGif
+	case fileTypeMp3:
+		fileType = values.FileTypeMp3
+	case fileTypeOgg:
+		fileType = values.FileTypeOgg
+	case fileTypeWav:
+		fileType = values.FileTypeWav
+	case fileTypeFlac:
+		fileType = values.FileTypeFlac
+	case fileTypeMp4:
+		fileType = values.FileTypeMp4
+	case fileTypeWebM:
+		fileType = values.FileTypeWebM
+	case fileTypePdf:
+		fileType = values.FileTypePdf
+	case fileTypeZip:
+		fileType = values.FileTypeZip
+	case fileTypeAvif:
+		fileType = values.FileTypeAvif
 	case fileTypeOther:
 		fileType = values.FileTypeOther
 	default:
 		return nil, fmt.Errorf("invalid file type: %s", fileTable.FileType.Name)
 	}
 
+	fileHash, err := values.NewFileHashFromString(fileTable.Hash)
+	if err != nil {
+		return nil, fmt.Errorf("invalid file hash: %w", err)
+	}
+
+	file := domain.NewFile(
+		fileID,
+		fileType,
+		fileHash,
+		fileTable.Size,
+		newImageMetadata(&fileTable),
+		fileTable.CreatedAt,
+	)
+	file.SetPerceptualHash(newPerceptualHash(&fileTable))
+	file.SetScan(newFileScan(&fileTable))
+	file.SetOriginal(values.FileName(fileTable.OriginalName), fileTable.OriginalSize)
+
 	return &repository.FileWithCreator{
-		File: domain.NewFile(
-			fileID,
+		File:    file,
+		Creator: values.NewTrapMemberID(fileTable.CreatorID),
+	}, nil
+}
+
+func (f *File) GetFiles(ctx context.Context, limit int, offset int) ([]*domain.File, error) {
+	db, err := f.db.getDB(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get db: %w", err)
+	}
+
+	query := db.
+		Session(&gorm.Session{}).
+		Unscoped().
+		Joins("FileType").
+		Order("files.created_at, files.id")
+
+	if limit != -1 {
+		query = query.Limit(limit)
+	}
+	if offset != 0 {
+		query = query.Offset(offset)
+	}
+
+	var fileTables []FileTable
+	err = query.
+		Select(
+			"files.id",
+			"files.hash",
+			"files.size",
+			"files.image_width",
+			"files.image_height",
+			"files.image_frames",
+			"files.color_space",
+			"files.image_orientation",
+			"files.captured_at",
+			"files.camera_model",
+			"files.perceptual_hash",
+			"files.scan_status",
+			"files.scan_signature",
+			"files.scanned_at",
+			"files.original_name",
+			"files.original_size",
+			"files.created_at",
+		).
+		Find(&fileTables).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get files: %w", err)
+	}
+
+	files := make([]*domain.File, 0, len(fileTables))
+	for _, fileTable := range fileTables {
+		var fileType values.FileType
+		switch fileTable.FileType.Name {
+		case fileTypeJpeg:
+			fileType = values.FileTypeJpeg
+		case fileTypePng:
+			fileType = values.FileTypePng
+		case fileTypeWebP:
+			fileType = values.FileTypeWebP
+		case fileTypeSvg:
+			fileType = values.FileTypeSvg
+		case fileTypeGif:
+			fileType = values.FileTypeGif
+		case fileTypeMp3:
+			fileType = values.FileTypeMp3
+		case fileTypeOgg:
+			fileType = values.FileTypeOgg
+		case fileTypeWav:
+			fileType = values.FileTypeWav
+		case fileTypeFlac:
+			fileType = values.FileTypeFlac
+		case fileTypeMp4:
+			fileType = values.FileTypeMp4
+		case fileTypeWebM:
+			fileType = values.FileTypeWebM
+		case fileTypePdf:
+			fileType = values.FileTypePdf
+		case fileTypeZip:
+			fileType = values.FileTypeZip
+		case fileTypeAvif:
+			fileType = values.FileTypeAvif
+		case fileTypeOther:
+			fileType = values.FileTypeOther
+		default:
+			return nil, fmt.Errorf("invalid file type: %s", fileTable.FileType.Name)
+		}
+
+		fileHash, err := values.NewFileHashFromString(fileTable.Hash)
+		if err != nil {
+			return nil, fmt.Errorf("invalid file hash: %w", err)
+		}
+
+		file := domain.NewFile(
+			values.NewFileIDFromUUID(fileTable.ID),
 			fileType,
+			fileHash,
+			fileTable.Size,
+			newImageMetadata(&fileTable),
 			fileTable.CreatedAt,
-		),
-		Creator: values.NewTrapMemberID(fileTable.CreatorID),
+		)
+		file.SetPerceptualHash(newPerceptualHash(&fileTable))
+		file.SetScan(newFileScan(&fileTable))
+		file.SetOriginal(values.FileName(fileTable.OriginalName), fileTable.OriginalSize)
+
+		files = append(files, file)
+	}
+
+	return files, nil
+}
+
+func (f *File) UpdateFileHash(ctx context.Context, fileID values.FileID, hash values.FileHash) error {
+	db, err := f.db.getDB(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get db: %w", err)
+	}
+
+	// 論理削除されたファイルも復元されうるので更新する
+	result := db.
+		Unscoped().
+		Model(&FileTable{}).
+		Where("id = ?", uuid.UUID(fileID)).
+		Update("hash", hash.String())
+	err = result.Error
+	if err != nil {
+		return fmt.Errorf("failed to update file hash: %w", err)
+	}
+
+	if result.RowsAffected == 0 {
+		return repository.ErrNoRecordUpdated
+	}
+
+	return nil
+}
+
+func (f *File) UpdateFilePerceptualHash(ctx context.Context, fileID values.FileID, perceptualHash values.PerceptualHash) error {
+	db, err := f.db.getDB(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get db: %w", err)
+	}
+
+	// 論理削除されたファイルも復元されうるので更新する
+	result := db.
+		Unscoped().
+		Model(&FileTable{}).
+		Where("id = ?", uuid.UUID(fileID)).
+		Update("perceptual_hash", int64(perceptualHash))
+	err = result.Error
+	if err != nil {
+		return fmt.Errorf("failed to update file perceptual hash: %w", err)
+	}
+
+	if result.RowsAffected == 0 {
+		return repository.ErrNoRecordUpdated
+	}
+
+	return nil
+}
+
+func (f *File) UpdateFileMetadata(ctx context.Context, file *domain.File) error {
+	db, err := f.db.getDB(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get db: %w", err)
+	}
+
+	fileTable := FileTable{
+		Size: file.GetSize(),
+	}
+	err = setImageMetadata(&fileTable, file.GetImageMetadata())
+	if err != nil {
+		return fmt.Errorf("failed to set image metadata: %w", err)
+	}
+
+	// 論理削除されたファイルも復元されうるので更新する
+	result := db.
+		Unscoped().
+		Model(&FileTable{}).
+		Where("id = ?", uuid.UUID(file.GetID())).
+		Select("size", imageMetadataColumns).
+		Updates(&fileTable)
+	err = result.Error
+	if err != nil {
+		return fmt.Errorf("failed to update file metadata: %w", err)
+	}
+
+	if result.RowsAffected == 0 {
+		return repository.ErrNoRecordUpdated
+	}
+
+	return nil
+}
+
+func (f *File) DeleteFile(ctx context.Context, fileID values.FileID, deletedAt time.Time) error {
+	db, err := f.db.getDB(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get db: %w", err)
+	}
+
+	result := db.
+		Model(&FileTable{}).
+		Where("id = ?", uuid.UUID(fileID)).
+		Update("deleted_at", deletedAt)
+	err = result.Error
+	if err != nil {
+		return fmt.Errorf("failed to delete file: %w", err)
+	}
+
+	if result.RowsAffected == 0 {
+		return repository.ErrNoRecordDeleted
+	}
+
+	return nil
+}
+
+func (f *File) RestoreFile(ctx context.Context, fileID values.FileID) error {
+	db, err := f.db.getDB(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get db: %w", err)
+	}
+
+	result := db.
+		Unscoped().
+		Model(&FileTable{}).
+		Where("id = ? AND deleted_at IS NOT NULL", uuid.UUID(fileID)).
+		Update("deleted_at", nil)
+	err = result.Error
+	if err != nil {
+		return fmt.Errorf("failed to restore file: %w", err)
+	}
+
+	if result.RowsAffected == 0 {
+		return repository.ErrNoRecordUpdated
+	}
+
+	return nil
+}
+
+func (f *File) GetDeletedFile(ctx context.Context, fileID values.FileID, lockType repository.LockType) (*repository.DeletedFile, error) {
+	db, err := f.db.getDB(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get db: %w", err)
+	}
+
+	db, err = f.db.setLock(db, lockType)
+	if err != nil {
+		return nil, fmt.Errorf("failed to set lock: %w", err)
+	}
+
+	var fileTable FileTable
+	err = db.
+		Session(&gorm.Session{}).
+		Unscoped().
+		Joins("FileType").
+		Where("files.id = ? AND files.deleted_at IS NOT NULL", uuid.UUID(fileID)).
+		Select(
+			"files.hash",
+			"files.size",
+			"files.image_width",
+			"files.image_height",
+			"files.image_frames",
+			"files.color_space",
+			"files.image_orientation",
+			"files.captured_at",
+			"files.camera_model",
+			"files.creator_id",
+			"files.created_at",
+			"files.deleted_at",
+		).
+		Take(&fileTable).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, repository.ErrRecordNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get file: %w", err)
+	}
+
+	var fileType values.FileType
+	switch fileTable.FileType.Name {
+	case fileTypeJpeg:
+		fileType = values.FileTypeJpeg
+	case fileTypePng:
+		fileType = values.FileTypePng
+	case fileTypeWebP:
+		fileType = values.FileTypeWebP
+	case fileTypeSvg:
+		fileType = values.FileTypeSvg
+	case fileTypeGif:
+		fileType = values.FileTypeGif
+	case fileTypeMp3:
+		fileType = values.FileTypeMp3
+	case fileTypeOgg:
+		fileType = values.FileTypeOgg
+	case fileTypeWav:
+		fileType = values.FileTypeWav
+	case fileTypeFlac:
+		fileType = values.FileTypeFlac
+	case fileTypeMp4:
+		fileType = values.FileTypeMp4
+	case fileTypeWebM:
+		fileType = values.FileTypeWebM
+	case fileTypePdf:
+		fileType = values.FileTypePdf
+	case fileTypeZip:
+		fileType = values.FileTypeZip
+	case fileTypeAvif:
+		fileType = values.FileTypeAvif
+	case fileTypeOther:
+		fileType = values.FileTypeOther
+	default:
+		return nil, fmt.Errorf("invalid file type: %s", fileTable.FileType.Name)
+	}
+
+	fileHash, err := values.NewFileHashFromString(fileTable.Hash)
+	if err != nil {
+		return nil, fmt.Errorf("invalid file hash: %w", err)
+	}
+
+	return &repository.DeletedFile{
+		FileWithCreator: &repository.FileWithCreator{
+			File: domain.NewFile(
+				fileID,
+				fileType,
+				fileHash,
+				fileTable.Size,
+				newImageMetadata(&fileTable),
+				fileTable.CreatedAt,
+			),
+			Creator: values.NewTrapMemberID(fileTable.CreatorID),
+		},
+		DeletedAt: fileTable.DeletedAt.Time,
+	}, nil
+}
+
+func (f *File) GetPurgeableFiles(ctx context.Context, before time.Time) ([]*domain.File, error) {
+	db, err := f.db.getDB(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get db: %w", err)
+	}
+
+	// 論理削除されたリソースも外部キーで参照しているので、それらが完全に削除されるまでは対象にしない
+	var fileTables []FileTable
+	err = db.
+		Session(&gorm.Session{}).
+		Unscoped().
+		Joins("FileType").
+		Where("files.deleted_at < ? OR (files.deleted_at IS NULL AND files.created_at < ?)", before, before).
+		Where("NOT EXISTS (SELECT 1 FROM resources WHERE resources.file_id = files.id)").
+		Where("files.deleted_at IS NOT NULL OR NOT EXISTS (SELECT 1 FROM resource_versions WHERE resource_versions.file_id = files.id)").
+		Where("NOT EXISTS (SELECT 1 FROM renditions WHERE renditions.rendition_file_id = files.id)").
+		Where("NOT EXISTS (SELECT 1 FROM transforms WHERE transforms.rendition_file_id = files.id)").
+		Select(
+			"files.id",
+			"files.hash",
+			"files.size",
+			"files.image_width",
+			"files.image_height",
+			"files.image_frames",
+			"files.color_space",
+			"files.image_orientation",
+			"files.captured_at",
+			"files.camera_model",
+			"files.created_at",
+		).
+		Find(&fileTables).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get files: %w", err)
+	}
+
+	files := make([]*domain.File, 0, len(fileTables))
+	for _, fileTable := range fileTables {
+		var fileType values.FileType
+		switch fileTable.FileType.Name {
+		case fileTypeJpeg:
+			fileType = values.FileTypeJpeg
+		case fileTypePng:
+			fileType = values.FileTypePng
+		case fileTypeWebP:
+			fileType = values.FileTypeWebP
+		case fileTypeSvg:
+			fileType = values.FileTypeSvg
+		case fileTypeGif:
+			fileType = values.FileTypeGif
+		case fileTypeMp3:
+			fileType = values.FileTypeMp3
+		case fileTypeOgg:
+			fileType = values.FileTypeOgg
+		case fileTypeWav:
+			fileType = values.FileTypeWav
+		case fileTypeFlac:
+			fileType = values.FileTypeFlac
+		case fileTypeMp4:
+			fileType = values.FileTypeMp4
+		case fileTypeWebM:
+			fileType = values.FileTypeWebM
+		case fileTypePdf:
+			fileType = values.FileTypePdf
+		case fileTypeZip:
+			fileType = values.FileTypeZip
+		case fileTypeAvif:
+			fileType = values.FileTypeAvif
+		case fileTypeOther:
+			fileType = values.FileTypeOther
+		default:
+			return nil, fmt.Errorf("invalid file type: %s", fileTable.FileType.Name)
+		}
+
+		fileHash, err := values.NewFileHashFromString(fileTable.Hash)
+		if err != nil {
+			return nil, fmt.Errorf("invalid file hash: %w", err)
+		}
+
+		files = append(files, domain.NewFile(
+			values.NewFileIDFromUUID(fileTable.ID),
+			fileType,
+			fileHash,
+			fileTable.Size,
+			newImageMetadata(&fileTable),
+			fileTable.CreatedAt,
+		))
+	}
+
+	return files, nil
+}
+
+func (f *File) PurgeFile(ctx context.Context, fileID values.FileID) error {
+	db, err := f.db.getDB(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get db: %w", err)
+	}
+
+	// 過去の版として参照している履歴も外部キーで参照しているので、先に削除する
+	err = db.
+		Where("file_id = ?", uuid.UUID(fileID)).
+		Delete(&ResourceVersionTable{}).Error
+	if err != nil {
+		return fmt.Errorf("failed to delete resource versions: %w", err)
+	}
+
+	result := db.
+		Unscoped().
+		Where("id = ?", uuid.UUID(fileID)).
+		Delete(&FileTable{})
+	err = result.Error
+	if err != nil {
+		return fmt.Errorf("failed to purge file: %w", err)
+	}
+
+	if result.RowsAffected == 0 {
+		return repository.ErrNoRecordDeleted
+	}
+
+	return nil
+}
+
+// usageResult 使用量の集計結果
+type usageResult struct {
+	Bytes int64
+	Files int64
+}
+
+func (f *File) GetUserUsage(ctx context.Context, userID values.TraPMemberID) (*service.StorageUsage, error) {
+	db, err := f.db.getDB(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get db: %w", err)
+	}
+
+	var result usageResult
+	err = db.
+		Session(&gorm.Session{}).
+		Unscoped().
+		Model(&FileTable{}).
+		Where("creator_id = ?", uuid.UUID(userID)).
+		Where("NOT EXISTS (SELECT 1 FROM renditions WHERE renditions.rendition_file_id = files.id)").
+		Where("NOT EXISTS (SELECT 1 FROM transforms WHERE transforms.rendition_file_id = files.id)").
+		Select("COALESCE(SUM(size), 0) AS bytes", "COUNT(*) AS files").
+		Take(&result).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get usage: %w", err)
+	}
+
+	return &service.StorageUsage{
+		Bytes: result.Bytes,
+		Files: result.Files,
+	}, nil
+}
+
+func (f *File) GetTotalUsage(ctx context.Context) (*service.StorageUsage, error) {
+	db, err := f.db.getDB(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get db: %w", err)
+	}
+
+	var result usageResult
+	err = db.
+		Session(&gorm.Session{}).
+		Unscoped().
+		Model(&FileTable{}).
+		Where("NOT EXISTS (SELECT 1 FROM renditions WHERE renditions.rendition_file_id = files.id)").
+		Where("NOT EXISTS (SELECT 1 FROM transforms WHERE transforms.rendition_file_id = files.id)").
+		Select("COALESCE(SUM(size), 0) AS bytes", "COUNT(*) AS files").
+		Take(&result).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get usage: %w", err)
+	}
+
+	return &service.StorageUsage{
+		Bytes: result.Bytes,
+		Files: result.Files,
 	}, nil
 }
+
+// newExtensionColumn 拡張子で検索できるよう、列の長さを超える拡張子は保存しない
+func newExtensionColumn(extension values.FileExtension) string {
+	if len(extension) > extensionColumnLength {
+		return ""
+	}
+
+	return string(extension)
+}
+
+// newImageMetadata 画像のメタデータがない場合はnil
+func newImageMetadata(fileTable *FileTable) *domain.ImageMetadata {
+	if fileTable.ImageWidth == 0 {
+		return nil
+	}
+
+	var colorSpace values.ColorSpace
+	switch fileTable.ColorSpace {
+	case colorSpaceGray:
+		colorSpace = values.ColorSpaceGray
+	case colorSpaceRGB:
+		colorSpace = values.ColorSpaceRGB
+	case colorSpaceCMYK:
+		colorSpace = values.ColorSpaceCMYK
+	default:
+		colorSpace = values.ColorSpaceUnknown
+	}
+
+	var capturedAt *time.Time
+	if fileTable.CapturedAt.Valid {
+		capturedAt = &fileTable.CapturedAt.Time
+	}
+
+	return domain.NewImageMetadata(
+		fileTable.ImageWidth,
+		fileTable.ImageHeight,
+		fileTable.ImageFrames,
+		colorSpace,
+		fileTable.ImageOrientation,
+		capturedAt,
+		fileTable.CameraModel,
+	)
+}
+
+// newPerceptualHash 知覚ハッシュを計算していない場合はnil。
+// 上位ビットが立っている場合もそのままのビット列で保存しているので、uint64に戻す
+func newPerceptualHash(fileTable *FileTable) *values.PerceptualHash {
+	if !fileTable.PerceptualHash.Valid {
+		return nil
+	}
+
+	perceptualHash := values.PerceptualHash(uint64(fileTable.PerceptualHash.Int64))
+
+	return &perceptualHash
+}
+
+// newFileScan 検査していない場合はnil。
+// 不明な検査結果は、ダウンロードできなくならないよう検査していないものとして扱う
+func newFileScan(fileTable *FileTable) *domain.FileScan {
+	var status values.ScanStatus
+	switch fileTable.ScanStatus {
+	case scanStatusClean:
+		status = values.ScanStatusClean
+	case scanStatusInfected:
+		status = values.ScanStatusInfected
+	default:
+		return nil
+	}
+
+	return domain.NewFileScan(
+		status,
+		values.NewScanSignature(fileTable.ScanSignature),
+		fileTable.ScannedAt.Time,
+	)
+}
+
+// setFileScan scanがnilの場合は検査していないものとする
+func setFileScan(fileTable *FileTable, scan *domain.FileScan) error {
+	if scan == nil {
+		fileTable.ScanStatus = scanStatusNotScanned
+		fileTable.ScanSignature = ""
+		fileTable.ScannedAt = sql.NullTime{}
+
+		return nil
+	}
+
+	switch scan.GetStatus() {
+	case values.ScanStatusClean:
+		fileTable.ScanStatus = scanStatusClean
+	case values.ScanStatusInfected:
+		fileTable.ScanStatus = scanStatusInfected
+	default:
+		return fmt.Errorf("invalid scan status: %d", scan.GetStatus())
+	}
+
+	fileTable.ScanSignature = string(scan.GetSignature())
+	fileTable.ScannedAt = sql.NullTime{
+		Time:  scan.GetScannedAt(),
+		Valid: true,
+	}
+
+	return nil
+}
+
+// setImageMetadata imageMetadataがnilの場合はメタデータのカラムをゼロ値にする
+func setImageMetadata(fileTable *FileTable, imageMetadata *domain.ImageMetadata) error {
+	if imageMetadata == nil {
+		fileTable.ImageWidth = 0
+		fileTable.ImageHeight = 0
+		fileTable.ImageFrames = 0
+		fileTable.ColorSpace = colorSpaceUnknown
+		fileTable.ImageOrientation = 0
+		fileTable.CapturedAt = sql.NullTime{}
+		fileTable.CameraModel = ""
+
+		return nil
+	}
+
+	switch imageMetadata.GetColorSpace() {
+	case values.ColorSpaceGray:
+		fileTable.ColorSpace = colorSpaceGray
+	case values.ColorSpaceRGB:
+		fileTable.ColorSpace = colorSpaceRGB
+	case values.ColorSpaceCMYK:
+		fileTable.ColorSpace = colorSpaceCMYK
+	case values.ColorSpaceUnknown:
+		fileTable.ColorSpace = colorSpaceUnknown
+	default:
+		return fmt.Errorf("invalid color space: %d", imageMetadata.GetColorSpace())
+	}
+
+	fileTable.ImageWidth = imageMetadata.GetWidth()
+	fileTable.ImageHeight = imageMetadata.GetHeight()
+	fileTable.ImageFrames = imageMetadata.GetFrames()
+	fileTable.ImageOrientation = imageMetadata.GetOrientation()
+	fileTable.CameraModel = imageMetadata.GetCameraModel()
+
+	fileTable.CapturedAt = sql.NullTime{}
+	if capturedAt := imageMetadata.GetCapturedAt(); capturedAt != nil {
+		fileTable.CapturedAt = sql.NullTime{
+			Time:  *capturedAt,
+			Valid: true,
+		}
+	}
+
+	return nil
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/mazrean/Quantainer/domain"
@@ -302,15 +303,16 @@ func (g *Group) EditGroup(ctx context.Context, group *domain.Group, mainResource
 	return nil
 }
 
-func (g *Group) DeleteGroup(ctx context.Context, group *domain.Group) error {
+func (g *Group) DeleteGroup(ctx context.Context, group *domain.Group, deletedAt time.Time) error {
 	db, err := g.db.getDB(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to get db: %w", err)
 	}
 
 	err = db.
+		Model(&GroupTable{}).
 		Where("id = ?", uuid.UUID(group.GetID())).
-		Delete(&GroupTable{}).Error
+		Update("deleted_at", deletedAt).Error
 	if err != nil {
 		return fmt.Errorf("failed to delete group: %w", err)
 	}
@@ -453,6 +455,14 @@ func (g *Group) GetGroup(ctx context.Context, groupID values.GroupID, lockType r
 	switch resourceTypeTable.Name {
 	case resourceTypeImage:
 		resourceType = values.ResourceTypeImage
+	case resourceTypeMusic:
+		resourceType = values.ResourceTypeMusic
+	case resourceTypeVideo:
+		resourceType = values.ResourceTypeVideo
+	case resourceTypeDocument:
+		resourceType = values.ResourceTypeDocument
+	case resourceTypeArchive:
+		resourceType = values.ResourceTypeArchive
 	case resourceTypeOther:
 		resourceType = values.ResourceTypeOther
 	default:
@@ -465,12 +475,52 @@ func (g *Group) GetGroup(ctx context.Context, groupID values.GroupID, lockType r
 		fileType = values.FileTypeJpeg
 	case fileTypePng:
 		fileType = values.FileTypePng
+	case fileTypeWebP:
+		fileType = values.FileTypeWebP
+	case fileTypeSvg:
+		fileType = values.FileTypeSvg
+	case fileTypeGif:
+		fileType = values.FileTypeGif
+	case fileTypeMp3:
+		fileType = values.FileTypeMp3
+	case fileTypeOgg:
+		fileType = values.FileTypeOgg
+	case fileTypeWav:
+		fileType = values.FileTypeWav
+	case fileTypeFlac:
+		fileType = values.FileTypeFlac
+	case fileTypeMp4:
+		fileType = values.FileTypeMp4
+	case fileTypeWebM:
+		fileType = values.FileTypeWebM
+	case fileTypePdf:
+		fileType = values.FileTypePdf
+	case fileTypeZip:
+		fileType = values.FileTypeZip
+	case fileTypeAvif:
+		fileType = values.FileTypeAvif
 	case fileTypeOther:
 		fileType = values.FileTypeOther
 	default:
 		return nil, fmt.Errorf("invalid file type: %s", resourceFileTable.FileType.Name)
 	}
 
+	fileHash, err := values.NewFileHashFromString(resourceFileTable.Hash)
+	if err != nil {
+		return nil, fmt.Errorf("invalid file hash: %w", err)
+	}
+
+	mainResourceFile := domain.NewFile(
+		values.NewFileIDFromUUID(resourceFileTable.ID),
+		fileType,
+		fileHash,
+		resourceFileTable.Size,
+		newImageMetadata(&resourceFileTable),
+		resourceFileTable.CreatedAt,
+	)
+	mainResourceFile.SetScan(newFileScan(&resourceFileTable))
+	mainResourceFile.SetOriginal(values.FileName(resourceFileTable.OriginalName), resourceFileTable.OriginalSize)
+
 	return &repository.GroupInfo{
 		Group: domain.NewGroup(
 			values.NewGroupIDFromUUID(groupTable.ID),
@@ -489,11 +539,7 @@ func (g *Group) GetGroup(ctx context.Context, groupID values.GroupID, lockType r
 				values.NewResourceComment(groupTable.MainResource.Comment),
 				groupTable.MainResource.CreatedAt,
 			),
-			File: domain.NewFile(
-				values.NewFileIDFromUUID(resourceFileTable.ID),
-				fileType,
-				resourceFileTable.CreatedAt,
-			),
+			File:    mainResourceFile,
 			Creator: values.NewTrapMemberID(resourceFileTable.CreatorID),
 		},
 	}, nil
@@ -589,6 +635,14 @@ func (g *Group) GetGroups(ctx context.Context, user *service.UserInfo, params *r
 		switch groupTable.MainResource.ResourceType.Name {
 		case resourceTypeImage:
 			resourceType = values.ResourceTypeImage
+		case resourceTypeMusic:
+			resourceType = values.ResourceTypeMusic
+		case resourceTypeVideo:
+			resourceType = values.ResourceTypeVideo
+		case resourceTypeDocument:
+			resourceType = values.ResourceTypeDocument
+		case resourceTypeArchive:
+			resourceType = values.ResourceTypeArchive
 		case resourceTypeOther:
 			resourceType = values.ResourceTypeOther
 		default:
@@ -601,12 +655,52 @@ func (g *Group) GetGroups(ctx context.Context, user *service.UserInfo, params *r
 			fileType = values.FileTypeJpeg
 		case fileTypePng:
 			fileType = values.FileTypePng
+		case fileTypeWebP:
+			fileType = values.FileTypeWebP
+		case fileTypeSvg:
+			fileType = values.FileTypeSvg
+		case fileTypeGif:
+			fileType = values.FileTypeGif
+		case fileTypeMp3:
+			fileType = values.FileTypeMp3
+		case fileTypeOgg:
+			fileType = values.FileTypeOgg
+		case fileTypeWav:
+			fileType = values.FileTypeWav
+		case fileTypeFlac:
+			fileType = values.FileTypeFlac
+		case fileTypeMp4:
+			fileType = values.FileTypeMp4
+		case fileTypeWebM:
+			fileType = values.FileTypeWebM
+		case fileTypePdf:
+			fileType = values.FileTypePdf
+		case fileTypeZip:
+			fileType = values.FileTypeZip
+		case fileTypeAvif:
+			fileType = values.FileTypeAvif
 		case fileTypeOther:
 			fileType = values.FileTypeOther
 		default:
 			return nil, fmt.Errorf("invalid file type: %s", groupTable.MainResource.File.FileType.Name)
 		}
 
+		fileHash, err := values.NewFileHashFromString(groupTable.MainResource.File.Hash)
+		if err != nil {
+			return nil, fmt.Errorf("invalid file hash: %w", err)
+		}
+
+		mainResourceFile := domain.NewFile(
+			values.NewFileIDFromUUID(groupTable.MainResource.File.ID),
+			fileType,
+			fileHash,
+			groupTable.MainResource.File.Size,
+			newImageMetadata(&groupTable.MainResource.File),
+			groupTable.MainResource.File.CreatedAt,
+		)
+		mainResourceFile.SetScan(newFileScan(&groupTable.MainResource.File))
+		mainResourceFile.SetOriginal(values.FileName(groupTable.MainResource.File.OriginalName), groupTable.MainResource.File.OriginalSize)
+
 		groups = append(groups, &repository.GroupInfo{
 			Group: domain.NewGroup(
 				values.NewGroupIDFromUUID(groupTable.ID),
@@ -625,11 +719,7 @@ func (g *Group) GetGroups(ctx context.Context, user *service.UserInfo, params *r
 					values.NewResourceComment(groupTable.MainResource.Comment),
 					groupTable.MainResource.CreatedAt,
 				),
-				File: domain.NewFile(
-					values.NewFileIDFromUUID(groupTable.MainResource.File.ID),
-					fileType,
-					groupTable.MainResource.File.CreatedAt,
-				),
+				File:    mainResourceFile,
 				Creator: values.NewTrapMemberID(groupTable.MainResource.File.CreatorID),
 			},
 		})
@@ -637,3 +727,176 @@ func (g *Group) GetGroups(ctx context.Context, user *service.UserInfo, params *r
 
 	return groups, nil
 }
+
+func (g *Group) GetGroupsByResource(ctx context.Context, resource values.ResourceID) ([]*domain.Group, error) {
+	db, err := g.db.getDB(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get db: %w", err)
+	}
+
+	// リソースとの関連は論理削除されたグループにも残っているので、それらも含める
+	query := db.
+		Unscoped().
+		Joins("JOIN group_resources ON groups.id = group_resources.id").
+		Where("group_resources.resource_table_id = ?", uuid.UUID(resource))
+
+	groups, err := g.findGroups(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find groups: %w", err)
+	}
+
+	return groups, nil
+}
+
+func (g *Group) GetGroupsByMainResource(ctx context.Context, resource values.ResourceID) ([]*domain.Group, error) {
+	db, err := g.db.getDB(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get db: %w", err)
+	}
+
+	query := db.Where("groups.main_resource_id = ?", uuid.UUID(resource))
+
+	groups, err := g.findGroups(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find groups: %w", err)
+	}
+
+	return groups, nil
+}
+
+// findGroups queryの条件に合うグループを取得する
+func (g *Group) findGroups(query *gorm.DB) ([]*domain.Group, error) {
+	var groupTables []GroupTable
+	err := query.
+		Session(&gorm.Session{}).
+		Joins("GroupType").
+		Joins("ReadPermission").
+		Joins("WritePermission").
+		Find(&groupTables).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get groups: %w", err)
+	}
+
+	groups := make([]*domain.Group, 0, len(groupTables))
+	for _, groupTable := range groupTables {
+		var groupType values.GroupType
+		switch groupTable.GroupType.Name {
+		case groupTypeArtBook:
+			groupType = values.GroupTypeArtBook
+		case groupTypeOther:
+			groupType = values.GroupTypeOther
+		default:
+			return nil, fmt.Errorf("invalid group type: %s", groupTable.GroupType.Name)
+		}
+
+		var readPermission values.GroupReadPermission
+		switch groupTable.ReadPermission.Name {
+		case readPermissionPublic:
+			readPermission = values.GroupReadPermissionPublic
+		case readPermissionPrivate:
+			readPermission = values.GroupReadPermissionPrivate
+		default:
+			return nil, fmt.Errorf("invalid read permission: %s", groupTable.ReadPermission.Name)
+		}
+
+		var writePermission values.GroupWritePermission
+		switch groupTable.WritePermission.Name {
+		case writePermissionPublic:
+			writePermission = values.GroupWritePermissionPublic
+		case writePermissionPrivate:
+			writePermission = values.GroupWritePermissionPrivate
+		default:
+			return nil, fmt.Errorf("invalid write permission: %s", groupTable.WritePermission.Name)
+		}
+
+		groups = append(groups, domain.NewGroup(
+			values.NewGroupIDFromUUID(groupTable.ID),
+			values.NewGroupName(groupTable.Name),
+			groupType,
+			values.NewGroupDescription(groupTable.Description),
+			readPermission,
+			writePermission,
+			groupTable.CreatedAt,
+		))
+	}
+
+	return groups, nil
+}
+
+func (g *Group) GetDeletedGroupIDsByMainResource(ctx context.Context, resource values.ResourceID) ([]values.GroupID, error) {
+	db, err := g.db.getDB(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get db: %w", err)
+	}
+
+	var uuidGroupIDs []uuid.UUID
+	err = db.
+		Session(&gorm.Session{}).
+		Unscoped().
+		Model(&GroupTable{}).
+		Where("main_resource_id = ? AND deleted_at IS NOT NULL", uuid.UUID(resource)).
+		Pluck("id", &uuidGroupIDs).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get group ids: %w", err)
+	}
+
+	groupIDs := make([]values.GroupID, 0, len(uuidGroupIDs))
+	for _, uuidGroupID := range uuidGroupIDs {
+		groupIDs = append(groupIDs, values.NewGroupIDFromUUID(uuidGroupID))
+	}
+
+	return groupIDs, nil
+}
+
+func (g *Group) RestoreGroupsByMainResource(ctx context.Context, resource values.ResourceID, deletedAt time.Time) error {
+	db, err := g.db.getDB(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get db: %w", err)
+	}
+
+	err = db.
+		Unscoped().
+		Model(&GroupTable{}).
+		Where("main_resource_id = ? AND deleted_at = ?", uuid.UUID(resource), deletedAt).
+		Update("deleted_at", nil).Error
+	if err != nil {
+		return fmt.Errorf("failed to restore groups: %w", err)
+	}
+
+	return nil
+}
+
+func (g *Group) PurgeGroup(ctx context.Context, groupID values.GroupID) error {
+	db, err := g.db.getDB(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get db: %w", err)
+	}
+
+	err = db.
+		Where("group_id = ?", uuid.UUID(groupID)).
+		Delete(&AdministratorTable{}).Error
+	if err != nil {
+		return fmt.Errorf("failed to delete administrators: %w", err)
+	}
+
+	err = db.
+		Exec("DELETE FROM group_resources WHERE id = ?", uuid.UUID(groupID)).Error
+	if err != nil {
+		return fmt.Errorf("failed to delete group resources: %w", err)
+	}
+
+	result := db.
+		Unscoped().
+		Where("id = ?", uuid.UUID(groupID)).
+		Delete(&GroupTable{})
+	err = result.Error
+	if err != nil {
+		return fmt.Errorf("failed to purge group: %w", err)
+	}
+
+	if result.RowsAffected == 0 {
+		return repository.ErrNoRecordDeleted
+	}
+
+	return nil
+}
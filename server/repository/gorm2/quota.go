@@ -0,0 +1,89 @@
+package gorm2
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/mazrean/Quantainer/domain/values"
+	"github.com/mazrean/Quantainer/repository"
+	"github.com/mazrean/Quantainer/service"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type Quota struct {
+	db *DB
+}
+
+func NewQuota(db *DB) *Quota {
+	return &Quota{
+		db: db,
+	}
+}
+
+func (q *Quota) SaveUserQuota(ctx context.Context, userID values.TraPMemberID, limit *service.QuotaLimit) error {
+	db, err := q.db.getDB(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get db: %w", err)
+	}
+
+	err = db.
+		Clauses(clause.OnConflict{UpdateAll: true}).
+		Create(&UserQuotaTable{
+			UserID:   uuid.UUID(userID),
+			MaxBytes: limit.MaxBytes,
+			MaxFiles: limit.MaxFiles,
+		}).Error
+	if err != nil {
+		return fmt.Errorf("failed to save user quota: %w", err)
+	}
+
+	return nil
+}
+
+func (q *Quota) GetUserQuota(ctx context.Context, userID values.TraPMemberID) (*service.QuotaLimit, error) {
+	db, err := q.db.getDB(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get db: %w", err)
+	}
+
+	var userQuotaTable UserQuotaTable
+	err = db.
+		Session(&gorm.Session{}).
+		Where("user_id = ?", uuid.UUID(userID)).
+		Take(&userQuotaTable).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, repository.ErrRecordNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user quota: %w", err)
+	}
+
+	return &service.QuotaLimit{
+		MaxBytes: userQuotaTable.MaxBytes,
+		MaxFiles: userQuotaTable.MaxFiles,
+	}, nil
+}
+
+func (q *Quota) DeleteUserQuota(ctx context.Context, userID values.TraPMemberID) error {
+	db, err := q.db.getDB(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get db: %w", err)
+	}
+
+	result := db.
+		Where("user_id = ?", uuid.UUID(userID)).
+		Delete(&UserQuotaTable{})
+	err = result.Error
+	if err != nil {
+		return fmt.Errorf("failed to delete user quota: %w", err)
+	}
+
+	if result.RowsAffected == 0 {
+		return repository.ErrNoRecordDeleted
+	}
+
+	return nil
+}
@@ -0,0 +1,301 @@
+package gorm2
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/mazrean/Quantainer/domain"
+	"github.com/mazrean/Quantainer/domain/values"
+	"github.com/mazrean/Quantainer/repository"
+	"gorm.io/gorm"
+)
+
+type Rendition struct {
+	db *DB
+}
+
+func NewRendition(db *DB) *Rendition {
+	return &Rendition{
+		db: db,
+	}
+}
+
+func (r *Rendition) SaveRendition(ctx context.Context, fileID values.FileID, size values.RenditionSize, rendition *domain.File) error {
+	db, err := r.db.getDB(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get db: %w", err)
+	}
+
+	renditionTable := RenditionTable{
+		FileID:          uuid.UUID(fileID),
+		Size:            int(size),
+		RenditionFileID: uuid.UUID(rendition.GetID()),
+	}
+
+	err = db.Create(&renditionTable).Error
+	if err != nil {
+		return fmt.Errorf("failed to create rendition: %w", err)
+	}
+
+	return nil
+}
+
+func (r *Rendition) GetRendition(ctx context.Context, fileID values.FileID, size values.RenditionSize, lockType repository.LockType) (*domain.File, error) {
+	db, err := r.db.getDB(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get db: %w", err)
+	}
+
+	db, err = r.db.setLock(db, lockType)
+	if err != nil {
+		return nil, fmt.Errorf("failed to set lock: %w", err)
+	}
+
+	var renditionTable RenditionTable
+	err = db.
+		Session(&gorm.Session{}).
+		Preload("RenditionFile.FileType").
+		// 元のファイルが論理削除されている場合は取得させない
+		Joins("JOIN files ON files.id = renditions.file_id AND files.deleted_at IS NULL").
+		Where("renditions.file_id = ? AND renditions.size = ?", uuid.UUID(fileID), int(size)).
+		Take(&renditionTable).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, repository.ErrRecordNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get rendition: %w", err)
+	}
+
+	rendition, err := newRenditionFile(&renditionTable.RenditionFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert rendition: %w", err)
+	}
+
+	return rendition, nil
+}
+
+func (r *Rendition) GetRenditions(ctx context.Context, fileID values.FileID) ([]*domain.File, error) {
+	db, err := r.db.getDB(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get db: %w", err)
+	}
+
+	var renditionTables []RenditionTable
+	err = db.
+		Session(&gorm.Session{}).
+		Preload("RenditionFile.FileType").
+		Where("file_id = ?", uuid.UUID(fileID)).
+		Find(&renditionTables).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get renditions: %w", err)
+	}
+
+	var transformTables []TransformTable
+	err = db.
+		Session(&gorm.Session{}).
+		Preload("RenditionFile.FileType").
+		Where("file_id = ?", uuid.UUID(fileID)).
+		Find(&transformTables).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transforms: %w", err)
+	}
+
+	renditions := make([]*domain.File, 0, len(renditionTables)+len(transformTables))
+	for _, renditionTable := range renditionTables {
+		rendition, err := newRenditionFile(&renditionTable.RenditionFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert rendition: %w", err)
+		}
+
+		renditions = append(renditions, rendition)
+	}
+
+	for _, transformTable := range transformTables {
+		rendition, err := newRenditionFile(&transformTable.RenditionFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert transform: %w", err)
+		}
+
+		renditions = append(renditions, rendition)
+	}
+
+	return renditions, nil
+}
+
+func (r *Rendition) DeleteRenditions(ctx context.Context, fileID values.FileID) error {
+	db, err := r.db.getDB(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get db: %w", err)
+	}
+
+	err = db.
+		Where("file_id = ?", uuid.UUID(fileID)).
+		Delete(&RenditionTable{}).Error
+	if err != nil {
+		return fmt.Errorf("failed to delete renditions: %w", err)
+	}
+
+	err = db.
+		Where("file_id = ?", uuid.UUID(fileID)).
+		Delete(&TransformTable{}).Error
+	if err != nil {
+		return fmt.Errorf("failed to delete transforms: %w", err)
+	}
+
+	return nil
+}
+
+func (r *Rendition) SaveTransform(ctx context.Context, fileID values.FileID, transform values.ImageTransform, rendition *domain.File) error {
+	db, err := r.db.getDB(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get db: %w", err)
+	}
+
+	key, err := transformKey(transform)
+	if err != nil {
+		return fmt.Errorf("failed to get transform key: %w", err)
+	}
+
+	transformTable := TransformTable{
+		FileID:          uuid.UUID(fileID),
+		Transform:       key,
+		RenditionFileID: uuid.UUID(rendition.GetID()),
+	}
+
+	err = db.Create(&transformTable).Error
+	if err != nil {
+		return fmt.Errorf("failed to create transform: %w", err)
+	}
+
+	return nil
+}
+
+func (r *Rendition) GetTransform(ctx context.Context, fileID values.FileID, transform values.ImageTransform, lockType repository.LockType) (*domain.File, error) {
+	db, err := r.db.getDB(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get db: %w", err)
+	}
+
+	db, err = r.db.setLock(db, lockType)
+	if err != nil {
+		return nil, fmt.Errorf("failed to set lock: %w", err)
+	}
+
+	key, err := transformKey(transform)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transform key: %w", err)
+	}
+
+	var transformTable TransformTable
+	err = db.
+		Session(&gorm.Session{}).
+		Preload("RenditionFile.FileType").
+		// 元のファイルが論理削除されている場合は取得させない
+		Joins("JOIN files ON files.id = transforms.file_id AND files.deleted_at IS NULL").
+		Where("transforms.file_id = ? AND transforms.transform = ?", uuid.UUID(fileID), key).
+		Take(&transformTable).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, repository.ErrRecordNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transform: %w", err)
+	}
+
+	rendition, err := newRenditionFile(&transformTable.RenditionFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert transform: %w", err)
+	}
+
+	return rendition, nil
+}
+
+// transformKey 変換の内容を表す文字列。例: 256x256_cover_jpeg_q85
+func transformKey(transform values.ImageTransform) (string, error) {
+	preset := transform.GetPreset()
+
+	var fitName string
+	switch preset.GetFit() {
+	case values.ImageFitContain:
+		fitName = "contain"
+	case values.ImageFitCover:
+		fitName = "cover"
+	default:
+		return "", fmt.Errorf("invalid image fit: %d", preset.GetFit())
+	}
+
+	var formatName string
+	switch transform.GetFormat() {
+	case values.FileTypeJpeg:
+		formatName = fileTypeJpeg
+	case values.FileTypePng:
+		formatName = fileTypePng
+	case values.FileTypeWebP:
+		formatName = fileTypeWebP
+	default:
+		return "", fmt.Errorf("invalid transform format: %d", transform.GetFormat())
+	}
+
+	key := fmt.Sprintf("%dx%d_%s_%s", preset.GetWidth(), preset.GetHeight(), fitName, formatName)
+	if quality := transform.GetQuality(); quality != 0 {
+		key = fmt.Sprintf("%s_q%d", key, quality)
+	}
+
+	return key, nil
+}
+
+func newRenditionFile(fileTable *FileTable) (*domain.File, error) {
+	var fileType values.FileType
+	switch fileTable.FileType.Name {
+	case fileTypeJpeg:
+		fileType = values.FileTypeJpeg
+	case fileTypePng:
+		fileType = values.FileTypePng
+	case fileTypeWebP:
+		fileType = values.FileTypeWebP
+	case fileTypeSvg:
+		fileType = values.FileTypeSvg
+	case fileTypeGif:
+		fileType = values.FileTypeGif
+	case fileTypeMp3:
+		fileType = values.FileTypeMp3
+	case fileTypeOgg:
+		fileType = values.FileTypeOgg
+	case fileTypeWav:
+		fileType = values.FileTypeWav
+	case fileTypeFlac:
+		fileType = values.FileTypeFlac
+	case fileTypeMp4:
+		fileType = values.FileTypeMp4
+	case fileTypeWebM:
+		fileType = values.FileTypeWebM
+	case fileTypePdf:
+		fileType = values.FileTypePdf
+	case fileTypeZip:
+		fileType = values.FileTypeZip
+	case fileTypeAvif:
+		fileType = values.FileTypeAvif
+	case fileTypeOther:
+		fileType = values.FileTypeOther
+	default:
+		return nil, fmt.Errorf("invalid file type: %s", fileTable.FileType.Name)
+	}
+
+	fileHash, err := values.NewFileHashFromString(fileTable.Hash)
+	if err != nil {
+		return nil, fmt.Errorf("invalid file hash: %w", err)
+	}
+
+	file := domain.NewFile(
+		values.NewFileIDFromUUID(fileTable.ID),
+		fileType,
+		fileHash,
+		fileTable.Size,
+		newImageMetadata(fileTable),
+		fileTable.CreatedAt,
+	)
+	file.SetOriginal(values.FileName(fileTable.OriginalName), fileTable.OriginalSize)
+
+	return file, nil
+}
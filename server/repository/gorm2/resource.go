@@ -5,19 +5,29 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"strings"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/mazrean/Quantainer/domain"
 	"github.com/mazrean/Quantainer/domain/values"
 	"github.com/mazrean/Quantainer/repository"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 const (
-	resourceTypeImage = "image"
-	resourceTypeOther = "other"
+	resourceTypeImage    = "image"
+	resourceTypeOther    = "other"
+	resourceTypeMusic    = "music"
+	resourceTypeVideo    = "video"
+	resourceTypeDocument = "document"
+	resourceTypeArchive  = "archive"
 )
 
+// likeEscaper LIKEの検索文字列でワイルドカードとして扱われる文字をエスケープする
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
 type Resource struct {
 	db *DB
 }
@@ -50,6 +60,22 @@ func setupResourceTypeTable(db *gorm.DB) error {
 			Name:   resourceTypeOther,
 			Active: true,
 		},
+		{
+			Name:   resourceTypeMusic,
+			Active: true,
+		},
+		{
+			Name:   resourceTypeVideo,
+			Active: true,
+		},
+		{
+			Name:   resourceTypeDocument,
+			Active: true,
+		},
+		{
+			Name:   resourceTypeArchive,
+			Active: true,
+		},
 	}
 
 	for _, resourceType := range resourceTypes {
@@ -75,6 +101,14 @@ func (r *Resource) SaveResource(ctx context.Context, fileID values.FileID, resou
 	switch resource.GetType() {
 	case values.ResourceTypeImage:
 		resourceTypeName = resourceTypeImage
+	case values.ResourceTypeMusic:
+		resourceTypeName = resourceTypeMusic
+	case values.ResourceTypeVideo:
+		resourceTypeName = resourceTypeVideo
+	case values.ResourceTypeDocument:
+		resourceTypeName = resourceTypeDocument
+	case values.ResourceTypeArchive:
+		resourceTypeName = resourceTypeArchive
 	case values.ResourceTypeOther:
 		resourceTypeName = resourceTypeOther
 	default:
@@ -104,6 +138,18 @@ func (r *Resource) SaveResource(ctx context.Context, fileID values.FileID, resou
 		return fmt.Errorf("failed to create resource: %w", err)
 	}
 
+	resourceVersionTable := ResourceVersionTable{
+		ResourceID: uuid.UUID(resource.GetID()),
+		Version:    int(values.FirstResourceVersion),
+		FileID:     uuid.UUID(fileID),
+		CreatedAt:  resource.GetCreatedAt(),
+	}
+
+	err = db.Create(&resourceVersionTable).Error
+	if err != nil {
+		return fmt.Errorf("failed to create resource version: %w", err)
+	}
+
 	return nil
 }
 
@@ -136,6 +182,14 @@ func (r *Resource) GetResource(ctx context.Context, resourceID values.ResourceID
 	switch resourceTable.ResourceType.Name {
 	case resourceTypeImage:
 		resourceType = values.ResourceTypeImage
+	case resourceTypeMusic:
+		resourceType = values.ResourceTypeMusic
+	case resourceTypeVideo:
+		resourceType = values.ResourceTypeVideo
+	case resourceTypeDocument:
+		resourceType = values.ResourceTypeDocument
+	case resourceTypeArchive:
+		resourceType = values.ResourceTypeArchive
 	case resourceTypeOther:
 		resourceType = values.ResourceTypeOther
 	default:
@@ -162,6 +216,24 @@ func (r *Resource) GetResource(ctx context.Context, resourceID values.ResourceID
 		fileType = values.FileTypeSvg
 	case fileTypeGif:
 		fileType = values.FileTypeGif
+	case fileTypeMp3:
+		fileType = values.FileTypeMp3
+	case fileTypeOgg:
+		fileType = values.FileTypeOgg
+	case fileTypeWav:
+		fileType = values.FileTypeWav
+	case fileTypeFlac:
+		fileType = values.FileTypeFlac
+	case fileTypeMp4:
+		fileType = values.FileTypeMp4
+	case fileTypeWebM:
+		fileType = values.FileTypeWebM
+	case fileTypePdf:
+		fileType = values.FileTypePdf
+	case fileTypeZip:
+		fileType = values.FileTypeZip
+	case fileTypeAvif:
+		fileType = values.FileTypeAvif
 	case fileTypeOther:
 		fileType = values.FileTypeOther
 	default:
@@ -169,6 +241,23 @@ func (r *Resource) GetResource(ctx context.Context, resourceID values.ResourceID
 		return nil, fmt.Errorf("invalid file type: %s", resourceTable.File.FileType.Name)
 	}
 
+	fileHash, err := values.NewFileHashFromString(resourceTable.File.Hash)
+	if err != nil {
+		return nil, fmt.Errorf("invalid file hash: %w", err)
+	}
+
+	file := domain.NewFile(
+		values.NewFileIDFromUUID(resourceTable.File.ID),
+		fileType,
+		fileHash,
+		resourceTable.File.Size,
+		newImageMetadata(&resourceTable.File),
+		resourceTable.File.CreatedAt,
+	)
+	file.SetPerceptualHash(newPerceptualHash(&resourceTable.File))
+	file.SetScan(newFileScan(&resourceTable.File))
+	file.SetOriginal(values.FileName(resourceTable.File.OriginalName), resourceTable.File.OriginalSize)
+
 	resource := repository.ResourceInfo{
 		Resource: domain.NewResource(
 			resourceID,
@@ -177,11 +266,7 @@ func (r *Resource) GetResource(ctx context.Context, resourceID values.ResourceID
 			values.NewResourceComment(resourceTable.Comment),
 			resourceTable.CreatedAt,
 		),
-		File: domain.NewFile(
-			values.NewFileIDFromUUID(resourceTable.File.ID),
-			fileType,
-			resourceTable.File.CreatedAt,
-		),
+		File:    file,
 		Creator: values.NewTrapMemberID(resourceTable.File.CreatorID),
 	}
 
@@ -199,6 +284,14 @@ func (r *Resource) GetResources(ctx context.Context, params *repository.Resource
 		switch resourceType {
 		case values.ResourceTypeImage:
 			resourceTypeNames = append(resourceTypeNames, resourceTypeImage)
+		case values.ResourceTypeMusic:
+			resourceTypeNames = append(resourceTypeNames, resourceTypeMusic)
+		case values.ResourceTypeVideo:
+			resourceTypeNames = append(resourceTypeNames, resourceTypeVideo)
+		case values.ResourceTypeDocument:
+			resourceTypeNames = append(resourceTypeNames, resourceTypeDocument)
+		case values.ResourceTypeArchive:
+			resourceTypeNames = append(resourceTypeNames, resourceTypeArchive)
 		case values.ResourceTypeOther:
 			resourceTypeNames = append(resourceTypeNames, resourceTypeOther)
 		default:
@@ -214,8 +307,7 @@ func (r *Resource) GetResources(ctx context.Context, params *repository.Resource
 	query := db.
 		Session(&gorm.Session{}).
 		Joins("ResourceType").
-		Joins("File").
-		Order("resources.created_at DESC")
+		Joins("File")
 
 	if len(resourceTypeNames) != 0 {
 		query = query.Where("ResourceType.name IN ?", resourceTypeNames)
@@ -224,6 +316,61 @@ func (r *Resource) GetResources(ctx context.Context, params *repository.Resource
 		query = query.Where("File.creator_id IN ?", creatorIDs)
 	}
 
+	if len(params.ImageOrientations) != 0 {
+		orientationConditions := make([]string, 0, len(params.ImageOrientations))
+		for _, imageOrientation := range params.ImageOrientations {
+			switch imageOrientation {
+			case values.ImageOrientationLandscape:
+				orientationConditions = append(orientationConditions, "File.image_width > File.image_height")
+			case values.ImageOrientationPortrait:
+				orientationConditions = append(orientationConditions, "File.image_width < File.image_height")
+			case values.ImageOrientationSquare:
+				orientationConditions = append(orientationConditions, "File.image_width = File.image_height")
+			default:
+				return nil, fmt.Errorf("invalid image orientation: %d", imageOrientation)
+			}
+		}
+
+		// メタデータのない画像は幅と高さが0なので除く
+		query = query.
+			Where("File.image_width > 0").
+			Where("(" + strings.Join(orientationConditions, " OR ") + ")")
+	}
+	if params.MinWidth > 0 {
+		query = query.Where("File.image_width >= ?", params.MinWidth)
+	}
+	if params.MinHeight > 0 {
+		query = query.Where("File.image_height >= ?", params.MinHeight)
+	}
+	if len(params.FileName) != 0 {
+		query = query.Where("File.original_name LIKE ?", "%"+likeEscaper.Replace(params.FileName)+"%")
+	}
+	if len(params.Extensions) != 0 {
+		extensions := make([]string, 0, len(params.Extensions))
+		for _, extension := range params.Extensions {
+			extensions = append(extensions, string(extension))
+		}
+		query = query.Where("File.extension IN ?", extensions)
+	}
+
+	if params.HasPerceptualHash || params.SimilarTo != nil {
+		query = query.Where("File.perceptual_hash IS NOT NULL")
+	}
+	if params.SimilarTo != nil {
+		// MySQLのビット演算は符号なしの64ビット整数として行われるので、保存したビット列のまま比べられる
+		perceptualHash := int64(params.SimilarTo.PerceptualHash)
+		query = query.
+			Where("BIT_COUNT(File.perceptual_hash ^ ?) <= ?", perceptualHash, params.SimilarTo.MaxDistance).
+			Order(clause.OrderBy{
+				Expression: clause.Expr{
+					SQL:                "BIT_COUNT(File.perceptual_hash ^ ?)",
+					Vars:               []interface{}{perceptualHash},
+					WithoutParentheses: true,
+				},
+			})
+	}
+	query = query.Order("resources.created_at DESC")
+
 	if len(params.Groups) != 0 {
 		groupIDs := make([]uuid.UUID, 0, len(params.Groups))
 		for _, groupInfo := range params.Groups {
@@ -273,6 +420,14 @@ func (r *Resource) GetResources(ctx context.Context, params *repository.Resource
 		switch resourceTable.ResourceType.Name {
 		case resourceTypeImage:
 			resourceType = values.ResourceTypeImage
+		case resourceTypeMusic:
+			resourceType = values.ResourceTypeMusic
+		case resourceTypeVideo:
+			resourceType = values.ResourceTypeVideo
+		case resourceTypeDocument:
+			resourceType = values.ResourceTypeDocument
+		case resourceTypeArchive:
+			resourceType = values.ResourceTypeArchive
 		case resourceTypeOther:
 			resourceType = values.ResourceTypeOther
 		default:
@@ -291,12 +446,47 @@ func (r *Resource) GetResources(ctx context.Context, params *repository.Resource
 			fileType = values.FileTypeSvg
 		case fileTypeGif:
 			fileType = values.FileTypeGif
+		case fileTypeMp3:
+			fileType = values.FileTypeMp3
+		case fileTypeOgg:
+			fileType = values.FileTypeOgg
+		case fileTypeWav:
+			fileType = values.FileTypeWav
+		case fileTypeFlac:
+			fileType = values.FileTypeFlac
+		case fileTypeMp4:
+			fileType = values.FileTypeMp4
+		case fileTypeWebM:
+			fileType = values.FileTypeWebM
+		case fileTypePdf:
+			fileType = values.FileTypePdf
+		case fileTypeZip:
+			fileType = values.FileTypeZip
+		case fileTypeAvif:
+			fileType = values.FileTypeAvif
 		case fileTypeOther:
 			fileType = values.FileTypeOther
 		default:
 			return nil, fmt.Errorf("invalid file type: %s", resourceTable.File.FileType.Name)
 		}
 
+		fileHash, err := values.NewFileHashFromString(resourceTable.File.Hash)
+		if err != nil {
+			return nil, fmt.Errorf("invalid file hash: %w", err)
+		}
+
+		file := domain.NewFile(
+			values.NewFileIDFromUUID(resourceTable.File.ID),
+			fileType,
+			fileHash,
+			resourceTable.File.Size,
+			newImageMetadata(&resourceTable.File),
+			resourceTable.File.CreatedAt,
+		)
+		file.SetPerceptualHash(newPerceptualHash(&resourceTable.File))
+		file.SetScan(newFileScan(&resourceTable.File))
+		file.SetOriginal(values.FileName(resourceTable.File.OriginalName), resourceTable.File.OriginalSize)
+
 		resource := repository.ResourceInfo{
 			Resource: domain.NewResource(
 				values.ResourceID(resourceTable.ID),
@@ -305,11 +495,7 @@ func (r *Resource) GetResources(ctx context.Context, params *repository.Resource
 				values.NewResourceComment(resourceTable.Comment),
 				resourceTable.CreatedAt,
 			),
-			File: domain.NewFile(
-				values.NewFileIDFromUUID(resourceTable.File.ID),
-				fileType,
-				resourceTable.File.CreatedAt,
-			),
+			File:    file,
 			Creator: values.NewTrapMemberID(resourceTable.File.CreatorID),
 		}
 
@@ -346,6 +532,14 @@ func (r *Resource) GetResourcesByIDs(ctx context.Context, resourceIDs []values.R
 		switch resourceTable.ResourceType.Name {
 		case resourceTypeImage:
 			resourceType = values.ResourceTypeImage
+		case resourceTypeMusic:
+			resourceType = values.ResourceTypeMusic
+		case resourceTypeVideo:
+			resourceType = values.ResourceTypeVideo
+		case resourceTypeDocument:
+			resourceType = values.ResourceTypeDocument
+		case resourceTypeArchive:
+			resourceType = values.ResourceTypeArchive
 		case resourceTypeOther:
 			resourceType = values.ResourceTypeOther
 		default:
@@ -363,3 +557,418 @@ func (r *Resource) GetResourcesByIDs(ctx context.Context, resourceIDs []values.R
 
 	return resources, nil
 }
+
+func (r *Resource) UpdateResourceFile(ctx context.Context, resourceID values.ResourceID, fileID values.FileID) error {
+	db, err := r.db.getDB(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get db: %w", err)
+	}
+
+	result := db.
+		Model(&ResourceTable{}).
+		Where("id = ?", uuid.UUID(resourceID)).
+		Update("file_id", uuid.UUID(fileID))
+	err = result.Error
+	if err != nil {
+		return fmt.Errorf("failed to update resource file: %w", err)
+	}
+
+	if result.RowsAffected == 0 {
+		return repository.ErrNoRecordUpdated
+	}
+
+	return nil
+}
+
+func (r *Resource) SaveResourceVersion(ctx context.Context, resourceID values.ResourceID, fileID values.FileID, version *domain.ResourceVersion) error {
+	db, err := r.db.getDB(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get db: %w", err)
+	}
+
+	resourceVersionTable := ResourceVersionTable{
+		ResourceID: uuid.UUID(resourceID),
+		Version:    int(version.GetVersion()),
+		FileID:     uuid.UUID(fileID),
+		CreatedAt:  version.GetCreatedAt(),
+	}
+
+	err = db.Create(&resourceVersionTable).Error
+	if err != nil {
+		return fmt.Errorf("failed to create resource version: %w", err)
+	}
+
+	return nil
+}
+
+func (r *Resource) GetResourceVersions(ctx context.Context, resourceID values.ResourceID) ([]*repository.ResourceVersionInfo, error) {
+	db, err := r.db.getDB(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get db: %w", err)
+	}
+
+	var resourceVersionTables []ResourceVersionTable
+	err = db.
+		Session(&gorm.Session{}).
+		Joins("File").
+		Where("resource_versions.resource_id = ?", uuid.UUID(resourceID)).
+		Where("File.deleted_at IS NULL").
+		Order("resource_versions.version DESC").
+		Select(
+			"resource_versions.version",
+			"resource_versions.created_at",
+		).
+		Find(&resourceVersionTables).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get resource versions: %w", err)
+	}
+
+	var fileTypeTables []FileTypeTable
+	err = db.
+		Session(&gorm.Session{}).
+		Find(&fileTypeTables).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get file type: %w", err)
+	}
+
+	fileTypeMap := make(map[int]string, len(fileTypeTables))
+	for _, fileTypeTable := range fileTypeTables {
+		fileTypeMap[fileTypeTable.ID] = fileTypeTable.Name
+	}
+
+	resourceVersions := make([]*repository.ResourceVersionInfo, 0, len(resourceVersionTables))
+	for _, resourceVersionTable := range resourceVersionTables {
+		var fileType values.FileType
+		switch fileTypeMap[resourceVersionTable.File.FileTypeID] {
+		case fileTypeJpeg:
+			fileType = values.FileTypeJpeg
+		case fileTypePng:
+			fileType = values.FileTypePng
+		case fileTypeWebP:
+			fileType = values.FileTypeWebP
+		case fileTypeSvg:
+			fileType = values.FileTypeSvg
+		case fileTypeGif:
+			fileType = values.FileTypeGif
+		case fileTypeMp3:
+			fileType = values.FileTypeMp3
+		case fileTypeOgg:
+			fileType = values.FileTypeOgg
+		case fileTypeWav:
+			fileType = values.FileTypeWav
+		case fileTypeFlac:
+			fileType = values.FileTypeFlac
+		case fileTypeMp4:
+			fileType = values.FileTypeMp4
+		case fileTypeWebM:
+			fileType = values.FileTypeWebM
+		case fileTypePdf:
+			fileType = values.FileTypePdf
+		case fileTypeZip:
+			fileType = values.FileTypeZip
+		case fileTypeAvif:
+			fileType = values.FileTypeAvif
+		case fileTypeOther:
+			fileType = values.FileTypeOther
+		default:
+			return nil, fmt.Errorf("invalid file type: %d", resourceVersionTable.File.FileTypeID)
+		}
+
+		fileHash, err := values.NewFileHashFromString(resourceVersionTable.File.Hash)
+		if err != nil {
+			return nil, fmt.Errorf("invalid file hash: %w", err)
+		}
+
+		file := domain.NewFile(
+			values.NewFileIDFromUUID(resourceVersionTable.File.ID),
+			fileType,
+			fileHash,
+			resourceVersionTable.File.Size,
+			newImageMetadata(&resourceVersionTable.File),
+			resourceVersionTable.File.CreatedAt,
+		)
+		file.SetPerceptualHash(newPerceptualHash(&resourceVersionTable.File))
+		file.SetScan(newFileScan(&resourceVersionTable.File))
+		file.SetOriginal(values.FileName(resourceVersionTable.File.OriginalName), resourceVersionTable.File.OriginalSize)
+
+		resourceVersions = append(resourceVersions, &repository.ResourceVersionInfo{
+			ResourceVersion: domain.NewResourceVersion(
+				values.ResourceVersion(resourceVersionTable.Version),
+				resourceVersionTable.CreatedAt,
+			),
+			File:    file,
+			Creator: values.NewTrapMemberID(resourceVersionTable.File.CreatorID),
+		})
+	}
+
+	return resourceVersions, nil
+}
+
+func (r *Resource) DeleteResource(ctx context.Context, resourceID values.ResourceID, deletedAt time.Time) error {
+	db, err := r.db.getDB(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get db: %w", err)
+	}
+
+	result := db.
+		Model(&ResourceTable{}).
+		Where("id = ?", uuid.UUID(resourceID)).
+		Update("deleted_at", deletedAt)
+	err = result.Error
+	if err != nil {
+		return fmt.Errorf("failed to delete resource: %w", err)
+	}
+
+	if result.RowsAffected == 0 {
+		return repository.ErrNoRecordDeleted
+	}
+
+	return nil
+}
+
+func (r *Resource) GetResourceIDsByFileID(ctx context.Context, fileID values.FileID) ([]values.ResourceID, error) {
+	db, err := r.db.getDB(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get db: %w", err)
+	}
+
+	var uuidResourceIDs []uuid.UUID
+	err = db.
+		Session(&gorm.Session{}).
+		Model(&ResourceTable{}).
+		Where("file_id = ?", uuid.UUID(fileID)).
+		Pluck("id", &uuidResourceIDs).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get resource ids: %w", err)
+	}
+
+	resourceIDs := make([]values.ResourceID, 0, len(uuidResourceIDs))
+	for _, uuidResourceID := range uuidResourceIDs {
+		resourceIDs = append(resourceIDs, values.NewResourceIDFromUUID(uuidResourceID))
+	}
+
+	return resourceIDs, nil
+}
+
+func (r *Resource) RestoreResource(ctx context.Context, resourceID values.ResourceID) error {
+	db, err := r.db.getDB(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get db: %w", err)
+	}
+
+	result := db.
+		Unscoped().
+		Model(&ResourceTable{}).
+		Where("id = ? AND deleted_at IS NOT NULL", uuid.UUID(resourceID)).
+		Update("deleted_at", nil)
+	err = result.Error
+	if err != nil {
+		return fmt.Errorf("failed to restore resource: %w", err)
+	}
+
+	if result.RowsAffected == 0 {
+		return repository.ErrNoRecordUpdated
+	}
+
+	return nil
+}
+
+func (r *Resource) RestoreResourcesByFileID(ctx context.Context, fileID values.FileID, deletedAt time.Time) error {
+	db, err := r.db.getDB(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get db: %w", err)
+	}
+
+	err = db.
+		Unscoped().
+		Model(&ResourceTable{}).
+		Where("file_id = ? AND deleted_at = ?", uuid.UUID(fileID), deletedAt).
+		Update("deleted_at", nil).Error
+	if err != nil {
+		return fmt.Errorf("failed to restore resources: %w", err)
+	}
+
+	return nil
+}
+
+func (r *Resource) GetDeletedResource(ctx context.Context, resourceID values.ResourceID, lockType repository.LockType) (*repository.DeletedResourceInfo, error) {
+	db, err := r.db.getDB(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get db: %w", err)
+	}
+
+	db, err = r.db.setLock(db, lockType)
+	if err != nil {
+		return nil, fmt.Errorf("failed to set lock: %w", err)
+	}
+
+	var resourceTable ResourceTable
+	err = db.
+		Session(&gorm.Session{}).
+		Unscoped().
+		Joins("ResourceType").
+		Joins("File").
+		Where("resources.id = ? AND resources.deleted_at IS NOT NULL", uuid.UUID(resourceID)).
+		Select(
+			"resources.name",
+			"resources.comment",
+			"resources.created_at",
+			"resources.deleted_at",
+		).
+		Take(&resourceTable).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, repository.ErrRecordNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get resource: %w", err)
+	}
+
+	var resourceType values.ResourceType
+	switch resourceTable.ResourceType.Name {
+	case resourceTypeImage:
+		resourceType = values.ResourceTypeImage
+	case resourceTypeMusic:
+		resourceType = values.ResourceTypeMusic
+	case resourceTypeVideo:
+		resourceType = values.ResourceTypeVideo
+	case resourceTypeDocument:
+		resourceType = values.ResourceTypeDocument
+	case resourceTypeArchive:
+		resourceType = values.ResourceTypeArchive
+	case resourceTypeOther:
+		resourceType = values.ResourceTypeOther
+	default:
+		return nil, fmt.Errorf("invalid resource type: %s", resourceTable.ResourceType.Name)
+	}
+
+	err = db.
+		Session(&gorm.Session{}).
+		Where("id = ?", resourceTable.File.FileTypeID).
+		Take(&resourceTable.File.FileType).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get resource type: %w", err)
+	}
+
+	var fileType values.FileType
+	switch resourceTable.File.FileType.Name {
+	case fileTypeJpeg:
+		fileType = values.FileTypeJpeg
+	case fileTypePng:
+		fileType = values.FileTypePng
+	case fileTypeWebP:
+		fileType = values.FileTypeWebP
+	case fileTypeSvg:
+		fileType = values.FileTypeSvg
+	case fileTypeGif:
+		fileType = values.FileTypeGif
+	case fileTypeMp3:
+		fileType = values.FileTypeMp3
+	case fileTypeOgg:
+		fileType = values.FileTypeOgg
+	case fileTypeWav:
+		fileType = values.FileTypeWav
+	case fileTypeFlac:
+		fileType = values.FileTypeFlac
+	case fileTypeMp4:
+		fileType = values.FileTypeMp4
+	case fileTypeWebM:
+		fileType = values.FileTypeWebM
+	case fileTypePdf:
+		fileType = values.FileTypePdf
+	case fileTypeZip:
+		fileType = values.FileTypeZip
+	case fileTypeAvif:
+		fileType = values.FileTypeAvif
+	case fileTypeOther:
+		fileType = values.FileTypeOther
+	default:
+		return nil, fmt.Errorf("invalid file type: %s", resourceTable.File.FileType.Name)
+	}
+
+	fileHash, err := values.NewFileHashFromString(resourceTable.File.Hash)
+	if err != nil {
+		return nil, fmt.Errorf("invalid file hash: %w", err)
+	}
+
+	var fileDeletedAt *time.Time
+	if resourceTable.File.DeletedAt.Valid {
+		fileDeletedAt = &resourceTable.File.DeletedAt.Time
+	}
+
+	return &repository.DeletedResourceInfo{
+		ResourceInfo: &repository.ResourceInfo{
+			Resource: domain.NewResource(
+				resourceID,
+				values.NewResourceName(resourceTable.Name),
+				resourceType,
+				values.NewResourceComment(resourceTable.Comment),
+				resourceTable.CreatedAt,
+			),
+			File: domain.NewFile(
+				values.NewFileIDFromUUID(resourceTable.File.ID),
+				fileType,
+				fileHash,
+				resourceTable.File.Size,
+				newImageMetadata(&resourceTable.File),
+				resourceTable.File.CreatedAt,
+			),
+			Creator: values.NewTrapMemberID(resourceTable.File.CreatorID),
+		},
+		FileDeletedAt: fileDeletedAt,
+		DeletedAt:     resourceTable.DeletedAt.Time,
+	}, nil
+}
+
+func (r *Resource) GetDeletedResourceIDs(ctx context.Context, before time.Time) ([]values.ResourceID, error) {
+	db, err := r.db.getDB(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get db: %w", err)
+	}
+
+	var uuidResourceIDs []uuid.UUID
+	err = db.
+		Session(&gorm.Session{}).
+		Unscoped().
+		Model(&ResourceTable{}).
+		Where("deleted_at < ?", before).
+		Pluck("id", &uuidResourceIDs).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get resource ids: %w", err)
+	}
+
+	resourceIDs := make([]values.ResourceID, 0, len(uuidResourceIDs))
+	for _, uuidResourceID := range uuidResourceIDs {
+		resourceIDs = append(resourceIDs, values.NewResourceIDFromUUID(uuidResourceID))
+	}
+
+	return resourceIDs, nil
+}
+
+func (r *Resource) PurgeResource(ctx context.Context, resourceID values.ResourceID) error {
+	db, err := r.db.getDB(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get db: %w", err)
+	}
+
+	err = db.
+		Where("resource_id = ?", uuid.UUID(resourceID)).
+		Delete(&ResourceVersionTable{}).Error
+	if err != nil {
+		return fmt.Errorf("failed to delete resource versions: %w", err)
+	}
+
+	result := db.
+		Unscoped().
+		Where("id = ?", uuid.UUID(resourceID)).
+		Delete(&ResourceTable{})
+	err = result.Error
+	if err != nil {
+		return fmt.Errorf("failed to purge resource: %w", err)
+	}
+
+	if result.RowsAffected == 0 {
+		return repository.ErrNoRecordDeleted
+	}
+
+	return nil
+}
@@ -0,0 +1,212 @@
+package gorm2
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mazrean/Quantainer/domain"
+	"github.com/mazrean/Quantainer/domain/values"
+	"github.com/mazrean/Quantainer/repository"
+	"gorm.io/gorm"
+)
+
+const (
+	shareLinkTargetTypeFile     = "file"
+	shareLinkTargetTypeResource = "resource"
+	shareLinkTargetTypeGroup    = "group"
+)
+
+type ShareLink struct {
+	db *DB
+}
+
+func NewShareLink(db *DB) *ShareLink {
+	return &ShareLink{
+		db: db,
+	}
+}
+
+func (sl *ShareLink) SaveShareLink(ctx context.Context, userID values.TraPMemberID, shareLink *domain.ShareLink) error {
+	db, err := sl.db.getDB(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get db: %w", err)
+	}
+
+	var targetType string
+	switch shareLink.GetTargetType() {
+	case values.ShareLinkTargetTypeFile:
+		targetType = shareLinkTargetTypeFile
+	case values.ShareLinkTargetTypeResource:
+		targetType = shareLinkTargetTypeResource
+	case values.ShareLinkTargetTypeGroup:
+		targetType = shareLinkTargetTypeGroup
+	default:
+		return fmt.Errorf("unknown share link target type: %d", shareLink.GetTargetType())
+	}
+
+	shareLinkTable := ShareLinkTable{
+		ID:           uuid.UUID(shareLink.GetID()),
+		TargetType:   targetType,
+		TargetID:     uuid.UUID(shareLink.GetTargetID()),
+		MaxDownloads: int64(shareLink.GetMaxDownloads()),
+		Downloads:    shareLink.GetDownloads(),
+		CreatorID:    uuid.UUID(userID),
+		ExpiresAt:    shareLink.GetExpiresAt(),
+		CreatedAt:    shareLink.GetCreatedAt(),
+	}
+	if revokedAt := shareLink.GetRevokedAt(); revokedAt != nil {
+		shareLinkTable.RevokedAt = sql.NullTime{
+			Time:  *revokedAt,
+			Valid: true,
+		}
+	}
+
+	err = db.Create(&shareLinkTable).Error
+	if err != nil {
+		return fmt.Errorf("failed to create share link: %w", err)
+	}
+
+	return nil
+}
+
+func (sl *ShareLink) GetShareLink(ctx context.Context, shareLinkID values.ShareLinkID, lockType repository.LockType) (*repository.ShareLinkWithCreator, error) {
+	db, err := sl.db.getDB(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get db: %w", err)
+	}
+
+	db, err = sl.db.setLock(db, lockType)
+	if err != nil {
+		return nil, fmt.Errorf("failed to set lock: %w", err)
+	}
+
+	var shareLinkTable ShareLinkTable
+	err = db.
+		Session(&gorm.Session{}).
+		Where("id = ?", uuid.UUID(shareLinkID)).
+		Take(&shareLinkTable).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, repository.ErrRecordNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get share link: %w", err)
+	}
+
+	shareLink, err := newShareLink(&shareLinkTable)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert share link: %w", err)
+	}
+
+	return &repository.ShareLinkWithCreator{
+		ShareLink: shareLink,
+		Creator:   values.NewTrapMemberID(shareLinkTable.CreatorID),
+	}, nil
+}
+
+func (sl *ShareLink) GetShareLinksByCreator(ctx context.Context, userID values.TraPMemberID) ([]*domain.ShareLink, error) {
+	db, err := sl.db.getDB(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get db: %w", err)
+	}
+
+	var shareLinkTables []ShareLinkTable
+	err = db.
+		Session(&gorm.Session{}).
+		Where("creator_id = ?", uuid.UUID(userID)).
+		Order("created_at DESC").
+		Find(&shareLinkTables).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get share links: %w", err)
+	}
+
+	shareLinks := make([]*domain.ShareLink, 0, len(shareLinkTables))
+	for i := range shareLinkTables {
+		shareLink, err := newShareLink(&shareLinkTables[i])
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert share link: %w", err)
+		}
+
+		shareLinks = append(shareLinks, shareLink)
+	}
+
+	return shareLinks, nil
+}
+
+func (sl *ShareLink) RevokeShareLink(ctx context.Context, shareLinkID values.ShareLinkID, revokedAt time.Time) error {
+	db, err := sl.db.getDB(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get db: %w", err)
+	}
+
+	result := db.
+		Model(&ShareLinkTable{}).
+		Where("id = ? AND revoked_at IS NULL", uuid.UUID(shareLinkID)).
+		Update("revoked_at", revokedAt)
+	err = result.Error
+	if err != nil {
+		return fmt.Errorf("failed to revoke share link: %w", err)
+	}
+
+	if result.RowsAffected == 0 {
+		return repository.ErrNoRecordUpdated
+	}
+
+	return nil
+}
+
+func (sl *ShareLink) IncrementShareLinkDownloads(ctx context.Context, shareLinkID values.ShareLinkID) error {
+	db, err := sl.db.getDB(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get db: %w", err)
+	}
+
+	// 同時にダウンロードされても上限を超えないよう、条件付きで更新する
+	result := db.
+		Model(&ShareLinkTable{}).
+		Where("id = ? AND revoked_at IS NULL AND (max_downloads = 0 OR downloads < max_downloads)", uuid.UUID(shareLinkID)).
+		Update("downloads", gorm.Expr("downloads + 1"))
+	err = result.Error
+	if err != nil {
+		return fmt.Errorf("failed to increment share link downloads: %w", err)
+	}
+
+	if result.RowsAffected == 0 {
+		return repository.ErrNoRecordUpdated
+	}
+
+	return nil
+}
+
+func newShareLink(shareLinkTable *ShareLinkTable) (*domain.ShareLink, error) {
+	var targetType values.ShareLinkTargetType
+	switch shareLinkTable.TargetType {
+	case shareLinkTargetTypeFile:
+		targetType = values.ShareLinkTargetTypeFile
+	case shareLinkTargetTypeResource:
+		targetType = values.ShareLinkTargetTypeResource
+	case shareLinkTargetTypeGroup:
+		targetType = values.ShareLinkTargetTypeGroup
+	default:
+		return nil, fmt.Errorf("unknown share link target type: %s", shareLinkTable.TargetType)
+	}
+
+	var revokedAt *time.Time
+	if shareLinkTable.RevokedAt.Valid {
+		revokedAt = &shareLinkTable.RevokedAt.Time
+	}
+
+	return domain.NewShareLink(
+		values.NewShareLinkIDFromUUID(shareLinkTable.ID),
+		targetType,
+		values.NewShareLinkTargetID(shareLinkTable.TargetID),
+		values.NewShareLinkMaxDownloads(shareLinkTable.MaxDownloads),
+		shareLinkTable.Downloads,
+		shareLinkTable.ExpiresAt,
+		revokedAt,
+		shareLinkTable.CreatedAt,
+	), nil
+}
@@ -1,6 +1,7 @@
 package gorm2
 
 import (
+	"database/sql"
 	"time"
 
 	"github.com/google/uuid"
@@ -11,22 +12,45 @@ var (
 	tables = []interface{}{
 		&FileTable{},
 		&FileTypeTable{},
+		&RenditionTable{},
+		&TransformTable{},
 		&ResourceTable{},
 		&ResourceTypeTable{},
+		&ResourceVersionTable{},
 		&GroupTable{},
 		&GroupTypeTable{},
 		&ReadPermissionTable{},
 		&WritePermissionTable{},
 		&AdministratorTable{},
+		&UserQuotaTable{},
+		&UploadTable{},
+		&ShareLinkTable{},
 	}
 )
 
 type FileTable struct {
-	ID         uuid.UUID     `gorm:"type:varchar(36);not null;primaryKey"`
-	FileTypeID int           `gorm:"type:tinyint;not null"`
-	CreatorID  uuid.UUID     `gorm:"type:varchar(36);not null"`
-	CreatedAt  time.Time     `gorm:"type:datetime;not null"`
-	FileType   FileTypeTable `gorm:"foreignKey:FileTypeID"`
+	ID               uuid.UUID      `gorm:"type:varchar(36);not null;primaryKey"`
+	FileTypeID       int            `gorm:"type:tinyint;not null"`
+	Hash             string         `gorm:"type:char(64);not null;default:'';index"`
+	Size             int64          `gorm:"type:bigint;not null;default:0"`
+	ImageWidth       int            `gorm:"type:int;not null;default:0"`
+	ImageHeight      int            `gorm:"type:int;not null;default:0"`
+	ImageFrames      int            `gorm:"type:int;not null;default:0"`
+	ColorSpace       string         `gorm:"type:varchar(16);not null;default:''"`
+	ImageOrientation int            `gorm:"type:tinyint;not null;default:0"`
+	CapturedAt       sql.NullTime   `gorm:"type:DATETIME NULL;default:NULL"`
+	CameraModel      string         `gorm:"type:varchar(255);not null;default:''"`
+	PerceptualHash   sql.NullInt64  `gorm:"type:BIGINT NULL;default:NULL;index"`
+	ScanStatus       string         `gorm:"type:varchar(16);not null;default:''"`
+	ScanSignature    string         `gorm:"type:varchar(255);not null;default:''"`
+	ScannedAt        sql.NullTime   `gorm:"type:DATETIME NULL;default:NULL"`
+	OriginalName     string         `gorm:"type:varchar(255);not null;default:''"`
+	Extension        string         `gorm:"type:varchar(32);not null;default:'';index"`
+	OriginalSize     int64          `gorm:"type:bigint;not null;default:0"`
+	CreatorID        uuid.UUID      `gorm:"type:varchar(36);not null"`
+	CreatedAt        time.Time      `gorm:"type:datetime;not null"`
+	DeletedAt        gorm.DeletedAt `gorm:"type:DATETIME NULL;default:NULL"`
+	FileType         FileTypeTable  `gorm:"foreignKey:FileTypeID"`
 }
 
 func (ft *FileTable) TableName() string {
@@ -43,6 +67,31 @@ func (ft *FileTypeTable) TableName() string {
 	return "file_types"
 }
 
+type RenditionTable struct {
+	FileID          uuid.UUID `gorm:"type:varchar(36);not null;primaryKey"`
+	Size            int       `gorm:"type:smallint;not null;primaryKey"`
+	RenditionFileID uuid.UUID `gorm:"type:varchar(36);not null;unique"`
+	File            FileTable `gorm:"foreignKey:FileID"`
+	RenditionFile   FileTable `gorm:"foreignKey:RenditionFileID"`
+}
+
+func (rt *RenditionTable) TableName() string {
+	return "renditions"
+}
+
+// TransformTable 変換した画像のレンディション
+type TransformTable struct {
+	FileID          uuid.UUID `gorm:"type:varchar(36);not null;primaryKey"`
+	Transform       string    `gorm:"type:varchar(64);size:64;not null;primaryKey"`
+	RenditionFileID uuid.UUID `gorm:"type:varchar(36);not null;unique"`
+	File            FileTable `gorm:"foreignKey:FileID"`
+	RenditionFile   FileTable `gorm:"foreignKey:RenditionFileID"`
+}
+
+func (tt *TransformTable) TableName() string {
+	return "transforms"
+}
+
 type ResourceTable struct {
 	ID             uuid.UUID         `gorm:"type:varchar(36);not null;primaryKey"`
 	FileID         uuid.UUID         `gorm:"type:varchar(36);not null"`
@@ -50,6 +99,7 @@ type ResourceTable struct {
 	ResourceTypeID int               `gorm:"type:tinyint;not null"`
 	Comment        string            `gorm:"type:varchar(400);size:400;not null"`
 	CreatedAt      time.Time         `gorm:"type:datetime;not null"`
+	DeletedAt      gorm.DeletedAt    `gorm:"type:DATETIME NULL;default:NULL"`
 	File           FileTable         `gorm:"foreignKey:FileID"`
 	ResourceType   ResourceTypeTable `gorm:"foreignKey:ResourceTypeID"`
 }
@@ -68,6 +118,21 @@ func (rtt *ResourceTypeTable) TableName() string {
 	return "resource_types"
 }
 
+// ResourceVersionTable リソースが参照してきたファイルの履歴。
+// 最新の版のファイルはresources.file_idにも保存する
+type ResourceVersionTable struct {
+	ResourceID uuid.UUID     `gorm:"type:varchar(36);not null;primaryKey"`
+	Version    int           `gorm:"type:int;not null;primaryKey"`
+	FileID     uuid.UUID     `gorm:"type:varchar(36);not null;index"`
+	CreatedAt  time.Time     `gorm:"type:datetime;not null"`
+	Resource   ResourceTable `gorm:"foreignKey:ResourceID"`
+	File       FileTable     `gorm:"foreignKey:FileID"`
+}
+
+func (rvt *ResourceVersionTable) TableName() string {
+	return "resource_versions"
+}
+
 type GroupTable struct {
 	ID                uuid.UUID            `gorm:"type:varchar(36);not null;primaryKey"`
 	Name              string               `gorm:"type:varchar(64);size:64;not null"`
@@ -128,3 +193,46 @@ type AdministratorTable struct {
 func (at *AdministratorTable) TableName() string {
 	return "administrators"
 }
+
+// UserQuotaTable 管理者がデフォルトから変更したユーザーの上限
+type UserQuotaTable struct {
+	UserID   uuid.UUID `gorm:"type:varchar(36);not null;primaryKey"`
+	MaxBytes int64     `gorm:"type:bigint;not null"`
+	MaxFiles int64     `gorm:"type:bigint;not null"`
+}
+
+func (uqt *UserQuotaTable) TableName() string {
+	return "user_quotas"
+}
+
+// UploadTable 再開可能なアップロードのうち、ファイルとして保存される前のもの
+type UploadTable struct {
+	ID        uuid.UUID `gorm:"type:varchar(36);not null;primaryKey"`
+	Length    int64     `gorm:"type:bigint;not null"`
+	Offset    int64     `gorm:"type:bigint;not null;default:0"`
+	Metadata  string    `gorm:"type:text;not null"`
+	CreatorID uuid.UUID `gorm:"type:varchar(36);not null;index"`
+	ExpiresAt time.Time `gorm:"type:datetime;not null;index"`
+	CreatedAt time.Time `gorm:"type:datetime;not null"`
+}
+
+func (ut *UploadTable) TableName() string {
+	return "uploads"
+}
+
+// ShareLinkTable traPのメンバー以外にファイル、リソース、グループを共有するリンク
+type ShareLinkTable struct {
+	ID           uuid.UUID    `gorm:"type:varchar(36);not null;primaryKey"`
+	TargetType   string       `gorm:"type:varchar(32);size:32;not null"`
+	TargetID     uuid.UUID    `gorm:"type:varchar(36);not null"`
+	MaxDownloads int64        `gorm:"type:bigint;not null;default:0"`
+	Downloads    int64        `gorm:"type:bigint;not null;default:0"`
+	CreatorID    uuid.UUID    `gorm:"type:varchar(36);not null;index"`
+	ExpiresAt    time.Time    `gorm:"type:datetime;not null"`
+	RevokedAt    sql.NullTime `gorm:"type:DATETIME NULL;default:NULL"`
+	CreatedAt    time.Time    `gorm:"type:datetime;not null"`
+}
+
+func (slt *ShareLinkTable) TableName() string {
+	return "share_links"
+}
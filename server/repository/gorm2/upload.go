@@ -0,0 +1,180 @@
+package gorm2
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mazrean/Quantainer/domain"
+	"github.com/mazrean/Quantainer/domain/values"
+	"github.com/mazrean/Quantainer/repository"
+	"gorm.io/gorm"
+)
+
+type Upload struct {
+	db *DB
+}
+
+func NewUpload(db *DB) *Upload {
+	return &Upload{
+		db: db,
+	}
+}
+
+func (u *Upload) SaveUpload(ctx context.Context, userID values.TraPMemberID, upload *domain.Upload) error {
+	db, err := u.db.getDB(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get db: %w", err)
+	}
+
+	uploadTable := UploadTable{
+		ID:        uuid.UUID(upload.GetID()),
+		Length:    upload.GetLength(),
+		Offset:    upload.GetOffset(),
+		Metadata:  string(upload.GetMetadata()),
+		CreatorID: uuid.UUID(userID),
+		ExpiresAt: upload.GetExpiresAt(),
+		CreatedAt: upload.GetCreatedAt(),
+	}
+
+	err = db.Create(&uploadTable).Error
+	if err != nil {
+		return fmt.Errorf("failed to create upload: %w", err)
+	}
+
+	return nil
+}
+
+func (u *Upload) GetUpload(ctx context.Context, uploadID values.UploadID, lockType repository.LockType) (*repository.UploadWithCreator, error) {
+	db, err := u.db.getDB(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get db: %w", err)
+	}
+
+	db, err = u.db.setLock(db, lockType)
+	if err != nil {
+		return nil, fmt.Errorf("failed to set lock: %w", err)
+	}
+
+	var uploadTable UploadTable
+	err = db.
+		Session(&gorm.Session{}).
+		Where("id = ?", uuid.UUID(uploadID)).
+		Take(&uploadTable).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, repository.ErrRecordNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get upload: %w", err)
+	}
+
+	return &repository.UploadWithCreator{
+		Upload:  newUpload(&uploadTable),
+		Creator: values.NewTrapMemberID(uploadTable.CreatorID),
+	}, nil
+}
+
+func (u *Upload) UpdateUploadOffset(ctx context.Context, upload *domain.Upload) error {
+	db, err := u.db.getDB(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get db: %w", err)
+	}
+
+	result := db.
+		Model(&UploadTable{}).
+		Where("id = ?", uuid.UUID(upload.GetID())).
+		Updates(map[string]interface{}{
+			"offset":     upload.GetOffset(),
+			"expires_at": upload.GetExpiresAt(),
+		})
+	err = result.Error
+	if err != nil {
+		return fmt.Errorf("failed to update upload offset: %w", err)
+	}
+
+	if result.RowsAffected == 0 {
+		return repository.ErrNoRecordUpdated
+	}
+
+	return nil
+}
+
+func (u *Upload) DeleteUpload(ctx context.Context, uploadID values.UploadID) error {
+	db, err := u.db.getDB(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get db: %w", err)
+	}
+
+	result := db.
+		Where("id = ?", uuid.UUID(uploadID)).
+		Delete(&UploadTable{})
+	err = result.Error
+	if err != nil {
+		return fmt.Errorf("failed to delete upload: %w", err)
+	}
+
+	if result.RowsAffected == 0 {
+		return repository.ErrNoRecordDeleted
+	}
+
+	return nil
+}
+
+func (u *Upload) GetUserUploadLength(ctx context.Context, userID values.TraPMemberID, after time.Time) (int64, error) {
+	db, err := u.db.getDB(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get db: %w", err)
+	}
+
+	var result struct {
+		Length int64
+	}
+	err = db.
+		Session(&gorm.Session{}).
+		Model(&UploadTable{}).
+		Where("creator_id = ?", uuid.UUID(userID)).
+		Where("expires_at > ?", after).
+		Select("COALESCE(SUM(length), 0) AS length").
+		Take(&result).Error
+	if err != nil {
+		return 0, fmt.Errorf("failed to get upload length: %w", err)
+	}
+
+	return result.Length, nil
+}
+
+func (u *Upload) GetExpiredUploads(ctx context.Context, before time.Time) ([]*domain.Upload, error) {
+	db, err := u.db.getDB(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get db: %w", err)
+	}
+
+	var uploadTables []UploadTable
+	err = db.
+		Session(&gorm.Session{}).
+		Where("expires_at <= ?", before).
+		Find(&uploadTables).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get expired uploads: %w", err)
+	}
+
+	uploads := make([]*domain.Upload, 0, len(uploadTables))
+	for i := range uploadTables {
+		uploads = append(uploads, newUpload(&uploadTables[i]))
+	}
+
+	return uploads, nil
+}
+
+func newUpload(uploadTable *UploadTable) *domain.Upload {
+	return domain.NewUpload(
+		values.NewUploadIDFromUUID(uploadTable.ID),
+		uploadTable.Length,
+		uploadTable.Offset,
+		values.UploadMetadata(uploadTable.Metadata),
+		uploadTable.ExpiresAt,
+		uploadTable.CreatedAt,
+	)
+}
@@ -1,7 +1,10 @@
 package repository
 
+//go:generate mockgen -source=$GOFILE -destination=mock/${GOFILE} -package=mock
+
 import (
 	"context"
+	"time"
 
 	"github.com/mazrean/Quantainer/domain"
 	"github.com/mazrean/Quantainer/domain/values"
@@ -11,11 +14,22 @@ import (
 type Group interface {
 	SaveGroup(ctx context.Context, group *domain.Group, mainResource values.ResourceID) error
 	EditGroup(ctx context.Context, group *domain.Group, mainResource values.ResourceID) error
-	DeleteGroup(ctx context.Context, group *domain.Group) error
+	// DeleteGroup deletedAtに論理削除する
+	DeleteGroup(ctx context.Context, group *domain.Group, deletedAt time.Time) error
 	AddResources(ctx context.Context, group *domain.Group, resources []values.ResourceID) error
 	DeleteResources(ctx context.Context, group *domain.Group, resources []values.ResourceID) error
 	GetGroup(ctx context.Context, groupID values.GroupID, lockType LockType) (*GroupInfo, error)
 	GetGroups(ctx context.Context, user *service.UserInfo, params *GroupSearchParams) ([]*GroupInfo, error)
+	// GetGroupsByResource resourceを含むグループを論理削除されたものも含めて取得する
+	GetGroupsByResource(ctx context.Context, resource values.ResourceID) ([]*domain.Group, error)
+	// GetGroupsByMainResource resourceをメインのリソースとするグループを取得する
+	GetGroupsByMainResource(ctx context.Context, resource values.ResourceID) ([]*domain.Group, error)
+	// GetDeletedGroupIDsByMainResource resourceをメインのリソースとする論理削除されたグループのIDを取得する
+	GetDeletedGroupIDsByMainResource(ctx context.Context, resource values.ResourceID) ([]values.GroupID, error)
+	// RestoreGroupsByMainResource resourceをメインのリソースとし、deletedAtに論理削除されたグループを復元する
+	RestoreGroupsByMainResource(ctx context.Context, resource values.ResourceID, deletedAt time.Time) error
+	// PurgeGroup 管理者やリソースとの関連も含めて完全に削除する
+	PurgeGroup(ctx context.Context, groupID values.GroupID) error
 }
 
 type GroupInfo struct {
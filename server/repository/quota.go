@@ -0,0 +1,18 @@
+package repository
+
+//go:generate mockgen -source=$GOFILE -destination=mock/${GOFILE} -package=mock
+
+import (
+	"context"
+
+	"github.com/mazrean/Quantainer/domain/values"
+	"github.com/mazrean/Quantainer/service"
+)
+
+type Quota interface {
+	// SaveUserQuota ユーザーの上限を保存する。既に保存されている場合は上書きする。
+	SaveUserQuota(ctx context.Context, userID values.TraPMemberID, limit *service.QuotaLimit) error
+	// GetUserQuota 上限が変更されていないユーザーの場合はErrRecordNotFoundを返す
+	GetUserQuota(ctx context.Context, userID values.TraPMemberID) (*service.QuotaLimit, error)
+	DeleteUserQuota(ctx context.Context, userID values.TraPMemberID) error
+}
@@ -0,0 +1,26 @@
+package repository
+
+//go:generate mockgen -source=$GOFILE -destination=mock/${GOFILE} -package=mock
+
+import (
+	"context"
+
+	"github.com/mazrean/Quantainer/domain"
+	"github.com/mazrean/Quantainer/domain/values"
+)
+
+type Rendition interface {
+	// SaveRendition renditionはrepository.File.SaveFileで保存済みである必要がある
+	SaveRendition(ctx context.Context, fileID values.FileID, size values.RenditionSize, rendition *domain.File) error
+	// GetRendition 元のファイルが論理削除されている場合はErrRecordNotFound
+	GetRendition(ctx context.Context, fileID values.FileID, size values.RenditionSize, lockType LockType) (*domain.File, error)
+	// SaveTransform 変換した画像をレンディションとして記録する。
+	// renditionはrepository.File.SaveFileで保存済みである必要がある
+	SaveTransform(ctx context.Context, fileID values.FileID, transform values.ImageTransform, rendition *domain.File) error
+	// GetTransform 元のファイルが論理削除されている場合はErrRecordNotFound
+	GetTransform(ctx context.Context, fileID values.FileID, transform values.ImageTransform, lockType LockType) (*domain.File, error)
+	// GetRenditions 変換した画像も含めて取得する。元のファイルが論理削除されていても取得する
+	GetRenditions(ctx context.Context, fileID values.FileID) ([]*domain.File, error)
+	// DeleteRenditions 変換した画像も含めてレンディションとの関連を削除する。レンディションのファイルは別途削除する必要がある。
+	DeleteRenditions(ctx context.Context, fileID values.FileID) error
+}
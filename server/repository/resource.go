@@ -1,7 +1,10 @@
 package repository
 
+//go:generate mockgen -source=$GOFILE -destination=mock/${GOFILE} -package=mock
+
 import (
 	"context"
+	"time"
 
 	"github.com/mazrean/Quantainer/domain"
 	"github.com/mazrean/Quantainer/domain/values"
@@ -13,6 +16,27 @@ type Resource interface {
 	GetResource(ctx context.Context, resourceID values.ResourceID) (*ResourceInfo, error)
 	GetResources(ctx context.Context, params *ResourceSearchParams) ([]*ResourceInfo, error)
 	GetResourcesByIDs(ctx context.Context, resourceIDs []values.ResourceID, lockType LockType) ([]*domain.Resource, error)
+	// UpdateResourceFile リソースの最新の版のファイルを差し替える。
+	// 版の履歴はSaveResourceVersionで別に保存する必要がある。
+	UpdateResourceFile(ctx context.Context, resourceID values.ResourceID, fileID values.FileID) error
+	SaveResourceVersion(ctx context.Context, resourceID values.ResourceID, fileID values.FileID, version *domain.ResourceVersion) error
+	// GetResourceVersions リソースの版を新しい順に取得する。
+	// 論理削除されたファイルの版と、版の履歴を保存する前に作成されたリソースの版は含まれない。
+	GetResourceVersions(ctx context.Context, resourceID values.ResourceID) ([]*ResourceVersionInfo, error)
+	// DeleteResource 論理削除する。GetResourceなどでは取得できなくなる。
+	DeleteResource(ctx context.Context, resourceID values.ResourceID, deletedAt time.Time) error
+	// GetResourceIDsByFileID fileIDのファイルを参照するリソースのIDを取得する
+	GetResourceIDsByFileID(ctx context.Context, fileID values.FileID) ([]values.ResourceID, error)
+	RestoreResource(ctx context.Context, resourceID values.ResourceID) error
+	// RestoreResourcesByFileID fileIDのファイルを参照し、deletedAtに論理削除されたリソースを復元する
+	RestoreResourcesByFileID(ctx context.Context, fileID values.FileID, deletedAt time.Time) error
+	// GetDeletedResource 論理削除されたリソースを取得する
+	GetDeletedResource(ctx context.Context, resourceID values.ResourceID, lockType LockType) (*DeletedResourceInfo, error)
+	// GetDeletedResourceIDs before以前に論理削除されたリソースのIDを取得する
+	GetDeletedResourceIDs(ctx context.Context, before time.Time) ([]values.ResourceID, error)
+	// PurgeResource 論理削除されたものも含めて、版の履歴と共に完全に削除する。
+	// グループからは事前に取り除いておく必要がある。
+	PurgeResource(ctx context.Context, resourceID values.ResourceID) error
 }
 
 type ResourceInfo struct {
@@ -21,10 +45,43 @@ type ResourceInfo struct {
 	Creator values.TraPMemberID
 }
 
+type ResourceVersionInfo struct {
+	*domain.ResourceVersion
+	*domain.File
+	Creator values.TraPMemberID
+}
+
+type DeletedResourceInfo struct {
+	*ResourceInfo
+	// FileDeletedAt ファイルが論理削除されていない場合はnil
+	FileDeletedAt *time.Time
+	DeletedAt     time.Time
+}
+
 type ResourceSearchParams struct {
 	ResourceTypes []values.ResourceType
 	Users         []*service.UserInfo
 	Groups        []*domain.Group
-	Limit         int
-	Offset        int
+	// ImageOrientations 指定された場合は画像のメタデータを持つリソースのみ
+	ImageOrientations []values.ImageOrientation
+	// MinWidth 0の場合は制限しない
+	MinWidth int
+	// MinHeight 0の場合は制限しない
+	MinHeight int
+	// FileName 空文字列でない場合は、アップロードされた時のファイル名にこれを含むリソースのみ
+	FileName string
+	// Extensions 指定された場合は、アップロードされた時のファイル名の拡張子がいずれかであるリソースのみ
+	Extensions []values.FileExtension
+	// HasPerceptualHash trueの場合は知覚ハッシュを計算済みの画像のリソースのみ
+	HasPerceptualHash bool
+	// SimilarTo 指定された場合は知覚ハッシュの近い画像のリソースのみを、近い順に取得する
+	SimilarTo *SimilarImageSearch
+	Limit     int
+	Offset    int
+}
+
+type SimilarImageSearch struct {
+	PerceptualHash values.PerceptualHash
+	// MaxDistance 知覚ハッシュの異なるビットの数の上限
+	MaxDistance int
 }
@@ -0,0 +1,28 @@
+package repository
+
+//go:generate mockgen -source=$GOFILE -destination=mock/${GOFILE} -package=mock
+
+import (
+	"context"
+	"time"
+
+	"github.com/mazrean/Quantainer/domain"
+	"github.com/mazrean/Quantainer/domain/values"
+)
+
+type ShareLink interface {
+	SaveShareLink(ctx context.Context, userID values.TraPMemberID, shareLink *domain.ShareLink) error
+	// GetShareLink 取り消されたものや有効期限切れのものも取得する
+	GetShareLink(ctx context.Context, shareLinkID values.ShareLinkID, lockType LockType) (*ShareLinkWithCreator, error)
+	// GetShareLinksByCreator userIDのユーザーが作成した共有リンクを作成日時の新しい順に取得する
+	GetShareLinksByCreator(ctx context.Context, userID values.TraPMemberID) ([]*domain.ShareLink, error)
+	RevokeShareLink(ctx context.Context, shareLinkID values.ShareLinkID, revokedAt time.Time) error
+	// IncrementShareLinkDownloads ダウンロードの回数を1増やす。
+	// 回数が上限に達している場合や取り消されている場合はErrNoRecordUpdatedを返す。
+	IncrementShareLinkDownloads(ctx context.Context, shareLinkID values.ShareLinkID) error
+}
+
+type ShareLinkWithCreator struct {
+	*domain.ShareLink
+	Creator values.TraPMemberID
+}
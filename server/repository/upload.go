@@ -0,0 +1,29 @@
+package repository
+
+//go:generate mockgen -source=$GOFILE -destination=mock/${GOFILE} -package=mock
+
+import (
+	"context"
+	"time"
+
+	"github.com/mazrean/Quantainer/domain"
+	"github.com/mazrean/Quantainer/domain/values"
+)
+
+type Upload interface {
+	SaveUpload(ctx context.Context, userID values.TraPMemberID, upload *domain.Upload) error
+	// GetUpload 期限切れで破棄される前のアップロードも取得する
+	GetUpload(ctx context.Context, uploadID values.UploadID, lockType LockType) (*UploadWithCreator, error)
+	// UpdateUploadOffset 書き込み済みのバイト数と有効期限を更新する
+	UpdateUploadOffset(ctx context.Context, upload *domain.Upload) error
+	DeleteUpload(ctx context.Context, uploadID values.UploadID) error
+	// GetUserUploadLength userIDのユーザーのafterより後まで有効なアップロードについて、Upload-Lengthの合計を取得する
+	GetUserUploadLength(ctx context.Context, userID values.TraPMemberID, after time.Time) (int64, error)
+	// GetExpiredUploads before以前に有効期限が切れたアップロードを取得する
+	GetExpiredUploads(ctx context.Context, before time.Time) ([]*domain.Upload, error)
+}
+
+type UploadWithCreator struct {
+	*domain.Upload
+	Creator values.TraPMemberID
+}
@@ -0,0 +1,148 @@
+package clamd
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/mazrean/Quantainer/domain"
+	"github.com/mazrean/Quantainer/domain/values"
+	"github.com/mazrean/Quantainer/pkg/common"
+)
+
+const (
+	// chunkSize INSTREAMで1度に送るバイト数
+	chunkSize = 64 << 10
+	// dialTimeout clamdに接続できない場合に、アップロードを長く待たせないための上限
+	dialTimeout = 10 * time.Second
+)
+
+var ErrScanFailed = errors.New("scan failed")
+
+// File clamdのINSTREAMコマンドで検査する。
+// clamdのStreamMaxLengthを超えるファイルは検査に失敗するので、アップロードの上限以上に設定する必要がある。
+type File struct {
+	network string
+	address string
+	dialer  *net.Dialer
+}
+
+func NewFile(address common.ClamdAddress) (*File, error) {
+	network, addr, err := parseAddress(string(address))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse clamd address: %w", err)
+	}
+
+	return &File{
+		network: network,
+		address: addr,
+		dialer: &net.Dialer{
+			Timeout: dialTimeout,
+		},
+	}, nil
+}
+
+// parseAddress unix:///var/run/clamav/clamd.ctlやtcp://localhost:3310の形式のアドレスを、
+// net.Dialに渡すネットワークとアドレスに分ける
+func parseAddress(address string) (string, string, error) {
+	u, err := url.Parse(address)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to parse url: %w", err)
+	}
+
+	switch u.Scheme {
+	case "unix":
+		if len(u.Path) == 0 {
+			return "", "", errors.New("empty socket path")
+		}
+
+		return "unix", u.Path, nil
+	case "tcp":
+		if len(u.Host) == 0 {
+			return "", "", errors.New("empty host")
+		}
+
+		return "tcp", u.Host, nil
+	default:
+		return "", "", fmt.Errorf("unsupported scheme: %s", u.Scheme)
+	}
+}
+
+func (f *File) Scan(ctx context.Context, reader io.Reader) (*domain.FileScan, error) {
+	conn, err := f.dialer.DialContext(ctx, f.network, f.address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to dial clamd: %w", err)
+	}
+	defer conn.Close()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		err = conn.SetDeadline(deadline)
+		if err != nil {
+			return nil, fmt.Errorf("failed to set deadline: %w", err)
+		}
+	}
+
+	// zで始まるコマンドは、応答がNULL文字で終わる
+	_, err = conn.Write([]byte("zINSTREAM\x00"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to write command: %w", err)
+	}
+
+	// 各チャンクはビッグエンディアンの4バイトの長さに続けて送り、長さ0のチャンクで終える
+	buf := make([]byte, 4+chunkSize)
+	for {
+		n, readErr := io.ReadFull(reader, buf[4:])
+		if n > 0 {
+			binary.BigEndian.PutUint32(buf[:4], uint32(n))
+			_, err = conn.Write(buf[:4+n])
+			if err != nil {
+				return nil, fmt.Errorf("failed to write chunk: %w", err)
+			}
+		}
+		if errors.Is(readErr, io.EOF) || errors.Is(readErr, io.ErrUnexpectedEOF) {
+			break
+		}
+		if readErr != nil {
+			return nil, fmt.Errorf("failed to read file: %w", readErr)
+		}
+	}
+
+	_, err = conn.Write([]byte{0, 0, 0, 0})
+	if err != nil {
+		return nil, fmt.Errorf("failed to write end of stream: %w", err)
+	}
+
+	response, err := bufio.NewReader(conn).ReadString(0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	return parseResponse(strings.TrimSuffix(response, "\x00"), time.Now())
+}
+
+// parseResponse 「stream: OK」「stream: <マルウェアの名前> FOUND」「<エラーの内容> ERROR」の形式の応答を解釈する
+func parseResponse(response string, scannedAt time.Time) (*domain.FileScan, error) {
+	response = strings.TrimSpace(response)
+	if strings.HasSuffix(response, " ERROR") {
+		return nil, fmt.Errorf("%s: %w", response, ErrScanFailed)
+	}
+
+	result := strings.TrimPrefix(response, "stream: ")
+	switch {
+	case result == "OK":
+		return domain.NewFileScan(values.ScanStatusClean, "", scannedAt), nil
+	case strings.HasSuffix(result, " FOUND"):
+		signature := strings.TrimSuffix(result, " FOUND")
+
+		return domain.NewFileScan(values.ScanStatusInfected, values.NewScanSignature(signature), scannedAt), nil
+	default:
+		return nil, fmt.Errorf("unexpected response(%s): %w", response, ErrScanFailed)
+	}
+}
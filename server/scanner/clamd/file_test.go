@@ -0,0 +1,238 @@
+package clamd
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mazrean/Quantainer/domain/values"
+	"github.com/mazrean/Quantainer/pkg/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseAddress(t *testing.T) {
+	t.Parallel()
+
+	type test struct {
+		description string
+		address     string
+		isErr       bool
+		network     string
+		addr        string
+	}
+
+	testCases := []test{
+		{
+			description: "unixソケット",
+			address:     "unix:///var/run/clamav/clamd.ctl",
+			network:     "unix",
+			addr:        "/var/run/clamav/clamd.ctl",
+		},
+		{
+			description: "tcp",
+			address:     "tcp://localhost:3310",
+			network:     "tcp",
+			addr:        "localhost:3310",
+		},
+		{
+			description: "ソケットのパスがないのでエラー",
+			address:     "unix://",
+			isErr:       true,
+		},
+		{
+			description: "対応していないスキームなのでエラー",
+			address:     "http://localhost:3310",
+			isErr:       true,
+		},
+	}
+
+	for _, testCase := range testCases {
+		testCase := testCase
+		t.Run(testCase.description, func(t *testing.T) {
+			t.Parallel()
+
+			network, addr, err := parseAddress(testCase.address)
+			if testCase.isErr {
+				assert.Error(t, err)
+				return
+			}
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			assert.Equal(t, testCase.network, network)
+			assert.Equal(t, testCase.addr, addr)
+		})
+	}
+}
+
+func TestParseResponse(t *testing.T) {
+	t.Parallel()
+
+	type test struct {
+		description string
+		response    string
+		isErr       bool
+		status      values.ScanStatus
+		signature   values.ScanSignature
+	}
+
+	testCases := []test{
+		{
+			description: "感染していない",
+			response:    "stream: OK",
+			status:      values.ScanStatusClean,
+		},
+		{
+			description: "感染している",
+			response:    "stream: Win.Test.EICAR_HDB-1 FOUND",
+			status:      values.ScanStatusInfected,
+			signature:   "Win.Test.EICAR_HDB-1",
+		},
+		{
+			description: "サイズの上限を超えたのでエラー",
+			response:    "INSTREAM size limit exceeded. ERROR",
+			isErr:       true,
+		},
+		{
+			description: "不明な応答なのでエラー",
+			response:    "UNKNOWN COMMAND",
+			isErr:       true,
+		},
+	}
+
+	for _, testCase := range testCases {
+		testCase := testCase
+		t.Run(testCase.description, func(t *testing.T) {
+			t.Parallel()
+
+			scannedAt := time.Now()
+			scan, err := parseResponse(testCase.response, scannedAt)
+			if testCase.isErr {
+				assert.ErrorIs(t, err, ErrScanFailed)
+				return
+			}
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			assert.Equal(t, testCase.status, scan.GetStatus())
+			assert.Equal(t, testCase.signature, scan.GetSignature())
+			assert.Equal(t, scannedAt, scan.GetScannedAt())
+		})
+	}
+}
+
+func TestScan(t *testing.T) {
+	t.Parallel()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	// 並列に実行するテストケースが終わるまで閉じない
+	t.Cleanup(func() {
+		listener.Close()
+	})
+
+	// 受け取った内容にEICARが含まれる場合のみ感染していると応答する
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+
+			go func(conn net.Conn) {
+				defer conn.Close()
+
+				reader := bufio.NewReader(conn)
+				command, err := reader.ReadString(0)
+				if err != nil || command != "zINSTREAM\x00" {
+					return
+				}
+
+				content := bytes.NewBuffer(nil)
+				for {
+					var length uint32
+					err = binary.Read(reader, binary.BigEndian, &length)
+					if err != nil {
+						return
+					}
+					if length == 0 {
+						break
+					}
+
+					_, err = io.CopyN(content, reader, int64(length))
+					if err != nil {
+						return
+					}
+				}
+
+				if strings.Contains(content.String(), "EICAR") {
+					_, _ = conn.Write([]byte("stream: Eicar-Signature FOUND\x00"))
+				} else {
+					_, _ = conn.Write([]byte("stream: OK\x00"))
+				}
+			}(conn)
+		}
+	}()
+
+	file, err := NewFile(common.ClamdAddress("tcp://" + listener.Addr().String()))
+	if err != nil {
+		t.Fatalf("failed to create file: %v", err)
+	}
+
+	type test struct {
+		description string
+		content     string
+		status      values.ScanStatus
+		signature   values.ScanSignature
+	}
+
+	testCases := []test{
+		{
+			description: "感染していない",
+			content:     "hello",
+			status:      values.ScanStatusClean,
+		},
+		{
+			description: "感染している",
+			content:     "X5O!P%@AP[4\\PZX54(P^)7CC)7}$EICAR-STANDARD-ANTIVIRUS-TEST-FILE!$H+H*",
+			status:      values.ScanStatusInfected,
+			signature:   "Eicar-Signature",
+		},
+		{
+			description: "複数のチャンクに分けて送っても検査できる",
+			content:     strings.Repeat("a", 2*chunkSize+1) + "EICAR",
+			status:      values.ScanStatusInfected,
+			signature:   "Eicar-Signature",
+		},
+		{
+			description: "空のファイルも検査できる",
+			content:     "",
+			status:      values.ScanStatusClean,
+		},
+	}
+
+	for _, testCase := range testCases {
+		testCase := testCase
+		t.Run(testCase.description, func(t *testing.T) {
+			t.Parallel()
+
+			scan, err := file.Scan(context.Background(), strings.NewReader(testCase.content))
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			assert.Equal(t, testCase.status, scan.GetStatus())
+			assert.Equal(t, testCase.signature, scan.GetSignature())
+		})
+	}
+}
@@ -0,0 +1,14 @@
+package scanner
+
+import (
+	"context"
+	"io"
+
+	"github.com/mazrean/Quantainer/domain"
+)
+
+// File アップロードされたファイルのマルウェアの検査
+type File interface {
+	// Scan readerの内容を検査する。検査しない場合はnilを返す
+	Scan(ctx context.Context, reader io.Reader) (*domain.FileScan, error)
+}
@@ -0,0 +1,19 @@
+package noop
+
+import (
+	"context"
+	"io"
+
+	"github.com/mazrean/Quantainer/domain"
+)
+
+// File 検査を行わない。検査が設定されていない場合に使う
+type File struct{}
+
+func NewFile() *File {
+	return &File{}
+}
+
+func (*File) Scan(context.Context, io.Reader) (*domain.FileScan, error) {
+	return nil, nil
+}
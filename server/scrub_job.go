@@ -0,0 +1,46 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"time"
+
+	"github.com/mazrean/Quantainer/service"
+)
+
+// defaultScrubGracePeriod アップロード中のファイルを孤立したファイルとして扱わないための猶予期間
+const defaultScrubGracePeriod = time.Hour
+
+// runScrubJob intervalごとにストレージとDBの整合性を確認し、不整合があればログに出力する。
+// 誤検知での削除を避けるため、定期実行では孤立したファイルの報告のみ行う。
+func runScrubJob(ctx context.Context, scrubber service.Scrubber, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		result, err := scrubber.Scrub(ctx, &service.ScrubParams{
+			OrphanAction: service.ScrubOrphanActionReport,
+			GracePeriod:  defaultScrubGracePeriod,
+		})
+		if err != nil {
+			log.Printf("error: failed to scrub: %v\n", err)
+			continue
+		}
+
+		if isScrubResultClean(result) {
+			log.Printf("info: scrub finished: checked %d files\n", result.Checked)
+			continue
+		}
+
+		buf := bytes.NewBuffer(nil)
+		printScrubResult(buf, result)
+		log.Printf("warn: scrub found inconsistency:\n%s", buf.String())
+	}
+}
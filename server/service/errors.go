@@ -16,4 +16,17 @@ var (
 	ErrInvalidPermission      = errors.New("invalid permission")
 	ErrResourceAlreadyExists  = errors.New("resource already exists")
 	ErrNotEditted             = errors.New("not editted")
+	ErrRestorePeriodExpired   = errors.New("restore period expired")
+	ErrFileDeleted            = errors.New("file deleted")
+	ErrQuotaExceeded          = errors.New("quota exceeded")
+	ErrStorageFull            = errors.New("storage full")
+	ErrFileTooLarge           = errors.New("file too large")
+	ErrNoUpload               = errors.New("no upload")
+	ErrUploadOffsetMismatch   = errors.New("upload offset mismatch")
+	ErrUploadLocked           = errors.New("upload locked")
+	ErrInvalidShareToken      = errors.New("invalid share token")
+	ErrShareLinkUnavailable   = errors.New("share link unavailable")
+	ErrNoShareLink            = errors.New("no share link")
+	ErrInvalidImport          = errors.New("invalid import")
+	ErrFileInfected           = errors.New("file infected")
 )
@@ -1,5 +1,7 @@
 package service
 
+//go:generate mockgen -source=$GOFILE -destination=mock/${GOFILE} -package=mock
+
 import (
 	"context"
 	"io"
@@ -9,12 +11,36 @@ import (
 )
 
 type File interface {
-	Upload(ctx context.Context, session *domain.OIDCSession, reader io.Reader) (*FileInfo, error)
-	UploadBotFile(ctx context.Context, user *UserInfo, reader io.Reader) (*FileInfo, error)
-	Download(ctx context.Context, fileID values.FileID, writer io.Writer) (*domain.File, error)
+	// Upload nameはアップロードされた時のファイル名で、わからない場合は空文字列
+	Upload(ctx context.Context, session *domain.OIDCSession, name values.FileName, reader io.Reader) (*FileInfo, error)
+	UploadBotFile(ctx context.Context, user *UserInfo, name values.FileName, reader io.Reader) (*FileInfo, error)
+	// Download 返り値のio.ReadSeekCloserは呼び出し側でCloseする必要がある
+	Download(ctx context.Context, fileID values.FileID) (*domain.File, io.ReadSeekCloser, error)
+	// DownloadRendition レンディションがない場合は元のファイルを返す
+	DownloadRendition(ctx context.Context, fileID values.FileID, size values.RenditionSize) (*domain.File, io.ReadSeekCloser, error)
+	// DownloadTransformed 画像をpresetに合わせてformatの形式に変換したものを返す。
+	// formatがnilの場合は元のファイルの種類に合わせる。変換結果はレンディションとして保存し、次からはそれを返す。
+	DownloadTransformed(
+		ctx context.Context,
+		fileID values.FileID,
+		preset values.ImageTransformPreset,
+		format *values.FileType,
+		quality values.ImageQuality,
+	) (*domain.File, io.ReadSeekCloser, error)
+	// DeleteFile ファイルとそれを参照するリソースを論理削除する。
+	// 作成者と管理者のみが削除でき、一定期間内であればRestoreFileで復元できる。
+	DeleteFile(ctx context.Context, session *domain.OIDCSession, fileID values.FileID) error
+	// RestoreFile ファイルと、ファイルと同時に削除されたリソースを復元する
+	RestoreFile(ctx context.Context, session *domain.OIDCSession, fileID values.FileID) error
+	// GetMaxUploadSize アップロードできるファイルのバイト数の上限。0の場合は無制限
+	GetMaxUploadSize() int64
 }
 
 type FileInfo struct {
 	File    *domain.File
 	Creator *UserInfo
+	// SimilarResources アップロードした画像に似た画像の既存のリソース。Uploadでのみ設定される
+	SimilarResources []*SimilarResourceInfo
+	// Renditions アップロード時に保存したレンディション。Uploadでのみ設定される
+	Renditions []*domain.File
 }
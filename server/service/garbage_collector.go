@@ -0,0 +1,21 @@
+package service
+
+import "context"
+
+type GarbageCollector interface {
+	// CollectGarbage 復元期間を過ぎたリソースと、復元期間を過ぎたか参照されていないファイルを完全に削除する。
+	// 期限切れの再開可能なアップロードと、どのファイルからも参照されなくなったストレージの実体も削除する。
+	CollectGarbage(ctx context.Context) (*GarbageCollectionResult, error)
+}
+
+type GarbageCollectionResult struct {
+	PurgedResources int
+	PurgedGroups    int
+	PurgedFiles     int
+	// DiscardedUploads 期限切れで破棄したアップロードの数
+	DiscardedUploads int
+	// DeletedObjects ストレージから削除した、どのファイルからも参照されていない実体の数
+	DeletedObjects int
+	// Failed 削除に失敗したリソース・ファイル・実体と破棄に失敗したアップロードの数。次回のGCで再度削除を試みる。
+	Failed int
+}
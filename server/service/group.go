@@ -1,7 +1,10 @@
 package service
 
+//go:generate mockgen -source=$GOFILE -destination=mock/${GOFILE} -package=mock
+
 import (
 	"context"
+	"io"
 
 	"github.com/mazrean/Quantainer/domain"
 	"github.com/mazrean/Quantainer/domain/values"
@@ -35,6 +38,9 @@ type Group interface {
 	AddResource(ctx context.Context, session *domain.OIDCSession, id values.GroupID, resource values.ResourceID) ([]*ResourceInfo, error)
 	GetGroup(ctx context.Context, session *domain.OIDCSession, groupID values.GroupID) (*GroupDetail, error)
 	GetGroups(ctx context.Context, session *domain.OIDCSession, params *GroupSearchParams) ([]*GroupInfo, error)
+	// GetGroupArchive グループの全てのリソースのファイルとmanifest.jsonをまとめたZIPを返す。
+	// ZIPは読み込みに合わせて作成するので、呼び出し側でCloseする必要がある。
+	GetGroupArchive(ctx context.Context, session *domain.OIDCSession, groupID values.GroupID) (*domain.Group, io.ReadCloser, error)
 }
 
 type GroupInfo struct {
@@ -0,0 +1,34 @@
+package service
+
+import (
+	"context"
+	"io/fs"
+
+	"github.com/mazrean/Quantainer/domain"
+	"github.com/mazrean/Quantainer/domain/values"
+)
+
+type Importer interface {
+	// ImportGroup fsysの各ファイルからファイルとリソースを作成し、それらをまとめたグループを作成する。
+	// fsysのmanifest.jsonでリソースの名前・コメントと並び順を指定できる。
+	// 途中で失敗した場合は、作成したファイル・リソースも含めて全て取り消す。
+	ImportGroup(ctx context.Context, session *domain.OIDCSession, params *ImportGroupParams, fsys fs.FS) (*ImportResult, error)
+}
+
+type ImportGroupParams struct {
+	// Name 空の場合はmanifest.jsonのグループ名
+	Name      values.GroupName
+	GroupType values.GroupType
+	// Description 空の場合はmanifest.jsonのグループの説明
+	Description     values.GroupDescription
+	ReadPermission  values.GroupReadPermission
+	WritePermission values.GroupWritePermission
+	// MainResource メインのリソースにするファイルのパス。
+	// 空の場合はmanifest.jsonで指定されたもの、指定がなければ最初のファイル
+	MainResource string
+}
+
+type ImportResult struct {
+	Group     *GroupDetail
+	Resources []*ResourceInfo
+}
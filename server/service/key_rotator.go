@@ -0,0 +1,24 @@
+package service
+
+import (
+	"context"
+
+	"github.com/mazrean/Quantainer/domain/values"
+)
+
+type KeyRotator interface {
+	// Rotate 全てのファイルを現在の鍵で暗号化し直す。
+	// 暗号化前に保存されたファイルも暗号化し、既に現在の鍵で暗号化されているファイルは飛ばす。
+	Rotate(ctx context.Context) (*KeyRotationResult, error)
+}
+
+type KeyRotationResult struct {
+	Rotated int
+	Skipped int
+	Failed  []*KeyRotationFailure
+}
+
+type KeyRotationFailure struct {
+	FileID values.FileID
+	Err    error
+}
@@ -0,0 +1,24 @@
+package service
+
+import (
+	"context"
+
+	"github.com/mazrean/Quantainer/domain/values"
+)
+
+type MetadataBackfiller interface {
+	// Backfill バイト数や画像のメタデータ、知覚ハッシュが記録されていないファイルについて、ストレージの内容から記録する。
+	// forceがtrueの場合は記録済みのファイルも記録し直す。
+	Backfill(ctx context.Context, force bool) (*MetadataBackfillResult, error)
+}
+
+type MetadataBackfillResult struct {
+	Updated int
+	Skipped int
+	Failed  []*MetadataBackfillFailure
+}
+
+type MetadataBackfillFailure struct {
+	FileID values.FileID
+	Err    error
+}
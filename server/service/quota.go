@@ -0,0 +1,36 @@
+package service
+
+//go:generate mockgen -source=$GOFILE -destination=mock/${GOFILE} -package=mock
+
+import (
+	"context"
+
+	"github.com/mazrean/Quantainer/domain"
+	"github.com/mazrean/Quantainer/domain/values"
+)
+
+type Quota interface {
+	// GetMyUsage 自分がアップロードしたファイルの使用量と上限を取得する
+	GetMyUsage(ctx context.Context, session *domain.OIDCSession) (*UsageInfo, error)
+	// SetUserQuota ユーザーの上限をデフォルトから変更する。管理者のみ実行できる。
+	SetUserQuota(ctx context.Context, session *domain.OIDCSession, userName values.TraPMemberName, limit *QuotaLimit) error
+	// ResetUserQuota ユーザーの上限をデフォルトに戻す。管理者のみ実行できる。
+	ResetUserQuota(ctx context.Context, session *domain.OIDCSession, userName values.TraPMemberName) error
+}
+
+// StorageUsage ファイルの使用量
+type StorageUsage struct {
+	Bytes int64
+	Files int64
+}
+
+// QuotaLimit 使用量の上限。0の場合は無制限。
+type QuotaLimit struct {
+	MaxBytes int64
+	MaxFiles int64
+}
+
+type UsageInfo struct {
+	Usage *StorageUsage
+	Limit *QuotaLimit
+}
@@ -1,5 +1,7 @@
 package service
 
+//go:generate mockgen -source=$GOFILE -destination=mock/${GOFILE} -package=mock
+
 import (
 	"context"
 	"time"
@@ -28,14 +30,39 @@ type Resource interface {
 	) (*ResourceInfo, error)
 	GetResource(ctx context.Context, session *domain.OIDCSession, resourceID values.ResourceID) (*ResourceInfo, error)
 	GetResources(ctx context.Context, session *domain.OIDCSession, params *ResourceSearchParams) ([]*ResourceInfo, error)
+	// GetSimilarResources 画像の知覚ハッシュが近いリソースを、近い順に最大limit個取得する
+	GetSimilarResources(ctx context.Context, session *domain.OIDCSession, resourceID values.ResourceID, limit int) ([]*SimilarResourceInfo, error)
+	// GetDuplicateResources 重複の可能性が高いリソースのまとまりを取得する。管理者のみが取得できる。
+	GetDuplicateResources(ctx context.Context, session *domain.OIDCSession) ([][]*ResourceInfo, error)
+	// ReplaceResourceFile リソースのファイルを次の版として差し替える。
+	// 作成者のみが、自身がアップロードしたファイルに差し替えられる。過去の版のファイルも引き続きダウンロードできる。
+	// 隔離されたファイルの場合はErrInvalidFormatを返し、現在のファイルの場合は版を作らない。
+	ReplaceResourceFile(ctx context.Context, session *domain.OIDCSession, resourceID values.ResourceID, fileID values.FileID) (*ResourceInfo, error)
+	// GetResourceVersions リソースの版を新しい順に取得する
+	GetResourceVersions(ctx context.Context, session *domain.OIDCSession, resourceID values.ResourceID) ([]*ResourceVersionInfo, error)
+	// DeleteResource リソースを論理削除する。
+	// 作成者と管理者のみが削除でき、一定期間内であればRestoreResourceで復元できる。
+	DeleteResource(ctx context.Context, session *domain.OIDCSession, resourceID values.ResourceID) error
+	// RestoreResource リソースを復元する。ファイルごと削除された場合はRestoreFileで復元する必要がある。
+	RestoreResource(ctx context.Context, session *domain.OIDCSession, resourceID values.ResourceID) error
 }
 
 type ResourceSearchParams struct {
 	ResourceTypes []values.ResourceType
 	Users         []values.TraPMemberName
 	Group         *values.GroupID
-	Limit         int
-	Offset        int
+	// ImageOrientations 指定された場合は画像のメタデータを持つリソースのみ
+	ImageOrientations []values.ImageOrientation
+	// MinWidth 0の場合は制限しない
+	MinWidth int
+	// MinHeight 0の場合は制限しない
+	MinHeight int
+	// FileName 空文字列でない場合は、アップロードされた時のファイル名にこれを含むリソースのみ
+	FileName string
+	// Extensions 指定された場合は、アップロードされた時のファイル名の拡張子がいずれかであるリソースのみ
+	Extensions []values.FileExtension
+	Limit      int
+	Offset     int
 }
 
 type ResourceInfo struct {
@@ -43,3 +70,15 @@ type ResourceInfo struct {
 	*domain.File
 	Creator *UserInfo
 }
+
+type ResourceVersionInfo struct {
+	*domain.ResourceVersion
+	*domain.File
+	Creator *UserInfo
+}
+
+type SimilarResourceInfo struct {
+	*ResourceInfo
+	// Distance 知覚ハッシュのハミング距離。小さいほど似ている
+	Distance int
+}
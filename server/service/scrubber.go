@@ -0,0 +1,63 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/mazrean/Quantainer/domain/values"
+)
+
+type Scrubber interface {
+	// Scrub DBのファイルとストレージに保存されているファイルを突き合わせ、不整合を報告する。
+	// params.OrphanActionに応じて、DBに存在しないファイルを削除・隔離する。
+	Scrub(ctx context.Context, params *ScrubParams) (*ScrubResult, error)
+}
+
+type ScrubParams struct {
+	// VerifyContent ファイルの内容を読み込み、ハッシュが一致するか検証する
+	VerifyContent bool
+	OrphanAction  ScrubOrphanAction
+	// QuarantineDir OrphanActionがScrubOrphanActionQuarantineの場合の隔離先のディレクトリ
+	QuarantineDir string
+	// GracePeriod 保存からこの期間が経っていないファイルは、
+	// アップロード中の可能性があるのでDBに存在しなくても孤立したファイルとして扱わない
+	GracePeriod time.Duration
+}
+
+// ScrubOrphanAction DBに存在しないファイルの扱い
+type ScrubOrphanAction int
+
+const (
+	// ScrubOrphanActionReport 報告のみ行う
+	ScrubOrphanActionReport ScrubOrphanAction = iota
+	// ScrubOrphanActionDelete ストレージから削除する
+	ScrubOrphanActionDelete
+	// ScrubOrphanActionQuarantine 隔離先のディレクトリに退避してからストレージから削除する
+	ScrubOrphanActionQuarantine
+)
+
+type ScrubResult struct {
+	// Checked 確認したストレージ上のファイルの数
+	Checked int
+	// Orphans ストレージに存在するが、DBに存在しないファイル
+	Orphans []*ScrubOrphan
+	// Missing DBに存在するが、ストレージに存在しないファイル
+	Missing []values.FileID
+	// HashMismatches DBとストレージでハッシュが異なるファイル
+	HashMismatches []*ScrubHashMismatch
+	// Corrupted 内容のハッシュが保存時のハッシュと異なるファイル
+	Corrupted []*ScrubHashMismatch
+}
+
+type ScrubOrphan struct {
+	FileID values.FileID
+	Hash   values.FileHash
+	// Err OrphanActionの実行に失敗した場合のエラー
+	Err error
+}
+
+type ScrubHashMismatch struct {
+	FileID   values.FileID
+	Expected values.FileHash
+	Actual   values.FileHash
+}
@@ -0,0 +1,50 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/mazrean/Quantainer/domain"
+	"github.com/mazrean/Quantainer/domain/values"
+)
+
+type ShareLink interface {
+	// CreateShareLink ファイルとリソースは作成者、グループは管理者のみが共有リンクを作成できる
+	CreateShareLink(
+		ctx context.Context,
+		session *domain.OIDCSession,
+		targetType values.ShareLinkTargetType,
+		targetID values.ShareLinkTargetID,
+		expiresAt time.Time,
+		maxDownloads values.ShareLinkMaxDownloads,
+	) (*ShareLinkInfo, error)
+	// GetMyShareLinks 自分が作成した共有リンクを、取り消されたものや有効期限切れのものも含めて取得する
+	GetMyShareLinks(ctx context.Context, session *domain.OIDCSession) ([]*ShareLinkInfo, error)
+	// RevokeShareLink 作成者と管理者のみが取り消せる
+	RevokeShareLink(ctx context.Context, session *domain.OIDCSession, shareLinkID values.ShareLinkID) error
+	// AuthenticateShareToken トークンを検証し、利用可能な共有リンクを返す
+	AuthenticateShareToken(ctx context.Context, token values.ShareToken) (*domain.ShareLink, error)
+	// GetSharedContent 共有リンクで共有されているファイルの一覧を取得する
+	GetSharedContent(ctx context.Context, shareLink *domain.ShareLink) (*SharedContent, error)
+	// AccessSharedFile 共有リンクでfileIDのファイルにアクセスできるか確認する。
+	// isDownloadがtrueの場合はダウンロードの回数を数える。
+	AccessSharedFile(ctx context.Context, shareLink *domain.ShareLink, fileID values.FileID, isDownload bool) error
+}
+
+type ShareLinkInfo struct {
+	*domain.ShareLink
+	Token values.ShareToken
+}
+
+type SharedContent struct {
+	*domain.ShareLink
+	// Group グループを共有している場合以外はnil
+	Group *domain.Group
+	Files []*SharedFile
+}
+
+type SharedFile struct {
+	*domain.File
+	// Resource ファイルを共有している場合はnil
+	Resource *domain.Resource
+}
@@ -0,0 +1,29 @@
+package service
+
+import (
+	"context"
+
+	"github.com/mazrean/Quantainer/domain/values"
+)
+
+type StorageMigrator interface {
+	// Migrate 全てのファイルを移行元のストレージから移行先のストレージへコピーする。
+	// migratedFileIDsに含まれるファイルは移行済みとして飛ばし、
+	// 新たに移行が完了したファイルごとにonMigratedを呼ぶ。
+	Migrate(
+		ctx context.Context,
+		migratedFileIDs map[values.FileID]struct{},
+		onMigrated func(fileID values.FileID) error,
+	) (*StorageMigrationResult, error)
+}
+
+type StorageMigrationResult struct {
+	Copied  int
+	Skipped int
+	Failed  []*StorageMigrationFailure
+}
+
+type StorageMigrationFailure struct {
+	FileID values.FileID
+	Err    error
+}
@@ -0,0 +1,22 @@
+package service
+
+import (
+	"context"
+	"io"
+
+	"github.com/mazrean/Quantainer/domain"
+	"github.com/mazrean/Quantainer/domain/values"
+)
+
+// Upload 途中で切断されても続きから再開できるアップロード
+type Upload interface {
+	// CreateUpload lengthバイトのファイルのアップロードを開始する
+	CreateUpload(ctx context.Context, session *domain.OIDCSession, length int64, metadata values.UploadMetadata) (*domain.Upload, error)
+	// GetUpload 作成者のみが取得できる
+	GetUpload(ctx context.Context, session *domain.OIDCSession, uploadID values.UploadID) (*domain.Upload, error)
+	// WriteUpload offsetの位置から続きを書き込む。
+	// 全て書き込まれた場合はファイルとして保存し、FileInfoも返す。
+	WriteUpload(ctx context.Context, session *domain.OIDCSession, uploadID values.UploadID, offset int64, reader io.Reader) (*domain.Upload, *FileInfo, error)
+	// GetMaxUploadSize アップロードできるファイルのバイト数の上限。0の場合は無制限
+	GetMaxUploadSize() int64
+}
@@ -1,89 +1,250 @@
 package v1
 
 import (
+	"bufio"
 	"bytes"
 	"context"
+	"encoding/binary"
 	"errors"
 	"fmt"
 	"io"
+	"log"
 	"net/http"
+	"regexp"
+	"strings"
 	"time"
+	"unicode/utf8"
 
-	svg "github.com/h2non/go-is-svg"
+	"github.com/google/uuid"
 	"github.com/mazrean/Quantainer/domain"
 	"github.com/mazrean/Quantainer/domain/values"
+	"github.com/mazrean/Quantainer/pkg/svgsanitize"
 	"github.com/mazrean/Quantainer/repository"
+	"github.com/mazrean/Quantainer/scanner"
 	"github.com/mazrean/Quantainer/service"
 	"github.com/mazrean/Quantainer/storage"
 )
 
 type File struct {
-	dbRepository   repository.DB
-	fileRepository repository.File
-	fileStorage    storage.File
-	userUtils      *UserUtils
+	dbRepository        repository.DB
+	fileRepository      repository.File
+	renditionRepository repository.Rendition
+	resourceRepository  repository.Resource
+	groupRepository     repository.Group
+	fileStorage         storage.File
+	fileScanner         scanner.File
+	userUtils           *UserUtils
+	quotaUtils          *QuotaUtils
+	uploadPolicy        *UploadPolicy
+	transformGroup      *transformGroup
 }
 
 func NewFile(
 	dbRepository repository.DB,
 	fileRepository repository.File,
+	renditionRepository repository.Rendition,
+	resourceRepository repository.Resource,
+	groupRepository repository.Group,
 	fileStorage storage.File,
+	fileScanner scanner.File,
 	userUtils *UserUtils,
+	quotaUtils *QuotaUtils,
+	uploadPolicy *UploadPolicy,
 ) *File {
 	return &File{
-		dbRepository:   dbRepository,
-		fileRepository: fileRepository,
-		fileStorage:    fileStorage,
-		userUtils:      userUtils,
+		dbRepository:        dbRepository,
+		fileRepository:      fileRepository,
+		renditionRepository: renditionRepository,
+		resourceRepository:  resourceRepository,
+		groupRepository:     groupRepository,
+		fileStorage:         fileStorage,
+		fileScanner:         fileScanner,
+		userUtils:           userUtils,
+		quotaUtils:          quotaUtils,
+		uploadPolicy:        uploadPolicy,
+		transformGroup:      newTransformGroup(),
 	}
 }
 
-func (f *File) Upload(ctx context.Context, session *domain.OIDCSession, reader io.Reader) (*service.FileInfo, error) {
+func (f *File) Upload(ctx context.Context, session *domain.OIDCSession, name values.FileName, reader io.Reader) (*service.FileInfo, error) {
 	user, err := f.userUtils.getMe(ctx, session)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get user: %w", err)
 	}
 
-	buf := bytes.NewBuffer(nil)
-	tr := io.TeeReader(reader, buf)
+	fileInfo, err := f.upload(ctx, user, values.NewFileID(), name, reader)
+	if err != nil {
+		return nil, err
+	}
+
+	perceptualHash := fileInfo.File.GetPerceptualHash()
+	if perceptualHash != nil {
+		// 似た画像の確認は警告のためなので、失敗してもアップロードは成功とする
+		users, err := f.userUtils.getAllActiveUser(ctx, session)
+		if err != nil {
+			log.Printf("error: failed to get users: %v\n", err)
+			return fileInfo, nil
+		}
+
+		fileInfo.SimilarResources, err = getSimilarResources(ctx, f.resourceRepository, users, *perceptualHash, nil, uploadSimilarResourceLimit)
+		if err != nil {
+			log.Printf("error: failed to get similar resources: %v\n", err)
+		}
+	}
+
+	return fileInfo, nil
+}
 
-	content, err := io.ReadAll(tr)
+func (f *File) UploadBotFile(ctx context.Context, user *service.UserInfo, name values.FileName, reader io.Reader) (*service.FileInfo, error) {
+	fileInfo, err := f.upload(ctx, user, values.NewFileID(), name, reader)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read file: %w", err)
+		return nil, err
 	}
 
-	var fileType values.FileType
-	mime := http.DetectContentType(content)
-	switch mime {
-	case "image/jpeg":
-		fileType = values.FileTypeJpeg
-	case "image/png":
-		fileType = values.FileTypePng
-	case "image/webp":
-		fileType = values.FileTypeWebP
-	case "image/gif":
-		fileType = values.FileTypeGif
-	default:
-		if svg.Is(content) {
-			fileType = values.FileTypeSvg
-		} else {
-			fileType = values.FileTypeOther
+	perceptualHash := fileInfo.File.GetPerceptualHash()
+	if perceptualHash != nil {
+		// botにはセッションがなく作成者を確認できないので、ログで知らせるのみとする
+		resourceInfos, err := f.resourceRepository.GetResources(ctx, &repository.ResourceSearchParams{
+			SimilarTo: &repository.SimilarImageSearch{
+				PerceptualHash: *perceptualHash,
+				MaxDistance:    similarImageMaxDistance,
+			},
+			Limit: 1,
+		})
+		if err != nil {
+			log.Printf("error: failed to get similar resources: %v\n", err)
+		} else if len(resourceInfos) != 0 {
+			log.Printf(
+				"warn: uploaded file(%s) is similar to resource(%s)\n",
+				uuid.UUID(fileInfo.File.GetID()).String(),
+				uuid.UUID(resourceInfos[0].Resource.GetID()).String(),
+			)
 		}
 	}
 
+	return fileInfo, nil
+}
+
+// upload readerの内容をfileIDのファイルとして、アップロードされた時のファイル名nameと共に保存する。
+// 再開可能なアップロードでは、アップロードのidをfileIDとして呼ぶ。
+func (f *File) upload(ctx context.Context, user *service.UserInfo, fileID values.FileID, name values.FileName, reader io.Reader) (*service.FileInfo, error) {
+	limit, err := f.quotaUtils.getUploadLimit(ctx, user)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get upload limit: %w", err)
+	}
+
+	// メタデータの除去などで保存する内容のバイト数は変わりうるので、アップロードされた時のバイト数を数えておく
+	originalReader := &countingReader{reader: reader}
+	reader = originalReader
+
+	// 保存前にはサイズがわからないので、上限を超えた時点で保存を中断する
+	var limitReader *limitedReader
+	if limit.remaining >= 0 {
+		limitReader = newLimitedReader(reader, limit.remaining)
+		reader = limitReader
+	}
+
+	fileType, mime, reader, err := detectFileType(reader)
+	if err != nil {
+		if limitReader != nil && limitReader.exceeded {
+			return nil, limit.err
+		}
+
+		return nil, fmt.Errorf("failed to detect file type: %w", err)
+	}
+
+	err = f.uploadPolicy.checkMimeType(mime)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check mime type: %w", err)
+	}
+
+	var sizeLimitReader *limitedReader
+	maxSize := f.uploadPolicy.getMaxSize(fileType)
+	if maxSize > 0 {
+		sizeLimitReader = newLimitedReader(reader, maxSize)
+		reader = sizeLimitReader
+	}
+
+	if fileType == values.FileTypeSvg {
+		// スクリプトなどを取り除いた内容を保存し解析するので、解析より前に処理する
+		reader = svgsanitize.NewReader(reader)
+	}
+
+	var (
+		imageMetadata *domain.ImageMetadata
+		analyzer      *imageAnalyzer
+	)
+	switch {
+	case fileType == values.FileTypeJpeg:
+		// 保存する内容自体を書き換えるので、保存前に処理する
+		imageMetadata, reader, err = processJPEGMetadata(reader, f.uploadPolicy.stripImageMetadata)
+		if limitReader != nil && limitReader.exceeded {
+			return nil, limit.err
+		}
+		if sizeLimitReader != nil && sizeLimitReader.exceeded {
+			return nil, service.ErrFileTooLarge
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to process jpeg metadata: %w", err)
+		}
+	case fileType.IsAnalyzableImage():
+		analyzer, reader = newImageAnalyzer(fileType, reader)
+	}
+
+	// 保存する内容を検査し、結果が出るまではDBに保存せず他のユーザーから見えないようにする
+	var contentScanner *uploadScanner
+	contentScanner, reader = newUploadScanner(ctx, f.fileScanner, reader)
+
+	// ハッシュはストレージへの保存時に設定される
 	file := domain.NewFile(
-		values.NewFileID(),
+		fileID,
 		fileType,
+		values.FileHash{},
+		0,
+		imageMetadata,
 		time.Now(),
 	)
 
 	err = f.dbRepository.Transaction(ctx, nil, func(ctx context.Context) error {
-		err := f.fileRepository.SaveFile(ctx, user, file)
+		err := f.fileStorage.SaveFile(ctx, file, reader)
+		scan, scanErr := contentScanner.wait(err)
+		if analyzer != nil {
+			// メタデータはなくてもファイルは使えるので、解析に失敗してもアップロードは成功とする
+			imageMetadata, analyzeErr := analyzer.wait(err)
+			if analyzeErr != nil {
+				log.Printf("error: failed to analyze image: %v\n", analyzeErr)
+			}
+			file.SetImageMetadata(imageMetadata)
+		}
+		if limitReader != nil && limitReader.exceeded {
+			return limit.err
+		}
+		if sizeLimitReader != nil && sizeLimitReader.exceeded {
+			return service.ErrFileTooLarge
+		}
+		if errors.Is(err, svgsanitize.ErrInvalidSVG) {
+			return fmt.Errorf("broken svg(%v): %w", err, service.ErrInvalidFormat)
+		}
 		if err != nil {
 			return fmt.Errorf("failed to save file: %w", err)
 		}
+		if scanErr != nil {
+			return fmt.Errorf("failed to scan file: %w", scanErr)
+		}
+
+		file.SetOriginal(name, originalReader.count)
 
-		err = f.fileStorage.SaveFile(ctx, file, buf)
+		// 感染が検出されたファイルも、検査の結果を示せるよう隔離した状態で保存する
+		file.SetScan(scan)
+		if file.IsInfected() {
+			log.Printf(
+				"warn: uploaded file(%s) is infected with %s and quarantined\n",
+				uuid.UUID(file.GetID()).String(),
+				file.GetScan().GetSignature(),
+			)
+		}
+
+		err = f.fileRepository.SaveFile(ctx, user, file)
 		if err != nil {
 			return fmt.Errorf("failed to save file: %w", err)
 		}
@@ -94,23 +255,51 @@ func (f *File) Upload(ctx context.Context, session *domain.OIDCSession, reader i
 		return nil, fmt.Errorf("failed in transaction: %w", err)
 	}
 
+	var renditions []*domain.File
+	if file.GetType().IsRenditionSupported() && !file.IsInfected() {
+		renditions = f.processStoredImage(ctx, user, file)
+	}
+
 	return &service.FileInfo{
-		File:    file,
-		Creator: user,
+		File:       file,
+		Creator:    user,
+		Renditions: renditions,
 	}, nil
 }
 
-func (f *File) UploadBotFile(ctx context.Context, user *service.UserInfo, reader io.Reader) (*service.FileInfo, error) {
-	buf := bytes.NewBuffer(nil)
-	tr := io.TeeReader(reader, buf)
+// countingReader 読み込んだバイト数を数えるio.Reader
+type countingReader struct {
+	reader io.Reader
+	count  int64
+}
+
+func (cr *countingReader) Read(p []byte) (int, error) {
+	n, err := cr.reader.Read(p)
+	cr.count += int64(n)
 
-	content, err := io.ReadAll(tr)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read file: %w", err)
+	return n, err
+}
+
+// sniffLength ファイルの種類の判定に使う先頭のバイト数
+const sniffLength = 4096
+
+var (
+	htmlCommentRegex = regexp.MustCompile(`(?s)<!--.*?-->`)
+	svgHeadRegex     = regexp.MustCompile(`(?i)^\s*(?:<\?xml[^>]*>\s*)?(?:<!doctype svg[^>]*>\s*)?<svg[\s>]`)
+)
+
+// detectFileType 先頭のsniffLengthバイトのみを読んでファイルの種類とMIMEタイプを判定する。
+// 返り値のio.Readerからは判定に使ったバイト列を含むファイル全体を読み出せる。
+func detectFileType(reader io.Reader) (values.FileType, string, io.Reader, error) {
+	bufReader := bufio.NewReaderSize(reader, sniffLength)
+
+	head, err := bufReader.Peek(sniffLength)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return 0, "", nil, fmt.Errorf("failed to read file head: %w", err)
 	}
 
 	var fileType values.FileType
-	mime := http.DetectContentType(content)
+	mime := detectMimeType(head)
 	switch mime {
 	case "image/jpeg":
 		fileType = values.FileTypeJpeg
@@ -120,56 +309,351 @@ func (f *File) UploadBotFile(ctx context.Context, user *service.UserInfo, reader
 		fileType = values.FileTypeWebP
 	case "image/gif":
 		fileType = values.FileTypeGif
+	case "image/svg+xml":
+		fileType = values.FileTypeSvg
+	case "image/avif":
+		fileType = values.FileTypeAvif
+	case "audio/mpeg":
+		fileType = values.FileTypeMp3
+	case "audio/ogg":
+		fileType = values.FileTypeOgg
+	case "audio/wav":
+		fileType = values.FileTypeWav
+	case "audio/flac":
+		fileType = values.FileTypeFlac
+	case "video/mp4":
+		fileType = values.FileTypeMp4
+	case "video/webm":
+		fileType = values.FileTypeWebM
+	case "application/pdf":
+		fileType = values.FileTypePdf
+	case "application/zip":
+		fileType = values.FileTypeZip
 	default:
-		if svg.Is(content) {
-			fileType = values.FileTypeSvg
-		} else {
-			fileType = values.FileTypeOther
+		fileType = values.FileTypeOther
+	}
+
+	return fileType, mime, bufReader, nil
+}
+
+var executableMagics = []struct {
+	magic []byte
+	mime  string
+}{
+	{[]byte("\x7FELF"), "application/x-executable"},
+	{[]byte("\xFE\xED\xFA\xCE"), "application/x-mach-binary"},
+	{[]byte("\xFE\xED\xFA\xCF"), "application/x-mach-binary"},
+	{[]byte("\xCE\xFA\xED\xFE"), "application/x-mach-binary"},
+	{[]byte("\xCF\xFA\xED\xFE"), "application/x-mach-binary"},
+	{[]byte("#!"), "text/x-shellscript"},
+}
+
+// detectMimeType http.DetectContentTypeの判定に加え、svg・avif・flac・ID3タグのないmp3と実行ファイルを判定する。
+// パラメーターは取り除き、同じ種類を表すMIMEタイプは1つにまとめて返す。
+func detectMimeType(head []byte) string {
+	// avifはftypボックスを持つのでvideo/mp4と判定されうる
+	if isAvifHead(head) {
+		return "image/avif"
+	}
+
+	mime := http.DetectContentType(head)
+	mime = strings.TrimSpace(strings.SplitN(mime, ";", 2)[0])
+
+	switch mime {
+	case "image/jpeg", "image/png", "image/webp", "image/gif",
+		"audio/mpeg", "audio/wav", "video/mp4", "video/webm",
+		"application/pdf", "application/zip":
+		return mime
+	case "application/ogg":
+		return "audio/ogg"
+	case "audio/wave":
+		return "audio/wav"
+	}
+
+	if isSvgHead(head) {
+		return "image/svg+xml"
+	}
+
+	if bytes.HasPrefix(head, []byte("fLaC")) {
+		return "audio/flac"
+	}
+
+	if isMp3FrameHead(head) {
+		return "audio/mpeg"
+	}
+
+	if isPortableExecutable(head) {
+		return "application/x-msdownload"
+	}
+
+	for _, executableMagic := range executableMagics {
+		if bytes.HasPrefix(head, executableMagic.magic) {
+			return executableMagic.mime
 		}
 	}
 
-	file := domain.NewFile(
-		values.NewFileID(),
-		fileType,
-		time.Now(),
-	)
+	return mime
+}
+
+// isAvifHead ftypボックスのmajor brandかcompatible brandにavifかavisが含まれるか
+func isAvifHead(head []byte) bool {
+	if len(head) < 16 || !bytes.Equal(head[4:8], []byte("ftyp")) {
+		return false
+	}
+
+	boxSize := int(binary.BigEndian.Uint32(head[:4]))
+	if boxSize < 16 || boxSize > len(head) {
+		boxSize = len(head)
+	}
+
+	// major brand、minor version、compatible brandsの順に並んでいる
+	brands := [][]byte{head[8:12]}
+	for i := 16; i+4 <= boxSize; i += 4 {
+		brands = append(brands, head[i:i+4])
+	}
+
+	for _, brand := range brands {
+		if bytes.Equal(brand, []byte("avif")) || bytes.Equal(brand, []byte("avis")) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isMp3FrameHead ID3タグのないmp3はMPEG Audio Layer IIIのフレームヘッダーで始まる
+func isMp3FrameHead(head []byte) bool {
+	if len(head) < 4 {
+		return false
+	}
+
+	// 11bitの同期ワード、予約値でないバージョン、Layer III、予約値でないビットレート・サンプリング周波数
+	return head[0] == 0xFF &&
+		head[1]&0xE0 == 0xE0 &&
+		(head[1]>>3)&0x03 != 0x01 &&
+		(head[1]>>1)&0x03 == 0x01 &&
+		head[2]>>4 != 0x0F &&
+		(head[2]>>2)&0x03 != 0x03
+}
+
+// isPortableExecutable MZで始まるテキストを誤判定しないよう、PEヘッダーの位置まで確認する
+func isPortableExecutable(head []byte) bool {
+	if len(head) < 0x40 || !bytes.HasPrefix(head, []byte("MZ")) {
+		return false
+	}
+
+	peOffset := int64(binary.LittleEndian.Uint32(head[0x3C:0x40]))
+	if peOffset+4 > int64(len(head)) {
+		return false
+	}
+
+	return bytes.Equal(head[peOffset:peOffset+4], []byte("PE\x00\x00"))
+}
+
+// isSvgHead ファイル全体を読まずに済むよう、先頭部分がsvgの開始タグで始まっているかで判定する
+func isSvgHead(head []byte) bool {
+	if !isText(head) {
+		return false
+	}
+
+	return svgHeadRegex.Match(htmlCommentRegex.ReplaceAll(head, []byte{}))
+}
+
+func isText(head []byte) bool {
+	for len(head) > 0 {
+		r, size := utf8.DecodeRune(head)
+		if r == utf8.RuneError && size == 1 {
+			// 先頭部分のみを読んでいるので、末尾で途切れたマルチバイト文字は許容する
+			return !utf8.FullRune(head)
+		}
+		if r < '\t' {
+			return false
+		}
+
+		head = head[size:]
+	}
+
+	return true
+}
+
+func (f *File) Download(ctx context.Context, fileID values.FileID) (*domain.File, io.ReadSeekCloser, error) {
+	file, err := f.fileRepository.GetFile(ctx, fileID, repository.LockTypeNone)
+	if errors.Is(err, repository.ErrRecordNotFound) {
+		return nil, nil, service.ErrNoFile
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get resource: %w", err)
+	}
+
+	if file.IsInfected() {
+		return nil, nil, service.ErrFileInfected
+	}
+
+	reader, err := f.fileStorage.OpenFile(ctx, file.File)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open file: %w", err)
+	}
+
+	if file.GetType() == values.FileTypeSvg {
+		// 無害化する前に保存されたSVGもあるので、配信時にも無害化する
+		reader, err = sanitizeStoredSVG(reader)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to sanitize svg: %w", err)
+		}
+	}
+
+	return file.File, reader, nil
+}
+
+// sanitizedSVG Rangeリクエストに対応するため、無害化したSVG全体をメモリ上に保持する
+type sanitizedSVG struct {
+	*bytes.Reader
+}
+
+func (sanitizedSVG) Close() error {
+	return nil
+}
+
+// sanitizeStoredSVG readerはCloseされる
+func sanitizeStoredSVG(reader io.ReadCloser) (io.ReadSeekCloser, error) {
+	defer reader.Close()
+
+	svg, err := io.ReadAll(svgsanitize.NewReader(reader))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read svg: %w", err)
+	}
+
+	return sanitizedSVG{
+		Reader: bytes.NewReader(svg),
+	}, nil
+}
+
+func (f *File) DownloadRendition(ctx context.Context, fileID values.FileID, size values.RenditionSize) (*domain.File, io.ReadSeekCloser, error) {
+	rendition, err := f.renditionRepository.GetRendition(ctx, fileID, size, repository.LockTypeNone)
+	if errors.Is(err, repository.ErrRecordNotFound) {
+		// 元の画像が十分小さい場合や、レンディションに対応しない種類のファイルの場合
+		return f.Download(ctx, fileID)
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get rendition: %w", err)
+	}
+
+	reader, err := f.fileStorage.OpenFile(ctx, rendition)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open file: %w", err)
+	}
+
+	return rendition, reader, nil
+}
+
+func (f *File) DeleteFile(ctx context.Context, session *domain.OIDCSession, fileID values.FileID) error {
+	user, err := f.userUtils.getMe(ctx, session)
+	if err != nil {
+		return fmt.Errorf("failed to get user: %w", err)
+	}
 
 	err = f.dbRepository.Transaction(ctx, nil, func(ctx context.Context) error {
-		err := f.fileRepository.SaveFile(ctx, user, file)
+		fileInfo, err := f.fileRepository.GetFile(ctx, fileID, repository.LockTypeRecord)
+		if errors.Is(err, repository.ErrRecordNotFound) {
+			return service.ErrNoFile
+		}
 		if err != nil {
-			return fmt.Errorf("failed to save file: %w", err)
+			return fmt.Errorf("failed to get file: %w", err)
+		}
+
+		if fileInfo.Creator != user.GetID() && !f.userUtils.isAdministrator(user) {
+			return service.ErrForbidden
 		}
 
-		err = f.fileStorage.SaveFile(ctx, file, buf)
+		resourceIDs, err := f.resourceRepository.GetResourceIDsByFileID(ctx, fileID)
 		if err != nil {
-			return fmt.Errorf("failed to save file: %w", err)
+			return fmt.Errorf("failed to get resources: %w", err)
+		}
+
+		// 復元時に同時に削除されたリソースを判別できるよう、同じ日時で削除する
+		deletedAt := time.Now()
+
+		err = f.fileRepository.DeleteFile(ctx, fileID, deletedAt)
+		if err != nil {
+			return fmt.Errorf("failed to delete file: %w", err)
+		}
+
+		for _, resourceID := range resourceIDs {
+			err = f.resourceRepository.DeleteResource(ctx, resourceID, deletedAt)
+			if err != nil {
+				return fmt.Errorf("failed to delete resource: %w", err)
+			}
+
+			err = replaceMainResource(ctx, f.resourceRepository, f.groupRepository, resourceID, deletedAt)
+			if err != nil {
+				return fmt.Errorf("failed to replace main resource: %w", err)
+			}
 		}
 
 		return nil
 	})
 	if err != nil {
-		return nil, fmt.Errorf("failed in transaction: %w", err)
+		return fmt.Errorf("failed in transaction: %w", err)
 	}
 
-	return &service.FileInfo{
-		File:    file,
-		Creator: user,
-	}, nil
+	return nil
 }
 
-func (f *File) Download(ctx context.Context, fileID values.FileID, writer io.Writer) (*domain.File, error) {
-	file, err := f.fileRepository.GetFile(ctx, fileID, repository.LockTypeNone)
-	if errors.Is(err, repository.ErrRecordNotFound) {
-		return nil, service.ErrNoFile
-	}
+func (f *File) RestoreFile(ctx context.Context, session *domain.OIDCSession, fileID values.FileID) error {
+	user, err := f.userUtils.getMe(ctx, session)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get resource: %w", err)
+		return fmt.Errorf("failed to get user: %w", err)
 	}
 
-	err = f.fileStorage.GetFile(ctx, file.File, writer)
+	err = f.dbRepository.Transaction(ctx, nil, func(ctx context.Context) error {
+		fileInfo, err := f.fileRepository.GetDeletedFile(ctx, fileID, repository.LockTypeRecord)
+		if errors.Is(err, repository.ErrRecordNotFound) {
+			return service.ErrNoFile
+		}
+		if err != nil {
+			return fmt.Errorf("failed to get deleted file: %w", err)
+		}
+
+		if fileInfo.Creator != user.GetID() && !f.userUtils.isAdministrator(user) {
+			return service.ErrForbidden
+		}
+
+		if time.Since(fileInfo.DeletedAt) > restorePeriod {
+			return service.ErrRestorePeriodExpired
+		}
+
+		err = f.fileRepository.RestoreFile(ctx, fileID)
+		if err != nil {
+			return fmt.Errorf("failed to restore file: %w", err)
+		}
+
+		err = f.resourceRepository.RestoreResourcesByFileID(ctx, fileID, fileInfo.DeletedAt)
+		if err != nil {
+			return fmt.Errorf("failed to restore resources: %w", err)
+		}
+
+		// リソースと同時に削除されたグループも復元する
+		resourceIDs, err := f.resourceRepository.GetResourceIDsByFileID(ctx, fileID)
+		if err != nil {
+			return fmt.Errorf("failed to get resources: %w", err)
+		}
+
+		for _, resourceID := range resourceIDs {
+			err = f.groupRepository.RestoreGroupsByMainResource(ctx, resourceID, fileInfo.DeletedAt)
+			if err != nil {
+				return fmt.Errorf("failed to restore groups: %w", err)
+			}
+		}
+
+		return nil
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to get file: %w", err)
+		return fmt.Errorf("failed in transaction: %w", err)
 	}
 
-	return file.File, nil
+	return nil
+}
+
+func (f *File) GetMaxUploadSize() int64 {
+	return f.uploadPolicy.getMaxUploadSize()
 }
@@ -0,0 +1,53 @@
+package v1
+
+import (
+	"context"
+	"io"
+
+	"github.com/mazrean/Quantainer/domain"
+	"github.com/mazrean/Quantainer/scanner"
+)
+
+// uploadScanner ストレージへの保存と並行して、読み出されたファイルの内容をマルウェアの検査に渡す
+type uploadScanner struct {
+	pipeWriter *io.PipeWriter
+	result     chan *uploadScanResult
+}
+
+type uploadScanResult struct {
+	scan *domain.FileScan
+	err  error
+}
+
+// newUploadScanner 返り値のio.Readerから読み出した内容が検査される
+func newUploadScanner(ctx context.Context, fileScanner scanner.File, reader io.Reader) (*uploadScanner, io.Reader) {
+	pr, pw := io.Pipe()
+	us := &uploadScanner{
+		pipeWriter: pw,
+		result:     make(chan *uploadScanResult, 1),
+	}
+
+	go func() {
+		scan, err := fileScanner.Scan(ctx, pr)
+
+		// 検査が途中で終わった場合も読み捨てないと、保存側の読み込みが止まる
+		_, _ = io.Copy(io.Discard, pr)
+
+		us.result <- &uploadScanResult{
+			scan: scan,
+			err:  err,
+		}
+	}()
+
+	return us, io.TeeReader(reader, pw)
+}
+
+// wait 読み込みの終了を伝え、検査結果を待つ。
+// readErrには読み込み側で発生したエラーを渡す。
+func (us *uploadScanner) wait(readErr error) (*domain.FileScan, error) {
+	us.pipeWriter.CloseWithError(readErr)
+
+	result := <-us.result
+
+	return result.scan, result.err
+}
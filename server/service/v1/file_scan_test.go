@@ -0,0 +1,250 @@
+package v1
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	mockAuth "github.com/mazrean/Quantainer/auth/mock"
+	mockCache "github.com/mazrean/Quantainer/cache/mock"
+	"github.com/mazrean/Quantainer/domain"
+	"github.com/mazrean/Quantainer/domain/values"
+	"github.com/mazrean/Quantainer/pkg/common"
+	"github.com/mazrean/Quantainer/repository"
+	mockRepository "github.com/mazrean/Quantainer/repository/mock"
+	"github.com/mazrean/Quantainer/scanner"
+	"github.com/mazrean/Quantainer/scanner/noop"
+	"github.com/mazrean/Quantainer/service"
+	"github.com/mazrean/Quantainer/storage/local"
+	"github.com/stretchr/testify/assert"
+)
+
+const eicarSignature = "Eicar-Test-Signature"
+
+// eicarScanner 内容を最後まで読み、EICARを含む場合は感染しているとする
+type eicarScanner struct{}
+
+func (eicarScanner) Scan(_ context.Context, reader io.Reader) (*domain.FileScan, error) {
+	content, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	if bytes.Contains(content, []byte("EICAR")) {
+		return domain.NewFileScan(values.ScanStatusInfected, values.NewScanSignature(eicarSignature), time.Now()), nil
+	}
+
+	return domain.NewFileScan(values.ScanStatusClean, "", time.Now()), nil
+}
+
+var errScanFailed = errors.New("scan failed")
+
+// failingScanner 内容を読まずに失敗する
+type failingScanner struct{}
+
+func (failingScanner) Scan(context.Context, io.Reader) (*domain.FileScan, error) {
+	return nil, errScanFailed
+}
+
+func TestUploadScanner(t *testing.T) {
+	t.Parallel()
+
+	errRead := errors.New("read error")
+
+	type test struct {
+		description string
+		scanner     scanner.File
+		content     string
+		readErr     error
+		isScanned   bool
+		status      values.ScanStatus
+		err         error
+	}
+
+	testCases := []test{
+		{
+			description: "感染していない",
+			scanner:     eicarScanner{},
+			content:     "hello",
+			isScanned:   true,
+			status:      values.ScanStatusClean,
+		},
+		{
+			description: "感染している",
+			scanner:     eicarScanner{},
+			content:     "hello EICAR",
+			isScanned:   true,
+			status:      values.ScanStatusInfected,
+		},
+		{
+			description: "検査しない場合は内容を読まなくても保存が止まらない",
+			scanner:     noop.NewFile(),
+			content:     strings.Repeat("a", 1<<20),
+		},
+		{
+			description: "検査に失敗しても保存が止まらない",
+			scanner:     failingScanner{},
+			content:     strings.Repeat("a", 1<<20),
+			err:         errScanFailed,
+		},
+		{
+			description: "読み込みに失敗した場合は検査も失敗する",
+			scanner:     eicarScanner{},
+			content:     "hello",
+			readErr:     errRead,
+			err:         errRead,
+		},
+	}
+
+	for _, testCase := range testCases {
+		testCase := testCase
+		t.Run(testCase.description, func(t *testing.T) {
+			t.Parallel()
+
+			s, reader := newUploadScanner(context.Background(), testCase.scanner, strings.NewReader(testCase.content))
+
+			content, err := io.ReadAll(reader)
+			if err != nil {
+				t.Fatalf("failed to read: %v", err)
+			}
+			assert.Equal(t, testCase.content, string(content))
+
+			scan, err := s.wait(testCase.readErr)
+			if testCase.err != nil {
+				assert.ErrorIs(t, err, testCase.err)
+				return
+			}
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			if !testCase.isScanned {
+				assert.Nil(t, scan)
+				return
+			}
+			if !assert.NotNil(t, scan) {
+				return
+			}
+			assert.Equal(t, testCase.status, scan.GetStatus())
+		})
+	}
+}
+
+func TestUploadInfectedFile(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	rootPath := "./file_scan_test"
+	defer func() {
+		err := os.RemoveAll(rootPath)
+		if err != nil {
+			t.Fatalf("failed to remove directory: %v", err)
+		}
+	}()
+
+	fileStorage, err := local.NewFile(local.NewDirectoryManager(common.FilePath(rootPath)))
+	if err != nil {
+		t.Fatalf("failed to create storage: %v", err)
+	}
+
+	mockUserCache := mockCache.NewMockUser(ctrl)
+	mockUserAuth := mockAuth.NewMockUser(ctrl)
+	mockDBRepository := mockRepository.NewMockDB(ctrl)
+	mockFileRepository := mockRepository.NewMockFile(ctrl)
+	mockRenditionRepository := mockRepository.NewMockRendition(ctrl)
+	mockResourceRepository := mockRepository.NewMockResource(ctrl)
+	mockGroupRepository := mockRepository.NewMockGroup(ctrl)
+	mockQuotaRepository := mockRepository.NewMockQuota(ctrl)
+
+	userUtils := NewUserUtils(mockUserAuth, mockUserCache, common.Administrators{})
+	quotaUtils := NewQuotaUtils(mockQuotaRepository, mockFileRepository, 0, 0, 0, 0)
+	uploadPolicy, err := NewUploadPolicy(0, nil, nil, nil, true, true)
+	if err != nil {
+		t.Fatalf("failed to create upload policy: %v", err)
+	}
+
+	fileService := NewFile(
+		mockDBRepository,
+		mockFileRepository,
+		mockRenditionRepository,
+		mockResourceRepository,
+		mockGroupRepository,
+		fileStorage,
+		eicarScanner{},
+		userUtils,
+		quotaUtils,
+		uploadPolicy,
+	)
+
+	session := domain.NewOIDCSession(
+		values.NewOIDCAccessToken("access token"),
+		time.Now().Add(time.Hour),
+	)
+	user := service.NewUserInfo(
+		values.NewTrapMemberID(uuid.New()),
+		values.NewTrapMemberName("mazrean"),
+		values.TrapMemberStatusActive,
+	)
+
+	mockUserCache.
+		EXPECT().
+		GetMe(gomock.Any(), gomock.Any()).
+		Return(user, nil).
+		AnyTimes()
+	mockQuotaRepository.
+		EXPECT().
+		GetUserQuota(gomock.Any(), user.GetID()).
+		Return(nil, repository.ErrRecordNotFound).
+		AnyTimes()
+	mockDBRepository.
+		EXPECT().
+		Transaction(gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, _ interface{}, fn func(ctx context.Context) error) error {
+			return fn(ctx)
+		}).
+		AnyTimes()
+
+	var savedFile *domain.File
+	mockFileRepository.
+		EXPECT().
+		SaveFile(gomock.Any(), user, gomock.Any()).
+		DoAndReturn(func(_ context.Context, _ *service.UserInfo, file *domain.File) error {
+			savedFile = file
+			return nil
+		})
+
+	fileInfo, err := fileService.Upload(ctx, session, values.NewFileName("eicar.com"), strings.NewReader("X5O!P%@AP[4\\PZX54(P^)7CC)7}$EICAR-STANDARD-ANTIVIRUS-TEST-FILE!$H+H*"))
+	if err != nil {
+		t.Fatalf("failed to upload: %v", err)
+	}
+
+	// 感染していても、検査の結果を示せるよう隔離した状態で保存する
+	if !assert.NotNil(t, savedFile) || !assert.NotNil(t, savedFile.GetScan()) {
+		return
+	}
+	assert.True(t, savedFile.IsInfected())
+	assert.Equal(t, values.NewScanSignature(eicarSignature), savedFile.GetScan().GetSignature())
+	assert.True(t, fileInfo.File.IsInfected())
+
+	mockFileRepository.
+		EXPECT().
+		GetFile(gomock.Any(), savedFile.GetID(), repository.LockTypeNone).
+		Return(&repository.FileWithCreator{
+			File:    savedFile,
+			Creator: user.GetID(),
+		}, nil)
+
+	_, _, err = fileService.Download(ctx, savedFile.GetID())
+	assert.ErrorIs(t, err, service.ErrFileInfected)
+}
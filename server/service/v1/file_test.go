@@ -0,0 +1,251 @@
+package v1
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/mazrean/Quantainer/domain/values"
+	"github.com/mazrean/Quantainer/pkg/svgsanitize"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDetectFileType(t *testing.T) {
+	t.Parallel()
+
+	type test struct {
+		description string
+		content     []byte
+		fileType    values.FileType
+		mime        string
+	}
+
+	testCases := []test{
+		{
+			description: "jpegなのでFileTypeJpeg",
+			content:     append([]byte("\xFF\xD8\xFF"), bytes.Repeat([]byte{0}, 10)...),
+			fileType:    values.FileTypeJpeg,
+		},
+		{
+			description: "pngなのでFileTypePng",
+			content:     append([]byte("\x89PNG\x0D\x0A\x1A\x0A"), bytes.Repeat([]byte{0}, 10)...),
+			fileType:    values.FileTypePng,
+		},
+		{
+			description: "gifなのでFileTypeGif",
+			content:     []byte("GIF89a"),
+			fileType:    values.FileTypeGif,
+		},
+		{
+			description: "webpなのでFileTypeWebP",
+			content:     []byte("RIFF\x00\x00\x00\x00WEBPVP"),
+			fileType:    values.FileTypeWebP,
+		},
+		{
+			description: "svgなのでFileTypeSvg",
+			content:     []byte(`<?xml version="1.0"?><svg xmlns="http://www.w3.org/2000/svg"></svg>`),
+			fileType:    values.FileTypeSvg,
+			mime:        "image/svg+xml",
+		},
+		{
+			description: "コメントが先頭にあってもsvgなのでFileTypeSvg",
+			content:     []byte("<!-- comment -->\n<svg width=\"10\"></svg>"),
+			fileType:    values.FileTypeSvg,
+		},
+		{
+			description: "判定に使う長さより大きいsvgでもFileTypeSvg",
+			content:     []byte("<svg>" + strings.Repeat("<g></g>", sniffLength) + "</svg>"),
+			fileType:    values.FileTypeSvg,
+		},
+		{
+			description: "svg以外のxmlなのでFileTypeOther",
+			content:     []byte(`<?xml version="1.0"?><html></html>`),
+			fileType:    values.FileTypeOther,
+		},
+		{
+			description: "バイナリなのでFileTypeOther",
+			content:     []byte("\x00\x01\x02<svg>"),
+			fileType:    values.FileTypeOther,
+		},
+		{
+			description: "ID3タグのあるmp3なのでFileTypeMp3",
+			content:     []byte("ID3\x04\x00\x00\x00\x00\x00\x00"),
+			fileType:    values.FileTypeMp3,
+			mime:        "audio/mpeg",
+		},
+		{
+			description: "ID3タグのないmp3なのでFileTypeMp3",
+			content:     append([]byte("\xFF\xFB\x90\x64"), bytes.Repeat([]byte{0}, 10)...),
+			fileType:    values.FileTypeMp3,
+			mime:        "audio/mpeg",
+		},
+		{
+			description: "oggなのでFileTypeOgg",
+			content:     append([]byte("OggS\x00\x02"), bytes.Repeat([]byte{0}, 10)...),
+			fileType:    values.FileTypeOgg,
+			mime:        "audio/ogg",
+		},
+		{
+			description: "wavなのでFileTypeWav",
+			content:     []byte("RIFF\x24\x00\x00\x00WAVEfmt "),
+			fileType:    values.FileTypeWav,
+			mime:        "audio/wav",
+		},
+		{
+			description: "flacなのでFileTypeFlac",
+			content:     []byte("fLaC\x00\x00\x00\x22"),
+			fileType:    values.FileTypeFlac,
+			mime:        "audio/flac",
+		},
+		{
+			description: "mp4なのでFileTypeMp4",
+			content:     []byte("\x00\x00\x00\x18ftypmp42\x00\x00\x00\x00mp42isom"),
+			fileType:    values.FileTypeMp4,
+			mime:        "video/mp4",
+		},
+		{
+			description: "webmなのでFileTypeWebM",
+			content:     []byte("\x1A\x45\xDF\xA3\x9F\x42\x86\x81\x01"),
+			fileType:    values.FileTypeWebM,
+			mime:        "video/webm",
+		},
+		{
+			description: "pdfなのでFileTypePdf",
+			content:     []byte("%PDF-1.7\n"),
+			fileType:    values.FileTypePdf,
+			mime:        "application/pdf",
+		},
+		{
+			description: "zipなのでFileTypeZip",
+			content:     append([]byte("PK\x03\x04"), bytes.Repeat([]byte{0}, 26)...),
+			fileType:    values.FileTypeZip,
+			mime:        "application/zip",
+		},
+		{
+			description: "avifなのでFileTypeAvif",
+			content:     []byte("\x00\x00\x00\x1CftypavifO\x00\x00\x00avifmif1miaf"),
+			fileType:    values.FileTypeAvif,
+			mime:        "image/avif",
+		},
+		{
+			description: "compatible brandにavifを含むのでFileTypeAvif",
+			content:     []byte("\x00\x00\x00\x1Cftypmif1\x00\x00\x00\x00mif1avifmiaf"),
+			fileType:    values.FileTypeAvif,
+			mime:        "image/avif",
+		},
+		{
+			description: "ELFなので実行ファイル",
+			content:     append([]byte("\x7FELF\x02\x01\x01"), bytes.Repeat([]byte{0}, 10)...),
+			fileType:    values.FileTypeOther,
+			mime:        "application/x-executable",
+		},
+		{
+			description: "PEなので実行ファイル",
+			content:     append(append([]byte("MZ"), bytes.Repeat([]byte{0}, 0x3A)...), []byte("\x40\x00\x00\x00PE\x00\x00")...),
+			fileType:    values.FileTypeOther,
+			mime:        "application/x-msdownload",
+		},
+		{
+			description: "MZで始まるだけのテキストは実行ファイルでない",
+			content:     []byte("MZ is not an executable"),
+			fileType:    values.FileTypeOther,
+			mime:        "text/plain",
+		},
+		{
+			description: "シバンで始まるのでシェルスクリプト",
+			content:     []byte("#!/bin/sh\necho hello\n"),
+			fileType:    values.FileTypeOther,
+			mime:        "text/x-shellscript",
+		},
+		{
+			description: "空ファイルなのでFileTypeOther",
+			content:     []byte{},
+			fileType:    values.FileTypeOther,
+		},
+		{
+			description: "判定に使う長さより大きくてもFileTypeOther",
+			content:     bytes.Repeat([]byte("a"), sniffLength*3),
+			fileType:    values.FileTypeOther,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.description, func(t *testing.T) {
+			fileType, mime, reader, err := detectFileType(bytes.NewReader(testCase.content))
+			assert.NoError(t, err)
+			if err != nil {
+				return
+			}
+
+			assert.Equal(t, testCase.fileType, fileType)
+			if len(testCase.mime) != 0 {
+				assert.Equal(t, testCase.mime, mime)
+			}
+
+			actualContent, err := io.ReadAll(reader)
+			if err != nil {
+				t.Fatalf("failed to read content: %v", err)
+			}
+
+			assert.Equal(t, testCase.content, actualContent)
+		})
+	}
+}
+
+func TestSanitizeStoredSVG(t *testing.T) {
+	t.Parallel()
+
+	type test struct {
+		description string
+		content     string
+		expected    string
+		isErr       bool
+		err         error
+	}
+
+	testCases := []test{
+		{
+			description: "スクリプトを取り除いてシークできる",
+			content:     `<svg onload="alert(1)"><script>alert(2)</script><g/></svg>`,
+			expected:    `<svg><g></g></svg>`,
+		},
+		{
+			description: "SVGとして不正なのでErrInvalidSVG",
+			content:     `<svg><g></svg>`,
+			isErr:       true,
+			err:         svgsanitize.ErrInvalidSVG,
+		},
+	}
+
+	for _, testCase := range testCases {
+		testCase := testCase
+
+		t.Run(testCase.description, func(t *testing.T) {
+			t.Parallel()
+
+			reader, err := sanitizeStoredSVG(io.NopCloser(strings.NewReader(testCase.content)))
+
+			if testCase.isErr {
+				assert.ErrorIs(t, err, testCase.err)
+				return
+			}
+			if !assert.NoError(t, err) {
+				return
+			}
+			defer reader.Close()
+
+			_, err = reader.Seek(int64(len("<svg>")), io.SeekStart)
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			actual, err := io.ReadAll(reader)
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			assert.Equal(t, testCase.expected[len("<svg>"):], string(actual))
+		})
+	}
+}
@@ -0,0 +1,247 @@
+package v1
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mazrean/Quantainer/domain"
+	"github.com/mazrean/Quantainer/domain/values"
+	"github.com/mazrean/Quantainer/repository"
+	"github.com/mazrean/Quantainer/service"
+	"github.com/mazrean/Quantainer/storage"
+)
+
+// restorePeriod 削除されたファイル・リソースを復元できる期間。
+// 参照されていないファイルも、アップロードからこの期間はリソースの作成を待つ。
+const restorePeriod = 7 * 24 * time.Hour
+
+// unreferencedObjectGracePeriod 参照がなくなった実体を削除するまでの猶予。
+// 他のプロセスで保存中の参照が一覧に反映されるのを待つ。
+const unreferencedObjectGracePeriod = time.Hour
+
+type GarbageCollector struct {
+	dbRepository        repository.DB
+	fileRepository      repository.File
+	renditionRepository repository.Rendition
+	resourceRepository  repository.Resource
+	groupRepository     repository.Group
+	uploadRepository    repository.Upload
+	fileStorage         storage.File
+	uploadStorage       storage.Upload
+}
+
+func NewGarbageCollector(
+	dbRepository repository.DB,
+	fileRepository repository.File,
+	renditionRepository repository.Rendition,
+	resourceRepository repository.Resource,
+	groupRepository repository.Group,
+	uploadRepository repository.Upload,
+	fileStorage storage.File,
+	uploadStorage storage.Upload,
+) *GarbageCollector {
+	return &GarbageCollector{
+		dbRepository:        dbRepository,
+		fileRepository:      fileRepository,
+		renditionRepository: renditionRepository,
+		resourceRepository:  resourceRepository,
+		groupRepository:     groupRepository,
+		uploadRepository:    uploadRepository,
+		fileStorage:         fileStorage,
+		uploadStorage:       uploadStorage,
+	}
+}
+
+func (gc *GarbageCollector) CollectGarbage(ctx context.Context) (*service.GarbageCollectionResult, error) {
+	before := time.Now().Add(-restorePeriod)
+	result := &service.GarbageCollectionResult{}
+
+	// リソースが完全に削除されるとファイルが参照されなくなるので、リソースを先に削除する
+	resourceIDs, err := gc.resourceRepository.GetDeletedResourceIDs(ctx, before)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get deleted resources: %w", err)
+	}
+
+	for _, resourceID := range resourceIDs {
+		err := ctx.Err()
+		if err != nil {
+			return nil, fmt.Errorf("garbage collection canceled: %w", err)
+		}
+
+		purgedGroups, err := gc.purgeResource(ctx, resourceID)
+		if err != nil {
+			log.Printf("error: failed to purge resource(%s): %v\n", uuid.UUID(resourceID).String(), err)
+			result.Failed++
+			continue
+		}
+
+		result.PurgedResources++
+		result.PurgedGroups += purgedGroups
+	}
+
+	files, err := gc.fileRepository.GetPurgeableFiles(ctx, before)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get purgeable files: %w", err)
+	}
+
+	for _, file := range files {
+		err := ctx.Err()
+		if err != nil {
+			return nil, fmt.Errorf("garbage collection canceled: %w", err)
+		}
+
+		err = gc.purgeFile(ctx, file)
+		if err != nil {
+			log.Printf("error: failed to purge file(%s): %v\n", uuid.UUID(file.GetID()).String(), err)
+			result.Failed++
+			continue
+		}
+
+		result.PurgedFiles++
+	}
+
+	uploads, err := gc.uploadRepository.GetExpiredUploads(ctx, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get expired uploads: %w", err)
+	}
+
+	for _, upload := range uploads {
+		err := ctx.Err()
+		if err != nil {
+			return nil, fmt.Errorf("garbage collection canceled: %w", err)
+		}
+
+		err = gc.discardUpload(ctx, upload)
+		if err != nil {
+			log.Printf("error: failed to discard upload(%s): %v\n", uuid.UUID(upload.GetID()).String(), err)
+			result.Failed++
+			continue
+		}
+
+		result.DiscardedUploads++
+	}
+
+	// 完全に削除したファイルの実体は、参照がなくなってから猶予を置いて削除する
+	deletedObjects, err := gc.fileStorage.DeleteUnreferencedObjects(ctx, time.Now().Add(-unreferencedObjectGracePeriod))
+	if err != nil {
+		log.Printf("error: failed to delete unreferenced objects: %v\n", err)
+		result.Failed++
+	}
+	result.DeletedObjects = deletedObjects
+
+	return result, nil
+}
+
+// purgeResource リソースをグループから取り除いてから完全に削除し、完全に削除したグループの数を返す
+func (gc *GarbageCollector) purgeResource(ctx context.Context, resourceID values.ResourceID) (int, error) {
+	var purgedGroups int
+	err := gc.dbRepository.Transaction(ctx, nil, func(ctx context.Context) error {
+		groups, err := gc.groupRepository.GetGroupsByResource(ctx, resourceID)
+		if err != nil {
+			return fmt.Errorf("failed to get groups: %w", err)
+		}
+
+		for _, group := range groups {
+			err = gc.groupRepository.DeleteResources(ctx, group, []values.ResourceID{resourceID})
+			if err != nil {
+				return fmt.Errorf("failed to delete resource from group: %w", err)
+			}
+		}
+
+		// 削除時に差し替えているので、通常はメインのリソースとしているグループは残っていない
+		err = replaceMainResource(ctx, gc.resourceRepository, gc.groupRepository, resourceID, time.Now())
+		if err != nil {
+			return fmt.Errorf("failed to replace main resource: %w", err)
+		}
+
+		// 論理削除されたグループもメインのリソースとして参照しているので、先に完全に削除する
+		groupIDs, err := gc.groupRepository.GetDeletedGroupIDsByMainResource(ctx, resourceID)
+		if err != nil {
+			return fmt.Errorf("failed to get deleted groups: %w", err)
+		}
+
+		for _, groupID := range groupIDs {
+			err = gc.groupRepository.PurgeGroup(ctx, groupID)
+			if err != nil {
+				return fmt.Errorf("failed to purge group: %w", err)
+			}
+		}
+		purgedGroups = len(groupIDs)
+
+		err = gc.resourceRepository.PurgeResource(ctx, resourceID)
+		if err != nil {
+			return fmt.Errorf("failed to purge resource: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed in transaction: %w", err)
+	}
+
+	return purgedGroups, nil
+}
+
+// purgeFile ファイルをレンディションと共にDBから完全に削除した後、ストレージから削除する。
+// ストレージからの削除に失敗した場合に残ったファイルは、scrubberで孤立したファイルとして検出できる。
+func (gc *GarbageCollector) purgeFile(ctx context.Context, file *domain.File) error {
+	var files []*domain.File
+	err := gc.dbRepository.Transaction(ctx, nil, func(ctx context.Context) error {
+		renditions, err := gc.renditionRepository.GetRenditions(ctx, file.GetID())
+		if err != nil {
+			return fmt.Errorf("failed to get renditions: %w", err)
+		}
+
+		err = gc.renditionRepository.DeleteRenditions(ctx, file.GetID())
+		if err != nil {
+			return fmt.Errorf("failed to delete renditions: %w", err)
+		}
+
+		for _, rendition := range renditions {
+			err = gc.fileRepository.PurgeFile(ctx, rendition.GetID())
+			if err != nil {
+				return fmt.Errorf("failed to purge rendition file: %w", err)
+			}
+		}
+
+		err = gc.fileRepository.PurgeFile(ctx, file.GetID())
+		if err != nil {
+			return fmt.Errorf("failed to purge file: %w", err)
+		}
+
+		files = append(renditions, file)
+
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed in transaction: %w", err)
+	}
+
+	for _, file := range files {
+		err = gc.fileStorage.DeleteFile(ctx, file)
+		if err != nil && !errors.Is(err, storage.ErrNotFound) {
+			log.Printf("error: failed to delete file(%s) from storage: %v\n", uuid.UUID(file.GetID()).String(), err)
+		}
+	}
+
+	return nil
+}
+
+// discardUpload 期限切れのアップロードをDBから削除した後、途中の内容を削除する
+func (gc *GarbageCollector) discardUpload(ctx context.Context, upload *domain.Upload) error {
+	err := gc.uploadRepository.DeleteUpload(ctx, upload.GetID())
+	if err != nil && !errors.Is(err, repository.ErrNoRecordDeleted) {
+		return fmt.Errorf("failed to delete upload: %w", err)
+	}
+
+	err = gc.uploadStorage.DeleteUpload(ctx, upload.GetID())
+	if err != nil && !errors.Is(err, storage.ErrNotFound) {
+		return fmt.Errorf("failed to delete upload from storage: %w", err)
+	}
+
+	return nil
+}
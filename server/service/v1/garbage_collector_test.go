@@ -0,0 +1,257 @@
+package v1
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/mazrean/Quantainer/domain"
+	"github.com/mazrean/Quantainer/domain/values"
+	"github.com/mazrean/Quantainer/pkg/common"
+	mockRepository "github.com/mazrean/Quantainer/repository/mock"
+	"github.com/mazrean/Quantainer/storage"
+	"github.com/mazrean/Quantainer/storage/local"
+)
+
+func TestCollectGarbage(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	rootPath := "./garbage_collector_test"
+	defer func() {
+		err := os.RemoveAll(rootPath)
+		if err != nil {
+			t.Fatalf("failed to remove directory: %v", err)
+		}
+	}()
+
+	fileStorage, err := local.NewFile(local.NewDirectoryManager(common.FilePath(rootPath)))
+	if err != nil {
+		t.Fatalf("failed to create storage: %v", err)
+	}
+
+	mockDBRepository := mockRepository.NewMockDB(ctrl)
+	mockFileRepository := mockRepository.NewMockFile(ctrl)
+	mockRenditionRepository := mockRepository.NewMockRendition(ctrl)
+	mockResourceRepository := mockRepository.NewMockResource(ctrl)
+	mockGroupRepository := mockRepository.NewMockGroup(ctrl)
+	mockUploadRepository := mockRepository.NewMockUpload(ctrl)
+
+	uploadStorage, err := local.NewUpload(local.NewDirectoryManager(common.FilePath(rootPath)))
+	if err != nil {
+		t.Fatalf("failed to create upload storage: %v", err)
+	}
+
+	garbageCollector := NewGarbageCollector(
+		mockDBRepository,
+		mockFileRepository,
+		mockRenditionRepository,
+		mockResourceRepository,
+		mockGroupRepository,
+		mockUploadRepository,
+		fileStorage,
+		uploadStorage,
+	)
+
+	mockDBRepository.
+		EXPECT().
+		Transaction(gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, _ interface{}, fn func(ctx context.Context) error) error {
+			return fn(ctx)
+		}).
+		AnyTimes()
+
+	newFile := func(content string) *domain.File {
+		file := domain.NewFile(
+			values.NewFileID(),
+			values.FileTypeJpeg,
+			values.FileHash{},
+			0,
+			nil,
+			time.Now(),
+		)
+
+		err := fileStorage.SaveFile(ctx, file, strings.NewReader(content))
+		if err != nil {
+			t.Fatalf("failed to save file: %v", err)
+		}
+
+		return file
+	}
+
+	// グループに含まれ、論理削除されたグループのメインのリソースでもあるリソース
+	resourceID := values.NewResourceID()
+	group := domain.NewGroup(
+		values.NewGroupID(),
+		values.NewGroupName("group"),
+		values.GroupTypeArtBook,
+		values.NewGroupDescription("description"),
+		values.GroupReadPermissionPublic,
+		values.GroupWritePermissionPublic,
+		time.Now(),
+	)
+	deletedGroupID := values.NewGroupID()
+
+	// 完全に削除に失敗するリソース
+	failedResourceID := values.NewResourceID()
+
+	mockResourceRepository.
+		EXPECT().
+		GetDeletedResourceIDs(ctx, gomock.Any()).
+		Return([]values.ResourceID{resourceID, failedResourceID}, nil)
+
+	mockGroupRepository.
+		EXPECT().
+		GetGroupsByResource(gomock.Any(), resourceID).
+		Return([]*domain.Group{group}, nil)
+	mockGroupRepository.
+		EXPECT().
+		DeleteResources(gomock.Any(), group, []values.ResourceID{resourceID}).
+		Return(nil)
+	mockGroupRepository.
+		EXPECT().
+		GetGroupsByMainResource(gomock.Any(), resourceID).
+		Return([]*domain.Group{}, nil)
+	mockGroupRepository.
+		EXPECT().
+		GetDeletedGroupIDsByMainResource(gomock.Any(), resourceID).
+		Return([]values.GroupID{deletedGroupID}, nil)
+	mockGroupRepository.
+		EXPECT().
+		PurgeGroup(gomock.Any(), deletedGroupID).
+		Return(nil)
+	mockResourceRepository.
+		EXPECT().
+		PurgeResource(gomock.Any(), resourceID).
+		Return(nil)
+
+	mockGroupRepository.
+		EXPECT().
+		GetGroupsByResource(gomock.Any(), failedResourceID).
+		Return(nil, errors.New("error"))
+
+	// レンディションを持つファイル
+	file := newFile("file")
+	rendition := newFile("rendition")
+	// ストレージに存在しないファイル
+	missingFile := domain.NewFile(
+		values.NewFileID(),
+		values.FileTypeJpeg,
+		values.FileHash{},
+		0,
+		nil,
+		time.Now(),
+	)
+
+	mockFileRepository.
+		EXPECT().
+		GetPurgeableFiles(ctx, gomock.Any()).
+		Return([]*domain.File{file, missingFile}, nil)
+
+	mockRenditionRepository.
+		EXPECT().
+		GetRenditions(gomock.Any(), file.GetID()).
+		Return([]*domain.File{rendition}, nil)
+	mockRenditionRepository.
+		EXPECT().
+		DeleteRenditions(gomock.Any(), file.GetID()).
+		Return(nil)
+	mockFileRepository.
+		EXPECT().
+		PurgeFile(gomock.Any(), rendition.GetID()).
+		Return(nil)
+	mockFileRepository.
+		EXPECT().
+		PurgeFile(gomock.Any(), file.GetID()).
+		Return(nil)
+
+	mockRenditionRepository.
+		EXPECT().
+		GetRenditions(gomock.Any(), missingFile.GetID()).
+		Return([]*domain.File{}, nil)
+	mockRenditionRepository.
+		EXPECT().
+		DeleteRenditions(gomock.Any(), missingFile.GetID()).
+		Return(nil)
+	mockFileRepository.
+		EXPECT().
+		PurgeFile(gomock.Any(), missingFile.GetID()).
+		Return(nil)
+
+	// 期限切れのアップロード
+	expiredUpload := domain.NewUpload(
+		values.NewUploadID(),
+		10,
+		5,
+		"",
+		time.Now().Add(-time.Hour),
+		time.Now().Add(-uploadExpiration-time.Hour),
+	)
+	err = uploadStorage.CreateUpload(ctx, expiredUpload.GetID())
+	if err != nil {
+		t.Fatalf("failed to create upload: %v", err)
+	}
+
+	mockUploadRepository.
+		EXPECT().
+		GetExpiredUploads(ctx, gomock.Any()).
+		Return([]*domain.Upload{expiredUpload}, nil)
+	mockUploadRepository.
+		EXPECT().
+		DeleteUpload(gomock.Any(), expiredUpload.GetID()).
+		Return(nil)
+
+	result, err := garbageCollector.CollectGarbage(ctx)
+	if err != nil {
+		t.Fatalf("failed to collect garbage: %v", err)
+	}
+
+	if result.PurgedResources != 1 {
+		t.Errorf("purged resources must be 1, but actual is %d", result.PurgedResources)
+	}
+	if result.PurgedGroups != 1 {
+		t.Errorf("purged groups must be 1, but actual is %d", result.PurgedGroups)
+	}
+	if result.PurgedFiles != 2 {
+		t.Errorf("purged files must be 2, but actual is %d", result.PurgedFiles)
+	}
+	if result.DiscardedUploads != 1 {
+		t.Errorf("discarded uploads must be 1, but actual is %d", result.DiscardedUploads)
+	}
+	if result.Failed != 1 {
+		t.Errorf("failed must be 1, but actual is %d", result.Failed)
+	}
+	// 参照がなくなったばかりの実体は、猶予の間は削除されない
+	if result.DeletedObjects != 0 {
+		t.Errorf("deleted objects must be 0, but actual is %d", result.DeletedObjects)
+	}
+
+	deletedObjects, err := fileStorage.DeleteUnreferencedObjects(ctx, time.Now().Add(time.Minute))
+	if err != nil {
+		t.Fatalf("failed to delete unreferenced objects: %v", err)
+	}
+	if deletedObjects != 2 {
+		t.Errorf("deleted objects must be 2, but actual is %d", deletedObjects)
+	}
+
+	for _, file := range []*domain.File{file, rendition} {
+		err := fileStorage.GetFile(ctx, file, bytes.NewBuffer(nil))
+		if !errors.Is(err, storage.ErrNotFound) {
+			t.Errorf("file must be deleted from storage, but actual error is %v", err)
+		}
+	}
+
+	_, err = uploadStorage.OpenUpload(ctx, expiredUpload.GetID())
+	if !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("upload must be deleted from storage, but actual error is %v", err)
+	}
+}
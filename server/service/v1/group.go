@@ -10,6 +10,7 @@ import (
 	"github.com/mazrean/Quantainer/domain/values"
 	"github.com/mazrean/Quantainer/repository"
 	"github.com/mazrean/Quantainer/service"
+	"github.com/mazrean/Quantainer/storage"
 )
 
 type Group struct {
@@ -18,6 +19,7 @@ type Group struct {
 	groupRepository         repository.Group
 	administratorRepository repository.Administrator
 	userUtils               *UserUtils
+	fileStorage             storage.File
 }
 
 func NewGroup(
@@ -26,6 +28,7 @@ func NewGroup(
 	groupRepository repository.Group,
 	administratorRepository repository.Administrator,
 	userUtils *UserUtils,
+	fileStorage storage.File,
 ) *Group {
 	return &Group{
 		dbRepository:            dbRepository,
@@ -33,6 +36,7 @@ func NewGroup(
 		groupRepository:         groupRepository,
 		administratorRepository: administratorRepository,
 		userUtils:               userUtils,
+		fileStorage:             fileStorage,
 	}
 }
 
@@ -330,7 +334,7 @@ func (g *Group) DeleteGroup(ctx context.Context, session *domain.OIDCSession, id
 			}
 		}
 
-		err = g.groupRepository.DeleteGroup(ctx, groupInfo.Group)
+		err = g.groupRepository.DeleteGroup(ctx, groupInfo.Group, time.Now())
 		if err != nil {
 			return fmt.Errorf("failed to delete group: %w", err)
 		}
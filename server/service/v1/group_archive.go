@@ -0,0 +1,268 @@
+package v1
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/google/uuid"
+	"github.com/mazrean/Quantainer/domain"
+	"github.com/mazrean/Quantainer/domain/values"
+	"github.com/mazrean/Quantainer/repository"
+	"github.com/mazrean/Quantainer/service"
+)
+
+const groupArchiveManifestName = "manifest.json"
+
+// groupArchiveManifest ZIPに含めるグループとリソースの情報
+type groupArchiveManifest struct {
+	ID          string                          `json:"id"`
+	Name        string                          `json:"name"`
+	Description string                          `json:"description"`
+	CreatedAt   time.Time                       `json:"createdAt"`
+	Resources   []*groupArchiveManifestResource `json:"resources"`
+}
+
+type groupArchiveManifestResource struct {
+	// Path ZIP内でのファイルのパス
+	Path    string `json:"path"`
+	ID      string `json:"id"`
+	Name    string `json:"name"`
+	Comment string `json:"comment"`
+	// Creator 作成者が既にいない場合は空文字列
+	Creator   string    `json:"creator"`
+	IsMain    bool      `json:"isMain"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type groupArchiveEntry struct {
+	path     string
+	resource *repository.ResourceInfo
+}
+
+func (g *Group) GetGroupArchive(ctx context.Context, session *domain.OIDCSession, groupID values.GroupID) (*domain.Group, io.ReadCloser, error) {
+	user, err := g.userUtils.getMe(ctx, session)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	users, err := g.userUtils.getAllActiveUser(ctx, session)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get users: %w", err)
+	}
+	userMap := make(map[values.TraPMemberID]*service.UserInfo)
+	for _, user := range users {
+		userMap[user.GetID()] = user
+	}
+
+	groupInfo, err := g.groupRepository.GetGroup(ctx, groupID, repository.LockTypeNone)
+	if errors.Is(err, repository.ErrRecordNotFound) {
+		return nil, nil, service.ErrNoGroup
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get group: %w", err)
+	}
+
+	if groupInfo.Group.GetReadPermission() != values.GroupReadPermissionPublic {
+		administratorIDs, err := g.administratorRepository.GetAdministrators(ctx, groupInfo.GetID())
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to get administrators: %w", err)
+		}
+
+		for i, administrator := range administratorIDs {
+			if administrator == user.GetID() {
+				break
+			}
+
+			if i == len(administratorIDs)-1 {
+				return nil, nil, service.ErrForbidden
+			}
+		}
+	}
+
+	resources, err := g.resourceRepository.GetResources(ctx, &repository.ResourceSearchParams{
+		Groups: []*domain.Group{groupInfo.Group},
+		Limit:  -1,
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get resources: %w", err)
+	}
+
+	entries := newGroupArchiveEntries(groupInfo.MainResource, resources)
+
+	manifest := &groupArchiveManifest{
+		ID:          uuid.UUID(groupInfo.Group.GetID()).String(),
+		Name:        string(groupInfo.Group.GetName()),
+		Description: string(groupInfo.Group.GetDescription()),
+		CreatedAt:   groupInfo.Group.GetCreatedAt(),
+		Resources:   make([]*groupArchiveManifestResource, 0, len(entries)),
+	}
+	for _, entry := range entries {
+		var creator string
+		if creatorInfo, ok := userMap[entry.resource.Creator]; ok {
+			creator = string(creatorInfo.GetName())
+		}
+
+		manifest.Resources = append(manifest.Resources, &groupArchiveManifestResource{
+			Path:      entry.path,
+			ID:        uuid.UUID(entry.resource.Resource.GetID()).String(),
+			Name:      string(entry.resource.GetName()),
+			Comment:   string(entry.resource.GetComment()),
+			Creator:   creator,
+			IsMain:    entry.resource.Resource.GetID() == groupInfo.MainResource.Resource.GetID(),
+			CreatedAt: entry.resource.Resource.GetCreatedAt(),
+		})
+	}
+
+	// ZIP全体をメモリやディスクに置かないよう、読み込みに合わせてストレージから読み込んで書き込む
+	pr, pw := io.Pipe()
+	go func() {
+		// エラーがない場合はCloseと同じ
+		_ = pw.CloseWithError(g.writeGroupArchive(ctx, pw, manifest, entries))
+	}()
+
+	return groupInfo.Group, pr, nil
+}
+
+func (g *Group) writeGroupArchive(ctx context.Context, w io.Writer, manifest *groupArchiveManifest, entries []*groupArchiveEntry) error {
+	zipWriter := zip.NewWriter(w)
+
+	manifestWriter, err := zipWriter.CreateHeader(&zip.FileHeader{
+		Name:     groupArchiveManifestName,
+		Method:   zip.Deflate,
+		Modified: time.Now(),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create manifest: %w", err)
+	}
+
+	encoder := json.NewEncoder(manifestWriter)
+	encoder.SetIndent("", "  ")
+	err = encoder.Encode(manifest)
+	if err != nil {
+		return fmt.Errorf("failed to encode manifest: %w", err)
+	}
+
+	for _, entry := range entries {
+		method := zip.Deflate
+		if isCompressedFileType(entry.resource.File.GetType()) {
+			// 圧縮済みの形式は圧縮してもほとんど小さくならないので、そのまま格納する
+			method = zip.Store
+		}
+
+		entryWriter, err := zipWriter.CreateHeader(&zip.FileHeader{
+			Name:     entry.path,
+			Method:   method,
+			Modified: entry.resource.Resource.GetCreatedAt(),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create entry: %w", err)
+		}
+
+		err = g.fileStorage.GetFile(ctx, entry.resource.File, entryWriter)
+		if err != nil {
+			return fmt.Errorf("failed to get file(%s): %w", uuid.UUID(entry.resource.File.GetID()), err)
+		}
+	}
+
+	err = zipWriter.Close()
+	if err != nil {
+		return fmt.Errorf("failed to close zip: %w", err)
+	}
+
+	return nil
+}
+
+// newGroupArchiveEntries メインのリソースを先頭に、残りのリソースを作成日時の古い順に並べ、
+// 並び順の番号とリソース名からZIP内でのパスを決める。隔離されたファイルのリソースは含めない
+func newGroupArchiveEntries(mainResource *repository.ResourceInfo, resources []*repository.ResourceInfo) []*groupArchiveEntry {
+	sortedResources := make([]*repository.ResourceInfo, 0, len(resources)+1)
+	for _, resource := range resources {
+		// 隔離されたファイルは配布しないよう、ZIPに含めない
+		if resource.Resource.GetID() == mainResource.Resource.GetID() || resource.File.IsInfected() {
+			continue
+		}
+
+		sortedResources = append(sortedResources, resource)
+	}
+
+	sort.SliceStable(sortedResources, func(i, j int) bool {
+		return sortedResources[i].Resource.GetCreatedAt().Before(sortedResources[j].Resource.GetCreatedAt())
+	})
+
+	if !mainResource.File.IsInfected() {
+		sortedResources = append([]*repository.ResourceInfo{mainResource}, sortedResources...)
+	}
+
+	// 番号の桁を揃え、名前順に並べても順番が変わらないようにする
+	digits := len(strconv.Itoa(len(sortedResources)))
+	if digits < 3 {
+		digits = 3
+	}
+
+	entries := make([]*groupArchiveEntry, 0, len(sortedResources))
+	for i, resource := range sortedResources {
+		name := sanitizeArchiveName(string(resource.GetName()))
+		extension := resource.File.GetType().DefaultExtension()
+		if len(extension) == 0 && len(resource.File.GetExtension()) != 0 {
+			// その他のファイルは、アップロードされた時の拡張子を使う
+			extension = "." + string(resource.File.GetExtension())
+		}
+		if !strings.HasSuffix(strings.ToLower(name), extension) {
+			name += extension
+		}
+
+		entries = append(entries, &groupArchiveEntry{
+			path:     fmt.Sprintf("%0*d_%s", digits, i+1, name),
+			resource: resource,
+		})
+	}
+
+	return entries
+}
+
+// sanitizeArchiveName 展開先のOSでファイル名に使えない文字を置き換える
+func sanitizeArchiveName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) || strings.ContainsRune(`/\:*?"<>|`, r) {
+			return '_'
+		}
+
+		return r
+	}, name)
+
+	// 隠しファイルや末尾のドットを許容しないOSがあるので取り除く
+	name = strings.Trim(name, " .")
+	if len(name) == 0 {
+		return "resource"
+	}
+
+	return name
+}
+
+func isCompressedFileType(fileType values.FileType) bool {
+	switch fileType {
+	case values.FileTypeJpeg,
+		values.FileTypePng,
+		values.FileTypeWebP,
+		values.FileTypeGif,
+		values.FileTypeMp3,
+		values.FileTypeOgg,
+		values.FileTypeFlac,
+		values.FileTypeMp4,
+		values.FileTypeWebM,
+		values.FileTypeZip,
+		values.FileTypeAvif:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,295 @@
+package v1
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	mockAuth "github.com/mazrean/Quantainer/auth/mock"
+	mockCache "github.com/mazrean/Quantainer/cache/mock"
+	"github.com/mazrean/Quantainer/domain"
+	"github.com/mazrean/Quantainer/domain/values"
+	"github.com/mazrean/Quantainer/pkg/common"
+	"github.com/mazrean/Quantainer/repository"
+	mockRepository "github.com/mazrean/Quantainer/repository/mock"
+	"github.com/mazrean/Quantainer/service"
+	"github.com/mazrean/Quantainer/storage/local"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetGroupArchive(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	rootPath := "./group_archive_test"
+	defer func() {
+		err := os.RemoveAll(rootPath)
+		if err != nil {
+			t.Fatalf("failed to remove directory: %v", err)
+		}
+	}()
+
+	fileStorage, err := local.NewFile(local.NewDirectoryManager(common.FilePath(rootPath)))
+	if err != nil {
+		t.Fatalf("failed to create storage: %v", err)
+	}
+
+	mockUserCache := mockCache.NewMockUser(ctrl)
+	mockUserAuth := mockAuth.NewMockUser(ctrl)
+	mockGroupRepository := mockRepository.NewMockGroup(ctrl)
+	mockResourceRepository := mockRepository.NewMockResource(ctrl)
+	mockAdministratorRepository := mockRepository.NewMockAdministrator(ctrl)
+
+	userUtils := NewUserUtils(mockUserAuth, mockUserCache, common.Administrators{})
+
+	groupService := NewGroup(
+		mockRepository.NewMockDB(ctrl),
+		mockResourceRepository,
+		mockGroupRepository,
+		mockAdministratorRepository,
+		userUtils,
+		fileStorage,
+	)
+
+	user := service.NewUserInfo(
+		values.NewTrapMemberID(uuid.New()),
+		values.NewTrapMemberName("mazrean"),
+		values.TrapMemberStatusActive,
+	)
+	otherUser := service.NewUserInfo(
+		values.NewTrapMemberID(uuid.New()),
+		values.NewTrapMemberName("other"),
+		values.TrapMemberStatusActive,
+	)
+
+	now := time.Now()
+
+	newResourceInfo := func(name string, fileType values.FileType, content string, createdAt time.Time) *repository.ResourceInfo {
+		file := domain.NewFile(
+			values.NewFileID(),
+			fileType,
+			values.FileHash{},
+			0,
+			nil,
+			createdAt,
+		)
+		err := fileStorage.SaveFile(ctx, file, strings.NewReader(content))
+		if err != nil {
+			t.Fatalf("failed to save file: %v", err)
+		}
+
+		return &repository.ResourceInfo{
+			Resource: domain.NewResource(
+				values.NewResourceID(),
+				values.NewResourceName(name),
+				values.ResourceTypeImage,
+				values.NewResourceComment("comment of "+name),
+				createdAt,
+			),
+			File:    file,
+			Creator: user.GetID(),
+		}
+	}
+
+	cover := newResourceInfo("cover", values.FileTypePng, "cover", now)
+	page1 := newResourceInfo("page/1", values.FileTypeJpeg, "page1", now.Add(-2*time.Hour))
+	page2 := newResourceInfo("page2.JPG", values.FileTypeJpeg, "page2", now.Add(-time.Hour))
+	note := newResourceInfo("..", values.FileTypeOther, "note", now.Add(-30*time.Minute))
+	// 隔離されたファイルはZIPに含めない
+	malware := newResourceInfo("malware", values.FileTypeOther, "EICAR", now.Add(-90*time.Minute))
+	malware.File.SetScan(domain.NewFileScan(values.ScanStatusInfected, values.NewScanSignature("Eicar-Test-Signature"), now))
+
+	type test struct {
+		description         string
+		readPermission      values.GroupReadPermission
+		administrators      []values.TraPMemberID
+		getGroupErr         error
+		executeGetResources bool
+		paths               []string
+		contents            []string
+		isErr               bool
+		err                 error
+	}
+
+	testCases := []test{
+		{
+			description:         "公開されたグループなので取得できる",
+			readPermission:      values.GroupReadPermissionPublic,
+			executeGetResources: true,
+			paths: []string{
+				"001_cover.png",
+				"002_page_1.jpg",
+				"003_page2.JPG",
+				"004_resource",
+			},
+			contents: []string{"cover", "page1", "page2", "note"},
+		},
+		{
+			description:         "非公開だが管理者なので取得できる",
+			readPermission:      values.GroupReadPermissionPrivate,
+			administrators:      []values.TraPMemberID{otherUser.GetID(), user.GetID()},
+			executeGetResources: true,
+			paths: []string{
+				"001_cover.png",
+				"002_page_1.jpg",
+				"003_page2.JPG",
+				"004_resource",
+			},
+			contents: []string{"cover", "page1", "page2", "note"},
+		},
+		{
+			description:    "非公開で管理者でないのでErrForbidden",
+			readPermission: values.GroupReadPermissionPrivate,
+			administrators: []values.TraPMemberID{otherUser.GetID()},
+			isErr:          true,
+			err:            service.ErrForbidden,
+		},
+		{
+			description: "グループが存在しないのでErrNoGroup",
+			getGroupErr: repository.ErrRecordNotFound,
+			isErr:       true,
+			err:         service.ErrNoGroup,
+		},
+	}
+
+	for _, testCase := range testCases {
+		testCase := testCase
+		t.Run(testCase.description, func(t *testing.T) {
+			group := domain.NewGroup(
+				values.NewGroupID(),
+				values.NewGroupName("art book"),
+				values.GroupTypeArtBook,
+				values.NewGroupDescription("description"),
+				testCase.readPermission,
+				values.GroupWritePermissionPrivate,
+				now,
+			)
+
+			mockUserCache.
+				EXPECT().
+				GetMe(gomock.Any(), gomock.Any()).
+				Return(user, nil)
+			mockUserCache.
+				EXPECT().
+				GetAllActiveUsers(gomock.Any()).
+				Return([]*service.UserInfo{user, otherUser}, nil)
+
+			var groupInfo *repository.GroupInfo
+			if testCase.getGroupErr == nil {
+				groupInfo = &repository.GroupInfo{
+					Group:        group,
+					MainResource: cover,
+				}
+			}
+			mockGroupRepository.
+				EXPECT().
+				GetGroup(gomock.Any(), group.GetID(), repository.LockTypeNone).
+				Return(groupInfo, testCase.getGroupErr)
+
+			if testCase.getGroupErr == nil && testCase.readPermission == values.GroupReadPermissionPrivate {
+				mockAdministratorRepository.
+					EXPECT().
+					GetAdministrators(gomock.Any(), group.GetID()).
+					Return(testCase.administrators, nil)
+			}
+
+			if testCase.executeGetResources {
+				// 作成日時の新しい順に返され、メインのリソースも含む
+				mockResourceRepository.
+					EXPECT().
+					GetResources(gomock.Any(), &repository.ResourceSearchParams{
+						Groups: []*domain.Group{group},
+						Limit:  -1,
+					}).
+					Return([]*repository.ResourceInfo{cover, note, page2, malware, page1}, nil)
+			}
+
+			session := domain.NewOIDCSession("accessToken", now.Add(time.Hour))
+
+			actualGroup, reader, err := groupService.GetGroupArchive(ctx, session, group.GetID())
+
+			if testCase.isErr {
+				if testCase.err == nil {
+					assert.Error(t, err)
+				} else if !errors.Is(err, testCase.err) {
+					t.Errorf("error must be %v, but actual is %v", testCase.err, err)
+				}
+			} else {
+				assert.NoError(t, err)
+			}
+			if err != nil {
+				return
+			}
+			defer reader.Close()
+
+			assert.Equal(t, group, actualGroup)
+
+			archive, err := io.ReadAll(reader)
+			if err != nil {
+				t.Fatalf("failed to read archive: %v", err)
+			}
+
+			zipReader, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+			if err != nil {
+				t.Fatalf("failed to open archive: %v", err)
+			}
+
+			if !assert.Len(t, zipReader.File, len(testCase.paths)+1) {
+				return
+			}
+			assert.Equal(t, groupArchiveManifestName, zipReader.File[0].Name)
+
+			for i, zipFile := range zipReader.File[1:] {
+				assert.Equal(t, testCase.paths[i], zipFile.Name)
+
+				r, err := zipFile.Open()
+				if err != nil {
+					t.Fatalf("failed to open entry: %v", err)
+				}
+
+				content, err := io.ReadAll(r)
+				_ = r.Close()
+				if err != nil {
+					t.Fatalf("failed to read entry: %v", err)
+				}
+
+				assert.Equal(t, testCase.contents[i], string(content))
+			}
+
+			manifestReader, err := zipReader.File[0].Open()
+			if err != nil {
+				t.Fatalf("failed to open manifest: %v", err)
+			}
+			defer manifestReader.Close()
+
+			var manifest groupArchiveManifest
+			err = json.NewDecoder(manifestReader).Decode(&manifest)
+			if err != nil {
+				t.Fatalf("failed to decode manifest: %v", err)
+			}
+
+			assert.Equal(t, "art book", manifest.Name)
+			if assert.Len(t, manifest.Resources, len(testCase.paths)) {
+				assert.Equal(t, testCase.paths[1], manifest.Resources[1].Path)
+				assert.Equal(t, "page/1", manifest.Resources[1].Name)
+				assert.Equal(t, "comment of page/1", manifest.Resources[1].Comment)
+				assert.Equal(t, "mazrean", manifest.Resources[1].Creator)
+				assert.True(t, manifest.Resources[0].IsMain)
+				assert.False(t, manifest.Resources[1].IsMain)
+			}
+		})
+	}
+}
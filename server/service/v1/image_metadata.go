@@ -0,0 +1,168 @@
+package v1
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/mazrean/Quantainer/domain"
+	"github.com/mazrean/Quantainer/domain/values"
+	"github.com/mazrean/Quantainer/pkg/imageinfo"
+	"github.com/mazrean/Quantainer/pkg/jpegmeta"
+	"github.com/mazrean/Quantainer/service"
+)
+
+// maxCameraModelLength カメラの機種として保存する最大の文字数
+const maxCameraModelLength = 255
+
+// processJPEGMetadata JPEGのメタデータを取り出す。
+// stripがtrueの場合、返り値のio.Readerからは位置情報などを取り除いたJPEGを読み出せる。
+func processJPEGMetadata(reader io.Reader, strip bool) (*domain.ImageMetadata, io.Reader, error) {
+	metadata, reader, err := jpegmeta.Process(reader, strip)
+	if errors.Is(err, jpegmeta.ErrInvalidJPEG) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return nil, nil, fmt.Errorf("broken jpeg(%v): %w", err, service.ErrInvalidFormat)
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to process jpeg: %w", err)
+	}
+
+	cameraModel := strings.ToValidUTF8(metadata.CameraModel, "")
+	if utf8.RuneCountInString(cameraModel) > maxCameraModelLength {
+		cameraModel = string([]rune(cameraModel)[:maxCameraModelLength])
+	}
+
+	var colorSpace values.ColorSpace
+	switch metadata.Components {
+	case 1:
+		colorSpace = values.ColorSpaceGray
+	case 3:
+		colorSpace = values.ColorSpaceRGB
+	case 4:
+		colorSpace = values.ColorSpaceCMYK
+	default:
+		colorSpace = values.ColorSpaceUnknown
+	}
+
+	// Orientationが5~8の場合は90度回転して表示されるので、幅と高さを入れ替える
+	width, height := metadata.Width, metadata.Height
+	if metadata.Orientation >= 5 {
+		width, height = height, width
+	}
+
+	return domain.NewImageMetadata(
+		width,
+		height,
+		1,
+		colorSpace,
+		metadata.Orientation,
+		metadata.CapturedAt,
+		cameraModel,
+	), reader, nil
+}
+
+// analyzeImage ファイルの内容から画像のメタデータを取り出す。
+// 画像以外の場合や、壊れているなどで大きさがわからない場合はnilを返す。
+func analyzeImage(fileType values.FileType, reader io.Reader) (*domain.ImageMetadata, error) {
+	var (
+		info *imageinfo.Info
+		err  error
+	)
+	switch fileType {
+	case values.FileTypeJpeg:
+		imageMetadata, _, err := processJPEGMetadata(reader, false)
+		if errors.Is(err, service.ErrInvalidFormat) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to process jpeg metadata: %w", err)
+		}
+
+		return imageMetadata, nil
+	case values.FileTypePng:
+		info, err = imageinfo.PNG(reader)
+	case values.FileTypeGif:
+		info, err = imageinfo.GIF(reader)
+	case values.FileTypeWebP:
+		info, err = imageinfo.WebP(reader)
+	case values.FileTypeAvif:
+		info, err = imageinfo.AVIF(reader)
+	case values.FileTypeSvg:
+		info, err = imageinfo.SVG(reader)
+	default:
+		return nil, nil
+	}
+	if errors.Is(err, imageinfo.ErrInvalidFormat) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to analyze image: %w", err)
+	}
+
+	var colorSpace values.ColorSpace
+	switch info.ColorSpace {
+	case imageinfo.ColorSpaceGray:
+		colorSpace = values.ColorSpaceGray
+	case imageinfo.ColorSpaceRGB:
+		colorSpace = values.ColorSpaceRGB
+	case imageinfo.ColorSpaceCMYK:
+		colorSpace = values.ColorSpaceCMYK
+	default:
+		colorSpace = values.ColorSpaceUnknown
+	}
+
+	return domain.NewImageMetadata(
+		info.Width,
+		info.Height,
+		info.Frames,
+		colorSpace,
+		0,
+		nil,
+		"",
+	), nil
+}
+
+type imageAnalysisResult struct {
+	imageMetadata *domain.ImageMetadata
+	err           error
+}
+
+// imageAnalyzer ストレージへの保存と並行して、読み出されたファイルの内容から画像のメタデータを取り出す
+type imageAnalyzer struct {
+	pipeWriter *io.PipeWriter
+	result     chan *imageAnalysisResult
+}
+
+// newImageAnalyzer 返り値のio.Readerから読み出した内容が解析される
+func newImageAnalyzer(fileType values.FileType, reader io.Reader) (*imageAnalyzer, io.Reader) {
+	pr, pw := io.Pipe()
+	analyzer := &imageAnalyzer{
+		pipeWriter: pw,
+		result:     make(chan *imageAnalysisResult, 1),
+	}
+
+	go func() {
+		imageMetadata, err := analyzeImage(fileType, pr)
+
+		// 解析に使わなかった部分も読み捨てないと、保存側の読み込みが止まる
+		_, _ = io.Copy(io.Discard, pr)
+
+		analyzer.result <- &imageAnalysisResult{
+			imageMetadata: imageMetadata,
+			err:           err,
+		}
+	}()
+
+	return analyzer, io.TeeReader(reader, pw)
+}
+
+// wait 読み込みの終了を伝え、解析結果を待つ。
+// readErrには読み込み側で発生したエラーを渡す。
+func (ia *imageAnalyzer) wait(readErr error) (*domain.ImageMetadata, error) {
+	ia.pipeWriter.CloseWithError(readErr)
+
+	result := <-ia.result
+
+	return result.imageMetadata, result.err
+}
@@ -0,0 +1,314 @@
+package v1
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"path"
+	"strings"
+
+	"github.com/mazrean/Quantainer/domain"
+	"github.com/mazrean/Quantainer/domain/values"
+	"github.com/mazrean/Quantainer/repository"
+	"github.com/mazrean/Quantainer/service"
+	"github.com/mazrean/Quantainer/storage"
+)
+
+const (
+	// maxImportFiles 1つのグループとしてインポートできるファイルの数
+	maxImportFiles = 1000
+	// maxResourceNameLength リソース名の最大の文字数
+	maxResourceNameLength = 64
+	// maxResourceCommentLength リソースのコメントの最大の文字数
+	maxResourceCommentLength = 400
+)
+
+type Importer struct {
+	dbRepository    repository.DB
+	fileStorage     storage.File
+	fileService     service.File
+	resourceService service.Resource
+	groupService    service.Group
+}
+
+func NewImporter(
+	dbRepository repository.DB,
+	fileStorage storage.File,
+	fileService service.File,
+	resourceService service.Resource,
+	groupService service.Group,
+) *Importer {
+	return &Importer{
+		dbRepository:    dbRepository,
+		fileStorage:     fileStorage,
+		fileService:     fileService,
+		resourceService: resourceService,
+		groupService:    groupService,
+	}
+}
+
+// importEntry インポートするファイルと、作成するリソースの情報
+type importEntry struct {
+	path    string
+	name    values.ResourceName
+	comment values.ResourceComment
+	isMain  bool
+}
+
+func (i *Importer) ImportGroup(ctx context.Context, session *domain.OIDCSession, params *service.ImportGroupParams, fsys fs.FS) (*service.ImportResult, error) {
+	manifest, err := readImportManifest(fsys)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifest: %w", err)
+	}
+
+	entries, err := newImportEntries(fsys, manifest, params.MainResource)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list files: %w", err)
+	}
+
+	name := params.Name
+	description := params.Description
+	if manifest != nil {
+		if len(name) == 0 {
+			name = values.NewGroupName(manifest.Name)
+		}
+		if len(description) == 0 {
+			description = values.NewGroupDescription(manifest.Description)
+		}
+	}
+	if len(name) == 0 {
+		return nil, fmt.Errorf("no group name: %w", service.ErrInvalidImport)
+	}
+
+	// ストレージへの保存はトランザクションで取り消せないので、失敗した場合は保存したファイルとレンディションを削除する
+	var uploadedFiles []*domain.File
+
+	var result *service.ImportResult
+	err = i.dbRepository.Transaction(ctx, nil, func(ctx context.Context) error {
+		resources := make([]*service.ResourceInfo, 0, len(entries))
+		resourceIDs := make([]values.ResourceID, 0, len(entries))
+		var mainResourceID values.ResourceID
+		for _, entry := range entries {
+			fileInfo, err := i.uploadFile(ctx, session, fsys, entry.path)
+			if fileInfo != nil {
+				uploadedFiles = append(uploadedFiles, fileInfo.File)
+				uploadedFiles = append(uploadedFiles, fileInfo.Renditions...)
+			}
+			if err != nil {
+				return fmt.Errorf("failed to upload %s: %w", entry.path, err)
+			}
+
+			resource, err := i.resourceService.CreateResource(
+				ctx,
+				session,
+				fileInfo.File.GetID(),
+				entry.name,
+				fileInfo.File.GetType().DefaultResourceType(),
+				entry.comment,
+			)
+			if err != nil {
+				return fmt.Errorf("failed to create resource(%s): %w", entry.path, err)
+			}
+
+			resources = append(resources, resource)
+			resourceIDs = append(resourceIDs, resource.Resource.GetID())
+			if entry.isMain {
+				mainResourceID = resource.Resource.GetID()
+			}
+		}
+
+		group, err := i.groupService.CreateGroup(
+			ctx,
+			session,
+			name,
+			params.GroupType,
+			description,
+			params.ReadPermission,
+			params.WritePermission,
+			mainResourceID,
+			resourceIDs,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to create group: %w", err)
+		}
+
+		result = &service.ImportResult{
+			Group:     group,
+			Resources: resources,
+		}
+
+		return nil
+	})
+	if err != nil {
+		// 中断によって失敗した場合も削除できるよう、ctxとは別のcontextを使う
+		for _, file := range uploadedFiles {
+			deleteErr := i.fileStorage.DeleteFile(context.Background(), file)
+			if deleteErr != nil {
+				log.Printf("error: failed to delete imported file: %v\n", deleteErr)
+			}
+		}
+
+		return nil, fmt.Errorf("failed in transaction: %w", err)
+	}
+
+	return result, nil
+}
+
+// uploadFile 保存に成功した場合は、後続の処理で失敗してもFileInfoを返す
+func (i *Importer) uploadFile(ctx context.Context, session *domain.OIDCSession, fsys fs.FS, filePath string) (*service.FileInfo, error) {
+	f, err := fsys.Open(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+	defer f.Close()
+
+	fileInfo, err := i.fileService.Upload(ctx, session, values.NewFileName(path.Base(filePath)), f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to upload file: %w", err)
+	}
+
+	return fileInfo, nil
+}
+
+// readImportManifest グループのZIPと同じ形式のmanifest.jsonを読み込む。ない場合はnilを返す。
+func readImportManifest(fsys fs.FS) (*groupArchiveManifest, error) {
+	data, err := fs.ReadFile(fsys, groupArchiveManifestName)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+
+	var manifest groupArchiveManifest
+	err = json.Unmarshal(data, &manifest)
+	if err != nil {
+		return nil, fmt.Errorf("broken manifest(%v): %w", err, service.ErrInvalidImport)
+	}
+
+	return &manifest, nil
+}
+
+// newImportEntries manifest.jsonに含まれるファイルをその順に並べ、残りのファイルをパスの順に並べる
+func newImportEntries(fsys fs.FS, manifest *groupArchiveManifest, mainResource string) ([]*importEntry, error) {
+	var filePaths []string
+	err := fs.WalkDir(fsys, ".", func(filePath string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		// 隠しファイルやmacOSのZIPに含まれるメタデータは除く
+		if filePath != "." && (strings.HasPrefix(d.Name(), ".") || d.Name() == "__MACOSX") {
+			if d.IsDir() {
+				return fs.SkipDir
+			}
+
+			return nil
+		}
+
+		if d.IsDir() || filePath == groupArchiveManifestName {
+			return nil
+		}
+
+		if !d.Type().IsRegular() {
+			return fmt.Errorf("not regular file(%s): %w", filePath, service.ErrInvalidImport)
+		}
+
+		filePaths = append(filePaths, filePath)
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to walk files: %w", err)
+	}
+
+	if len(filePaths) == 0 {
+		return nil, fmt.Errorf("no file: %w", service.ErrInvalidImport)
+	}
+	if len(filePaths) > maxImportFiles {
+		return nil, fmt.Errorf("too many files(%d): %w", len(filePaths), service.ErrInvalidImport)
+	}
+
+	entryMap := make(map[string]*importEntry, len(filePaths))
+	for _, filePath := range filePaths {
+		entryMap[filePath] = &importEntry{
+			path: filePath,
+			name: values.NewResourceName(resourceNameFromPath(filePath)),
+		}
+	}
+
+	entries := make([]*importEntry, 0, len(filePaths))
+	if manifest != nil {
+		for _, manifestResource := range manifest.Resources {
+			entry, ok := entryMap[manifestResource.Path]
+			if !ok {
+				return nil, fmt.Errorf("file(%s) in manifest not found: %w", manifestResource.Path, service.ErrInvalidImport)
+			}
+			delete(entryMap, manifestResource.Path)
+
+			if len([]rune(manifestResource.Name)) > maxResourceNameLength {
+				return nil, fmt.Errorf("too long name(%s): %w", manifestResource.Path, service.ErrInvalidImport)
+			}
+			if len(manifestResource.Name) != 0 {
+				entry.name = values.NewResourceName(manifestResource.Name)
+			}
+
+			if len([]rune(manifestResource.Comment)) > maxResourceCommentLength {
+				return nil, fmt.Errorf("too long comment(%s): %w", manifestResource.Path, service.ErrInvalidImport)
+			}
+			entry.comment = values.NewResourceComment(manifestResource.Comment)
+
+			entry.isMain = len(mainResource) == 0 && manifestResource.IsMain
+
+			entries = append(entries, entry)
+		}
+	}
+
+	// fs.WalkDirはパスの順に呼ぶ
+	for _, filePath := range filePaths {
+		entry, ok := entryMap[filePath]
+		if ok {
+			entries = append(entries, entry)
+		}
+	}
+
+	mainCount := 0
+	for _, entry := range entries {
+		if len(mainResource) != 0 && entry.path == mainResource {
+			entry.isMain = true
+		}
+
+		if entry.isMain {
+			mainCount++
+		}
+	}
+
+	switch {
+	case mainCount == 0 && len(mainResource) != 0:
+		return nil, fmt.Errorf("main resource(%s) not found: %w", mainResource, service.ErrInvalidImport)
+	case mainCount == 0:
+		entries[0].isMain = true
+	case mainCount > 1:
+		return nil, fmt.Errorf("multiple main resources: %w", service.ErrInvalidImport)
+	}
+
+	return entries, nil
+}
+
+// resourceNameFromPath 拡張子を除いたファイル名を、リソース名の長さに収まるように切り詰める
+func resourceNameFromPath(filePath string) string {
+	name := path.Base(filePath)
+	if extension := path.Ext(name); len(extension) != len(name) {
+		name = strings.TrimSuffix(name, extension)
+	}
+
+	runes := []rune(name)
+	if len(runes) > maxResourceNameLength {
+		runes = runes[:maxResourceNameLength]
+	}
+
+	return string(runes)
+}
@@ -0,0 +1,383 @@
+package v1
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/mazrean/Quantainer/domain"
+	"github.com/mazrean/Quantainer/domain/values"
+	"github.com/mazrean/Quantainer/pkg/common"
+	mockRepository "github.com/mazrean/Quantainer/repository/mock"
+	"github.com/mazrean/Quantainer/service"
+	mockService "github.com/mazrean/Quantainer/service/mock"
+	"github.com/mazrean/Quantainer/storage"
+	"github.com/mazrean/Quantainer/storage/local"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestImportGroup(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	rootPath := "./importer_test"
+	defer func() {
+		err := os.RemoveAll(rootPath)
+		if err != nil {
+			t.Fatalf("failed to remove directory: %v", err)
+		}
+	}()
+
+	fileStorage, err := local.NewFile(local.NewDirectoryManager(common.FilePath(rootPath)))
+	if err != nil {
+		t.Fatalf("failed to create storage: %v", err)
+	}
+
+	mockDB := mockRepository.NewMockDB(ctrl)
+	mockFileService := mockService.NewMockFile(ctrl)
+	mockResourceService := mockService.NewMockResource(ctrl)
+	mockGroupService := mockService.NewMockGroup(ctrl)
+
+	importer := NewImporter(
+		mockDB,
+		fileStorage,
+		mockFileService,
+		mockResourceService,
+		mockGroupService,
+	)
+
+	mockDB.
+		EXPECT().
+		Transaction(gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, _ interface{}, fn func(ctx context.Context) error) error {
+			return fn(ctx)
+		}).
+		AnyTimes()
+
+	manifest := `{
+  "name": "manifest group",
+  "description": "manifest description",
+  "resources": [
+    {"path": "b.png", "name": "cover", "comment": "comment of cover", "isMain": true},
+    {"path": "a/c.jpg", "name": ""}
+  ]
+}`
+
+	type test struct {
+		description     string
+		params          *service.ImportGroupParams
+		fsys            fstest.MapFS
+		createGroupErr  error
+		paths           []string
+		names           []string
+		comments        []string
+		mainPath        string
+		groupName       string
+		isErr           bool
+		err             error
+		executeGroup    bool
+		uploadFileCount int
+	}
+
+	testCases := []test{
+		{
+			description: "manifest.jsonがないのでパスの順に作成される",
+			params: &service.ImportGroupParams{
+				Name:        values.NewGroupName("group"),
+				Description: values.NewGroupDescription("description"),
+			},
+			fsys: fstest.MapFS{
+				"b.png":            {Data: []byte("b")},
+				"a/c.jpg":          {Data: []byte("c")},
+				".DS_Store":        {Data: []byte("hidden")},
+				"__MACOSX/._b.png": {Data: []byte("meta")},
+			},
+			paths:           []string{"a/c.jpg", "b.png"},
+			names:           []string{"c", "b"},
+			comments:        []string{"", ""},
+			mainPath:        "a/c.jpg",
+			groupName:       "group",
+			executeGroup:    true,
+			uploadFileCount: 2,
+		},
+		{
+			description: "manifest.jsonの順に作成され、残りはパスの順に作成される",
+			params:      &service.ImportGroupParams{},
+			fsys: fstest.MapFS{
+				"manifest.json": {Data: []byte(manifest)},
+				"a/c.jpg":       {Data: []byte("c")},
+				"b.png":         {Data: []byte("b")},
+				"d.txt":         {Data: []byte("d")},
+			},
+			paths:           []string{"b.png", "a/c.jpg", "d.txt"},
+			names:           []string{"cover", "c", "d"},
+			comments:        []string{"comment of cover", "", ""},
+			mainPath:        "b.png",
+			groupName:       "manifest group",
+			executeGroup:    true,
+			uploadFileCount: 3,
+		},
+		{
+			description: "メインのリソースの指定がmanifest.jsonより優先される",
+			params: &service.ImportGroupParams{
+				MainResource: "d.txt",
+			},
+			fsys: fstest.MapFS{
+				"manifest.json": {Data: []byte(manifest)},
+				"a/c.jpg":       {Data: []byte("c")},
+				"b.png":         {Data: []byte("b")},
+				"d.txt":         {Data: []byte("d")},
+			},
+			paths:           []string{"b.png", "a/c.jpg", "d.txt"},
+			names:           []string{"cover", "c", "d"},
+			comments:        []string{"comment of cover", "", ""},
+			mainPath:        "d.txt",
+			groupName:       "manifest group",
+			executeGroup:    true,
+			uploadFileCount: 3,
+		},
+		{
+			description: "メインのリソースが存在しないのでErrInvalidImport",
+			params: &service.ImportGroupParams{
+				Name:         values.NewGroupName("group"),
+				MainResource: "e.txt",
+			},
+			fsys: fstest.MapFS{
+				"d.txt": {Data: []byte("d")},
+			},
+			isErr: true,
+			err:   service.ErrInvalidImport,
+		},
+		{
+			description: "manifest.jsonのファイルが存在しないのでErrInvalidImport",
+			params:      &service.ImportGroupParams{},
+			fsys: fstest.MapFS{
+				"manifest.json": {Data: []byte(manifest)},
+				"b.png":         {Data: []byte("b")},
+			},
+			isErr: true,
+			err:   service.ErrInvalidImport,
+		},
+		{
+			description: "ファイルがないのでErrInvalidImport",
+			params: &service.ImportGroupParams{
+				Name: values.NewGroupName("group"),
+			},
+			fsys: fstest.MapFS{
+				".DS_Store": {Data: []byte("hidden")},
+			},
+			isErr: true,
+			err:   service.ErrInvalidImport,
+		},
+		{
+			description: "グループ名がないのでErrInvalidImport",
+			params:      &service.ImportGroupParams{},
+			fsys: fstest.MapFS{
+				"d.txt": {Data: []byte("d")},
+			},
+			isErr: true,
+			err:   service.ErrInvalidImport,
+		},
+		{
+			description: "グループの作成に失敗したので保存したファイルが削除される",
+			params: &service.ImportGroupParams{
+				Name: values.NewGroupName("group"),
+			},
+			// 同じ内容のファイルが残っていると実体が削除されないので、他と異なる内容にする
+			fsys: fstest.MapFS{
+				"b.png": {Data: []byte("rollback b")},
+				"d.txt": {Data: []byte("rollback d")},
+			},
+			createGroupErr:  service.ErrNoResource,
+			paths:           []string{"b.png", "d.txt"},
+			names:           []string{"b", "d"},
+			comments:        []string{"", ""},
+			mainPath:        "b.png",
+			groupName:       "group",
+			executeGroup:    true,
+			uploadFileCount: 2,
+			isErr:           true,
+			err:             service.ErrNoResource,
+		},
+	}
+
+	for _, testCase := range testCases {
+		testCase := testCase
+		t.Run(testCase.description, func(t *testing.T) {
+			session := domain.NewOIDCSession("accessToken", time.Now().Add(time.Hour))
+
+			var files, renditions []*domain.File
+			mockFileService.
+				EXPECT().
+				Upload(gomock.Any(), session, gomock.Any(), gomock.Any()).
+				DoAndReturn(func(ctx context.Context, _ *domain.OIDCSession, name values.FileName, reader io.Reader) (*service.FileInfo, error) {
+					// ディレクトリを除いたファイル名を記録する
+					assert.NotContains(t, string(name), "/")
+
+					file := domain.NewFile(
+						values.NewFileID(),
+						values.FileTypeOther,
+						values.FileHash{},
+						0,
+						nil,
+						time.Now(),
+					)
+					err := fileStorage.SaveFile(ctx, file, reader)
+					if err != nil {
+						return nil, err
+					}
+					files = append(files, file)
+
+					// レンディションはトランザクションの外で保存されるので、取り消された場合は削除される必要がある
+					rendition := domain.NewFile(
+						values.NewFileID(),
+						values.FileTypePng,
+						values.FileHash{},
+						0,
+						nil,
+						time.Now(),
+					)
+					err = fileStorage.SaveFile(ctx, rendition, strings.NewReader("rendition of "+uuid.UUID(file.GetID()).String()))
+					if err != nil {
+						return nil, err
+					}
+					renditions = append(renditions, rendition)
+
+					return &service.FileInfo{
+						File:       file,
+						Renditions: []*domain.File{rendition},
+					}, nil
+				}).
+				Times(testCase.uploadFileCount)
+
+			var resourceIDs []values.ResourceID
+			var mainResourceID values.ResourceID
+			for i := range testCase.paths {
+				i := i
+				mockResourceService.
+					EXPECT().
+					CreateResource(
+						gomock.Any(),
+						session,
+						gomock.Any(),
+						values.NewResourceName(testCase.names[i]),
+						values.ResourceTypeOther,
+						values.NewResourceComment(testCase.comments[i]),
+					).
+					DoAndReturn(func(_ context.Context, _ *domain.OIDCSession, fileID values.FileID, name values.ResourceName, resourceType values.ResourceType, comment values.ResourceComment) (*service.ResourceInfo, error) {
+						resource := domain.NewResource(
+							values.NewResourceID(),
+							name,
+							resourceType,
+							comment,
+							time.Now(),
+						)
+						resourceIDs = append(resourceIDs, resource.GetID())
+						if testCase.paths[i] == testCase.mainPath {
+							mainResourceID = resource.GetID()
+						}
+
+						return &service.ResourceInfo{
+							Resource: resource,
+							File:     files[len(files)-1],
+						}, nil
+					})
+			}
+
+			var group *service.GroupDetail
+			if testCase.executeGroup {
+				mockGroupService.
+					EXPECT().
+					CreateGroup(
+						gomock.Any(),
+						session,
+						values.NewGroupName(testCase.groupName),
+						gomock.Any(),
+						gomock.Any(),
+						gomock.Any(),
+						gomock.Any(),
+						gomock.Any(),
+						gomock.Any(),
+					).
+					DoAndReturn(func(_ context.Context, _ *domain.OIDCSession, name values.GroupName, groupType values.GroupType, description values.GroupDescription, readPermission values.GroupReadPermission, writePermission values.GroupWritePermission, mainResource values.ResourceID, resources []values.ResourceID) (*service.GroupDetail, error) {
+						assert.Equal(t, mainResourceID, mainResource)
+						assert.Equal(t, resourceIDs, resources)
+
+						if testCase.createGroupErr != nil {
+							return nil, testCase.createGroupErr
+						}
+
+						group = &service.GroupDetail{
+							Group: domain.NewGroup(
+								values.NewGroupID(),
+								name,
+								groupType,
+								description,
+								readPermission,
+								writePermission,
+								time.Now(),
+							),
+						}
+
+						return group, nil
+					})
+			}
+
+			result, err := importer.ImportGroup(ctx, session, testCase.params, testCase.fsys)
+
+			if testCase.isErr {
+				if testCase.err == nil {
+					assert.Error(t, err)
+				} else if !errors.Is(err, testCase.err) {
+					t.Errorf("error must be %v, but actual is %v", testCase.err, err)
+				}
+			} else {
+				assert.NoError(t, err)
+			}
+			if err != nil {
+				// 保存したファイルとレンディションは削除される。
+				// 参照がなくなった実体はGCで削除されるので、猶予を待たずに削除しておく
+				_, err := fileStorage.DeleteUnreferencedObjects(ctx, time.Now().Add(time.Minute))
+				if err != nil {
+					t.Fatalf("failed to delete unreferenced objects: %v", err)
+				}
+
+				for _, file := range append(files, renditions...) {
+					_, err := fileStorage.OpenFile(ctx, file)
+					assert.ErrorIs(t, err, storage.ErrNotFound)
+				}
+
+				return
+			}
+
+			assert.Equal(t, group, result.Group)
+			assert.Len(t, result.Resources, len(testCase.paths))
+
+			for i, file := range files {
+				reader, err := fileStorage.OpenFile(ctx, file)
+				if err != nil {
+					t.Fatalf("failed to open file: %v", err)
+				}
+
+				content, err := io.ReadAll(reader)
+				_ = reader.Close()
+				if err != nil {
+					t.Fatalf("failed to read file: %v", err)
+				}
+
+				assert.Equal(t, string(testCase.fsys[testCase.paths[i]].Data), string(content))
+			}
+		})
+	}
+}
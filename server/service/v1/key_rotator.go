@@ -0,0 +1,112 @@
+package v1
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/google/uuid"
+	"github.com/mazrean/Quantainer/domain"
+	"github.com/mazrean/Quantainer/repository"
+	"github.com/mazrean/Quantainer/service"
+	"github.com/mazrean/Quantainer/storage"
+)
+
+// keyRotationBatchSize 1度にDBから取得するファイルの数
+const keyRotationBatchSize = 100
+
+type KeyRotator struct {
+	fileRepository repository.File
+	fileStorage    storage.File
+	keyRotator     storage.KeyRotator
+}
+
+func NewKeyRotator(
+	fileRepository repository.File,
+	fileStorage storage.File,
+	keyRotator storage.KeyRotator,
+) *KeyRotator {
+	return &KeyRotator{
+		fileRepository: fileRepository,
+		fileStorage:    fileStorage,
+		keyRotator:     keyRotator,
+	}
+}
+
+func (kr *KeyRotator) Rotate(ctx context.Context) (*service.KeyRotationResult, error) {
+	result := &service.KeyRotationResult{
+		Failed: []*service.KeyRotationFailure{},
+	}
+
+	// 現在の鍵で暗号化済みのファイルは飛ばされるので、中断しても途中から再開できる
+	for offset := 0; ; offset += keyRotationBatchSize {
+		files, err := kr.fileRepository.GetFiles(ctx, keyRotationBatchSize, offset)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get files: %w", err)
+		}
+
+		for _, file := range files {
+			err := ctx.Err()
+			if err != nil {
+				return nil, fmt.Errorf("key rotation canceled: %w", err)
+			}
+
+			isRotated, err := kr.rotateFile(ctx, file)
+			if err != nil {
+				log.Printf("error: failed to rotate key of file(%s): %v\n", uuid.UUID(file.GetID()).String(), err)
+				result.Failed = append(result.Failed, &service.KeyRotationFailure{
+					FileID: file.GetID(),
+					Err:    err,
+				})
+				continue
+			}
+
+			if isRotated {
+				result.Rotated++
+			} else {
+				result.Skipped++
+			}
+		}
+
+		if len(files) < keyRotationBatchSize {
+			break
+		}
+	}
+
+	return result, nil
+}
+
+// rotateFile 暗号化し直した内容を保存し、DBのハッシュを更新してから元の内容を削除する。
+// DBを更新できなかった場合は、暗号化し直した内容を削除する。
+func (kr *KeyRotator) rotateFile(ctx context.Context, file *domain.File) (bool, error) {
+	rotatedFile, isRotated, err := kr.keyRotator.RotateKey(ctx, file)
+	if err != nil {
+		return false, fmt.Errorf("failed to rotate key: %w", err)
+	}
+	if !isRotated {
+		return false, nil
+	}
+
+	err = kr.fileRepository.UpdateFileHash(ctx, file.GetID(), rotatedFile.GetHash())
+	if err != nil {
+		deleteErr := kr.fileStorage.DeleteFile(ctx, rotatedFile)
+		if deleteErr != nil {
+			log.Printf("error: failed to delete rotated file: %v\n", deleteErr)
+		}
+	}
+	if errors.Is(err, repository.ErrNoRecordUpdated) {
+		// 途中で完全に削除された場合
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to update file hash: %w", err)
+	}
+
+	err = kr.fileStorage.DeleteFile(ctx, file)
+	if err != nil && !errors.Is(err, storage.ErrNotFound) {
+		return false, fmt.Errorf("failed to delete old file: %w", err)
+	}
+
+	return true, nil
+}
@@ -0,0 +1,188 @@
+package v1
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/mazrean/Quantainer/domain"
+	"github.com/mazrean/Quantainer/domain/values"
+	"github.com/mazrean/Quantainer/pkg/common"
+	"github.com/mazrean/Quantainer/repository"
+	mockRepository "github.com/mazrean/Quantainer/repository/mock"
+	"github.com/mazrean/Quantainer/storage"
+	"github.com/mazrean/Quantainer/storage/encryption"
+	"github.com/mazrean/Quantainer/storage/local"
+)
+
+func TestRotate(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	rootPath := "./key_rotator_test"
+	defer func() {
+		err := os.RemoveAll(rootPath)
+		if err != nil {
+			t.Fatalf("failed to remove directory: %v", err)
+		}
+	}()
+
+	localStorage, err := local.NewFile(local.NewDirectoryManager(common.FilePath(rootPath)))
+	if err != nil {
+		t.Fatalf("failed to create storage: %v", err)
+	}
+
+	oldKey := common.StorageEncryptionKey{ID: "old", Key: bytes.Repeat([]byte{1}, 32)}
+	newKey := common.StorageEncryptionKey{ID: "new", Key: bytes.Repeat([]byte{2}, 32)}
+
+	oldKeyring, err := encryption.NewKeyring(common.StorageEncryptionKeys{oldKey})
+	if err != nil {
+		t.Fatalf("failed to create keyring: %v", err)
+	}
+	keyring, err := encryption.NewKeyring(common.StorageEncryptionKeys{newKey, oldKey})
+	if err != nil {
+		t.Fatalf("failed to create keyring: %v", err)
+	}
+
+	oldStorage := encryption.NewFile(localStorage, oldKeyring)
+	fileStorage := encryption.NewFile(localStorage, keyring)
+
+	mockFileRepository := mockRepository.NewMockFile(ctrl)
+
+	keyRotator := NewKeyRotator(mockFileRepository, fileStorage, fileStorage)
+
+	newFile := func() *domain.File {
+		return domain.NewFile(
+			values.NewFileID(),
+			values.FileTypeOther,
+			values.FileHash{},
+			0,
+			nil,
+			time.Now(),
+		)
+	}
+
+	saveFile := func(fileStorage storage.File, content string) *domain.File {
+		file := newFile()
+		err := fileStorage.SaveFile(ctx, file, strings.NewReader(content))
+		if err != nil {
+			t.Fatalf("failed to save file: %v", err)
+		}
+
+		return file
+	}
+
+	// 以前の鍵で暗号化されたファイル
+	oldFile := saveFile(oldStorage, "old")
+	// 暗号化前に保存されたファイル
+	plainFile := saveFile(localStorage, "plain")
+	// 現在の鍵で暗号化されたファイル
+	currentFile := saveFile(fileStorage, "current")
+	// 切り替え中に完全に削除されたファイル
+	deletedFile := saveFile(oldStorage, "deleted")
+	// ストレージに存在しないファイル
+	missingFile := newFile()
+
+	files := []*domain.File{oldFile, plainFile, currentFile, deletedFile, missingFile}
+
+	rotatedHashes := map[values.FileID]values.FileHash{}
+	mockFileRepository.
+		EXPECT().
+		GetFiles(ctx, keyRotationBatchSize, 0).
+		Return(files, nil)
+	for _, file := range []*domain.File{oldFile, plainFile} {
+		file := file
+		mockFileRepository.
+			EXPECT().
+			UpdateFileHash(ctx, file.GetID(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, _ values.FileID, hash values.FileHash) error {
+				rotatedHashes[file.GetID()] = hash
+				return nil
+			})
+	}
+	mockFileRepository.
+		EXPECT().
+		UpdateFileHash(ctx, deletedFile.GetID(), gomock.Any()).
+		Return(repository.ErrNoRecordUpdated)
+
+	result, err := keyRotator.Rotate(ctx)
+	if err != nil {
+		t.Fatalf("failed to rotate: %v", err)
+	}
+
+	if result.Rotated != 2 {
+		t.Errorf("rotated must be 2, but actual is %d", result.Rotated)
+	}
+	if result.Skipped != 2 {
+		t.Errorf("skipped must be 2, but actual is %d", result.Skipped)
+	}
+	if len(result.Failed) != 1 || result.Failed[0].FileID != missingFile.GetID() {
+		t.Errorf("failed must be only missing file, but actual is %+v", result.Failed)
+	}
+
+	// 以前の鍵を使わずに読み込める
+	newKeyring, err := encryption.NewKeyring(common.StorageEncryptionKeys{newKey})
+	if err != nil {
+		t.Fatalf("failed to create keyring: %v", err)
+	}
+	newStorage := encryption.NewFile(localStorage, newKeyring)
+
+	for file, expected := range map[*domain.File]string{oldFile: "old", plainFile: "plain"} {
+		rotatedFile := domain.NewFile(
+			file.GetID(),
+			file.GetType(),
+			rotatedHashes[file.GetID()],
+			file.GetSize(),
+			nil,
+			file.GetCreatedAt(),
+		)
+
+		reader, err := newStorage.OpenFile(ctx, rotatedFile)
+		if err != nil {
+			t.Fatalf("failed to open rotated file: %v", err)
+		}
+
+		actual, err := io.ReadAll(reader)
+		reader.Close()
+		if err != nil {
+			t.Fatalf("failed to read rotated file: %v", err)
+		}
+
+		if string(actual) != expected {
+			t.Errorf("content must be %s, but actual is %s", expected, string(actual))
+		}
+
+		// 元の内容は削除される。参照がなくなった実体はGCで削除されるので、猶予を待たずに削除しておく
+		_, err = localStorage.DeleteUnreferencedObjects(ctx, time.Now().Add(time.Minute))
+		if err != nil {
+			t.Fatalf("failed to delete unreferenced objects: %v", err)
+		}
+
+		_, err = localStorage.OpenFile(ctx, file)
+		if !errors.Is(err, storage.ErrNotFound) {
+			t.Errorf("old file must be deleted, but actual error is %v", err)
+		}
+	}
+
+	// 削除されたファイルは、暗号化し直した内容も残さない
+	err = localStorage.ListFiles(ctx, func(entry *storage.FileEntry) error {
+		if entry.FileID == deletedFile.GetID() && entry.Hash != deletedFile.GetHash() {
+			t.Errorf("rotated content of deleted file must be deleted")
+		}
+
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("failed to list files: %v", err)
+	}
+}
@@ -0,0 +1,200 @@
+package v1
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+
+	"github.com/google/uuid"
+	"github.com/mazrean/Quantainer/domain"
+	"github.com/mazrean/Quantainer/domain/values"
+	"github.com/mazrean/Quantainer/pkg/imagehash"
+	"github.com/mazrean/Quantainer/repository"
+	"github.com/mazrean/Quantainer/service"
+	"github.com/mazrean/Quantainer/storage"
+)
+
+// metadataBackfillBatchSize 1度にDBから取得するファイルの数
+const metadataBackfillBatchSize = 100
+
+type MetadataBackfiller struct {
+	fileRepository repository.File
+	fileStorage    storage.File
+}
+
+func NewMetadataBackfiller(
+	fileRepository repository.File,
+	fileStorage storage.File,
+) *MetadataBackfiller {
+	return &MetadataBackfiller{
+		fileRepository: fileRepository,
+		fileStorage:    fileStorage,
+	}
+}
+
+func (mb *MetadataBackfiller) Backfill(ctx context.Context, force bool) (*service.MetadataBackfillResult, error) {
+	result := &service.MetadataBackfillResult{
+		Failed: []*service.MetadataBackfillFailure{},
+	}
+
+	for offset := 0; ; offset += metadataBackfillBatchSize {
+		files, err := mb.fileRepository.GetFiles(ctx, metadataBackfillBatchSize, offset)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get files: %w", err)
+		}
+
+		for _, file := range files {
+			err := ctx.Err()
+			if err != nil {
+				return nil, fmt.Errorf("backfill canceled: %w", err)
+			}
+
+			if !force && !needsMetadataBackfill(file) {
+				result.Skipped++
+				continue
+			}
+
+			isUpdated, err := mb.backfillFile(ctx, file, force)
+			if err != nil {
+				log.Printf("error: failed to backfill file(%s): %v\n", uuid.UUID(file.GetID()).String(), err)
+				result.Failed = append(result.Failed, &service.MetadataBackfillFailure{
+					FileID: file.GetID(),
+					Err:    err,
+				})
+				continue
+			}
+
+			if isUpdated {
+				result.Updated++
+			} else {
+				result.Skipped++
+			}
+		}
+
+		if len(files) < metadataBackfillBatchSize {
+			break
+		}
+	}
+
+	return result, nil
+}
+
+// needsMetadataBackfill バイト数の記録前に保存されたファイルはバイト数が0になっている
+func needsMetadataBackfill(file *domain.File) bool {
+	return file.GetSize() == 0 ||
+		(file.GetType().IsAnalyzableImage() && file.GetImageMetadata() == nil) ||
+		needsPerceptualHashBackfill(file)
+}
+
+// needsPerceptualHashBackfill 知覚ハッシュの記録前に保存された画像は知覚ハッシュがnilになっている
+func needsPerceptualHashBackfill(file *domain.File) bool {
+	return file.GetType().IsPerceptualHashSupported() && file.GetPerceptualHash() == nil
+}
+
+// backfillFile バイト数と画像のメタデータ、知覚ハッシュを記録する。
+// 記録済みの内容から変化がなければ更新せず、falseを返す。
+func (mb *MetadataBackfiller) backfillFile(ctx context.Context, file *domain.File, force bool) (bool, error) {
+	isUpdated, err := mb.backfillMetadata(ctx, file, force)
+	if err != nil {
+		return false, fmt.Errorf("failed to backfill metadata: %w", err)
+	}
+
+	if (force && file.GetType().IsPerceptualHashSupported()) || needsPerceptualHashBackfill(file) {
+		isHashUpdated, err := mb.backfillPerceptualHash(ctx, file)
+		if err != nil {
+			return false, fmt.Errorf("failed to backfill perceptual hash: %w", err)
+		}
+
+		isUpdated = isUpdated || isHashUpdated
+	}
+
+	return isUpdated, nil
+}
+
+// backfillMetadata ストレージの内容を読んでバイト数と画像のメタデータを記録する。
+// 記録済みの内容から変化がなければ更新せず、falseを返す。
+func (mb *MetadataBackfiller) backfillMetadata(ctx context.Context, file *domain.File, force bool) (bool, error) {
+	reader, err := mb.fileStorage.OpenFile(ctx, file)
+	if err != nil {
+		return false, fmt.Errorf("failed to open file: %w", err)
+	}
+	defer reader.Close()
+
+	hashReader := storage.NewHashReader(reader)
+
+	imageMetadata, err := analyzeImage(file.GetType(), hashReader)
+	if err != nil {
+		return false, fmt.Errorf("failed to analyze image: %w", err)
+	}
+
+	// バイト数を求めるため、解析に使わなかった残りも読み切る
+	_, err = io.Copy(io.Discard, hashReader)
+	if err != nil {
+		return false, fmt.Errorf("failed to read file: %w", err)
+	}
+	size := hashReader.Size()
+
+	// 画像として解析できなかった場合、記録済みのメタデータは残す
+	if imageMetadata == nil {
+		imageMetadata = file.GetImageMetadata()
+	}
+
+	if !force && size == file.GetSize() && imageMetadata == file.GetImageMetadata() {
+		return false, nil
+	}
+
+	file.SetSize(size)
+	file.SetImageMetadata(imageMetadata)
+
+	err = mb.fileRepository.UpdateFileMetadata(ctx, file)
+	if errors.Is(err, repository.ErrNoRecordUpdated) {
+		// 記録し直しても内容が同じ場合や、途中で完全に削除された場合
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to update file metadata: %w", err)
+	}
+
+	return true, nil
+}
+
+// backfillPerceptualHash ストレージの画像を展開して知覚ハッシュを記録する。
+// 記録済みの知覚ハッシュから変化がなければ更新せず、falseを返す。
+func (mb *MetadataBackfiller) backfillPerceptualHash(ctx context.Context, file *domain.File) (bool, error) {
+	release, err := imageDecodeSlots.acquire(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to acquire image decode slot: %w", err)
+	}
+	defer release()
+
+	reader, err := mb.fileStorage.OpenFile(ctx, file)
+	if err != nil {
+		return false, fmt.Errorf("failed to open file: %w", err)
+	}
+	defer reader.Close()
+
+	img, err := decodeImage(file.GetType(), reader)
+	if err != nil {
+		return false, fmt.Errorf("failed to decode image: %w", err)
+	}
+
+	perceptualHash := values.PerceptualHash(imagehash.DHash(img))
+	if recorded := file.GetPerceptualHash(); recorded != nil && *recorded == perceptualHash {
+		return false, nil
+	}
+
+	err = mb.fileRepository.UpdateFilePerceptualHash(ctx, file.GetID(), perceptualHash)
+	if errors.Is(err, repository.ErrNoRecordUpdated) {
+		// 途中で完全に削除された場合
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to update perceptual hash: %w", err)
+	}
+
+	file.SetPerceptualHash(&perceptualHash)
+
+	return true, nil
+}
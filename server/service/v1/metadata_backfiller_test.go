@@ -0,0 +1,138 @@
+package v1
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/png"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/mazrean/Quantainer/domain"
+	"github.com/mazrean/Quantainer/domain/values"
+	"github.com/mazrean/Quantainer/pkg/common"
+	mockRepository "github.com/mazrean/Quantainer/repository/mock"
+	"github.com/mazrean/Quantainer/storage/local"
+)
+
+func TestBackfill(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	rootPath := "./metadata_backfiller_test"
+	defer func() {
+		err := os.RemoveAll(rootPath)
+		if err != nil {
+			t.Fatalf("failed to remove directory: %v", err)
+		}
+	}()
+
+	fileStorage, err := local.NewFile(local.NewDirectoryManager(common.FilePath(rootPath)))
+	if err != nil {
+		t.Fatalf("failed to create storage: %v", err)
+	}
+
+	mockFileRepository := mockRepository.NewMockFile(ctrl)
+
+	metadataBackfiller := NewMetadataBackfiller(mockFileRepository, fileStorage)
+
+	newFile := func(fileType values.FileType) *domain.File {
+		return domain.NewFile(
+			values.NewFileID(),
+			fileType,
+			values.FileHash{},
+			0,
+			nil,
+			time.Now(),
+		)
+	}
+
+	pngBuf := bytes.NewBuffer(nil)
+	err = png.Encode(pngBuf, image.NewGray(image.Rect(0, 0, 4, 3)))
+	if err != nil {
+		t.Fatalf("failed to encode png: %v", err)
+	}
+	pngSize := int64(pngBuf.Len())
+
+	// バイト数とメタデータの記録前に保存された画像
+	imageFile := newFile(values.FileTypePng)
+	err = fileStorage.SaveFile(ctx, imageFile, bytes.NewReader(pngBuf.Bytes()))
+	if err != nil {
+		t.Fatalf("failed to save file: %v", err)
+	}
+	imageFile.SetSize(0)
+
+	// バイト数が記録済みの画像以外のファイル
+	recordedFile := newFile(values.FileTypeOther)
+	err = fileStorage.SaveFile(ctx, recordedFile, strings.NewReader("recorded"))
+	if err != nil {
+		t.Fatalf("failed to save file: %v", err)
+	}
+
+	// ストレージに存在しないファイル
+	missingFile := newFile(values.FileTypeOther)
+
+	files := []*domain.File{imageFile, recordedFile, missingFile}
+
+	mockFileRepository.
+		EXPECT().
+		GetFiles(ctx, metadataBackfillBatchSize, 0).
+		Return(files, nil)
+	mockFileRepository.
+		EXPECT().
+		UpdateFileMetadata(ctx, imageFile).
+		Return(nil)
+	// 一色の画像は隣り合う画素の明るさが同じなので、知覚ハッシュは0になる
+	mockFileRepository.
+		EXPECT().
+		UpdateFilePerceptualHash(ctx, imageFile.GetID(), values.PerceptualHash(0)).
+		Return(nil)
+
+	result, err := metadataBackfiller.Backfill(ctx, false)
+	if err != nil {
+		t.Fatalf("failed to backfill: %v", err)
+	}
+
+	if result.Updated != 1 {
+		t.Errorf("updated must be 1, but actual is %d", result.Updated)
+	}
+	if result.Skipped != 1 {
+		t.Errorf("skipped must be 1, but actual is %d", result.Skipped)
+	}
+	if len(result.Failed) != 1 || result.Failed[0].FileID != missingFile.GetID() {
+		t.Errorf("failed must be only missing file, but actual is %+v", result.Failed)
+	}
+
+	if imageFile.GetSize() != pngSize {
+		t.Errorf("size must be %d, but actual is %d", pngSize, imageFile.GetSize())
+	}
+
+	imageMetadata := imageFile.GetImageMetadata()
+	if imageMetadata == nil {
+		t.Fatalf("image metadata must be set")
+	}
+	if imageMetadata.GetWidth() != 4 || imageMetadata.GetHeight() != 3 {
+		t.Errorf("size must be 4x3, but actual is %dx%d", imageMetadata.GetWidth(), imageMetadata.GetHeight())
+	}
+	if imageMetadata.GetFrames() != 1 {
+		t.Errorf("frames must be 1, but actual is %d", imageMetadata.GetFrames())
+	}
+	if imageMetadata.GetColorSpace() != values.ColorSpaceGray {
+		t.Errorf("color space must be gray, but actual is %d", imageMetadata.GetColorSpace())
+	}
+	if imageMetadata.GetImageOrientation() != values.ImageOrientationLandscape {
+		t.Errorf("orientation must be landscape, but actual is %d", imageMetadata.GetImageOrientation())
+	}
+
+	perceptualHash := imageFile.GetPerceptualHash()
+	if perceptualHash == nil || *perceptualHash != 0 {
+		t.Errorf("perceptual hash must be 0, but actual is %v", perceptualHash)
+	}
+}
@@ -0,0 +1,268 @@
+package v1
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/mazrean/Quantainer/domain"
+	"github.com/mazrean/Quantainer/domain/values"
+	"github.com/mazrean/Quantainer/pkg/common"
+	"github.com/mazrean/Quantainer/repository"
+	"github.com/mazrean/Quantainer/service"
+)
+
+type Quota struct {
+	quotaRepository repository.Quota
+	fileRepository  repository.File
+	userUtils       *UserUtils
+	quotaUtils      *QuotaUtils
+}
+
+func NewQuota(
+	quotaRepository repository.Quota,
+	fileRepository repository.File,
+	userUtils *UserUtils,
+	quotaUtils *QuotaUtils,
+) *Quota {
+	return &Quota{
+		quotaRepository: quotaRepository,
+		fileRepository:  fileRepository,
+		userUtils:       userUtils,
+		quotaUtils:      quotaUtils,
+	}
+}
+
+func (q *Quota) GetMyUsage(ctx context.Context, session *domain.OIDCSession) (*service.UsageInfo, error) {
+	user, err := q.userUtils.getMe(ctx, session)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	limit, err := q.quotaUtils.getUserQuota(ctx, user.GetID())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user quota: %w", err)
+	}
+
+	usage, err := q.fileRepository.GetUserUsage(ctx, user.GetID())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user usage: %w", err)
+	}
+
+	return &service.UsageInfo{
+		Usage: usage,
+		Limit: limit,
+	}, nil
+}
+
+func (q *Quota) SetUserQuota(
+	ctx context.Context,
+	session *domain.OIDCSession,
+	userName values.TraPMemberName,
+	limit *service.QuotaLimit,
+) error {
+	if limit.MaxBytes < 0 || limit.MaxFiles < 0 {
+		return service.ErrInvalidFormat
+	}
+
+	user, err := q.getUserByAdministrator(ctx, session, userName)
+	if err != nil {
+		return err
+	}
+
+	err = q.quotaRepository.SaveUserQuota(ctx, user.GetID(), limit)
+	if err != nil {
+		return fmt.Errorf("failed to save user quota: %w", err)
+	}
+
+	return nil
+}
+
+func (q *Quota) ResetUserQuota(ctx context.Context, session *domain.OIDCSession, userName values.TraPMemberName) error {
+	user, err := q.getUserByAdministrator(ctx, session, userName)
+	if err != nil {
+		return err
+	}
+
+	err = q.quotaRepository.DeleteUserQuota(ctx, user.GetID())
+	if err != nil && !errors.Is(err, repository.ErrNoRecordDeleted) {
+		return fmt.Errorf("failed to delete user quota: %w", err)
+	}
+
+	return nil
+}
+
+// getUserByAdministrator 管理者であることを確認し、上限を変更するユーザーを取得する
+func (q *Quota) getUserByAdministrator(
+	ctx context.Context,
+	session *domain.OIDCSession,
+	userName values.TraPMemberName,
+) (*service.UserInfo, error) {
+	me, err := q.userUtils.getMe(ctx, session)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	if !q.userUtils.isAdministrator(me) {
+		return nil, service.ErrForbidden
+	}
+
+	users, err := q.userUtils.getAllActiveUser(ctx, session)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get users: %w", err)
+	}
+
+	for _, user := range users {
+		if user.GetName() == userName {
+			return user, nil
+		}
+	}
+
+	return nil, service.ErrNoUser
+}
+
+/*
+	QuotaUtils
+	アップロード時の使用量の上限の確認周り。
+*/
+type QuotaUtils struct {
+	quotaRepository  repository.Quota
+	fileRepository   repository.File
+	defaultUserQuota *service.QuotaLimit
+	globalQuota      *service.QuotaLimit
+}
+
+func NewQuotaUtils(
+	quotaRepository repository.Quota,
+	fileRepository repository.File,
+	userMaxBytes common.UserMaxBytes,
+	userMaxFiles common.UserMaxFiles,
+	globalMaxBytes common.GlobalMaxBytes,
+	globalMaxFiles common.GlobalMaxFiles,
+) *QuotaUtils {
+	return &QuotaUtils{
+		quotaRepository: quotaRepository,
+		fileRepository:  fileRepository,
+		defaultUserQuota: &service.QuotaLimit{
+			MaxBytes: int64(userMaxBytes),
+			MaxFiles: int64(userMaxFiles),
+		},
+		globalQuota: &service.QuotaLimit{
+			MaxBytes: int64(globalMaxBytes),
+			MaxFiles: int64(globalMaxFiles),
+		},
+	}
+}
+
+// getUserQuota 管理者に変更されていればその上限、変更されていなければデフォルトの上限を返す
+func (qu *QuotaUtils) getUserQuota(ctx context.Context, userID values.TraPMemberID) (*service.QuotaLimit, error) {
+	limit, err := qu.quotaRepository.GetUserQuota(ctx, userID)
+	if errors.Is(err, repository.ErrRecordNotFound) {
+		return qu.defaultUserQuota, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user quota: %w", err)
+	}
+
+	return limit, nil
+}
+
+// uploadLimit アップロードできるファイルのバイト数の上限
+type uploadLimit struct {
+	// remaining 負の場合は無制限
+	remaining int64
+	// err remainingを超えた場合に返すエラー
+	err error
+}
+
+// getUploadLimit 上限に達している場合はErrQuotaExceededかErrStorageFullを返す。
+// 同時にアップロードされた場合は上限を少し超えることがあるが、その分は許容する。
+func (qu *QuotaUtils) getUploadLimit(ctx context.Context, user *service.UserInfo) (*uploadLimit, error) {
+	limit := &uploadLimit{
+		remaining: -1,
+	}
+
+	if qu.globalQuota.MaxBytes != 0 || qu.globalQuota.MaxFiles != 0 {
+		totalUsage, err := qu.fileRepository.GetTotalUsage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get total usage: %w", err)
+		}
+
+		if qu.globalQuota.MaxFiles != 0 && totalUsage.Files >= qu.globalQuota.MaxFiles {
+			return nil, service.ErrStorageFull
+		}
+
+		if qu.globalQuota.MaxBytes != 0 {
+			remaining := qu.globalQuota.MaxBytes - totalUsage.Bytes
+			if remaining <= 0 {
+				return nil, service.ErrStorageFull
+			}
+
+			limit = &uploadLimit{
+				remaining: remaining,
+				err:       service.ErrStorageFull,
+			}
+		}
+	}
+
+	userQuota, err := qu.getUserQuota(ctx, user.GetID())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user quota: %w", err)
+	}
+
+	if userQuota.MaxBytes != 0 || userQuota.MaxFiles != 0 {
+		userUsage, err := qu.fileRepository.GetUserUsage(ctx, user.GetID())
+		if err != nil {
+			return nil, fmt.Errorf("failed to get user usage: %w", err)
+		}
+
+		if userQuota.MaxFiles != 0 && userUsage.Files >= userQuota.MaxFiles {
+			return nil, service.ErrQuotaExceeded
+		}
+
+		if userQuota.MaxBytes != 0 {
+			remaining := userQuota.MaxBytes - userUsage.Bytes
+			if remaining <= 0 {
+				return nil, service.ErrQuotaExceeded
+			}
+
+			if limit.remaining < 0 || remaining < limit.remaining {
+				limit = &uploadLimit{
+					remaining: remaining,
+					err:       service.ErrQuotaExceeded,
+				}
+			}
+		}
+	}
+
+	return limit, nil
+}
+
+var errUploadLimitExceeded = errors.New("upload limit exceeded")
+
+// limitedReader limitバイトを超えて読み込むとエラーを返すio.Reader。
+// ストレージによってはreaderのエラーをそのまま返さないので、超えたかはexceededで確認する。
+type limitedReader struct {
+	reader    io.Reader
+	remaining int64
+	exceeded  bool
+}
+
+func newLimitedReader(reader io.Reader, limit int64) *limitedReader {
+	return &limitedReader{
+		reader:    reader,
+		remaining: limit,
+	}
+}
+
+func (lr *limitedReader) Read(p []byte) (int, error) {
+	n, err := lr.reader.Read(p)
+	lr.remaining -= int64(n)
+	if lr.remaining < 0 {
+		lr.exceeded = true
+		return 0, errUploadLimitExceeded
+	}
+
+	return n, err
+}
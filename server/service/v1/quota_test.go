@@ -0,0 +1,222 @@
+package v1
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/mazrean/Quantainer/domain/values"
+	"github.com/mazrean/Quantainer/pkg/common"
+	"github.com/mazrean/Quantainer/repository"
+	mockRepository "github.com/mazrean/Quantainer/repository/mock"
+	"github.com/mazrean/Quantainer/service"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetUploadLimit(t *testing.T) {
+	t.Parallel()
+
+	type test struct {
+		description    string
+		defaultQuota   *service.QuotaLimit
+		globalQuota    *service.QuotaLimit
+		userQuota      *service.QuotaLimit
+		userUsage      *service.StorageUsage
+		totalUsage     *service.StorageUsage
+		isErr          bool
+		err            error
+		remaining      int64
+		remainingError error
+	}
+
+	testCases := []test{
+		{
+			description:  "上限がないので無制限",
+			defaultQuota: &service.QuotaLimit{},
+			globalQuota:  &service.QuotaLimit{},
+			remaining:    -1,
+		},
+		{
+			description:    "ユーザーの上限までアップロードできる",
+			defaultQuota:   &service.QuotaLimit{MaxBytes: 100, MaxFiles: 10},
+			globalQuota:    &service.QuotaLimit{},
+			userUsage:      &service.StorageUsage{Bytes: 30, Files: 9},
+			remaining:      70,
+			remainingError: service.ErrQuotaExceeded,
+		},
+		{
+			description:  "ユーザーのファイル数が上限に達しているのでエラー",
+			defaultQuota: &service.QuotaLimit{MaxBytes: 100, MaxFiles: 10},
+			globalQuota:  &service.QuotaLimit{},
+			userUsage:    &service.StorageUsage{Bytes: 30, Files: 10},
+			isErr:        true,
+			err:          service.ErrQuotaExceeded,
+		},
+		{
+			description:  "ユーザーのバイト数が上限に達しているのでエラー",
+			defaultQuota: &service.QuotaLimit{MaxBytes: 100},
+			globalQuota:  &service.QuotaLimit{},
+			userUsage:    &service.StorageUsage{Bytes: 100, Files: 1},
+			isErr:        true,
+			err:          service.ErrQuotaExceeded,
+		},
+		{
+			description:  "管理者が変更した上限が優先される",
+			defaultQuota: &service.QuotaLimit{MaxBytes: 100},
+			globalQuota:  &service.QuotaLimit{},
+			userQuota:    &service.QuotaLimit{},
+			remaining:    -1,
+		},
+		{
+			description:  "全体のファイル数が上限に達しているのでエラー",
+			defaultQuota: &service.QuotaLimit{},
+			globalQuota:  &service.QuotaLimit{MaxFiles: 100},
+			totalUsage:   &service.StorageUsage{Bytes: 1000, Files: 100},
+			isErr:        true,
+			err:          service.ErrStorageFull,
+		},
+		{
+			description:    "全体の残りの方が少ないので全体の上限が使われる",
+			defaultQuota:   &service.QuotaLimit{MaxBytes: 100},
+			globalQuota:    &service.QuotaLimit{MaxBytes: 1000},
+			userUsage:      &service.StorageUsage{Bytes: 0, Files: 0},
+			totalUsage:     &service.StorageUsage{Bytes: 950, Files: 10},
+			remaining:      50,
+			remainingError: service.ErrStorageFull,
+		},
+		{
+			description:    "ユーザーの残りの方が少ないのでユーザーの上限が使われる",
+			defaultQuota:   &service.QuotaLimit{MaxBytes: 100},
+			globalQuota:    &service.QuotaLimit{MaxBytes: 1000},
+			userUsage:      &service.StorageUsage{Bytes: 80, Files: 1},
+			totalUsage:     &service.StorageUsage{Bytes: 500, Files: 10},
+			remaining:      20,
+			remainingError: service.ErrQuotaExceeded,
+		},
+	}
+
+	for _, testCase := range testCases {
+		testCase := testCase
+		t.Run(testCase.description, func(t *testing.T) {
+			t.Parallel()
+
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockQuotaRepository := mockRepository.NewMockQuota(ctrl)
+			mockFileRepository := mockRepository.NewMockFile(ctrl)
+
+			quotaUtils := NewQuotaUtils(
+				mockQuotaRepository,
+				mockFileRepository,
+				common.UserMaxBytes(testCase.defaultQuota.MaxBytes),
+				common.UserMaxFiles(testCase.defaultQuota.MaxFiles),
+				common.GlobalMaxBytes(testCase.globalQuota.MaxBytes),
+				common.GlobalMaxFiles(testCase.globalQuota.MaxFiles),
+			)
+
+			user := service.NewUserInfo(
+				values.NewTrapMemberID(uuid.New()),
+				values.NewTrapMemberName("mazrean"),
+				values.TrapMemberStatusActive,
+			)
+
+			ctx := context.Background()
+
+			if testCase.userQuota != nil {
+				mockQuotaRepository.
+					EXPECT().
+					GetUserQuota(ctx, user.GetID()).
+					Return(testCase.userQuota, nil).
+					AnyTimes()
+			} else {
+				mockQuotaRepository.
+					EXPECT().
+					GetUserQuota(ctx, user.GetID()).
+					Return(nil, repository.ErrRecordNotFound).
+					AnyTimes()
+			}
+
+			if testCase.userUsage != nil {
+				mockFileRepository.
+					EXPECT().
+					GetUserUsage(ctx, user.GetID()).
+					Return(testCase.userUsage, nil)
+			}
+			if testCase.totalUsage != nil {
+				mockFileRepository.
+					EXPECT().
+					GetTotalUsage(ctx).
+					Return(testCase.totalUsage, nil)
+			}
+
+			limit, err := quotaUtils.getUploadLimit(ctx, user)
+
+			if testCase.isErr {
+				if testCase.err == nil {
+					assert.Error(t, err)
+				} else if !errors.Is(err, testCase.err) {
+					t.Errorf("error must be %v, but actual is %v", testCase.err, err)
+				}
+				return
+			}
+			assert.NoError(t, err)
+
+			assert.Equal(t, testCase.remaining, limit.remaining)
+			assert.Equal(t, testCase.remainingError, limit.err)
+		})
+	}
+}
+
+func TestLimitedReader(t *testing.T) {
+	t.Parallel()
+
+	type test struct {
+		description string
+		content     string
+		limit       int64
+		isExceeded  bool
+	}
+
+	testCases := []test{
+		{
+			description: "上限より小さいので読み込める",
+			content:     "abc",
+			limit:       4,
+		},
+		{
+			description: "上限と同じ大きさなので読み込める",
+			content:     "abcd",
+			limit:       4,
+		},
+		{
+			description: "上限を超えるのでエラー",
+			content:     "abcde",
+			limit:       4,
+			isExceeded:  true,
+		},
+	}
+
+	for _, testCase := range testCases {
+		testCase := testCase
+		t.Run(testCase.description, func(t *testing.T) {
+			t.Parallel()
+
+			reader := newLimitedReader(strings.NewReader(testCase.content), testCase.limit)
+
+			content, err := io.ReadAll(reader)
+
+			assert.Equal(t, testCase.isExceeded, reader.exceeded)
+			if testCase.isExceeded {
+				assert.ErrorIs(t, err, errUploadLimitExceeded)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, testCase.content, string(content))
+		})
+	}
+}
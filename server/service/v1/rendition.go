@@ -0,0 +1,268 @@
+package v1
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/mazrean/Quantainer/domain"
+	"github.com/mazrean/Quantainer/domain/values"
+	"github.com/mazrean/Quantainer/service"
+	"golang.org/x/image/draw"
+	"golang.org/x/image/webp"
+)
+
+const (
+	// maxRenditionSourcePixels 巨大な画像の展開でメモリを使い果たさないための上限。RGBAで展開しても約160MBに収まる
+	maxRenditionSourcePixels = 40_000_000
+	// maxConcurrentImageDecodes 展開した画像を同時に保持できる数
+	maxConcurrentImageDecodes = 2
+	renditionJpegQuality      = 85
+)
+
+var errTooLargeImage = errors.New("too large image")
+
+// imageDecodeLimiter 展開した画像を同時に保持する数を制限する
+type imageDecodeLimiter struct {
+	slots chan struct{}
+}
+
+func newImageDecodeLimiter(limit int) *imageDecodeLimiter {
+	return &imageDecodeLimiter{
+		slots: make(chan struct{}, limit),
+	}
+}
+
+// acquire 空きができるまで待つ。展開した画像を使い終わったら、返り値の関数を呼んで解放する。
+func (idl *imageDecodeLimiter) acquire(ctx context.Context) (func(), error) {
+	select {
+	case idl.slots <- struct{}{}:
+		return func() {
+			<-idl.slots
+		}, nil
+	case <-ctx.Done():
+		return nil, fmt.Errorf("failed to wait for image decode: %w", ctx.Err())
+	}
+}
+
+// imageDecodeSlots 画像の展開はリクエストごとに大量のメモリを使うので、プロセス全体で同時に展開する数を制限する
+var imageDecodeSlots = newImageDecodeLimiter(maxConcurrentImageDecodes)
+
+// decodeStoredImage 元のファイルをストレージから読み出して展開する
+func (f *File) decodeStoredImage(ctx context.Context, file *domain.File) (image.Image, error) {
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(f.fileStorage.GetFile(ctx, file, pw))
+	}()
+	defer pr.Close()
+
+	img, err := decodeImage(file.GetType(), pr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode image: %w", err)
+	}
+
+	return img, nil
+}
+
+// processStoredImage 保存した画像を展開し、知覚ハッシュとレンディションを保存する。
+// 知覚ハッシュとレンディションは元のファイルがあれば後から作り直せるので、失敗してもログに残すのみとし、
+// 保存できたレンディションを返す。
+func (f *File) processStoredImage(ctx context.Context, user *service.UserInfo, file *domain.File) []*domain.File {
+	release, err := imageDecodeSlots.acquire(ctx)
+	if err != nil {
+		log.Printf("error: failed to acquire image decode slot: %v\n", err)
+		return nil
+	}
+	defer release()
+
+	img, err := f.decodeStoredImage(ctx, file)
+	if err != nil {
+		log.Printf("error: failed to decode image: %v\n", err)
+		return nil
+	}
+
+	err = f.savePerceptualHash(ctx, file, img)
+	if err != nil {
+		log.Printf("error: failed to save perceptual hash: %v\n", err)
+	}
+
+	renditions, err := f.createRenditions(ctx, user, file, img)
+	if err != nil {
+		log.Printf("error: failed to create renditions: %v\n", err)
+	}
+
+	return renditions
+}
+
+// createRenditions 展開した元の画像から、RenditionSizesの各サイズのレンディションを保存する。
+// 元の画像の長辺がサイズ以下の場合はレンディションを作らず、元のファイルをそのまま使う。
+// 途中で失敗した場合も、それまでに保存したレンディションを返す。
+func (f *File) createRenditions(ctx context.Context, user *service.UserInfo, file *domain.File, img image.Image) ([]*domain.File, error) {
+	renditions := make([]*domain.File, 0, len(values.RenditionSizes))
+	for _, size := range values.RenditionSizes {
+		resized, ok := resizeImage(img, size)
+		if !ok {
+			continue
+		}
+
+		buf := bytes.NewBuffer(nil)
+		fileType, err := encodeRendition(buf, resized)
+		if err != nil {
+			return renditions, fmt.Errorf("failed to encode rendition: %w", err)
+		}
+
+		rendition := domain.NewFile(
+			values.NewFileID(),
+			fileType,
+			values.FileHash{},
+			0,
+			nil,
+			time.Now(),
+		)
+		rendition.SetOriginal(renditionFileName(file, fileType), 0)
+
+		err = f.dbRepository.Transaction(ctx, nil, func(ctx context.Context) error {
+			err := f.fileStorage.SaveFile(ctx, rendition, buf)
+			if err != nil {
+				return fmt.Errorf("failed to save file: %w", err)
+			}
+
+			err = f.fileRepository.SaveFile(ctx, user, rendition)
+			if err != nil {
+				return fmt.Errorf("failed to save file: %w", err)
+			}
+
+			err = f.renditionRepository.SaveRendition(ctx, file.GetID(), size, rendition)
+			if err != nil {
+				return fmt.Errorf("failed to save rendition: %w", err)
+			}
+
+			return nil
+		})
+		if err != nil {
+			return renditions, fmt.Errorf("failed in transaction: %w", err)
+		}
+
+		renditions = append(renditions, rendition)
+	}
+
+	return renditions, nil
+}
+
+// decodeImage 画像を展開する。
+// GIFは1フレーム目のみを展開するので、アニメーションGIFのレンディションは静止画になる。
+func decodeImage(fileType values.FileType, reader io.Reader) (image.Image, error) {
+	var (
+		decodeConfig func(io.Reader) (image.Config, error)
+		decode       func(io.Reader) (image.Image, error)
+	)
+	switch fileType {
+	case values.FileTypeJpeg:
+		decodeConfig = jpeg.DecodeConfig
+		decode = jpeg.Decode
+	case values.FileTypePng:
+		decodeConfig = png.DecodeConfig
+		decode = png.Decode
+	case values.FileTypeWebP:
+		decodeConfig = webp.DecodeConfig
+		decode = webp.Decode
+	case values.FileTypeGif:
+		decodeConfig = gif.DecodeConfig
+		decode = gif.Decode
+	default:
+		return nil, fmt.Errorf("unsupported file type: %d", fileType)
+	}
+
+	// 展開前にサイズを確認するため、ヘッダー部分を読んだ分を記録しておき、展開時に読み直す
+	head := bytes.NewBuffer(nil)
+	config, err := decodeConfig(io.TeeReader(reader, head))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode config: %w", err)
+	}
+
+	if config.Width*config.Height > maxRenditionSourcePixels {
+		return nil, errTooLargeImage
+	}
+
+	img, err := decode(io.MultiReader(head, reader))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode: %w", err)
+	}
+
+	return img, nil
+}
+
+// resizeImage 長辺がsizeになるよう縦横比を保って縮小する。
+// 縮小の必要がない場合はfalseを返す。
+func resizeImage(img image.Image, size values.RenditionSize) (image.Image, bool) {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width <= int(size) && height <= int(size) {
+		return nil, false
+	}
+
+	var dstWidth, dstHeight int
+	if width >= height {
+		dstWidth = int(size)
+		dstHeight = height * int(size) / width
+	} else {
+		dstHeight = int(size)
+		dstWidth = width * int(size) / height
+	}
+	if dstWidth < 1 {
+		dstWidth = 1
+	}
+	if dstHeight < 1 {
+		dstHeight = 1
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, dstWidth, dstHeight))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, bounds, draw.Src, nil)
+
+	return dst, true
+}
+
+// renditionFileName 元のファイル名の拡張子をレンディションの形式のものに置き換える。
+// 元のファイル名がわからない場合は空文字列
+func renditionFileName(file *domain.File, fileType values.FileType) values.FileName {
+	name := string(file.GetOriginalName())
+	if len(name) == 0 {
+		return ""
+	}
+
+	if len(file.GetExtension()) != 0 {
+		name = name[:strings.LastIndex(name, ".")]
+	}
+
+	return values.NewFileName(name + fileType.DefaultExtension())
+}
+
+// encodeRendition 透過のない画像はJPEG、透過のある画像はPNGで書き込む。
+// webpencは可逆圧縮のみで、写真ではJPEGの数倍、透過のある画像でもPNGより大きくなることが多いので、
+// サムネイルを小さくする目的に合わずWebPにはしない。
+func encodeRendition(writer io.Writer, img image.Image) (values.FileType, error) {
+	if opaque, ok := img.(interface{ Opaque() bool }); ok && opaque.Opaque() {
+		err := jpeg.Encode(writer, img, &jpeg.Options{Quality: renditionJpegQuality})
+		if err != nil {
+			return 0, fmt.Errorf("failed to encode jpeg: %w", err)
+		}
+
+		return values.FileTypeJpeg, nil
+	}
+
+	err := png.Encode(writer, img)
+	if err != nil {
+		return 0, fmt.Errorf("failed to encode png: %w", err)
+	}
+
+	return values.FileTypePng, nil
+}
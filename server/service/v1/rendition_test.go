@@ -0,0 +1,326 @@
+package v1
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/png"
+	"testing"
+	"time"
+
+	"github.com/mazrean/Quantainer/domain"
+	"github.com/mazrean/Quantainer/domain/values"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResizeImage(t *testing.T) {
+	t.Parallel()
+
+	type test struct {
+		description string
+		width       int
+		height      int
+		size        values.RenditionSize
+		isResized   bool
+		dstWidth    int
+		dstHeight   int
+	}
+
+	testCases := []test{
+		{
+			description: "横長なので横がsizeになる",
+			width:       2048,
+			height:      1024,
+			size:        values.RenditionSizeSmall,
+			isResized:   true,
+			dstWidth:    256,
+			dstHeight:   128,
+		},
+		{
+			description: "縦長なので縦がsizeになる",
+			width:       1000,
+			height:      2000,
+			size:        values.RenditionSizeLarge,
+			isResized:   true,
+			dstWidth:    512,
+			dstHeight:   1024,
+		},
+		{
+			description: "極端に細長くても1px以上になる",
+			width:       10000,
+			height:      1,
+			size:        values.RenditionSizeSmall,
+			isResized:   true,
+			dstWidth:    256,
+			dstHeight:   1,
+		},
+		{
+			description: "sizeと同じ大きさなので縮小しない",
+			width:       256,
+			height:      100,
+			size:        values.RenditionSizeSmall,
+			isResized:   false,
+		},
+		{
+			description: "sizeより小さいので縮小しない",
+			width:       100,
+			height:      100,
+			size:        values.RenditionSizeLarge,
+			isResized:   false,
+		},
+	}
+
+	for _, testCase := range testCases {
+		testCase := testCase
+		t.Run(testCase.description, func(t *testing.T) {
+			t.Parallel()
+
+			img := image.NewRGBA(image.Rect(0, 0, testCase.width, testCase.height))
+
+			resized, ok := resizeImage(img, testCase.size)
+			assert.Equal(t, testCase.isResized, ok)
+			if !ok {
+				return
+			}
+
+			assert.Equal(t, testCase.dstWidth, resized.Bounds().Dx())
+			assert.Equal(t, testCase.dstHeight, resized.Bounds().Dy())
+		})
+	}
+}
+
+func TestEncodeRendition(t *testing.T) {
+	t.Parallel()
+
+	opaqueImage := image.NewRGBA(image.Rect(0, 0, 10, 10))
+	for y := 0; y < 10; y++ {
+		for x := 0; x < 10; x++ {
+			opaqueImage.Set(x, y, color.RGBA{R: 255, A: 255})
+		}
+	}
+
+	type test struct {
+		description string
+		img         image.Image
+		fileType    values.FileType
+	}
+
+	testCases := []test{
+		{
+			description: "透過のない画像なのでjpeg",
+			img:         opaqueImage,
+			fileType:    values.FileTypeJpeg,
+		},
+		{
+			description: "透過のある画像なのでpng",
+			img:         image.NewRGBA(image.Rect(0, 0, 10, 10)),
+			fileType:    values.FileTypePng,
+		},
+	}
+
+	for _, testCase := range testCases {
+		testCase := testCase
+		t.Run(testCase.description, func(t *testing.T) {
+			t.Parallel()
+
+			buf := bytes.NewBuffer(nil)
+			fileType, err := encodeRendition(buf, testCase.img)
+			assert.NoError(t, err)
+			assert.Equal(t, testCase.fileType, fileType)
+
+			fileType, _, _, err = detectFileType(buf)
+			assert.NoError(t, err)
+			assert.Equal(t, testCase.fileType, fileType)
+		})
+	}
+}
+
+func TestDecodeImage(t *testing.T) {
+	t.Parallel()
+
+	pngBuf := bytes.NewBuffer(nil)
+	err := png.Encode(pngBuf, image.NewRGBA(image.Rect(0, 0, 20, 10)))
+	if err != nil {
+		t.Fatalf("failed to encode png: %v", err)
+	}
+
+	palette := color.Palette{color.Black, color.White}
+	gifBuf := bytes.NewBuffer(nil)
+	err = gif.EncodeAll(gifBuf, &gif.GIF{
+		Image: []*image.Paletted{
+			image.NewPaletted(image.Rect(0, 0, 30, 10), palette),
+			image.NewPaletted(image.Rect(0, 0, 30, 10), palette),
+		},
+		Delay: []int{10, 10},
+	})
+	if err != nil {
+		t.Fatalf("failed to encode gif: %v", err)
+	}
+
+	// 展開前に大きさで弾かれることを確かめるため、ヘッダーのみのpngにする
+	largePNGBuf := bytes.NewBuffer([]byte("\x89PNG\r\n\x1a\n"))
+	ihdr := make([]byte, 13)
+	binary.BigEndian.PutUint32(ihdr[0:], 8000)
+	binary.BigEndian.PutUint32(ihdr[4:], 8000)
+	ihdr[8] = 8
+	ihdr[9] = 6
+	chunkHeader := make([]byte, 8)
+	binary.BigEndian.PutUint32(chunkHeader, uint32(len(ihdr)))
+	copy(chunkHeader[4:], "IHDR")
+	crc := make([]byte, 4)
+	binary.BigEndian.PutUint32(crc, crc32.ChecksumIEEE(append(chunkHeader[4:], ihdr...)))
+	largePNGBuf.Write(chunkHeader)
+	largePNGBuf.Write(ihdr)
+	largePNGBuf.Write(crc)
+
+	type test struct {
+		description string
+		fileType    values.FileType
+		content     []byte
+		width       int
+		height      int
+		isErr       bool
+		err         error
+	}
+
+	testCases := []test{
+		{
+			description: "pngを展開できる",
+			fileType:    values.FileTypePng,
+			content:     pngBuf.Bytes(),
+			width:       20,
+			height:      10,
+		},
+		{
+			description: "アニメーションgifは1フレーム目を展開する",
+			fileType:    values.FileTypeGif,
+			content:     gifBuf.Bytes(),
+			width:       30,
+			height:      10,
+		},
+		{
+			description: "種類と内容が一致しないのでエラー",
+			fileType:    values.FileTypeJpeg,
+			content:     pngBuf.Bytes(),
+			isErr:       true,
+		},
+		{
+			description: "svgは展開できないのでエラー",
+			fileType:    values.FileTypeSvg,
+			content:     []byte("<svg></svg>"),
+			isErr:       true,
+		},
+		{
+			description: "画素数が上限を超えるのでerrTooLargeImage",
+			fileType:    values.FileTypePng,
+			content:     largePNGBuf.Bytes(),
+			isErr:       true,
+			err:         errTooLargeImage,
+		},
+	}
+
+	for _, testCase := range testCases {
+		testCase := testCase
+		t.Run(testCase.description, func(t *testing.T) {
+			t.Parallel()
+
+			img, err := decodeImage(testCase.fileType, bytes.NewReader(testCase.content))
+			if testCase.isErr {
+				if testCase.err == nil {
+					assert.Error(t, err)
+				} else {
+					assert.ErrorIs(t, err, testCase.err)
+				}
+				return
+			}
+			assert.NoError(t, err)
+
+			assert.Equal(t, testCase.width, img.Bounds().Dx())
+			assert.Equal(t, testCase.height, img.Bounds().Dy())
+		})
+	}
+}
+
+func TestRenditionFileName(t *testing.T) {
+	t.Parallel()
+
+	type test struct {
+		description string
+		name        values.FileName
+		fileType    values.FileType
+		fileName    values.FileName
+	}
+
+	testCases := []test{
+		{
+			description: "拡張子をレンディションの形式のものに置き換える",
+			name:        "cursor.PNG",
+			fileType:    values.FileTypeJpeg,
+			fileName:    "cursor.jpg",
+		},
+		{
+			description: "拡張子がないので付け加える",
+			name:        "cursor",
+			fileType:    values.FileTypePng,
+			fileName:    "cursor.png",
+		},
+		{
+			description: "最後の拡張子のみ置き換える",
+			name:        "cursor.v2.webp",
+			fileType:    values.FileTypePng,
+			fileName:    "cursor.v2.png",
+		},
+		{
+			description: "元のファイル名がわからないので空文字列",
+			name:        "",
+			fileType:    values.FileTypePng,
+			fileName:    "",
+		},
+	}
+
+	for _, testCase := range testCases {
+		testCase := testCase
+		t.Run(testCase.description, func(t *testing.T) {
+			t.Parallel()
+
+			file := domain.NewFile(
+				values.NewFileID(),
+				values.FileTypePng,
+				values.FileHash{},
+				0,
+				nil,
+				time.Now(),
+			)
+			file.SetOriginal(testCase.name, 0)
+
+			assert.Equal(t, testCase.fileName, renditionFileName(file, testCase.fileType))
+		})
+	}
+}
+
+func TestImageDecodeLimiter(t *testing.T) {
+	t.Parallel()
+
+	limiter := newImageDecodeLimiter(1)
+
+	release, err := limiter.acquire(context.Background())
+	if err != nil {
+		t.Fatalf("failed to acquire: %v", err)
+	}
+
+	// 空きがないので、待っている間にキャンセルされるとエラー
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = limiter.acquire(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	// 解放すると再び取得できる
+	release()
+	release, err = limiter.acquire(context.Background())
+	assert.NoError(t, err)
+	release()
+}
@@ -220,11 +220,16 @@ func (r *Resource) GetResources(ctx context.Context, session *domain.OIDCSession
 	}
 
 	resourceInfos, err := r.resourceRepository.GetResources(ctx, &repository.ResourceSearchParams{
-		ResourceTypes: params.ResourceTypes,
-		Users:         userList,
-		Groups:        groups,
-		Limit:         params.Limit,
-		Offset:        params.Offset,
+		ResourceTypes:     params.ResourceTypes,
+		Users:             userList,
+		Groups:            groups,
+		ImageOrientations: params.ImageOrientations,
+		MinWidth:          params.MinWidth,
+		MinHeight:         params.MinHeight,
+		FileName:          params.FileName,
+		Extensions:        params.Extensions,
+		Limit:             params.Limit,
+		Offset:            params.Offset,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to get resources: %w", err)
@@ -251,3 +256,133 @@ func (r *Resource) GetResources(ctx context.Context, session *domain.OIDCSession
 
 	return resources, nil
 }
+
+func (r *Resource) DeleteResource(ctx context.Context, session *domain.OIDCSession, resourceID values.ResourceID) error {
+	user, err := r.userUtils.getMe(ctx, session)
+	if err != nil {
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+
+	err = r.dbRepository.Transaction(ctx, nil, func(ctx context.Context) error {
+		resourceInfo, err := r.resourceRepository.GetResource(ctx, resourceID)
+		if errors.Is(err, repository.ErrRecordNotFound) {
+			return service.ErrNoResource
+		}
+		if err != nil {
+			return fmt.Errorf("failed to get resource: %w", err)
+		}
+
+		if resourceInfo.Creator != user.GetID() && !r.userUtils.isAdministrator(user) {
+			return service.ErrForbidden
+		}
+
+		// 復元時に同時に削除されたグループを判別できるよう、同じ日時で削除する
+		deletedAt := time.Now()
+
+		err = r.resourceRepository.DeleteResource(ctx, resourceID, deletedAt)
+		if err != nil {
+			return fmt.Errorf("failed to delete resource: %w", err)
+		}
+
+		err = replaceMainResource(ctx, r.resourceRepository, r.groupRepository, resourceID, deletedAt)
+		if err != nil {
+			return fmt.Errorf("failed to replace main resource: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed in transaction: %w", err)
+	}
+
+	return nil
+}
+
+func (r *Resource) RestoreResource(ctx context.Context, session *domain.OIDCSession, resourceID values.ResourceID) error {
+	user, err := r.userUtils.getMe(ctx, session)
+	if err != nil {
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+
+	err = r.dbRepository.Transaction(ctx, nil, func(ctx context.Context) error {
+		resourceInfo, err := r.resourceRepository.GetDeletedResource(ctx, resourceID, repository.LockTypeRecord)
+		if errors.Is(err, repository.ErrRecordNotFound) {
+			return service.ErrNoResource
+		}
+		if err != nil {
+			return fmt.Errorf("failed to get deleted resource: %w", err)
+		}
+
+		if resourceInfo.Creator != user.GetID() && !r.userUtils.isAdministrator(user) {
+			return service.ErrForbidden
+		}
+
+		if time.Since(resourceInfo.DeletedAt) > restorePeriod {
+			return service.ErrRestorePeriodExpired
+		}
+
+		if resourceInfo.FileDeletedAt != nil {
+			return service.ErrFileDeleted
+		}
+
+		err = r.resourceRepository.RestoreResource(ctx, resourceID)
+		if err != nil {
+			return fmt.Errorf("failed to restore resource: %w", err)
+		}
+
+		err = r.groupRepository.RestoreGroupsByMainResource(ctx, resourceID, resourceInfo.DeletedAt)
+		if err != nil {
+			return fmt.Errorf("failed to restore groups: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed in transaction: %w", err)
+	}
+
+	return nil
+}
+
+// replaceMainResource resourceIDをメインのリソースとするグループのメインのリソースを、グループ内の他のリソースに差し替える。
+// 他にリソースがないグループは、リソースの復元時に一緒に復元できるよう、メインのリソースを残したままdeletedAtに論理削除する。
+// resourceIDのリソースを論理削除した後に呼ぶ必要がある。
+func replaceMainResource(
+	ctx context.Context,
+	resourceRepository repository.Resource,
+	groupRepository repository.Group,
+	resourceID values.ResourceID,
+	deletedAt time.Time,
+) error {
+	groups, err := groupRepository.GetGroupsByMainResource(ctx, resourceID)
+	if err != nil {
+		return fmt.Errorf("failed to get groups: %w", err)
+	}
+
+	for _, group := range groups {
+		// 論理削除したリソースは含まれないので、新しいものから1つ取得すれば良い
+		resources, err := resourceRepository.GetResources(ctx, &repository.ResourceSearchParams{
+			Groups: []*domain.Group{group},
+			Limit:  1,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to get resources: %w", err)
+		}
+
+		if len(resources) == 0 {
+			err = groupRepository.DeleteGroup(ctx, group, deletedAt)
+			if err != nil {
+				return fmt.Errorf("failed to delete group: %w", err)
+			}
+
+			continue
+		}
+
+		err = groupRepository.EditGroup(ctx, group, resources[0].Resource.GetID())
+		if err != nil {
+			return fmt.Errorf("failed to edit group: %w", err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,161 @@
+package v1
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	mockAuth "github.com/mazrean/Quantainer/auth/mock"
+	mockCache "github.com/mazrean/Quantainer/cache/mock"
+	"github.com/mazrean/Quantainer/domain"
+	"github.com/mazrean/Quantainer/domain/values"
+	"github.com/mazrean/Quantainer/pkg/common"
+	"github.com/mazrean/Quantainer/repository"
+	mockRepository "github.com/mazrean/Quantainer/repository/mock"
+	"github.com/mazrean/Quantainer/service"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeleteAndRestoreResourceInGroup(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUserCache := mockCache.NewMockUser(ctrl)
+	mockUserAuth := mockAuth.NewMockUser(ctrl)
+	mockDBRepository := mockRepository.NewMockDB(ctrl)
+	mockFileRepository := mockRepository.NewMockFile(ctrl)
+	mockResourceRepository := mockRepository.NewMockResource(ctrl)
+	mockGroupRepository := mockRepository.NewMockGroup(ctrl)
+
+	userUtils := NewUserUtils(mockUserAuth, mockUserCache, common.Administrators{})
+
+	resourceService := NewResource(
+		mockDBRepository,
+		mockFileRepository,
+		mockResourceRepository,
+		mockGroupRepository,
+		userUtils,
+	)
+
+	session := domain.NewOIDCSession(
+		values.NewOIDCAccessToken("access token"),
+		time.Now().Add(time.Hour),
+	)
+	user := service.NewUserInfo(
+		values.NewTrapMemberID(uuid.New()),
+		values.NewTrapMemberName("mazrean"),
+		values.TrapMemberStatusActive,
+	)
+
+	resource := domain.NewResource(
+		values.NewResourceID(),
+		values.NewResourceName("resource"),
+		values.ResourceTypeImage,
+		values.NewResourceComment("comment"),
+		time.Now().Add(-time.Hour),
+	)
+	file := domain.NewFile(
+		values.NewFileID(),
+		values.FileTypePng,
+		values.FileHash{},
+		0,
+		nil,
+		time.Now().Add(-time.Hour),
+	)
+	group := domain.NewGroup(
+		values.NewGroupID(),
+		values.NewGroupName("group"),
+		values.GroupTypeArtBook,
+		values.NewGroupDescription("description"),
+		values.GroupReadPermissionPublic,
+		values.GroupWritePermissionPublic,
+		time.Now().Add(-time.Hour),
+	)
+	resourceInfo := &repository.ResourceInfo{
+		Resource: resource,
+		File:     file,
+		Creator:  user.GetID(),
+	}
+
+	mockUserCache.
+		EXPECT().
+		GetMe(gomock.Any(), gomock.Any()).
+		Return(user, nil).
+		AnyTimes()
+	mockDBRepository.
+		EXPECT().
+		Transaction(gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, _ interface{}, fn func(ctx context.Context) error) error {
+			return fn(ctx)
+		}).
+		AnyTimes()
+
+	// DBの代わりに論理削除した日時を保持する
+	var resourceDeletedAt, groupDeletedAt *time.Time
+
+	mockResourceRepository.
+		EXPECT().
+		GetResource(gomock.Any(), resource.GetID()).
+		Return(resourceInfo, nil)
+	mockResourceRepository.
+		EXPECT().
+		DeleteResource(gomock.Any(), resource.GetID(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, _ values.ResourceID, deletedAt time.Time) error {
+			resourceDeletedAt = &deletedAt
+			return nil
+		})
+	mockGroupRepository.
+		EXPECT().
+		GetGroupsByMainResource(gomock.Any(), resource.GetID()).
+		Return([]*domain.Group{group}, nil)
+	// グループ内の他のリソースはない
+	mockResourceRepository.
+		EXPECT().
+		GetResources(gomock.Any(), gomock.Any()).
+		Return([]*repository.ResourceInfo{}, nil)
+	mockGroupRepository.
+		EXPECT().
+		DeleteGroup(gomock.Any(), group, gomock.Any()).
+		DoAndReturn(func(_ context.Context, _ *domain.Group, deletedAt time.Time) error {
+			groupDeletedAt = &deletedAt
+			return nil
+		})
+
+	err := resourceService.DeleteResource(ctx, session, resource.GetID())
+	if err != nil {
+		t.Fatalf("failed to delete resource: %v", err)
+	}
+
+	if !assert.NotNil(t, resourceDeletedAt) || !assert.NotNil(t, groupDeletedAt) {
+		return
+	}
+	// 復元時に判別できるよう、リソースと同じ日時でグループを削除する
+	assert.Equal(t, *resourceDeletedAt, *groupDeletedAt)
+
+	mockResourceRepository.
+		EXPECT().
+		GetDeletedResource(gomock.Any(), resource.GetID(), repository.LockTypeRecord).
+		Return(&repository.DeletedResourceInfo{
+			ResourceInfo: resourceInfo,
+			DeletedAt:    *resourceDeletedAt,
+		}, nil)
+	mockResourceRepository.
+		EXPECT().
+		RestoreResource(gomock.Any(), resource.GetID()).
+		Return(nil)
+	mockGroupRepository.
+		EXPECT().
+		RestoreGroupsByMainResource(gomock.Any(), resource.GetID(), *groupDeletedAt).
+		Return(nil)
+
+	err = resourceService.RestoreResource(ctx, session, resource.GetID())
+	if err != nil {
+		t.Fatalf("failed to restore resource: %v", err)
+	}
+}
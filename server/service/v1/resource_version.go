@@ -0,0 +1,183 @@
+package v1
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/mazrean/Quantainer/domain"
+	"github.com/mazrean/Quantainer/domain/values"
+	"github.com/mazrean/Quantainer/repository"
+	"github.com/mazrean/Quantainer/service"
+)
+
+func (r *Resource) ReplaceResourceFile(
+	ctx context.Context,
+	session *domain.OIDCSession,
+	resourceID values.ResourceID,
+	fileID values.FileID,
+) (*service.ResourceInfo, error) {
+	user, err := r.userUtils.getMe(ctx, session)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	var (
+		resource *domain.Resource
+		fileInfo *repository.FileWithCreator
+	)
+	err = r.dbRepository.Transaction(ctx, nil, func(ctx context.Context) error {
+		// 同時に差し替えた場合に同じ版を作らないよう、リソースをロックする
+		resources, err := r.resourceRepository.GetResourcesByIDs(ctx, []values.ResourceID{resourceID}, repository.LockTypeRecord)
+		if err != nil {
+			return fmt.Errorf("failed to lock resource: %w", err)
+		}
+		if len(resources) == 0 {
+			return service.ErrNoResource
+		}
+
+		resourceInfo, err := r.resourceRepository.GetResource(ctx, resourceID)
+		if errors.Is(err, repository.ErrRecordNotFound) {
+			return service.ErrNoResource
+		}
+		if err != nil {
+			return fmt.Errorf("failed to get resource: %w", err)
+		}
+
+		// リソースの作成者はファイルの作成者なので、差し替えで作成者が変わらないよう管理者にも許可しない
+		if resourceInfo.Creator != user.GetID() {
+			return service.ErrForbidden
+		}
+
+		fileInfo, err = r.fileRepository.GetFile(ctx, fileID, repository.LockTypeRecord)
+		if errors.Is(err, repository.ErrRecordNotFound) {
+			return service.ErrNoFile
+		}
+		if err != nil {
+			return fmt.Errorf("failed to get file: %w", err)
+		}
+
+		if fileInfo.Creator != user.GetID() {
+			return service.ErrForbidden
+		}
+
+		if !fileInfo.File.GetType().IsValidResourceType(resourceInfo.Resource.GetType()) {
+			return service.ErrInvalidResourceType
+		}
+
+		// ダウンロードできない隔離されたファイルを最新の版にしない
+		if fileInfo.File.IsInfected() {
+			return service.ErrInvalidFormat
+		}
+
+		// 現在のファイルへの差し替えは、中身の変わらない版を作らずにそのまま返す
+		if fileID == resourceInfo.File.GetID() {
+			resource = resourceInfo.Resource
+
+			return nil
+		}
+
+		versions, err := r.resourceRepository.GetResourceVersions(ctx, resourceID)
+		if err != nil {
+			return fmt.Errorf("failed to get resource versions: %w", err)
+		}
+
+		var latestVersion values.ResourceVersion
+		if len(versions) == 0 {
+			// 版の履歴を保存する前に作成されたリソースは、現在のファイルを最初の版として保存する
+			latestVersion = values.FirstResourceVersion
+			err = r.resourceRepository.SaveResourceVersion(
+				ctx,
+				resourceID,
+				resourceInfo.File.GetID(),
+				domain.NewResourceVersion(latestVersion, resourceInfo.Resource.GetCreatedAt()),
+			)
+			if err != nil {
+				return fmt.Errorf("failed to save first resource version: %w", err)
+			}
+		} else {
+			latestVersion = versions[0].GetVersion()
+		}
+
+		err = r.resourceRepository.SaveResourceVersion(
+			ctx,
+			resourceID,
+			fileID,
+			domain.NewResourceVersion(latestVersion.Next(), time.Now()),
+		)
+		if err != nil {
+			return fmt.Errorf("failed to save resource version: %w", err)
+		}
+
+		err = r.resourceRepository.UpdateResourceFile(ctx, resourceID, fileID)
+		if err != nil {
+			return fmt.Errorf("failed to update resource file: %w", err)
+		}
+
+		resource = resourceInfo.Resource
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed in transaction: %w", err)
+	}
+
+	return &service.ResourceInfo{
+		Resource: resource,
+		File:     fileInfo.File,
+		Creator:  user,
+	}, nil
+}
+
+func (r *Resource) GetResourceVersions(ctx context.Context, session *domain.OIDCSession, resourceID values.ResourceID) ([]*service.ResourceVersionInfo, error) {
+	resourceInfo, err := r.resourceRepository.GetResource(ctx, resourceID)
+	if errors.Is(err, repository.ErrRecordNotFound) {
+		return nil, service.ErrNoResource
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get resource: %w", err)
+	}
+
+	versionInfos, err := r.resourceRepository.GetResourceVersions(ctx, resourceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get resource versions: %w", err)
+	}
+
+	if len(versionInfos) == 0 {
+		// 版の履歴を保存する前に作成され、差し替えられていないリソースは現在のファイルのみの版とする
+		versionInfos = []*repository.ResourceVersionInfo{
+			{
+				ResourceVersion: domain.NewResourceVersion(values.FirstResourceVersion, resourceInfo.Resource.GetCreatedAt()),
+				File:            resourceInfo.File,
+				Creator:         resourceInfo.Creator,
+			},
+		}
+	}
+
+	users, err := r.userUtils.getAllActiveUser(ctx, session)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get users: %w", err)
+	}
+
+	userMap := make(map[values.TraPMemberID]*service.UserInfo, len(users))
+	for _, user := range users {
+		userMap[user.GetID()] = user
+	}
+
+	versions := make([]*service.ResourceVersionInfo, 0, len(versionInfos))
+	for _, versionInfo := range versionInfos {
+		user, ok := userMap[versionInfo.Creator]
+		if !ok {
+			return nil, service.ErrNoUser
+		}
+
+		versions = append(versions, &service.ResourceVersionInfo{
+			ResourceVersion: versionInfo.ResourceVersion,
+			File:            versionInfo.File,
+			Creator:         user,
+		})
+	}
+
+	return versions, nil
+}
@@ -0,0 +1,436 @@
+package v1
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	mockAuth "github.com/mazrean/Quantainer/auth/mock"
+	mockCache "github.com/mazrean/Quantainer/cache/mock"
+	"github.com/mazrean/Quantainer/domain"
+	"github.com/mazrean/Quantainer/domain/values"
+	"github.com/mazrean/Quantainer/pkg/common"
+	"github.com/mazrean/Quantainer/repository"
+	mockRepository "github.com/mazrean/Quantainer/repository/mock"
+	"github.com/mazrean/Quantainer/service"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReplaceResourceFile(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	session := domain.NewOIDCSession(
+		values.NewOIDCAccessToken("access token"),
+		time.Now().Add(time.Hour),
+	)
+	user := service.NewUserInfo(
+		values.NewTrapMemberID(uuid.New()),
+		values.NewTrapMemberName("mazrean"),
+		values.TrapMemberStatusActive,
+	)
+	otherUserID := values.NewTrapMemberID(uuid.New())
+
+	resource := domain.NewResource(
+		values.NewResourceID(),
+		values.NewResourceName("resource"),
+		values.ResourceTypeImage,
+		values.NewResourceComment("comment"),
+		time.Now().Add(-time.Hour),
+	)
+	currentFile := domain.NewFile(
+		values.NewFileID(),
+		values.FileTypePng,
+		values.FileHash{},
+		0,
+		nil,
+		time.Now().Add(-time.Hour),
+	)
+	newFile := domain.NewFile(
+		values.NewFileID(),
+		values.FileTypeJpeg,
+		values.FileHash{},
+		0,
+		nil,
+		time.Now(),
+	)
+	musicFile := domain.NewFile(
+		values.NewFileID(),
+		values.FileTypeMp3,
+		values.FileHash{},
+		0,
+		nil,
+		time.Now(),
+	)
+	infectedFile := domain.NewFile(
+		values.NewFileID(),
+		values.FileTypeJpeg,
+		values.FileHash{},
+		0,
+		nil,
+		time.Now(),
+	)
+	infectedFile.SetScan(domain.NewFileScan(
+		values.ScanStatusInfected,
+		values.NewScanSignature("Eicar-Signature"),
+		time.Now(),
+	))
+
+	type test struct {
+		description      string
+		resourceCreator  values.TraPMemberID
+		noResource       bool
+		file             *domain.File
+		fileCreator      values.TraPMemberID
+		getFileErr       error
+		latestVersion    values.ResourceVersion
+		executeSave      bool
+		savedVersions    []values.ResourceVersion
+		savedVersionFile []values.FileID
+		isErr            bool
+		err              error
+	}
+
+	testCases := []test{
+		{
+			description:      "版の履歴があるので次の版として保存する",
+			resourceCreator:  user.GetID(),
+			file:             newFile,
+			fileCreator:      user.GetID(),
+			latestVersion:    2,
+			executeSave:      true,
+			savedVersions:    []values.ResourceVersion{3},
+			savedVersionFile: []values.FileID{newFile.GetID()},
+		},
+		{
+			description:      "版の履歴がないので現在のファイルを最初の版として保存してから差し替える",
+			resourceCreator:  user.GetID(),
+			file:             newFile,
+			fileCreator:      user.GetID(),
+			executeSave:      true,
+			savedVersions:    []values.ResourceVersion{1, 2},
+			savedVersionFile: []values.FileID{currentFile.GetID(), newFile.GetID()},
+		},
+		{
+			description: "リソースが存在しないのでErrNoResource",
+			noResource:  true,
+			isErr:       true,
+			err:         service.ErrNoResource,
+		},
+		{
+			description:     "リソースの作成者でないのでErrForbidden",
+			resourceCreator: otherUserID,
+			isErr:           true,
+			err:             service.ErrForbidden,
+		},
+		{
+			description:     "ファイルが存在しないのでErrNoFile",
+			resourceCreator: user.GetID(),
+			getFileErr:      repository.ErrRecordNotFound,
+			isErr:           true,
+			err:             service.ErrNoFile,
+		},
+		{
+			description:     "ファイルの作成者でないのでErrForbidden",
+			resourceCreator: user.GetID(),
+			file:            newFile,
+			fileCreator:     otherUserID,
+			isErr:           true,
+			err:             service.ErrForbidden,
+		},
+		{
+			description:     "リソースの種類に合わないファイルなのでErrInvalidResourceType",
+			resourceCreator: user.GetID(),
+			file:            musicFile,
+			fileCreator:     user.GetID(),
+			isErr:           true,
+			err:             service.ErrInvalidResourceType,
+		},
+		{
+			description:     "隔離されたファイルなのでErrInvalidFormat",
+			resourceCreator: user.GetID(),
+			file:            infectedFile,
+			fileCreator:     user.GetID(),
+			isErr:           true,
+			err:             service.ErrInvalidFormat,
+		},
+		{
+			description:     "現在のファイルなので版を作らない",
+			resourceCreator: user.GetID(),
+			file:            currentFile,
+			fileCreator:     user.GetID(),
+		},
+		{
+			description:     "GetFileがエラーなのでエラー",
+			resourceCreator: user.GetID(),
+			getFileErr:      errors.New("error"),
+			isErr:           true,
+		},
+	}
+
+	for _, testCase := range testCases {
+		testCase := testCase
+		t.Run(testCase.description, func(t *testing.T) {
+			t.Parallel()
+
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockUserCache := mockCache.NewMockUser(ctrl)
+			mockUserAuth := mockAuth.NewMockUser(ctrl)
+			mockDBRepository := mockRepository.NewMockDB(ctrl)
+			mockFileRepository := mockRepository.NewMockFile(ctrl)
+			mockResourceRepository := mockRepository.NewMockResource(ctrl)
+			mockGroupRepository := mockRepository.NewMockGroup(ctrl)
+
+			userUtils := NewUserUtils(mockUserAuth, mockUserCache, common.Administrators{})
+			resourceService := NewResource(
+				mockDBRepository,
+				mockFileRepository,
+				mockResourceRepository,
+				mockGroupRepository,
+				userUtils,
+			)
+
+			mockUserCache.
+				EXPECT().
+				GetMe(gomock.Any(), gomock.Any()).
+				Return(user, nil)
+			mockDBRepository.
+				EXPECT().
+				Transaction(gomock.Any(), gomock.Any(), gomock.Any()).
+				DoAndReturn(func(ctx context.Context, _ interface{}, fn func(ctx context.Context) error) error {
+					return fn(ctx)
+				})
+
+			if testCase.noResource {
+				mockResourceRepository.
+					EXPECT().
+					GetResourcesByIDs(gomock.Any(), []values.ResourceID{resource.GetID()}, repository.LockTypeRecord).
+					Return([]*domain.Resource{}, nil)
+			} else {
+				mockResourceRepository.
+					EXPECT().
+					GetResourcesByIDs(gomock.Any(), []values.ResourceID{resource.GetID()}, repository.LockTypeRecord).
+					Return([]*domain.Resource{resource}, nil)
+				mockResourceRepository.
+					EXPECT().
+					GetResource(gomock.Any(), resource.GetID()).
+					Return(&repository.ResourceInfo{
+						Resource: resource,
+						File:     currentFile,
+						Creator:  testCase.resourceCreator,
+					}, nil)
+			}
+
+			fileID := newFile.GetID()
+			if testCase.file != nil {
+				fileID = testCase.file.GetID()
+			}
+
+			if !testCase.noResource && testCase.resourceCreator == user.GetID() {
+				if testCase.getFileErr != nil {
+					mockFileRepository.
+						EXPECT().
+						GetFile(gomock.Any(), fileID, repository.LockTypeRecord).
+						Return(nil, testCase.getFileErr)
+				} else {
+					mockFileRepository.
+						EXPECT().
+						GetFile(gomock.Any(), fileID, repository.LockTypeRecord).
+						Return(&repository.FileWithCreator{
+							File:    testCase.file,
+							Creator: testCase.fileCreator,
+						}, nil)
+				}
+			}
+
+			var savedVersions []values.ResourceVersion
+			var savedVersionFile []values.FileID
+			if testCase.executeSave {
+				var versionInfos []*repository.ResourceVersionInfo
+				if testCase.latestVersion != 0 {
+					versionInfos = []*repository.ResourceVersionInfo{
+						{
+							ResourceVersion: domain.NewResourceVersion(testCase.latestVersion, time.Now()),
+							File:            currentFile,
+							Creator:         user.GetID(),
+						},
+					}
+				}
+
+				mockResourceRepository.
+					EXPECT().
+					GetResourceVersions(gomock.Any(), resource.GetID()).
+					Return(versionInfos, nil)
+				mockResourceRepository.
+					EXPECT().
+					SaveResourceVersion(gomock.Any(), resource.GetID(), gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ context.Context, _ values.ResourceID, fileID values.FileID, version *domain.ResourceVersion) error {
+						savedVersions = append(savedVersions, version.GetVersion())
+						savedVersionFile = append(savedVersionFile, fileID)
+						return nil
+					}).
+					Times(len(testCase.savedVersions))
+				mockResourceRepository.
+					EXPECT().
+					UpdateResourceFile(gomock.Any(), resource.GetID(), fileID).
+					Return(nil)
+			}
+
+			resourceInfo, err := resourceService.ReplaceResourceFile(ctx, session, resource.GetID(), fileID)
+
+			if testCase.isErr {
+				if testCase.err == nil {
+					assert.Error(t, err)
+				} else if !errors.Is(err, testCase.err) {
+					t.Errorf("error must be %v, but actual is %v", testCase.err, err)
+				}
+			} else {
+				assert.NoError(t, err)
+			}
+			if err != nil {
+				return
+			}
+
+			assert.Equal(t, testCase.savedVersions, savedVersions)
+			assert.Equal(t, testCase.savedVersionFile, savedVersionFile)
+			assert.Equal(t, resource, resourceInfo.Resource)
+			assert.Equal(t, testCase.file, resourceInfo.File)
+			assert.Equal(t, user, resourceInfo.Creator)
+		})
+	}
+}
+
+func TestGetResourceVersions(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	session := domain.NewOIDCSession(
+		values.NewOIDCAccessToken("access token"),
+		time.Now().Add(time.Hour),
+	)
+	user := service.NewUserInfo(
+		values.NewTrapMemberID(uuid.New()),
+		values.NewTrapMemberName("mazrean"),
+		values.TrapMemberStatusActive,
+	)
+
+	resource := domain.NewResource(
+		values.NewResourceID(),
+		values.NewResourceName("resource"),
+		values.ResourceTypeImage,
+		values.NewResourceComment("comment"),
+		time.Now().Add(-time.Hour),
+	)
+	currentFile := domain.NewFile(
+		values.NewFileID(),
+		values.FileTypePng,
+		values.FileHash{},
+		0,
+		nil,
+		time.Now(),
+	)
+	oldFile := domain.NewFile(
+		values.NewFileID(),
+		values.FileTypePng,
+		values.FileHash{},
+		0,
+		nil,
+		time.Now().Add(-time.Hour),
+	)
+
+	type test struct {
+		description  string
+		versionInfos []*repository.ResourceVersionInfo
+		versions     []values.ResourceVersion
+		files        []*domain.File
+	}
+
+	testCases := []test{
+		{
+			description: "版の履歴をそのまま返す",
+			versionInfos: []*repository.ResourceVersionInfo{
+				{
+					ResourceVersion: domain.NewResourceVersion(2, time.Now()),
+					File:            currentFile,
+					Creator:         user.GetID(),
+				},
+				{
+					ResourceVersion: domain.NewResourceVersion(1, resource.GetCreatedAt()),
+					File:            oldFile,
+					Creator:         user.GetID(),
+				},
+			},
+			versions: []values.ResourceVersion{2, 1},
+			files:    []*domain.File{currentFile, oldFile},
+		},
+		{
+			description:  "版の履歴がないので現在のファイルを最初の版として返す",
+			versionInfos: []*repository.ResourceVersionInfo{},
+			versions:     []values.ResourceVersion{values.FirstResourceVersion},
+			files:        []*domain.File{currentFile},
+		},
+	}
+
+	for _, testCase := range testCases {
+		testCase := testCase
+		t.Run(testCase.description, func(t *testing.T) {
+			t.Parallel()
+
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockUserCache := mockCache.NewMockUser(ctrl)
+			mockUserAuth := mockAuth.NewMockUser(ctrl)
+			mockResourceRepository := mockRepository.NewMockResource(ctrl)
+
+			userUtils := NewUserUtils(mockUserAuth, mockUserCache, common.Administrators{})
+			resourceService := NewResource(
+				mockRepository.NewMockDB(ctrl),
+				mockRepository.NewMockFile(ctrl),
+				mockResourceRepository,
+				mockRepository.NewMockGroup(ctrl),
+				userUtils,
+			)
+
+			mockResourceRepository.
+				EXPECT().
+				GetResource(gomock.Any(), resource.GetID()).
+				Return(&repository.ResourceInfo{
+					Resource: resource,
+					File:     currentFile,
+					Creator:  user.GetID(),
+				}, nil)
+			mockResourceRepository.
+				EXPECT().
+				GetResourceVersions(gomock.Any(), resource.GetID()).
+				Return(testCase.versionInfos, nil)
+			mockUserCache.
+				EXPECT().
+				GetAllActiveUsers(gomock.Any()).
+				Return([]*service.UserInfo{user}, nil)
+
+			versionInfos, err := resourceService.GetResourceVersions(ctx, session, resource.GetID())
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			versions := make([]values.ResourceVersion, 0, len(versionInfos))
+			files := make([]*domain.File, 0, len(versionInfos))
+			for _, versionInfo := range versionInfos {
+				versions = append(versions, versionInfo.GetVersion())
+				files = append(files, versionInfo.File)
+				assert.Equal(t, user, versionInfo.Creator)
+			}
+
+			assert.Equal(t, testCase.versions, versions)
+			assert.Equal(t, testCase.files, files)
+		})
+	}
+}
@@ -0,0 +1,238 @@
+package v1
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mazrean/Quantainer/domain"
+	"github.com/mazrean/Quantainer/domain/values"
+	"github.com/mazrean/Quantainer/repository"
+	"github.com/mazrean/Quantainer/service"
+	"github.com/mazrean/Quantainer/storage"
+)
+
+type Scrubber struct {
+	fileRepository repository.File
+	fileStorage    storage.File
+}
+
+func NewScrubber(fileRepository repository.File, fileStorage storage.File) *Scrubber {
+	return &Scrubber{
+		fileRepository: fileRepository,
+		fileStorage:    fileStorage,
+	}
+}
+
+func (s *Scrubber) Scrub(ctx context.Context, params *service.ScrubParams) (*service.ScrubResult, error) {
+	// DBより後にストレージを確認するので、確認中に追加されたファイルは孤立したファイルに見えうる。
+	// これは猶予期間によって除外する。
+	startedAt := time.Now()
+
+	dbHashes, err := s.getFileHashes(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get files: %w", err)
+	}
+
+	result := &service.ScrubResult{
+		Orphans:        []*service.ScrubOrphan{},
+		Missing:        []values.FileID{},
+		HashMismatches: []*service.ScrubHashMismatch{},
+		Corrupted:      []*service.ScrubHashMismatch{},
+	}
+
+	// 削除はListFilesの走査と競合しないよう、走査後にまとめて行う
+	orphanEntries := []*storage.FileEntry{}
+	seenFileIDs := make(map[values.FileID]struct{}, len(dbHashes))
+	// 同じ内容を共有するファイルを何度も読まないよう、検証結果をハッシュごとに記録する
+	verifiedHashes := map[values.FileHash]values.FileHash{}
+	err = s.fileStorage.ListFiles(ctx, func(entry *storage.FileEntry) error {
+		err := ctx.Err()
+		if err != nil {
+			return err
+		}
+
+		result.Checked++
+
+		dbHash, ok := dbHashes[entry.FileID]
+		if !ok {
+			if startedAt.Sub(entry.ModifiedAt) >= params.GracePeriod {
+				orphanEntries = append(orphanEntries, entry)
+			}
+			return nil
+		}
+		seenFileIDs[entry.FileID] = struct{}{}
+
+		// ハッシュ導入前のファイルは、移行などでDBにのみハッシュが記録されていることがある
+		if !dbHash.IsZero() && !entry.Hash.IsZero() && dbHash != entry.Hash {
+			result.HashMismatches = append(result.HashMismatches, &service.ScrubHashMismatch{
+				FileID:   entry.FileID,
+				Expected: dbHash,
+				Actual:   entry.Hash,
+			})
+		}
+
+		if !params.VerifyContent {
+			return nil
+		}
+
+		expectedHash := entry.Hash
+		if expectedHash.IsZero() {
+			expectedHash = dbHash
+		}
+
+		actualHash, ok := verifiedHashes[entry.Hash]
+		if !ok || entry.Hash.IsZero() {
+			actualHash, err = s.hashContent(ctx, entry)
+			if errors.Is(err, storage.ErrNotFound) {
+				// 参照だけが残り、実体が失われている
+				delete(seenFileIDs, entry.FileID)
+				return nil
+			}
+			if err != nil {
+				return fmt.Errorf("failed to hash content: %w", err)
+			}
+
+			if !entry.Hash.IsZero() {
+				verifiedHashes[entry.Hash] = actualHash
+			}
+		}
+
+		if !expectedHash.IsZero() && actualHash != expectedHash {
+			result.Corrupted = append(result.Corrupted, &service.ScrubHashMismatch{
+				FileID:   entry.FileID,
+				Expected: expectedHash,
+				Actual:   actualHash,
+			})
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list files: %w", err)
+	}
+
+	for fileID := range dbHashes {
+		if _, ok := seenFileIDs[fileID]; !ok {
+			result.Missing = append(result.Missing, fileID)
+		}
+	}
+
+	for _, entry := range orphanEntries {
+		err := ctx.Err()
+		if err != nil {
+			return nil, fmt.Errorf("scrub canceled: %w", err)
+		}
+
+		orphan := &service.ScrubOrphan{
+			FileID: entry.FileID,
+			Hash:   entry.Hash,
+		}
+
+		switch params.OrphanAction {
+		case service.ScrubOrphanActionReport:
+		case service.ScrubOrphanActionDelete:
+			orphan.Err = s.deleteOrphan(ctx, entry)
+		case service.ScrubOrphanActionQuarantine:
+			orphan.Err = s.quarantineOrphan(ctx, entry, params.QuarantineDir)
+		default:
+			return nil, fmt.Errorf("invalid orphan action: %d", params.OrphanAction)
+		}
+		if orphan.Err != nil {
+			log.Printf("error: failed to handle orphan file(%s): %v\n", uuid.UUID(entry.FileID).String(), orphan.Err)
+		}
+
+		result.Orphans = append(result.Orphans, orphan)
+	}
+
+	return result, nil
+}
+
+func (s *Scrubber) getFileHashes(ctx context.Context) (map[values.FileID]values.FileHash, error) {
+	fileHashes := map[values.FileID]values.FileHash{}
+	for offset := 0; ; offset += storageMigrationBatchSize {
+		files, err := s.fileRepository.GetFiles(ctx, storageMigrationBatchSize, offset)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get files: %w", err)
+		}
+
+		for _, file := range files {
+			fileHashes[file.GetID()] = file.GetHash()
+		}
+
+		if len(files) < storageMigrationBatchSize {
+			break
+		}
+	}
+
+	return fileHashes, nil
+}
+
+func (s *Scrubber) hashContent(ctx context.Context, entry *storage.FileEntry) (values.FileHash, error) {
+	reader, err := s.fileStorage.OpenFile(ctx, entryToFile(entry))
+	if err != nil {
+		return values.FileHash{}, fmt.Errorf("failed to open file: %w", err)
+	}
+	defer reader.Close()
+
+	hashReader := storage.NewHashReader(reader)
+	_, err = io.Copy(io.Discard, hashReader)
+	if err != nil {
+		return values.FileHash{}, fmt.Errorf("failed to read file: %w", err)
+	}
+
+	return hashReader.Sum(), nil
+}
+
+func (s *Scrubber) deleteOrphan(ctx context.Context, entry *storage.FileEntry) error {
+	err := s.fileStorage.DeleteFile(ctx, entryToFile(entry))
+	if err != nil {
+		return fmt.Errorf("failed to delete file: %w", err)
+	}
+
+	return nil
+}
+
+// quarantineOrphan 誤検知の場合に復旧できるよう、内容をquarantineDir/<fileID>に書き出してから削除する
+func (s *Scrubber) quarantineOrphan(ctx context.Context, entry *storage.FileEntry, quarantineDir string) error {
+	err := os.MkdirAll(quarantineDir, 0755)
+	if err != nil {
+		return fmt.Errorf("failed to create quarantine directory: %w", err)
+	}
+
+	f, err := os.Create(filepath.Join(quarantineDir, uuid.UUID(entry.FileID).String()))
+	if err != nil {
+		return fmt.Errorf("failed to create quarantine file: %w", err)
+	}
+
+	err = s.fileStorage.GetFile(ctx, entryToFile(entry), f)
+	if err != nil {
+		_ = f.Close()
+		return fmt.Errorf("failed to copy file: %w", err)
+	}
+
+	err = f.Close()
+	if err != nil {
+		return fmt.Errorf("failed to close quarantine file: %w", err)
+	}
+
+	return s.deleteOrphan(ctx, entry)
+}
+
+// entryToFile ストレージの操作に必要なIDとハッシュのみを持つファイルを作る
+func entryToFile(entry *storage.FileEntry) *domain.File {
+	return domain.NewFile(
+		entry.FileID,
+		values.FileTypeOther,
+		entry.Hash,
+		0,
+		nil,
+		entry.ModifiedAt,
+	)
+}
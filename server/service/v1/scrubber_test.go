@@ -0,0 +1,230 @@
+package v1
+
+import (
+	"context"
+	"crypto/sha256"
+	"os"
+	"path"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/mazrean/Quantainer/domain"
+	"github.com/mazrean/Quantainer/domain/values"
+	"github.com/mazrean/Quantainer/pkg/common"
+	mockRepository "github.com/mazrean/Quantainer/repository/mock"
+	"github.com/mazrean/Quantainer/service"
+	"github.com/mazrean/Quantainer/storage"
+	"github.com/mazrean/Quantainer/storage/local"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScrub(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	rootPath := "./scrubber_test"
+	defer func() {
+		err := os.RemoveAll(rootPath)
+		if err != nil {
+			t.Fatalf("failed to remove directory: %v", err)
+		}
+	}()
+
+	type test struct {
+		description     string
+		params          *service.ScrubParams
+		orphanCount     int
+		isOrphanRemoved bool
+		isQuarantined   bool
+		missingCount    int
+		mismatchCount   int
+		corruptedCount  int
+	}
+
+	testCases := []test{
+		{
+			description: "報告のみなので孤立したファイルは残る",
+			params: &service.ScrubParams{
+				OrphanAction: service.ScrubOrphanActionReport,
+			},
+			orphanCount:   1,
+			missingCount:  1,
+			mismatchCount: 1,
+		},
+		{
+			description: "内容を検証するので壊れたファイルも報告される",
+			params: &service.ScrubParams{
+				VerifyContent: true,
+				OrphanAction:  service.ScrubOrphanActionReport,
+			},
+			orphanCount:    1,
+			missingCount:   1,
+			mismatchCount:  1,
+			corruptedCount: 1,
+		},
+		{
+			description: "孤立したファイルは削除される",
+			params: &service.ScrubParams{
+				OrphanAction: service.ScrubOrphanActionDelete,
+			},
+			orphanCount:     1,
+			isOrphanRemoved: true,
+			missingCount:    1,
+			mismatchCount:   1,
+		},
+		{
+			description: "孤立したファイルは隔離される",
+			params: &service.ScrubParams{
+				OrphanAction: service.ScrubOrphanActionQuarantine,
+			},
+			orphanCount:     1,
+			isOrphanRemoved: true,
+			isQuarantined:   true,
+			missingCount:    1,
+			mismatchCount:   1,
+		},
+		{
+			description: "猶予期間内のファイルは孤立したファイルとして扱わない",
+			params: &service.ScrubParams{
+				OrphanAction: service.ScrubOrphanActionDelete,
+				GracePeriod:  time.Hour,
+			},
+			missingCount:  1,
+			mismatchCount: 1,
+		},
+	}
+
+	for _, testCase := range testCases {
+		casePath := path.Join(rootPath, uuid.NewString())
+		testCase := testCase
+		t.Run(testCase.description, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			fileStorage, err := local.NewFile(local.NewDirectoryManager(common.FilePath(path.Join(casePath, "storage"))))
+			if err != nil {
+				t.Fatalf("failed to create storage: %v", err)
+			}
+
+			mockFileRepository := mockRepository.NewMockFile(ctrl)
+
+			scrubber := NewScrubber(mockFileRepository, fileStorage)
+
+			saveFile := func(content string) *domain.File {
+				file := domain.NewFile(
+					values.NewFileID(),
+					values.FileTypeOther,
+					values.FileHash{},
+					0,
+					nil,
+					time.Now(),
+				)
+				err := fileStorage.SaveFile(ctx, file, strings.NewReader(content))
+				if err != nil {
+					t.Fatalf("failed to save file: %v", err)
+				}
+
+				return file
+			}
+
+			okFile := saveFile("ok")
+			orphanFile := saveFile("orphan")
+
+			// DBとストレージでハッシュが異なるファイル
+			mismatchFile := saveFile("mismatch")
+			dbMismatchFile := domain.NewFile(
+				mismatchFile.GetID(),
+				mismatchFile.GetType(),
+				values.FileHash(sha256.Sum256([]byte("other"))),
+				0,
+				nil,
+				mismatchFile.GetCreatedAt(),
+			)
+
+			// 保存後に内容が書き換えられたファイル
+			corruptedFile := saveFile("corrupted")
+			err = os.WriteFile(
+				path.Join(casePath, "storage", "objects", corruptedFile.GetHash().String()),
+				[]byte("broken"),
+				0644,
+			)
+			if err != nil {
+				t.Fatalf("failed to break file: %v", err)
+			}
+
+			missingFile := domain.NewFile(
+				values.NewFileID(),
+				values.FileTypeOther,
+				values.FileHash(sha256.Sum256([]byte("missing"))),
+				0,
+				nil,
+				time.Now(),
+			)
+
+			mockFileRepository.
+				EXPECT().
+				GetFiles(ctx, storageMigrationBatchSize, 0).
+				Return([]*domain.File{okFile, dbMismatchFile, corruptedFile, missingFile}, nil)
+
+			params := *testCase.params
+			params.QuarantineDir = path.Join(casePath, "quarantine")
+
+			result, err := scrubber.Scrub(ctx, &params)
+			if err != nil {
+				t.Fatalf("failed to scrub: %v", err)
+			}
+
+			assert.Equal(t, 4, result.Checked)
+
+			assert.Len(t, result.Orphans, testCase.orphanCount)
+			for _, orphan := range result.Orphans {
+				assert.Equal(t, orphanFile.GetID(), orphan.FileID)
+				assert.NoError(t, orphan.Err)
+			}
+
+			assert.Len(t, result.Missing, testCase.missingCount)
+			for _, fileID := range result.Missing {
+				assert.Equal(t, missingFile.GetID(), fileID)
+			}
+
+			assert.Len(t, result.HashMismatches, testCase.mismatchCount)
+			for _, mismatch := range result.HashMismatches {
+				assert.Equal(t, mismatchFile.GetID(), mismatch.FileID)
+				assert.Equal(t, dbMismatchFile.GetHash(), mismatch.Expected)
+				assert.Equal(t, mismatchFile.GetHash(), mismatch.Actual)
+			}
+
+			assert.Len(t, result.Corrupted, testCase.corruptedCount)
+			for _, corrupted := range result.Corrupted {
+				assert.Equal(t, corruptedFile.GetID(), corrupted.FileID)
+				assert.Equal(t, corruptedFile.GetHash(), corrupted.Expected)
+				assert.Equal(t, values.FileHash(sha256.Sum256([]byte("broken"))), corrupted.Actual)
+			}
+
+			// 参照がなくなった実体はGCで削除されるので、猶予を待たずに削除しておく
+			_, err = fileStorage.DeleteUnreferencedObjects(ctx, time.Now().Add(time.Minute))
+			if err != nil {
+				t.Fatalf("failed to delete unreferenced objects: %v", err)
+			}
+
+			err = fileStorage.GetFile(ctx, orphanFile, &strings.Builder{})
+			if testCase.isOrphanRemoved {
+				assert.ErrorIs(t, err, storage.ErrNotFound)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			content, err := os.ReadFile(path.Join(casePath, "quarantine", uuid.UUID(orphanFile.GetID()).String()))
+			if testCase.isQuarantined {
+				assert.NoError(t, err)
+				assert.Equal(t, "orphan", string(content))
+			} else {
+				assert.ErrorIs(t, err, os.ErrNotExist)
+			}
+		})
+	}
+}
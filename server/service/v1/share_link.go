@@ -0,0 +1,335 @@
+package v1
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mazrean/Quantainer/domain"
+	"github.com/mazrean/Quantainer/domain/values"
+	"github.com/mazrean/Quantainer/pkg/common"
+	"github.com/mazrean/Quantainer/repository"
+	"github.com/mazrean/Quantainer/service"
+)
+
+// shareLinkMaxExpiration 共有リンクの有効期間の上限
+const shareLinkMaxExpiration = 30 * 24 * time.Hour
+
+type ShareLink struct {
+	fileRepository          repository.File
+	resourceRepository      repository.Resource
+	groupRepository         repository.Group
+	administratorRepository repository.Administrator
+	shareLinkRepository     repository.ShareLink
+	userUtils               *UserUtils
+	secret                  []byte
+}
+
+func NewShareLink(
+	fileRepository repository.File,
+	resourceRepository repository.Resource,
+	groupRepository repository.Group,
+	administratorRepository repository.Administrator,
+	shareLinkRepository repository.ShareLink,
+	userUtils *UserUtils,
+	secret common.ShareLinkSecret,
+) *ShareLink {
+	return &ShareLink{
+		fileRepository:          fileRepository,
+		resourceRepository:      resourceRepository,
+		groupRepository:         groupRepository,
+		administratorRepository: administratorRepository,
+		shareLinkRepository:     shareLinkRepository,
+		userUtils:               userUtils,
+		secret:                  []byte(secret),
+	}
+}
+
+func (sl *ShareLink) CreateShareLink(
+	ctx context.Context,
+	session *domain.OIDCSession,
+	targetType values.ShareLinkTargetType,
+	targetID values.ShareLinkTargetID,
+	expiresAt time.Time,
+	maxDownloads values.ShareLinkMaxDownloads,
+) (*service.ShareLinkInfo, error) {
+	user, err := sl.userUtils.getMe(ctx, session)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	err = maxDownloads.Validate()
+	if err != nil {
+		return nil, fmt.Errorf("invalid max downloads: %w", service.ErrInvalidFormat)
+	}
+
+	// トークンには秒単位の有効期限を含めるので、秒未満は切り捨てる
+	now := time.Now()
+	expiresAt = expiresAt.Truncate(time.Second)
+	if !expiresAt.After(now) || expiresAt.After(now.Add(shareLinkMaxExpiration)) {
+		return nil, fmt.Errorf("invalid expires at(%s): %w", expiresAt, service.ErrInvalidFormat)
+	}
+
+	switch targetType {
+	case values.ShareLinkTargetTypeFile:
+		file, err := sl.fileRepository.GetFile(ctx, values.NewFileIDFromUUID(uuid.UUID(targetID)), repository.LockTypeNone)
+		if errors.Is(err, repository.ErrRecordNotFound) {
+			return nil, service.ErrNoFile
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to get file: %w", err)
+		}
+
+		if file.Creator != user.GetID() && !sl.userUtils.isAdministrator(user) {
+			return nil, service.ErrForbidden
+		}
+	case values.ShareLinkTargetTypeResource:
+		resource, err := sl.resourceRepository.GetResource(ctx, values.NewResourceIDFromUUID(uuid.UUID(targetID)))
+		if errors.Is(err, repository.ErrRecordNotFound) {
+			return nil, service.ErrNoResource
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to get resource: %w", err)
+		}
+
+		if resource.Creator != user.GetID() && !sl.userUtils.isAdministrator(user) {
+			return nil, service.ErrForbidden
+		}
+	case values.ShareLinkTargetTypeGroup:
+		groupID := values.NewGroupIDFromUUID(uuid.UUID(targetID))
+		_, err := sl.groupRepository.GetGroup(ctx, groupID, repository.LockTypeNone)
+		if errors.Is(err, repository.ErrRecordNotFound) {
+			return nil, service.ErrNoGroup
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to get group: %w", err)
+		}
+
+		administrators, err := sl.administratorRepository.GetAdministrators(ctx, groupID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get administrators: %w", err)
+		}
+
+		isGroupAdministrator := false
+		for _, administrator := range administrators {
+			if administrator == user.GetID() {
+				isGroupAdministrator = true
+				break
+			}
+		}
+
+		if !isGroupAdministrator && !sl.userUtils.isAdministrator(user) {
+			return nil, service.ErrForbidden
+		}
+	default:
+		return nil, fmt.Errorf("unknown share link target type(%d): %w", targetType, service.ErrInvalidFormat)
+	}
+
+	shareLink := domain.NewShareLink(
+		values.NewShareLinkID(),
+		targetType,
+		targetID,
+		maxDownloads,
+		0,
+		expiresAt,
+		nil,
+		now,
+	)
+
+	err = sl.shareLinkRepository.SaveShareLink(ctx, user.GetID(), shareLink)
+	if err != nil {
+		return nil, fmt.Errorf("failed to save share link: %w", err)
+	}
+
+	return &service.ShareLinkInfo{
+		ShareLink: shareLink,
+		Token:     values.NewShareToken(sl.secret, shareLink.GetID(), shareLink.GetExpiresAt()),
+	}, nil
+}
+
+func (sl *ShareLink) GetMyShareLinks(ctx context.Context, session *domain.OIDCSession) ([]*service.ShareLinkInfo, error) {
+	user, err := sl.userUtils.getMe(ctx, session)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	shareLinks, err := sl.shareLinkRepository.GetShareLinksByCreator(ctx, user.GetID())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get share links: %w", err)
+	}
+
+	shareLinkInfos := make([]*service.ShareLinkInfo, 0, len(shareLinks))
+	for _, shareLink := range shareLinks {
+		shareLinkInfos = append(shareLinkInfos, &service.ShareLinkInfo{
+			ShareLink: shareLink,
+			Token:     values.NewShareToken(sl.secret, shareLink.GetID(), shareLink.GetExpiresAt()),
+		})
+	}
+
+	return shareLinkInfos, nil
+}
+
+func (sl *ShareLink) RevokeShareLink(ctx context.Context, session *domain.OIDCSession, shareLinkID values.ShareLinkID) error {
+	user, err := sl.userUtils.getMe(ctx, session)
+	if err != nil {
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+
+	shareLink, err := sl.shareLinkRepository.GetShareLink(ctx, shareLinkID, repository.LockTypeNone)
+	if errors.Is(err, repository.ErrRecordNotFound) {
+		return service.ErrNoShareLink
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get share link: %w", err)
+	}
+
+	if shareLink.Creator != user.GetID() && !sl.userUtils.isAdministrator(user) {
+		return service.ErrForbidden
+	}
+
+	err = sl.shareLinkRepository.RevokeShareLink(ctx, shareLinkID, time.Now())
+	// 取り消し済みの場合は何もしない
+	if err != nil && !errors.Is(err, repository.ErrNoRecordUpdated) {
+		return fmt.Errorf("failed to revoke share link: %w", err)
+	}
+
+	return nil
+}
+
+func (sl *ShareLink) AuthenticateShareToken(ctx context.Context, token values.ShareToken) (*domain.ShareLink, error) {
+	shareLinkID, expiresAt, err := token.Verify(sl.secret)
+	if err != nil {
+		return nil, service.ErrInvalidShareToken
+	}
+
+	// 有効期限切れの場合はDBを参照しない
+	now := time.Now()
+	if !now.Before(expiresAt) {
+		return nil, service.ErrShareLinkUnavailable
+	}
+
+	shareLink, err := sl.shareLinkRepository.GetShareLink(ctx, shareLinkID, repository.LockTypeNone)
+	if errors.Is(err, repository.ErrRecordNotFound) {
+		return nil, service.ErrInvalidShareToken
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get share link: %w", err)
+	}
+
+	if !shareLink.GetExpiresAt().Equal(expiresAt) {
+		return nil, service.ErrInvalidShareToken
+	}
+
+	if !shareLink.IsAvailable(now) {
+		return nil, service.ErrShareLinkUnavailable
+	}
+
+	return shareLink.ShareLink, nil
+}
+
+func (sl *ShareLink) GetSharedContent(ctx context.Context, shareLink *domain.ShareLink) (*service.SharedContent, error) {
+	targetID := uuid.UUID(shareLink.GetTargetID())
+
+	switch shareLink.GetTargetType() {
+	case values.ShareLinkTargetTypeFile:
+		file, err := sl.fileRepository.GetFile(ctx, values.NewFileIDFromUUID(targetID), repository.LockTypeNone)
+		if errors.Is(err, repository.ErrRecordNotFound) {
+			return nil, service.ErrNoFile
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to get file: %w", err)
+		}
+
+		return &service.SharedContent{
+			ShareLink: shareLink,
+			Files: []*service.SharedFile{
+				{File: file.File},
+			},
+		}, nil
+	case values.ShareLinkTargetTypeResource:
+		resource, err := sl.resourceRepository.GetResource(ctx, values.NewResourceIDFromUUID(targetID))
+		if errors.Is(err, repository.ErrRecordNotFound) {
+			return nil, service.ErrNoResource
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to get resource: %w", err)
+		}
+
+		return &service.SharedContent{
+			ShareLink: shareLink,
+			Files: []*service.SharedFile{
+				{
+					File:     resource.File,
+					Resource: resource.Resource,
+				},
+			},
+		}, nil
+	case values.ShareLinkTargetTypeGroup:
+		group, err := sl.groupRepository.GetGroup(ctx, values.NewGroupIDFromUUID(targetID), repository.LockTypeNone)
+		if errors.Is(err, repository.ErrRecordNotFound) {
+			return nil, service.ErrNoGroup
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to get group: %w", err)
+		}
+
+		resources, err := sl.resourceRepository.GetResources(ctx, &repository.ResourceSearchParams{
+			Groups: []*domain.Group{group.Group},
+			Limit:  -1,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get resources: %w", err)
+		}
+
+		files := make([]*service.SharedFile, 0, len(resources))
+		for _, resource := range resources {
+			files = append(files, &service.SharedFile{
+				File:     resource.File,
+				Resource: resource.Resource,
+			})
+		}
+
+		return &service.SharedContent{
+			ShareLink: shareLink,
+			Group:     group.Group,
+			Files:     files,
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown share link target type: %d", shareLink.GetTargetType())
+	}
+}
+
+func (sl *ShareLink) AccessSharedFile(ctx context.Context, shareLink *domain.ShareLink, fileID values.FileID, isDownload bool) error {
+	content, err := sl.GetSharedContent(ctx, shareLink)
+	if err != nil {
+		return fmt.Errorf("failed to get shared content: %w", err)
+	}
+
+	isShared := false
+	for _, file := range content.Files {
+		if file.GetID() == fileID {
+			isShared = true
+			break
+		}
+	}
+
+	if !isShared {
+		return service.ErrForbidden
+	}
+
+	if !isDownload {
+		return nil
+	}
+
+	err = sl.shareLinkRepository.IncrementShareLinkDownloads(ctx, shareLink.GetID())
+	if errors.Is(err, repository.ErrNoRecordUpdated) {
+		return service.ErrShareLinkUnavailable
+	}
+	if err != nil {
+		return fmt.Errorf("failed to increment share link downloads: %w", err)
+	}
+
+	return nil
+}
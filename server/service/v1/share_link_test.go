@@ -0,0 +1,386 @@
+package v1
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/mazrean/Quantainer/domain"
+	"github.com/mazrean/Quantainer/domain/values"
+	"github.com/mazrean/Quantainer/pkg/common"
+	"github.com/mazrean/Quantainer/repository"
+	mockRepository "github.com/mazrean/Quantainer/repository/mock"
+	"github.com/mazrean/Quantainer/service"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuthenticateShareToken(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockShareLinkRepository := mockRepository.NewMockShareLink(ctrl)
+
+	secret := common.ShareLinkSecret("secret")
+	shareLinkService := NewShareLink(nil, nil, nil, nil, mockShareLinkRepository, nil, secret)
+
+	now := time.Now()
+	expiresAt := now.Add(time.Hour).Truncate(time.Second)
+	revokedAt := now.Add(-time.Minute)
+
+	type test struct {
+		description  string
+		token        func(id values.ShareLinkID) values.ShareToken
+		executeGet   bool
+		getErr       error
+		maxDownloads values.ShareLinkMaxDownloads
+		downloads    int64
+		revokedAt    *time.Time
+		isErr        bool
+		err          error
+	}
+
+	testCases := []test{
+		{
+			description: "有効な共有リンクなのでエラーなし",
+			token: func(id values.ShareLinkID) values.ShareToken {
+				return values.NewShareToken([]byte(secret), id, expiresAt)
+			},
+			executeGet: true,
+		},
+		{
+			description: "ダウンロードの回数が上限未満なのでエラーなし",
+			token: func(id values.ShareLinkID) values.ShareToken {
+				return values.NewShareToken([]byte(secret), id, expiresAt)
+			},
+			executeGet:   true,
+			maxDownloads: 3,
+			downloads:    2,
+		},
+		{
+			description: "署名が異なるのでErrInvalidShareToken",
+			token: func(id values.ShareLinkID) values.ShareToken {
+				return values.NewShareToken([]byte("other secret"), id, expiresAt)
+			},
+			isErr: true,
+			err:   service.ErrInvalidShareToken,
+		},
+		{
+			description: "トークンの有効期限が切れているのでDBを参照せずErrShareLinkUnavailable",
+			token: func(id values.ShareLinkID) values.ShareToken {
+				return values.NewShareToken([]byte(secret), id, now.Add(-time.Second))
+			},
+			isErr: true,
+			err:   service.ErrShareLinkUnavailable,
+		},
+		{
+			description: "共有リンクが存在しないのでErrInvalidShareToken",
+			token: func(id values.ShareLinkID) values.ShareToken {
+				return values.NewShareToken([]byte(secret), id, expiresAt)
+			},
+			executeGet: true,
+			getErr:     repository.ErrRecordNotFound,
+			isErr:      true,
+			err:        service.ErrInvalidShareToken,
+		},
+		{
+			description: "トークンとDBの有効期限が異なるのでErrInvalidShareToken",
+			token: func(id values.ShareLinkID) values.ShareToken {
+				return values.NewShareToken([]byte(secret), id, expiresAt.Add(time.Minute))
+			},
+			executeGet: true,
+			isErr:      true,
+			err:        service.ErrInvalidShareToken,
+		},
+		{
+			description: "取り消されているのでErrShareLinkUnavailable",
+			token: func(id values.ShareLinkID) values.ShareToken {
+				return values.NewShareToken([]byte(secret), id, expiresAt)
+			},
+			executeGet: true,
+			revokedAt:  &revokedAt,
+			isErr:      true,
+			err:        service.ErrShareLinkUnavailable,
+		},
+		{
+			description: "ダウンロードの回数が上限に達しているのでErrShareLinkUnavailable",
+			token: func(id values.ShareLinkID) values.ShareToken {
+				return values.NewShareToken([]byte(secret), id, expiresAt)
+			},
+			executeGet:   true,
+			maxDownloads: 3,
+			downloads:    3,
+			isErr:        true,
+			err:          service.ErrShareLinkUnavailable,
+		},
+		{
+			description: "GetShareLinkがエラーなのでエラー",
+			token: func(id values.ShareLinkID) values.ShareToken {
+				return values.NewShareToken([]byte(secret), id, expiresAt)
+			},
+			executeGet: true,
+			getErr:     errors.New("error"),
+			isErr:      true,
+		},
+	}
+
+	for _, testCase := range testCases {
+		testCase := testCase
+
+		t.Run(testCase.description, func(t *testing.T) {
+			t.Parallel()
+
+			shareLink := domain.NewShareLink(
+				values.NewShareLinkID(),
+				values.ShareLinkTargetTypeFile,
+				values.NewShareLinkTargetID(uuid.New()),
+				testCase.maxDownloads,
+				testCase.downloads,
+				expiresAt,
+				testCase.revokedAt,
+				now,
+			)
+
+			if testCase.executeGet {
+				mockShareLinkRepository.
+					EXPECT().
+					GetShareLink(ctx, shareLink.GetID(), repository.LockTypeNone).
+					Return(&repository.ShareLinkWithCreator{
+						ShareLink: shareLink,
+						Creator:   values.NewTrapMemberID(uuid.New()),
+					}, testCase.getErr)
+			}
+
+			actual, err := shareLinkService.AuthenticateShareToken(ctx, testCase.token(shareLink.GetID()))
+
+			if testCase.isErr {
+				if testCase.err == nil {
+					assert.Error(t, err)
+				} else if !errors.Is(err, testCase.err) {
+					t.Errorf("error must be %v, but actual is %v", testCase.err, err)
+				}
+				return
+			}
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			assert.Equal(t, shareLink, actual)
+		})
+	}
+}
+
+func TestAccessSharedFile(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockFileRepository := mockRepository.NewMockFile(ctrl)
+	mockResourceRepository := mockRepository.NewMockResource(ctrl)
+	mockGroupRepository := mockRepository.NewMockGroup(ctrl)
+	mockShareLinkRepository := mockRepository.NewMockShareLink(ctrl)
+
+	shareLinkService := NewShareLink(
+		mockFileRepository,
+		mockResourceRepository,
+		mockGroupRepository,
+		nil,
+		mockShareLinkRepository,
+		nil,
+		common.ShareLinkSecret("secret"),
+	)
+
+	newFile := func() *domain.File {
+		return domain.NewFile(
+			values.NewFileID(),
+			values.FileTypePng,
+			values.FileHash{},
+			0,
+			nil,
+			time.Now(),
+		)
+	}
+	newResourceInfo := func(file *domain.File) *repository.ResourceInfo {
+		return &repository.ResourceInfo{
+			Resource: domain.NewResource(
+				values.NewResourceID(),
+				values.NewResourceName("resource"),
+				values.ResourceTypeImage,
+				values.NewResourceComment("comment"),
+				time.Now(),
+			),
+			File:    file,
+			Creator: values.NewTrapMemberID(uuid.New()),
+		}
+	}
+
+	type test struct {
+		description      string
+		targetType       values.ShareLinkTargetType
+		isSharedFile     bool
+		isDownload       bool
+		incrementErr     error
+		executeIncrement bool
+		isErr            bool
+		err              error
+	}
+
+	testCases := []test{
+		{
+			description:      "共有されているファイルのダウンロードは回数を数える",
+			targetType:       values.ShareLinkTargetTypeFile,
+			isSharedFile:     true,
+			isDownload:       true,
+			executeIncrement: true,
+		},
+		{
+			description:  "レンディションの取得は回数を数えない",
+			targetType:   values.ShareLinkTargetTypeFile,
+			isSharedFile: true,
+		},
+		{
+			description:      "リソースのファイルは共有されている",
+			targetType:       values.ShareLinkTargetTypeResource,
+			isSharedFile:     true,
+			isDownload:       true,
+			executeIncrement: true,
+		},
+		{
+			description:      "グループに含まれるリソースのファイルは共有されている",
+			targetType:       values.ShareLinkTargetTypeGroup,
+			isSharedFile:     true,
+			isDownload:       true,
+			executeIncrement: true,
+		},
+		{
+			description: "共有されていないファイルなのでErrForbidden",
+			targetType:  values.ShareLinkTargetTypeFile,
+			isDownload:  true,
+			isErr:       true,
+			err:         service.ErrForbidden,
+		},
+		{
+			description: "グループに含まれないリソースのファイルなのでErrForbidden",
+			targetType:  values.ShareLinkTargetTypeGroup,
+			isDownload:  true,
+			isErr:       true,
+			err:         service.ErrForbidden,
+		},
+		{
+			description:      "同時のダウンロードで上限に達したのでErrShareLinkUnavailable",
+			targetType:       values.ShareLinkTargetTypeFile,
+			isSharedFile:     true,
+			isDownload:       true,
+			executeIncrement: true,
+			incrementErr:     repository.ErrNoRecordUpdated,
+			isErr:            true,
+			err:              service.ErrShareLinkUnavailable,
+		},
+	}
+
+	for _, testCase := range testCases {
+		testCase := testCase
+
+		t.Run(testCase.description, func(t *testing.T) {
+			t.Parallel()
+
+			sharedFile := newFile()
+
+			var targetID values.ShareLinkTargetID
+			switch testCase.targetType {
+			case values.ShareLinkTargetTypeFile:
+				targetID = values.NewShareLinkTargetID(uuid.UUID(sharedFile.GetID()))
+
+				mockFileRepository.
+					EXPECT().
+					GetFile(ctx, sharedFile.GetID(), repository.LockTypeNone).
+					Return(&repository.FileWithCreator{
+						File:    sharedFile,
+						Creator: values.NewTrapMemberID(uuid.New()),
+					}, nil)
+			case values.ShareLinkTargetTypeResource:
+				resourceInfo := newResourceInfo(sharedFile)
+				targetID = values.NewShareLinkTargetID(uuid.UUID(resourceInfo.Resource.GetID()))
+
+				mockResourceRepository.
+					EXPECT().
+					GetResource(ctx, resourceInfo.Resource.GetID()).
+					Return(resourceInfo, nil)
+			case values.ShareLinkTargetTypeGroup:
+				group := domain.NewGroup(
+					values.NewGroupID(),
+					values.NewGroupName("group"),
+					values.GroupTypeArtBook,
+					values.NewGroupDescription("description"),
+					values.GroupReadPermissionPrivate,
+					values.GroupWritePermissionPrivate,
+					time.Now(),
+				)
+				targetID = values.NewShareLinkTargetID(uuid.UUID(group.GetID()))
+
+				mockGroupRepository.
+					EXPECT().
+					GetGroup(ctx, group.GetID(), repository.LockTypeNone).
+					Return(&repository.GroupInfo{
+						Group:        group,
+						MainResource: newResourceInfo(sharedFile),
+					}, nil)
+				mockResourceRepository.
+					EXPECT().
+					GetResources(ctx, &repository.ResourceSearchParams{
+						Groups: []*domain.Group{group},
+						Limit:  -1,
+					}).
+					Return([]*repository.ResourceInfo{
+						newResourceInfo(sharedFile),
+						newResourceInfo(newFile()),
+					}, nil)
+			}
+
+			shareLink := domain.NewShareLink(
+				values.NewShareLinkID(),
+				testCase.targetType,
+				targetID,
+				0,
+				0,
+				time.Now().Add(time.Hour),
+				nil,
+				time.Now(),
+			)
+
+			if testCase.executeIncrement {
+				mockShareLinkRepository.
+					EXPECT().
+					IncrementShareLinkDownloads(ctx, shareLink.GetID()).
+					Return(testCase.incrementErr)
+			}
+
+			fileID := sharedFile.GetID()
+			if !testCase.isSharedFile {
+				fileID = values.NewFileID()
+			}
+
+			err := shareLinkService.AccessSharedFile(ctx, shareLink, fileID, testCase.isDownload)
+
+			if testCase.isErr {
+				if testCase.err == nil {
+					assert.Error(t, err)
+				} else if !errors.Is(err, testCase.err) {
+					t.Errorf("error must be %v, but actual is %v", testCase.err, err)
+				}
+				return
+			}
+
+			assert.NoError(t, err)
+		})
+	}
+}
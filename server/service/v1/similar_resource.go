@@ -0,0 +1,196 @@
+package v1
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"sort"
+
+	"github.com/mazrean/Quantainer/domain"
+	"github.com/mazrean/Quantainer/domain/values"
+	"github.com/mazrean/Quantainer/pkg/imagehash"
+	"github.com/mazrean/Quantainer/repository"
+	"github.com/mazrean/Quantainer/service"
+)
+
+const (
+	// similarImageMaxDistance 似た画像とみなす知覚ハッシュの異なるビットの数の上限。
+	// 再エンコードや縮小では数ビットしか変わらないので、少し余裕を持たせている
+	similarImageMaxDistance = 8
+	// uploadSimilarResourceLimit アップロード時に警告として返す似たリソースの数の上限
+	uploadSimilarResourceLimit = 5
+)
+
+// savePerceptualHash 展開した画像の知覚ハッシュを記録する
+func (f *File) savePerceptualHash(ctx context.Context, file *domain.File, img image.Image) error {
+	perceptualHash := values.PerceptualHash(imagehash.DHash(img))
+
+	err := f.fileRepository.UpdateFilePerceptualHash(ctx, file.GetID(), perceptualHash)
+	if err != nil {
+		return fmt.Errorf("failed to update perceptual hash: %w", err)
+	}
+
+	file.SetPerceptualHash(&perceptualHash)
+
+	return nil
+}
+
+func (r *Resource) GetSimilarResources(
+	ctx context.Context,
+	session *domain.OIDCSession,
+	resourceID values.ResourceID,
+	limit int,
+) ([]*service.SimilarResourceInfo, error) {
+	resourceInfo, err := r.resourceRepository.GetResource(ctx, resourceID)
+	if errors.Is(err, repository.ErrRecordNotFound) {
+		return nil, service.ErrNoResource
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get resource: %w", err)
+	}
+
+	// 画像以外のファイルや、知覚ハッシュを計算する前の画像
+	perceptualHash := resourceInfo.File.GetPerceptualHash()
+	if perceptualHash == nil {
+		return []*service.SimilarResourceInfo{}, nil
+	}
+
+	users, err := r.userUtils.getAllActiveUser(ctx, session)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get users: %w", err)
+	}
+
+	similarResources, err := getSimilarResources(ctx, r.resourceRepository, users, *perceptualHash, &resourceID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get similar resources: %w", err)
+	}
+
+	return similarResources, nil
+}
+
+func (r *Resource) GetDuplicateResources(ctx context.Context, session *domain.OIDCSession) ([][]*service.ResourceInfo, error) {
+	me, err := r.userUtils.getMe(ctx, session)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	if !r.userUtils.isAdministrator(me) {
+		return nil, service.ErrForbidden
+	}
+
+	users, err := r.userUtils.getAllActiveUser(ctx, session)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get users: %w", err)
+	}
+
+	userMap := make(map[values.TraPMemberID]*service.UserInfo, len(users))
+	for _, user := range users {
+		userMap[user.GetID()] = user
+	}
+
+	resourceInfos, err := r.resourceRepository.GetResources(ctx, &repository.ResourceSearchParams{
+		HasPerceptualHash: true,
+		Limit:             -1,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get resources: %w", err)
+	}
+
+	resources := make([]*service.ResourceInfo, 0, len(resourceInfos))
+	hashes := make([]uint64, 0, len(resourceInfos))
+	for _, resourceInfo := range resourceInfos {
+		user, ok := userMap[resourceInfo.Creator]
+		if !ok {
+			// 退部したユーザーのリソースは他のAPIでも取得できないので、整理の対象にしない
+			continue
+		}
+
+		resources = append(resources, &service.ResourceInfo{
+			Resource: resourceInfo.Resource,
+			File:     resourceInfo.File,
+			Creator:  user,
+		})
+		hashes = append(hashes, uint64(*resourceInfo.File.GetPerceptualHash()))
+	}
+
+	clusters := imagehash.Cluster(hashes, similarImageMaxDistance)
+
+	duplicates := make([][]*service.ResourceInfo, 0, len(clusters))
+	for _, cluster := range clusters {
+		duplicate := make([]*service.ResourceInfo, 0, len(cluster))
+		for _, index := range cluster {
+			duplicate = append(duplicate, resources[index])
+		}
+
+		// 最初に作られたリソースを残すことが多いので、作成日時の古い順に並べる
+		sort.SliceStable(duplicate, func(i, j int) bool {
+			return duplicate[i].Resource.GetCreatedAt().Before(duplicate[j].Resource.GetCreatedAt())
+		})
+
+		duplicates = append(duplicates, duplicate)
+	}
+
+	return duplicates, nil
+}
+
+// getSimilarResources perceptualHashに近い画像のリソースを、近い順に最大limit個取得する。
+// excludeResourceIDが指定された場合はそのリソースを含めない。
+// 作成者が退部したリソースは含めないので、limit個より少なくなることがある。
+func getSimilarResources(
+	ctx context.Context,
+	resourceRepository repository.Resource,
+	users []*service.UserInfo,
+	perceptualHash values.PerceptualHash,
+	excludeResourceID *values.ResourceID,
+	limit int,
+) ([]*service.SimilarResourceInfo, error) {
+	searchLimit := limit
+	if excludeResourceID != nil {
+		// 除くリソース自身も距離0で取得されるので、1つ多く取得する
+		searchLimit++
+	}
+
+	resourceInfos, err := resourceRepository.GetResources(ctx, &repository.ResourceSearchParams{
+		SimilarTo: &repository.SimilarImageSearch{
+			PerceptualHash: perceptualHash,
+			MaxDistance:    similarImageMaxDistance,
+		},
+		Limit: searchLimit,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get resources: %w", err)
+	}
+
+	userMap := make(map[values.TraPMemberID]*service.UserInfo, len(users))
+	for _, user := range users {
+		userMap[user.GetID()] = user
+	}
+
+	similarResources := make([]*service.SimilarResourceInfo, 0, len(resourceInfos))
+	for _, resourceInfo := range resourceInfos {
+		if excludeResourceID != nil && resourceInfo.Resource.GetID() == *excludeResourceID {
+			continue
+		}
+
+		user, ok := userMap[resourceInfo.Creator]
+		if !ok {
+			continue
+		}
+
+		similarResources = append(similarResources, &service.SimilarResourceInfo{
+			ResourceInfo: &service.ResourceInfo{
+				Resource: resourceInfo.Resource,
+				File:     resourceInfo.File,
+				Creator:  user,
+			},
+			Distance: resourceInfo.File.GetPerceptualHash().Distance(perceptualHash),
+		})
+
+		if len(similarResources) >= limit {
+			break
+		}
+	}
+
+	return similarResources, nil
+}
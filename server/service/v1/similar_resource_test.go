@@ -0,0 +1,174 @@
+package v1
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/mazrean/Quantainer/domain"
+	"github.com/mazrean/Quantainer/domain/values"
+	"github.com/mazrean/Quantainer/repository"
+	mockRepository "github.com/mazrean/Quantainer/repository/mock"
+	"github.com/mazrean/Quantainer/service"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetSimilarResources(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockResourceRepository := mockRepository.NewMockResource(ctrl)
+
+	user := service.NewUserInfo(
+		values.NewTrapMemberID(uuid.New()),
+		values.NewTrapMemberName("mazrean"),
+		values.TrapMemberStatusActive,
+	)
+	deactivatedUserID := values.NewTrapMemberID(uuid.New())
+
+	perceptualHash := values.PerceptualHash(0xF0F0)
+
+	newResourceInfo := func(perceptualHash values.PerceptualHash, creator values.TraPMemberID) *repository.ResourceInfo {
+		file := domain.NewFile(
+			values.NewFileID(),
+			values.FileTypePng,
+			values.FileHash{},
+			0,
+			nil,
+			time.Now(),
+		)
+		file.SetPerceptualHash(&perceptualHash)
+
+		return &repository.ResourceInfo{
+			Resource: domain.NewResource(
+				values.NewResourceID(),
+				values.NewResourceName("resource"),
+				values.ResourceTypeImage,
+				values.NewResourceComment("comment"),
+				time.Now(),
+			),
+			File:    file,
+			Creator: creator,
+		}
+	}
+
+	self := newResourceInfo(perceptualHash, user.GetID())
+	same := newResourceInfo(perceptualHash, user.GetID())
+	near := newResourceInfo(0xF0F3, user.GetID())
+	deactivated := newResourceInfo(0xF0F1, deactivatedUserID)
+
+	type test struct {
+		description       string
+		excludeResourceID *values.ResourceID
+		limit             int
+		searchLimit       int
+		resourceInfos     []*repository.ResourceInfo
+		getResourcesErr   error
+		resources         []*repository.ResourceInfo
+		distances         []int
+		isErr             bool
+	}
+
+	selfID := self.Resource.GetID()
+
+	testCases := []test{
+		{
+			description:   "近い順にそのまま返す",
+			limit:         3,
+			searchLimit:   3,
+			resourceInfos: []*repository.ResourceInfo{same, near},
+			resources:     []*repository.ResourceInfo{same, near},
+			distances:     []int{0, 2},
+		},
+		{
+			description:       "除くリソースの分だけ多く取得し、そのリソースは返さない",
+			excludeResourceID: &selfID,
+			limit:             2,
+			searchLimit:       3,
+			resourceInfos:     []*repository.ResourceInfo{self, same, near},
+			resources:         []*repository.ResourceInfo{same, near},
+			distances:         []int{0, 2},
+		},
+		{
+			description:       "除くリソースが含まれなかった場合もlimit個まで",
+			excludeResourceID: &selfID,
+			limit:             1,
+			searchLimit:       2,
+			resourceInfos:     []*repository.ResourceInfo{same, near},
+			resources:         []*repository.ResourceInfo{same},
+			distances:         []int{0},
+		},
+		{
+			description:   "作成者が退部したリソースは返さない",
+			limit:         3,
+			searchLimit:   3,
+			resourceInfos: []*repository.ResourceInfo{same, deactivated, near},
+			resources:     []*repository.ResourceInfo{same, near},
+			distances:     []int{0, 2},
+		},
+		{
+			description:   "似たリソースがないので空",
+			limit:         3,
+			searchLimit:   3,
+			resourceInfos: []*repository.ResourceInfo{},
+			resources:     []*repository.ResourceInfo{},
+			distances:     []int{},
+		},
+		{
+			description:     "GetResourcesがエラーなのでエラー",
+			limit:           3,
+			searchLimit:     3,
+			getResourcesErr: errors.New("error"),
+			isErr:           true,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.description, func(t *testing.T) {
+			mockResourceRepository.
+				EXPECT().
+				GetResources(ctx, &repository.ResourceSearchParams{
+					SimilarTo: &repository.SimilarImageSearch{
+						PerceptualHash: perceptualHash,
+						MaxDistance:    similarImageMaxDistance,
+					},
+					Limit: testCase.searchLimit,
+				}).
+				Return(testCase.resourceInfos, testCase.getResourcesErr)
+
+			similarResources, err := getSimilarResources(
+				ctx,
+				mockResourceRepository,
+				[]*service.UserInfo{user},
+				perceptualHash,
+				testCase.excludeResourceID,
+				testCase.limit,
+			)
+
+			if testCase.isErr {
+				assert.Error(t, err)
+				return
+			}
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			if !assert.Len(t, similarResources, len(testCase.resources)) {
+				return
+			}
+			for i, similarResource := range similarResources {
+				assert.Equal(t, testCase.resources[i].Resource, similarResource.Resource)
+				assert.Equal(t, testCase.resources[i].File, similarResource.File)
+				assert.Equal(t, user, similarResource.Creator)
+				assert.Equal(t, testCase.distances[i], similarResource.Distance)
+			}
+		})
+	}
+}
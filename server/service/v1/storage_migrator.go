@@ -0,0 +1,183 @@
+package v1
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+
+	"github.com/google/uuid"
+	"github.com/mazrean/Quantainer/domain"
+	"github.com/mazrean/Quantainer/domain/values"
+	"github.com/mazrean/Quantainer/repository"
+	"github.com/mazrean/Quantainer/service"
+	"github.com/mazrean/Quantainer/storage"
+)
+
+// storageMigrationBatchSize 1度にDBから取得するファイルの数
+const storageMigrationBatchSize = 100
+
+type StorageMigrator struct {
+	fileRepository repository.File
+	srcStorage     storage.File
+	dstStorage     storage.File
+}
+
+func NewStorageMigrator(
+	fileRepository repository.File,
+	srcStorage storage.File,
+	dstStorage storage.File,
+) *StorageMigrator {
+	return &StorageMigrator{
+		fileRepository: fileRepository,
+		srcStorage:     srcStorage,
+		dstStorage:     dstStorage,
+	}
+}
+
+var (
+	errHashMismatch = errors.New("hash mismatch")
+	errSizeMismatch = errors.New("size mismatch")
+)
+
+func (sm *StorageMigrator) Migrate(
+	ctx context.Context,
+	migratedFileIDs map[values.FileID]struct{},
+	onMigrated func(fileID values.FileID) error,
+) (*service.StorageMigrationResult, error) {
+	result := &service.StorageMigrationResult{
+		Failed: []*service.StorageMigrationFailure{},
+	}
+
+	// 移行中に追加されたファイルは作成日時順で末尾に追加されるので、offsetでの取得で漏れは出ない
+	for offset := 0; ; offset += storageMigrationBatchSize {
+		files, err := sm.fileRepository.GetFiles(ctx, storageMigrationBatchSize, offset)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get files: %w", err)
+		}
+
+		for _, file := range files {
+			// 中断された場合、以降のファイルを失敗として扱わないようにする
+			err := ctx.Err()
+			if err != nil {
+				return nil, fmt.Errorf("migration canceled: %w", err)
+			}
+
+			if _, ok := migratedFileIDs[file.GetID()]; ok {
+				result.Skipped++
+				continue
+			}
+
+			isAlreadyExist, err := sm.migrateFile(ctx, file)
+			if err != nil {
+				log.Printf("error: failed to migrate file(%s): %v\n", uuid.UUID(file.GetID()).String(), err)
+				result.Failed = append(result.Failed, &service.StorageMigrationFailure{
+					FileID: file.GetID(),
+					Err:    err,
+				})
+				continue
+			}
+
+			if isAlreadyExist {
+				result.Skipped++
+			} else {
+				result.Copied++
+			}
+
+			err = onMigrated(file.GetID())
+			if err != nil {
+				return nil, fmt.Errorf("failed to record migrated file: %w", err)
+			}
+		}
+
+		if len(files) < storageMigrationBatchSize {
+			break
+		}
+	}
+
+	return result, nil
+}
+
+// migrateFile 1つのファイルをコピーし、移行先から読み直して内容を検証する。
+// 移行先に既に存在した場合はtrueを返す。
+func (sm *StorageMigrator) migrateFile(ctx context.Context, file *domain.File) (bool, error) {
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(sm.srcStorage.GetFile(ctx, file, pw))
+	}()
+	defer pr.Close()
+
+	// 移行先での保存時にハッシュとバイト数が設定されるので、移行元のファイルとは別に用意する
+	dstFile := domain.NewFile(
+		file.GetID(),
+		file.GetType(),
+		values.FileHash{},
+		0,
+		nil,
+		file.GetCreatedAt(),
+	)
+
+	hashReader := storage.NewHashReader(pr)
+	err := sm.dstStorage.SaveFile(ctx, dstFile, hashReader)
+	isAlreadyExist := errors.Is(err, storage.ErrAlreadyExists)
+	if err != nil && !isAlreadyExist {
+		return false, fmt.Errorf("failed to save file: %w", err)
+	}
+
+	if isAlreadyExist {
+		// 移行元の内容のハッシュを求めるため、残りを読み切る
+		_, err = io.Copy(io.Discard, hashReader)
+		if err != nil {
+			return false, fmt.Errorf("failed to read source file: %w", err)
+		}
+	}
+
+	hash := hashReader.Sum()
+	size := hashReader.Size()
+
+	srcHash := file.GetHash()
+	if !srcHash.IsZero() && srcHash != hash {
+		return false, fmt.Errorf("source file is broken(expected: %s, actual: %s): %w", srcHash, hash, errHashMismatch)
+	}
+
+	dstFile.SetHash(hash)
+	err = sm.verifyFile(ctx, dstFile, size)
+	if err != nil {
+		return false, fmt.Errorf("failed to verify file: %w", err)
+	}
+
+	// ハッシュ導入前に保存されたファイルは、移行先での参照のためにハッシュを記録する
+	if srcHash.IsZero() {
+		err = sm.fileRepository.UpdateFileHash(ctx, file.GetID(), hash)
+		if err != nil {
+			return false, fmt.Errorf("failed to update file hash: %w", err)
+		}
+	}
+
+	return isAlreadyExist, nil
+}
+
+func (sm *StorageMigrator) verifyFile(ctx context.Context, file *domain.File, size int64) error {
+	reader, err := sm.dstStorage.OpenFile(ctx, file)
+	if err != nil {
+		return fmt.Errorf("failed to open file: %w", err)
+	}
+	defer reader.Close()
+
+	hashReader := storage.NewHashReader(reader)
+	_, err = io.Copy(io.Discard, hashReader)
+	if err != nil {
+		return fmt.Errorf("failed to read file: %w", err)
+	}
+
+	if hashReader.Size() != size {
+		return fmt.Errorf("expected: %d, actual: %d: %w", size, hashReader.Size(), errSizeMismatch)
+	}
+
+	if hashReader.Sum() != file.GetHash() {
+		return fmt.Errorf("expected: %s, actual: %s: %w", file.GetHash(), hashReader.Sum(), errHashMismatch)
+	}
+
+	return nil
+}
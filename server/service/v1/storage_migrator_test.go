@@ -0,0 +1,177 @@
+package v1
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"os"
+	"path"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/mazrean/Quantainer/domain"
+	"github.com/mazrean/Quantainer/domain/values"
+	"github.com/mazrean/Quantainer/pkg/common"
+	mockRepository "github.com/mazrean/Quantainer/repository/mock"
+	"github.com/mazrean/Quantainer/storage/local"
+)
+
+func TestMigrate(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	rootPath := "./storage_migrator_test"
+	defer func() {
+		err := os.RemoveAll(rootPath)
+		if err != nil {
+			t.Fatalf("failed to remove directory: %v", err)
+		}
+	}()
+
+	srcStorage, err := local.NewFile(local.NewDirectoryManager(common.FilePath(path.Join(rootPath, "src"))))
+	if err != nil {
+		t.Fatalf("failed to create storage: %v", err)
+	}
+
+	dstStorage, err := local.NewFile(local.NewDirectoryManager(common.FilePath(path.Join(rootPath, "dst"))))
+	if err != nil {
+		t.Fatalf("failed to create storage: %v", err)
+	}
+
+	mockFileRepository := mockRepository.NewMockFile(ctrl)
+
+	storageMigrator := NewStorageMigrator(mockFileRepository, srcStorage, dstStorage)
+
+	newFile := func() *domain.File {
+		return domain.NewFile(
+			values.NewFileID(),
+			values.FileTypeOther,
+			values.FileHash{},
+			0,
+			nil,
+			time.Now(),
+		)
+	}
+
+	// 移行先に存在しないファイル
+	copiedFile := newFile()
+	err = srcStorage.SaveFile(ctx, copiedFile, strings.NewReader("copied"))
+	if err != nil {
+		t.Fatalf("failed to save file: %v", err)
+	}
+
+	// 前回の移行で記録済みのファイル
+	recordedFile := newFile()
+	err = srcStorage.SaveFile(ctx, recordedFile, strings.NewReader("recorded"))
+	if err != nil {
+		t.Fatalf("failed to save file: %v", err)
+	}
+
+	// 移行先には保存されたが、記録前に中断されたファイル
+	existFile := newFile()
+	err = srcStorage.SaveFile(ctx, existFile, strings.NewReader("exist"))
+	if err != nil {
+		t.Fatalf("failed to save file: %v", err)
+	}
+	err = dstStorage.SaveFile(ctx, newFileWithID(existFile), strings.NewReader("exist"))
+	if err != nil {
+		t.Fatalf("failed to save file: %v", err)
+	}
+
+	// ハッシュ導入前に保存されたファイル
+	legacyFile := newFile()
+	legacyFilePath := path.Join(rootPath, "src", "files", uuid.UUID(legacyFile.GetID()).String())
+	err = os.WriteFile(legacyFilePath, []byte("legacy"), 0644)
+	if err != nil {
+		t.Fatalf("failed to write legacy file: %v", err)
+	}
+	legacyHash := sha256.Sum256([]byte("legacy"))
+
+	// 移行元に存在しないファイル
+	missingFile := newFile()
+	missingHash := sha256.Sum256([]byte("missing"))
+	missingFile.SetHash(values.FileHash(missingHash))
+
+	files := []*domain.File{copiedFile, recordedFile, existFile, legacyFile, missingFile}
+
+	mockFileRepository.
+		EXPECT().
+		GetFiles(ctx, storageMigrationBatchSize, 0).
+		Return(files, nil)
+	mockFileRepository.
+		EXPECT().
+		UpdateFileHash(ctx, legacyFile.GetID(), values.FileHash(legacyHash)).
+		Return(nil)
+
+	migratedFileIDs := map[values.FileID]struct{}{
+		recordedFile.GetID(): {},
+	}
+
+	recordedFileIDs := []values.FileID{}
+	result, err := storageMigrator.Migrate(ctx, migratedFileIDs, func(fileID values.FileID) error {
+		recordedFileIDs = append(recordedFileIDs, fileID)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+
+	if result.Copied != 2 {
+		t.Errorf("copied must be 2, but actual is %d", result.Copied)
+	}
+	if result.Skipped != 2 {
+		t.Errorf("skipped must be 2, but actual is %d", result.Skipped)
+	}
+	if len(result.Failed) != 1 || result.Failed[0].FileID != missingFile.GetID() {
+		t.Errorf("failed must be only missing file, but actual is %+v", result.Failed)
+	}
+
+	expectRecordedFileIDs := []values.FileID{copiedFile.GetID(), existFile.GetID(), legacyFile.GetID()}
+	if len(recordedFileIDs) != len(expectRecordedFileIDs) {
+		t.Fatalf("recorded file ids must be %v, but actual is %v", expectRecordedFileIDs, recordedFileIDs)
+	}
+	for i, fileID := range expectRecordedFileIDs {
+		if recordedFileIDs[i] != fileID {
+			t.Errorf("recorded file ids must be %v, but actual is %v", expectRecordedFileIDs, recordedFileIDs)
+		}
+	}
+
+	legacyFile.SetHash(values.FileHash(legacyHash))
+	for _, testCase := range []struct {
+		file    *domain.File
+		content string
+	}{
+		{copiedFile, "copied"},
+		{existFile, "exist"},
+		{legacyFile, "legacy"},
+	} {
+		buf := bytes.NewBuffer(nil)
+		err := dstStorage.GetFile(ctx, testCase.file, buf)
+		if err != nil {
+			t.Errorf("failed to get file: %v", err)
+			continue
+		}
+
+		if buf.String() != testCase.content {
+			t.Errorf("content must be %s, but actual is %s", testCase.content, buf.String())
+		}
+	}
+}
+
+func newFileWithID(file *domain.File) *domain.File {
+	return domain.NewFile(
+		file.GetID(),
+		file.GetType(),
+		values.FileHash{},
+		0,
+		nil,
+		file.GetCreatedAt(),
+	)
+}
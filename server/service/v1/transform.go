@@ -0,0 +1,313 @@
+package v1
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/mazrean/Quantainer/domain"
+	"github.com/mazrean/Quantainer/domain/values"
+	"github.com/mazrean/Quantainer/pkg/webpenc"
+	"github.com/mazrean/Quantainer/repository"
+	"github.com/mazrean/Quantainer/service"
+	"golang.org/x/image/draw"
+)
+
+func (f *File) DownloadTransformed(
+	ctx context.Context,
+	fileID values.FileID,
+	preset values.ImageTransformPreset,
+	format *values.FileType,
+	quality values.ImageQuality,
+) (*domain.File, io.ReadSeekCloser, error) {
+	fileInfo, err := f.fileRepository.GetFile(ctx, fileID, repository.LockTypeNone)
+	if errors.Is(err, repository.ErrRecordNotFound) {
+		return nil, nil, service.ErrNoFile
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get file: %w", err)
+	}
+
+	if fileInfo.IsInfected() {
+		return nil, nil, service.ErrFileInfected
+	}
+
+	if !fileInfo.GetType().IsRenditionSupported() {
+		return nil, nil, fmt.Errorf("not raster image: %w", service.ErrInvalidFormat)
+	}
+
+	var transformFormat values.FileType
+	if format != nil {
+		transformFormat = *format
+	} else {
+		transformFormat = defaultTransformFormat(fileInfo.GetType())
+	}
+	transform := values.NewImageTransform(preset, transformFormat, quality)
+
+	rendition, err := f.renditionRepository.GetTransform(ctx, fileID, transform, repository.LockTypeNone)
+	if errors.Is(err, repository.ErrRecordNotFound) {
+		// 同じ変換への同時のリクエストで何度も展開しないよう、1つの変換の完了を待たせる
+		key := transformCallKey{
+			fileID:    fileID,
+			transform: transform,
+		}
+		rendition, err = f.transformGroup.do(ctx, key, func() (*domain.File, error) {
+			return f.createTransform(ctx, fileInfo, transform)
+		})
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to create transform: %w", err)
+		}
+	} else if err != nil {
+		return nil, nil, fmt.Errorf("failed to get transform: %w", err)
+	}
+
+	reader, err := f.fileStorage.OpenFile(ctx, rendition)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open file: %w", err)
+	}
+
+	return rendition, reader, nil
+}
+
+// createTransform 元の画像を変換してレンディションとして保存する。
+// 同時に同じ変換が保存された場合は、先に保存された方を返す。
+func (f *File) createTransform(ctx context.Context, fileInfo *repository.FileWithCreator, transform values.ImageTransform) (*domain.File, error) {
+	buf, err := f.renderTransform(ctx, fileInfo.File, transform)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render transform: %w", err)
+	}
+
+	rendition := domain.NewFile(
+		values.NewFileID(),
+		transform.GetFormat(),
+		values.FileHash{},
+		0,
+		nil,
+		time.Now(),
+	)
+	rendition.SetOriginal(renditionFileName(fileInfo.File, transform.GetFormat()), 0)
+
+	// レンディションの作成者はIDのみ記録するので、元のファイルの作成者のIDで保存する
+	creator := service.NewUserInfo(fileInfo.Creator, "", values.TrapMemberStatusActive)
+
+	err = f.dbRepository.Transaction(ctx, nil, func(ctx context.Context) error {
+		// 同じ変換を重複して保存しないよう、元のファイルをロックしてから確認する
+		_, err := f.fileRepository.GetFile(ctx, fileInfo.GetID(), repository.LockTypeRecord)
+		if errors.Is(err, repository.ErrRecordNotFound) {
+			return service.ErrNoFile
+		}
+		if err != nil {
+			return fmt.Errorf("failed to get file: %w", err)
+		}
+
+		savedRendition, err := f.renditionRepository.GetTransform(ctx, fileInfo.GetID(), transform, repository.LockTypeNone)
+		if err == nil {
+			rendition = savedRendition
+			return nil
+		}
+		if !errors.Is(err, repository.ErrRecordNotFound) {
+			return fmt.Errorf("failed to get transform: %w", err)
+		}
+
+		err = f.fileStorage.SaveFile(ctx, rendition, buf)
+		if err != nil {
+			return fmt.Errorf("failed to save file: %w", err)
+		}
+
+		err = f.fileRepository.SaveFile(ctx, creator, rendition)
+		if err != nil {
+			return fmt.Errorf("failed to save file: %w", err)
+		}
+
+		err = f.renditionRepository.SaveTransform(ctx, fileInfo.GetID(), transform, rendition)
+		if err != nil {
+			return fmt.Errorf("failed to save transform: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed in transaction: %w", err)
+	}
+
+	return rendition, nil
+}
+
+// renderTransform 元の画像を展開して変換した内容を返す。
+// アップロード時のレンディションの作成と合わせて、同時に展開する数を制限する。
+func (f *File) renderTransform(ctx context.Context, file *domain.File, transform values.ImageTransform) (*bytes.Buffer, error) {
+	release, err := imageDecodeSlots.acquire(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to acquire image decode slot: %w", err)
+	}
+	defer release()
+
+	img, err := f.decodeStoredImage(ctx, file)
+	if errors.Is(err, errTooLargeImage) {
+		return nil, fmt.Errorf("too large image(%v): %w", err, service.ErrInvalidFormat)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode image: %w", err)
+	}
+
+	buf := bytes.NewBuffer(nil)
+	err = encodeTransform(buf, transformImage(img, transform.GetPreset()), transform)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode transform: %w", err)
+	}
+
+	return buf, nil
+}
+
+// transformCallKey 変換元のファイルと変換の組
+type transformCallKey struct {
+	fileID    values.FileID
+	transform values.ImageTransform
+}
+
+// transformCall 実行中の変換
+type transformCall struct {
+	done      chan struct{}
+	rendition *domain.File
+	err       error
+	// dups 完了を待っている呼び出しの数
+	dups int
+}
+
+// transformGroup 同じ変換の同時の実行を1つにまとめる
+type transformGroup struct {
+	locker sync.Mutex
+	calls  map[transformCallKey]*transformCall
+}
+
+func newTransformGroup() *transformGroup {
+	return &transformGroup{
+		calls: map[transformCallKey]*transformCall{},
+	}
+}
+
+// do 同じkeyの変換が実行中であればその完了を待って結果を共有し、実行中でなければfnを実行する。
+// 待っている間にctxがキャンセルされた場合は、実行中の変換は続けたまま中断する。
+func (tg *transformGroup) do(ctx context.Context, key transformCallKey, fn func() (*domain.File, error)) (*domain.File, error) {
+	tg.locker.Lock()
+	c, ok := tg.calls[key]
+	if ok {
+		c.dups++
+		tg.locker.Unlock()
+
+		select {
+		case <-c.done:
+			return c.rendition, c.err
+		case <-ctx.Done():
+			return nil, fmt.Errorf("failed to wait for transform: %w", ctx.Err())
+		}
+	}
+
+	c = &transformCall{
+		done: make(chan struct{}),
+	}
+	tg.calls[key] = c
+	tg.locker.Unlock()
+
+	c.rendition, c.err = fn()
+
+	tg.locker.Lock()
+	delete(tg.calls, key)
+	tg.locker.Unlock()
+	close(c.done)
+
+	return c.rendition, c.err
+}
+
+// defaultTransformFormat 形式が指定されなかった場合、JPEGはJPEGのまま、それ以外は透過を保てるPNGにする
+func defaultTransformFormat(fileType values.FileType) values.FileType {
+	if fileType == values.FileTypeJpeg {
+		return values.FileTypeJpeg
+	}
+
+	return values.FileTypePng
+}
+
+// transformImage presetの幅と高さに合わせて画像を拡大縮小する
+func transformImage(img image.Image, preset values.ImageTransformPreset) image.Image {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	dstWidth, dstHeight := preset.GetWidth(), preset.GetHeight()
+
+	srcRect := bounds
+	switch preset.GetFit() {
+	case values.ImageFitCover:
+		// 変換後と縦横比が同じになるよう、中央を切り取る
+		if width*dstHeight > height*dstWidth {
+			cropWidth := height * dstWidth / dstHeight
+			minX := bounds.Min.X + (width-cropWidth)/2
+			srcRect = image.Rect(minX, bounds.Min.Y, minX+cropWidth, bounds.Max.Y)
+		} else {
+			cropHeight := width * dstHeight / dstWidth
+			minY := bounds.Min.Y + (height-cropHeight)/2
+			srcRect = image.Rect(bounds.Min.X, minY, bounds.Max.X, minY+cropHeight)
+		}
+	default:
+		if width <= dstWidth && height <= dstHeight {
+			return img
+		}
+
+		if width*dstHeight > height*dstWidth {
+			dstHeight = height * dstWidth / width
+		} else {
+			dstWidth = width * dstHeight / height
+		}
+		if dstWidth < 1 {
+			dstWidth = 1
+		}
+		if dstHeight < 1 {
+			dstHeight = 1
+		}
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, dstWidth, dstHeight))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, srcRect, draw.Src, nil)
+
+	return dst
+}
+
+// encodeTransform transformの形式で書き込む。
+// JPEGは透過を表せないので、透過のある画像は白い背景に重ねてから書き込む
+func encodeTransform(writer io.Writer, img image.Image, transform values.ImageTransform) error {
+	switch transform.GetFormat() {
+	case values.FileTypeJpeg:
+		if opaque, ok := img.(interface{ Opaque() bool }); !ok || !opaque.Opaque() {
+			flattened := image.NewRGBA(img.Bounds())
+			draw.Draw(flattened, flattened.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+			draw.Draw(flattened, flattened.Bounds(), img, img.Bounds().Min, draw.Over)
+			img = flattened
+		}
+
+		err := jpeg.Encode(writer, img, &jpeg.Options{Quality: int(transform.GetQuality())})
+		if err != nil {
+			return fmt.Errorf("failed to encode jpeg: %w", err)
+		}
+	case values.FileTypePng:
+		err := png.Encode(writer, img)
+		if err != nil {
+			return fmt.Errorf("failed to encode png: %w", err)
+		}
+	case values.FileTypeWebP:
+		err := webpenc.Encode(writer, img)
+		if err != nil {
+			return fmt.Errorf("failed to encode webp: %w", err)
+		}
+	default:
+		return fmt.Errorf("unsupported transform format: %d", transform.GetFormat())
+	}
+
+	return nil
+}
@@ -0,0 +1,217 @@
+package v1
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"testing"
+	"time"
+
+	"github.com/mazrean/Quantainer/domain"
+	"github.com/mazrean/Quantainer/domain/values"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTransformImage(t *testing.T) {
+	t.Parallel()
+
+	type test struct {
+		description string
+		width       int
+		height      int
+		preset      values.ImageTransformPreset
+		dstWidth    int
+		dstHeight   int
+	}
+
+	testCases := []test{
+		{
+			description: "coverなので横長でも正方形に切り取る",
+			width:       2048,
+			height:      1024,
+			preset:      values.NewImageTransformPreset(256, 256, values.ImageFitCover),
+			dstWidth:    256,
+			dstHeight:   256,
+		},
+		{
+			description: "coverなので小さい画像は拡大する",
+			width:       32,
+			height:      16,
+			preset:      values.NewImageTransformPreset(64, 64, values.ImageFitCover),
+			dstWidth:    64,
+			dstHeight:   64,
+		},
+		{
+			description: "containなので横長は横が幅になる",
+			width:       2048,
+			height:      1024,
+			preset:      values.NewImageTransformPreset(1024, 1024, values.ImageFitContain),
+			dstWidth:    1024,
+			dstHeight:   512,
+		},
+		{
+			description: "containなので縦長は縦が高さになる",
+			width:       1000,
+			height:      2000,
+			preset:      values.NewImageTransformPreset(512, 512, values.ImageFitContain),
+			dstWidth:    256,
+			dstHeight:   512,
+		},
+		{
+			description: "containなので小さい画像は拡大しない",
+			width:       100,
+			height:      50,
+			preset:      values.NewImageTransformPreset(512, 512, values.ImageFitContain),
+			dstWidth:    100,
+			dstHeight:   50,
+		},
+		{
+			description: "containで極端に細長くても1px以上になる",
+			width:       10000,
+			height:      1,
+			preset:      values.NewImageTransformPreset(512, 512, values.ImageFitContain),
+			dstWidth:    512,
+			dstHeight:   1,
+		},
+	}
+
+	for _, testCase := range testCases {
+		testCase := testCase
+		t.Run(testCase.description, func(t *testing.T) {
+			t.Parallel()
+
+			img := image.NewRGBA(image.Rect(0, 0, testCase.width, testCase.height))
+
+			transformed := transformImage(img, testCase.preset)
+			assert.Equal(t, testCase.dstWidth, transformed.Bounds().Dx())
+			assert.Equal(t, testCase.dstHeight, transformed.Bounds().Dy())
+		})
+	}
+}
+
+func TestEncodeTransform(t *testing.T) {
+	t.Parallel()
+
+	preset := values.NewImageTransformPreset(64, 64, values.ImageFitCover)
+
+	type test struct {
+		description string
+		format      values.FileType
+	}
+
+	testCases := []test{
+		{
+			description: "透過のある画像でもjpegにできる",
+			format:      values.FileTypeJpeg,
+		},
+		{
+			description: "pngにできる",
+			format:      values.FileTypePng,
+		},
+		{
+			description: "webpにできる",
+			format:      values.FileTypeWebP,
+		},
+	}
+
+	for _, testCase := range testCases {
+		testCase := testCase
+		t.Run(testCase.description, func(t *testing.T) {
+			t.Parallel()
+
+			img := image.NewNRGBA(image.Rect(0, 0, 64, 64))
+
+			buf := bytes.NewBuffer(nil)
+			err := encodeTransform(buf, img, values.NewImageTransform(preset, testCase.format, values.DefaultImageQuality))
+			assert.NoError(t, err)
+
+			fileType, _, _, err := detectFileType(buf)
+			assert.NoError(t, err)
+			assert.Equal(t, testCase.format, fileType)
+		})
+	}
+}
+
+func TestTransformGroup(t *testing.T) {
+	t.Parallel()
+
+	const waiterCount = 5
+
+	group := newTransformGroup()
+	key := transformCallKey{
+		fileID: values.NewFileID(),
+		transform: values.NewImageTransform(
+			values.NewImageTransformPreset(256, 256, values.ImageFitContain),
+			values.FileTypePng,
+			0,
+		),
+	}
+	rendition := domain.NewFile(
+		values.NewFileID(),
+		values.FileTypePng,
+		values.FileHash{},
+		0,
+		nil,
+		time.Now(),
+	)
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	results := make(chan *domain.File, waiterCount+1)
+	go func() {
+		result, err := group.do(context.Background(), key, func() (*domain.File, error) {
+			close(started)
+			<-release
+			return rendition, nil
+		})
+		assert.NoError(t, err)
+		results <- result
+	}()
+	<-started
+
+	for i := 0; i < waiterCount; i++ {
+		go func() {
+			result, err := group.do(context.Background(), key, func() (*domain.File, error) {
+				t.Error("transform must not be executed while the same transform is running")
+				return nil, nil
+			})
+			assert.NoError(t, err)
+			results <- result
+		}()
+	}
+
+	// 待っている呼び出しがいても、別の変換は待たずに実行される
+	otherKey := key
+	otherKey.fileID = values.NewFileID()
+	otherRendition, err := group.do(context.Background(), otherKey, func() (*domain.File, error) {
+		return nil, nil
+	})
+	assert.NoError(t, err)
+	assert.Nil(t, otherRendition)
+
+	// 待っている間にキャンセルされた呼び出しは、実行中の変換を待たずに中断する
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = group.do(ctx, key, func() (*domain.File, error) {
+		t.Error("transform must not be executed while the same transform is running")
+		return nil, nil
+	})
+	assert.ErrorIs(t, err, context.Canceled)
+
+	assert.Eventually(t, func() bool {
+		group.locker.Lock()
+		defer group.locker.Unlock()
+
+		return group.calls[key].dups == waiterCount+1
+	}, time.Second, time.Millisecond)
+
+	close(release)
+
+	for i := 0; i < waiterCount+1; i++ {
+		assert.Same(t, rendition, <-results)
+	}
+
+	group.locker.Lock()
+	defer group.locker.Unlock()
+	assert.Empty(t, group.calls)
+}
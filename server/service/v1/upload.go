@@ -0,0 +1,292 @@
+package v1
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mazrean/Quantainer/domain"
+	"github.com/mazrean/Quantainer/domain/values"
+	"github.com/mazrean/Quantainer/repository"
+	"github.com/mazrean/Quantainer/service"
+	"github.com/mazrean/Quantainer/storage"
+)
+
+// uploadExpiration 最後の書き込みからアップロードを破棄するまでの期間
+const uploadExpiration = 24 * time.Hour
+
+type Upload struct {
+	fileRepository   repository.File
+	uploadRepository repository.Upload
+	uploadStorage    storage.Upload
+	userUtils        *UserUtils
+	quotaUtils       *QuotaUtils
+	uploadPolicy     *UploadPolicy
+	file             *File
+	// writingUploads 書き込み中のアップロード。同じアップロードへの同時の書き込みを防ぐ
+	writingUploads map[values.UploadID]struct{}
+	locker         sync.Mutex
+}
+
+func NewUpload(
+	fileRepository repository.File,
+	uploadRepository repository.Upload,
+	uploadStorage storage.Upload,
+	userUtils *UserUtils,
+	quotaUtils *QuotaUtils,
+	uploadPolicy *UploadPolicy,
+	file *File,
+) *Upload {
+	return &Upload{
+		fileRepository:   fileRepository,
+		uploadRepository: uploadRepository,
+		uploadStorage:    uploadStorage,
+		userUtils:        userUtils,
+		quotaUtils:       quotaUtils,
+		uploadPolicy:     uploadPolicy,
+		file:             file,
+		writingUploads:   map[values.UploadID]struct{}{},
+	}
+}
+
+func (u *Upload) CreateUpload(ctx context.Context, session *domain.OIDCSession, length int64, metadata values.UploadMetadata) (*domain.Upload, error) {
+	user, err := u.userUtils.getMe(ctx, session)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	if length <= 0 {
+		return nil, fmt.Errorf("invalid upload length(%d): %w", length, service.ErrInvalidFormat)
+	}
+
+	// 種類ごとの上限は全て書き込まれてから確認する
+	maxSize := u.uploadPolicy.getMaxUploadSize()
+	if maxSize > 0 && length > maxSize {
+		return nil, service.ErrFileTooLarge
+	}
+
+	limit, err := u.quotaUtils.getUploadLimit(ctx, user)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get upload limit: %w", err)
+	}
+
+	now := time.Now()
+
+	if limit.remaining >= 0 {
+		// 書き込み途中のアップロードも完了すれば使用量になるので、その分を除いた残りで確認する
+		stagedLength, err := u.uploadRepository.GetUserUploadLength(ctx, user.GetID(), now)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get staged upload length: %w", err)
+		}
+
+		if length > limit.remaining-stagedLength {
+			return nil, limit.err
+		}
+	}
+
+	upload := domain.NewUpload(
+		values.NewUploadID(),
+		length,
+		0,
+		metadata,
+		now.Add(uploadExpiration),
+		now,
+	)
+
+	err = u.uploadStorage.CreateUpload(ctx, upload.GetID())
+	if err != nil {
+		return nil, fmt.Errorf("failed to create upload in storage: %w", err)
+	}
+
+	err = u.uploadRepository.SaveUpload(ctx, user.GetID(), upload)
+	if err != nil {
+		deleteErr := u.uploadStorage.DeleteUpload(ctx, upload.GetID())
+		if deleteErr != nil {
+			log.Printf("error: failed to delete upload(%s) from storage: %v\n", uuid.UUID(upload.GetID()).String(), deleteErr)
+		}
+
+		return nil, fmt.Errorf("failed to save upload: %w", err)
+	}
+
+	return upload, nil
+}
+
+func (u *Upload) GetUpload(ctx context.Context, session *domain.OIDCSession, uploadID values.UploadID) (*domain.Upload, error) {
+	user, err := u.userUtils.getMe(ctx, session)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	return u.getUpload(ctx, user, uploadID)
+}
+
+// getUpload 作成者でない場合はErrForbidden、期限切れの場合は破棄される前でもErrNoUploadを返す
+func (u *Upload) getUpload(ctx context.Context, user *service.UserInfo, uploadID values.UploadID) (*domain.Upload, error) {
+	upload, err := u.uploadRepository.GetUpload(ctx, uploadID, repository.LockTypeNone)
+	if errors.Is(err, repository.ErrRecordNotFound) {
+		return nil, service.ErrNoUpload
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get upload: %w", err)
+	}
+
+	if upload.Creator != user.GetID() {
+		return nil, service.ErrForbidden
+	}
+
+	if time.Now().After(upload.GetExpiresAt()) {
+		return nil, service.ErrNoUpload
+	}
+
+	return upload.Upload, nil
+}
+
+func (u *Upload) WriteUpload(
+	ctx context.Context,
+	session *domain.OIDCSession,
+	uploadID values.UploadID,
+	offset int64,
+	reader io.Reader,
+) (*domain.Upload, *service.FileInfo, error) {
+	user, err := u.userUtils.getMe(ctx, session)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	err = u.lockUpload(uploadID)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer u.unlockUpload(uploadID)
+
+	upload, err := u.getUpload(ctx, user, uploadID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if offset != upload.GetOffset() {
+		return nil, nil, service.ErrUploadOffsetMismatch
+	}
+
+	if !upload.IsCompleted() {
+		// Upload-Lengthを超える分は書き込まない
+		n, writeErr := u.uploadStorage.WriteUpload(ctx, uploadID, offset, io.LimitReader(reader, upload.GetLength()-offset))
+
+		// 切断された場合も、書き込めた分から再開できるよう記録する
+		upload.SetOffset(offset + n)
+		upload.SetExpiresAt(time.Now().Add(uploadExpiration))
+		err = u.uploadRepository.UpdateUploadOffset(ctx, upload)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to update upload offset: %w", err)
+		}
+
+		if writeErr != nil {
+			return nil, nil, fmt.Errorf("failed to write upload: %w", writeErr)
+		}
+	}
+
+	if !upload.IsCompleted() {
+		return upload, nil, nil
+	}
+
+	fileInfo, err := u.completeUpload(ctx, user, upload)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to complete upload: %w", err)
+	}
+
+	return upload, fileInfo, nil
+}
+
+// completeUpload 全て書き込まれたアップロードをファイルとして保存し、アップロードを破棄する。
+// 保存に失敗した場合、続きのバイト数を0としたPATCHで再度保存を試みることができる。
+func (u *Upload) completeUpload(ctx context.Context, user *service.UserInfo, upload *domain.Upload) (*service.FileInfo, error) {
+	// 前回の保存後、アップロードの破棄前に中断された場合は保存済み
+	file, err := u.fileRepository.GetFile(ctx, upload.GetID().FileID(), repository.LockTypeNone)
+	if err != nil && !errors.Is(err, repository.ErrRecordNotFound) {
+		return nil, fmt.Errorf("failed to get file: %w", err)
+	}
+
+	var fileInfo *service.FileInfo
+	if err == nil {
+		fileInfo = &service.FileInfo{
+			File:    file.File,
+			Creator: user,
+		}
+	} else {
+		reader, err := u.uploadStorage.OpenUpload(ctx, upload.GetID())
+		if err != nil {
+			return nil, fmt.Errorf("failed to open upload: %w", err)
+		}
+		defer reader.Close()
+
+		fileInfo, err = u.file.upload(ctx, user, upload.GetID().FileID(), uploadFileName(upload.GetMetadata()), reader)
+		if errors.Is(err, service.ErrInvalidFormat) || errors.Is(err, service.ErrFileTooLarge) {
+			// 再度保存を試みても保存できないので破棄する
+			u.discardUpload(ctx, upload.GetID())
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to upload: %w", err)
+		}
+	}
+
+	u.discardUpload(ctx, upload.GetID())
+
+	return fileInfo, nil
+}
+
+// uploadFileName Upload-Metadataからファイル名を取り出す。
+// tusのクライアントによってキーが異なるので、filenameとnameの順に探す。
+func uploadFileName(metadata values.UploadMetadata) values.FileName {
+	for _, key := range []string{"filename", "name"} {
+		name, ok := metadata.Get(key)
+		if ok && len(name) != 0 {
+			return values.NewFileName(name)
+		}
+	}
+
+	return ""
+}
+
+// discardUpload 破棄に失敗しても、期限切れになった後にGCで再度破棄を試みる
+func (u *Upload) discardUpload(ctx context.Context, uploadID values.UploadID) {
+	err := u.uploadRepository.DeleteUpload(ctx, uploadID)
+	if err != nil && !errors.Is(err, repository.ErrNoRecordDeleted) {
+		log.Printf("error: failed to delete upload(%s): %v\n", uuid.UUID(uploadID).String(), err)
+		return
+	}
+
+	err = u.uploadStorage.DeleteUpload(ctx, uploadID)
+	if err != nil && !errors.Is(err, storage.ErrNotFound) {
+		log.Printf("error: failed to delete upload(%s) from storage: %v\n", uuid.UUID(uploadID).String(), err)
+	}
+}
+
+func (u *Upload) GetMaxUploadSize() int64 {
+	return u.uploadPolicy.getMaxUploadSize()
+}
+
+// lockUpload 書き込み中の場合はErrUploadLockedを返す
+func (u *Upload) lockUpload(uploadID values.UploadID) error {
+	u.locker.Lock()
+	defer u.locker.Unlock()
+
+	if _, ok := u.writingUploads[uploadID]; ok {
+		return service.ErrUploadLocked
+	}
+
+	u.writingUploads[uploadID] = struct{}{}
+
+	return nil
+}
+
+func (u *Upload) unlockUpload(uploadID values.UploadID) {
+	u.locker.Lock()
+	defer u.locker.Unlock()
+
+	delete(u.writingUploads, uploadID)
+}
@@ -0,0 +1,178 @@
+package v1
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/mazrean/Quantainer/domain/values"
+	"github.com/mazrean/Quantainer/pkg/common"
+	"github.com/mazrean/Quantainer/service"
+)
+
+// executableMimeTypes detectMimeTypeで実行ファイルと判定されるMIMEタイプ
+var executableMimeTypes = map[string]struct{}{
+	"application/x-executable":  {},
+	"application/x-msdownload":  {},
+	"application/x-mach-binary": {},
+	"text/x-shellscript":        {},
+}
+
+/*
+UploadPolicy
+アップロードできるファイルの種類と大きさの制限。
+*/
+type UploadPolicy struct {
+	maxSizes          map[values.FileType]int64
+	allowedMimeTypes  []string
+	deniedMimeTypes   []string
+	rejectExecutables bool
+	// stripImageMetadata 画像の位置情報などの機密性の高いメタデータを取り除いて保存するか
+	stripImageMetadata bool
+}
+
+func NewUploadPolicy(
+	maxSize common.UploadMaxSize,
+	maxSizes common.UploadMaxSizes,
+	allowedMimeTypes common.UploadAllowedMimeTypes,
+	deniedMimeTypes common.UploadDeniedMimeTypes,
+	rejectExecutables common.UploadRejectExecutables,
+	stripImageMetadata common.StripImageMetadata,
+) (*UploadPolicy, error) {
+	fileTypeMaxSizes := map[values.FileType]int64{
+		values.FileTypeJpeg:  int64(maxSize),
+		values.FileTypePng:   int64(maxSize),
+		values.FileTypeWebP:  int64(maxSize),
+		values.FileTypeSvg:   int64(maxSize),
+		values.FileTypeGif:   int64(maxSize),
+		values.FileTypeOther: int64(maxSize),
+		values.FileTypeMp3:   int64(maxSize),
+		values.FileTypeOgg:   int64(maxSize),
+		values.FileTypeWav:   int64(maxSize),
+		values.FileTypeFlac:  int64(maxSize),
+		values.FileTypeMp4:   int64(maxSize),
+		values.FileTypeWebM:  int64(maxSize),
+		values.FileTypePdf:   int64(maxSize),
+		values.FileTypeZip:   int64(maxSize),
+		values.FileTypeAvif:  int64(maxSize),
+	}
+	for strFileType, size := range maxSizes {
+		var fileType values.FileType
+		switch strFileType {
+		case "jpeg":
+			fileType = values.FileTypeJpeg
+		case "png":
+			fileType = values.FileTypePng
+		case "webp":
+			fileType = values.FileTypeWebP
+		case "svg":
+			fileType = values.FileTypeSvg
+		case "gif":
+			fileType = values.FileTypeGif
+		case "other":
+			fileType = values.FileTypeOther
+		case "mp3":
+			fileType = values.FileTypeMp3
+		case "ogg":
+			fileType = values.FileTypeOgg
+		case "wav":
+			fileType = values.FileTypeWav
+		case "flac":
+			fileType = values.FileTypeFlac
+		case "mp4":
+			fileType = values.FileTypeMp4
+		case "webm":
+			fileType = values.FileTypeWebM
+		case "pdf":
+			fileType = values.FileTypePdf
+		case "zip":
+			fileType = values.FileTypeZip
+		case "avif":
+			fileType = values.FileTypeAvif
+		default:
+			return nil, fmt.Errorf("invalid file type: %s", strFileType)
+		}
+
+		if size < 0 {
+			return nil, fmt.Errorf("invalid max size(%s): %d", strFileType, size)
+		}
+
+		fileTypeMaxSizes[fileType] = size
+	}
+
+	return &UploadPolicy{
+		maxSizes:           fileTypeMaxSizes,
+		allowedMimeTypes:   normalizeMimeTypes(allowedMimeTypes),
+		deniedMimeTypes:    normalizeMimeTypes(deniedMimeTypes),
+		rejectExecutables:  bool(rejectExecutables),
+		stripImageMetadata: bool(stripImageMetadata),
+	}, nil
+}
+
+func normalizeMimeTypes(mimeTypes []string) []string {
+	normalized := make([]string, 0, len(mimeTypes))
+	for _, mimeType := range mimeTypes {
+		mimeType = strings.ToLower(strings.TrimSpace(mimeType))
+		if len(mimeType) != 0 {
+			normalized = append(normalized, mimeType)
+		}
+	}
+
+	return normalized
+}
+
+// getMaxSize ファイルのバイト数の上限。0の場合は無制限。
+func (up *UploadPolicy) getMaxSize(fileType values.FileType) int64 {
+	return up.maxSizes[fileType]
+}
+
+// getMaxUploadSize 種類がわからないファイルのバイト数の上限。
+// 全ての種類の上限のうち最大のもので、いずれかの種類が無制限の場合は0。
+func (up *UploadPolicy) getMaxUploadSize() int64 {
+	var maxSize int64
+	for _, size := range up.maxSizes {
+		if size == 0 {
+			return 0
+		}
+
+		if size > maxSize {
+			maxSize = size
+		}
+	}
+
+	return maxSize
+}
+
+// checkMimeType アップロードできないMIMEタイプの場合はErrInvalidFormatを返す。
+// 拒否リストは許可リストより優先される。
+func (up *UploadPolicy) checkMimeType(mimeType string) error {
+	mimeType = strings.ToLower(mimeType)
+
+	if _, ok := executableMimeTypes[mimeType]; ok && up.rejectExecutables {
+		return fmt.Errorf("executable is not allowed(%s): %w", mimeType, service.ErrInvalidFormat)
+	}
+
+	if matchMimeTypes(up.deniedMimeTypes, mimeType) {
+		return fmt.Errorf("mime type is denied(%s): %w", mimeType, service.ErrInvalidFormat)
+	}
+
+	if len(up.allowedMimeTypes) != 0 && !matchMimeTypes(up.allowedMimeTypes, mimeType) {
+		return fmt.Errorf("mime type is not allowed(%s): %w", mimeType, service.ErrInvalidFormat)
+	}
+
+	return nil
+}
+
+// matchMimeTypes image/*のようにサブタイプを省略したパターンにも一致させる
+func matchMimeTypes(patterns []string, mimeType string) bool {
+	for _, pattern := range patterns {
+		if pattern == "*/*" || pattern == mimeType {
+			return true
+		}
+
+		if strings.HasSuffix(pattern, "/*") && strings.HasPrefix(mimeType, strings.TrimSuffix(pattern, "*")) {
+			return true
+		}
+	}
+
+	return false
+}
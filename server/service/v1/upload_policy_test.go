@@ -0,0 +1,205 @@
+package v1
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/mazrean/Quantainer/domain/values"
+	"github.com/mazrean/Quantainer/pkg/common"
+	"github.com/mazrean/Quantainer/service"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewUploadPolicy(t *testing.T) {
+	t.Parallel()
+
+	type test struct {
+		description string
+		maxSize     int64
+		maxSizes    map[string]int64
+		isErr       bool
+		expected    map[values.FileType]int64
+		// maxUploadSize 種類がわからないファイルの上限
+		maxUploadSize int64
+	}
+
+	testCases := []test{
+		{
+			description: "種類ごとの上限がなければデフォルトの上限",
+			maxSize:     100,
+			maxSizes:    map[string]int64{},
+			expected: map[values.FileType]int64{
+				values.FileTypeJpeg:  100,
+				values.FileTypeOther: 100,
+			},
+			maxUploadSize: 100,
+		},
+		{
+			description: "種類がわからないファイルの上限は最も大きい上限",
+			maxSize:     100,
+			maxSizes: map[string]int64{
+				"jpeg": 300,
+				"png":  50,
+			},
+			expected: map[values.FileType]int64{
+				values.FileTypeJpeg: 300,
+				values.FileTypePng:  50,
+				values.FileTypeGif:  100,
+			},
+			maxUploadSize: 300,
+		},
+		{
+			description: "デフォルトの上限がなければ種類がわからないファイルも無制限",
+			maxSizes: map[string]int64{
+				"jpeg": 300,
+			},
+			expected: map[values.FileType]int64{
+				values.FileTypeJpeg: 300,
+				values.FileTypePng:  0,
+			},
+			maxUploadSize: 0,
+		},
+		{
+			description: "種類ごとの上限が優先される",
+			maxSize:     100,
+			maxSizes: map[string]int64{
+				"jpeg":  200,
+				"other": 0,
+			},
+			expected: map[values.FileType]int64{
+				values.FileTypeJpeg:  200,
+				values.FileTypePng:   100,
+				values.FileTypeOther: 0,
+			},
+			maxUploadSize: 0,
+		},
+		{
+			description: "存在しない種類なのでエラー",
+			maxSizes: map[string]int64{
+				"exe": 100,
+			},
+			isErr: true,
+		},
+		{
+			description: "負の上限なのでエラー",
+			maxSizes: map[string]int64{
+				"png": -1,
+			},
+			isErr: true,
+		},
+	}
+
+	for _, testCase := range testCases {
+		testCase := testCase
+		t.Run(testCase.description, func(t *testing.T) {
+			t.Parallel()
+
+			uploadPolicy, err := NewUploadPolicy(
+				common.UploadMaxSize(testCase.maxSize),
+				common.UploadMaxSizes(testCase.maxSizes),
+				nil,
+				nil,
+				false,
+				true,
+			)
+
+			if testCase.isErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+
+			for fileType, size := range testCase.expected {
+				assert.Equal(t, size, uploadPolicy.getMaxSize(fileType))
+			}
+			assert.Equal(t, testCase.maxUploadSize, uploadPolicy.getMaxUploadSize())
+		})
+	}
+}
+
+func TestCheckMimeType(t *testing.T) {
+	t.Parallel()
+
+	type test struct {
+		description       string
+		allowedMimeTypes  []string
+		deniedMimeTypes   []string
+		rejectExecutables bool
+		mimeType          string
+		isErr             bool
+	}
+
+	testCases := []test{
+		{
+			description: "制限がないので許可",
+			mimeType:    "application/zip",
+		},
+		{
+			description:      "許可リストに含まれるので許可",
+			allowedMimeTypes: []string{"image/png", "application/pdf"},
+			mimeType:         "application/pdf",
+		},
+		{
+			description:      "許可リストに含まれないので拒否",
+			allowedMimeTypes: []string{"image/png"},
+			mimeType:         "application/pdf",
+			isErr:            true,
+		},
+		{
+			description:      "ワイルドカードに一致するので許可",
+			allowedMimeTypes: []string{"image/*"},
+			mimeType:         "image/webp",
+		},
+		{
+			description:      "大文字小文字は区別しない",
+			allowedMimeTypes: []string{" Image/PNG "},
+			mimeType:         "image/png",
+		},
+		{
+			description:      "拒否リストが許可リストより優先される",
+			allowedMimeTypes: []string{"image/*"},
+			deniedMimeTypes:  []string{"image/svg+xml"},
+			mimeType:         "image/svg+xml",
+			isErr:            true,
+		},
+		{
+			description:       "実行ファイルを拒否する",
+			rejectExecutables: true,
+			mimeType:          "application/x-executable",
+			isErr:             true,
+		},
+		{
+			description: "実行ファイルを拒否しない設定なので許可",
+			mimeType:    "application/x-executable",
+		},
+	}
+
+	for _, testCase := range testCases {
+		testCase := testCase
+		t.Run(testCase.description, func(t *testing.T) {
+			t.Parallel()
+
+			uploadPolicy, err := NewUploadPolicy(
+				0,
+				nil,
+				common.UploadAllowedMimeTypes(testCase.allowedMimeTypes),
+				common.UploadDeniedMimeTypes(testCase.deniedMimeTypes),
+				common.UploadRejectExecutables(testCase.rejectExecutables),
+				true,
+			)
+			if err != nil {
+				t.Fatalf("failed to create upload policy: %v", err)
+			}
+
+			err = uploadPolicy.checkMimeType(testCase.mimeType)
+
+			if testCase.isErr {
+				if !errors.Is(err, service.ErrInvalidFormat) {
+					t.Errorf("error must be ErrInvalidFormat, but actual is %v", err)
+				}
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
@@ -0,0 +1,368 @@
+package v1
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	mockAuth "github.com/mazrean/Quantainer/auth/mock"
+	mockCache "github.com/mazrean/Quantainer/cache/mock"
+	"github.com/mazrean/Quantainer/domain"
+	"github.com/mazrean/Quantainer/domain/values"
+	"github.com/mazrean/Quantainer/pkg/common"
+	"github.com/mazrean/Quantainer/repository"
+	mockRepository "github.com/mazrean/Quantainer/repository/mock"
+	"github.com/mazrean/Quantainer/scanner/noop"
+	"github.com/mazrean/Quantainer/service"
+	"github.com/mazrean/Quantainer/storage"
+	"github.com/mazrean/Quantainer/storage/local"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResumableUpload(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	rootPath := "./upload_test"
+	defer func() {
+		err := os.RemoveAll(rootPath)
+		if err != nil {
+			t.Fatalf("failed to remove directory: %v", err)
+		}
+	}()
+
+	fileStorage, err := local.NewFile(local.NewDirectoryManager(common.FilePath(path.Join(rootPath, "files"))))
+	if err != nil {
+		t.Fatalf("failed to create storage: %v", err)
+	}
+
+	uploadStorage, err := local.NewUpload(local.NewDirectoryManager(common.FilePath(rootPath)))
+	if err != nil {
+		t.Fatalf("failed to create upload storage: %v", err)
+	}
+
+	mockUserCache := mockCache.NewMockUser(ctrl)
+	mockUserAuth := mockAuth.NewMockUser(ctrl)
+	mockDBRepository := mockRepository.NewMockDB(ctrl)
+	mockFileRepository := mockRepository.NewMockFile(ctrl)
+	mockRenditionRepository := mockRepository.NewMockRendition(ctrl)
+	mockResourceRepository := mockRepository.NewMockResource(ctrl)
+	mockGroupRepository := mockRepository.NewMockGroup(ctrl)
+	mockQuotaRepository := mockRepository.NewMockQuota(ctrl)
+	mockUploadRepository := mockRepository.NewMockUpload(ctrl)
+
+	userUtils := NewUserUtils(mockUserAuth, mockUserCache, common.Administrators{})
+	quotaUtils := NewQuotaUtils(mockQuotaRepository, mockFileRepository, 0, 0, 0, 0)
+	uploadPolicy, err := NewUploadPolicy(100, nil, nil, nil, true, true)
+	if err != nil {
+		t.Fatalf("failed to create upload policy: %v", err)
+	}
+
+	fileService := NewFile(
+		mockDBRepository,
+		mockFileRepository,
+		mockRenditionRepository,
+		mockResourceRepository,
+		mockGroupRepository,
+		fileStorage,
+		noop.NewFile(),
+		userUtils,
+		quotaUtils,
+		uploadPolicy,
+	)
+
+	uploadService := NewUpload(
+		mockFileRepository,
+		mockUploadRepository,
+		uploadStorage,
+		userUtils,
+		quotaUtils,
+		uploadPolicy,
+		fileService,
+	)
+
+	session := domain.NewOIDCSession(
+		values.NewOIDCAccessToken("access token"),
+		time.Now().Add(time.Hour),
+	)
+	user := service.NewUserInfo(
+		values.NewTrapMemberID(uuid.New()),
+		values.NewTrapMemberName("mazrean"),
+		values.TrapMemberStatusActive,
+	)
+
+	mockUserCache.
+		EXPECT().
+		GetMe(gomock.Any(), gomock.Any()).
+		Return(user, nil).
+		AnyTimes()
+	mockQuotaRepository.
+		EXPECT().
+		GetUserQuota(gomock.Any(), user.GetID()).
+		Return(nil, repository.ErrRecordNotFound).
+		AnyTimes()
+	mockDBRepository.
+		EXPECT().
+		Transaction(gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, _ interface{}, fn func(ctx context.Context) error) error {
+			return fn(ctx)
+		}).
+		AnyTimes()
+
+	// DBの代わりにアップロードの状態を保持する
+	uploads := map[values.UploadID]*repository.UploadWithCreator{}
+	mockUploadRepository.
+		EXPECT().
+		SaveUpload(gomock.Any(), user.GetID(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, userID values.TraPMemberID, upload *domain.Upload) error {
+			uploads[upload.GetID()] = &repository.UploadWithCreator{
+				Upload:  upload,
+				Creator: userID,
+			}
+			return nil
+		}).
+		AnyTimes()
+	mockUploadRepository.
+		EXPECT().
+		GetUpload(gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, uploadID values.UploadID, _ repository.LockType) (*repository.UploadWithCreator, error) {
+			upload, ok := uploads[uploadID]
+			if !ok {
+				return nil, repository.ErrRecordNotFound
+			}
+
+			return &repository.UploadWithCreator{
+				Upload: domain.NewUpload(
+					upload.GetID(),
+					upload.GetLength(),
+					upload.GetOffset(),
+					upload.GetMetadata(),
+					upload.GetExpiresAt(),
+					upload.GetCreatedAt(),
+				),
+				Creator: upload.Creator,
+			}, nil
+		}).
+		AnyTimes()
+	mockUploadRepository.
+		EXPECT().
+		UpdateUploadOffset(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, upload *domain.Upload) error {
+			uploads[upload.GetID()].Upload = upload
+			return nil
+		}).
+		AnyTimes()
+	mockUploadRepository.
+		EXPECT().
+		DeleteUpload(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, uploadID values.UploadID) error {
+			delete(uploads, uploadID)
+			return nil
+		}).
+		AnyTimes()
+
+	_, err = uploadService.CreateUpload(ctx, session, 101, "")
+	if !errors.Is(err, service.ErrFileTooLarge) {
+		t.Errorf("error must be ErrFileTooLarge, but actual is %v", err)
+	}
+
+	content := "resumable upload content"
+
+	upload, err := uploadService.CreateUpload(ctx, session, int64(len(content)), "filename dGVzdC50eHQ=")
+	if err != nil {
+		t.Fatalf("failed to create upload: %v", err)
+	}
+	assert.Equal(t, int64(0), upload.GetOffset())
+
+	// 途中で切断された書き込み
+	upload, fileInfo, err := uploadService.WriteUpload(ctx, session, upload.GetID(), 0, strings.NewReader(content[:10]))
+	if err != nil {
+		t.Fatalf("failed to write upload: %v", err)
+	}
+	assert.Equal(t, int64(10), upload.GetOffset())
+	assert.Nil(t, fileInfo)
+
+	_, _, err = uploadService.WriteUpload(ctx, session, upload.GetID(), 0, strings.NewReader(content))
+	if !errors.Is(err, service.ErrUploadOffsetMismatch) {
+		t.Errorf("error must be ErrUploadOffsetMismatch, but actual is %v", err)
+	}
+
+	upload, err = uploadService.GetUpload(ctx, session, upload.GetID())
+	if err != nil {
+		t.Fatalf("failed to get upload: %v", err)
+	}
+	assert.Equal(t, int64(10), upload.GetOffset())
+
+	mockFileRepository.
+		EXPECT().
+		GetFile(gomock.Any(), upload.GetID().FileID(), repository.LockTypeNone).
+		Return(nil, repository.ErrRecordNotFound)
+	mockFileRepository.
+		EXPECT().
+		SaveFile(gomock.Any(), user, gomock.Any()).
+		Return(nil)
+
+	// Upload-Lengthを超える分は書き込まない
+	upload, fileInfo, err = uploadService.WriteUpload(ctx, session, upload.GetID(), 10, strings.NewReader(content[10:]+"extra"))
+	if err != nil {
+		t.Fatalf("failed to write upload: %v", err)
+	}
+	assert.Equal(t, int64(len(content)), upload.GetOffset())
+	if !assert.NotNil(t, fileInfo) {
+		return
+	}
+
+	assert.Equal(t, upload.GetID().FileID(), fileInfo.File.GetID())
+	assert.Equal(t, values.FileTypeOther, fileInfo.File.GetType())
+	assert.Equal(t, int64(len(content)), fileInfo.File.GetSize())
+	assert.Equal(t, values.FileName("test.txt"), fileInfo.File.GetOriginalName())
+	assert.Equal(t, int64(len(content)), fileInfo.File.GetOriginalSize())
+
+	buf := bytes.NewBuffer(nil)
+	err = fileStorage.GetFile(ctx, fileInfo.File, buf)
+	if err != nil {
+		t.Fatalf("failed to get file: %v", err)
+	}
+	assert.Equal(t, content, buf.String())
+
+	// 保存後はアップロードを破棄する
+	_, err = uploadService.GetUpload(ctx, session, upload.GetID())
+	assert.ErrorIs(t, err, service.ErrNoUpload)
+
+	_, err = uploadStorage.OpenUpload(ctx, upload.GetID())
+	assert.ErrorIs(t, err, storage.ErrNotFound)
+}
+
+func TestCreateUploadQuota(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	type test struct {
+		description  string
+		length       int64
+		stagedLength int64
+		isErr        bool
+		err          error
+	}
+
+	testCases := []test{
+		{
+			description:  "書き込み途中のアップロードを除いた残りに収まるので作成できる",
+			length:       30,
+			stagedLength: 30,
+		},
+		{
+			description:  "書き込み途中のアップロードを除いた残りを超えるのでエラー",
+			length:       31,
+			stagedLength: 30,
+			isErr:        true,
+			err:          service.ErrQuotaExceeded,
+		},
+		{
+			description:  "書き込み途中のアップロードがなければ残りまで作成できる",
+			length:       60,
+			stagedLength: 0,
+		},
+	}
+
+	for _, testCase := range testCases {
+		testCase := testCase
+
+		t.Run(testCase.description, func(t *testing.T) {
+			t.Parallel()
+
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			uploadStorage, err := local.NewUpload(local.NewDirectoryManager(common.FilePath(t.TempDir())))
+			if err != nil {
+				t.Fatalf("failed to create upload storage: %v", err)
+			}
+
+			mockUserCache := mockCache.NewMockUser(ctrl)
+			mockUserAuth := mockAuth.NewMockUser(ctrl)
+			mockFileRepository := mockRepository.NewMockFile(ctrl)
+			mockQuotaRepository := mockRepository.NewMockQuota(ctrl)
+			mockUploadRepository := mockRepository.NewMockUpload(ctrl)
+
+			userUtils := NewUserUtils(mockUserAuth, mockUserCache, common.Administrators{})
+			quotaUtils := NewQuotaUtils(mockQuotaRepository, mockFileRepository, 100, 0, 0, 0)
+			uploadPolicy, err := NewUploadPolicy(100, nil, nil, nil, true, true)
+			if err != nil {
+				t.Fatalf("failed to create upload policy: %v", err)
+			}
+
+			uploadService := NewUpload(
+				mockFileRepository,
+				mockUploadRepository,
+				uploadStorage,
+				userUtils,
+				quotaUtils,
+				uploadPolicy,
+				nil,
+			)
+
+			session := domain.NewOIDCSession(
+				values.NewOIDCAccessToken("access token"),
+				time.Now().Add(time.Hour),
+			)
+			user := service.NewUserInfo(
+				values.NewTrapMemberID(uuid.New()),
+				values.NewTrapMemberName("mazrean"),
+				values.TrapMemberStatusActive,
+			)
+
+			mockUserCache.
+				EXPECT().
+				GetMe(gomock.Any(), gomock.Any()).
+				Return(user, nil)
+			mockQuotaRepository.
+				EXPECT().
+				GetUserQuota(gomock.Any(), user.GetID()).
+				Return(nil, repository.ErrRecordNotFound)
+			mockFileRepository.
+				EXPECT().
+				GetUserUsage(gomock.Any(), user.GetID()).
+				Return(&service.StorageUsage{Bytes: 40, Files: 1}, nil)
+			mockUploadRepository.
+				EXPECT().
+				GetUserUploadLength(gomock.Any(), user.GetID(), gomock.Any()).
+				Return(testCase.stagedLength, nil)
+			if !testCase.isErr {
+				mockUploadRepository.
+					EXPECT().
+					SaveUpload(gomock.Any(), user.GetID(), gomock.Any()).
+					Return(nil)
+			}
+
+			upload, err := uploadService.CreateUpload(ctx, session, testCase.length, "")
+
+			if testCase.isErr {
+				if testCase.err == nil {
+					assert.Error(t, err)
+				} else if !errors.Is(err, testCase.err) {
+					t.Errorf("error must be %v, but actual is %v", testCase.err, err)
+				}
+			} else {
+				assert.NoError(t, err)
+			}
+			if err != nil {
+				return
+			}
+
+			assert.Equal(t, testCase.length, upload.GetLength())
+		})
+	}
+}
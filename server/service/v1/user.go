@@ -9,6 +9,8 @@ import (
 	"github.com/mazrean/Quantainer/auth"
 	"github.com/mazrean/Quantainer/cache"
 	"github.com/mazrean/Quantainer/domain"
+	"github.com/mazrean/Quantainer/domain/values"
+	"github.com/mazrean/Quantainer/pkg/common"
 	"github.com/mazrean/Quantainer/service"
 )
 
@@ -36,17 +38,30 @@ func (u *User) GetAllActiveUser(ctx context.Context, session *domain.OIDCSession
 	TODO: 名前をもちょっとどうにかしたい。
 */
 type UserUtils struct {
-	userAuth  auth.User
-	userCache cache.User
+	userAuth       auth.User
+	userCache      cache.User
+	administrators map[values.TraPMemberName]struct{}
 }
 
-func NewUserUtils(userAuth auth.User, userCache cache.User) *UserUtils {
+func NewUserUtils(userAuth auth.User, userCache cache.User, administrators common.Administrators) *UserUtils {
+	administratorMap := make(map[values.TraPMemberName]struct{}, len(administrators))
+	for _, administrator := range administrators {
+		administratorMap[values.NewTrapMemberName(administrator)] = struct{}{}
+	}
+
 	return &UserUtils{
-		userAuth:  userAuth,
-		userCache: userCache,
+		userAuth:       userAuth,
+		userCache:      userCache,
+		administrators: administratorMap,
 	}
 }
 
+// isAdministrator 他のユーザーのファイルやリソースも削除できる、アプリケーション全体の管理者か
+func (uu *UserUtils) isAdministrator(user *service.UserInfo) bool {
+	_, ok := uu.administrators[user.GetName()]
+	return ok
+}
+
 func (uu *UserUtils) getMe(ctx context.Context, session *domain.OIDCSession) (*service.UserInfo, error) {
 	user, err := uu.userCache.GetMe(ctx, session.GetAccessToken())
 	if err != nil && !errors.Is(err, cache.ErrCacheMiss) {
@@ -13,6 +13,7 @@ import (
 	mockCache "github.com/mazrean/Quantainer/cache/mock"
 	"github.com/mazrean/Quantainer/domain"
 	"github.com/mazrean/Quantainer/domain/values"
+	"github.com/mazrean/Quantainer/pkg/common"
 	"github.com/mazrean/Quantainer/service"
 	"github.com/stretchr/testify/assert"
 )
@@ -28,7 +29,7 @@ func TestGetMe(t *testing.T) {
 	mockUserCache := mockCache.NewMockUser(ctrl)
 	mockUserAuth := mockAuth.NewMockUser(ctrl)
 
-	userUtils := NewUserUtils(mockUserAuth, mockUserCache)
+	userUtils := NewUserUtils(mockUserAuth, mockUserCache, common.Administrators{})
 
 	userService := NewUser(userUtils)
 
@@ -143,7 +144,7 @@ func TestGetAllActiveUser(t *testing.T) {
 	mockUserCache := mockCache.NewMockUser(ctrl)
 	mockUserAuth := mockAuth.NewMockUser(ctrl)
 
-	userUtils := NewUserUtils(mockUserAuth, mockUserCache)
+	userUtils := NewUserUtils(mockUserAuth, mockUserCache, common.Administrators{})
 
 	userService := NewUser(userUtils)
 
@@ -0,0 +1,264 @@
+package encryption
+
+import (
+	"bytes"
+	"context"
+	"crypto/cipher"
+	"crypto/rand"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/mazrean/Quantainer/domain"
+	"github.com/mazrean/Quantainer/storage"
+)
+
+// File 保存する内容をファイルごとのデータ鍵でAES-GCMにより暗号化するstorage.File。
+// データ鍵はマスター鍵で包んで内容の先頭に保存する。
+// 内容の保存・参照カウントはfileに任せるので、ハッシュは暗号化した内容のものになり、
+// データ鍵が異なる同じ内容のファイルは実体を共有しない。
+// 暗号化前に保存された内容はそのまま読み込む。
+type File struct {
+	file    storage.File
+	keyring *Keyring
+}
+
+func NewFile(file storage.File, keyring *Keyring) *File {
+	return &File{
+		file:    file,
+		keyring: keyring,
+	}
+}
+
+func (f *File) SaveFile(ctx context.Context, file *domain.File, reader io.Reader) error {
+	h, aead, err := f.newHeader()
+	if err != nil {
+		return fmt.Errorf("failed to create header: %w", err)
+	}
+
+	encryptReader := newEncryptReader(reader, h, aead)
+
+	err = f.file.SaveFile(ctx, file, encryptReader)
+	if err != nil {
+		return fmt.Errorf("failed to save file: %w", err)
+	}
+
+	// バイト数は使用量の計算などに使うので、暗号化前のものにする
+	file.SetSize(encryptReader.size)
+
+	return nil
+}
+
+func (f *File) GetFile(ctx context.Context, file *domain.File, writer io.Writer) error {
+	reader, err := f.OpenFile(ctx, file)
+	if err != nil {
+		return fmt.Errorf("failed to open file: %w", err)
+	}
+	defer reader.Close()
+
+	_, err = io.Copy(writer, reader)
+	if err != nil {
+		return fmt.Errorf("failed to copy: %w", err)
+	}
+
+	return nil
+}
+
+func (f *File) OpenFile(ctx context.Context, file *domain.File) (io.ReadSeekCloser, error) {
+	reader, err := f.file.OpenFile(ctx, file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+
+	decryptReader, err := f.newDecryptReader(reader)
+	if err != nil {
+		_ = reader.Close()
+		return nil, err
+	}
+
+	return decryptReader, nil
+}
+
+func (f *File) DeleteFile(ctx context.Context, file *domain.File) error {
+	return f.file.DeleteFile(ctx, file)
+}
+
+func (f *File) DeleteUnreferencedObjects(ctx context.Context, before time.Time) (int, error) {
+	return f.file.DeleteUnreferencedObjects(ctx, before)
+}
+
+func (f *File) ListFiles(ctx context.Context, fn func(entry *storage.FileEntry) error) error {
+	return f.file.ListFiles(ctx, fn)
+}
+
+func (f *File) RotateKey(ctx context.Context, file *domain.File) (*domain.File, bool, error) {
+	reader, err := f.file.OpenFile(ctx, file)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to open file: %w", err)
+	}
+	defer reader.Close()
+
+	isEncrypted, err := readMagic(reader)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to read magic: %w", err)
+	}
+
+	currentKeyID, currentAEAD := f.keyring.current()
+
+	// 保存し直した内容のハッシュとバイト数が設定されるので、元のファイルとは別に用意する
+	rotatedFile := domain.NewFile(
+		file.GetID(),
+		file.GetType(),
+		file.GetHash(),
+		file.GetSize(),
+		file.GetImageMetadata(),
+		file.GetCreatedAt(),
+	)
+
+	if !isEncrypted {
+		_, err = reader.Seek(0, io.SeekStart)
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to seek: %w", err)
+		}
+
+		err = f.SaveFile(ctx, rotatedFile, reader)
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to save file: %w", err)
+		}
+
+		return rotatedFile, true, nil
+	}
+
+	h, err := readHeader(reader)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to read header: %w", err)
+	}
+
+	if h.keyID == currentKeyID {
+		return nil, false, nil
+	}
+
+	dataKey, err := f.unwrapDataKey(h)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to unwrap data key: %w", err)
+	}
+
+	// 暗号化した本文はデータ鍵が変わらないので、包み直したデータ鍵のヘッダーに付け替えるだけでよい
+	rotatedHeader := &header{
+		keyID:       currentKeyID,
+		noncePrefix: h.noncePrefix,
+	}
+	err = wrapDataKey(rotatedHeader, currentAEAD, dataKey)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to wrap data key: %w", err)
+	}
+
+	err = f.file.SaveFile(ctx, rotatedFile, io.MultiReader(
+		bytes.NewReader(rotatedHeader.marshal()),
+		reader,
+	))
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to save file: %w", err)
+	}
+	rotatedFile.SetSize(file.GetSize())
+
+	return rotatedFile, true, nil
+}
+
+// newHeader 新しいデータ鍵を作り、現在のマスター鍵で包んだヘッダーを返す
+func (f *File) newHeader() (*header, cipher.AEAD, error) {
+	keyID, masterAEAD := f.keyring.current()
+
+	dataKey := make([]byte, dataKeySize)
+	_, err := rand.Read(dataKey)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to generate data key: %w", err)
+	}
+
+	noncePrefix := make([]byte, noncePrefixSize)
+	_, err = rand.Read(noncePrefix)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to generate nonce prefix: %w", err)
+	}
+
+	h := &header{
+		keyID:       keyID,
+		noncePrefix: noncePrefix,
+	}
+	err = wrapDataKey(h, masterAEAD, dataKey)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to wrap data key: %w", err)
+	}
+
+	aead, err := newAEAD(dataKey)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+
+	return h, aead, nil
+}
+
+// newDecryptReader 暗号化前に保存された内容の場合はreaderをそのまま返す
+func (f *File) newDecryptReader(reader io.ReadSeekCloser) (io.ReadSeekCloser, error) {
+	isEncrypted, err := readMagic(reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read magic: %w", err)
+	}
+
+	if !isEncrypted {
+		_, err = reader.Seek(0, io.SeekStart)
+		if err != nil {
+			return nil, fmt.Errorf("failed to seek: %w", err)
+		}
+
+		return reader, nil
+	}
+
+	h, err := readHeader(reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read header: %w", err)
+	}
+
+	dataKey, err := f.unwrapDataKey(h)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unwrap data key: %w", err)
+	}
+
+	aead, err := newAEAD(dataKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+
+	decryptReader, err := newDecryptReader(reader, h, aead)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create decrypt reader: %w", err)
+	}
+
+	return decryptReader, nil
+}
+
+func (f *File) unwrapDataKey(h *header) ([]byte, error) {
+	masterAEAD, err := f.keyring.get(h.keyID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get master key: %w", err)
+	}
+
+	dataKey, err := masterAEAD.Open(nil, h.wrapNonce, h.wrappedKey, h.wrapAAD())
+	if err != nil {
+		return nil, fmt.Errorf("failed to open data key: %w", ErrBroken)
+	}
+
+	return dataKey, nil
+}
+
+func wrapDataKey(h *header, masterAEAD cipher.AEAD, dataKey []byte) error {
+	h.wrapNonce = make([]byte, nonceSize)
+	_, err := rand.Read(h.wrapNonce)
+	if err != nil {
+		return fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	h.wrappedKey = masterAEAD.Seal(nil, h.wrapNonce, dataKey, h.wrapAAD())
+
+	return nil
+}
@@ -0,0 +1,470 @@
+package encryption
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/mazrean/Quantainer/domain"
+	"github.com/mazrean/Quantainer/domain/values"
+	"github.com/mazrean/Quantainer/pkg/common"
+	"github.com/mazrean/Quantainer/storage"
+	"github.com/mazrean/Quantainer/storage/local"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestKey(b byte) []byte {
+	return bytes.Repeat([]byte{b}, masterKeySize)
+}
+
+func newTestFile() *domain.File {
+	return domain.NewFile(
+		values.NewFileID(),
+		values.FileTypeOther,
+		values.FileHash{},
+		0,
+		nil,
+		time.Now(),
+	)
+}
+
+func newTestStorage(t *testing.T, rootPath string) *local.File {
+	t.Helper()
+
+	t.Cleanup(func() {
+		err := os.RemoveAll(rootPath)
+		if err != nil {
+			t.Fatalf("failed to remove directory: %v", err)
+		}
+	})
+
+	fileStorage, err := local.NewFile(local.NewDirectoryManager(common.FilePath(rootPath)))
+	if err != nil {
+		t.Fatalf("failed to create file storage: %v", err)
+	}
+
+	return fileStorage
+}
+
+func readRaw(t *testing.T, fileStorage storage.File, file *domain.File) []byte {
+	t.Helper()
+
+	reader, err := fileStorage.OpenFile(context.Background(), file)
+	if err != nil {
+		t.Fatalf("failed to open file: %v", err)
+	}
+	defer reader.Close()
+
+	raw, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatalf("failed to read file: %v", err)
+	}
+
+	return raw
+}
+
+var errRead = errors.New("read error")
+
+type errReader struct{}
+
+func (errReader) Read([]byte) (int, error) {
+	return 0, errRead
+}
+
+func TestSaveFile(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	localStorage := newTestStorage(t, "./file_test_save")
+
+	keyring, err := NewKeyring(common.StorageEncryptionKeys{
+		{ID: "key", Key: newTestKey(1)},
+	})
+	if err != nil {
+		t.Fatalf("failed to create keyring: %v", err)
+	}
+
+	fileStorage := NewFile(localStorage, keyring)
+
+	type test struct {
+		description string
+		size        int
+	}
+
+	testCases := []test{
+		{
+			description: "空のファイル",
+			size:        0,
+		},
+		{
+			description: "1chunkに収まるファイル",
+			size:        100,
+		},
+		{
+			description: "chunkの大きさちょうどのファイル",
+			size:        chunkSize,
+		},
+		{
+			description: "複数のchunkに分かれるファイル",
+			size:        chunkSize*2 + 100,
+		},
+	}
+
+	for _, testCase := range testCases {
+		testCase := testCase
+
+		t.Run(testCase.description, func(t *testing.T) {
+			t.Parallel()
+
+			content := make([]byte, testCase.size)
+			for i := range content {
+				content[i] = byte(i % 251)
+			}
+
+			file := newTestFile()
+			err := fileStorage.SaveFile(ctx, file, bytes.NewReader(content))
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			assert.Equal(t, int64(testCase.size), file.GetSize())
+
+			raw := readRaw(t, localStorage, file)
+			assert.True(t, bytes.HasPrefix(raw, magic))
+			if testCase.size != 0 {
+				assert.False(t, bytes.Contains(raw, content))
+			}
+
+			reader, err := fileStorage.OpenFile(ctx, file)
+			if !assert.NoError(t, err) {
+				return
+			}
+			defer reader.Close()
+
+			actual, err := io.ReadAll(reader)
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.Equal(t, content, actual)
+
+			buf := bytes.NewBuffer(nil)
+			err = fileStorage.GetFile(ctx, file, buf)
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.Equal(t, content, buf.Bytes())
+		})
+	}
+}
+
+func TestSaveFileReadError(t *testing.T) {
+	t.Parallel()
+
+	localStorage := newTestStorage(t, "./file_test_save_read_error")
+
+	keyring, err := NewKeyring(common.StorageEncryptionKeys{
+		{ID: "key", Key: newTestKey(1)},
+	})
+	if err != nil {
+		t.Fatalf("failed to create keyring: %v", err)
+	}
+
+	fileStorage := NewFile(localStorage, keyring)
+
+	err = fileStorage.SaveFile(context.Background(), newTestFile(), errReader{})
+	// 読み込み元のエラーは呼び出し側で判定できる
+	assert.ErrorIs(t, err, errRead)
+}
+
+func TestOpenFile(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	localStorage := newTestStorage(t, "./file_test_open")
+
+	oldKeyring, err := NewKeyring(common.StorageEncryptionKeys{
+		{ID: "old", Key: newTestKey(1)},
+	})
+	if err != nil {
+		t.Fatalf("failed to create keyring: %v", err)
+	}
+
+	keyring, err := NewKeyring(common.StorageEncryptionKeys{
+		{ID: "new", Key: newTestKey(2)},
+		{ID: "old", Key: newTestKey(1)},
+	})
+	if err != nil {
+		t.Fatalf("failed to create keyring: %v", err)
+	}
+
+	fileStorage := NewFile(localStorage, keyring)
+
+	content := bytes.Repeat([]byte("content"), chunkSize/3)
+
+	type test struct {
+		description string
+		// save fileとして内容を保存する
+		save     func(t *testing.T, file *domain.File)
+		offset   int64
+		expected []byte
+		isErr    bool
+		err      error
+	}
+
+	saveEncrypted := func(t *testing.T, file *domain.File) {
+		err := fileStorage.SaveFile(ctx, file, bytes.NewReader(content))
+		if err != nil {
+			t.Fatalf("failed to save file: %v", err)
+		}
+	}
+
+	// rewrite 暗号化した内容を書き換えて、別のファイルとして保存し直す
+	rewrite := func(fn func(raw []byte) []byte) func(t *testing.T, file *domain.File) {
+		return func(t *testing.T, file *domain.File) {
+			encryptedFile := newTestFile()
+			saveEncrypted(t, encryptedFile)
+
+			raw := readRaw(t, localStorage, encryptedFile)
+			err := localStorage.SaveFile(ctx, file, bytes.NewReader(fn(raw)))
+			if err != nil {
+				t.Fatalf("failed to save file: %v", err)
+			}
+		}
+	}
+
+	testCases := []test{
+		{
+			description: "暗号化した内容を復号できる",
+			save:        saveEncrypted,
+			expected:    content,
+		},
+		{
+			description: "chunkの途中から読み込める",
+			save:        saveEncrypted,
+			offset:      chunkSize + 10,
+			expected:    content[chunkSize+10:],
+		},
+		{
+			description: "以前の鍵で暗号化した内容も復号できる",
+			save: func(t *testing.T, file *domain.File) {
+				err := NewFile(localStorage, oldKeyring).SaveFile(ctx, file, bytes.NewReader(content))
+				if err != nil {
+					t.Fatalf("failed to save file: %v", err)
+				}
+			},
+			expected: content,
+		},
+		{
+			description: "暗号化前に保存された内容はそのまま読み込む",
+			save: func(t *testing.T, file *domain.File) {
+				err := localStorage.SaveFile(ctx, file, bytes.NewReader(content))
+				if err != nil {
+					t.Fatalf("failed to save file: %v", err)
+				}
+			},
+			expected: content,
+		},
+		{
+			description: "magicより短い暗号化前の内容もそのまま読み込む",
+			save: func(t *testing.T, file *domain.File) {
+				err := localStorage.SaveFile(ctx, file, bytes.NewReader([]byte("a")))
+				if err != nil {
+					t.Fatalf("failed to save file: %v", err)
+				}
+			},
+			expected: []byte("a"),
+		},
+		{
+			description: "改ざんされているのでErrBroken",
+			save: rewrite(func(raw []byte) []byte {
+				raw[len(raw)-1] ^= 1
+				return raw
+			}),
+			offset: int64(len(content) - 1),
+			isErr:  true,
+			err:    ErrBroken,
+		},
+		{
+			description: "chunkの境界で切り詰められているのでErrBroken",
+			save: rewrite(func(raw []byte) []byte {
+				return raw[:len(raw)-(len(content)-2*chunkSize)-tagSize]
+			}),
+			offset: chunkSize,
+			isErr:  true,
+			err:    ErrBroken,
+		},
+		{
+			description: "包んだ鍵のIDが書き換えられているのでErrBroken",
+			save: rewrite(func(raw []byte) []byte {
+				raw[len(magic)+1] = 'o'
+				raw[len(magic)+2] = 'l'
+				raw[len(magic)+3] = 'd'
+				return raw
+			}),
+			isErr: true,
+			err:   ErrBroken,
+		},
+		{
+			description: "鍵のIDが存在しないのでErrUnknownKey",
+			save: rewrite(func(raw []byte) []byte {
+				raw[len(magic)+1] = 'x'
+				return raw
+			}),
+			isErr: true,
+			err:   ErrUnknownKey,
+		},
+		{
+			description: "ファイルが存在しないのでErrNotFound",
+			save:        func(t *testing.T, file *domain.File) {},
+			isErr:       true,
+			err:         storage.ErrNotFound,
+		},
+	}
+
+	for _, testCase := range testCases {
+		testCase := testCase
+
+		t.Run(testCase.description, func(t *testing.T) {
+			t.Parallel()
+
+			file := newTestFile()
+			testCase.save(t, file)
+
+			var actual []byte
+			reader, err := fileStorage.OpenFile(ctx, file)
+			if err == nil {
+				defer reader.Close()
+
+				_, err = reader.Seek(testCase.offset, io.SeekStart)
+				if err != nil {
+					t.Fatalf("failed to seek: %v", err)
+				}
+
+				actual, err = io.ReadAll(reader)
+			}
+
+			if testCase.isErr {
+				if testCase.err == nil {
+					assert.Error(t, err)
+				} else if !errors.Is(err, testCase.err) {
+					t.Errorf("error must be %v, but actual is %v", testCase.err, err)
+				}
+				return
+			}
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			assert.Equal(t, testCase.expected, actual)
+		})
+	}
+}
+
+func TestRotateKey(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	localStorage := newTestStorage(t, "./file_test_rotate_key")
+
+	oldKeyring, err := NewKeyring(common.StorageEncryptionKeys{
+		{ID: "old", Key: newTestKey(1)},
+	})
+	if err != nil {
+		t.Fatalf("failed to create keyring: %v", err)
+	}
+
+	keyring, err := NewKeyring(common.StorageEncryptionKeys{
+		{ID: "new", Key: newTestKey(2)},
+		{ID: "old", Key: newTestKey(1)},
+	})
+	if err != nil {
+		t.Fatalf("failed to create keyring: %v", err)
+	}
+
+	// 切り替え後は以前の鍵を使わずに読み込めることを確かめる
+	newKeyring, err := NewKeyring(common.StorageEncryptionKeys{
+		{ID: "new", Key: newTestKey(2)},
+	})
+	if err != nil {
+		t.Fatalf("failed to create keyring: %v", err)
+	}
+
+	fileStorage := NewFile(localStorage, keyring)
+
+	content := bytes.Repeat([]byte("content"), chunkSize/3)
+
+	type test struct {
+		description string
+		storage     storage.File
+		isRotated   bool
+	}
+
+	testCases := []test{
+		{
+			description: "以前の鍵で暗号化されているので切り替える",
+			storage:     NewFile(localStorage, oldKeyring),
+			isRotated:   true,
+		},
+		{
+			description: "暗号化前に保存されているので暗号化する",
+			storage:     localStorage,
+			isRotated:   true,
+		},
+		{
+			description: "現在の鍵で暗号化されているので何もしない",
+			storage:     fileStorage,
+			isRotated:   false,
+		},
+	}
+
+	for _, testCase := range testCases {
+		testCase := testCase
+
+		t.Run(testCase.description, func(t *testing.T) {
+			t.Parallel()
+
+			file := newTestFile()
+			err := testCase.storage.SaveFile(ctx, file, bytes.NewReader(content))
+			if err != nil {
+				t.Fatalf("failed to save file: %v", err)
+			}
+
+			rotatedFile, isRotated, err := fileStorage.RotateKey(ctx, file)
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			assert.Equal(t, testCase.isRotated, isRotated)
+			if !isRotated {
+				return
+			}
+
+			assert.Equal(t, file.GetID(), rotatedFile.GetID())
+			assert.NotEqual(t, file.GetHash(), rotatedFile.GetHash())
+			assert.Equal(t, int64(len(content)), rotatedFile.GetSize())
+
+			reader, err := NewFile(localStorage, newKeyring).OpenFile(ctx, rotatedFile)
+			if !assert.NoError(t, err) {
+				return
+			}
+			defer reader.Close()
+
+			actual, err := io.ReadAll(reader)
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.Equal(t, content, actual)
+
+			_, isRotated, err = fileStorage.RotateKey(ctx, rotatedFile)
+			assert.NoError(t, err)
+			assert.False(t, isRotated)
+		})
+	}
+}
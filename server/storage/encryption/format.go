@@ -0,0 +1,318 @@
+package encryption
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/cipher"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// 暗号化した内容は、ヘッダーの後にchunkSizeごとに分けて暗号化した内容を並べる。
+//
+//	magic(8) | 鍵のIDの長さ(1) | 鍵のID | データ鍵を包む際のnonce(12) | 包んだデータ鍵(48) | nonceの接頭辞(7) | chunk...
+//
+// chunkのnonceは、nonceの接頭辞・chunkの番号(4)・最後のchunkかどうか(1)をつなげたもので、
+// 途中で切り詰められた場合や並び替えられた場合は復号に失敗する。
+// 最後のchunkは必ずchunkSize未満で、内容がchunkSizeの倍数の場合は空のchunkを最後に置く。
+const (
+	chunkSize       = 64 * 1024
+	dataKeySize     = 32
+	noncePrefixSize = 7
+	nonceSize       = 12
+	tagSize         = 16
+	maxChunkCount   = 1 << 32
+)
+
+// magic 暗号化前に保存された内容と区別するため、テキストや画像の先頭には現れないバイト列にする。
+// 最後のバイトは形式のバージョン。
+var magic = []byte{0x00, 'Q', 'T', 'E', 'N', 'C', 0x00, 0x01}
+
+var ErrBroken = errors.New("broken encrypted content")
+
+type header struct {
+	keyID       string
+	wrapNonce   []byte
+	wrappedKey  []byte
+	noncePrefix []byte
+}
+
+// wrapAAD データ鍵を包む際に、包んだ鍵のIDも改ざんされないよう認証する
+func (h *header) wrapAAD() []byte {
+	aad := make([]byte, 0, len(magic)+1+len(h.keyID))
+	aad = append(aad, magic...)
+	aad = append(aad, byte(len(h.keyID)))
+	aad = append(aad, h.keyID...)
+
+	return aad
+}
+
+func (h *header) marshal() []byte {
+	buf := bytes.NewBuffer(make([]byte, 0, h.size()))
+	buf.Write(h.wrapAAD())
+	buf.Write(h.wrapNonce)
+	buf.Write(h.wrappedKey)
+	buf.Write(h.noncePrefix)
+
+	return buf.Bytes()
+}
+
+func (h *header) size() int64 {
+	return int64(len(magic) + 1 + len(h.keyID) + nonceSize + dataKeySize + tagSize + noncePrefixSize)
+}
+
+// readMagic 暗号化された内容であればtrueを返す。
+// magicより短い内容も、暗号化前に保存された内容として扱う。
+func readMagic(reader io.Reader) (bool, error) {
+	buf := make([]byte, len(magic))
+	_, err := io.ReadFull(reader, buf)
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to read magic: %w", err)
+	}
+
+	return bytes.Equal(buf, magic), nil
+}
+
+// readHeader magicの直後からヘッダーを読み込む
+func readHeader(reader io.Reader) (*header, error) {
+	var keyIDLength [1]byte
+	_, err := io.ReadFull(reader, keyIDLength[:])
+	if err != nil {
+		return nil, fmt.Errorf("failed to read key id length: %w", wrapEOF(err))
+	}
+
+	buf := make([]byte, int(keyIDLength[0])+nonceSize+dataKeySize+tagSize+noncePrefixSize)
+	_, err = io.ReadFull(reader, buf)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read header: %w", wrapEOF(err))
+	}
+
+	keyIDEnd := int(keyIDLength[0])
+	wrapNonceEnd := keyIDEnd + nonceSize
+	wrappedKeyEnd := wrapNonceEnd + dataKeySize + tagSize
+
+	return &header{
+		keyID:       string(buf[:keyIDEnd]),
+		wrapNonce:   buf[keyIDEnd:wrapNonceEnd],
+		wrappedKey:  buf[wrapNonceEnd:wrappedKeyEnd],
+		noncePrefix: buf[wrappedKeyEnd:],
+	}, nil
+}
+
+// wrapEOF ヘッダーが途中で終わっている場合は壊れた内容として扱う
+func wrapEOF(err error) error {
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return fmt.Errorf("%v: %w", err, ErrBroken)
+	}
+
+	return err
+}
+
+func chunkNonce(noncePrefix []byte, index int64, isFinal bool) []byte {
+	nonce := make([]byte, nonceSize)
+	copy(nonce, noncePrefix)
+	binary.BigEndian.PutUint32(nonce[noncePrefixSize:], uint32(index))
+	if isFinal {
+		nonce[nonceSize-1] = 1
+	}
+
+	return nonce
+}
+
+// encryptReader 読み込むごとに平文をchunkごとに暗号化して読み出す
+type encryptReader struct {
+	reader      *bufio.Reader
+	aead        cipher.AEAD
+	noncePrefix []byte
+	index       int64
+	pending     []byte
+	sealed      []byte
+	// size 読み込んだ平文のバイト数
+	size   int64
+	isDone bool
+}
+
+// newEncryptReader headerを読み出した後、readerの内容を暗号化して読み出す
+func newEncryptReader(reader io.Reader, h *header, aead cipher.AEAD) *encryptReader {
+	return &encryptReader{
+		// chunkSizeずつ先読みし、足りなければ最後のchunkとする
+		reader:      bufio.NewReaderSize(reader, chunkSize),
+		aead:        aead,
+		noncePrefix: h.noncePrefix,
+		pending:     h.marshal(),
+		sealed:      make([]byte, 0, chunkSize+tagSize),
+	}
+}
+
+func (er *encryptReader) Read(p []byte) (int, error) {
+	for len(er.pending) == 0 {
+		if er.isDone {
+			return 0, io.EOF
+		}
+
+		err := er.sealNext()
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	n := copy(p, er.pending)
+	er.pending = er.pending[n:]
+
+	return n, nil
+}
+
+func (er *encryptReader) sealNext() error {
+	if er.index >= maxChunkCount {
+		return errors.New("too many chunks")
+	}
+
+	// chunkSizeちょうどの場合は、最後に空のchunkを置くので最後のchunkではない
+	data, err := er.reader.Peek(chunkSize)
+	isFinal := len(data) < chunkSize
+	if isFinal && err != nil && !errors.Is(err, io.EOF) {
+		// 読み込み元のエラーは呼び出し側で判定できるよう、そのまま返す
+		return err
+	}
+
+	er.sealed = er.aead.Seal(er.sealed[:0], chunkNonce(er.noncePrefix, er.index, isFinal), data, nil)
+	er.pending = er.sealed
+	er.size += int64(len(data))
+	er.index++
+	er.isDone = isFinal
+
+	// Peekした内容はsealedにコピー済みなので、読み捨ててよい
+	_, err = er.reader.Discard(len(data))
+	if err != nil {
+		return fmt.Errorf("failed to discard: %w", err)
+	}
+
+	return nil
+}
+
+// decryptReader chunk単位で復号し、任意の位置から読み込めるようにする
+type decryptReader struct {
+	reader      io.ReadSeekCloser
+	aead        cipher.AEAD
+	noncePrefix []byte
+	headerSize  int64
+	chunkCount  int64
+	// size 平文のバイト数
+	size   int64
+	offset int64
+	// chunkIndex chunkに復号済みのchunkの番号。-1の場合は未復号
+	chunkIndex int64
+	chunk      []byte
+	sealed     []byte
+}
+
+// newDecryptReader readerの全体の長さから平文のバイト数を求める
+func newDecryptReader(reader io.ReadSeekCloser, h *header, aead cipher.AEAD) (*decryptReader, error) {
+	totalSize, err := reader.Seek(0, io.SeekEnd)
+	if err != nil {
+		return nil, fmt.Errorf("failed to seek: %w", err)
+	}
+
+	bodySize := totalSize - h.size()
+	if bodySize < 0 {
+		return nil, fmt.Errorf("too short content: %w", ErrBroken)
+	}
+
+	// 最後のchunkの暗号文はtagSize以上chunkSize+tagSize未満になる
+	fullChunkCount := bodySize / (chunkSize + tagSize)
+	lastChunkSize := bodySize % (chunkSize + tagSize)
+	if lastChunkSize < tagSize {
+		return nil, fmt.Errorf("invalid content size(%d): %w", totalSize, ErrBroken)
+	}
+
+	return &decryptReader{
+		reader:      reader,
+		aead:        aead,
+		noncePrefix: h.noncePrefix,
+		headerSize:  h.size(),
+		chunkCount:  fullChunkCount + 1,
+		size:        fullChunkCount*chunkSize + lastChunkSize - tagSize,
+		chunkIndex:  -1,
+		chunk:       make([]byte, 0, chunkSize),
+		sealed:      make([]byte, chunkSize+tagSize),
+	}, nil
+}
+
+func (dr *decryptReader) Read(p []byte) (int, error) {
+	if dr.offset >= dr.size {
+		return 0, io.EOF
+	}
+
+	index := dr.offset / chunkSize
+	if index != dr.chunkIndex {
+		err := dr.openChunk(index)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	n := copy(p, dr.chunk[dr.offset-index*chunkSize:])
+	dr.offset += int64(n)
+
+	return n, nil
+}
+
+func (dr *decryptReader) openChunk(index int64) error {
+	// 失敗した場合に、復号前の内容を返さないようにする
+	dr.chunkIndex = -1
+
+	_, err := dr.reader.Seek(dr.headerSize+index*(chunkSize+tagSize), io.SeekStart)
+	if err != nil {
+		return fmt.Errorf("failed to seek: %w", err)
+	}
+
+	isFinal := index == dr.chunkCount-1
+	sealedSize := int64(chunkSize + tagSize)
+	if isFinal {
+		sealedSize = dr.size - index*chunkSize + tagSize
+	}
+
+	sealed := dr.sealed[:sealedSize]
+	_, err = io.ReadFull(dr.reader, sealed)
+	if err != nil {
+		return fmt.Errorf("failed to read chunk: %w", wrapEOF(err))
+	}
+
+	dr.chunk, err = dr.aead.Open(dr.chunk[:0], chunkNonce(dr.noncePrefix, index, isFinal), sealed, nil)
+	if err != nil {
+		return fmt.Errorf("failed to decrypt chunk(%d): %w", index, ErrBroken)
+	}
+	dr.chunkIndex = index
+
+	return nil
+}
+
+func (dr *decryptReader) Seek(offset int64, whence int) (int64, error) {
+	var newOffset int64
+	switch whence {
+	case io.SeekStart:
+		newOffset = offset
+	case io.SeekCurrent:
+		newOffset = dr.offset + offset
+	case io.SeekEnd:
+		newOffset = dr.size + offset
+	default:
+		return 0, errors.New("invalid whence")
+	}
+
+	if newOffset < 0 {
+		return 0, errors.New("negative position")
+	}
+	dr.offset = newOffset
+
+	return newOffset, nil
+}
+
+func (dr *decryptReader) Close() error {
+	return dr.reader.Close()
+}
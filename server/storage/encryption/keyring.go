@@ -0,0 +1,86 @@
+package encryption
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"errors"
+	"fmt"
+
+	"github.com/mazrean/Quantainer/pkg/common"
+)
+
+const (
+	// masterKeySize AES-256の鍵のバイト数
+	masterKeySize = 32
+	// maxKeyIDLength 鍵のIDはヘッダーに1バイトの長さとともに記録する
+	maxKeyIDLength = 255
+)
+
+var ErrUnknownKey = errors.New("unknown key")
+
+// Keyring データ鍵を包むマスター鍵の一覧
+type Keyring struct {
+	currentKeyID string
+	keys         map[string]cipher.AEAD
+}
+
+// NewKeyring 先頭の鍵を新しいデータ鍵を包む現在の鍵とし、残りは以前の鍵として包まれたデータ鍵の取り出しにのみ使う
+func NewKeyring(encryptionKeys common.StorageEncryptionKeys) (*Keyring, error) {
+	if len(encryptionKeys) == 0 {
+		return nil, errors.New("no encryption key")
+	}
+
+	keys := make(map[string]cipher.AEAD, len(encryptionKeys))
+	for _, encryptionKey := range encryptionKeys {
+		if len(encryptionKey.ID) == 0 || len(encryptionKey.ID) > maxKeyIDLength {
+			return nil, fmt.Errorf("invalid key id length(%s)", encryptionKey.ID)
+		}
+
+		if _, ok := keys[encryptionKey.ID]; ok {
+			return nil, fmt.Errorf("duplicate key id(%s)", encryptionKey.ID)
+		}
+
+		if len(encryptionKey.Key) != masterKeySize {
+			return nil, fmt.Errorf("key(%s) must be %d bytes", encryptionKey.ID, masterKeySize)
+		}
+
+		aead, err := newAEAD(encryptionKey.Key)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create cipher: %w", err)
+		}
+
+		keys[encryptionKey.ID] = aead
+	}
+
+	return &Keyring{
+		currentKeyID: encryptionKeys[0].ID,
+		keys:         keys,
+	}, nil
+}
+
+func (k *Keyring) current() (string, cipher.AEAD) {
+	return k.currentKeyID, k.keys[k.currentKeyID]
+}
+
+func (k *Keyring) get(keyID string) (cipher.AEAD, error) {
+	aead, ok := k.keys[keyID]
+	if !ok {
+		return nil, fmt.Errorf("key(%s): %w", keyID, ErrUnknownKey)
+	}
+
+	return aead, nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create block cipher: %w", err)
+	}
+
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create gcm: %w", err)
+	}
+
+	return aead, nil
+}
@@ -3,11 +3,37 @@ package storage
 import (
 	"context"
 	"io"
+	"time"
 
 	"github.com/mazrean/Quantainer/domain"
+	"github.com/mazrean/Quantainer/domain/values"
 )
 
+// File ファイルの内容は内容のSHA-256をキーとして保存し、
+// 同じ内容のファイルは1つの実体を参照カウントで共有する。
+// 複数のプロセスが同じストレージを使っても保存中の実体を削除しないよう、
+// 保存は参照を追加してから実体を置き直し、実体の削除はDeleteUnreferencedObjectsでのみ行う。
 type File interface {
+	// SaveFile readerの内容を保存し、保存した内容のハッシュとバイト数をfileに設定する
 	SaveFile(ctx context.Context, file *domain.File, reader io.Reader) error
 	GetFile(ctx context.Context, file *domain.File, writer io.Writer) error
+	// OpenFile 範囲指定での読み込みのためにシーク可能な形で開く。
+	// 呼び出し側でCloseする必要がある。
+	OpenFile(ctx context.Context, file *domain.File) (io.ReadSeekCloser, error)
+	// DeleteFile fileの参照を削除する。参照がなくなった実体は、DeleteUnreferencedObjectsで削除するまで残る。
+	DeleteFile(ctx context.Context, file *domain.File) error
+	// DeleteUnreferencedObjects before以前に参照がなくなった実体を削除し、削除した数を返す。
+	// 実体を退避してから参照を確認し直し、その間に参照が追加された場合は元に戻す。
+	DeleteUnreferencedObjects(ctx context.Context, before time.Time) (int, error)
+	// ListFiles 保存されている全てのファイルについてfnを呼ぶ。
+	// fnがエラーを返した場合はそこで中断する。
+	ListFiles(ctx context.Context, fn func(entry *FileEntry) error) error
+}
+
+// FileEntry ストレージに保存されているファイル
+type FileEntry struct {
+	FileID values.FileID
+	// Hash ハッシュ導入前に保存されたファイルはゼロ値
+	Hash       values.FileHash
+	ModifiedAt time.Time
 }
@@ -0,0 +1,47 @@
+package storage
+
+import (
+	"crypto/sha256"
+	"hash"
+	"io"
+
+	"github.com/mazrean/Quantainer/domain/values"
+)
+
+// HashReader 読み込んだ内容のSHA-256とバイト数を計算するio.Reader
+type HashReader struct {
+	reader io.Reader
+	hash   hash.Hash
+	size   int64
+}
+
+func NewHashReader(reader io.Reader) *HashReader {
+	return &HashReader{
+		reader: reader,
+		hash:   sha256.New(),
+	}
+}
+
+func (hr *HashReader) Read(p []byte) (int, error) {
+	n, err := hr.reader.Read(p)
+	if n > 0 {
+		// hash.HashのWriteはエラーを返さない
+		_, _ = hr.hash.Write(p[:n])
+		hr.size += int64(n)
+	}
+
+	return n, err
+}
+
+// Sum それまでに読み込んだ内容のハッシュ
+func (hr *HashReader) Sum() values.FileHash {
+	var fileHash values.FileHash
+	copy(fileHash[:], hr.hash.Sum(nil))
+
+	return fileHash
+}
+
+// Size それまでに読み込んだバイト数
+func (hr *HashReader) Size() int64 {
+	return hr.size
+}
@@ -0,0 +1,15 @@
+package storage
+
+import (
+	"context"
+
+	"github.com/mazrean/Quantainer/domain"
+)
+
+// KeyRotator 保存されている内容の暗号化に使う鍵を切り替える
+type KeyRotator interface {
+	// RotateKey fileの内容を現在の鍵で暗号化し直し、元の内容とは別に保存したファイルを返す。
+	// 暗号化前に保存された内容も暗号化する。既に現在の鍵で暗号化されている場合はfalseを返す。
+	// 元の内容は削除しないので、呼び出し側でハッシュを記録し直した後にDeleteFileで削除する必要がある。
+	RotateKey(ctx context.Context, file *domain.File) (*domain.File, bool, error)
+}
@@ -6,60 +6,151 @@ import (
 	"fmt"
 	"io"
 	"io/fs"
+	"log"
 	"os"
 	"path"
+	"sync"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/mazrean/Quantainer/domain"
+	"github.com/mazrean/Quantainer/domain/values"
 	"github.com/mazrean/Quantainer/storage"
 )
 
 type File struct {
-	fileRootPath     string
+	fileRootPath   string
+	objectRootPath string
+	refRootPath    string
+	// orphanRootPath 参照がなくなった実体の印
+	orphanRootPath string
+	// trashRootPath 削除する前に参照を確認し直す間、実体を退避させる先
+	trashRootPath    string
+	tmpRootPath      string
 	directoryManager *DirectoryManager
+	// 参照カウントの更新を直列化するためのロック
+	locker sync.Mutex
 }
 
 func NewFile(directoryManager *DirectoryManager) (*File, error) {
+	// ハッシュ導入前に保存されたファイル
 	fileRootPath, err := directoryManager.setupDirectory("files")
 	if err != nil {
 		return nil, fmt.Errorf("failed to setup directory: %w", err)
 	}
 
+	objectRootPath, err := directoryManager.setupDirectory("objects")
+	if err != nil {
+		return nil, fmt.Errorf("failed to setup directory: %w", err)
+	}
+
+	refRootPath, err := directoryManager.setupDirectory("refs")
+	if err != nil {
+		return nil, fmt.Errorf("failed to setup directory: %w", err)
+	}
+
+	orphanRootPath, err := directoryManager.setupDirectory("orphans")
+	if err != nil {
+		return nil, fmt.Errorf("failed to setup directory: %w", err)
+	}
+
+	trashRootPath, err := directoryManager.setupDirectory("trash")
+	if err != nil {
+		return nil, fmt.Errorf("failed to setup directory: %w", err)
+	}
+
+	tmpRootPath, err := directoryManager.setupDirectory("tmp")
+	if err != nil {
+		return nil, fmt.Errorf("failed to setup directory: %w", err)
+	}
+
 	return &File{
 		fileRootPath:     fileRootPath,
+		objectRootPath:   objectRootPath,
+		refRootPath:      refRootPath,
+		orphanRootPath:   orphanRootPath,
+		trashRootPath:    trashRootPath,
+		tmpRootPath:      tmpRootPath,
 		directoryManager: directoryManager,
 	}, nil
 }
 
 func (f *File) SaveFile(ctx context.Context, file *domain.File, reader io.Reader) error {
-	filePath := path.Join(f.fileRootPath, uuid.UUID(file.GetID()).String())
+	// ハッシュは読み終わるまでわからないので、一時ファイルに書き込んでから移動する
+	tmpFile, err := os.CreateTemp(f.tmpRootPath, "upload-")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	tmpFilePath := tmpFile.Name()
+	defer func() {
+		// 移動済みの場合は存在しないので、エラーは無視する
+		_ = os.Remove(tmpFilePath)
+	}()
+
+	hashReader := storage.NewHashReader(reader)
+
+	_, err = io.Copy(tmpFile, hashReader)
+	if err != nil {
+		_ = tmpFile.Close()
+		return fmt.Errorf("failed to copy: %w", err)
+	}
+
+	err = tmpFile.Close()
+	if err != nil {
+		return fmt.Errorf("failed to close temporary file: %w", err)
+	}
+
+	hash := hashReader.Sum()
+
+	f.locker.Lock()
+	defer f.locker.Unlock()
 
-	_, err := os.Stat(filePath)
+	refDirectoryPath := path.Join(f.refRootPath, hash.String())
+	refPath := path.Join(refDirectoryPath, uuid.UUID(file.GetID()).String())
+
+	_, err = os.Stat(refPath)
 	if err == nil {
 		return storage.ErrAlreadyExists
 	}
 	if err != nil && !errors.Is(err, fs.ErrNotExist) {
-		return fmt.Errorf("failed to stat file: %w", err)
+		return fmt.Errorf("failed to stat ref: %w", err)
 	}
 
-	fl, err := os.Create(filePath)
+	// 他のプロセスが実体を削除していても参照の確認で止まるよう、実体より先に参照を追加する
+	err = os.MkdirAll(refDirectoryPath, 0755)
 	if err != nil {
-		return fmt.Errorf("failed to open file: %w", err)
+		return fmt.Errorf("failed to create ref directory: %w", err)
 	}
-	defer fl.Close()
 
-	_, err = io.Copy(fl, reader)
+	ref, err := os.OpenFile(refPath, os.O_CREATE|os.O_EXCL, 0644)
 	if err != nil {
-		return fmt.Errorf("failed to copy: %w", err)
+		return fmt.Errorf("failed to create ref: %w", err)
+	}
+
+	err = ref.Close()
+	if err != nil {
+		return fmt.Errorf("failed to close ref: %w", err)
 	}
 
+	// 同じ内容のオブジェクトが既にあっても、削除中の場合に備えて置き直す
+	err = os.Rename(tmpFilePath, path.Join(f.objectRootPath, hash.String()))
+	if err != nil {
+		deleteErr := f.deleteRef(hash, file.GetID())
+		if deleteErr != nil {
+			log.Printf("error: failed to delete ref: %v\n", deleteErr)
+		}
+
+		return fmt.Errorf("failed to move object: %w", err)
+	}
+
+	file.SetHash(hash)
+	file.SetSize(hashReader.Size())
+
 	return nil
 }
 
 func (f *File) GetFile(ctx context.Context, file *domain.File, writer io.Writer) error {
-	filePath := path.Join(f.fileRootPath, uuid.UUID(file.GetID()).String())
-
-	fl, err := os.Open(filePath)
+	fl, err := f.openObject(file)
 	if errors.Is(err, fs.ErrNotExist) {
 		return storage.ErrNotFound
 	}
@@ -75,3 +166,281 @@ func (f *File) GetFile(ctx context.Context, file *domain.File, writer io.Writer)
 
 	return nil
 }
+
+func (f *File) OpenFile(ctx context.Context, file *domain.File) (io.ReadSeekCloser, error) {
+	fl, err := f.openObject(file)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, storage.ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+
+	return fl, nil
+}
+
+func (f *File) DeleteFile(ctx context.Context, file *domain.File) error {
+	f.locker.Lock()
+	defer f.locker.Unlock()
+
+	hash := file.GetHash()
+	if !hash.IsZero() {
+		_, err := os.Stat(path.Join(f.refRootPath, hash.String(), uuid.UUID(file.GetID()).String()))
+		if err == nil {
+			return f.deleteRef(hash, file.GetID())
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("failed to stat ref: %w", err)
+		}
+	}
+
+	// 参照がない場合、ハッシュ導入前に保存されたファイルの可能性がある
+	err := os.Remove(path.Join(f.fileRootPath, uuid.UUID(file.GetID()).String()))
+	if errors.Is(err, fs.ErrNotExist) {
+		return storage.ErrNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to remove file: %w", err)
+	}
+
+	return nil
+}
+
+// deleteRef 参照を削除し、参照がなくなった場合はDeleteUnreferencedObjectsで実体を削除するよう印を付ける
+func (f *File) deleteRef(hash values.FileHash, fileID values.FileID) error {
+	err := os.Remove(path.Join(f.refRootPath, hash.String(), uuid.UUID(fileID).String()))
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("failed to remove ref: %w", err)
+	}
+
+	isRefExist, err := f.hasRefs(hash)
+	if err != nil {
+		return fmt.Errorf("failed to check refs: %w", err)
+	}
+	if isRefExist {
+		return nil
+	}
+
+	orphan, err := os.Create(path.Join(f.orphanRootPath, hash.String()))
+	if err != nil {
+		return fmt.Errorf("failed to create orphan: %w", err)
+	}
+
+	err = orphan.Close()
+	if err != nil {
+		return fmt.Errorf("failed to close orphan: %w", err)
+	}
+
+	return nil
+}
+
+func (f *File) hasRefs(hash values.FileHash) (bool, error) {
+	refs, err := os.ReadDir(path.Join(f.refRootPath, hash.String()))
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to read ref directory: %w", err)
+	}
+
+	return len(refs) != 0, nil
+}
+
+func (f *File) DeleteUnreferencedObjects(ctx context.Context, before time.Time) (int, error) {
+	orphans, err := os.ReadDir(f.orphanRootPath)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read orphan directory: %w", err)
+	}
+
+	var deleted int
+	for _, orphan := range orphans {
+		hash, err := values.NewFileHashFromString(orphan.Name())
+		if err != nil || hash.IsZero() {
+			log.Printf("error: invalid orphan: %s\n", orphan.Name())
+			continue
+		}
+
+		info, err := orphan.Info()
+		if errors.Is(err, fs.ErrNotExist) {
+			// 一覧の取得後に処理された
+			continue
+		}
+		if err != nil {
+			return deleted, fmt.Errorf("failed to get orphan info: %w", err)
+		}
+		if !info.ModTime().Before(before) {
+			continue
+		}
+
+		isDeleted, err := f.deleteUnreferencedObject(hash)
+		if err != nil {
+			return deleted, fmt.Errorf("failed to delete object(%s): %w", hash.String(), err)
+		}
+
+		if isDeleted {
+			deleted++
+		}
+	}
+
+	return deleted, nil
+}
+
+// deleteUnreferencedObject 参照が残っていなければ実体を削除し、削除したかを返す。
+// 削除の途中で参照が追加されても実体を失わないよう、退避してから参照を確認し直す。
+func (f *File) deleteUnreferencedObject(hash values.FileHash) (bool, error) {
+	f.locker.Lock()
+	defer f.locker.Unlock()
+
+	orphanPath := path.Join(f.orphanRootPath, hash.String())
+
+	// 処理中に参照がなくなった場合に付け直された印を消さないよう、先に印を削除する
+	err := os.Remove(orphanPath)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return false, fmt.Errorf("failed to remove orphan: %w", err)
+	}
+
+	isDeleted, err := f.evictObject(hash)
+	if err != nil {
+		// 次回に再度削除を試みられるよう、印を付け直す
+		orphan, markErr := os.Create(orphanPath)
+		if markErr != nil {
+			log.Printf("error: failed to create orphan: %v\n", markErr)
+		} else {
+			_ = orphan.Close()
+		}
+
+		return false, err
+	}
+
+	return isDeleted, nil
+}
+
+func (f *File) evictObject(hash values.FileHash) (bool, error) {
+	objectPath := path.Join(f.objectRootPath, hash.String())
+	trashPath := path.Join(f.trashRootPath, hash.String())
+
+	isRefExist, err := f.hasRefs(hash)
+	if err != nil {
+		return false, fmt.Errorf("failed to check refs: %w", err)
+	}
+	if isRefExist {
+		// 前回の削除が退避の途中で失敗していた場合は元に戻す
+		return false, restoreObject(trashPath, objectPath)
+	}
+
+	err = os.Rename(objectPath, trashPath)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return false, fmt.Errorf("failed to move object to trash: %w", err)
+	}
+	isMoved := err == nil
+
+	isRefExist, err = f.hasRefs(hash)
+	if err != nil {
+		return false, fmt.Errorf("failed to check refs: %w", err)
+	}
+	if isRefExist {
+		return false, restoreObject(trashPath, objectPath)
+	}
+
+	err = os.Remove(trashPath)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return false, fmt.Errorf("failed to remove trash: %w", err)
+	}
+
+	// 保存中に参照が追加された場合は空でないので削除されない
+	_ = os.Remove(path.Join(f.refRootPath, hash.String()))
+
+	return isMoved, nil
+}
+
+// restoreObject 退避した実体を戻す。保存で置き直されている場合も同じ内容なので上書きする。
+func restoreObject(trashPath string, objectPath string) error {
+	err := os.Rename(trashPath, objectPath)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("failed to restore object: %w", err)
+	}
+
+	return nil
+}
+
+func (f *File) ListFiles(ctx context.Context, fn func(entry *storage.FileEntry) error) error {
+	hashDirectories, err := os.ReadDir(f.refRootPath)
+	if err != nil {
+		return fmt.Errorf("failed to read ref directory: %w", err)
+	}
+
+	for _, hashDirectory := range hashDirectories {
+		hash, err := values.NewFileHashFromString(hashDirectory.Name())
+		if err != nil || hash.IsZero() {
+			log.Printf("error: invalid ref directory: %s\n", hashDirectory.Name())
+			continue
+		}
+
+		refs, err := os.ReadDir(path.Join(f.refRootPath, hashDirectory.Name()))
+		if err != nil {
+			return fmt.Errorf("failed to read ref directory: %w", err)
+		}
+
+		for _, ref := range refs {
+			err = callWithEntry(ref, hash, fn)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	legacyFiles, err := os.ReadDir(f.fileRootPath)
+	if err != nil {
+		return fmt.Errorf("failed to read file directory: %w", err)
+	}
+
+	for _, legacyFile := range legacyFiles {
+		err = callWithEntry(legacyFile, values.FileHash{}, fn)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func callWithEntry(dirEntry fs.DirEntry, hash values.FileHash, fn func(entry *storage.FileEntry) error) error {
+	fileID, err := uuid.Parse(dirEntry.Name())
+	if err != nil {
+		log.Printf("error: invalid file name: %s\n", dirEntry.Name())
+		return nil
+	}
+
+	info, err := dirEntry.Info()
+	if errors.Is(err, fs.ErrNotExist) {
+		// 一覧の取得後に削除された
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get file info: %w", err)
+	}
+
+	return fn(&storage.FileEntry{
+		FileID:     values.NewFileIDFromUUID(fileID),
+		Hash:       hash,
+		ModifiedAt: info.ModTime(),
+	})
+}
+
+// openObject ハッシュ導入前に保存されたファイルは従来のパスに存在する。
+// 移行などで後からハッシュが設定された場合も、従来のパスにしかないことがある。
+func (f *File) openObject(file *domain.File) (*os.File, error) {
+	legacyPath := path.Join(f.fileRootPath, uuid.UUID(file.GetID()).String())
+
+	hash := file.GetHash()
+	if hash.IsZero() {
+		return os.Open(legacyPath)
+	}
+
+	fl, err := os.Open(path.Join(f.objectRootPath, hash.String()))
+	if errors.Is(err, fs.ErrNotExist) {
+		return os.Open(legacyPath)
+	}
+
+	return fl, err
+}
@@ -0,0 +1,462 @@
+package local
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"os"
+	"path"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mazrean/Quantainer/domain"
+	"github.com/mazrean/Quantainer/domain/values"
+	"github.com/mazrean/Quantainer/pkg/common"
+	"github.com/mazrean/Quantainer/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSaveFile(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	rootPath := common.FilePath("./file_test_save")
+
+	directoryManager := NewDirectoryManager(rootPath)
+	defer func() {
+		err := os.RemoveAll(string(rootPath))
+		if err != nil {
+			t.Fatalf("failed to remove directory: %v", err)
+		}
+	}()
+
+	fileStorage, err := NewFile(directoryManager)
+	if err != nil {
+		t.Fatalf("failed to create file storage: %v", err)
+	}
+
+	existFile := domain.NewFile(
+		values.NewFileID(),
+		values.FileTypeOther,
+		values.FileHash{},
+		0,
+		nil,
+		time.Now(),
+	)
+	err = fileStorage.SaveFile(ctx, existFile, strings.NewReader("exist"))
+	if err != nil {
+		t.Fatalf("failed to save file: %v", err)
+	}
+
+	type test struct {
+		description string
+		file        *domain.File
+		content     string
+		isErr       bool
+		err         error
+	}
+
+	testCases := []test{
+		{
+			description: "特に問題ないのでエラーなし",
+			file: domain.NewFile(
+				values.NewFileID(),
+				values.FileTypeOther,
+				values.FileHash{},
+				0,
+				nil,
+				time.Now(),
+			),
+			content: "a",
+		},
+		{
+			description: "同じ内容のファイルがあってもエラーなし",
+			file: domain.NewFile(
+				values.NewFileID(),
+				values.FileTypeOther,
+				values.FileHash{},
+				0,
+				nil,
+				time.Now(),
+			),
+			content: "exist",
+		},
+		{
+			description: "同じIDのファイルがあるのでErrAlreadyExists",
+			file:        existFile,
+			content:     "exist",
+			isErr:       true,
+			err:         storage.ErrAlreadyExists,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.description, func(t *testing.T) {
+			err := fileStorage.SaveFile(ctx, testCase.file, strings.NewReader(testCase.content))
+
+			if testCase.isErr {
+				if testCase.err == nil {
+					assert.Error(t, err)
+				} else if !errors.Is(err, testCase.err) {
+					t.Errorf("error must be %v, but actual is %v", testCase.err, err)
+				}
+			} else {
+				assert.NoError(t, err)
+			}
+			if err != nil {
+				return
+			}
+
+			sum := sha256.Sum256([]byte(testCase.content))
+			hash, err := values.NewFileHash(sum[:])
+			if err != nil {
+				t.Fatalf("failed to create hash: %v", err)
+			}
+			assert.Equal(t, hash, testCase.file.GetHash())
+
+			refPath := path.Join(string(rootPath), "refs", hash.String(), uuid.UUID(testCase.file.GetID()).String())
+			_, err = os.Stat(refPath)
+			assert.NoError(t, err)
+
+			buf := bytes.NewBuffer(nil)
+			err = fileStorage.GetFile(ctx, testCase.file, buf)
+			assert.NoError(t, err)
+			assert.Equal(t, testCase.content, buf.String())
+		})
+	}
+
+	// 同じ内容のファイルは1つのオブジェクトを共有する
+	entries, err := os.ReadDir(path.Join(string(rootPath), "objects"))
+	if err != nil {
+		t.Fatalf("failed to read directory: %v", err)
+	}
+	assert.Len(t, entries, 2)
+
+	refs, err := os.ReadDir(path.Join(string(rootPath), "refs", existFile.GetHash().String()))
+	if err != nil {
+		t.Fatalf("failed to read directory: %v", err)
+	}
+	assert.Len(t, refs, 2)
+}
+
+func TestDeleteFile(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	rootPath := common.FilePath("./file_test_delete")
+
+	directoryManager := NewDirectoryManager(rootPath)
+	defer func() {
+		err := os.RemoveAll(string(rootPath))
+		if err != nil {
+			t.Fatalf("failed to remove directory: %v", err)
+		}
+	}()
+
+	fileStorage, err := NewFile(directoryManager)
+	if err != nil {
+		t.Fatalf("failed to create file storage: %v", err)
+	}
+
+	newFile := func(content string) *domain.File {
+		file := domain.NewFile(
+			values.NewFileID(),
+			values.FileTypeOther,
+			values.FileHash{},
+			0,
+			nil,
+			time.Now(),
+		)
+		err := fileStorage.SaveFile(ctx, file, strings.NewReader(content))
+		if err != nil {
+			t.Fatalf("failed to save file: %v", err)
+		}
+
+		return file
+	}
+
+	sharedFile1 := newFile("shared")
+	sharedFile2 := newFile("shared")
+	singleFile := newFile("single")
+
+	legacyFile := domain.NewFile(
+		values.NewFileID(),
+		values.FileTypeOther,
+		values.FileHash{},
+		0,
+		nil,
+		time.Now(),
+	)
+	legacyFilePath := path.Join(string(rootPath), "files", uuid.UUID(legacyFile.GetID()).String())
+	err = os.WriteFile(legacyFilePath, []byte("legacy"), 0644)
+	if err != nil {
+		t.Fatalf("failed to write legacy file: %v", err)
+	}
+
+	objectPath := func(file *domain.File) string {
+		return path.Join(string(rootPath), "objects", file.GetHash().String())
+	}
+	refPath := func(file *domain.File) string {
+		return path.Join(string(rootPath), "refs", file.GetHash().String(), uuid.UUID(file.GetID()).String())
+	}
+	orphanPath := func(file *domain.File) string {
+		return path.Join(string(rootPath), "orphans", file.GetHash().String())
+	}
+
+	type test struct {
+		description   string
+		file          *domain.File
+		isErr         bool
+		err           error
+		removedPaths  []string
+		remainedPaths []string
+	}
+
+	testCases := []test{
+		{
+			description:   "同じ内容のファイルが残っているので実体は残る",
+			file:          sharedFile1,
+			removedPaths:  []string{refPath(sharedFile1), orphanPath(sharedFile1)},
+			remainedPaths: []string{objectPath(sharedFile1)},
+		},
+		{
+			description:   "最後の参照なので実体に削除の印が付く",
+			file:          sharedFile2,
+			removedPaths:  []string{refPath(sharedFile2)},
+			remainedPaths: []string{objectPath(sharedFile2), orphanPath(sharedFile2)},
+		},
+		{
+			description:   "参照が1つのファイルなので実体に削除の印が付く",
+			file:          singleFile,
+			removedPaths:  []string{refPath(singleFile)},
+			remainedPaths: []string{objectPath(singleFile), orphanPath(singleFile)},
+		},
+		{
+			description:  "ハッシュ導入前のファイルも削除できる",
+			file:         legacyFile,
+			removedPaths: []string{legacyFilePath},
+		},
+		{
+			description: "削除済みのファイルなのでErrNotFound",
+			file:        singleFile,
+			isErr:       true,
+			err:         storage.ErrNotFound,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.description, func(t *testing.T) {
+			err := fileStorage.DeleteFile(ctx, testCase.file)
+
+			if testCase.isErr {
+				if testCase.err == nil {
+					assert.Error(t, err)
+				} else if !errors.Is(err, testCase.err) {
+					t.Errorf("error must be %v, but actual is %v", testCase.err, err)
+				}
+			} else {
+				assert.NoError(t, err)
+			}
+			if err != nil {
+				return
+			}
+
+			for _, removedPath := range testCase.removedPaths {
+				_, err := os.Stat(removedPath)
+				assert.ErrorIs(t, err, os.ErrNotExist)
+			}
+			for _, remainedPath := range testCase.remainedPaths {
+				_, err := os.Stat(remainedPath)
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestDeleteUnreferencedObjects(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	rootPath := common.FilePath("./file_test_delete_unreferenced")
+
+	directoryManager := NewDirectoryManager(rootPath)
+	defer func() {
+		err := os.RemoveAll(string(rootPath))
+		if err != nil {
+			t.Fatalf("failed to remove directory: %v", err)
+		}
+	}()
+
+	fileStorage, err := NewFile(directoryManager)
+	if err != nil {
+		t.Fatalf("failed to create file storage: %v", err)
+	}
+
+	newFile := func(content string) *domain.File {
+		file := domain.NewFile(
+			values.NewFileID(),
+			values.FileTypeOther,
+			values.FileHash{},
+			0,
+			nil,
+			time.Now(),
+		)
+		err := fileStorage.SaveFile(ctx, file, strings.NewReader(content))
+		if err != nil {
+			t.Fatalf("failed to save file: %v", err)
+		}
+
+		return file
+	}
+	deleteFile := func(file *domain.File) {
+		err := fileStorage.DeleteFile(ctx, file)
+		if err != nil {
+			t.Fatalf("failed to delete file: %v", err)
+		}
+	}
+
+	objectPath := func(file *domain.File) string {
+		return path.Join(string(rootPath), "objects", file.GetHash().String())
+	}
+	orphanPath := func(file *domain.File) string {
+		return path.Join(string(rootPath), "orphans", file.GetHash().String())
+	}
+	trashPath := func(file *domain.File) string {
+		return path.Join(string(rootPath), "trash", file.GetHash().String())
+	}
+
+	sharedFile1 := newFile("shared")
+	sharedFile2 := newFile("shared")
+	singleFile := newFile("single")
+	readdedFile := newFile("readded")
+	evictedFile := newFile("evicted")
+
+	deleteFile(sharedFile1)
+	deleteFile(singleFile)
+	deleteFile(readdedFile)
+	// 削除の印を付けた後に同じ内容のファイルが保存された
+	readdedFile2 := newFile("readded")
+
+	// 実体を退避させた直後に処理が中断された
+	deleteFile(evictedFile)
+	evictedFile2 := newFile("evicted")
+	err = os.Rename(objectPath(evictedFile2), trashPath(evictedFile2))
+	if err != nil {
+		t.Fatalf("failed to move object to trash: %v", err)
+	}
+	orphan, err := os.Create(orphanPath(evictedFile2))
+	if err != nil {
+		t.Fatalf("failed to create orphan: %v", err)
+	}
+	err = orphan.Close()
+	if err != nil {
+		t.Fatalf("failed to close orphan: %v", err)
+	}
+
+	// 猶予期間内の実体は削除しない
+	deleted, err := fileStorage.DeleteUnreferencedObjects(ctx, time.Now().Add(-time.Hour))
+	if err != nil {
+		t.Fatalf("failed to delete unreferenced objects: %v", err)
+	}
+	assert.Equal(t, 0, deleted)
+
+	_, err = os.Stat(objectPath(singleFile))
+	assert.NoError(t, err)
+
+	deleted, err = fileStorage.DeleteUnreferencedObjects(ctx, time.Now().Add(time.Minute))
+	if err != nil {
+		t.Fatalf("failed to delete unreferenced objects: %v", err)
+	}
+	// 参照がなくなった実体のみ削除される
+	assert.Equal(t, 1, deleted)
+
+	_, err = os.Stat(objectPath(singleFile))
+	assert.ErrorIs(t, err, os.ErrNotExist)
+
+	for _, file := range []*domain.File{sharedFile2, readdedFile2, evictedFile2} {
+		buf := bytes.NewBuffer(nil)
+		err := fileStorage.GetFile(ctx, file, buf)
+		assert.NoError(t, err)
+	}
+
+	orphans, err := os.ReadDir(path.Join(string(rootPath), "orphans"))
+	if err != nil {
+		t.Fatalf("failed to read orphan directory: %v", err)
+	}
+	assert.Len(t, orphans, 0)
+
+	trashes, err := os.ReadDir(path.Join(string(rootPath), "trash"))
+	if err != nil {
+		t.Fatalf("failed to read trash directory: %v", err)
+	}
+	assert.Len(t, trashes, 0)
+}
+
+func TestListFiles(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	rootPath := common.FilePath("./file_test_list")
+
+	directoryManager := NewDirectoryManager(rootPath)
+	defer func() {
+		err := os.RemoveAll(string(rootPath))
+		if err != nil {
+			t.Fatalf("failed to remove directory: %v", err)
+		}
+	}()
+
+	fileStorage, err := NewFile(directoryManager)
+	if err != nil {
+		t.Fatalf("failed to create file storage: %v", err)
+	}
+
+	expectEntries := map[values.FileID]values.FileHash{}
+	for _, content := range []string{"a", "a", "b"} {
+		file := domain.NewFile(
+			values.NewFileID(),
+			values.FileTypeOther,
+			values.FileHash{},
+			0,
+			nil,
+			time.Now(),
+		)
+		err := fileStorage.SaveFile(ctx, file, strings.NewReader(content))
+		if err != nil {
+			t.Fatalf("failed to save file: %v", err)
+		}
+
+		expectEntries[file.GetID()] = file.GetHash()
+	}
+
+	legacyFileID := values.NewFileID()
+	err = os.WriteFile(path.Join(string(rootPath), "files", uuid.UUID(legacyFileID).String()), []byte("legacy"), 0644)
+	if err != nil {
+		t.Fatalf("failed to write legacy file: %v", err)
+	}
+	expectEntries[legacyFileID] = values.FileHash{}
+
+	// 不正な名前のファイルは無視される
+	err = os.WriteFile(path.Join(string(rootPath), "files", "invalid"), []byte("invalid"), 0644)
+	if err != nil {
+		t.Fatalf("failed to write invalid file: %v", err)
+	}
+
+	actualEntries := map[values.FileID]values.FileHash{}
+	err = fileStorage.ListFiles(ctx, func(entry *storage.FileEntry) error {
+		actualEntries[entry.FileID] = entry.Hash
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("failed to list files: %v", err)
+	}
+
+	assert.Equal(t, expectEntries, actualEntries)
+}
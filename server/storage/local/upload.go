@@ -0,0 +1,110 @@
+package local
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+
+	"github.com/google/uuid"
+	"github.com/mazrean/Quantainer/domain/values"
+	"github.com/mazrean/Quantainer/storage"
+)
+
+// Upload ファイルの保存先のストレージの種類に関わらず、アップロードの途中の内容はローカルに保持する
+type Upload struct {
+	uploadRootPath string
+}
+
+func NewUpload(directoryManager *DirectoryManager) (*Upload, error) {
+	uploadRootPath, err := directoryManager.setupDirectory("uploads")
+	if err != nil {
+		return nil, fmt.Errorf("failed to setup directory: %w", err)
+	}
+
+	return &Upload{
+		uploadRootPath: uploadRootPath,
+	}, nil
+}
+
+func (u *Upload) CreateUpload(ctx context.Context, uploadID values.UploadID) error {
+	fl, err := os.OpenFile(u.uploadPath(uploadID), os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+	if errors.Is(err, fs.ErrExist) {
+		return storage.ErrAlreadyExists
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create file: %w", err)
+	}
+
+	err = fl.Close()
+	if err != nil {
+		return fmt.Errorf("failed to close file: %w", err)
+	}
+
+	return nil
+}
+
+func (u *Upload) WriteUpload(ctx context.Context, uploadID values.UploadID, offset int64, reader io.Reader) (int64, error) {
+	fl, err := os.OpenFile(u.uploadPath(uploadID), os.O_WRONLY, 0644)
+	if errors.Is(err, fs.ErrNotExist) {
+		return 0, storage.ErrNotFound
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to open file: %w", err)
+	}
+	defer fl.Close()
+
+	// 前回の書き込みのうち、書き込み済みのバイト数として記録される前に中断された分を捨てる
+	err = fl.Truncate(offset)
+	if err != nil {
+		return 0, fmt.Errorf("failed to truncate file: %w", err)
+	}
+
+	_, err = fl.Seek(offset, io.SeekStart)
+	if err != nil {
+		return 0, fmt.Errorf("failed to seek file: %w", err)
+	}
+
+	n, err := io.Copy(fl, reader)
+	if err != nil {
+		return n, fmt.Errorf("failed to copy: %w", err)
+	}
+
+	err = fl.Sync()
+	if err != nil {
+		return 0, fmt.Errorf("failed to sync file: %w", err)
+	}
+
+	return n, nil
+}
+
+func (u *Upload) OpenUpload(ctx context.Context, uploadID values.UploadID) (io.ReadCloser, error) {
+	fl, err := os.Open(u.uploadPath(uploadID))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, storage.ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+
+	return fl, nil
+}
+
+func (u *Upload) DeleteUpload(ctx context.Context, uploadID values.UploadID) error {
+	err := os.Remove(u.uploadPath(uploadID))
+	if errors.Is(err, fs.ErrNotExist) {
+		return storage.ErrNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to remove file: %w", err)
+	}
+
+	return nil
+}
+
+func (u *Upload) uploadPath(uploadID values.UploadID) string {
+	return path.Join(u.uploadRootPath, uuid.UUID(uploadID).String())
+}
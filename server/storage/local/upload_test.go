@@ -0,0 +1,104 @@
+package local
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/mazrean/Quantainer/domain/values"
+	"github.com/mazrean/Quantainer/pkg/common"
+	"github.com/mazrean/Quantainer/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteUpload(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	rootPath := common.FilePath("./upload_test_write")
+
+	directoryManager := NewDirectoryManager(rootPath)
+	defer func() {
+		err := os.RemoveAll(string(rootPath))
+		if err != nil {
+			t.Fatalf("failed to remove directory: %v", err)
+		}
+	}()
+
+	uploadStorage, err := NewUpload(directoryManager)
+	if err != nil {
+		t.Fatalf("failed to create upload storage: %v", err)
+	}
+
+	uploadID := values.NewUploadID()
+	err = uploadStorage.CreateUpload(ctx, uploadID)
+	if err != nil {
+		t.Fatalf("failed to create upload: %v", err)
+	}
+
+	err = uploadStorage.CreateUpload(ctx, uploadID)
+	assert.ErrorIs(t, err, storage.ErrAlreadyExists)
+
+	type test struct {
+		description string
+		offset      int64
+		content     string
+		expect      string
+	}
+
+	// 順に書き込むので並列には実行しない
+	testCases := []test{
+		{
+			description: "先頭から書き込める",
+			offset:      0,
+			content:     "hello, ",
+			expect:      "hello, ",
+		},
+		{
+			description: "続きから書き込める",
+			offset:      7,
+			content:     "world!!",
+			expect:      "hello, world!!",
+		},
+		{
+			description: "記録されなかった分は上書きされる",
+			offset:      12,
+			content:     "?",
+			expect:      "hello, world?",
+		},
+	}
+
+	for _, testCase := range testCases {
+		n, err := uploadStorage.WriteUpload(ctx, uploadID, testCase.offset, strings.NewReader(testCase.content))
+		if !assert.NoError(t, err, testCase.description) {
+			continue
+		}
+		assert.Equal(t, int64(len(testCase.content)), n, testCase.description)
+
+		reader, err := uploadStorage.OpenUpload(ctx, uploadID)
+		if !assert.NoError(t, err, testCase.description) {
+			continue
+		}
+
+		content, err := io.ReadAll(reader)
+		_ = reader.Close()
+		if !assert.NoError(t, err, testCase.description) {
+			continue
+		}
+		assert.Equal(t, testCase.expect, string(content), testCase.description)
+	}
+
+	err = uploadStorage.DeleteUpload(ctx, uploadID)
+	if err != nil {
+		t.Fatalf("failed to delete upload: %v", err)
+	}
+
+	_, err = uploadStorage.WriteUpload(ctx, uploadID, 0, strings.NewReader("deleted"))
+	if !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("error must be ErrNotFound, but actual is %v", err)
+	}
+}
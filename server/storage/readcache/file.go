@@ -0,0 +1,509 @@
+package readcache
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"path"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mazrean/Quantainer/domain"
+	"github.com/mazrean/Quantainer/domain/values"
+	"github.com/mazrean/Quantainer/pkg/common"
+	"github.com/mazrean/Quantainer/storage"
+)
+
+// File 読み込んだ内容をローカルのディレクトリにキャッシュするstorage.File。
+// キャッシュの合計バイト数がmaxBytesを超えた場合は、最後に読み込まれてから最も時間が経ったものから削除する。
+// 同じ内容への同時の読み込みは、1つだけがfileから読み込み、残りはその完了を待ってキャッシュを使う。
+type File struct {
+	file           storage.File
+	objectRootPath string
+	tmpRootPath    string
+	maxBytes       int64
+	// entries・lru・size・callsを保護するロック
+	locker  sync.Mutex
+	entries map[string]*list.Element
+	// lru 先頭ほど最近読み込まれたキャッシュ
+	lru   *list.List
+	size  int64
+	calls map[string]*call
+
+	hits      int64
+	misses    int64
+	evictions int64
+}
+
+type entry struct {
+	key  string
+	size int64
+}
+
+// call 実行中のキャッシュへの読み込み
+type call struct {
+	done chan struct{}
+	err  error
+}
+
+// Stats キャッシュの利用状況
+type Stats struct {
+	Hits      int64
+	Misses    int64
+	Evictions int64
+	// Bytes キャッシュしている内容の合計バイト数
+	Bytes   int64
+	Entries int64
+}
+
+func NewFile(file storage.File, cacheDirectory common.FilePath, maxBytes common.StorageCacheMaxBytes) (*File, error) {
+	if maxBytes <= 0 {
+		return nil, errors.New("max bytes must be positive")
+	}
+
+	objectRootPath := path.Join(string(cacheDirectory), "objects")
+	err := os.MkdirAll(objectRootPath, 0755)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create object directory: %w", err)
+	}
+
+	// 前回の終了時に書き込み途中だった内容は使えないので捨てる
+	tmpRootPath := path.Join(string(cacheDirectory), "tmp")
+	err = os.RemoveAll(tmpRootPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to remove temporary directory: %w", err)
+	}
+
+	err = os.MkdirAll(tmpRootPath, 0755)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temporary directory: %w", err)
+	}
+
+	f := &File{
+		file:           file,
+		objectRootPath: objectRootPath,
+		tmpRootPath:    tmpRootPath,
+		maxBytes:       int64(maxBytes),
+		entries:        map[string]*list.Element{},
+		lru:            list.New(),
+		calls:          map[string]*call{},
+	}
+
+	err = f.loadEntries()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load entries: %w", err)
+	}
+
+	return f, nil
+}
+
+// loadEntries 再起動前のキャッシュを更新日時の順に読み込み、上限を超えた分は削除する
+func (f *File) loadEntries() error {
+	dirEntries, err := os.ReadDir(f.objectRootPath)
+	if err != nil {
+		return fmt.Errorf("failed to read directory: %w", err)
+	}
+
+	infos := make([]fs.FileInfo, 0, len(dirEntries))
+	for _, dirEntry := range dirEntries {
+		if dirEntry.IsDir() {
+			continue
+		}
+
+		info, err := dirEntry.Info()
+		if err != nil {
+			return fmt.Errorf("failed to get file info: %w", err)
+		}
+
+		infos = append(infos, info)
+	}
+
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].ModTime().Before(infos[j].ModTime())
+	})
+
+	f.locker.Lock()
+	defer f.locker.Unlock()
+
+	for _, info := range infos {
+		f.entries[info.Name()] = f.lru.PushFront(&entry{
+			key:  info.Name(),
+			size: info.Size(),
+		})
+		f.size += info.Size()
+	}
+	f.evict()
+
+	return nil
+}
+
+// SaveFile fileに保存すると同時にキャッシュにも書き込む。
+// キャッシュへの書き込みに失敗しても、保存は失敗させない。
+func (f *File) SaveFile(ctx context.Context, file *domain.File, reader io.Reader) error {
+	tmpFile, err := os.CreateTemp(f.tmpRootPath, "save-")
+	if err != nil {
+		log.Printf("error: failed to create temporary file: %v\n", err)
+		return f.file.SaveFile(ctx, file, reader)
+	}
+	tmpFilePath := tmpFile.Name()
+	defer func() {
+		// 移動済みの場合は存在しないので、エラーは無視する
+		_ = os.Remove(tmpFilePath)
+	}()
+
+	cacheWriter := &cacheWriter{
+		file:     tmpFile,
+		maxBytes: f.maxBytes,
+	}
+
+	err = f.file.SaveFile(ctx, file, io.TeeReader(reader, cacheWriter))
+	closeErr := tmpFile.Close()
+	if err != nil {
+		return fmt.Errorf("failed to save file: %w", err)
+	}
+
+	if errors.Is(cacheWriter.err, errTooLarge) {
+		// キャッシュより大きいファイルはキャッシュしないだけなので、エラーにしない
+		return nil
+	}
+	if cacheWriter.err != nil {
+		log.Printf("error: failed to write cache: %v\n", cacheWriter.err)
+		return nil
+	}
+	if closeErr != nil {
+		log.Printf("error: failed to close cache: %v\n", closeErr)
+		return nil
+	}
+
+	err = f.commit(cacheKey(file), tmpFilePath, cacheWriter.size)
+	if err != nil {
+		log.Printf("error: failed to commit cache: %v\n", err)
+	}
+
+	return nil
+}
+
+func (f *File) GetFile(ctx context.Context, file *domain.File, writer io.Writer) error {
+	reader, err := f.OpenFile(ctx, file)
+	if err != nil {
+		return fmt.Errorf("failed to open file: %w", err)
+	}
+	defer reader.Close()
+
+	_, err = io.Copy(writer, reader)
+	if err != nil {
+		return fmt.Errorf("failed to copy: %w", err)
+	}
+
+	return nil
+}
+
+// OpenFile キャッシュがなければfileから読み込んでキャッシュしてから開く。
+// キャッシュに収まらない内容や、キャッシュへの読み込みに失敗した場合はfileから直接開く。
+func (f *File) OpenFile(ctx context.Context, file *domain.File) (io.ReadSeekCloser, error) {
+	key := cacheKey(file)
+
+	reader, ok := f.openCache(key)
+	if ok {
+		atomic.AddInt64(&f.hits, 1)
+		return reader, nil
+	}
+	atomic.AddInt64(&f.misses, 1)
+
+	if file.GetSize() > f.maxBytes {
+		return f.file.OpenFile(ctx, file)
+	}
+
+	err := f.fill(ctx, key, file)
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil, storage.ErrNotFound
+	}
+	if err != nil {
+		log.Printf("error: failed to fill cache: %v\n", err)
+		return f.file.OpenFile(ctx, file)
+	}
+
+	reader, ok = f.openCache(key)
+	if ok {
+		return reader, nil
+	}
+
+	// 読み込んだ直後に削除された場合や、キャッシュに収まらなかった場合
+	return f.file.OpenFile(ctx, file)
+}
+
+// DeleteFile fileから削除した後、キャッシュも削除する。
+// 同じ内容を参照する他のファイルがある場合は、次の読み込み時にキャッシュし直す。
+func (f *File) DeleteFile(ctx context.Context, file *domain.File) error {
+	err := f.file.DeleteFile(ctx, file)
+
+	f.locker.Lock()
+	element, ok := f.entries[cacheKey(file)]
+	if ok {
+		f.remove(element)
+	}
+	f.locker.Unlock()
+
+	return err
+}
+
+// DeleteUnreferencedObjects 参照がなくなった時点でキャッシュは削除しているので、fileに任せる
+func (f *File) DeleteUnreferencedObjects(ctx context.Context, before time.Time) (int, error) {
+	return f.file.DeleteUnreferencedObjects(ctx, before)
+}
+
+func (f *File) ListFiles(ctx context.Context, fn func(entry *storage.FileEntry) error) error {
+	return f.file.ListFiles(ctx, fn)
+}
+
+func (f *File) Stats() *Stats {
+	f.locker.Lock()
+	size := f.size
+	entries := int64(f.lru.Len())
+	f.locker.Unlock()
+
+	return &Stats{
+		Hits:      atomic.LoadInt64(&f.hits),
+		Misses:    atomic.LoadInt64(&f.misses),
+		Evictions: atomic.LoadInt64(&f.evictions),
+		Bytes:     size,
+		Entries:   entries,
+	}
+}
+
+// cacheKey 内容が同じファイルはキャッシュを共有する。
+// ハッシュ導入前に保存されたファイルはファイルごとにキャッシュする。
+func cacheKey(file *domain.File) string {
+	hash := file.GetHash()
+	if hash.IsZero() {
+		return "legacy-" + uuid.UUID(file.GetID()).String()
+	}
+
+	return hash.String()
+}
+
+func (f *File) openCache(key string) (io.ReadSeekCloser, bool) {
+	f.locker.Lock()
+	defer f.locker.Unlock()
+
+	element, ok := f.entries[key]
+	if !ok {
+		return nil, false
+	}
+
+	objectPath := path.Join(f.objectRootPath, key)
+
+	// ロックを取得している間は削除されないので、開いた後に削除されても読み込める
+	reader, err := os.Open(objectPath)
+	if err != nil {
+		log.Printf("error: failed to open cache: %v\n", err)
+		f.remove(element)
+		return nil, false
+	}
+
+	f.lru.MoveToFront(element)
+
+	// 再起動後も読み込まれた順を復元できるよう、更新日時を読み込んだ日時にする
+	now := time.Now()
+	_ = os.Chtimes(objectPath, now, now)
+
+	return reader, true
+}
+
+// fill 同じkeyの読み込みが実行中であれば、その完了を待つ
+func (f *File) fill(ctx context.Context, key string, file *domain.File) error {
+	f.locker.Lock()
+	// キャッシュを確認してから、他の読み込みが完了している場合
+	_, ok := f.entries[key]
+	if ok {
+		f.locker.Unlock()
+		return nil
+	}
+
+	c, ok := f.calls[key]
+	if ok {
+		f.locker.Unlock()
+		<-c.done
+		return c.err
+	}
+
+	c = &call{
+		done: make(chan struct{}),
+	}
+	f.calls[key] = c
+	f.locker.Unlock()
+
+	c.err = f.load(ctx, key, file)
+
+	f.locker.Lock()
+	delete(f.calls, key)
+	f.locker.Unlock()
+	close(c.done)
+
+	return c.err
+}
+
+// load fileの内容を一時ファイルに読み込み、ハッシュが一致すればキャッシュとして追加する
+func (f *File) load(ctx context.Context, key string, file *domain.File) error {
+	tmpFile, err := os.CreateTemp(f.tmpRootPath, "load-")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	tmpFilePath := tmpFile.Name()
+	defer func() {
+		// 移動済みの場合は存在しないので、エラーは無視する
+		_ = os.Remove(tmpFilePath)
+	}()
+
+	hashWriter := newHashWriter(tmpFile)
+
+	err = f.file.GetFile(ctx, file, hashWriter)
+	if err != nil {
+		_ = tmpFile.Close()
+		return fmt.Errorf("failed to get file: %w", err)
+	}
+
+	err = tmpFile.Close()
+	if err != nil {
+		return fmt.Errorf("failed to close temporary file: %w", err)
+	}
+
+	hash := file.GetHash()
+	if !hash.IsZero() && hashWriter.sum() != hash {
+		return fmt.Errorf("hash mismatch(expected: %s, actual: %s)", hash, hashWriter.sum())
+	}
+
+	err = f.commit(key, tmpFilePath, hashWriter.size)
+	if err != nil {
+		return fmt.Errorf("failed to commit cache: %w", err)
+	}
+
+	return nil
+}
+
+// commit 一時ファイルをキャッシュとして追加し、上限を超えた分を削除する
+func (f *File) commit(key string, tmpFilePath string, size int64) error {
+	if size > f.maxBytes {
+		return nil
+	}
+
+	f.locker.Lock()
+	defer f.locker.Unlock()
+
+	element, ok := f.entries[key]
+	if ok {
+		// 同じ内容が既にキャッシュされている
+		f.lru.MoveToFront(element)
+		return nil
+	}
+
+	err := os.Rename(tmpFilePath, path.Join(f.objectRootPath, key))
+	if err != nil {
+		return fmt.Errorf("failed to move cache: %w", err)
+	}
+
+	f.entries[key] = f.lru.PushFront(&entry{
+		key:  key,
+		size: size,
+	})
+	f.size += size
+	f.evict()
+
+	return nil
+}
+
+// evict 合計バイト数が上限以下になるまで、最も前に読み込まれたキャッシュから削除する。
+// f.lockerを取得した状態で呼ぶ必要がある。
+func (f *File) evict() {
+	for f.size > f.maxBytes {
+		element := f.lru.Back()
+		if element == nil {
+			return
+		}
+
+		f.remove(element)
+		atomic.AddInt64(&f.evictions, 1)
+	}
+}
+
+// remove f.lockerを取得した状態で呼ぶ必要がある
+func (f *File) remove(element *list.Element) {
+	e := element.Value.(*entry)
+
+	f.lru.Remove(element)
+	delete(f.entries, e.key)
+	f.size -= e.size
+
+	err := os.Remove(path.Join(f.objectRootPath, e.key))
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		log.Printf("error: failed to remove cache: %v\n", err)
+	}
+}
+
+// cacheWriter 書き込みに失敗しても読み込み元の保存を止めないよう、エラーを記録するのみにする
+type cacheWriter struct {
+	file     *os.File
+	maxBytes int64
+	size     int64
+	err      error
+}
+
+var errTooLarge = errors.New("too large")
+
+func (cw *cacheWriter) Write(p []byte) (int, error) {
+	if cw.err != nil {
+		return len(p), nil
+	}
+
+	cw.size += int64(len(p))
+	if cw.size > cw.maxBytes {
+		cw.err = errTooLarge
+		return len(p), nil
+	}
+
+	_, cw.err = cw.file.Write(p)
+
+	return len(p), nil
+}
+
+// hashWriter 書き込んだ内容のSHA-256とバイト数を計算するio.Writer
+type hashWriter struct {
+	writer io.Writer
+	hash   hash.Hash
+	size   int64
+}
+
+func newHashWriter(writer io.Writer) *hashWriter {
+	return &hashWriter{
+		writer: writer,
+		hash:   sha256.New(),
+	}
+}
+
+func (hw *hashWriter) Write(p []byte) (int, error) {
+	n, err := hw.writer.Write(p)
+	if n > 0 {
+		// hash.HashのWriteはエラーを返さない
+		_, _ = hw.hash.Write(p[:n])
+		hw.size += int64(n)
+	}
+
+	return n, err
+}
+
+func (hw *hashWriter) sum() values.FileHash {
+	var fileHash values.FileHash
+	copy(fileHash[:], hw.hash.Sum(nil))
+
+	return fileHash
+}
@@ -0,0 +1,261 @@
+package readcache
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mazrean/Quantainer/domain"
+	"github.com/mazrean/Quantainer/domain/values"
+	"github.com/mazrean/Quantainer/pkg/common"
+	"github.com/mazrean/Quantainer/storage"
+	"github.com/mazrean/Quantainer/storage/local"
+	"github.com/stretchr/testify/assert"
+)
+
+// countingFile 読み込みの回数を数えるstorage.File
+type countingFile struct {
+	*local.File
+	gets int64
+	// release 閉じられるまで読み込みを待たせる。nilの場合は待たない
+	release chan struct{}
+}
+
+func (cf *countingFile) GetFile(ctx context.Context, file *domain.File, writer io.Writer) error {
+	atomic.AddInt64(&cf.gets, 1)
+	if cf.release != nil {
+		<-cf.release
+	}
+
+	return cf.File.GetFile(ctx, file, writer)
+}
+
+func newTestFile() *domain.File {
+	return domain.NewFile(
+		values.NewFileID(),
+		values.FileTypeOther,
+		values.FileHash{},
+		0,
+		nil,
+		time.Now(),
+	)
+}
+
+func newTestStorage(t *testing.T, rootPath string) *countingFile {
+	t.Helper()
+
+	t.Cleanup(func() {
+		err := os.RemoveAll(rootPath)
+		if err != nil {
+			t.Fatalf("failed to remove directory: %v", err)
+		}
+	})
+
+	fileStorage, err := local.NewFile(local.NewDirectoryManager(common.FilePath(rootPath)))
+	if err != nil {
+		t.Fatalf("failed to create file storage: %v", err)
+	}
+
+	return &countingFile{
+		File: fileStorage,
+	}
+}
+
+// saveToBackend キャッシュを通さずに保存する
+func saveToBackend(t *testing.T, fileStorage storage.File, content string) *domain.File {
+	t.Helper()
+
+	file := newTestFile()
+	err := fileStorage.SaveFile(context.Background(), file, strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("failed to save file: %v", err)
+	}
+
+	return file
+}
+
+func readAll(t *testing.T, fileStorage storage.File, file *domain.File) string {
+	t.Helper()
+
+	reader, err := fileStorage.OpenFile(context.Background(), file)
+	if err != nil {
+		t.Fatalf("failed to open file: %v", err)
+	}
+	defer reader.Close()
+
+	content, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatalf("failed to read file: %v", err)
+	}
+
+	return string(content)
+}
+
+func TestOpenFile(t *testing.T) {
+	t.Parallel()
+
+	rootPath := "open_file"
+	backend := newTestStorage(t, rootPath)
+
+	cacheFile, err := NewFile(backend, common.FilePath(path.Join(rootPath, "cache")), 1024)
+	if err != nil {
+		t.Fatalf("failed to create cache: %v", err)
+	}
+
+	file := saveToBackend(t, backend, "abcdef")
+
+	assert.Equal(t, "abcdef", readAll(t, cacheFile, file))
+	assert.Equal(t, "abcdef", readAll(t, cacheFile, file))
+
+	// 2回目はキャッシュから読み込む
+	assert.Equal(t, int64(1), atomic.LoadInt64(&backend.gets))
+
+	stats := cacheFile.Stats()
+	assert.Equal(t, int64(1), stats.Hits)
+	assert.Equal(t, int64(1), stats.Misses)
+	assert.Equal(t, int64(1), stats.Entries)
+	assert.Equal(t, int64(6), stats.Bytes)
+
+	_, err = cacheFile.OpenFile(context.Background(), newTestFile())
+	assert.ErrorIs(t, err, storage.ErrNotFound)
+}
+
+func TestOpenFileEviction(t *testing.T) {
+	t.Parallel()
+
+	rootPath := "open_file_eviction"
+	backend := newTestStorage(t, rootPath)
+
+	cacheFile, err := NewFile(backend, common.FilePath(path.Join(rootPath, "cache")), 10)
+	if err != nil {
+		t.Fatalf("failed to create cache: %v", err)
+	}
+
+	file1 := saveToBackend(t, backend, "aaaa")
+	file2 := saveToBackend(t, backend, "bbbb")
+	file3 := saveToBackend(t, backend, "cccc")
+	largeFile := saveToBackend(t, backend, strings.Repeat("d", 11))
+
+	readAll(t, cacheFile, file1)
+	readAll(t, cacheFile, file2)
+	// file1を最近読み込まれたものにし、file2を削除対象にする
+	readAll(t, cacheFile, file1)
+	readAll(t, cacheFile, file3)
+
+	stats := cacheFile.Stats()
+	assert.Equal(t, int64(1), stats.Evictions)
+	assert.Equal(t, int64(2), stats.Entries)
+	assert.Equal(t, int64(8), stats.Bytes)
+
+	_, err = os.Stat(path.Join(rootPath, "cache", "objects", file2.GetHash().String()))
+	assert.ErrorIs(t, err, os.ErrNotExist)
+
+	// 上限を超える内容はキャッシュせずに読み込む
+	assert.Equal(t, strings.Repeat("d", 11), readAll(t, cacheFile, largeFile))
+	assert.Equal(t, int64(1), cacheFile.Stats().Evictions)
+
+	// 再起動後も最近読み込まれたものが残る
+	cacheFile, err = NewFile(backend, common.FilePath(path.Join(rootPath, "cache")), 4)
+	if err != nil {
+		t.Fatalf("failed to create cache: %v", err)
+	}
+
+	stats = cacheFile.Stats()
+	assert.Equal(t, int64(1), stats.Entries)
+
+	gets := atomic.LoadInt64(&backend.gets)
+	readAll(t, cacheFile, file3)
+	assert.Equal(t, gets, atomic.LoadInt64(&backend.gets))
+}
+
+func TestOpenFileSingleFlight(t *testing.T) {
+	t.Parallel()
+
+	rootPath := "open_file_single_flight"
+	backend := newTestStorage(t, rootPath)
+
+	cacheFile, err := NewFile(backend, common.FilePath(path.Join(rootPath, "cache")), 1024)
+	if err != nil {
+		t.Fatalf("failed to create cache: %v", err)
+	}
+
+	file := saveToBackend(t, backend, "abcdef")
+
+	backend.release = make(chan struct{})
+
+	const concurrency = 10
+	contents := make([]string, concurrency)
+
+	wg := sync.WaitGroup{}
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			contents[i] = readAll(t, cacheFile, file)
+		}(i)
+	}
+
+	// 全ての読み込みが待ち状態になるまで待つ
+	assert.Eventually(t, func() bool {
+		cacheFile.locker.Lock()
+		defer cacheFile.locker.Unlock()
+
+		return atomic.LoadInt64(&cacheFile.misses) == concurrency && len(cacheFile.calls) == 1
+	}, time.Second, time.Millisecond)
+	close(backend.release)
+	wg.Wait()
+
+	assert.Equal(t, int64(1), atomic.LoadInt64(&backend.gets))
+	for _, content := range contents {
+		assert.Equal(t, "abcdef", content)
+	}
+}
+
+func TestSaveFile(t *testing.T) {
+	t.Parallel()
+
+	rootPath := "save_file"
+	backend := newTestStorage(t, rootPath)
+
+	cacheFile, err := NewFile(backend, common.FilePath(path.Join(rootPath, "cache")), 1024)
+	if err != nil {
+		t.Fatalf("failed to create cache: %v", err)
+	}
+
+	file := newTestFile()
+	err = cacheFile.SaveFile(context.Background(), file, bytes.NewBufferString("abcdef"))
+	if err != nil {
+		t.Fatalf("failed to save file: %v", err)
+	}
+
+	assert.Equal(t, int64(6), file.GetSize())
+	assert.False(t, file.GetHash().IsZero())
+
+	// 保存時にキャッシュされているので、fileからは読み込まない
+	assert.Equal(t, "abcdef", readAll(t, cacheFile, file))
+	assert.Equal(t, int64(0), atomic.LoadInt64(&backend.gets))
+	assert.Equal(t, int64(1), cacheFile.Stats().Hits)
+
+	err = cacheFile.DeleteFile(context.Background(), file)
+	if err != nil {
+		t.Fatalf("failed to delete file: %v", err)
+	}
+
+	assert.Equal(t, int64(0), cacheFile.Stats().Entries)
+
+	deletedObjects, err := cacheFile.DeleteUnreferencedObjects(context.Background(), time.Now().Add(time.Minute))
+	if err != nil {
+		t.Fatalf("failed to delete unreferenced objects: %v", err)
+	}
+	assert.Equal(t, 1, deletedObjects)
+
+	_, err = cacheFile.OpenFile(context.Background(), file)
+	assert.ErrorIs(t, err, storage.ErrNotFound)
+}
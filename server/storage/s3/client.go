@@ -0,0 +1,367 @@
+package s3
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3manager"
+	"github.com/mazrean/Quantainer/pkg/common"
+)
+
+const (
+	// uploadPartSize マルチパートアップロードの1パートの大きさ
+	uploadPartSize = 16 * 1024 * 1024
+	// uploadConcurrency 同時に送信するパートの数
+	uploadConcurrency = 4
+)
+
+type Client struct {
+	client   *s3.S3
+	uploader *s3manager.Uploader
+	bucket   string
+	prefix   string
+}
+
+func NewClient(
+	endpoint common.S3Endpoint,
+	region common.S3Region,
+	bucket common.S3Bucket,
+	accessKeyID common.S3AccessKeyID,
+	secretAccessKey common.S3SecretAccessKey,
+	prefix common.S3Prefix,
+) (*Client, error) {
+	ctx := context.Background()
+
+	config := &aws.Config{
+		Credentials: credentials.NewStaticCredentials(
+			string(accessKeyID),
+			string(secretAccessKey),
+			"",
+		),
+		Region: aws.String(string(region)),
+	}
+	// MinIOやCeph RGWなどはバケット名をホスト名に含める形式に対応していないことが多いので、パス形式を使う
+	if len(endpoint) != 0 {
+		config.Endpoint = aws.String(string(endpoint))
+		config.S3ForcePathStyle = aws.Bool(true)
+	}
+
+	sess, err := session.NewSession(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create session: %w", err)
+	}
+
+	client := s3.New(sess)
+
+	_, err = client.HeadBucketWithContext(ctx, &s3.HeadBucketInput{
+		Bucket: aws.String(string(bucket)),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to head bucket: %w", err)
+	}
+
+	uploader := s3manager.NewUploaderWithClient(client, func(u *s3manager.Uploader) {
+		u.PartSize = uploadPartSize
+		u.Concurrency = uploadConcurrency
+	})
+
+	return &Client{
+		client:   client,
+		uploader: uploader,
+		bucket:   string(bucket),
+		prefix:   string(prefix),
+	}, nil
+}
+
+var (
+	ErrNotFound = fmt.Errorf("not found")
+)
+
+func (c *Client) key(name string) string {
+	return path.Join(c.prefix, name)
+}
+
+// saveFile 大きいファイルはマルチパートアップロードで、全体をメモリに載せずに保存する
+func (c *Client) saveFile(
+	ctx context.Context,
+	name string,
+	contentType string,
+	content io.Reader,
+) error {
+	_, err := c.uploader.UploadWithContext(ctx, &s3manager.UploadInput{
+		Bucket:      aws.String(c.bucket),
+		Key:         aws.String(c.key(name)),
+		ContentType: aws.String(contentType),
+		Body:        content,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to upload object: %w", err)
+	}
+
+	return nil
+}
+
+func (c *Client) loadFile(ctx context.Context, name string, w io.Writer) error {
+	output, err := c.client.GetObjectWithContext(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(c.bucket),
+		Key:    aws.String(c.key(name)),
+	})
+	if isNotFound(err) {
+		return ErrNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get object: %w", err)
+	}
+	defer output.Body.Close()
+
+	_, err = io.Copy(w, output.Body)
+	if err != nil {
+		return fmt.Errorf("failed to copy object: %w", err)
+	}
+
+	return nil
+}
+
+// openFile 読み込み位置からのRange指定でオブジェクトを取得するio.ReadSeekCloserを返す
+func (c *Client) openFile(ctx context.Context, name string) (io.ReadSeekCloser, error) {
+	output, err := c.client.HeadObjectWithContext(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(c.bucket),
+		Key:    aws.String(c.key(name)),
+	})
+	if isNotFound(err) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to head object: %w", err)
+	}
+
+	return &objectFile{
+		ctx:    ctx,
+		client: c,
+		name:   name,
+		size:   aws.Int64Value(output.ContentLength),
+	}, nil
+}
+
+type objectFile struct {
+	ctx    context.Context
+	client *Client
+	name   string
+	size   int64
+	pos    int64
+	// body シーク後の最初の読み込みまでnil
+	body io.ReadCloser
+}
+
+func (of *objectFile) Read(p []byte) (int, error) {
+	if of.body == nil {
+		if of.pos >= of.size {
+			return 0, io.EOF
+		}
+
+		output, err := of.client.client.GetObjectWithContext(of.ctx, &s3.GetObjectInput{
+			Bucket: aws.String(of.client.bucket),
+			Key:    aws.String(of.client.key(of.name)),
+			Range:  aws.String(fmt.Sprintf("bytes=%d-", of.pos)),
+		})
+		if err != nil {
+			return 0, fmt.Errorf("failed to get object: %w", err)
+		}
+
+		of.body = output.Body
+	}
+
+	n, err := of.body.Read(p)
+	of.pos += int64(n)
+
+	return n, err
+}
+
+var errNegativePosition = errors.New("negative position")
+
+func (of *objectFile) Seek(offset int64, whence int) (int64, error) {
+	var newPos int64
+	switch whence {
+	case io.SeekStart:
+		newPos = offset
+	case io.SeekCurrent:
+		newPos = of.pos + offset
+	case io.SeekEnd:
+		newPos = of.size + offset
+	default:
+		return of.pos, fmt.Errorf("invalid whence: %d", whence)
+	}
+	if newPos < 0 {
+		return of.pos, errNegativePosition
+	}
+
+	if newPos != of.pos && of.body != nil {
+		err := of.body.Close()
+		if err != nil {
+			return of.pos, fmt.Errorf("failed to close body: %w", err)
+		}
+		of.body = nil
+	}
+	of.pos = newPos
+
+	return newPos, nil
+}
+
+func (of *objectFile) Close() error {
+	if of.body == nil {
+		return nil
+	}
+
+	return of.body.Close()
+}
+
+func (c *Client) existsFile(ctx context.Context, name string) (bool, error) {
+	_, err := c.client.HeadObjectWithContext(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(c.bucket),
+		Key:    aws.String(c.key(name)),
+	})
+	if isNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to head object: %w", err)
+	}
+
+	return true, nil
+}
+
+// moveFile S3には移動がないので、サーバー側でコピーしてから削除する
+func (c *Client) moveFile(ctx context.Context, srcName string, dstName string) error {
+	_, err := c.client.CopyObjectWithContext(ctx, &s3.CopyObjectInput{
+		Bucket:     aws.String(c.bucket),
+		Key:        aws.String(c.key(dstName)),
+		CopySource: aws.String(url.PathEscape(c.bucket) + "/" + escapeKey(c.key(srcName))),
+	})
+	if isNotFound(err) {
+		return ErrNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to copy object: %w", err)
+	}
+
+	err = c.deleteFile(ctx, srcName)
+	if err != nil {
+		return fmt.Errorf("failed to delete object: %w", err)
+	}
+
+	return nil
+}
+
+// escapeKey CopySourceに使うため、/以外をエスケープする
+func escapeKey(key string) string {
+	return (&url.URL{Path: key}).EscapedPath()
+}
+
+func (c *Client) deleteFile(ctx context.Context, name string) error {
+	_, err := c.client.DeleteObjectWithContext(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(c.bucket),
+		Key:    aws.String(c.key(name)),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete object: %w", err)
+	}
+
+	return nil
+}
+
+// saveMarker 参照カウント用の空のオブジェクトを保存する
+func (c *Client) saveMarker(ctx context.Context, name string) error {
+	_, err := c.client.PutObjectWithContext(ctx, &s3.PutObjectInput{
+		Bucket: aws.String(c.bucket),
+		Key:    aws.String(c.key(name)),
+		Body:   bytes.NewReader(nil),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to put object: %w", err)
+	}
+
+	return nil
+}
+
+func isNotFound(err error) bool {
+	var awsErr awserr.Error
+	if !errors.As(err, &awsErr) {
+		return false
+	}
+
+	switch awsErr.Code() {
+	// HEADはレスポンスボディがないので、NoSuchKeyではなくNotFoundになる
+	case s3.ErrCodeNoSuchKey, "NotFound":
+		return true
+	default:
+		return false
+	}
+}
+
+// walkFiles prefixから始まる名前のオブジェクトについてfnを呼ぶ
+func (c *Client) walkFiles(ctx context.Context, prefix string, fn func(name string, lastModified time.Time) error) error {
+	keyPrefix := c.key(prefix)
+	// path.Joinで末尾の/が消えるので付け直す
+	if strings.HasSuffix(prefix, "/") {
+		keyPrefix += "/"
+	}
+
+	var fnErr error
+	err := c.client.ListObjectsV2PagesWithContext(ctx, &s3.ListObjectsV2Input{
+		Bucket: aws.String(c.bucket),
+		Prefix: aws.String(keyPrefix),
+	}, func(output *s3.ListObjectsV2Output, lastPage bool) bool {
+		for _, object := range output.Contents {
+			name := aws.StringValue(object.Key)
+			if len(c.prefix) != 0 {
+				name = strings.TrimPrefix(name, path.Clean(c.prefix)+"/")
+			}
+
+			fnErr = fn(name, aws.TimeValue(object.LastModified))
+			if fnErr != nil {
+				return false
+			}
+		}
+
+		return true
+	})
+	if fnErr != nil {
+		return fnErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to list objects: %w", err)
+	}
+
+	return nil
+}
+
+// hasFiles prefixから始まる名前のオブジェクトが存在するか
+func (c *Client) hasFiles(ctx context.Context, prefix string) (bool, error) {
+	keyPrefix := c.key(prefix)
+	if strings.HasSuffix(prefix, "/") {
+		keyPrefix += "/"
+	}
+
+	output, err := c.client.ListObjectsV2WithContext(ctx, &s3.ListObjectsV2Input{
+		Bucket:  aws.String(c.bucket),
+		Prefix:  aws.String(keyPrefix),
+		MaxKeys: aws.Int64(1),
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to list objects: %w", err)
+	}
+
+	return len(output.Contents) != 0, nil
+}
@@ -0,0 +1,318 @@
+package s3
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/johannesboyne/gofakes3"
+	"github.com/johannesboyne/gofakes3/backend/s3mem"
+	"github.com/mazrean/Quantainer/pkg/common"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	testServer  *httptest.Server
+	testBackend *s3mem.Backend
+)
+
+func TestMain(m *testing.M) {
+	testBackend = s3mem.New()
+	testServer = httptest.NewServer(gofakes3.New(testBackend).Server())
+
+	code := m.Run()
+
+	testServer.Close()
+
+	os.Exit(code)
+}
+
+func newTestClient(bucket common.S3Bucket, prefix common.S3Prefix) (*Client, error) {
+	err := testBackend.CreateBucket(string(bucket))
+	if err != nil && !gofakes3.HasErrorCode(err, gofakes3.ErrBucketAlreadyExists) {
+		return nil, fmt.Errorf("failed to create bucket: %w", err)
+	}
+
+	client, err := NewClient(
+		common.S3Endpoint(testServer.URL),
+		common.S3Region("us-east-1"),
+		bucket,
+		common.S3AccessKeyID("access_key"),
+		common.S3SecretAccessKey("secret_key"),
+		prefix,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create client: %w", err)
+	}
+
+	return client, nil
+}
+
+func TestNewClient(t *testing.T) {
+	t.Parallel()
+
+	_, err := NewClient(
+		common.S3Endpoint(testServer.URL),
+		common.S3Region("us-east-1"),
+		common.S3Bucket("not-exist"),
+		common.S3AccessKeyID("access_key"),
+		common.S3SecretAccessKey("secret_key"),
+		common.S3Prefix(""),
+	)
+	assert.Error(t, err)
+}
+
+func TestSaveFile(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	client, err := newTestClient(common.S3Bucket("save-file"), common.S3Prefix("prefix"))
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+
+	type test struct {
+		description string
+		name        string
+		content     []byte
+	}
+
+	testCases := []test{
+		{
+			description: "特に問題ないので保存できる",
+			name:        "a",
+			content:     []byte("a"),
+		},
+		{
+			description: "マルチパートアップロードになる大きさでも保存できる",
+			name:        "b",
+			content:     bytes.Repeat([]byte("b"), uploadPartSize*2+1),
+		},
+		{
+			description: "空でも保存できる",
+			name:        "c",
+			content:     []byte{},
+		},
+		{
+			description: "名前に/が含まれていても保存できる",
+			name:        "d/e",
+			content:     []byte("d"),
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.description, func(t *testing.T) {
+			err := client.saveFile(ctx, testCase.name, "application/octet-stream", bytes.NewReader(testCase.content))
+			assert.NoError(t, err)
+			if err != nil {
+				return
+			}
+
+			// prefixの下に保存されている
+			obj, err := testBackend.GetObject("save-file", "prefix/"+testCase.name, nil)
+			if err != nil {
+				t.Fatalf("failed to get object: %v", err)
+			}
+			defer obj.Contents.Close()
+
+			actual, err := io.ReadAll(obj.Contents)
+			if err != nil {
+				t.Fatalf("failed to read object: %v", err)
+			}
+
+			assert.Equal(t, testCase.content, actual)
+		})
+	}
+}
+
+func TestLoadFile(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	client, err := newTestClient(common.S3Bucket("load-file"), common.S3Prefix(""))
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+
+	type test struct {
+		description string
+		name        string
+		isFileExist bool
+		content     string
+		isErr       bool
+		err         error
+	}
+
+	testCases := []test{
+		{
+			description: "特に問題ないので取得できる",
+			name:        "a",
+			isFileExist: true,
+			content:     "a",
+		},
+		{
+			description: "ファイルが存在しないのでErrNotFound",
+			name:        "b",
+			isErr:       true,
+			err:         ErrNotFound,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.description, func(t *testing.T) {
+			if testCase.isFileExist {
+				err := client.saveFile(ctx, testCase.name, "application/octet-stream", strings.NewReader(testCase.content))
+				if err != nil {
+					t.Fatalf("failed to save file: %v", err)
+				}
+			}
+
+			buf := bytes.NewBuffer(nil)
+			err := client.loadFile(ctx, testCase.name, buf)
+
+			if testCase.isErr {
+				if testCase.err == nil {
+					assert.Error(t, err)
+				} else if !errors.Is(err, testCase.err) {
+					t.Errorf("error must be %v, but actual is %v", testCase.err, err)
+				}
+			} else {
+				assert.NoError(t, err)
+			}
+			if err != nil {
+				return
+			}
+
+			assert.Equal(t, testCase.content, buf.String())
+		})
+	}
+}
+
+func TestOpenFile(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	client, err := newTestClient(common.S3Bucket("open-file"), common.S3Prefix(""))
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+
+	type test struct {
+		description string
+		name        string
+		isFileExist bool
+		content     string
+		offset      int64
+		isErr       bool
+		err         error
+	}
+
+	testCases := []test{
+		{
+			description: "特に問題ないので途中から取得できる",
+			name:        "a",
+			isFileExist: true,
+			content:     "abcdef",
+			offset:      2,
+		},
+		{
+			description: "先頭から取得できる",
+			name:        "b",
+			isFileExist: true,
+			content:     "abcdef",
+		},
+		{
+			description: "末尾までシークしても取得できる",
+			name:        "c",
+			isFileExist: true,
+			content:     "abcdef",
+			offset:      6,
+		},
+		{
+			description: "ファイルが存在しないのでErrNotFound",
+			name:        "d",
+			isErr:       true,
+			err:         ErrNotFound,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.description, func(t *testing.T) {
+			if testCase.isFileExist {
+				err := client.saveFile(ctx, testCase.name, "application/octet-stream", strings.NewReader(testCase.content))
+				if err != nil {
+					t.Fatalf("failed to save file: %v", err)
+				}
+			}
+
+			reader, err := client.openFile(ctx, testCase.name)
+
+			if testCase.isErr {
+				if testCase.err == nil {
+					assert.Error(t, err)
+				} else if !errors.Is(err, testCase.err) {
+					t.Errorf("error must be %v, but actual is %v", testCase.err, err)
+				}
+			} else {
+				assert.NoError(t, err)
+			}
+			if err != nil {
+				return
+			}
+			defer reader.Close()
+
+			size, err := reader.Seek(0, io.SeekEnd)
+			assert.NoError(t, err)
+			assert.Equal(t, int64(len(testCase.content)), size)
+
+			_, err = reader.Seek(testCase.offset, io.SeekStart)
+			assert.NoError(t, err)
+
+			actual, err := io.ReadAll(reader)
+			assert.NoError(t, err)
+			assert.Equal(t, testCase.content[testCase.offset:], string(actual))
+		})
+	}
+}
+
+func TestMoveFile(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	client, err := newTestClient(common.S3Bucket("move-file"), common.S3Prefix("prefix"))
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+
+	err = client.saveFile(ctx, "tmp/a b", "application/octet-stream", strings.NewReader("a"))
+	if err != nil {
+		t.Fatalf("failed to save file: %v", err)
+	}
+
+	err = client.moveFile(ctx, "tmp/a b", "objects/a")
+	assert.NoError(t, err)
+
+	isExist, err := client.existsFile(ctx, "tmp/a b")
+	assert.NoError(t, err)
+	assert.False(t, isExist)
+
+	buf := bytes.NewBuffer(nil)
+	err = client.loadFile(ctx, "objects/a", buf)
+	assert.NoError(t, err)
+	assert.Equal(t, "a", buf.String())
+
+	err = client.moveFile(ctx, "tmp/not-exist", "objects/b")
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("error must be %v, but actual is %v", ErrNotFound, err)
+	}
+}
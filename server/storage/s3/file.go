@@ -0,0 +1,429 @@
+package s3
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mazrean/Quantainer/domain"
+	"github.com/mazrean/Quantainer/domain/values"
+	"github.com/mazrean/Quantainer/storage"
+)
+
+type File struct {
+	client *Client
+	// 参照カウントの更新を直列化するためのロック
+	locker sync.Mutex
+}
+
+func NewFile(client *Client) *File {
+	return &File{
+		client: client,
+	}
+}
+
+func (sf *File) SaveFile(ctx context.Context, file *domain.File, reader io.Reader) error {
+	var contentType string
+	switch file.GetType() {
+	case values.FileTypeJpeg:
+		contentType = "image/jpeg"
+	case values.FileTypePng:
+		contentType = "image/png"
+	case values.FileTypeWebP:
+		contentType = "image/webp"
+	case values.FileTypeSvg:
+		contentType = "image/svg+xml"
+	case values.FileTypeGif:
+		contentType = "image/gif"
+	case values.FileTypeMp3:
+		contentType = "audio/mpeg"
+	case values.FileTypeOgg:
+		contentType = "audio/ogg"
+	case values.FileTypeWav:
+		contentType = "audio/wav"
+	case values.FileTypeFlac:
+		contentType = "audio/flac"
+	case values.FileTypeMp4:
+		contentType = "video/mp4"
+	case values.FileTypeWebM:
+		contentType = "video/webm"
+	case values.FileTypePdf:
+		contentType = "application/pdf"
+	case values.FileTypeZip:
+		contentType = "application/zip"
+	case values.FileTypeAvif:
+		contentType = "image/avif"
+	default:
+		contentType = "application/octet-stream"
+	}
+
+	// ハッシュは読み終わるまでわからないので、一時的な名前で保存してから移動する
+	tmpKey := sf.tmpKey(file)
+	hashReader := storage.NewHashReader(reader)
+
+	err := sf.client.saveFile(
+		ctx,
+		tmpKey,
+		contentType,
+		hashReader,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save file: %w", err)
+	}
+
+	hash := hashReader.Sum()
+
+	sf.locker.Lock()
+	defer sf.locker.Unlock()
+
+	refKey := sf.refKey(hash, file.GetID())
+	isRefExist, err := sf.client.existsFile(ctx, refKey)
+	if err != nil {
+		return fmt.Errorf("failed to check ref: %w", err)
+	}
+	if isRefExist {
+		sf.deleteTmpFile(ctx, tmpKey)
+		return storage.ErrAlreadyExists
+	}
+
+	// 他のプロセスが実体を削除していても参照の確認で止まるよう、実体より先に参照を追加する
+	err = sf.client.saveMarker(ctx, refKey)
+	if err != nil {
+		sf.deleteTmpFile(ctx, tmpKey)
+		return fmt.Errorf("failed to save ref: %w", err)
+	}
+
+	// 同じ内容のオブジェクトが既にあっても、削除中の場合に備えて置き直す
+	err = sf.client.moveFile(ctx, tmpKey, sf.objectKey(hash))
+	if err != nil {
+		sf.deleteTmpFile(ctx, tmpKey)
+
+		deleteErr := sf.deleteRef(ctx, hash, file.GetID())
+		if deleteErr != nil {
+			log.Printf("error: failed to delete ref: %v\n", deleteErr)
+		}
+
+		return fmt.Errorf("failed to move file: %w", err)
+	}
+
+	file.SetHash(hash)
+	file.SetSize(hashReader.Size())
+
+	return nil
+}
+
+func (sf *File) deleteTmpFile(ctx context.Context, tmpKey string) {
+	err := sf.client.deleteFile(ctx, tmpKey)
+	if err != nil {
+		log.Printf("error: failed to delete tmp file: %v\n", err)
+	}
+}
+
+func (sf *File) GetFile(ctx context.Context, file *domain.File, writer io.Writer) error {
+	var err error
+	for _, fileKey := range sf.fileKeys(file) {
+		err = sf.client.loadFile(
+			ctx,
+			fileKey,
+			writer,
+		)
+		if !errors.Is(err, ErrNotFound) {
+			break
+		}
+	}
+	if errors.Is(err, ErrNotFound) {
+		return storage.ErrNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get file: %w", err)
+	}
+
+	return nil
+}
+
+func (sf *File) OpenFile(ctx context.Context, file *domain.File) (io.ReadSeekCloser, error) {
+	var (
+		reader io.ReadSeekCloser
+		err    error
+	)
+	for _, fileKey := range sf.fileKeys(file) {
+		reader, err = sf.client.openFile(ctx, fileKey)
+		if !errors.Is(err, ErrNotFound) {
+			break
+		}
+	}
+	if errors.Is(err, ErrNotFound) {
+		return nil, storage.ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+
+	return reader, nil
+}
+
+func (sf *File) DeleteFile(ctx context.Context, file *domain.File) error {
+	sf.locker.Lock()
+	defer sf.locker.Unlock()
+
+	hash := file.GetHash()
+	if !hash.IsZero() {
+		refKey := sf.refKey(hash, file.GetID())
+
+		isRefExist, err := sf.client.existsFile(ctx, refKey)
+		if err != nil {
+			return fmt.Errorf("failed to check ref: %w", err)
+		}
+
+		if isRefExist {
+			return sf.deleteRef(ctx, hash, file.GetID())
+		}
+	}
+
+	// 参照がない場合、ハッシュ導入前に保存されたファイルの可能性がある
+	legacyKey := sf.legacyKey(file.GetID())
+
+	isLegacyExist, err := sf.client.existsFile(ctx, legacyKey)
+	if err != nil {
+		return fmt.Errorf("failed to check file: %w", err)
+	}
+	if !isLegacyExist {
+		return storage.ErrNotFound
+	}
+
+	err = sf.client.deleteFile(ctx, legacyKey)
+	if err != nil {
+		return fmt.Errorf("failed to delete file: %w", err)
+	}
+
+	return nil
+}
+
+// deleteRef 参照を削除し、参照がなくなった場合はDeleteUnreferencedObjectsで実体を削除するよう印を付ける
+func (sf *File) deleteRef(ctx context.Context, hash values.FileHash, fileID values.FileID) error {
+	err := sf.client.deleteFile(ctx, sf.refKey(hash, fileID))
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return fmt.Errorf("failed to delete ref: %w", err)
+	}
+
+	isRefExist, err := sf.client.hasFiles(ctx, sf.refPrefix(hash))
+	if err != nil {
+		return fmt.Errorf("failed to check refs: %w", err)
+	}
+	if isRefExist {
+		return nil
+	}
+
+	err = sf.client.saveMarker(ctx, sf.orphanKey(hash))
+	if err != nil {
+		return fmt.Errorf("failed to save orphan: %w", err)
+	}
+
+	return nil
+}
+
+func (sf *File) DeleteUnreferencedObjects(ctx context.Context, before time.Time) (int, error) {
+	// 一覧の取得中に削除しないよう、先に削除するものを集める
+	var hashes []values.FileHash
+	err := sf.client.walkFiles(ctx, "orphans/", func(name string, lastModified time.Time) error {
+		if !lastModified.Before(before) {
+			return nil
+		}
+
+		hash, err := values.NewFileHashFromString(strings.TrimPrefix(name, "orphans/"))
+		if err != nil || hash.IsZero() {
+			log.Printf("error: invalid orphan: %s\n", name)
+			return nil
+		}
+
+		hashes = append(hashes, hash)
+
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to walk orphans: %w", err)
+	}
+
+	var deleted int
+	for _, hash := range hashes {
+		isDeleted, err := sf.deleteUnreferencedObject(ctx, hash)
+		if err != nil {
+			return deleted, fmt.Errorf("failed to delete object(%s): %w", hash.String(), err)
+		}
+
+		if isDeleted {
+			deleted++
+		}
+	}
+
+	return deleted, nil
+}
+
+// deleteUnreferencedObject 参照が残っていなければ実体を削除し、削除したかを返す。
+// 削除の途中で参照が追加されても実体を失わないよう、退避してから参照を確認し直す。
+func (sf *File) deleteUnreferencedObject(ctx context.Context, hash values.FileHash) (bool, error) {
+	sf.locker.Lock()
+	defer sf.locker.Unlock()
+
+	// 処理中に参照がなくなった場合に付け直された印を消さないよう、先に印を削除する
+	err := sf.client.deleteFile(ctx, sf.orphanKey(hash))
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return false, fmt.Errorf("failed to delete orphan: %w", err)
+	}
+
+	isDeleted, err := sf.evictObject(ctx, hash)
+	if err != nil {
+		// 次回に再度削除を試みられるよう、印を付け直す
+		markErr := sf.client.saveMarker(ctx, sf.orphanKey(hash))
+		if markErr != nil {
+			log.Printf("error: failed to save orphan: %v\n", markErr)
+		}
+
+		return false, err
+	}
+
+	return isDeleted, nil
+}
+
+func (sf *File) evictObject(ctx context.Context, hash values.FileHash) (bool, error) {
+	isRefExist, err := sf.client.hasFiles(ctx, sf.refPrefix(hash))
+	if err != nil {
+		return false, fmt.Errorf("failed to check refs: %w", err)
+	}
+	if isRefExist {
+		// 前回の削除が退避の途中で失敗していた場合は元に戻す
+		return false, sf.restoreObject(ctx, hash)
+	}
+
+	err = sf.client.moveFile(ctx, sf.objectKey(hash), sf.trashKey(hash))
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return false, fmt.Errorf("failed to move object to trash: %w", err)
+	}
+	isMoved := err == nil
+
+	isRefExist, err = sf.client.hasFiles(ctx, sf.refPrefix(hash))
+	if err != nil {
+		return false, fmt.Errorf("failed to check refs: %w", err)
+	}
+	if isRefExist {
+		return false, sf.restoreObject(ctx, hash)
+	}
+
+	err = sf.client.deleteFile(ctx, sf.trashKey(hash))
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return false, fmt.Errorf("failed to delete trash: %w", err)
+	}
+
+	return isMoved, nil
+}
+
+// restoreObject 退避した実体を戻す。保存で置き直されている場合も同じ内容なので上書きする。
+func (sf *File) restoreObject(ctx context.Context, hash values.FileHash) error {
+	err := sf.client.moveFile(ctx, sf.trashKey(hash), sf.objectKey(hash))
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return fmt.Errorf("failed to restore object: %w", err)
+	}
+
+	return nil
+}
+
+func (sf *File) ListFiles(ctx context.Context, fn func(entry *storage.FileEntry) error) error {
+	err := sf.client.walkFiles(ctx, "refs/", func(name string, lastModified time.Time) error {
+		// refs/<hash>/<fileID>
+		var strHash, strFileID string
+		_, err := fmt.Sscanf(strings.ReplaceAll(name, "/", " "), "refs %s %s", &strHash, &strFileID)
+		if err != nil {
+			log.Printf("error: invalid ref: %s\n", name)
+			return nil
+		}
+
+		hash, err := values.NewFileHashFromString(strHash)
+		if err != nil || hash.IsZero() {
+			log.Printf("error: invalid ref: %s\n", name)
+			return nil
+		}
+
+		fileID, err := uuid.Parse(strFileID)
+		if err != nil {
+			log.Printf("error: invalid ref: %s\n", name)
+			return nil
+		}
+
+		return fn(&storage.FileEntry{
+			FileID:     values.NewFileIDFromUUID(fileID),
+			Hash:       hash,
+			ModifiedAt: lastModified,
+		})
+	})
+	if err != nil {
+		return fmt.Errorf("failed to walk refs: %w", err)
+	}
+
+	err = sf.client.walkFiles(ctx, "files/", func(name string, lastModified time.Time) error {
+		fileID, err := uuid.Parse(strings.TrimPrefix(name, "files/"))
+		if err != nil {
+			log.Printf("error: invalid file: %s\n", name)
+			return nil
+		}
+
+		return fn(&storage.FileEntry{
+			FileID:     values.NewFileIDFromUUID(fileID),
+			ModifiedAt: lastModified,
+		})
+	})
+	if err != nil {
+		return fmt.Errorf("failed to walk files: %w", err)
+	}
+
+	return nil
+}
+
+// fileKeys ファイルが存在しうるキーを優先度順に返す。
+// ハッシュ導入前に保存されたファイルは従来のキーに存在し、
+// 移行などで後からハッシュが設定された場合も従来のキーにしかないことがある。
+func (sf *File) fileKeys(file *domain.File) []string {
+	legacyKey := sf.legacyKey(file.GetID())
+
+	hash := file.GetHash()
+	if hash.IsZero() {
+		return []string{legacyKey}
+	}
+
+	return []string{sf.objectKey(hash), legacyKey}
+}
+
+func (sf *File) legacyKey(fileID values.FileID) string {
+	return fmt.Sprintf("files/%s", uuid.UUID(fileID).String())
+}
+
+func (sf *File) objectKey(hash values.FileHash) string {
+	return fmt.Sprintf("objects/%s", hash.String())
+}
+
+func (sf *File) refKey(hash values.FileHash, fileID values.FileID) string {
+	return sf.refPrefix(hash) + uuid.UUID(fileID).String()
+}
+
+func (sf *File) refPrefix(hash values.FileHash) string {
+	return fmt.Sprintf("refs/%s/", hash.String())
+}
+
+// orphanKey 参照がなくなった実体の印
+func (sf *File) orphanKey(hash values.FileHash) string {
+	return fmt.Sprintf("orphans/%s", hash.String())
+}
+
+// trashKey 削除する前に参照を確認し直す間、実体を退避させる先
+func (sf *File) trashKey(hash values.FileHash) string {
+	return fmt.Sprintf("trash/%s", hash.String())
+}
+
+func (sf *File) tmpKey(file *domain.File) string {
+	return fmt.Sprintf("tmp/%s", uuid.UUID(file.GetID()).String())
+}
@@ -0,0 +1,126 @@
+package s3
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mazrean/Quantainer/domain"
+	"github.com/mazrean/Quantainer/domain/values"
+	"github.com/mazrean/Quantainer/pkg/common"
+	"github.com/mazrean/Quantainer/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFileSaveFile(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	client, err := newTestClient(common.S3Bucket("file-save-file"), common.S3Prefix(""))
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+
+	fileStorage := NewFile(client)
+
+	existFile := domain.NewFile(
+		values.NewFileID(),
+		values.FileTypeOther,
+		values.FileHash{},
+		0,
+		nil,
+		time.Now(),
+	)
+	err = fileStorage.SaveFile(ctx, existFile, strings.NewReader("exist"))
+	if err != nil {
+		t.Fatalf("failed to save file: %v", err)
+	}
+
+	type test struct {
+		description string
+		file        *domain.File
+		content     string
+		isErr       bool
+		err         error
+	}
+
+	testCases := []test{
+		{
+			description: "特に問題ないのでエラーなし",
+			file: domain.NewFile(
+				values.NewFileID(),
+				values.FileTypePng,
+				values.FileHash{},
+				0,
+				nil,
+				time.Now(),
+			),
+			content: "a",
+		},
+		{
+			description: "同じ内容のファイルがあってもエラーなし",
+			file: domain.NewFile(
+				values.NewFileID(),
+				values.FileTypeOther,
+				values.FileHash{},
+				0,
+				nil,
+				time.Now(),
+			),
+			content: "exist",
+		},
+		{
+			description: "同じIDのファイルがあるのでErrAlreadyExists",
+			file:        existFile,
+			content:     "exist",
+			isErr:       true,
+			err:         storage.ErrAlreadyExists,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.description, func(t *testing.T) {
+			err := fileStorage.SaveFile(ctx, testCase.file, strings.NewReader(testCase.content))
+
+			if testCase.isErr {
+				if testCase.err == nil {
+					assert.Error(t, err)
+				} else if !errors.Is(err, testCase.err) {
+					t.Errorf("error must be %v, but actual is %v", testCase.err, err)
+				}
+			} else {
+				assert.NoError(t, err)
+			}
+			if err != nil {
+				return
+			}
+
+			sum := sha256.Sum256([]byte(testCase.content))
+			hash, err := values.NewFileHash(sum[:])
+			if err != nil {
+				t.Fatalf("failed to create hash: %v", err)
+			}
+			assert.Equal(t, hash, testCase.file.GetHash())
+
+			isExist, err := client.existsFile(ctx, fmt.Sprintf("refs/%s/%s", hash.String(), uuid.UUID(testCase.file.GetID()).String()))
+			assert.NoError(t, err)
+			assert.True(t, isExist)
+
+			isExist, err = client.existsFile(ctx, fmt.Sprintf("tmp/%s", uuid.UUID(testCase.file.GetID()).String()))
+			assert.NoError(t, err)
+			assert.False(t, isExist)
+
+			buf := bytes.NewBuffer(nil)
+			err = fileStorage.GetFile(ctx, testCase.file, buf)
+			assert.NoError(t, err)
+			assert.Equal(t, testCase.content, buf.String())
+		})
+	}
+}
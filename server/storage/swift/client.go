@@ -1,7 +1,6 @@
 package swift
 
 import (
-	"bytes"
 	"context"
 	"errors"
 	"fmt"
@@ -11,17 +10,11 @@ import (
 
 	"github.com/mazrean/Quantainer/pkg/common"
 	"github.com/ncw/swift/v2"
-	"gopkg.in/djherbis/fscache.v0"
-)
-
-const (
-	cacheDuration = 7 * 24 * time.Hour
 )
 
 type Client struct {
 	connection    *swift.Connection
 	containerName string
-	cache         fscache.Cache
 }
 
 func NewClient(
@@ -31,7 +24,6 @@ func NewClient(
 	tennantName common.SwiftTenantName,
 	tennantID common.SwiftTenantID,
 	containerName common.SwiftContainer,
-	cacheDirectory common.FilePath,
 ) (*Client, error) {
 	ctx := context.Background()
 
@@ -47,15 +39,9 @@ func NewClient(
 		return nil, fmt.Errorf("failed to setup swift: %w", err)
 	}
 
-	cache, err := fscache.New(string(cacheDirectory), 0755, cacheDuration)
-	if err != nil {
-		return nil, fmt.Errorf("failed to setup cache: %w", err)
-	}
-
 	return &Client{
 		connection:    connection,
 		containerName: string(containerName),
-		cache:         cache,
 	}, nil
 }
 
@@ -121,35 +107,16 @@ func (c *Client) saveFile(
 	if err != nil {
 		return fmt.Errorf("failed to create object: %w", err)
 	}
-	defer f.Close()
-
-	// cacheにも保存したいので、書き込みと同時にbufferに読み込む
-	buf := bytes.NewBuffer(nil)
-	tr := io.TeeReader(content, buf)
 
-	_, err = io.Copy(f, tr)
+	_, err = io.Copy(f, content)
 	if err != nil {
+		_ = f.CloseWithError(err)
 		return fmt.Errorf("failed to copy content: %w", err)
 	}
 
-	/*
-		オブジェクトストレージに存在しないことは確認済みなので、
-		ここでキャッシュが存在することはない
-	*/
-	r, w, err := c.cache.Get(name)
-	if err != nil {
-		return fmt.Errorf("failed to get cache: %w", err)
-	}
-	defer w.Close()
-
-	err = r.Close()
-	if err != nil {
-		return fmt.Errorf("failed to close cache: %w", err)
-	}
-
-	_, err = io.Copy(w, buf)
+	err = f.Close()
 	if err != nil {
-		return fmt.Errorf("failed to copy buffer: %w", err)
+		return fmt.Errorf("failed to close object: %w", err)
 	}
 
 	return nil
@@ -160,40 +127,142 @@ var (
 )
 
 func (c *Client) loadFile(ctx context.Context, name string, w io.Writer) error {
-	if c.cache.Exists(name) {
-		r, _, err := c.cache.Get(name)
-		if err != nil {
-			return fmt.Errorf("failed to get cache: %w", err)
-		}
-		defer r.Close()
+	_, err := c.connection.ObjectGet(
+		ctx,
+		c.containerName,
+		name,
+		w,
+		true,
+		nil,
+	)
+	if errors.Is(err, swift.ObjectNotFound) {
+		return ErrNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get object: %w", err)
+	}
 
-		_, err = io.Copy(w, r)
-		if err != nil {
-			return fmt.Errorf("failed to copy cache: %w", err)
-		}
+	return nil
+}
+
+// openFile オブジェクトストレージのオブジェクトを範囲指定で読み込めるように開く
+func (c *Client) openFile(ctx context.Context, name string) (io.ReadSeekCloser, error) {
+	f, _, err := c.connection.ObjectOpen(ctx, c.containerName, name, false, nil)
+	if errors.Is(err, swift.ObjectNotFound) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open object: %w", err)
+	}
 
-		return nil
+	// 末尾からのシークにはサイズが必要なので、事前に取得しておく
+	_, err = f.Length(ctx)
+	if err != nil {
+		_ = f.Close()
+		return nil, fmt.Errorf("failed to get length: %w", err)
 	}
 
+	return &objectFile{
+		ctx:  ctx,
+		file: f,
+	}, nil
+}
+
+// objectFile swift.ObjectOpenFileをio.ReadSeekCloserとして扱うためのラッパー
+type objectFile struct {
+	ctx  context.Context
+	file *swift.ObjectOpenFile
+}
+
+func (of *objectFile) Read(p []byte) (int, error) {
+	return of.file.Read(p)
+}
+
+func (of *objectFile) Seek(offset int64, whence int) (int64, error) {
+	return of.file.Seek(of.ctx, offset, whence)
+}
+
+func (of *objectFile) Close() error {
+	return of.file.Close()
+}
+
+func (c *Client) existsFile(ctx context.Context, name string) (bool, error) {
 	_, _, err := c.connection.Object(ctx, c.containerName, name)
+	if errors.Is(err, swift.ObjectNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to get object: %w", err)
+	}
+
+	return true, nil
+}
+
+// moveFile サーバー側でオブジェクトを移動する
+func (c *Client) moveFile(ctx context.Context, srcName string, dstName string) error {
+	err := c.connection.ObjectMove(ctx, c.containerName, srcName, c.containerName, dstName)
 	if errors.Is(err, swift.ObjectNotFound) {
 		return ErrNotFound
 	}
 	if err != nil {
-		return fmt.Errorf("failed to get object: %w", err)
+		return fmt.Errorf("failed to move object: %w", err)
 	}
 
-	_, err = c.connection.ObjectGet(
-		ctx,
-		c.containerName,
-		name,
-		w,
-		true,
-		nil,
-	)
+	return nil
+}
+
+func (c *Client) deleteFile(ctx context.Context, name string) error {
+	err := c.connection.ObjectDelete(ctx, c.containerName, name)
+	if errors.Is(err, swift.ObjectNotFound) {
+		return ErrNotFound
+	}
 	if err != nil {
-		return fmt.Errorf("failed to get object: %w", err)
+		return fmt.Errorf("failed to delete object: %w", err)
 	}
 
 	return nil
 }
+
+// saveMarker 参照カウント用の空のオブジェクトを保存する
+func (c *Client) saveMarker(ctx context.Context, name string) error {
+	err := c.connection.ObjectPutBytes(ctx, c.containerName, name, []byte{}, "application/octet-stream")
+	if err != nil {
+		return fmt.Errorf("failed to put object: %w", err)
+	}
+
+	return nil
+}
+
+// walkFiles prefixから始まる名前のオブジェクトについてfnを呼ぶ
+func (c *Client) walkFiles(ctx context.Context, prefix string, fn func(name string, lastModified time.Time) error) error {
+	return c.connection.ObjectsWalk(ctx, c.containerName, &swift.ObjectsOpts{
+		Prefix: prefix,
+	}, func(ctx context.Context, opts *swift.ObjectsOpts) (interface{}, error) {
+		objects, err := c.connection.Objects(ctx, c.containerName, opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get objects: %w", err)
+		}
+
+		for _, object := range objects {
+			err := fn(object.Name, object.LastModified)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		return objects, nil
+	})
+}
+
+// hasFiles prefixから始まる名前のオブジェクトが存在するか
+func (c *Client) hasFiles(ctx context.Context, prefix string) (bool, error) {
+	names, err := c.connection.ObjectNames(ctx, c.containerName, &swift.ObjectsOpts{
+		Prefix: prefix,
+		Limit:  1,
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to get object names: %w", err)
+	}
+
+	return len(names) != 0, nil
+}
@@ -33,7 +33,7 @@ func TestMain(m *testing.M) {
 	os.Exit(code)
 }
 
-func newTestClient(ctx context.Context, containerName common.SwiftContainer, cacheDirectory common.FilePath) (*Client, error) {
+func newTestClient(ctx context.Context, containerName common.SwiftContainer) (*Client, error) {
 	authURL, err := url.Parse(testServer.AuthURL)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse auth url: %w", err)
@@ -47,7 +47,6 @@ func newTestClient(ctx context.Context, containerName common.SwiftContainer, cac
 		common.SwiftTenantName(""),
 		common.SwiftTenantID(""),
 		common.SwiftContainer(containerName),
-		cacheDirectory,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create client: %w", err)
@@ -64,20 +63,10 @@ func newTestClient(ctx context.Context, containerName common.SwiftContainer, cac
 func TestSaveFile(t *testing.T) {
 	ctx := context.Background()
 
-	client, err := newTestClient(
-		ctx,
-		common.SwiftContainer("save_file"),
-		common.FilePath("save_file"),
-	)
+	client, err := newTestClient(ctx, common.SwiftContainer("save_file"))
 	if err != nil {
 		t.Fatalf("failed to create client: %v", err)
 	}
-	defer func() {
-		err := os.RemoveAll("save_file")
-		if err != nil {
-			t.Fatalf("failed to remove directory: %v", err)
-		}
-	}()
 
 	type test struct {
 		description        string
@@ -138,14 +127,6 @@ func TestSaveFile(t *testing.T) {
 
 	for _, testCase := range testCases {
 		t.Run(testCase.description, func(t *testing.T) {
-			defer func() {
-				// 確実にキャッシュが残るように、キャッシュを消す
-				err := client.cache.Clean()
-				if err != nil {
-					t.Fatalf("failed to clean cache: %v", err)
-				}
-			}()
-
 			expectBytes := testCase.content.Bytes()
 
 			if testCase.isAlreadyFileExist {
@@ -191,20 +172,6 @@ func TestSaveFile(t *testing.T) {
 
 			assert.Equal(t, expectBytes, actualBytes)
 
-			assert.True(t, client.cache.Exists(testCase.name))
-
-			r, _, err := client.cache.Get(testCase.name)
-			if err != nil {
-				t.Fatalf("failed to get cache: %v", err)
-			}
-			defer r.Close()
-
-			actualBytes, err = io.ReadAll(r)
-			if err != nil {
-				t.Fatalf("failed to read cache: %v", err)
-			}
-
-			assert.Equal(t, expectBytes, actualBytes)
 		})
 	}
 }
@@ -212,44 +179,26 @@ func TestSaveFile(t *testing.T) {
 func TestLoadFile(t *testing.T) {
 	ctx := context.Background()
 
-	client, err := newTestClient(
-		ctx,
-		common.SwiftContainer("load_file"),
-		common.FilePath("load_file"),
-	)
+	client, err := newTestClient(ctx, common.SwiftContainer("load_file"))
 	if err != nil {
 		t.Fatalf("failed to create client: %v", err)
 	}
-	defer func() {
-		err := os.RemoveAll("load_file")
-		if err != nil {
-			t.Fatalf("failed to remove directory: %v", err)
-		}
-	}()
 
 	type test struct {
-		description  string
-		name         string
-		isCacheExist bool
-		isFileExist  bool
-		content      *bytes.Buffer
-		isErr        bool
-		err          error
+		description string
+		name        string
+		isFileExist bool
+		content     *bytes.Buffer
+		isErr       bool
+		err         error
 	}
 
 	testCases := []test{
 		{
-			description:  "特に問題ないので取得できる",
-			isCacheExist: true,
-			isFileExist:  true,
-			name:         "a",
-			content:      bytes.NewBufferString("a"),
-		},
-		{
-			description: "キャッシュが存在しなくても取得できる",
+			description: "特に問題ないので取得できる",
 			isFileExist: true,
-			name:        "b",
-			content:     bytes.NewBufferString("b"),
+			name:        "a",
+			content:     bytes.NewBufferString("a"),
 		},
 		{
 			description: "ファイルが存在しないのでErrNotFound",
@@ -259,62 +208,21 @@ func TestLoadFile(t *testing.T) {
 			err:         ErrNotFound,
 		},
 		{
-			description:  "サイズが大きくても取得できる",
-			name:         "d",
-			isCacheExist: true,
-			isFileExist:  true,
-			content:      bytes.NewBufferString(strings.Repeat("d", 1024*1024*10)),
-		},
-		{
-			description: "サイズが大きくてキャッシュが存在しなくても取得できる",
-			name:        "e",
+			description: "サイズが大きくても取得できる",
+			name:        "d",
 			isFileExist: true,
-			content:     bytes.NewBufferString(strings.Repeat("e", 1024*1024*10)),
+			content:     bytes.NewBufferString(strings.Repeat("d", 1024*1024*10)),
 		},
 		{
-			description:  "名前に/が含まれていても取得できる",
-			isCacheExist: true,
-			isFileExist:  true,
-			name:         "f/g",
-			content:      bytes.NewBufferString("f"),
+			description: "名前に/が含まれていても取得できる",
+			isFileExist: true,
+			name:        "f/g",
+			content:     bytes.NewBufferString("f"),
 		},
 	}
 
 	for _, testCase := range testCases {
 		t.Run(testCase.description, func(t *testing.T) {
-			defer func() {
-				// 確実にキャッシュが残るように、キャッシュを消す
-				err := client.cache.Clean()
-				if err != nil {
-					t.Fatalf("failed to clean cache: %v", err)
-				}
-			}()
-
-			if testCase.isCacheExist {
-				func() {
-					r, w, err := client.cache.Get(testCase.name)
-					if err != nil {
-						t.Fatalf("failed to set cache: %v", err)
-					}
-					defer r.Close()
-
-					func() {
-						defer w.Close()
-
-						_, err = io.Copy(w, testCase.content)
-						if err != nil {
-							t.Fatalf("failed to write cache: %v", err)
-						}
-					}()
-
-					testCase.content.Reset()
-					_, err = io.Copy(testCase.content, r)
-					if err != nil {
-						t.Fatalf("failed to read cache: %v", err)
-					}
-				}()
-			}
-
 			var expectBytes []byte
 			if testCase.isFileExist {
 				expectBytes = testCase.content.Bytes()
@@ -355,3 +263,92 @@ func TestLoadFile(t *testing.T) {
 		})
 	}
 }
+
+func TestOpenFile(t *testing.T) {
+	ctx := context.Background()
+
+	client, err := newTestClient(ctx, common.SwiftContainer("open_file"))
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+
+	type test struct {
+		description string
+		name        string
+		isFileExist bool
+		content     string
+		offset      int64
+		isErr       bool
+		err         error
+	}
+
+	testCases := []test{
+		{
+			description: "途中から読んでも取得できる",
+			isFileExist: true,
+			name:        "a",
+			content:     "abcdef",
+			offset:      2,
+		},
+		{
+			description: "先頭から読んでも取得できる",
+			isFileExist: true,
+			name:        "c",
+			content:     "abcdef",
+		},
+		{
+			description: "ファイルが存在しないのでErrNotFound",
+			name:        "d",
+			isFileExist: false,
+			isErr:       true,
+			err:         ErrNotFound,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.description, func(t *testing.T) {
+			if testCase.isFileExist {
+				_, err := client.connection.ObjectPut(
+					ctx,
+					client.containerName,
+					testCase.name,
+					strings.NewReader(testCase.content),
+					true,
+					"",
+					"",
+					nil,
+				)
+				if err != nil {
+					t.Fatalf("failed to put object: %v", err)
+				}
+			}
+
+			reader, err := client.openFile(ctx, testCase.name)
+
+			if testCase.isErr {
+				if testCase.err == nil {
+					assert.Error(t, err)
+				} else if !errors.Is(err, testCase.err) {
+					t.Errorf("error must be %v, but actual is %v", testCase.err, err)
+				}
+			} else {
+				assert.NoError(t, err)
+			}
+			if err != nil {
+				return
+			}
+			defer reader.Close()
+
+			size, err := reader.Seek(0, io.SeekEnd)
+			assert.NoError(t, err)
+			assert.Equal(t, int64(len(testCase.content)), size)
+
+			_, err = reader.Seek(testCase.offset, io.SeekStart)
+			assert.NoError(t, err)
+
+			actual, err := io.ReadAll(reader)
+			assert.NoError(t, err)
+			assert.Equal(t, testCase.content[testCase.offset:], string(actual))
+		})
+	}
+}
@@ -5,6 +5,10 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"log"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/mazrean/Quantainer/domain"
@@ -14,6 +18,8 @@ import (
 
 type File struct {
 	client *Client
+	// 参照カウントの更新を直列化するためのロック
+	locker sync.Mutex
 }
 
 func NewFile(client *Client) *File {
@@ -23,8 +29,6 @@ func NewFile(client *Client) *File {
 }
 
 func (gf *File) SaveFile(ctx context.Context, file *domain.File, reader io.Reader) error {
-	fileKey := gf.fileKey(file)
-
 	var contentType string
 	switch file.GetType() {
 	case values.FileTypeJpeg:
@@ -37,16 +41,44 @@ func (gf *File) SaveFile(ctx context.Context, file *domain.File, reader io.Reade
 		contentType = "image/svg+xml"
 	case values.FileTypeGif:
 		contentType = "image/gif"
+	case values.FileTypeMp3:
+		contentType = "audio/mpeg"
+	case values.FileTypeOgg:
+		contentType = "audio/ogg"
+	case values.FileTypeWav:
+		contentType = "audio/wav"
+	case values.FileTypeFlac:
+		contentType = "audio/flac"
+	case values.FileTypeMp4:
+		contentType = "video/mp4"
+	case values.FileTypeWebM:
+		contentType = "video/webm"
+	case values.FileTypePdf:
+		contentType = "application/pdf"
+	case values.FileTypeZip:
+		contentType = "application/zip"
+	case values.FileTypeAvif:
+		contentType = "image/avif"
 	default:
 		contentType = "application/octet-stream"
 	}
 
-	err := gf.client.saveFile(
+	// ハッシュは読み終わるまでわからないので、一時的な名前で保存してから移動する
+	tmpKey := gf.tmpKey(file)
+	hashReader := storage.NewHashReader(reader)
+
+	// 中断された保存の一時オブジェクトが残っていると保存できないので、先に削除する
+	err := gf.client.deleteFile(ctx, tmpKey)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return fmt.Errorf("failed to delete tmp file: %w", err)
+	}
+
+	err = gf.client.saveFile(
 		ctx,
-		fileKey,
+		tmpKey,
 		contentType,
 		"",
-		reader,
+		hashReader,
 	)
 	if errors.Is(err, ErrAlreadyExists) {
 		return storage.ErrAlreadyExists
@@ -55,27 +87,353 @@ func (gf *File) SaveFile(ctx context.Context, file *domain.File, reader io.Reade
 		return fmt.Errorf("failed to save file: %w", err)
 	}
 
+	hash := hashReader.Sum()
+
+	gf.locker.Lock()
+	defer gf.locker.Unlock()
+
+	refKey := gf.refKey(hash, file.GetID())
+	isRefExist, err := gf.client.existsFile(ctx, refKey)
+	if err != nil {
+		return fmt.Errorf("failed to check ref: %w", err)
+	}
+	if isRefExist {
+		gf.deleteTmpFile(ctx, tmpKey)
+		return storage.ErrAlreadyExists
+	}
+
+	// 他のプロセスが実体を削除していても参照の確認で止まるよう、実体より先に参照を追加する
+	err = gf.client.saveMarker(ctx, refKey)
+	if err != nil {
+		gf.deleteTmpFile(ctx, tmpKey)
+		return fmt.Errorf("failed to save ref: %w", err)
+	}
+
+	// 同じ内容のオブジェクトが既にあっても、削除中の場合に備えて置き直す
+	err = gf.client.moveFile(ctx, tmpKey, gf.objectKey(hash))
+	if err != nil {
+		gf.deleteTmpFile(ctx, tmpKey)
+
+		deleteErr := gf.deleteRef(ctx, hash, file.GetID())
+		if deleteErr != nil {
+			log.Printf("error: failed to delete ref: %v\n", deleteErr)
+		}
+
+		return fmt.Errorf("failed to move file: %w", err)
+	}
+
+	file.SetHash(hash)
+	file.SetSize(hashReader.Size())
+
 	return nil
 }
 
+func (gf *File) deleteTmpFile(ctx context.Context, tmpKey string) {
+	err := gf.client.deleteFile(ctx, tmpKey)
+	if err != nil {
+		log.Printf("error: failed to delete tmp file: %v\n", err)
+	}
+}
+
 func (gf *File) GetFile(ctx context.Context, file *domain.File, writer io.Writer) error {
-	fileKey := gf.fileKey(file)
+	var err error
+	for _, fileKey := range gf.fileKeys(file) {
+		err = gf.client.loadFile(
+			ctx,
+			fileKey,
+			writer,
+		)
+		if !errors.Is(err, ErrNotFound) {
+			break
+		}
+	}
+	if errors.Is(err, ErrNotFound) {
+		return storage.ErrNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get file: %w", err)
+	}
 
-	err := gf.client.loadFile(
-		ctx,
-		fileKey,
-		writer,
+	return nil
+}
+
+func (gf *File) OpenFile(ctx context.Context, file *domain.File) (io.ReadSeekCloser, error) {
+	var (
+		reader io.ReadSeekCloser
+		err    error
 	)
+	for _, fileKey := range gf.fileKeys(file) {
+		reader, err = gf.client.openFile(ctx, fileKey)
+		if !errors.Is(err, ErrNotFound) {
+			break
+		}
+	}
 	if errors.Is(err, ErrNotFound) {
+		return nil, storage.ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+
+	return reader, nil
+}
+
+func (gf *File) DeleteFile(ctx context.Context, file *domain.File) error {
+	gf.locker.Lock()
+	defer gf.locker.Unlock()
+
+	hash := file.GetHash()
+	if !hash.IsZero() {
+		refKey := gf.refKey(hash, file.GetID())
+
+		isRefExist, err := gf.client.existsFile(ctx, refKey)
+		if err != nil {
+			return fmt.Errorf("failed to check ref: %w", err)
+		}
+
+		if isRefExist {
+			return gf.deleteRef(ctx, hash, file.GetID())
+		}
+	}
+
+	// 参照がない場合、ハッシュ導入前に保存されたファイルの可能性がある
+	legacyKey := gf.legacyKey(file.GetID())
+
+	isLegacyExist, err := gf.client.existsFile(ctx, legacyKey)
+	if err != nil {
+		return fmt.Errorf("failed to check file: %w", err)
+	}
+	if !isLegacyExist {
 		return storage.ErrNotFound
 	}
+
+	err = gf.client.deleteFile(ctx, legacyKey)
 	if err != nil {
-		return fmt.Errorf("failed to get file: %w", err)
+		return fmt.Errorf("failed to delete file: %w", err)
+	}
+
+	return nil
+}
+
+// deleteRef 参照を削除し、参照がなくなった場合はDeleteUnreferencedObjectsで実体を削除するよう印を付ける
+func (gf *File) deleteRef(ctx context.Context, hash values.FileHash, fileID values.FileID) error {
+	err := gf.client.deleteFile(ctx, gf.refKey(hash, fileID))
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return fmt.Errorf("failed to delete ref: %w", err)
+	}
+
+	isRefExist, err := gf.client.hasFiles(ctx, gf.refPrefix(hash))
+	if err != nil {
+		return fmt.Errorf("failed to check refs: %w", err)
+	}
+	if isRefExist {
+		return nil
+	}
+
+	err = gf.client.saveMarker(ctx, gf.orphanKey(hash))
+	if err != nil {
+		return fmt.Errorf("failed to save orphan: %w", err)
 	}
 
 	return nil
 }
 
-func (gf *File) fileKey(file *domain.File) string {
-	return fmt.Sprintf("files/%s", uuid.UUID(file.GetID()).String())
+func (gf *File) DeleteUnreferencedObjects(ctx context.Context, before time.Time) (int, error) {
+	// 一覧の取得中に削除しないよう、先に削除するものを集める
+	var hashes []values.FileHash
+	err := gf.client.walkFiles(ctx, "orphans/", func(name string, lastModified time.Time) error {
+		if !lastModified.Before(before) {
+			return nil
+		}
+
+		hash, err := values.NewFileHashFromString(strings.TrimPrefix(name, "orphans/"))
+		if err != nil || hash.IsZero() {
+			log.Printf("error: invalid orphan: %s\n", name)
+			return nil
+		}
+
+		hashes = append(hashes, hash)
+
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to walk orphans: %w", err)
+	}
+
+	var deleted int
+	for _, hash := range hashes {
+		isDeleted, err := gf.deleteUnreferencedObject(ctx, hash)
+		if err != nil {
+			return deleted, fmt.Errorf("failed to delete object(%s): %w", hash.String(), err)
+		}
+
+		if isDeleted {
+			deleted++
+		}
+	}
+
+	return deleted, nil
+}
+
+// deleteUnreferencedObject 参照が残っていなければ実体を削除し、削除したかを返す。
+// 削除の途中で参照が追加されても実体を失わないよう、退避してから参照を確認し直す。
+func (gf *File) deleteUnreferencedObject(ctx context.Context, hash values.FileHash) (bool, error) {
+	gf.locker.Lock()
+	defer gf.locker.Unlock()
+
+	// 処理中に参照がなくなった場合に付け直された印を消さないよう、先に印を削除する
+	err := gf.client.deleteFile(ctx, gf.orphanKey(hash))
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return false, fmt.Errorf("failed to delete orphan: %w", err)
+	}
+
+	isDeleted, err := gf.evictObject(ctx, hash)
+	if err != nil {
+		// 次回に再度削除を試みられるよう、印を付け直す
+		markErr := gf.client.saveMarker(ctx, gf.orphanKey(hash))
+		if markErr != nil {
+			log.Printf("error: failed to save orphan: %v\n", markErr)
+		}
+
+		return false, err
+	}
+
+	return isDeleted, nil
+}
+
+func (gf *File) evictObject(ctx context.Context, hash values.FileHash) (bool, error) {
+	isRefExist, err := gf.client.hasFiles(ctx, gf.refPrefix(hash))
+	if err != nil {
+		return false, fmt.Errorf("failed to check refs: %w", err)
+	}
+	if isRefExist {
+		// 前回の削除が退避の途中で失敗していた場合は元に戻す
+		return false, gf.restoreObject(ctx, hash)
+	}
+
+	err = gf.client.moveFile(ctx, gf.objectKey(hash), gf.trashKey(hash))
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return false, fmt.Errorf("failed to move object to trash: %w", err)
+	}
+	isMoved := err == nil
+
+	isRefExist, err = gf.client.hasFiles(ctx, gf.refPrefix(hash))
+	if err != nil {
+		return false, fmt.Errorf("failed to check refs: %w", err)
+	}
+	if isRefExist {
+		return false, gf.restoreObject(ctx, hash)
+	}
+
+	err = gf.client.deleteFile(ctx, gf.trashKey(hash))
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return false, fmt.Errorf("failed to delete trash: %w", err)
+	}
+
+	return isMoved, nil
+}
+
+// restoreObject 退避した実体を戻す。保存で置き直されている場合も同じ内容なので上書きする。
+func (gf *File) restoreObject(ctx context.Context, hash values.FileHash) error {
+	err := gf.client.moveFile(ctx, gf.trashKey(hash), gf.objectKey(hash))
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return fmt.Errorf("failed to restore object: %w", err)
+	}
+
+	return nil
+}
+
+func (gf *File) ListFiles(ctx context.Context, fn func(entry *storage.FileEntry) error) error {
+	err := gf.client.walkFiles(ctx, "refs/", func(name string, lastModified time.Time) error {
+		// refs/<hash>/<fileID>
+		var strHash, strFileID string
+		_, err := fmt.Sscanf(strings.ReplaceAll(name, "/", " "), "refs %s %s", &strHash, &strFileID)
+		if err != nil {
+			log.Printf("error: invalid ref: %s\n", name)
+			return nil
+		}
+
+		hash, err := values.NewFileHashFromString(strHash)
+		if err != nil || hash.IsZero() {
+			log.Printf("error: invalid ref: %s\n", name)
+			return nil
+		}
+
+		fileID, err := uuid.Parse(strFileID)
+		if err != nil {
+			log.Printf("error: invalid ref: %s\n", name)
+			return nil
+		}
+
+		return fn(&storage.FileEntry{
+			FileID:     values.NewFileIDFromUUID(fileID),
+			Hash:       hash,
+			ModifiedAt: lastModified,
+		})
+	})
+	if err != nil {
+		return fmt.Errorf("failed to walk refs: %w", err)
+	}
+
+	err = gf.client.walkFiles(ctx, "files/", func(name string, lastModified time.Time) error {
+		fileID, err := uuid.Parse(strings.TrimPrefix(name, "files/"))
+		if err != nil {
+			log.Printf("error: invalid file: %s\n", name)
+			return nil
+		}
+
+		return fn(&storage.FileEntry{
+			FileID:     values.NewFileIDFromUUID(fileID),
+			ModifiedAt: lastModified,
+		})
+	})
+	if err != nil {
+		return fmt.Errorf("failed to walk files: %w", err)
+	}
+
+	return nil
+}
+
+// fileKeys ファイルが存在しうるキーを優先度順に返す。
+// ハッシュ導入前に保存されたファイルは従来のキーに存在し、
+// 移行などで後からハッシュが設定された場合も従来のキーにしかないことがある。
+func (gf *File) fileKeys(file *domain.File) []string {
+	legacyKey := gf.legacyKey(file.GetID())
+
+	hash := file.GetHash()
+	if hash.IsZero() {
+		return []string{legacyKey}
+	}
+
+	return []string{gf.objectKey(hash), legacyKey}
+}
+
+func (gf *File) legacyKey(fileID values.FileID) string {
+	return fmt.Sprintf("files/%s", uuid.UUID(fileID).String())
+}
+
+func (gf *File) objectKey(hash values.FileHash) string {
+	return fmt.Sprintf("objects/%s", hash.String())
+}
+
+func (gf *File) refKey(hash values.FileHash, fileID values.FileID) string {
+	return gf.refPrefix(hash) + uuid.UUID(fileID).String()
+}
+
+func (gf *File) refPrefix(hash values.FileHash) string {
+	return fmt.Sprintf("refs/%s/", hash.String())
+}
+
+// orphanKey 参照がなくなった実体の印
+func (gf *File) orphanKey(hash values.FileHash) string {
+	return fmt.Sprintf("orphans/%s", hash.String())
+}
+
+// trashKey 削除する前に参照を確認し直す間、実体を退避させる先
+func (gf *File) trashKey(hash values.FileHash) string {
+	return fmt.Sprintf("trash/%s", hash.String())
+}
+
+func (gf *File) tmpKey(file *domain.File) string {
+	return fmt.Sprintf("tmp/%s", uuid.UUID(file.GetID()).String())
 }
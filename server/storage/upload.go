@@ -0,0 +1,20 @@
+package storage
+
+import (
+	"context"
+	"io"
+
+	"github.com/mazrean/Quantainer/domain/values"
+)
+
+// Upload 再開可能なアップロードの途中の内容を、ファイルとして保存するまで一時的に保持する
+type Upload interface {
+	CreateUpload(ctx context.Context, uploadID values.UploadID) error
+	// WriteUpload offsetの位置からreaderの内容を書き込み、書き込んだバイト数を返す。
+	// offsetより後に書き込まれていた内容は破棄する。
+	// 途中でエラーになった場合も、それまでに書き込んだバイト数を返す。
+	WriteUpload(ctx context.Context, uploadID values.UploadID, offset int64, reader io.Reader) (int64, error)
+	// OpenUpload 呼び出し側でCloseする必要がある
+	OpenUpload(ctx context.Context, uploadID values.UploadID) (io.ReadCloser, error)
+	DeleteUpload(ctx context.Context, uploadID values.UploadID) error
+}
@@ -0,0 +1,459 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path"
+
+	"github.com/google/uuid"
+	"github.com/mazrean/Quantainer/domain/values"
+	"github.com/mazrean/Quantainer/pkg/common"
+	"github.com/mazrean/Quantainer/repository/gorm2"
+	"github.com/mazrean/Quantainer/service"
+	v1Service "github.com/mazrean/Quantainer/service/v1"
+)
+
+const storageCommandUsage = `usage:
+  quantainer storage migrate --from <local|swift|s3> --to <local|swift|s3> [--state <path>]
+  quantainer storage scrub [--storage <local|swift|s3>] [--verify] [--orphans <report|delete|quarantine>] [--quarantine-dir <path>] [--grace-period <duration>]
+  quantainer storage backfill [--storage <local|swift|s3>] [--force]
+  quantainer storage rotate-keys [--storage <local|swift|s3>]`
+
+// runStorageCommand quantainer storage <subcommand>
+func runStorageCommand(isProduction bool, args []string) error {
+	if len(args) == 0 {
+		return errors.New(storageCommandUsage)
+	}
+
+	switch args[0] {
+	case "migrate":
+		return runStorageMigrateCommand(isProduction, args[1:])
+	case "scrub":
+		return runStorageScrubCommand(isProduction, args[1:])
+	case "backfill":
+		return runStorageBackfillCommand(isProduction, args[1:])
+	case "rotate-keys":
+		return runStorageRotateKeysCommand(isProduction, args[1:])
+	default:
+		return fmt.Errorf("unknown command: %s\n%s", args[0], storageCommandUsage)
+	}
+}
+
+func runStorageMigrateCommand(isProduction bool, args []string) error {
+	flagSet := flag.NewFlagSet("migrate", flag.ContinueOnError)
+	from := flagSet.String("from", "", "移行元のストレージ(local, swift, s3)")
+	to := flagSet.String("to", "", "移行先のストレージ(local, swift, s3)")
+	statePath := flagSet.String("state", "", "移行済みのファイルを記録するファイル(デフォルト: FILE_PATH/migrate-<from>-<to>.state)")
+	err := flagSet.Parse(args)
+	if err != nil {
+		return fmt.Errorf("failed to parse flags: %w", err)
+	}
+
+	srcType, err := parseStorageType(*from)
+	if err != nil {
+		return fmt.Errorf("invalid --from: %w", err)
+	}
+
+	dstType, err := parseStorageType(*to)
+	if err != nil {
+		return fmt.Errorf("invalid --to: %w", err)
+	}
+
+	if srcType == dstType {
+		return errors.New("--from and --to must be different")
+	}
+
+	filePath, ok := os.LookupEnv("FILE_PATH")
+	if !ok {
+		return errors.New("ENV FILE_PATH is not set")
+	}
+
+	if len(*statePath) == 0 {
+		*statePath = path.Join(filePath, fmt.Sprintf("migrate-%s-%s.state", srcType, dstType))
+	}
+
+	srcStorage, err := newMigrationStorage(isProduction, srcType, filePath)
+	if err != nil {
+		return fmt.Errorf("failed to setup source storage: %w", err)
+	}
+
+	dstStorage, err := newMigrationStorage(isProduction, dstType, filePath)
+	if err != nil {
+		return fmt.Errorf("failed to setup destination storage: %w", err)
+	}
+
+	db, err := gorm2.NewDB(common.IsProduction(isProduction))
+	if err != nil {
+		return fmt.Errorf("failed to setup db: %w", err)
+	}
+
+	fileRepository, err := gorm2.NewFile(db)
+	if err != nil {
+		return fmt.Errorf("failed to setup file repository: %w", err)
+	}
+
+	migratedFileIDs, err := loadMigratedFileIDs(*statePath)
+	if err != nil {
+		return fmt.Errorf("failed to load state: %w", err)
+	}
+
+	stateFile, err := os.OpenFile(*statePath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open state file: %w", err)
+	}
+	defer stateFile.Close()
+
+	// 中断しても状態ファイルに記録済みのファイルは次回飛ばされるので、途中から再開できる
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	migrator := v1Service.NewStorageMigrator(fileRepository, srcStorage.File, dstStorage.File)
+	result, err := migrator.Migrate(ctx, migratedFileIDs, func(fileID values.FileID) error {
+		_, err := fmt.Fprintln(stateFile, uuid.UUID(fileID).String())
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to migrate: %w", err)
+	}
+
+	fmt.Printf("copied: %d, skipped: %d, failed: %d\n", result.Copied, result.Skipped, len(result.Failed))
+	for _, failure := range result.Failed {
+		fmt.Printf("failed: %s: %v\n", uuid.UUID(failure.FileID).String(), failure.Err)
+	}
+
+	if len(result.Failed) != 0 {
+		return fmt.Errorf("failed to migrate %d files", len(result.Failed))
+	}
+
+	return nil
+}
+
+func runStorageScrubCommand(isProduction bool, args []string) error {
+	flagSet := flag.NewFlagSet("scrub", flag.ContinueOnError)
+	strStorageType := flagSet.String("storage", os.Getenv("STORAGE_TYPE"), "確認するストレージ(local, swift, s3)(デフォルト: STORAGE_TYPE)")
+	verify := flagSet.Bool("verify", false, "ファイルの内容を読み込み、ハッシュを検証する")
+	orphans := flagSet.String("orphans", "report", "DBに存在しないファイルの扱い(report, delete, quarantine)")
+	quarantineDir := flagSet.String("quarantine-dir", "", "隔離先のディレクトリ(デフォルト: FILE_PATH/quarantine)")
+	gracePeriod := flagSet.Duration("grace-period", defaultScrubGracePeriod, "DBに存在しなくても孤立したファイルとして扱わない、保存からの期間")
+	err := flagSet.Parse(args)
+	if err != nil {
+		return fmt.Errorf("failed to parse flags: %w", err)
+	}
+
+	filePath, ok := os.LookupEnv("FILE_PATH")
+	if !ok {
+		return errors.New("ENV FILE_PATH is not set")
+	}
+
+	if len(*strStorageType) == 0 {
+		if isProduction {
+			*strStorageType = string(common.StorageTypeSwift)
+		} else {
+			*strStorageType = string(common.StorageTypeLocal)
+		}
+	}
+
+	storageType, err := parseStorageType(*strStorageType)
+	if err != nil {
+		return fmt.Errorf("invalid --storage: %w", err)
+	}
+
+	var orphanAction service.ScrubOrphanAction
+	switch *orphans {
+	case "report":
+		orphanAction = service.ScrubOrphanActionReport
+	case "delete":
+		orphanAction = service.ScrubOrphanActionDelete
+	case "quarantine":
+		orphanAction = service.ScrubOrphanActionQuarantine
+	default:
+		return fmt.Errorf("invalid --orphans: %s", *orphans)
+	}
+
+	if len(*quarantineDir) == 0 {
+		*quarantineDir = path.Join(filePath, "quarantine")
+	}
+
+	fileStorage, err := newMigrationStorage(isProduction, storageType, filePath)
+	if err != nil {
+		return fmt.Errorf("failed to setup storage: %w", err)
+	}
+
+	db, err := gorm2.NewDB(common.IsProduction(isProduction))
+	if err != nil {
+		return fmt.Errorf("failed to setup db: %w", err)
+	}
+
+	fileRepository, err := gorm2.NewFile(db)
+	if err != nil {
+		return fmt.Errorf("failed to setup file repository: %w", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	scrubber := v1Service.NewScrubber(fileRepository, fileStorage.File)
+	result, err := scrubber.Scrub(ctx, &service.ScrubParams{
+		VerifyContent: *verify,
+		OrphanAction:  orphanAction,
+		QuarantineDir: *quarantineDir,
+		GracePeriod:   *gracePeriod,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to scrub: %w", err)
+	}
+
+	printScrubResult(os.Stdout, result)
+
+	if !isScrubResultClean(result) {
+		return errors.New("inconsistency found")
+	}
+
+	return nil
+}
+
+func runStorageBackfillCommand(isProduction bool, args []string) error {
+	flagSet := flag.NewFlagSet("backfill", flag.ContinueOnError)
+	strStorageType := flagSet.String("storage", os.Getenv("STORAGE_TYPE"), "読み込むストレージ(local, swift, s3)(デフォルト: STORAGE_TYPE)")
+	force := flagSet.Bool("force", false, "記録済みのファイルも記録し直す")
+	err := flagSet.Parse(args)
+	if err != nil {
+		return fmt.Errorf("failed to parse flags: %w", err)
+	}
+
+	filePath, ok := os.LookupEnv("FILE_PATH")
+	if !ok {
+		return errors.New("ENV FILE_PATH is not set")
+	}
+
+	if len(*strStorageType) == 0 {
+		if isProduction {
+			*strStorageType = string(common.StorageTypeSwift)
+		} else {
+			*strStorageType = string(common.StorageTypeLocal)
+		}
+	}
+
+	storageType, err := parseStorageType(*strStorageType)
+	if err != nil {
+		return fmt.Errorf("invalid --storage: %w", err)
+	}
+
+	fileStorage, err := newMigrationStorage(isProduction, storageType, filePath)
+	if err != nil {
+		return fmt.Errorf("failed to setup storage: %w", err)
+	}
+
+	db, err := gorm2.NewDB(common.IsProduction(isProduction))
+	if err != nil {
+		return fmt.Errorf("failed to setup db: %w", err)
+	}
+
+	fileRepository, err := gorm2.NewFile(db)
+	if err != nil {
+		return fmt.Errorf("failed to setup file repository: %w", err)
+	}
+
+	// 記録済みのファイルは次回飛ばされるので、中断しても途中から再開できる
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	// 内容を解析するので、暗号化されている場合は復号して読み込む
+	backfillStorage := fileStorage.File
+	storageEncryptionKeys := lookupStorageEncryptionKeys()
+	if len(storageEncryptionKeys) != 0 {
+		backfillStorage, err = newEncryptedFile(fileStorage.File, storageEncryptionKeys)
+		if err != nil {
+			return fmt.Errorf("failed to setup encryption: %w", err)
+		}
+	}
+
+	backfiller := v1Service.NewMetadataBackfiller(fileRepository, backfillStorage)
+	result, err := backfiller.Backfill(ctx, *force)
+	if err != nil {
+		return fmt.Errorf("failed to backfill: %w", err)
+	}
+
+	fmt.Printf("updated: %d, skipped: %d, failed: %d\n", result.Updated, result.Skipped, len(result.Failed))
+	for _, failure := range result.Failed {
+		fmt.Printf("failed: %s: %v\n", uuid.UUID(failure.FileID).String(), failure.Err)
+	}
+
+	if len(result.Failed) != 0 {
+		return fmt.Errorf("failed to backfill %d files", len(result.Failed))
+	}
+
+	return nil
+}
+
+func runStorageRotateKeysCommand(isProduction bool, args []string) error {
+	flagSet := flag.NewFlagSet("rotate-keys", flag.ContinueOnError)
+	strStorageType := flagSet.String("storage", os.Getenv("STORAGE_TYPE"), "暗号化し直すストレージ(local, swift, s3)(デフォルト: STORAGE_TYPE)")
+	err := flagSet.Parse(args)
+	if err != nil {
+		return fmt.Errorf("failed to parse flags: %w", err)
+	}
+
+	filePath, ok := os.LookupEnv("FILE_PATH")
+	if !ok {
+		return errors.New("ENV FILE_PATH is not set")
+	}
+
+	if len(*strStorageType) == 0 {
+		if isProduction {
+			*strStorageType = string(common.StorageTypeSwift)
+		} else {
+			*strStorageType = string(common.StorageTypeLocal)
+		}
+	}
+
+	storageType, err := parseStorageType(*strStorageType)
+	if err != nil {
+		return fmt.Errorf("invalid --storage: %w", err)
+	}
+
+	storageEncryptionKeys := lookupStorageEncryptionKeys()
+	if len(storageEncryptionKeys) == 0 {
+		return errors.New("ENV STORAGE_ENCRYPTION_KEYS is not set")
+	}
+
+	fileStorage, err := newMigrationStorage(isProduction, storageType, filePath)
+	if err != nil {
+		return fmt.Errorf("failed to setup storage: %w", err)
+	}
+
+	encryptedFile, err := newEncryptedFile(fileStorage.File, storageEncryptionKeys)
+	if err != nil {
+		return fmt.Errorf("failed to setup encryption: %w", err)
+	}
+
+	db, err := gorm2.NewDB(common.IsProduction(isProduction))
+	if err != nil {
+		return fmt.Errorf("failed to setup db: %w", err)
+	}
+
+	fileRepository, err := gorm2.NewFile(db)
+	if err != nil {
+		return fmt.Errorf("failed to setup file repository: %w", err)
+	}
+
+	// 現在の鍵で暗号化済みのファイルは次回飛ばされるので、中断しても途中から再開できる
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	keyRotator := v1Service.NewKeyRotator(fileRepository, encryptedFile, encryptedFile)
+	result, err := keyRotator.Rotate(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to rotate keys: %w", err)
+	}
+
+	fmt.Printf("rotated: %d, skipped: %d, failed: %d\n", result.Rotated, result.Skipped, len(result.Failed))
+	for _, failure := range result.Failed {
+		fmt.Printf("failed: %s: %v\n", uuid.UUID(failure.FileID).String(), failure.Err)
+	}
+
+	if len(result.Failed) != 0 {
+		return fmt.Errorf("failed to rotate keys of %d files", len(result.Failed))
+	}
+
+	return nil
+}
+
+func printScrubResult(w io.Writer, result *service.ScrubResult) {
+	fmt.Fprintf(
+		w,
+		"checked: %d, orphans: %d, missing: %d, hash mismatches: %d, corrupted: %d\n",
+		result.Checked,
+		len(result.Orphans),
+		len(result.Missing),
+		len(result.HashMismatches),
+		len(result.Corrupted),
+	)
+	for _, orphan := range result.Orphans {
+		if orphan.Err != nil {
+			fmt.Fprintf(w, "orphan: %s(hash: %s): %v\n", uuid.UUID(orphan.FileID).String(), orphan.Hash, orphan.Err)
+		} else {
+			fmt.Fprintf(w, "orphan: %s(hash: %s)\n", uuid.UUID(orphan.FileID).String(), orphan.Hash)
+		}
+	}
+	for _, fileID := range result.Missing {
+		fmt.Fprintf(w, "missing: %s\n", uuid.UUID(fileID).String())
+	}
+	for _, mismatch := range result.HashMismatches {
+		fmt.Fprintf(w, "hash mismatch: %s(db: %s, storage: %s)\n", uuid.UUID(mismatch.FileID).String(), mismatch.Expected, mismatch.Actual)
+	}
+	for _, corrupted := range result.Corrupted {
+		fmt.Fprintf(w, "corrupted: %s(expected: %s, actual: %s)\n", uuid.UUID(corrupted.FileID).String(), corrupted.Expected, corrupted.Actual)
+	}
+}
+
+func isScrubResultClean(result *service.ScrubResult) bool {
+	return len(result.Orphans) == 0 &&
+		len(result.Missing) == 0 &&
+		len(result.HashMismatches) == 0 &&
+		len(result.Corrupted) == 0
+}
+
+func parseStorageType(strStorageType string) (common.StorageType, error) {
+	storageType := common.StorageType(strStorageType)
+	switch storageType {
+	case common.StorageTypeLocal, common.StorageTypeSwift, common.StorageTypeS3:
+		return storageType, nil
+	default:
+		return "", fmt.Errorf("unknown storage type: %s", strStorageType)
+	}
+}
+
+// newMigrationStorage 移行時には読み込みのキャッシュは不要なので、キャッシュを挟まないストレージを返す
+func newMigrationStorage(isProduction bool, storageType common.StorageType, filePath string) (*Storage, error) {
+	config := &Config{
+		IsProduction: common.IsProduction(isProduction),
+		StorageType:  storageType,
+		FilePath:     common.FilePath(filePath),
+	}
+	loadStorageConfig(config)
+
+	s, err := injectedStorage(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to inject storage: %w", err)
+	}
+
+	return s, nil
+}
+
+func loadMigratedFileIDs(statePath string) (map[values.FileID]struct{}, error) {
+	migratedFileIDs := map[values.FileID]struct{}{}
+
+	f, err := os.Open(statePath)
+	if errors.Is(err, os.ErrNotExist) {
+		return migratedFileIDs, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open state file: %w", err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		// 書き込み途中で中断された行は記録されていないものとして扱う
+		uuidFileID, err := uuid.Parse(scanner.Text())
+		if err != nil {
+			continue
+		}
+
+		migratedFileIDs[values.NewFileIDFromUUID(uuidFileID)] = struct{}{}
+	}
+
+	err = scanner.Err()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read state file: %w", err)
+	}
+
+	return migratedFileIDs, nil
+}
@@ -4,7 +4,9 @@
 package main
 
 import (
+	"fmt"
 	"net/http"
+	"path"
 
 	"github.com/google/wire"
 	"github.com/mazrean/Quantainer/auth"
@@ -16,35 +18,67 @@ import (
 	"github.com/mazrean/Quantainer/pkg/common"
 	"github.com/mazrean/Quantainer/repository"
 	"github.com/mazrean/Quantainer/repository/gorm2"
+	"github.com/mazrean/Quantainer/scanner"
+	"github.com/mazrean/Quantainer/scanner/clamd"
+	"github.com/mazrean/Quantainer/scanner/noop"
 	"github.com/mazrean/Quantainer/service"
 	v1Service "github.com/mazrean/Quantainer/service/v1"
 	"github.com/mazrean/Quantainer/storage"
+	"github.com/mazrean/Quantainer/storage/encryption"
 	"github.com/mazrean/Quantainer/storage/local"
+	"github.com/mazrean/Quantainer/storage/readcache"
+	"github.com/mazrean/Quantainer/storage/s3"
 	"github.com/mazrean/Quantainer/storage/swift"
 )
 
 type Config struct {
-	IsProduction      common.IsProduction
-	SessionKey        common.SessionKey
-	SessionSecret     common.SessionSecret
-	TraQBaseURL       common.TraQBaseURL
-	OAuthClientID     common.ClientID
-	SwiftAuthURL      common.SwiftAuthURL
-	SwiftUserName     common.SwiftUserName
-	SwiftPassword     common.SwiftPassword
-	SwiftTenantID     common.SwiftTenantID
-	SwiftTenantName   common.SwiftTenantName
-	SwiftContainer    common.SwiftContainer
-	FilePath          common.FilePath
-	AccessToken       common.AccessToken
-	VerificationToken common.VerificationToken
-	DefaultChannels   common.DefaultChannels
-	UpdatedAt         common.UpdatedAt
-	HttpClient        *http.Client
+	IsProduction            common.IsProduction
+	SessionKey              common.SessionKey
+	SessionSecret           common.SessionSecret
+	TraQBaseURL             common.TraQBaseURL
+	OAuthClientID           common.ClientID
+	SwiftAuthURL            common.SwiftAuthURL
+	SwiftUserName           common.SwiftUserName
+	SwiftPassword           common.SwiftPassword
+	SwiftTenantID           common.SwiftTenantID
+	SwiftTenantName         common.SwiftTenantName
+	SwiftContainer          common.SwiftContainer
+	StorageType             common.StorageType
+	S3Endpoint              common.S3Endpoint
+	S3Region                common.S3Region
+	S3Bucket                common.S3Bucket
+	S3AccessKeyID           common.S3AccessKeyID
+	S3SecretAccessKey       common.S3SecretAccessKey
+	S3Prefix                common.S3Prefix
+	FilePath                common.FilePath
+	AccessToken             common.AccessToken
+	VerificationToken       common.VerificationToken
+	DefaultChannels         common.DefaultChannels
+	Administrators          common.Administrators
+	UserMaxBytes            common.UserMaxBytes
+	UserMaxFiles            common.UserMaxFiles
+	GlobalMaxBytes          common.GlobalMaxBytes
+	GlobalMaxFiles          common.GlobalMaxFiles
+	UploadMaxSize           common.UploadMaxSize
+	UploadMaxSizes          common.UploadMaxSizes
+	ImportMaxBodySize       common.ImportMaxBodySize
+	UploadAllowedMimeTypes  common.UploadAllowedMimeTypes
+	UploadDeniedMimeTypes   common.UploadDeniedMimeTypes
+	UploadRejectExecutables common.UploadRejectExecutables
+	StripImageMetadata      common.StripImageMetadata
+	ShareLinkSecret         common.ShareLinkSecret
+	StorageEncryptionKeys   common.StorageEncryptionKeys
+	StorageCacheMaxBytes    common.StorageCacheMaxBytes
+	ScannerType             common.ScannerType
+	ClamdAddress            common.ClamdAddress
+	UpdatedAt               common.UpdatedAt
+	HttpClient              *http.Client
 }
 
 type Storage struct {
 	File storage.File
+	// Cache 読み込みのキャッシュが無効の場合はnil
+	Cache *readcache.File
 }
 
 func newStorage(file storage.File) *Storage {
@@ -54,31 +88,116 @@ func newStorage(file storage.File) *Storage {
 }
 
 var (
-	isProductionField      = wire.FieldsOf(new(*Config), "IsProduction")
-	sessionKeyField        = wire.FieldsOf(new(*Config), "SessionKey")
-	sessionSecretField     = wire.FieldsOf(new(*Config), "SessionSecret")
-	traQBaseURLField       = wire.FieldsOf(new(*Config), "TraQBaseURL")
-	oAuthClientIDField     = wire.FieldsOf(new(*Config), "OAuthClientID")
-	swiftAuthURLField      = wire.FieldsOf(new(*Config), "SwiftAuthURL")
-	swiftUserNameField     = wire.FieldsOf(new(*Config), "SwiftUserName")
-	swiftPasswordField     = wire.FieldsOf(new(*Config), "SwiftPassword")
-	swiftTenantIDField     = wire.FieldsOf(new(*Config), "SwiftTenantID")
-	swiftTenantNameField   = wire.FieldsOf(new(*Config), "SwiftTenantName")
-	swiftContainerField    = wire.FieldsOf(new(*Config), "SwiftContainer")
-	filePathField          = wire.FieldsOf(new(*Config), "FilePath")
-	accessTokenField       = wire.FieldsOf(new(*Config), "AccessToken")
-	verificationTokenField = wire.FieldsOf(new(*Config), "VerificationToken")
-	defaultChannelsField   = wire.FieldsOf(new(*Config), "DefaultChannels")
-	updatedAtField         = wire.FieldsOf(new(*Config), "UpdatedAt")
-	httpClientField        = wire.FieldsOf(new(*Config), "HttpClient")
+	isProductionField            = wire.FieldsOf(new(*Config), "IsProduction")
+	sessionKeyField              = wire.FieldsOf(new(*Config), "SessionKey")
+	sessionSecretField           = wire.FieldsOf(new(*Config), "SessionSecret")
+	traQBaseURLField             = wire.FieldsOf(new(*Config), "TraQBaseURL")
+	oAuthClientIDField           = wire.FieldsOf(new(*Config), "OAuthClientID")
+	swiftAuthURLField            = wire.FieldsOf(new(*Config), "SwiftAuthURL")
+	swiftUserNameField           = wire.FieldsOf(new(*Config), "SwiftUserName")
+	swiftPasswordField           = wire.FieldsOf(new(*Config), "SwiftPassword")
+	swiftTenantIDField           = wire.FieldsOf(new(*Config), "SwiftTenantID")
+	swiftTenantNameField         = wire.FieldsOf(new(*Config), "SwiftTenantName")
+	swiftContainerField          = wire.FieldsOf(new(*Config), "SwiftContainer")
+	s3EndpointField              = wire.FieldsOf(new(*Config), "S3Endpoint")
+	s3RegionField                = wire.FieldsOf(new(*Config), "S3Region")
+	s3BucketField                = wire.FieldsOf(new(*Config), "S3Bucket")
+	s3AccessKeyIDField           = wire.FieldsOf(new(*Config), "S3AccessKeyID")
+	s3SecretAccessKeyField       = wire.FieldsOf(new(*Config), "S3SecretAccessKey")
+	s3PrefixField                = wire.FieldsOf(new(*Config), "S3Prefix")
+	filePathField                = wire.FieldsOf(new(*Config), "FilePath")
+	accessTokenField             = wire.FieldsOf(new(*Config), "AccessToken")
+	verificationTokenField       = wire.FieldsOf(new(*Config), "VerificationToken")
+	defaultChannelsField         = wire.FieldsOf(new(*Config), "DefaultChannels")
+	administratorsField          = wire.FieldsOf(new(*Config), "Administrators")
+	userMaxBytesField            = wire.FieldsOf(new(*Config), "UserMaxBytes")
+	userMaxFilesField            = wire.FieldsOf(new(*Config), "UserMaxFiles")
+	globalMaxBytesField          = wire.FieldsOf(new(*Config), "GlobalMaxBytes")
+	globalMaxFilesField          = wire.FieldsOf(new(*Config), "GlobalMaxFiles")
+	uploadMaxSizeField           = wire.FieldsOf(new(*Config), "UploadMaxSize")
+	uploadMaxSizesField          = wire.FieldsOf(new(*Config), "UploadMaxSizes")
+	importMaxBodySizeField       = wire.FieldsOf(new(*Config), "ImportMaxBodySize")
+	uploadAllowedMimeTypesField  = wire.FieldsOf(new(*Config), "UploadAllowedMimeTypes")
+	uploadDeniedMimeTypesField   = wire.FieldsOf(new(*Config), "UploadDeniedMimeTypes")
+	uploadRejectExecutablesField = wire.FieldsOf(new(*Config), "UploadRejectExecutables")
+	stripImageMetadataField      = wire.FieldsOf(new(*Config), "StripImageMetadata")
+	shareLinkSecretField         = wire.FieldsOf(new(*Config), "ShareLinkSecret")
+	updatedAtField               = wire.FieldsOf(new(*Config), "UpdatedAt")
+	httpClientField              = wire.FieldsOf(new(*Config), "HttpClient")
 )
 
 func injectedStorage(config *Config) (*Storage, error) {
-	if config.IsProduction {
+	switch config.StorageType {
+	case common.StorageTypeSwift:
 		return injectSwiftStorage(config)
+	case common.StorageTypeS3:
+		return injectS3Storage(config)
+	case common.StorageTypeLocal:
+		return injectLocalStorage(config)
+	default:
+		return nil, fmt.Errorf("invalid storage type: %s", config.StorageType)
 	}
+}
+
+// injectedFileStorage キャッシュの上限が設定されている場合は読み込んだ内容をキャッシュし、
+// 暗号化の鍵が設定されている場合は保存する内容を暗号化する。
+// キャッシュには暗号化された内容を保存するよう、暗号化はキャッシュの外側で行う。
+// ストレージ間の移行や整合性の確認では、暗号化された内容のまま扱うためinjectedStorageを使う。
+func injectedFileStorage(config *Config) (*Storage, error) {
+	s, err := injectedStorage(config)
+	if err != nil {
+		return nil, err
+	}
+
+	if config.StorageCacheMaxBytes > 0 {
+		s.Cache, err = readcache.NewFile(
+			s.File,
+			common.FilePath(path.Join(string(config.FilePath), "cache")),
+			config.StorageCacheMaxBytes,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create read cache: %w", err)
+		}
+
+		s.File = s.Cache
+	}
+
+	if len(config.StorageEncryptionKeys) == 0 {
+		return s, nil
+	}
+
+	s.File, err = newEncryptedFile(s.File, config.StorageEncryptionKeys)
+	if err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+func newEncryptedFile(file storage.File, keys common.StorageEncryptionKeys) (*encryption.File, error) {
+	keyring, err := encryption.NewKeyring(keys)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create keyring: %w", err)
+	}
+
+	return encryption.NewFile(file, keyring), nil
+}
+
+// injectedScanner 未指定の場合は検査しない
+func injectedScanner(config *Config) (scanner.File, error) {
+	switch config.ScannerType {
+	case common.ScannerTypeNone:
+		return noop.NewFile(), nil
+	case common.ScannerTypeClamd:
+		file, err := clamd.NewFile(config.ClamdAddress)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create clamd scanner: %w", err)
+		}
 
-	return injectLocalStorage(config)
+		return file, nil
+	default:
+		return nil, fmt.Errorf("invalid scanner type: %s", config.ScannerType)
+	}
 }
 
 func injectSwiftStorage(config *Config) (*Storage, error) {
@@ -89,7 +208,6 @@ func injectSwiftStorage(config *Config) (*Storage, error) {
 		swiftTenantIDField,
 		swiftTenantNameField,
 		swiftContainerField,
-		filePathField,
 		wire.Bind(new(storage.File), new(*swift.File)),
 		swift.NewClient,
 		swift.NewFile,
@@ -99,6 +217,23 @@ func injectSwiftStorage(config *Config) (*Storage, error) {
 	return nil, nil
 }
 
+func injectS3Storage(config *Config) (*Storage, error) {
+	wire.Build(
+		s3EndpointField,
+		s3RegionField,
+		s3BucketField,
+		s3AccessKeyIDField,
+		s3SecretAccessKeyField,
+		s3PrefixField,
+		wire.Bind(new(storage.File), new(*s3.File)),
+		s3.NewClient,
+		s3.NewFile,
+		newStorage,
+	)
+
+	return nil, nil
+}
+
 func injectLocalStorage(config *Config) (*Storage, error) {
 	wire.Build(
 		filePathField,
@@ -114,33 +249,59 @@ func injectLocalStorage(config *Config) (*Storage, error) {
 var (
 	dbBind                      = wire.Bind(new(repository.DB), new(*gorm2.DB))
 	fileRepositoryBind          = wire.Bind(new(repository.File), new(*gorm2.File))
+	renditionRepositoryBind     = wire.Bind(new(repository.Rendition), new(*gorm2.Rendition))
 	resourceRepositoryBind      = wire.Bind(new(repository.Resource), new(*gorm2.Resource))
 	groupRepositoryBind         = wire.Bind(new(repository.Group), new(*gorm2.Group))
 	administratorRepositoryBind = wire.Bind(new(repository.Administrator), new(*gorm2.Administrator))
+	quotaRepositoryBind         = wire.Bind(new(repository.Quota), new(*gorm2.Quota))
+	uploadRepositoryBind        = wire.Bind(new(repository.Upload), new(*gorm2.Upload))
+	shareLinkRepositoryBind     = wire.Bind(new(repository.ShareLink), new(*gorm2.ShareLink))
 
 	oidcAuthBind = wire.Bind(new(auth.OIDC), new(*traq.OIDC))
 	userAuthBind = wire.Bind(new(auth.User), new(*traq.User))
 
 	userCacheBind = wire.Bind(new(cache.User), new(*ristretto.User))
 
-	oidcServiceBind     = wire.Bind(new(service.OIDC), new(*v1Service.OIDC))
-	userServiceBind     = wire.Bind(new(service.User), new(*v1Service.User))
-	fileServiceBind     = wire.Bind(new(service.File), new(*v1Service.File))
-	resourceServiceBind = wire.Bind(new(service.Resource), new(*v1Service.Resource))
-	groupServiceBind    = wire.Bind(new(service.Group), new(*v1Service.Group))
+	oidcServiceBind      = wire.Bind(new(service.OIDC), new(*v1Service.OIDC))
+	userServiceBind      = wire.Bind(new(service.User), new(*v1Service.User))
+	fileServiceBind      = wire.Bind(new(service.File), new(*v1Service.File))
+	resourceServiceBind  = wire.Bind(new(service.Resource), new(*v1Service.Resource))
+	groupServiceBind     = wire.Bind(new(service.Group), new(*v1Service.Group))
+	quotaServiceBind     = wire.Bind(new(service.Quota), new(*v1Service.Quota))
+	scrubberServiceBind  = wire.Bind(new(service.Scrubber), new(*v1Service.Scrubber))
+	gcServiceBind        = wire.Bind(new(service.GarbageCollector), new(*v1Service.GarbageCollector))
+	uploadServiceBind    = wire.Bind(new(service.Upload), new(*v1Service.Upload))
+	shareLinkServiceBind = wire.Bind(new(service.ShareLink), new(*v1Service.ShareLink))
+	importerServiceBind  = wire.Bind(new(service.Importer), new(*v1Service.Importer))
+
+	// 再開可能なアップロードの途中の内容は、ストレージの種類に関わらずローカルに保持する
+	uploadStorageBind = wire.Bind(new(storage.Upload), new(*local.Upload))
 
-	fileField = wire.FieldsOf(new(*Storage), "File")
+	fileField  = wire.FieldsOf(new(*Storage), "File")
+	cacheField = wire.FieldsOf(new(*Storage), "Cache")
 )
 
 type Service struct {
 	*v1Handler.API
 	*bot.Bot
+	Scrubber         service.Scrubber
+	GarbageCollector service.GarbageCollector
+	ReadCache        *readcache.File
 }
 
-func NewService(api *v1Handler.API, b *bot.Bot) *Service {
+func NewService(
+	api *v1Handler.API,
+	b *bot.Bot,
+	scrubber service.Scrubber,
+	garbageCollector service.GarbageCollector,
+	readCache *readcache.File,
+) *Service {
 	return &Service{
-		API: api,
-		Bot: b,
+		API:              api,
+		Bot:              b,
+		Scrubber:         scrubber,
+		GarbageCollector: garbageCollector,
+		ReadCache:        readCache,
 	}
 }
 
@@ -153,15 +314,34 @@ func InjectService(config *Config) (*Service, error) {
 		oAuthClientIDField,
 		httpClientField,
 		fileField,
+		cacheField,
 		accessTokenField,
 		verificationTokenField,
 		defaultChannelsField,
+		administratorsField,
+		userMaxBytesField,
+		userMaxFilesField,
+		globalMaxBytesField,
+		globalMaxFilesField,
+		uploadMaxSizeField,
+		uploadMaxSizesField,
+		importMaxBodySizeField,
+		uploadAllowedMimeTypesField,
+		uploadDeniedMimeTypesField,
+		uploadRejectExecutablesField,
+		stripImageMetadataField,
+		shareLinkSecretField,
 		updatedAtField,
+		filePathField,
 		dbBind,
 		fileRepositoryBind,
+		renditionRepositoryBind,
 		resourceRepositoryBind,
 		groupRepositoryBind,
 		administratorRepositoryBind,
+		quotaRepositoryBind,
+		uploadRepositoryBind,
+		shareLinkRepositoryBind,
 		oidcAuthBind,
 		userAuthBind,
 		userCacheBind,
@@ -170,11 +350,22 @@ func InjectService(config *Config) (*Service, error) {
 		fileServiceBind,
 		resourceServiceBind,
 		groupServiceBind,
+		quotaServiceBind,
+		scrubberServiceBind,
+		gcServiceBind,
+		uploadServiceBind,
+		shareLinkServiceBind,
+		importerServiceBind,
+		uploadStorageBind,
 		gorm2.NewDB,
 		gorm2.NewFile,
+		gorm2.NewRendition,
 		gorm2.NewResource,
 		gorm2.NewGroup,
 		gorm2.NewAdministrator,
+		gorm2.NewQuota,
+		gorm2.NewUpload,
+		gorm2.NewShareLink,
 		traq.NewOIDC,
 		traq.NewUser,
 		ristretto.NewUser,
@@ -184,6 +375,14 @@ func InjectService(config *Config) (*Service, error) {
 		v1Service.NewFile,
 		v1Service.NewResource,
 		v1Service.NewGroup,
+		v1Service.NewQuota,
+		v1Service.NewQuotaUtils,
+		v1Service.NewUploadPolicy,
+		v1Service.NewScrubber,
+		v1Service.NewGarbageCollector,
+		v1Service.NewUpload,
+		v1Service.NewShareLink,
+		v1Service.NewImporter,
 		v1Handler.NewAPI,
 		v1Handler.NewSession,
 		v1Handler.NewOAuth2,
@@ -192,9 +391,69 @@ func InjectService(config *Config) (*Service, error) {
 		v1Handler.NewFile,
 		v1Handler.NewResource,
 		v1Handler.NewGroup,
+		v1Handler.NewQuota,
+		v1Handler.NewUpload,
+		v1Handler.NewShareLink,
+		v1Handler.NewImporter,
 		bot.NewBot,
-		injectedStorage,
+		local.NewDirectoryManager,
+		local.NewUpload,
+		injectedFileStorage,
+		injectedScanner,
 		NewService,
 	)
 	return nil, nil
 }
+
+// InjectImporter quantainer importで使う。botやAPIは起動しない
+func InjectImporter(config *Config) (service.Importer, error) {
+	wire.Build(
+		isProductionField,
+		traQBaseURLField,
+		httpClientField,
+		fileField,
+		administratorsField,
+		userMaxBytesField,
+		userMaxFilesField,
+		globalMaxBytesField,
+		globalMaxFilesField,
+		uploadMaxSizeField,
+		uploadMaxSizesField,
+		uploadAllowedMimeTypesField,
+		uploadDeniedMimeTypesField,
+		uploadRejectExecutablesField,
+		stripImageMetadataField,
+		dbBind,
+		fileRepositoryBind,
+		renditionRepositoryBind,
+		resourceRepositoryBind,
+		groupRepositoryBind,
+		administratorRepositoryBind,
+		quotaRepositoryBind,
+		userAuthBind,
+		userCacheBind,
+		fileServiceBind,
+		resourceServiceBind,
+		groupServiceBind,
+		importerServiceBind,
+		gorm2.NewDB,
+		gorm2.NewFile,
+		gorm2.NewRendition,
+		gorm2.NewResource,
+		gorm2.NewGroup,
+		gorm2.NewAdministrator,
+		gorm2.NewQuota,
+		traq.NewUser,
+		ristretto.NewUser,
+		v1Service.NewUserUtils,
+		v1Service.NewFile,
+		v1Service.NewResource,
+		v1Service.NewGroup,
+		v1Service.NewQuotaUtils,
+		v1Service.NewUploadPolicy,
+		v1Service.NewImporter,
+		injectedFileStorage,
+		injectedScanner,
+	)
+	return nil, nil
+}
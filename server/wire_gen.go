@@ -7,6 +7,7 @@
 package main
 
 import (
+	"fmt"
 	"github.com/google/wire"
 	"github.com/mazrean/Quantainer/auth"
 	"github.com/mazrean/Quantainer/auth/traQ"
@@ -17,12 +18,19 @@ import (
 	"github.com/mazrean/Quantainer/pkg/common"
 	"github.com/mazrean/Quantainer/repository"
 	"github.com/mazrean/Quantainer/repository/gorm2"
+	"github.com/mazrean/Quantainer/scanner"
+	"github.com/mazrean/Quantainer/scanner/clamd"
+	"github.com/mazrean/Quantainer/scanner/noop"
 	"github.com/mazrean/Quantainer/service"
 	v1_2 "github.com/mazrean/Quantainer/service/v1"
 	"github.com/mazrean/Quantainer/storage"
+	"github.com/mazrean/Quantainer/storage/encryption"
 	"github.com/mazrean/Quantainer/storage/local"
+	"github.com/mazrean/Quantainer/storage/readcache"
+	"github.com/mazrean/Quantainer/storage/s3"
 	"github.com/mazrean/Quantainer/storage/swift"
 	"net/http"
+	"path"
 )
 
 // Injectors from wire.go:
@@ -34,8 +42,7 @@ func injectSwiftStorage(config *Config) (*Storage, error) {
 	swiftTenantName := config.SwiftTenantName
 	swiftTenantID := config.SwiftTenantID
 	swiftContainer := config.SwiftContainer
-	filePath := config.FilePath
-	client, err := swift.NewClient(swiftAuthURL, swiftUserName, swiftPassword, swiftTenantName, swiftTenantID, swiftContainer, filePath)
+	client, err := swift.NewClient(swiftAuthURL, swiftUserName, swiftPassword, swiftTenantName, swiftTenantID, swiftContainer)
 	if err != nil {
 		return nil, err
 	}
@@ -44,6 +51,22 @@ func injectSwiftStorage(config *Config) (*Storage, error) {
 	return storage, nil
 }
 
+func injectS3Storage(config *Config) (*Storage, error) {
+	s3Endpoint := config.S3Endpoint
+	s3Region := config.S3Region
+	s3Bucket := config.S3Bucket
+	s3AccessKeyID := config.S3AccessKeyID
+	s3SecretAccessKey := config.S3SecretAccessKey
+	s3Prefix := config.S3Prefix
+	client, err := s3.NewClient(s3Endpoint, s3Region, s3Bucket, s3AccessKeyID, s3SecretAccessKey, s3Prefix)
+	if err != nil {
+		return nil, err
+	}
+	file := s3.NewFile(client)
+	storage := newStorage(file)
+	return storage, nil
+}
+
 func injectLocalStorage(config *Config) (*Storage, error) {
 	filePath := config.FilePath
 	directoryManager := local.NewDirectoryManager(filePath)
@@ -64,16 +87,6 @@ func InjectService(config *Config) (*Service, error) {
 	oidc := traq.NewOIDC(client, traQBaseURL)
 	clientID := config.OAuthClientID
 	v1OIDC := v1_2.NewOIDC(oidc, clientID)
-	checker := v1.NewChecker(session, v1OIDC)
-	user := traq.NewUser(client, traQBaseURL)
-	ristrettoUser, err := ristretto.NewUser()
-	if err != nil {
-		return nil, err
-	}
-	userUtils := v1_2.NewUserUtils(user, ristrettoUser)
-	v1User := v1_2.NewUser(userUtils)
-	user2 := v1.NewUser(session, checker, v1User)
-	oAuth2 := v1.NewOAuth2(traQBaseURL, session, checker, v1OIDC)
 	isProduction := config.IsProduction
 	db, err := gorm2.NewDB(isProduction)
 	if err != nil {
@@ -83,27 +96,77 @@ func InjectService(config *Config) (*Service, error) {
 	if err != nil {
 		return nil, err
 	}
-	storage, err := injectedStorage(config)
+	resource, err := gorm2.NewResource(db)
+	if err != nil {
+		return nil, err
+	}
+	group, err := gorm2.NewGroup(db)
+	if err != nil {
+		return nil, err
+	}
+	administrator := gorm2.NewAdministrator(db)
+	shareLink := gorm2.NewShareLink(db)
+	user := traq.NewUser(client, traQBaseURL)
+	ristrettoUser, err := ristretto.NewUser()
+	if err != nil {
+		return nil, err
+	}
+	administrators := config.Administrators
+	userUtils := v1_2.NewUserUtils(user, ristrettoUser, administrators)
+	shareLinkSecret := config.ShareLinkSecret
+	v1ShareLink := v1_2.NewShareLink(file, resource, group, administrator, shareLink, userUtils, shareLinkSecret)
+	checker := v1.NewChecker(session, v1OIDC, v1ShareLink)
+	v1User := v1_2.NewUser(userUtils)
+	user2 := v1.NewUser(session, checker, v1User)
+	oAuth2 := v1.NewOAuth2(traQBaseURL, session, checker, v1OIDC)
+	rendition := gorm2.NewRendition(db)
+	storage, err := injectedFileStorage(config)
 	if err != nil {
 		return nil, err
 	}
 	storageFile := storage.File
-	v1File := v1_2.NewFile(db, file, storageFile, userUtils)
-	file2 := v1.NewFile(session, checker, v1File)
-	resource, err := gorm2.NewResource(db)
+	scannerFile, err := injectedScanner(config)
 	if err != nil {
 		return nil, err
 	}
-	group, err := gorm2.NewGroup(db)
+	quota := gorm2.NewQuota(db)
+	userMaxBytes := config.UserMaxBytes
+	userMaxFiles := config.UserMaxFiles
+	globalMaxBytes := config.GlobalMaxBytes
+	globalMaxFiles := config.GlobalMaxFiles
+	quotaUtils := v1_2.NewQuotaUtils(quota, file, userMaxBytes, userMaxFiles, globalMaxBytes, globalMaxFiles)
+	uploadMaxSize := config.UploadMaxSize
+	uploadMaxSizes := config.UploadMaxSizes
+	uploadAllowedMimeTypes := config.UploadAllowedMimeTypes
+	uploadDeniedMimeTypes := config.UploadDeniedMimeTypes
+	uploadRejectExecutables := config.UploadRejectExecutables
+	stripImageMetadata := config.StripImageMetadata
+	uploadPolicy, err := v1_2.NewUploadPolicy(uploadMaxSize, uploadMaxSizes, uploadAllowedMimeTypes, uploadDeniedMimeTypes, uploadRejectExecutables, stripImageMetadata)
 	if err != nil {
 		return nil, err
 	}
+	v1File := v1_2.NewFile(db, file, rendition, resource, group, storageFile, scannerFile, userUtils, quotaUtils, uploadPolicy)
+	file2 := v1.NewFile(session, checker, v1File, v1ShareLink)
 	v1Resource := v1_2.NewResource(db, file, resource, group, userUtils)
 	resource2 := v1.NewResource(session, checker, v1Resource)
-	administrator := gorm2.NewAdministrator(db)
-	v1Group := v1_2.NewGroup(db, resource, group, administrator, userUtils)
+	v1Group := v1_2.NewGroup(db, resource, group, administrator, userUtils, storageFile)
 	group2 := v1.NewGroup(session, checker, v1Group)
-	api := v1.NewAPI(user2, oAuth2, session, file2, resource2, group2)
+	v1Quota := v1_2.NewQuota(quota, file, userUtils, quotaUtils)
+	quota2 := v1.NewQuota(session, checker, v1Quota)
+	upload := gorm2.NewUpload(db)
+	filePath := config.FilePath
+	directoryManager := local.NewDirectoryManager(filePath)
+	localUpload, err := local.NewUpload(directoryManager)
+	if err != nil {
+		return nil, err
+	}
+	v1Upload := v1_2.NewUpload(file, upload, localUpload, userUtils, quotaUtils, uploadPolicy, v1File)
+	upload2 := v1.NewUpload(session, checker, v1Upload)
+	shareLink2 := v1.NewShareLink(session, checker, v1ShareLink)
+	importer := v1_2.NewImporter(db, storageFile, v1File, v1Resource, v1Group)
+	importMaxBodySize := config.ImportMaxBodySize
+	v1Importer := v1.NewImporter(session, checker, importer, importMaxBodySize)
+	api := v1.NewAPI(user2, oAuth2, session, file2, resource2, group2, quota2, upload2, shareLink2, v1Importer)
 	accessToken := config.AccessToken
 	verificationToken := config.VerificationToken
 	defaultChannels := config.DefaultChannels
@@ -112,34 +175,125 @@ func InjectService(config *Config) (*Service, error) {
 	if err != nil {
 		return nil, err
 	}
-	service := NewService(api, botBot)
+	scrubber := v1_2.NewScrubber(file, storageFile)
+	garbageCollector := v1_2.NewGarbageCollector(db, file, rendition, resource, group, upload, storageFile, localUpload)
+	readcacheFile := storage.Cache
+	service := NewService(api, botBot, scrubber, garbageCollector, readcacheFile)
 	return service, nil
 }
 
+// InjectImporter quantainer importで使う。botやAPIは起動しない
+func InjectImporter(config *Config) (service.Importer, error) {
+	isProduction := config.IsProduction
+	db, err := gorm2.NewDB(isProduction)
+	if err != nil {
+		return nil, err
+	}
+	storage, err := injectedFileStorage(config)
+	if err != nil {
+		return nil, err
+	}
+	file := storage.File
+	gorm2File, err := gorm2.NewFile(db)
+	if err != nil {
+		return nil, err
+	}
+	rendition := gorm2.NewRendition(db)
+	resource, err := gorm2.NewResource(db)
+	if err != nil {
+		return nil, err
+	}
+	group, err := gorm2.NewGroup(db)
+	if err != nil {
+		return nil, err
+	}
+	scannerFile, err := injectedScanner(config)
+	if err != nil {
+		return nil, err
+	}
+	client := config.HttpClient
+	traQBaseURL := config.TraQBaseURL
+	user := traq.NewUser(client, traQBaseURL)
+	ristrettoUser, err := ristretto.NewUser()
+	if err != nil {
+		return nil, err
+	}
+	administrators := config.Administrators
+	userUtils := v1_2.NewUserUtils(user, ristrettoUser, administrators)
+	quota := gorm2.NewQuota(db)
+	userMaxBytes := config.UserMaxBytes
+	userMaxFiles := config.UserMaxFiles
+	globalMaxBytes := config.GlobalMaxBytes
+	globalMaxFiles := config.GlobalMaxFiles
+	quotaUtils := v1_2.NewQuotaUtils(quota, gorm2File, userMaxBytes, userMaxFiles, globalMaxBytes, globalMaxFiles)
+	uploadMaxSize := config.UploadMaxSize
+	uploadMaxSizes := config.UploadMaxSizes
+	uploadAllowedMimeTypes := config.UploadAllowedMimeTypes
+	uploadDeniedMimeTypes := config.UploadDeniedMimeTypes
+	uploadRejectExecutables := config.UploadRejectExecutables
+	stripImageMetadata := config.StripImageMetadata
+	uploadPolicy, err := v1_2.NewUploadPolicy(uploadMaxSize, uploadMaxSizes, uploadAllowedMimeTypes, uploadDeniedMimeTypes, uploadRejectExecutables, stripImageMetadata)
+	if err != nil {
+		return nil, err
+	}
+	v1File := v1_2.NewFile(db, gorm2File, rendition, resource, group, file, scannerFile, userUtils, quotaUtils, uploadPolicy)
+	v1Resource := v1_2.NewResource(db, gorm2File, resource, group, userUtils)
+	administrator := gorm2.NewAdministrator(db)
+	v1Group := v1_2.NewGroup(db, resource, group, administrator, userUtils, file)
+	importer := v1_2.NewImporter(db, file, v1File, v1Resource, v1Group)
+	return importer, nil
+}
+
 // wire.go:
 
 type Config struct {
-	IsProduction      common.IsProduction
-	SessionKey        common.SessionKey
-	SessionSecret     common.SessionSecret
-	TraQBaseURL       common.TraQBaseURL
-	OAuthClientID     common.ClientID
-	SwiftAuthURL      common.SwiftAuthURL
-	SwiftUserName     common.SwiftUserName
-	SwiftPassword     common.SwiftPassword
-	SwiftTenantID     common.SwiftTenantID
-	SwiftTenantName   common.SwiftTenantName
-	SwiftContainer    common.SwiftContainer
-	FilePath          common.FilePath
-	AccessToken       common.AccessToken
-	VerificationToken common.VerificationToken
-	DefaultChannels   common.DefaultChannels
-	UpdatedAt         common.UpdatedAt
-	HttpClient        *http.Client
+	IsProduction            common.IsProduction
+	SessionKey              common.SessionKey
+	SessionSecret           common.SessionSecret
+	TraQBaseURL             common.TraQBaseURL
+	OAuthClientID           common.ClientID
+	SwiftAuthURL            common.SwiftAuthURL
+	SwiftUserName           common.SwiftUserName
+	SwiftPassword           common.SwiftPassword
+	SwiftTenantID           common.SwiftTenantID
+	SwiftTenantName         common.SwiftTenantName
+	SwiftContainer          common.SwiftContainer
+	StorageType             common.StorageType
+	S3Endpoint              common.S3Endpoint
+	S3Region                common.S3Region
+	S3Bucket                common.S3Bucket
+	S3AccessKeyID           common.S3AccessKeyID
+	S3SecretAccessKey       common.S3SecretAccessKey
+	S3Prefix                common.S3Prefix
+	FilePath                common.FilePath
+	AccessToken             common.AccessToken
+	VerificationToken       common.VerificationToken
+	DefaultChannels         common.DefaultChannels
+	Administrators          common.Administrators
+	UserMaxBytes            common.UserMaxBytes
+	UserMaxFiles            common.UserMaxFiles
+	GlobalMaxBytes          common.GlobalMaxBytes
+	GlobalMaxFiles          common.GlobalMaxFiles
+	UploadMaxSize           common.UploadMaxSize
+	UploadMaxSizes          common.UploadMaxSizes
+	ImportMaxBodySize       common.ImportMaxBodySize
+	UploadAllowedMimeTypes  common.UploadAllowedMimeTypes
+	UploadDeniedMimeTypes   common.UploadDeniedMimeTypes
+	UploadRejectExecutables common.UploadRejectExecutables
+	StripImageMetadata      common.StripImageMetadata
+	ShareLinkSecret         common.ShareLinkSecret
+	StorageEncryptionKeys   common.StorageEncryptionKeys
+	StorageCacheMaxBytes    common.StorageCacheMaxBytes
+	ScannerType             common.ScannerType
+	ClamdAddress            common.ClamdAddress
+	UpdatedAt               common.UpdatedAt
+	HttpClient              *http.Client
 }
 
 type Storage struct {
 	File storage.File
+	// Cache 読み込みのキャッシュが無効の場合はnil
+	Cache *readcache.File
 }
 
 func newStorage(file storage.File) *Storage {
@@ -149,62 +303,171 @@ func newStorage(file storage.File) *Storage {
 }
 
 var (
-	isProductionField      = wire.FieldsOf(new(*Config), "IsProduction")
-	sessionKeyField        = wire.FieldsOf(new(*Config), "SessionKey")
-	sessionSecretField     = wire.FieldsOf(new(*Config), "SessionSecret")
-	traQBaseURLField       = wire.FieldsOf(new(*Config), "TraQBaseURL")
-	oAuthClientIDField     = wire.FieldsOf(new(*Config), "OAuthClientID")
-	swiftAuthURLField      = wire.FieldsOf(new(*Config), "SwiftAuthURL")
-	swiftUserNameField     = wire.FieldsOf(new(*Config), "SwiftUserName")
-	swiftPasswordField     = wire.FieldsOf(new(*Config), "SwiftPassword")
-	swiftTenantIDField     = wire.FieldsOf(new(*Config), "SwiftTenantID")
-	swiftTenantNameField   = wire.FieldsOf(new(*Config), "SwiftTenantName")
-	swiftContainerField    = wire.FieldsOf(new(*Config), "SwiftContainer")
-	filePathField          = wire.FieldsOf(new(*Config), "FilePath")
-	accessTokenField       = wire.FieldsOf(new(*Config), "AccessToken")
-	verificationTokenField = wire.FieldsOf(new(*Config), "VerificationToken")
-	defaultChannelsField   = wire.FieldsOf(new(*Config), "DefaultChannels")
-	updatedAtField         = wire.FieldsOf(new(*Config), "UpdatedAt")
-	httpClientField        = wire.FieldsOf(new(*Config), "HttpClient")
+	isProductionField            = wire.FieldsOf(new(*Config), "IsProduction")
+	sessionKeyField              = wire.FieldsOf(new(*Config), "SessionKey")
+	sessionSecretField           = wire.FieldsOf(new(*Config), "SessionSecret")
+	traQBaseURLField             = wire.FieldsOf(new(*Config), "TraQBaseURL")
+	oAuthClientIDField           = wire.FieldsOf(new(*Config), "OAuthClientID")
+	swiftAuthURLField            = wire.FieldsOf(new(*Config), "SwiftAuthURL")
+	swiftUserNameField           = wire.FieldsOf(new(*Config), "SwiftUserName")
+	swiftPasswordField           = wire.FieldsOf(new(*Config), "SwiftPassword")
+	swiftTenantIDField           = wire.FieldsOf(new(*Config), "SwiftTenantID")
+	swiftTenantNameField         = wire.FieldsOf(new(*Config), "SwiftTenantName")
+	swiftContainerField          = wire.FieldsOf(new(*Config), "SwiftContainer")
+	s3EndpointField              = wire.FieldsOf(new(*Config), "S3Endpoint")
+	s3RegionField                = wire.FieldsOf(new(*Config), "S3Region")
+	s3BucketField                = wire.FieldsOf(new(*Config), "S3Bucket")
+	s3AccessKeyIDField           = wire.FieldsOf(new(*Config), "S3AccessKeyID")
+	s3SecretAccessKeyField       = wire.FieldsOf(new(*Config), "S3SecretAccessKey")
+	s3PrefixField                = wire.FieldsOf(new(*Config), "S3Prefix")
+	filePathField                = wire.FieldsOf(new(*Config), "FilePath")
+	accessTokenField             = wire.FieldsOf(new(*Config), "AccessToken")
+	verificationTokenField       = wire.FieldsOf(new(*Config), "VerificationToken")
+	defaultChannelsField         = wire.FieldsOf(new(*Config), "DefaultChannels")
+	administratorsField          = wire.FieldsOf(new(*Config), "Administrators")
+	userMaxBytesField            = wire.FieldsOf(new(*Config), "UserMaxBytes")
+	userMaxFilesField            = wire.FieldsOf(new(*Config), "UserMaxFiles")
+	globalMaxBytesField          = wire.FieldsOf(new(*Config), "GlobalMaxBytes")
+	globalMaxFilesField          = wire.FieldsOf(new(*Config), "GlobalMaxFiles")
+	uploadMaxSizeField           = wire.FieldsOf(new(*Config), "UploadMaxSize")
+	uploadMaxSizesField          = wire.FieldsOf(new(*Config), "UploadMaxSizes")
+	importMaxBodySizeField       = wire.FieldsOf(new(*Config), "ImportMaxBodySize")
+	uploadAllowedMimeTypesField  = wire.FieldsOf(new(*Config), "UploadAllowedMimeTypes")
+	uploadDeniedMimeTypesField   = wire.FieldsOf(new(*Config), "UploadDeniedMimeTypes")
+	uploadRejectExecutablesField = wire.FieldsOf(new(*Config), "UploadRejectExecutables")
+	stripImageMetadataField      = wire.FieldsOf(new(*Config), "StripImageMetadata")
+	shareLinkSecretField         = wire.FieldsOf(new(*Config), "ShareLinkSecret")
+	updatedAtField               = wire.FieldsOf(new(*Config), "UpdatedAt")
+	httpClientField              = wire.FieldsOf(new(*Config), "HttpClient")
 )
 
 func injectedStorage(config *Config) (*Storage, error) {
-	if config.IsProduction {
+	switch config.StorageType {
+	case common.StorageTypeSwift:
 		return injectSwiftStorage(config)
+	case common.StorageTypeS3:
+		return injectS3Storage(config)
+	case common.StorageTypeLocal:
+		return injectLocalStorage(config)
+	default:
+		return nil, fmt.Errorf("invalid storage type: %s", config.StorageType)
 	}
+}
+
+// injectedFileStorage キャッシュの上限が設定されている場合は読み込んだ内容をキャッシュし、
+// 暗号化の鍵が設定されている場合は保存する内容を暗号化する。
+// キャッシュには暗号化された内容を保存するよう、暗号化はキャッシュの外側で行う。
+// ストレージ間の移行や整合性の確認では、暗号化された内容のまま扱うためinjectedStorageを使う。
+func injectedFileStorage(config *Config) (*Storage, error) {
+	s, err := injectedStorage(config)
+	if err != nil {
+		return nil, err
+	}
+
+	if config.StorageCacheMaxBytes > 0 {
+		s.Cache, err = readcache.NewFile(
+			s.File, common.FilePath(path.Join(string(config.FilePath), "cache")), config.StorageCacheMaxBytes,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create read cache: %w", err)
+		}
 
-	return injectLocalStorage(config)
+		s.File = s.Cache
+	}
+
+	if len(config.StorageEncryptionKeys) == 0 {
+		return s, nil
+	}
+
+	s.File, err = newEncryptedFile(s.File, config.StorageEncryptionKeys)
+	if err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+func newEncryptedFile(file storage.File, keys common.StorageEncryptionKeys) (*encryption.File, error) {
+	keyring, err := encryption.NewKeyring(keys)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create keyring: %w", err)
+	}
+
+	return encryption.NewFile(file, keyring), nil
+}
+
+// injectedScanner 未指定の場合は検査しない
+func injectedScanner(config *Config) (scanner.File, error) {
+	switch config.ScannerType {
+	case common.ScannerTypeNone:
+		return noop.NewFile(), nil
+	case common.ScannerTypeClamd:
+		file, err := clamd.NewFile(config.ClamdAddress)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create clamd scanner: %w", err)
+		}
+
+		return file, nil
+	default:
+		return nil, fmt.Errorf("invalid scanner type: %s", config.ScannerType)
+	}
 }
 
 var (
 	dbBind                      = wire.Bind(new(repository.DB), new(*gorm2.DB))
 	fileRepositoryBind          = wire.Bind(new(repository.File), new(*gorm2.File))
+	renditionRepositoryBind     = wire.Bind(new(repository.Rendition), new(*gorm2.Rendition))
 	resourceRepositoryBind      = wire.Bind(new(repository.Resource), new(*gorm2.Resource))
 	groupRepositoryBind         = wire.Bind(new(repository.Group), new(*gorm2.Group))
 	administratorRepositoryBind = wire.Bind(new(repository.Administrator), new(*gorm2.Administrator))
+	quotaRepositoryBind         = wire.Bind(new(repository.Quota), new(*gorm2.Quota))
+	uploadRepositoryBind        = wire.Bind(new(repository.Upload), new(*gorm2.Upload))
+	shareLinkRepositoryBind     = wire.Bind(new(repository.ShareLink), new(*gorm2.ShareLink))
 
 	oidcAuthBind = wire.Bind(new(auth.OIDC), new(*traq.OIDC))
 	userAuthBind = wire.Bind(new(auth.User), new(*traq.User))
 
 	userCacheBind = wire.Bind(new(cache.User), new(*ristretto.User))
 
-	oidcServiceBind     = wire.Bind(new(service.OIDC), new(*v1_2.OIDC))
-	userServiceBind     = wire.Bind(new(service.User), new(*v1_2.User))
-	fileServiceBind     = wire.Bind(new(service.File), new(*v1_2.File))
-	resourceServiceBind = wire.Bind(new(service.Resource), new(*v1_2.Resource))
-	groupServiceBind    = wire.Bind(new(service.Group), new(*v1_2.Group))
+	oidcServiceBind      = wire.Bind(new(service.OIDC), new(*v1_2.OIDC))
+	userServiceBind      = wire.Bind(new(service.User), new(*v1_2.User))
+	fileServiceBind      = wire.Bind(new(service.File), new(*v1_2.File))
+	resourceServiceBind  = wire.Bind(new(service.Resource), new(*v1_2.Resource))
+	groupServiceBind     = wire.Bind(new(service.Group), new(*v1_2.Group))
+	quotaServiceBind     = wire.Bind(new(service.Quota), new(*v1_2.Quota))
+	scrubberServiceBind  = wire.Bind(new(service.Scrubber), new(*v1_2.Scrubber))
+	gcServiceBind        = wire.Bind(new(service.GarbageCollector), new(*v1_2.GarbageCollector))
+	uploadServiceBind    = wire.Bind(new(service.Upload), new(*v1_2.Upload))
+	shareLinkServiceBind = wire.Bind(new(service.ShareLink), new(*v1_2.ShareLink))
+	importerServiceBind  = wire.Bind(new(service.Importer), new(*v1_2.Importer))
+
+	// 再開可能なアップロードの途中の内容は、ストレージの種類に関わらずローカルに保持する
+	uploadStorageBind = wire.Bind(new(storage.Upload), new(*local.Upload))
 
-	fileField = wire.FieldsOf(new(*Storage), "File")
+	fileField  = wire.FieldsOf(new(*Storage), "File")
+	cacheField = wire.FieldsOf(new(*Storage), "Cache")
 )
 
 type Service struct {
 	*v1.API
 	*bot.Bot
+	Scrubber         service.Scrubber
+	GarbageCollector service.GarbageCollector
+	ReadCache        *readcache.File
 }
 
-func NewService(api *v1.API, b *bot.Bot) *Service {
+func NewService(
+	api *v1.API,
+	b *bot.Bot,
+	scrubber service.Scrubber,
+	garbageCollector service.GarbageCollector,
+	readCache *readcache.File,
+) *Service {
 	return &Service{
-		API: api,
-		Bot: b,
+		API:              api,
+		Bot:              b,
+		Scrubber:         scrubber,
+		GarbageCollector: garbageCollector,
+		ReadCache:        readCache,
 	}
 }